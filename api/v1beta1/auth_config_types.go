@@ -28,33 +28,58 @@ const (
 	IdentityOidc                     = "IDENTITY_OIDC"
 	IdentityApiKey                   = "IDENTITY_APIKEY"
 	IdentityMTLS                     = "IDENTITY_MTLS"
+	IdentityHMAC                     = "IDENTITY_HMAC"
+	IdentityAWSSigV4                 = "IDENTITY_AWS_SIGV4"
+	IdentityJWT                      = "IDENTITY_JWT"
+	IdentitySPIFFE                   = "IDENTITY_SPIFFE"
+	IdentitySAML                     = "IDENTITY_SAML"
 	IdentityKubernetesAuth           = "IDENTITY_KUBERNETESAUTH"
 	IdentityAnonymous                = "IDENTITY_ANONYMOUS"
 	IdentityPlain                    = "IDENTITY_PLAIN"
+	IdentityCustom                   = "IDENTITY_CUSTOM"
+	IdentityExternal                 = "IDENTITY_EXTERNAL"
 	MetadataUma                      = "METADATA_UMA"
 	MetadataGenericHTTP              = "METADATA_GENERIC_HTTP"
 	MetadataUserinfo                 = "METADATA_USERINFO"
+	MetadataCustom                   = "METADATA_CUSTOM"
+	MetadataExternal                 = "METADATA_EXTERNAL"
+	MetadataSQL                      = "METADATA_SQL"
+	MetadataGRPC                     = "METADATA_GRPC"
 	AuthorizationOPA                 = "AUTHORIZATION_OPA"
 	AuthorizationJSONPatternMatching = "AUTHORIZATION_JSON"
 	AuthorizationKubernetesAuthz     = "AUTHORIZATION_KUBERNETESAUTHZ"
 	AuthorizationAuthzed             = "AUTHORIZATION_AUTHZED"
+	AuthorizationUMA                 = "AUTHORIZATION_UMA"
+	AuthorizationGenericHTTP         = "AUTHORIZATION_GENERIC_HTTP"
+	AuthorizationCustom              = "AUTHORIZATION_CUSTOM"
+	AuthorizationExternal            = "AUTHORIZATION_EXTERNAL"
+	AuthorizationCEL                 = "AUTHORIZATION_CEL"
 	ResponseWristband                = "RESPONSE_WRISTBAND"
 	ResponseDynamicJSON              = "RESPONSE_DYNAMIC_JSON"
+	ResponseRateLimitDescriptors     = "RESPONSE_RATE_LIMIT_DESCRIPTORS"
 	CallbackHTTP                     = "CALLBACK_HTTP"
 	EvaluatorDefaultCacheTTL         = 60
+	RequestBodyDefaultMaxSize        = 8192
 
 	// Status conditions
 	StatusConditionAvailable ConditionType = "Available"
 	StatusConditionReady     ConditionType = "Ready"
 
 	// Status reasons
-	StatusReasonReconciling     string = "Reconciling"
-	StatusReasonReconciled      string = "Reconciled"
-	StatusReasonInvalidResource string = "Invalid"
-	StatusReasonHostsLinked     string = "HostsLinked"
-	StatusReasonHostsNotLinked  string = "HostsNotLinked"
-	StatusReasonCachingError    string = "CachingError"
-	StatusReasonUnknown         string = "Unknown"
+	StatusReasonReconciling             string = "Reconciling"
+	StatusReasonReconciled              string = "Reconciled"
+	StatusReasonInvalidResource         string = "Invalid"
+	StatusReasonHostsLinked             string = "HostsLinked"
+	StatusReasonHostsNotLinked          string = "HostsNotLinked"
+	StatusReasonHostTaken               string = "HostTaken"
+	StatusReasonCachingError            string = "CachingError"
+	StatusReasonDiscoveryFailed         string = "DiscoveryFailed"
+	StatusReasonPolicyCompilationFailed string = "PolicyCompilationFailed"
+	StatusReasonSecretNotFound          string = "SecretNotFound"
+	StatusReasonUnknown                 string = "Unknown"
+
+	// Annotation of the base AuthConfigs, whose defaults and overrides are merged into the other AuthConfigs of the namespace
+	BaseAuthConfigAnnotation = "authorino.kuadrant.io/base"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -112,26 +137,35 @@ type AuthConfigSpec struct {
 
 	// The list of public host names of the services protected by this authentication/authorization scheme.
 	// Authorino uses the requested host to lookup for the corresponding authentication/authorization configs to enforce.
-	Hosts []string `json:"hosts"`
+	// The hostnames resolved from the `targetRef` are protected in addition to the ones listed here.
+	Hosts []string `json:"hosts,omitempty"`
+
+	// Reference to a Gateway API HTTPRoute or Gateway whose hostnames are protected by this authentication/authorization scheme.
+	// The hostnames are resolved at reconcile time and re-resolved whenever the target resource changes.
+	TargetRef *TargetRef `json:"targetRef,omitempty"`
 
 	// Named sets of JSON patterns that can be referred in `when` conditionals and in JSON-pattern matching policy rules.
 	Patterns map[string]JSONPatternExpressions `json:"patterns,omitempty"`
 
 	// Conditions for the AuthConfig to be enforced.
 	// If omitted, the AuthConfig will be enforced for all requests.
-	// If present, all conditions must match for the AuthConfig to be enforced; otherwise, Authorino skips the AuthConfig and returns immediately with status OK.
+	// If present, all conditions must match for the AuthConfig to be enforced; otherwise, Authorino skips the AuthConfig and returns immediately with status OK,
+	// unless `denyWith.unmatched` is set, in which case the request is denied without evaluating any further.
 	Conditions []JSONPattern `json:"when,omitempty"`
 
 	// List of identity sources/authentication modes.
 	// At least one config of this list MUST evaluate to a valid identity for a request to be successful in the identity verification phase.
+	// +kubebuilder:validation:MaxItems:=100
 	Identity []*Identity `json:"identity,omitempty"`
 
 	// List of metadata source configs.
 	// Authorino fetches JSON content from sources on this list on every request.
+	// +kubebuilder:validation:MaxItems:=100
 	Metadata []*Metadata `json:"metadata,omitempty"`
 
 	// Authorization is the list of authorization policies.
 	// All policies in this list MUST evaluate to "true" for a request be successful in the authorization phase.
+	// +kubebuilder:validation:MaxItems:=100
 	Authorization []*Authorization `json:"authorization,omitempty"`
 
 	// List of response configs.
@@ -144,11 +178,124 @@ type AuthConfigSpec struct {
 
 	// Custom denial response codes, statuses and headers to override default 40x's.
 	DenyWith *DenyWith `json:"denyWith,omitempty"`
+
+	// Parsing of the body of the request forwarded by Envoy into the authorization JSON (`context.request.http.body`).
+	// If omitted, the body is kept as the raw string sent by Envoy.
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+
+	// Names of HTTP headers to remove from the request sent upstream, when the request is authorized, e.g. the headers that carry the credentials consumed by Authorino.
+	// Pseudo-headers and the `Host` header cannot be removed.
+	// +kubebuilder:validation:MaxItems:=100
+	HeadersToRemove []string `json:"headersToRemove,omitempty"`
+
+	// Enforcement mode of the AuthConfig.
+	// In `dryRun` mode, the auth pipeline is evaluated fully and the decision is logged and reported in the metrics, but requests are always allowed.
+	// +kubebuilder:validation:Enum:=enforce;dryRun
+	// +kubebuilder:default:=enforce
+	EnforcementMode EnforcementMode `json:"enforcementMode,omitempty"`
+
+	// Per-host overrides of the config, for AuthConfigs that list multiple hosts.
+	// Keys are hosts listed in `hosts`; the rest of the config is shared by all the hosts.
+	// +kubebuilder:validation:MaxProperties:=100
+	HostOverrides map[string]HostOverride `json:"hostOverrides,omitempty"`
+
+	// Configs merged into the other AuthConfigs of the namespace, if the AuthConfig is annotated with `authorino.kuadrant.io/base: "true"`.
+	// Configs of the other AuthConfigs with the same name prevail over the defaults.
+	Defaults *SharedSpec `json:"defaults,omitempty"`
+
+	// Configs merged into the other AuthConfigs of the namespace, if the AuthConfig is annotated with `authorino.kuadrant.io/base: "true"`.
+	// Overrides prevail over the configs of the other AuthConfigs with the same name, including the ones of their host overrides.
+	Overrides *SharedSpec `json:"overrides,omitempty"`
+}
+
+const (
+	TargetRefKindHTTPRoute = "HTTPRoute"
+	TargetRefKindGateway   = "Gateway"
+)
+
+// Reference to a Gateway API resource in the namespace of the AuthConfig.
+// The hostnames of an HTTPRoute are the ones listed in its spec or, if none, the ones of the listeners of its parent Gateways.
+// The hostnames of a Gateway are the ones of its listeners.
+type TargetRef struct {
+	// API group of the target resource.
+	// +kubebuilder:default:=gateway.networking.k8s.io
+	// +kubebuilder:validation:Enum:=gateway.networking.k8s.io
+	Group string `json:"group,omitempty"`
+
+	// Kind of the target resource.
+	// +kubebuilder:validation:Enum:=HTTPRoute;Gateway
+	Kind string `json:"kind"`
+
+	// Name of the target resource.
+	// +kubebuilder:validation:MaxLength:=253
+	Name string `json:"name"`
+}
+
+// Overrides of the config for a specific host.
+// Identity, metadata, authorization and response configs replace the ones with the same name in the spec of the AuthConfig; configs with new names are added.
+type HostOverride struct {
+	// +kubebuilder:validation:MaxItems:=100
+	Identity []*Identity `json:"identity,omitempty"`
+	// +kubebuilder:validation:MaxItems:=100
+	Metadata []*Metadata `json:"metadata,omitempty"`
+	// +kubebuilder:validation:MaxItems:=100
+	Authorization []*Authorization `json:"authorization,omitempty"`
+	Response      []*Response      `json:"response,omitempty"`
+
+	// Custom denial responses for the host. Replaces the denyWith settings of the AuthConfig altogether.
+	DenyWith *DenyWith `json:"denyWith,omitempty"`
+}
+
+// Configs shared with other AuthConfigs, merged into their specs by name.
+type SharedSpec struct {
+	// Named sets of JSON patterns that can be referred in `when` conditionals and in JSON-pattern matching policy rules.
+	Patterns map[string]JSONPatternExpressions `json:"patterns,omitempty"`
+
+	// List of identity sources/authentication modes inherited by the AuthConfigs.
+	// +kubebuilder:validation:MaxItems:=100
+	Identity []*Identity `json:"identity,omitempty"`
+
+	// List of metadata source configs inherited by the AuthConfigs.
+	// +kubebuilder:validation:MaxItems:=100
+	Metadata []*Metadata `json:"metadata,omitempty"`
+
+	// List of authorization policies inherited by the AuthConfigs.
+	// +kubebuilder:validation:MaxItems:=100
+	Authorization []*Authorization `json:"authorization,omitempty"`
+
+	// List of response configs inherited by the AuthConfigs.
+	Response []*Response `json:"response,omitempty"`
+
+	// List of callback configs inherited by the AuthConfigs.
+	Callbacks []*Callback `json:"callbacks,omitempty"`
+
+	// Custom denial responses inherited by the AuthConfigs.
+	// Each kind of denial (unauthenticated, unauthorized, unmatched) is merged separately.
+	DenyWith *DenyWith `json:"denyWith,omitempty"`
+}
+
+type EnforcementMode string
+
+const (
+	EnforcementModeEnforce EnforcementMode = "enforce"
+	EnforcementModeDryRun  EnforcementMode = "dryRun"
+)
+
+// Parsing options of the body of the request.
+// JSON and form-urlencoded bodies are parsed into objects; bodies of other content types and bodies exceeding the maximum size are kept as raw strings.
+type RequestBody struct {
+	// Maximum size of the body to parse, in bytes.
+	// +kubebuilder:default:=8192
+	MaxSize int `json:"maxSize,omitempty"`
 }
 
 type JSONPattern struct {
 	JSONPatternRef        `json:",omitempty"`
 	JSONPatternExpression `json:",omitempty"`
+
+	// List of alternative expressions, at least one of which must match for the pattern to match.
+	// Patterns of a list are combined with AND; alternative expressions within a pattern are combined with OR.
+	Any []JSONPatternExpression `json:"any,omitempty"`
 }
 
 type JSONPatternRef struct {
@@ -163,14 +310,16 @@ type JSONPatternExpression struct {
 	// The value is used to fetch content from the input authorization JSON built by Authorino along the identity and metadata phases.
 	Selector string `json:"selector,omitempty"`
 	// The binary operator to be applied to the content fetched from the authorization JSON, for comparison with "value".
-	// Possible values are: "eq" (equal to), "neq" (not equal to), "incl" (includes; for arrays), "excl" (excludes; for arrays), "matches" (regex)
+	// Possible values are: "eq" (equal to), "neq" (not equal to), "incl" (includes; for arrays), "excl" (excludes; for arrays), "matches" (regex), "withinSchedule" (recurring time window)
 	Operator JSONPatternOperator `json:"operator,omitempty"`
 	// The value of reference for the comparison with the content fetched from the authorization JSON.
 	// If used with the "matches" operator, the value must compile to a valid Golang regex.
+	// If used with the "withinSchedule" operator, the value must be a cron-like expression of a recurring time window, optionally prefixed with a time zone (e.g. "TZ=Europe/Berlin * 9-17 * * MON-FRI").
+	// The selector of a "withinSchedule" expression is optional and must resolve to an RFC 3339 string, a Unix timestamp, or a protobuf timestamp object (e.g. "context.request.time"); it defaults to the current time.
 	Value string `json:"value,omitempty"`
 }
 
-// +kubebuilder:validation:Enum:=eq;neq;incl;excl;matches
+// +kubebuilder:validation:Enum:=eq;neq;incl;excl;matches;withinSchedule
 type JSONPatternOperator string
 
 // +kubebuilder:validation:Enum:=authorization_header;custom_header;query;cookie
@@ -188,10 +337,13 @@ type Credentials struct {
 
 // The identity source/authentication mode config.
 // Apart from "name", one of the following parameters is required and only one of the following parameters is allowed: "oicd", "apiKey" or "kubernetes".
+// +kubebuilder:validation:XValidation:rule="[has(self.oauth2), has(self.oidc), has(self.apiKey), has(self.mtls), has(self.hmac), has(self.awsSigV4), has(self.jwt), has(self.spiffe), has(self.saml), has(self.kubernetes), has(self.anonymous), has(self.plain), has(self.custom), has(self.external)].exists_one(x, x)",message="exactly one of oauth2, oidc, apiKey, mtls, hmac, awsSigV4, jwt, spiffe, saml, kubernetes, anonymous, plain, custom or external must be set"
 type Identity struct {
 	// The name of this identity source/authentication mode.
 	// It usually identifies a source of identities or group of users/clients of the protected service.
 	// It can be used to refer to the resolved identity object in other configs.
+	// +kubebuilder:validation:MaxLength:=253
+	// +kubebuilder:validation:Pattern:=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
 	Name string `json:"name"`
 
 	// Priority group of the config.
@@ -220,13 +372,37 @@ type Identity struct {
 	// It requires the resolved identity object to always be of the JSON type 'object'. Other JSON types (array, string, etc) will break.
 	ExtendedProperties []JsonProperty `json:"extendedProperties,omitempty"`
 
+	// Projects claims of the resolved identity object into a normalized shape (i.e. "username", "groups", "email" and "tenant" properties of the identity object),
+	// so authorization policies can be written regardless of the identity source that authenticated the request.
+	// It requires the resolved identity object to always be of the JSON type 'object'. Mapped claims not found in the identity object are omitted.
+	ClaimMappings *ClaimMappings `json:"claimMappings,omitempty"`
+
+	// Deny-list of revoked identities, consulted after the identity is verified.
+	// Requests whose resolved identity object is revoked are rejected, even before the natural expiry of the credentials.
+	Revocation *Revocation `json:"revocation,omitempty"`
+
+	// Protection against the replay of single-use credentials.
+	// Requests whose resolved identity object holds a one-time value (e.g. the "jti" claim of a token) already seen before are rejected.
+	ReplayProtection *ReplayProtection `json:"replayProtection,omitempty"`
+
+	// Challenge returned in the WWW-Authenticate header of the response when the request cannot be authenticated.
+	// If omitted, the challenge is inferred from the credentials of the identity config (e.g. `Bearer realm="<name>"`).
+	Challenge *Challenge `json:"challenge,omitempty"`
+
 	OAuth2         *Identity_OAuth2Config   `json:"oauth2,omitempty"`
 	Oidc           *Identity_OidcConfig     `json:"oidc,omitempty"`
 	APIKey         *Identity_APIKey         `json:"apiKey,omitempty"`
 	MTLS           *Identity_MTLS           `json:"mtls,omitempty"`
+	HMAC           *Identity_HMAC           `json:"hmac,omitempty"`
+	AWSSigV4       *Identity_AWSSigV4       `json:"awsSigV4,omitempty"`
+	JWT            *Identity_JWT            `json:"jwt,omitempty"`
+	SPIFFE         *Identity_SPIFFE         `json:"spiffe,omitempty"`
+	SAML           *Identity_SAML           `json:"saml,omitempty"`
 	KubernetesAuth *Identity_KubernetesAuth `json:"kubernetes,omitempty"`
 	Anonymous      *Identity_Anonymous      `json:"anonymous,omitempty"`
 	Plain          *Identity_Plain          `json:"plain,omitempty"`
+	Custom         *CustomEvaluator         `json:"custom,omitempty"`
+	External       *ExternalEvaluator       `json:"external,omitempty"`
 }
 
 func (i *Identity) GetType() string {
@@ -238,19 +414,83 @@ func (i *Identity) GetType() string {
 		return IdentityApiKey
 	} else if i.MTLS != nil {
 		return IdentityMTLS
+	} else if i.HMAC != nil {
+		return IdentityHMAC
+	} else if i.AWSSigV4 != nil {
+		return IdentityAWSSigV4
+	} else if i.JWT != nil {
+		return IdentityJWT
+	} else if i.SPIFFE != nil {
+		return IdentitySPIFFE
+	} else if i.SAML != nil {
+		return IdentitySAML
 	} else if i.KubernetesAuth != nil {
 		return IdentityKubernetesAuth
 	} else if i.Anonymous != nil {
 		return IdentityAnonymous
 	} else if i.Plain != nil {
 		return IdentityPlain
+	} else if i.Custom != nil {
+		return IdentityCustom
+	} else if i.External != nil {
+		return IdentityExternal
 	} else {
 		return TypeUnknown
 	}
 }
 
+// Challenge customizes the WWW-Authenticate challenge of an identity config.
+type Challenge struct {
+	// Authentication scheme of the challenge (e.g. "Bearer", "Basic").
+	// Defaults to the prefix of the credentials passed in the Authorization header, or to "ApiKey" for credentials passed elsewhere.
+	Scheme string `json:"scheme,omitempty"`
+
+	// Realm of the challenge. Defaults to the name of the identity config.
+	Realm string `json:"realm,omitempty"`
+
+	// Omits the challenge of this identity config.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// ClaimMappings selects claims of the resolved identity object by JSON paths relative to the identity object (e.g. "preferred_username", "realm_access.roles").
+type ClaimMappings struct {
+	// Claim mapped to the "username" property of the identity object.
+	Username string `json:"username,omitempty"`
+	// Claim mapped to the "groups" property of the identity object. Single values are turned into a list.
+	Groups string `json:"groups,omitempty"`
+	// Claim mapped to the "email" property of the identity object.
+	Email string `json:"email,omitempty"`
+	// Claim mapped to the "tenant" property of the identity object.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Revocation lists the revoked identities in Kubernetes Secrets and/or an external revocation endpoint.
+type Revocation struct {
+	// Label selector used by Authorino to match Kubernetes Secrets, in the same namespace as the AuthConfig, that list revoked values.
+	// Each key of the Secrets is a path to a claim of the identity object (e.g. "jti", "sub"), and its value lists the revoked values of the claim, one per line.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	// Endpoint of an external revocation service.
+	// Authorino sends the identity object as the JSON body of a POST request to the endpoint, expecting a JSON response with a boolean "revoked" property.
+	// Identities are rejected if the endpoint cannot be reached.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// ReplayProtection rejects identity objects whose one-time value has been used before.
+type ReplayProtection struct {
+	// Path to the one-time value in the identity object.
+	// +kubebuilder:default:=jti
+	Selector string `json:"selector,omitempty"`
+
+	// How long the one-time values are remembered, in seconds.
+	// If omitted, values are remembered until the expiry of the identity object ("exp" claim), or for 5 minutes if the identity object does not expire.
+	TTL int `json:"ttl,omitempty"`
+}
+
 type Identity_OAuth2Config struct {
 	// The full URL of the token introspection endpoint.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
 	TokenIntrospectionUrl string `json:"tokenIntrospectionUrl"`
 	// The token type hint for the token introspection.
 	// If omitted, it defaults to "access_token".
@@ -264,9 +504,104 @@ type Identity_OidcConfig struct {
 	// Endpoint of the OIDC issuer.
 	// Authorino will append to this value the well-known path to the OpenID Connect discovery endpoint (i.e. "/.well-known/openid-configuration"), used to automatically discover the OpenID Connect configuration, whose set of claims is expected to include (among others) the "jkws_uri" claim.
 	// The value must coincide with the value of  the "iss" (issuer) claim of the discovered OpenID Connect configuration.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
 	Endpoint string `json:"endpoint"`
 	// Decides how long to wait before refreshing the OIDC configuration (in seconds).
 	TTL int `json:"ttl,omitempty"`
+	// List of audiences accepted in the "aud" claim of the token.
+	// If present, tokens whose "aud" claim does not include at least one of the values are rejected.
+	Audiences []string `json:"audiences,omitempty"`
+	// List of issuers accepted in the "iss" claim of the token.
+	// If present, tokens whose "iss" claim does not match one of the values are rejected.
+	AllowedIssuers []string `json:"allowedIssuers,omitempty"`
+	// Requires the access tokens to be sender-constrained with Demonstrating Proof-of-Possession (DPoP) proofs (RFC 9449).
+	// If present, requests must include a valid DPoP proof in the "DPoP" header, bound to the access token by the "cnf.jkt" claim.
+	DPoP *Identity_DPoP `json:"dpop,omitempty"`
+	// Verifies opaque (non-JWT) access tokens with the token introspection endpoint of the issuer (RFC 7662).
+	// If omitted, only JWTs are accepted.
+	Introspection *Identity_OidcIntrospection `json:"introspection,omitempty"`
+}
+
+type Identity_OidcIntrospection struct {
+	// The full URL of the token introspection endpoint.
+	// If omitted, it defaults to the "introspection_endpoint" claim of the discovered OpenID Connect configuration.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
+	TokenIntrospectionUrl string `json:"tokenIntrospectionUrl,omitempty"`
+	// The token type hint for the token introspection.
+	// If omitted, it defaults to "access_token".
+	TokenTypeHint string `json:"tokenTypeHint,omitempty"`
+	// Reference to a Kubernetes secret in the same namespace, that stores client credentials to the OAuth2 server.
+	Credentials *k8score.LocalObjectReference `json:"credentialsRef"`
+}
+
+type Identity_DPoP struct {
+	// Maximum age of the DPoP proofs (in seconds), based on their "iat" claim.
+	// Proofs are remembered by their "jti" claim for as long and rejected if replayed.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:default:=60
+	MaxAge int `json:"maxAge,omitempty"`
+}
+
+type Identity_JWT struct {
+	// Inline JSON Web Key Set (JWKS) used to verify the signature of the tokens.
+	JWKS string `json:"jwks,omitempty"`
+	// Reference to a key of a Secret or ConfigMap, in the same namespace as the AuthConfig, that stores the JSON Web Key Set (JWKS) used to verify the signature of the tokens.
+	// Changes to the referenced object are only picked up when the AuthConfig is reconciled again.
+	JWKSRef *JWKSReference `json:"jwksRef,omitempty"`
+	// List of audiences accepted in the "aud" claim of the token.
+	// If present, tokens whose "aud" claim does not include at least one of the values are rejected.
+	Audiences []string `json:"audiences,omitempty"`
+	// List of issuers accepted in the "iss" claim of the token.
+	// If present, tokens whose "iss" claim does not match one of the values are rejected.
+	AllowedIssuers []string `json:"allowedIssuers,omitempty"`
+}
+
+type Identity_SPIFFE struct {
+	// SPIFFE trust domain of the accepted SVIDs (e.g. "example.org").
+	TrustDomain string `json:"trustDomain"`
+	// Reference to a key of a Secret or ConfigMap, in the same namespace as the AuthConfig, that stores the SPIFFE trust bundle of the trust domain (in the JWKS-based SPIFFE bundle format).
+	// Changes to the referenced object are only picked up when the AuthConfig is reconciled again.
+	TrustBundleRef *JWKSReference `json:"trustBundleRef,omitempty"`
+	// URL of a SPIFFE bundle endpoint (e.g. of a SPIRE server) that serves the trust bundle of the trust domain.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
+	TrustBundleEndpoint string `json:"trustBundleEndpoint,omitempty"`
+	// Decides how long to wait before fetching the trust bundle again from the bundle endpoint (in seconds).
+	TTL int `json:"ttl,omitempty"`
+	// List of audiences accepted in the "aud" claim of JWT-SVIDs.
+	// If present, JWT-SVIDs whose "aud" claim does not include at least one of the values are rejected.
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+type Identity_SAML struct {
+	// Reference to a key of a Secret or ConfigMap, in the same namespace as the AuthConfig, that stores the SAML metadata of the trusted identity provider (IdP).
+	// Changes to the referenced object are only picked up when the AuthConfig is reconciled again.
+	IdPMetadataRef *JWKSReference `json:"idpMetadataRef,omitempty"`
+	// URL of the SAML metadata of the trusted identity provider (IdP).
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
+	IdPMetadataUrl string `json:"idpMetadataUrl,omitempty"`
+	// Decides how long to wait before fetching the metadata again from the metadata URL (in seconds).
+	TTL int `json:"ttl,omitempty"`
+	// List of audiences (e.g. the entity ID of the service provider) accepted in the audience restrictions of the assertions.
+	// Assertions without an audience restriction that includes at least one of the values are rejected.
+	// +kubebuilder:validation:MinItems:=1
+	Audiences []string `json:"audiences"`
+	// Maximum difference (in seconds) tolerated between the validity period of the assertions and the current time.
+	// +kubebuilder:default:=60
+	MaxClockSkew int `json:"maxClockSkew,omitempty"`
+}
+
+// JWKSReference selects a key of a Secret or ConfigMap that stores a JSON Web Key Set.
+type JWKSReference struct {
+	// Kind of the object that stores the JSON Web Key Set.
+	// +kubebuilder:validation:Enum:=Secret;ConfigMap
+	// +kubebuilder:default:=Secret
+	Kind string `json:"kind,omitempty"`
+
+	// The name of the Secret or ConfigMap.
+	Name string `json:"name"`
+
+	// The key of the Secret or ConfigMap to select from.
+	Key string `json:"key"`
 }
 
 type Identity_APIKey struct {
@@ -275,8 +610,22 @@ type Identity_APIKey struct {
 
 	// Whether Authorino should look for API key secrets in all namespaces or only in the same namespace as the AuthConfig.
 	// Enabling this option in namespaced Authorino instances has no effect.
+	// If Authorino is not allowed to list secrets cluster-wide, it falls back to looking for the API key secrets only in the namespace of the AuthConfig.
 	// +kubebuilder:default:=false
 	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// Namespace where Authorino should look for the API key secrets, instead of the namespace of the AuthConfig.
+	// The namespace must be watched by the Authorino instance. Ignored if `allNamespaces` is enabled.
+	// Reading API key secrets from another namespace must be allowed in the Authorino instance (--allow-cross-namespace-api-keys).
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Hashing algorithm of the API keys stored in the secrets, in which case the API keys supplied in the requests are hashed before compared.
+	// For "sha256", the secrets store the hex-encoded SHA-256 digest of the API key; for "bcrypt", the id of the API key and its bcrypt hash ("<key-id>:<hash>").
+	// With "bcrypt", the API keys must start with their id, followed by a dot (e.g. "<key-id>.<secret>"), so each key is compared with a single hash.
+	// Omit it for API keys stored in plain text.
+	// +kubebuilder:validation:Enum:=sha256;bcrypt
+	KeyHashing string `json:"keyHashing,omitempty"`
 }
 
 type Identity_MTLS struct {
@@ -289,6 +638,62 @@ type Identity_MTLS struct {
 	AllNamespaces bool `json:"allNamespaces,omitempty"`
 }
 
+type Identity_HMAC struct {
+	// Label selector used by Authorino to match secrets from the cluster storing the shared secrets (in the `hmac_secret` entry) used by the clients to sign the requests
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// Whether Authorino should look for HMAC secrets in all namespaces or only in the same namespace as the AuthConfig.
+	// Enabling this option in namespaced Authorino instances has no effect.
+	// +kubebuilder:default:=false
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// Hashing algorithm of the HMAC signature.
+	// +kubebuilder:validation:Enum:=sha256;sha512
+	// +kubebuilder:default:=sha256
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Name of the request header that holds the signature, hex- or base64-encoded and optionally prefixed with the name of the algorithm (e.g. "sha256=<signature>").
+	// +kubebuilder:default:=X-Signature
+	SignatureHeader string `json:"signatureHeader,omitempty"`
+
+	// Components of the request that are signed, in order. Each component is prefixed with its length in bytes and a colon, and followed by a new line (e.g. "4:POST\n").
+	// Supported components: "method", "authority", "path" (including the query string), "date" (value of the Date header), "body", "digest" (SHA-256 digest of the body, formatted as "SHA-256=<base64>") and "header:<name>" (value of a request header).
+	// The "date" component is required, so signatures cannot be replayed beyond the allowed clock skew.
+	// If omitted, defaults to "method", "authority", "path", "date", "body" and "digest".
+	SignedComponents []string `json:"signedComponents,omitempty"`
+
+	// Maximum difference (in seconds) between the time of the Date header of the request and the current time.
+	// +kubebuilder:default:=300
+	MaxClockSkew int `json:"maxClockSkew,omitempty"`
+}
+
+type Identity_AWSSigV4 struct {
+	// Label selector used by Authorino to match secrets from the cluster storing the AWS access key pairs (in the `aws_access_key_id` and `aws_secret_access_key` entries) used by the clients to sign the requests
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// Whether Authorino should look for access key secrets in all namespaces or only in the same namespace as the AuthConfig.
+	// Enabling this option in namespaced Authorino instances has no effect.
+	// +kubebuilder:default:=false
+	AllNamespaces bool `json:"allNamespaces,omitempty"`
+
+	// AWS region the requests must be signed for (e.g. "us-east-1").
+	// If omitted, requests signed for any region are accepted.
+	Region string `json:"region,omitempty"`
+
+	// AWS service name the requests must be signed for (e.g. "execute-api").
+	// If omitted, requests signed for any service are accepted.
+	Service string `json:"service,omitempty"`
+
+	// Maximum difference (in seconds) between the signing time of the request (X-Amz-Date header) and the current time.
+	// +kubebuilder:default:=300
+	MaxClockSkew int `json:"maxClockSkew,omitempty"`
+
+	// Whether Authorino should accept requests whose payload is not signed, i.e. with the X-Amz-Content-Sha256 header set to "UNSIGNED-PAYLOAD".
+	// Otherwise, the payload hash of the request must match the body of the request.
+	// +kubebuilder:default:=false
+	AllowUnsignedPayload bool `json:"allowUnsignedPayload,omitempty"`
+}
+
 type Identity_KubernetesAuth struct {
 	// The list of audiences (scopes) that must be claimed in a Kubernetes authentication token supplied in the request, and reviewed by Authorino.
 	// If omitted, Authorino will review tokens expecting the host name of the requested protected service amongst the audiences.
@@ -301,9 +706,12 @@ type Identity_Plain ValueFrom
 
 // The metadata config.
 // Apart from "name", one of the following parameters is required and only one of the following parameters is allowed: "http", userInfo" or "uma".
+// +kubebuilder:validation:XValidation:rule="[has(self.userInfo), has(self.uma), has(self.http), has(self.custom), has(self.external), has(self.sql), has(self.grpc)].exists_one(x, x)",message="exactly one of userInfo, uma, http, custom, external, sql or grpc must be set"
 type Metadata struct {
 	// The name of the metadata source.
 	// It can be used to refer to the resolved metadata object in other configs.
+	// +kubebuilder:validation:MaxLength:=253
+	// +kubebuilder:validation:Pattern:=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
 	Name string `json:"name"`
 
 	// Priority group of the config.
@@ -324,9 +732,18 @@ type Metadata struct {
 	// Omit it to avoid caching metadata from this source.
 	Cache *EvaluatorCaching `json:"cache,omitempty"`
 
+	// Whether the request must be denied if the metadata cannot be fetched.
+	// By default, metadata is fetched on a best-effort basis, i.e. failures are ignored and the metadata object is omitted from the authorization JSON.
+	// +kubebuilder:default:=false
+	Required bool `json:"required,omitempty"`
+
 	UserInfo    *Metadata_UserInfo    `json:"userInfo,omitempty"`
 	UMA         *Metadata_UMA         `json:"uma,omitempty"`
 	GenericHTTP *Metadata_GenericHTTP `json:"http,omitempty"`
+	Custom      *CustomEvaluator      `json:"custom,omitempty"`
+	External    *ExternalEvaluator    `json:"external,omitempty"`
+	SQL         *Metadata_SQL         `json:"sql,omitempty"`
+	GRPC        *Metadata_GRPC        `json:"grpc,omitempty"`
 }
 
 func (m *Metadata) GetType() string {
@@ -336,6 +753,14 @@ func (m *Metadata) GetType() string {
 		return MetadataUma
 	} else if m.GenericHTTP != nil {
 		return MetadataGenericHTTP
+	} else if m.Custom != nil {
+		return MetadataCustom
+	} else if m.External != nil {
+		return MetadataExternal
+	} else if m.SQL != nil {
+		return MetadataSQL
+	} else if m.GRPC != nil {
+		return MetadataGRPC
 	}
 	return TypeUnknown
 }
@@ -344,16 +769,41 @@ func (m *Metadata) GetType() string {
 type Metadata_UserInfo struct {
 	// The name of an OIDC identity source included in the "identity" section and whose OpenID Connect configuration discovered includes the OIDC "userinfo_endpoint" claim.
 	IdentitySource string `json:"identitySource"`
+
+	// Caches the UserInfo response for the given number of seconds, keyed by a hash of the access token.
+	// Omit or set to 0 to disable caching.
+	TTL int `json:"ttl,omitempty"`
 }
 
 // User-Managed Access (UMA) source of resource data.
 type Metadata_UMA struct {
 	// The endpoint of the UMA server.
 	// The value must coincide with the "issuer" claim of the UMA config discovered from the well-known uma configuration endpoint.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
 	Endpoint string `json:"endpoint"`
 
 	// Reference to a Kubernetes secret in the same namespace, that stores client credentials to the resource registration API of the UMA server.
 	Credentials *k8score.LocalObjectReference `json:"credentialsRef"`
+
+	// Filters of the query to the resource registration API of the UMA server.
+	// If omitted, resources are queried by the path of the request (uri).
+	Filter *Metadata_UMA_Filter `json:"filter,omitempty"`
+
+	// Maximum number of resources fetched per page from the resource registration API of the UMA server.
+	// Pages are followed until all matching resources are fetched.
+	// Omit or set to 0 to fetch all matching resources in a single request.
+	PageSize int `json:"pageSize,omitempty"`
+}
+
+type Metadata_UMA_Filter struct {
+	// URI of the resources. Defaults to the path of the request.
+	URI *StaticOrDynamicValue `json:"uri,omitempty"`
+
+	// Type of the resources.
+	Type *StaticOrDynamicValue `json:"type,omitempty"`
+
+	// Owner of the resources.
+	Owner *StaticOrDynamicValue `json:"owner,omitempty"`
 }
 
 // +kubebuilder:validation:Enum:=GET;POST
@@ -368,6 +818,7 @@ type Metadata_GenericHTTP struct {
 	// The endpoint accepts variable placeholders in the format "{selector}", where "selector" is any pattern supported
 	// by https://pkg.go.dev/github.com/tidwall/gjson and selects value from the authorization JSON.
 	// E.g. https://ext-auth-server.io/metadata?p={context.request.http.path}
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
 	Endpoint string `json:"endpoint"`
 
 	// HTTP verb used in the request to the service. Accepted values: GET (default), POST.
@@ -408,6 +859,7 @@ type Metadata_GenericHTTP struct {
 
 type OAuth2ClientAuthentication struct {
 	// Token endpoint URL of the OAuth2 resource server.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
 	TokenUrl string `json:"tokenUrl"`
 	// OAuth2 Client ID.
 	ClientId string `json:"clientId"`
@@ -424,10 +876,13 @@ type OAuth2ClientAuthentication struct {
 }
 
 // Authorization policy to be enforced.
-// Apart from "name", one of the following parameters is required and only one of the following parameters is allowed: "opa", "json" or "kubernetes".
+// Apart from "name", one of the following parameters is required and only one of the following parameters is allowed: "opa", "json", "kubernetes", "authzed", "uma", "http" or "cel".
+// +kubebuilder:validation:XValidation:rule="[has(self.opa), has(self.json), has(self.kubernetes), has(self.authzed), has(self.uma), has(self.http), has(self.custom), has(self.external), has(self.cel)].exists_one(x, x)",message="exactly one of opa, json, kubernetes, authzed, uma, http, custom, external or cel must be set"
 type Authorization struct {
 	// Name of the authorization policy.
 	// It can be used to refer to the resolved authorization object in other configs.
+	// +kubebuilder:validation:MaxLength:=253
+	// +kubebuilder:validation:Pattern:=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
 	Name string `json:"name"`
 
 	// Priority group of the config.
@@ -448,10 +903,19 @@ type Authorization struct {
 	// Omit it to avoid caching policy evaluation results for this config.
 	Cache *EvaluatorCaching `json:"cache,omitempty"`
 
+	// Whether to deny (default) or to allow the request when the policy cannot be evaluated due to a failure of an external service it depends on,
+	// e.g. when the service cannot be reached, times out or is unavailable. Denials by the policy itself are not affected.
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+
 	OPA             *Authorization_OPA                 `json:"opa,omitempty"`
 	JSON            *Authorization_JSONPatternMatching `json:"json,omitempty"`
 	KubernetesAuthz *Authorization_KubernetesAuthz     `json:"kubernetes,omitempty"`
 	Authzed         *Authorization_Authzed             `json:"authzed,omitempty"`
+	UMA             *Authorization_UMA                 `json:"uma,omitempty"`
+	GenericHTTP     *Authorization_GenericHTTP         `json:"http,omitempty"`
+	Custom          *CustomEvaluator                   `json:"custom,omitempty"`
+	External        *ExternalEvaluator                 `json:"external,omitempty"`
+	CEL             *Authorization_CEL                 `json:"cel,omitempty"`
 }
 
 func (a *Authorization) GetType() string {
@@ -463,15 +927,127 @@ func (a *Authorization) GetType() string {
 		return AuthorizationKubernetesAuthz
 	} else if a.Authzed != nil {
 		return AuthorizationAuthzed
+	} else if a.UMA != nil {
+		return AuthorizationUMA
+	} else if a.GenericHTTP != nil {
+		return AuthorizationGenericHTTP
+	} else if a.Custom != nil {
+		return AuthorizationCustom
+	} else if a.External != nil {
+		return AuthorizationExternal
+	} else if a.CEL != nil {
+		return AuthorizationCEL
 	}
 	return TypeUnknown
 }
 
+// CustomEvaluator refers to an evaluator compiled into the build of Authorino and registered under a name.
+// Custom evaluators are not available in the official builds of Authorino.
+type CustomEvaluator struct {
+	// Name under which the custom evaluator was registered in the build of Authorino.
+	Name string `json:"name"`
+
+	// Free-form settings passed as-is to the custom evaluator.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Settings runtime.RawExtension `json:"settings,omitempty"`
+}
+
+// Call to a unary method of a gRPC service, whose response message is exposed as the metadata object.
+// The service must support gRPC server reflection, for Authorino to transcode the request and response messages from and to JSON.
+type Metadata_GRPC struct {
+	// Endpoint of the gRPC service (host:port).
+	Endpoint string `json:"endpoint"`
+
+	// Full name of the method to call, in the format <package>.<service>/<method>.
+	// E.g. acme.entitlements.v1.Entitlements/GetPlan
+	Method string `json:"method"`
+
+	// Insecure connection to the service (i.e. without TLS)
+	Insecure bool `json:"insecure,omitempty"`
+
+	// JSON representation of the request message.
+	// Supersedes 'parameters'; use either one or the other.
+	// Use 'valueFrom.authJSON' with a template to build the message from values of the authorization JSON; curly braces of the JSON must then be escaped with backslashes.
+	Body *StaticOrDynamicValue `json:"body,omitempty"`
+
+	// Fields of the request message.
+	// Superseded by 'body'; use either one or the other.
+	Parameters []JsonProperty `json:"parameters,omitempty"`
+
+	// Custom gRPC metadata sent in the request.
+	Headers []JsonProperty `json:"headers,omitempty"`
+
+	// Reference to a Secret key whose value will be sent to the service as a bearer token, for the service to authenticate Authorino.
+	SharedSecret *SecretKeyReference `json:"sharedSecretRef,omitempty"`
+
+	// Timeout of the call, in milliseconds.
+	// If omitted, the call is only bound to the timeout of the auth pipeline.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=postgres;mysql
+type Metadata_SQL_Driver string
+
+// Read-only SQL query to a relational database, whose resulting rows are exposed as the metadata object.
+type Metadata_SQL struct {
+	// Database driver. Accepted values: postgres, mysql.
+	Driver Metadata_SQL_Driver `json:"driver"`
+
+	// Reference to a Secret key whose value is the data source name (DSN) of the database, i.e. the connection string in the format of the driver.
+	// E.g. postgres://authorino:secret@db:5432/plans?sslmode=disable, authorino:secret@tcp(db:3306)/plans
+	DSN *SecretKeyReference `json:"dsnRef"`
+
+	// The SQL query, run in a read-only transaction.
+	// Parameters are referred in the query with the placeholders of the driver, i.e. $1, $2, etc for postgres and ? for mysql.
+	// E.g. SELECT plan, quota FROM entitlements WHERE user_id = $1
+	Query string `json:"query"`
+
+	// Values of the parameters of the query, in order.
+	// Use it to pass values from the authorization JSON to the query, instead of concatenating them into the SQL statement.
+	Parameters []StaticOrDynamicValue `json:"parameters,omitempty"`
+
+	// Maximum number of rows read from the result of the query.
+	// +kubebuilder:default:=100
+	MaxRows int `json:"maxRows,omitempty"`
+
+	// Maximum number of open connections to the database, pooled across the requests of the config.
+	// +kubebuilder:default:=10
+	MaxOpenConns int `json:"maxOpenConns,omitempty"`
+
+	// Timeout of the query, in milliseconds.
+	// If omitted, the query is only bound to the timeout of the auth pipeline.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// ExternalEvaluator delegates the evaluation to an external service (plugin) that implements the authorino.plugin.v1.Evaluator gRPC service.
+// The plugin receives the Authorization JSON and returns the resolved object, or denies the request with the PERMISSION_DENIED or UNAUTHENTICATED status.
+type ExternalEvaluator struct {
+	// Endpoint of the gRPC service of the plugin (host:port).
+	Endpoint string `json:"endpoint"`
+
+	// Insecure connection to the plugin (i.e. without TLS)
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Reference to a Secret key whose value will be sent to the plugin as a bearer token, for the plugin to authenticate Authorino.
+	SharedSecret *SecretKeyReference `json:"sharedSecretRef,omitempty"`
+
+	// Timeout of the calls to the plugin, in milliseconds.
+	// If omitted, the calls are only bound to the timeout of the auth pipeline.
+	Timeout int `json:"timeout,omitempty"`
+
+	// Free-form settings sent to the plugin, JSON-encoded, in the "x-authorino-settings" gRPC metadata.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Settings runtime.RawExtension `json:"settings,omitempty"`
+}
+
 // ExternalRegistry specifies external source of data (i.e. OPA policy registry)
 type ExternalRegistry struct {
 	// Endpoint of the HTTP external registry.
 	// The endpoint must respond with either plain/text or application/json content-type.
 	// In the latter case, the JSON returned in the body must include a path `result.raw`, where the raw Rego policy will be extracted from. This complies with the specification of the OPA REST API (https://www.openpolicyagent.org/docs/latest/rest-api/#get-a-policy).
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
 	Endpoint string `json:"endpoint,omitempty"`
 
 	// Reference to a Secret key whose value will be passed by Authorino in the request.
@@ -496,6 +1072,11 @@ type Authorization_OPA struct {
 	// External registry of OPA policies.
 	ExternalRegistry ExternalRegistry `json:"externalRegistry,omitempty"`
 
+	// References to ConfigMaps in the same namespace, that store shared Rego modules (libraries) to be compiled together with the policy.
+	// Each key of the ConfigMaps is a Rego module, that must include the "package" declaration, imported by the policy (e.g. "import data.lib.helpers").
+	// Changes to the ConfigMaps are only picked up when the AuthConfig is reconciled again.
+	Libraries []k8score.LocalObjectReference `json:"libraryRefs,omitempty"`
+
 	// Returns the value of all Rego rules in the virtual document. Values can be read in subsequent evaluators/phases of the Auth Pipeline.
 	// Otherwise, only the default `allow` rule will be exposed.
 	// Returning all Rego rules can affect performance of OPA policies during reconciliation (policy precompile) and at runtime.
@@ -509,6 +1090,15 @@ type Authorization_JSONPatternMatching struct {
 	Rules []JSONPattern `json:"rules"`
 }
 
+// Common Expression Language (CEL) authorization policy.
+type Authorization_CEL struct {
+	// CEL expression that must evaluate to "true" for the request to be authorized.
+	// The Authorization JSON is available in the expression through the "context" and "auth" variables, e.g. `auth.identity.group == "admin" && context.request.http.method == "GET"`.
+	// The expression is compiled when the AuthConfig is reconciled.
+	// +kubebuilder:validation:MinLength:=1
+	Expression string `json:"expression"`
+}
+
 type Authorization_KubernetesAuthz_ResourceAttributes struct {
 	Namespace   StaticOrDynamicValue `json:"namespace,omitempty"`
 	Group       StaticOrDynamicValue `json:"group,omitempty"`
@@ -557,14 +1147,106 @@ type AuthzedObject struct {
 	Kind StaticOrDynamicValue `json:"kind,omitempty"`
 }
 
-// +kubebuilder:validation:Enum:=httpHeader;envoyDynamicMetadata
+// +kubebuilder:validation:Enum:=deny;allow
+type FailurePolicy string
+
+// +kubebuilder:validation:Enum:=deny;allow
+type Authorization_GenericHTTP_FailurePolicy string
+
+// External HTTP authorization service.
+// Authorino sends a POST request to the service, passing the authorization JSON in the body, unless a custom body is set.
+// Access is granted if the service responds with a 2xx status code and the rules, if any, match the JSON body of the response.
+type Authorization_GenericHTTP struct {
+	// Endpoint of the HTTP service.
+	// The endpoint accepts variable placeholders in the format "{selector}", where "selector" is any pattern supported
+	// by https://pkg.go.dev/github.com/tidwall/gjson and selects value from the authorization JSON.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
+	Endpoint string `json:"endpoint"`
+
+	// Raw body of the HTTP request, sent as application/json.
+	// If omitted, the whole authorization JSON is sent.
+	Body *StaticOrDynamicValue `json:"body,omitempty"`
+
+	// Custom headers in the HTTP request.
+	Headers []JsonProperty `json:"headers,omitempty"`
+
+	// Reference to a Secret key whose value will be passed by Authorino in the request.
+	// The HTTP service can use the shared secret to authenticate the origin of the request.
+	// Ignored if used together with oauth2.
+	SharedSecret *SecretKeyReference `json:"sharedSecretRef,omitempty"`
+
+	// Authentication with the HTTP service by OAuth2 Client Credentials grant.
+	OAuth2 *OAuth2ClientAuthentication `json:"oauth2,omitempty"`
+
+	// Defines where client credentials will be passed in the request to the service.
+	// If omitted, it defaults to client credentials passed in the HTTP Authorization header and the "Bearer" prefix expected prepended to the secret value.
+	Credentials Credentials `json:"credentials,omitempty"`
+
+	// Rules evaluated against the JSON body of the response of the HTTP service (e.g. selector: allowed, operator: eq, value: "true").
+	// All rules must match for the request to be authorized. If omitted, any 2xx response authorizes the request.
+	Rules []JSONPattern `json:"rules,omitempty"`
+
+	// Timeout of the request to the HTTP service, in milliseconds.
+	// Omit or set to 0 to wait for as long as the request to Authorino lasts.
+	Timeout int `json:"timeout,omitempty"`
+
+	// Whether to deny (default) or to allow the request when the HTTP service cannot be reached, times out or fails with a 5xx status code.
+	// +kubebuilder:default:=deny
+	FailurePolicy Authorization_GenericHTTP_FailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// User-Managed Access (UMA) 2.0 grant authorization (e.g. Keycloak Authorization Services).
+// Authorino requests a permission ticket for the resource and exchanges it for a requesting party token (RPT) on behalf of the user.
+// Access is granted if the UMA server issues the RPT.
+type Authorization_UMA struct {
+	// The endpoint of the UMA server.
+	// The value must coincide with the "issuer" claim of the UMA config discovered from the well-known uma configuration endpoint.
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
+	Endpoint string `json:"endpoint"`
+
+	// Reference to a Kubernetes secret in the same namespace, that stores client credentials of the resource server to the protection API of the UMA server.
+	Credentials *k8score.LocalObjectReference `json:"credentialsRef"`
+
+	// Location of the access token of the requesting party in the request.
+	// Defaults to the HTTP Authorization header with the "Bearer" prefix.
+	AccessToken *Credentials `json:"accessToken,omitempty"`
+
+	// Id of the protected resource registered in the UMA server.
+	// If omitted, resources are queried by the path of the request (uri).
+	ResourceID *StaticOrDynamicValue `json:"resourceId,omitempty"`
+
+	// Scopes of the resource requested on behalf of the user.
+	Scopes []StaticOrDynamicValue `json:"scopes,omitempty"`
+}
+
+// +kubebuilder:validation:Enum:=httpHeader;httpResponseHeader;httpCookie;envoyDynamicMetadata
 type Response_Wrapper string
 
+// Attributes of the cookie set with the response.
+type Response_Cookie struct {
+	// Domain of the cookie.
+	Domain string `json:"domain,omitempty"`
+	// Path of the cookie.
+	Path string `json:"path,omitempty"`
+	// Time to live of the cookie, in seconds. Omit it for a session cookie.
+	// +kubebuilder:validation:Minimum:=1
+	TTL int `json:"ttl,omitempty"`
+	// Whether the cookie is only sent over HTTPS.
+	Secure bool `json:"secure,omitempty"`
+	// Whether the cookie is hidden from scripts of the browser.
+	HttpOnly bool `json:"httpOnly,omitempty"`
+	// SameSite attribute of the cookie.
+	// +kubebuilder:validation:Enum:=Strict;Lax;None
+	SameSite string `json:"sameSite,omitempty"`
+}
+
 // Dynamic response to return to the client.
-// Apart from "name", one of the following parameters is required and only one of the following parameters is allowed: "wristband" or "json".
+// Apart from "name", one of the following parameters is required and only one of the following parameters is allowed: "wristband", "json" or "rateLimitDescriptors".
 type Response struct {
 	// Name of the custom response.
 	// It can be used to refer to the resolved response object in other configs.
+	// +kubebuilder:validation:MaxLength:=253
+	// +kubebuilder:validation:Pattern:=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
 	Name string `json:"name"`
 
 	// Priority group of the config.
@@ -586,15 +1268,18 @@ type Response struct {
 	Cache *EvaluatorCaching `json:"cache,omitempty"`
 
 	// How Authorino wraps the response.
-	// Use "httpHeader" (default) to wrap the response in an HTTP header; or "envoyDynamicMetadata" to wrap the response as Envoy Dynamic Metadata
+	// Use "httpHeader" (default) to wrap the response in an HTTP header added to the request sent upstream; "httpResponseHeader" to wrap the response in an HTTP header added to the response sent to the client; "httpCookie" to wrap the response in a cookie set in the response sent to the client; or "envoyDynamicMetadata" to wrap the response as Envoy Dynamic Metadata
 	// +kubebuilder:default:=httpHeader
 	Wrapper Response_Wrapper `json:"wrapper,omitempty"`
-	// The name of key used in the wrapped response (name of the HTTP header or property of the Envoy Dynamic Metadata JSON).
+	// The name of key used in the wrapped response (name of the HTTP header, name of the cookie or property of the Envoy Dynamic Metadata JSON).
 	// If omitted, it will be set to the name of the configuration.
 	WrapperKey string `json:"wrapperKey,omitempty"`
+	// Attributes of the cookie, when the response is wrapped as "httpCookie".
+	Cookie *Response_Cookie `json:"cookie,omitempty"`
 
-	Wristband *Response_Wristband   `json:"wristband,omitempty"`
-	JSON      *Response_DynamicJSON `json:"json,omitempty"`
+	Wristband            *Response_Wristband            `json:"wristband,omitempty"`
+	JSON                 *Response_DynamicJSON          `json:"json,omitempty"`
+	RateLimitDescriptors *Response_RateLimitDescriptors `json:"rateLimitDescriptors,omitempty"`
 }
 
 func (r *Response) GetType() string {
@@ -602,6 +1287,8 @@ func (r *Response) GetType() string {
 		return ResponseWristband
 	} else if r.JSON != nil {
 		return ResponseDynamicJSON
+	} else if r.RateLimitDescriptors != nil {
+		return ResponseRateLimitDescriptors
 	}
 	return TypeUnknown
 }
@@ -610,6 +1297,8 @@ func (r *Response) GetType() string {
 type Callback struct {
 	// Name of the callback.
 	// It can be used to refer to the resolved callback response in other configs.
+	// +kubebuilder:validation:MaxLength:=253
+	// +kubebuilder:validation:Pattern:=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
 	Name string `json:"name"`
 
 	// Priority group of the config.
@@ -636,7 +1325,7 @@ func (r *Callback) GetType() string {
 	return TypeUnknown
 }
 
-// +kubebuilder:validation:Enum:=ES256;ES384;ES512;RS256;RS384;RS512
+// +kubebuilder:validation:Enum:=ES256;ES384;ES512;RS256;RS384;RS512;EdDSA
 type SigningKeyAlgorithm string
 
 type SigningKeyRef struct {
@@ -650,10 +1339,14 @@ type SigningKeyRef struct {
 
 type Response_Wristband struct {
 	// The endpoint to the Authorino service that issues the wristband (format: <scheme>://<host>:<port>/<realm>, where <realm> = <namespace>/<authorino-auth-config-resource-name/wristband-config-name)
+	// +kubebuilder:validation:Pattern:=`^[a-zA-Z][a-zA-Z0-9+.-]*://[^/?#\s]+`
 	Issuer string `json:"issuer"`
 	// Any claims to be added to the wristband token apart from the standard JWT claims (iss, iat, exp) added by default.
+	// The values can be static or fetched from the Authorization JSON (e.g. metadata); claims whose values are not found are omitted.
+	// The standard claims cannot be overridden.
 	CustomClaims []JsonProperty `json:"customClaims,omitempty"`
 	// Time span of the wristband token, in seconds.
+	// +kubebuilder:validation:Minimum:=1
 	TokenDuration *int64 `json:"tokenDuration,omitempty"`
 	// Reference by name to Kubernetes secrets and corresponding signing algorithms.
 	// The secrets must contain a `key.pem` entry whose value is the signing key formatted as PEM.
@@ -665,6 +1358,14 @@ type Response_DynamicJSON struct {
 	Properties []JsonProperty `json:"properties"`
 }
 
+// Entries of the descriptors of a rate limit service (e.g. Limitador), to be read from the Envoy Dynamic Metadata by the rate limit actions of Envoy.
+// The response must be wrapped as "envoyDynamicMetadata".
+type Response_RateLimitDescriptors struct {
+	// List of descriptor entries, whose values are converted to strings.
+	// Entries whose values are not found in the Authorization JSON are omitted.
+	Entries []JsonProperty `json:"entries"`
+}
+
 // +kubebuilder:validation:Minimum:=300
 // +kubebuilder:validation:Maximum:=599
 type DenyWith_Code int64
@@ -681,6 +1382,28 @@ type DenyWithSpec struct {
 
 	// HTTP response body to override the default denial body.
 	Body *StaticOrDynamicValue `json:"body,omitempty"`
+
+	// Go template of the HTTP response body, rendered with the Authorization JSON as data (e.g. "{{.auth.identity.username}}").
+	// Takes precedence over "body". Values are HTML-escaped when the content type is "text/html".
+	BodyTemplate string `json:"bodyTemplate,omitempty"`
+
+	// Content type of the HTTP response body, i.e. value of the "Content-Type" header of the denial.
+	ContentType string `json:"contentType,omitempty"`
+
+	// Redirects the client to another location instead, e.g. to the login page of an identity provider.
+	// Takes precedence over "code".
+	Redirect *DenyWithRedirect `json:"redirect,omitempty"`
+}
+
+type DenyWithRedirect struct {
+	// Go template of the URL of the location to redirect to, rendered with the Authorization JSON as data.
+	// The template function "requestURL" returns the URL of the original request (e.g. "https://sso.acme.com/login?redirect_uri={{requestURL . | urlquery}}").
+	Location string `json:"location"`
+
+	// HTTP status code of the redirect.
+	// +kubebuilder:validation:Enum:=301;302;303;307;308
+	// +kubebuilder:default:=302
+	Code DenyWith_Code `json:"code,omitempty"`
 }
 
 type DenyWith struct {
@@ -689,6 +1412,10 @@ type DenyWith struct {
 
 	// Denial status customization when the request is unauthorized.
 	Unauthorized *DenyWithSpec `json:"unauthorized,omitempty"`
+
+	// Denial status customization when the request does not match the top-level conditions of the AuthConfig (`when`).
+	// If omitted, unmatched requests are not denied, but skip the AuthConfig instead.
+	Unmatched *DenyWithSpec `json:"unmatched,omitempty"`
 }
 
 type ConditionType string
@@ -724,6 +1451,10 @@ type Summary struct {
 	// Lists the hosts from spec.hosts linked to the resource in the index
 	HostsReady []string `json:"hostsReady"`
 
+	// Lists the hosts from spec.hosts not linked to the resource in the index, with the reasons
+	// +optional
+	HostsNotReady []HostStatus `json:"hostsNotReady,omitempty"`
+
 	// Number of hosts from spec.hosts linked to the resource in the index, compared to the total number of hosts in spec.hosts
 	NumHostsReady string `json:"numHostsReady"`
 
@@ -743,6 +1474,19 @@ type Summary struct {
 	FestivalWristbandEnabled bool `json:"festivalWristbandEnabled"`
 }
 
+// HostStatus is the status of a host from spec.hosts not linked to the resource in the index
+type HostStatus struct {
+	// The host from spec.hosts
+	Host string `json:"host"`
+
+	// (brief) reason why the host is not linked to the resource in the index
+	Reason string `json:"reason"`
+
+	// Human readable message indicating details about the reason
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // AuthConfigStatus defines the observed state of AuthConfig
 type AuthConfigStatus struct {
 	Conditions []Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
@@ -763,11 +1507,13 @@ func (s *AuthConfigStatus) Ready() bool {
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.summary.ready`,description="Ready for all hosts"
 // +kubebuilder:printcolumn:name="Hosts",type=string,JSONPath=`.status.summary.numHostsReady`,description="Number of hosts ready"
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`,description="Reason of the readiness of the resource"
 // +kubebuilder:printcolumn:name="Authentication",type=integer,JSONPath=`.status.summary.numIdentitySources`,description="Number of trusted identity sources",priority=2
 // +kubebuilder:printcolumn:name="Metadata",type=integer,JSONPath=`.status.summary.numMetadataSources`,description="Number of external metadata sources",priority=2
 // +kubebuilder:printcolumn:name="Authorization",type=integer,JSONPath=`.status.summary.numAuthorizationPolicies`,description="Number of authorization policies",priority=2
 // +kubebuilder:printcolumn:name="Response",type=integer,JSONPath=`.status.summary.numResponseItems`,description="Number of items added to the authorization response",priority=2
 // +kubebuilder:printcolumn:name="Wristband",type=boolean,JSONPath=`.status.summary.festivalWristbandEnabled`,description="Whether issuing Festival Wristbands",priority=2
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type AuthConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 Red Hat, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Specifies the platform-wide defaults inherited by the AuthConfigs, e.g. a corporate identity provider or a baseline authorization policy.
+// Identity, metadata, authorization, response and callback configs are added to the ones of the AuthConfigs; configs of the AuthConfigs with the same name replace the inherited ones.
+type ClusterAuthConfigSpec struct {
+	// Label selector of the AuthConfigs that inherit the configs.
+	// If omitted, all the AuthConfigs watched by the Authorino instance inherit the configs.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+
+	SharedSpec `json:",inline"`
+}
+
+// ClusterAuthConfig is the schema for Authorino's ClusterAuthConfig API
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ClusterAuthConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterAuthConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterAuthConfigList contains a list of ClusterAuthConfig
+type ClusterAuthConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterAuthConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterAuthConfig{}, &ClusterAuthConfigList{})
+}
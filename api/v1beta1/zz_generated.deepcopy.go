@@ -115,6 +115,11 @@ func (in *AuthConfigSpec) DeepCopyInto(out *AuthConfigSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TargetRef != nil {
+		in, out := &in.TargetRef, &out.TargetRef
+		*out = new(TargetRef)
+		**out = **in
+	}
 	if in.Patterns != nil {
 		in, out := &in.Patterns, &out.Patterns
 		*out = make(map[string]JSONPatternExpressions, len(*in))
@@ -133,7 +138,9 @@ func (in *AuthConfigSpec) DeepCopyInto(out *AuthConfigSpec) {
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]JSONPattern, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Identity != nil {
 		in, out := &in.Identity, &out.Identity
@@ -195,6 +202,33 @@ func (in *AuthConfigSpec) DeepCopyInto(out *AuthConfigSpec) {
 		*out = new(DenyWith)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RequestBody != nil {
+		in, out := &in.RequestBody, &out.RequestBody
+		*out = new(RequestBody)
+		**out = **in
+	}
+	if in.HeadersToRemove != nil {
+		in, out := &in.HeadersToRemove, &out.HeadersToRemove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HostOverrides != nil {
+		in, out := &in.HostOverrides, &out.HostOverrides
+		*out = make(map[string]HostOverride, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(SharedSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Overrides != nil {
+		in, out := &in.Overrides, &out.Overrides
+		*out = new(SharedSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthConfigSpec.
@@ -236,7 +270,9 @@ func (in *Authorization) DeepCopyInto(out *Authorization) {
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]JSONPattern, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Cache != nil {
 		in, out := &in.Cache, &out.Cache
@@ -263,6 +299,31 @@ func (in *Authorization) DeepCopyInto(out *Authorization) {
 		*out = new(Authorization_Authzed)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.UMA != nil {
+		in, out := &in.UMA, &out.UMA
+		*out = new(Authorization_UMA)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GenericHTTP != nil {
+		in, out := &in.GenericHTTP, &out.GenericHTTP
+		*out = new(Authorization_GenericHTTP)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = new(CustomEvaluator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalEvaluator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CEL != nil {
+		in, out := &in.CEL, &out.CEL
+		*out = new(Authorization_CEL)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization.
@@ -306,13 +367,75 @@ func (in *Authorization_Authzed) DeepCopy() *Authorization_Authzed {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_CEL) DeepCopyInto(out *Authorization_CEL) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_CEL.
+func (in *Authorization_CEL) DeepCopy() *Authorization_CEL {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_CEL)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_GenericHTTP) DeepCopyInto(out *Authorization_GenericHTTP) {
+	*out = *in
+	if in.Body != nil {
+		in, out := &in.Body, &out.Body
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]JsonProperty, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SharedSecret != nil {
+		in, out := &in.SharedSecret, &out.SharedSecret
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.OAuth2 != nil {
+		in, out := &in.OAuth2, &out.OAuth2
+		*out = new(OAuth2ClientAuthentication)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Credentials = in.Credentials
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]JSONPattern, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_GenericHTTP.
+func (in *Authorization_GenericHTTP) DeepCopy() *Authorization_GenericHTTP {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_GenericHTTP)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Authorization_JSONPatternMatching) DeepCopyInto(out *Authorization_JSONPatternMatching) {
 	*out = *in
 	if in.Rules != nil {
 		in, out := &in.Rules, &out.Rules
 		*out = make([]JSONPattern, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -377,6 +500,11 @@ func (in *Authorization_KubernetesAuthz_ResourceAttributes) DeepCopy() *Authoriz
 func (in *Authorization_OPA) DeepCopyInto(out *Authorization_OPA) {
 	*out = *in
 	in.ExternalRegistry.DeepCopyInto(&out.ExternalRegistry)
+	if in.Libraries != nil {
+		in, out := &in.Libraries, &out.Libraries
+		*out = make([]v1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_OPA.
@@ -389,6 +517,41 @@ func (in *Authorization_OPA) DeepCopy() *Authorization_OPA {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Authorization_UMA) DeepCopyInto(out *Authorization_UMA) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.AccessToken != nil {
+		in, out := &in.AccessToken, &out.AccessToken
+		*out = new(Credentials)
+		**out = **in
+	}
+	if in.ResourceID != nil {
+		in, out := &in.ResourceID, &out.ResourceID
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+	if in.Scopes != nil {
+		in, out := &in.Scopes, &out.Scopes
+		*out = make([]StaticOrDynamicValue, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Authorization_UMA.
+func (in *Authorization_UMA) DeepCopy() *Authorization_UMA {
+	if in == nil {
+		return nil
+	}
+	out := new(Authorization_UMA)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthzedObject) DeepCopyInto(out *AuthzedObject) {
 	*out = *in
@@ -412,7 +575,9 @@ func (in *Callback) DeepCopyInto(out *Callback) {
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]JSONPattern, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.HTTP != nil {
 		in, out := &in.HTTP, &out.HTTP
@@ -431,6 +596,115 @@ func (in *Callback) DeepCopy() *Callback {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Challenge) DeepCopyInto(out *Challenge) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Challenge.
+func (in *Challenge) DeepCopy() *Challenge {
+	if in == nil {
+		return nil
+	}
+	out := new(Challenge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClaimMappings) DeepCopyInto(out *ClaimMappings) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClaimMappings.
+func (in *ClaimMappings) DeepCopy() *ClaimMappings {
+	if in == nil {
+		return nil
+	}
+	out := new(ClaimMappings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAuthConfig) DeepCopyInto(out *ClusterAuthConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAuthConfig.
+func (in *ClusterAuthConfig) DeepCopy() *ClusterAuthConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAuthConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAuthConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAuthConfigList) DeepCopyInto(out *ClusterAuthConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterAuthConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAuthConfigList.
+func (in *ClusterAuthConfigList) DeepCopy() *ClusterAuthConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAuthConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterAuthConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterAuthConfigSpec) DeepCopyInto(out *ClusterAuthConfigSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.SharedSpec.DeepCopyInto(&out.SharedSpec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterAuthConfigSpec.
+func (in *ClusterAuthConfigSpec) DeepCopy() *ClusterAuthConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterAuthConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Condition) DeepCopyInto(out *Condition) {
 	*out = *in
@@ -466,6 +740,22 @@ func (in *Credentials) DeepCopy() *Credentials {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomEvaluator) DeepCopyInto(out *CustomEvaluator) {
+	*out = *in
+	in.Settings.DeepCopyInto(&out.Settings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomEvaluator.
+func (in *CustomEvaluator) DeepCopy() *CustomEvaluator {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomEvaluator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DenyWith) DeepCopyInto(out *DenyWith) {
 	*out = *in
@@ -479,6 +769,11 @@ func (in *DenyWith) DeepCopyInto(out *DenyWith) {
 		*out = new(DenyWithSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Unmatched != nil {
+		in, out := &in.Unmatched, &out.Unmatched
+		*out = new(DenyWithSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DenyWith.
@@ -491,6 +786,21 @@ func (in *DenyWith) DeepCopy() *DenyWith {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DenyWithRedirect) DeepCopyInto(out *DenyWithRedirect) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DenyWithRedirect.
+func (in *DenyWithRedirect) DeepCopy() *DenyWithRedirect {
+	if in == nil {
+		return nil
+	}
+	out := new(DenyWithRedirect)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DenyWithSpec) DeepCopyInto(out *DenyWithSpec) {
 	*out = *in
@@ -511,6 +821,11 @@ func (in *DenyWithSpec) DeepCopyInto(out *DenyWithSpec) {
 		*out = new(StaticOrDynamicValue)
 		**out = **in
 	}
+	if in.Redirect != nil {
+		in, out := &in.Redirect, &out.Redirect
+		*out = new(DenyWithRedirect)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DenyWithSpec.
@@ -539,6 +854,27 @@ func (in *EvaluatorCaching) DeepCopy() *EvaluatorCaching {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalEvaluator) DeepCopyInto(out *ExternalEvaluator) {
+	*out = *in
+	if in.SharedSecret != nil {
+		in, out := &in.SharedSecret, &out.SharedSecret
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	in.Settings.DeepCopyInto(&out.Settings)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalEvaluator.
+func (in *ExternalEvaluator) DeepCopy() *ExternalEvaluator {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalEvaluator)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExternalRegistry) DeepCopyInto(out *ExternalRegistry) {
 	*out = *in
@@ -561,12 +897,93 @@ func (in *ExternalRegistry) DeepCopy() *ExternalRegistry {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Identity) DeepCopyInto(out *Identity) {
+func (in *HostOverride) DeepCopyInto(out *HostOverride) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]JSONPattern, len(*in))
-		copy(*out, *in)
+	if in.Identity != nil {
+		in, out := &in.Identity, &out.Identity
+		*out = make([]*Identity, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Identity)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make([]*Metadata, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Metadata)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Authorization != nil {
+		in, out := &in.Authorization, &out.Authorization
+		*out = make([]*Authorization, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Authorization)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = make([]*Response, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Response)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.DenyWith != nil {
+		in, out := &in.DenyWith, &out.DenyWith
+		*out = new(DenyWith)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostOverride.
+func (in *HostOverride) DeepCopy() *HostOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(HostOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostStatus) DeepCopyInto(out *HostStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostStatus.
+func (in *HostStatus) DeepCopy() *HostStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(HostStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity) DeepCopyInto(out *Identity) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]JSONPattern, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Cache != nil {
 		in, out := &in.Cache, &out.Cache
@@ -581,6 +998,26 @@ func (in *Identity) DeepCopyInto(out *Identity) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ClaimMappings != nil {
+		in, out := &in.ClaimMappings, &out.ClaimMappings
+		*out = new(ClaimMappings)
+		**out = **in
+	}
+	if in.Revocation != nil {
+		in, out := &in.Revocation, &out.Revocation
+		*out = new(Revocation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplayProtection != nil {
+		in, out := &in.ReplayProtection, &out.ReplayProtection
+		*out = new(ReplayProtection)
+		**out = **in
+	}
+	if in.Challenge != nil {
+		in, out := &in.Challenge, &out.Challenge
+		*out = new(Challenge)
+		**out = **in
+	}
 	if in.OAuth2 != nil {
 		in, out := &in.OAuth2, &out.OAuth2
 		*out = new(Identity_OAuth2Config)
@@ -589,7 +1026,7 @@ func (in *Identity) DeepCopyInto(out *Identity) {
 	if in.Oidc != nil {
 		in, out := &in.Oidc, &out.Oidc
 		*out = new(Identity_OidcConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.APIKey != nil {
 		in, out := &in.APIKey, &out.APIKey
@@ -601,6 +1038,31 @@ func (in *Identity) DeepCopyInto(out *Identity) {
 		*out = new(Identity_MTLS)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HMAC != nil {
+		in, out := &in.HMAC, &out.HMAC
+		*out = new(Identity_HMAC)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AWSSigV4 != nil {
+		in, out := &in.AWSSigV4, &out.AWSSigV4
+		*out = new(Identity_AWSSigV4)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JWT != nil {
+		in, out := &in.JWT, &out.JWT
+		*out = new(Identity_JWT)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SPIFFE != nil {
+		in, out := &in.SPIFFE, &out.SPIFFE
+		*out = new(Identity_SPIFFE)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SAML != nil {
+		in, out := &in.SAML, &out.SAML
+		*out = new(Identity_SAML)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.KubernetesAuth != nil {
 		in, out := &in.KubernetesAuth, &out.KubernetesAuth
 		*out = new(Identity_KubernetesAuth)
@@ -616,6 +1078,16 @@ func (in *Identity) DeepCopyInto(out *Identity) {
 		*out = new(Identity_Plain)
 		**out = **in
 	}
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = new(CustomEvaluator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalEvaluator)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity.
@@ -648,6 +1120,26 @@ func (in *Identity_APIKey) DeepCopy() *Identity_APIKey {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_AWSSigV4) DeepCopyInto(out *Identity_AWSSigV4) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_AWSSigV4.
+func (in *Identity_AWSSigV4) DeepCopy() *Identity_AWSSigV4 {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_AWSSigV4)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity_Anonymous) DeepCopyInto(out *Identity_Anonymous) {
 	*out = *in
@@ -663,6 +1155,76 @@ func (in *Identity_Anonymous) DeepCopy() *Identity_Anonymous {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_DPoP) DeepCopyInto(out *Identity_DPoP) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_DPoP.
+func (in *Identity_DPoP) DeepCopy() *Identity_DPoP {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_DPoP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_HMAC) DeepCopyInto(out *Identity_HMAC) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SignedComponents != nil {
+		in, out := &in.SignedComponents, &out.SignedComponents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_HMAC.
+func (in *Identity_HMAC) DeepCopy() *Identity_HMAC {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_HMAC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_JWT) DeepCopyInto(out *Identity_JWT) {
+	*out = *in
+	if in.JWKSRef != nil {
+		in, out := &in.JWKSRef, &out.JWKSRef
+		*out = new(JWKSReference)
+		**out = **in
+	}
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedIssuers != nil {
+		in, out := &in.AllowedIssuers, &out.AllowedIssuers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_JWT.
+func (in *Identity_JWT) DeepCopy() *Identity_JWT {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_JWT)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity_KubernetesAuth) DeepCopyInto(out *Identity_KubernetesAuth) {
 	*out = *in
@@ -726,6 +1288,26 @@ func (in *Identity_OAuth2Config) DeepCopy() *Identity_OAuth2Config {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity_OidcConfig) DeepCopyInto(out *Identity_OidcConfig) {
 	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedIssuers != nil {
+		in, out := &in.AllowedIssuers, &out.AllowedIssuers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DPoP != nil {
+		in, out := &in.DPoP, &out.DPoP
+		*out = new(Identity_DPoP)
+		**out = **in
+	}
+	if in.Introspection != nil {
+		in, out := &in.Introspection, &out.Introspection
+		*out = new(Identity_OidcIntrospection)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_OidcConfig.
@@ -738,6 +1320,26 @@ func (in *Identity_OidcConfig) DeepCopy() *Identity_OidcConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_OidcIntrospection) DeepCopyInto(out *Identity_OidcIntrospection) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_OidcIntrospection.
+func (in *Identity_OidcIntrospection) DeepCopy() *Identity_OidcIntrospection {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_OidcIntrospection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Identity_Plain) DeepCopyInto(out *Identity_Plain) {
 	*out = *in
@@ -753,11 +1355,66 @@ func (in *Identity_Plain) DeepCopy() *Identity_Plain {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_SAML) DeepCopyInto(out *Identity_SAML) {
+	*out = *in
+	if in.IdPMetadataRef != nil {
+		in, out := &in.IdPMetadataRef, &out.IdPMetadataRef
+		*out = new(JWKSReference)
+		**out = **in
+	}
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_SAML.
+func (in *Identity_SAML) DeepCopy() *Identity_SAML {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_SAML)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Identity_SPIFFE) DeepCopyInto(out *Identity_SPIFFE) {
+	*out = *in
+	if in.TrustBundleRef != nil {
+		in, out := &in.TrustBundleRef, &out.TrustBundleRef
+		*out = new(JWKSReference)
+		**out = **in
+	}
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Identity_SPIFFE.
+func (in *Identity_SPIFFE) DeepCopy() *Identity_SPIFFE {
+	if in == nil {
+		return nil
+	}
+	out := new(Identity_SPIFFE)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JSONPattern) DeepCopyInto(out *JSONPattern) {
 	*out = *in
 	out.JSONPatternRef = in.JSONPatternRef
 	out.JSONPatternExpression = in.JSONPatternExpression
+	if in.Any != nil {
+		in, out := &in.Any, &out.Any
+		*out = make([]JSONPatternExpression, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JSONPattern.
@@ -819,6 +1476,21 @@ func (in *JSONPatternRef) DeepCopy() *JSONPatternRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JWKSReference) DeepCopyInto(out *JWKSReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JWKSReference.
+func (in *JWKSReference) DeepCopy() *JWKSReference {
+	if in == nil {
+		return nil
+	}
+	out := new(JWKSReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JsonProperty) DeepCopyInto(out *JsonProperty) {
 	*out = *in
@@ -842,7 +1514,9 @@ func (in *Metadata) DeepCopyInto(out *Metadata) {
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]JSONPattern, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Cache != nil {
 		in, out := &in.Cache, &out.Cache
@@ -864,6 +1538,26 @@ func (in *Metadata) DeepCopyInto(out *Metadata) {
 		*out = new(Metadata_GenericHTTP)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Custom != nil {
+		in, out := &in.Custom, &out.Custom
+		*out = new(CustomEvaluator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ExternalEvaluator)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SQL != nil {
+		in, out := &in.SQL, &out.SQL
+		*out = new(Metadata_SQL)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GRPC != nil {
+		in, out := &in.GRPC, &out.GRPC
+		*out = new(Metadata_GRPC)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata.
@@ -876,6 +1570,45 @@ func (in *Metadata) DeepCopy() *Metadata {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metadata_GRPC) DeepCopyInto(out *Metadata_GRPC) {
+	*out = *in
+	if in.Body != nil {
+		in, out := &in.Body, &out.Body
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]JsonProperty, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]JsonProperty, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SharedSecret != nil {
+		in, out := &in.SharedSecret, &out.SharedSecret
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata_GRPC.
+func (in *Metadata_GRPC) DeepCopy() *Metadata_GRPC {
+	if in == nil {
+		return nil
+	}
+	out := new(Metadata_GRPC)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metadata_GenericHTTP) DeepCopyInto(out *Metadata_GenericHTTP) {
 	*out = *in
@@ -926,6 +1659,31 @@ func (in *Metadata_GenericHTTP) DeepCopy() *Metadata_GenericHTTP {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metadata_SQL) DeepCopyInto(out *Metadata_SQL) {
+	*out = *in
+	if in.DSN != nil {
+		in, out := &in.DSN, &out.DSN
+		*out = new(SecretKeyReference)
+		**out = **in
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]StaticOrDynamicValue, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata_SQL.
+func (in *Metadata_SQL) DeepCopy() *Metadata_SQL {
+	if in == nil {
+		return nil
+	}
+	out := new(Metadata_SQL)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metadata_UMA) DeepCopyInto(out *Metadata_UMA) {
 	*out = *in
@@ -934,6 +1692,11 @@ func (in *Metadata_UMA) DeepCopyInto(out *Metadata_UMA) {
 		*out = new(v1.LocalObjectReference)
 		**out = **in
 	}
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(Metadata_UMA_Filter)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata_UMA.
@@ -946,6 +1709,36 @@ func (in *Metadata_UMA) DeepCopy() *Metadata_UMA {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Metadata_UMA_Filter) DeepCopyInto(out *Metadata_UMA_Filter) {
+	*out = *in
+	if in.URI != nil {
+		in, out := &in.URI, &out.URI
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+	if in.Type != nil {
+		in, out := &in.Type, &out.Type
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+	if in.Owner != nil {
+		in, out := &in.Owner, &out.Owner
+		*out = new(StaticOrDynamicValue)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Metadata_UMA_Filter.
+func (in *Metadata_UMA_Filter) DeepCopy() *Metadata_UMA_Filter {
+	if in == nil {
+		return nil
+	}
+	out := new(Metadata_UMA_Filter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Metadata_UserInfo) DeepCopyInto(out *Metadata_UserInfo) {
 	*out = *in
@@ -994,19 +1787,56 @@ func (in *OAuth2ClientAuthentication) DeepCopy() *OAuth2ClientAuthentication {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplayProtection) DeepCopyInto(out *ReplayProtection) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplayProtection.
+func (in *ReplayProtection) DeepCopy() *ReplayProtection {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplayProtection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RequestBody) DeepCopyInto(out *RequestBody) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RequestBody.
+func (in *RequestBody) DeepCopy() *RequestBody {
+	if in == nil {
+		return nil
+	}
+	out := new(RequestBody)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Response) DeepCopyInto(out *Response) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]JSONPattern, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Cache != nil {
 		in, out := &in.Cache, &out.Cache
 		*out = new(EvaluatorCaching)
 		**out = **in
 	}
+	if in.Cookie != nil {
+		in, out := &in.Cookie, &out.Cookie
+		*out = new(Response_Cookie)
+		**out = **in
+	}
 	if in.Wristband != nil {
 		in, out := &in.Wristband, &out.Wristband
 		*out = new(Response_Wristband)
@@ -1017,6 +1847,11 @@ func (in *Response) DeepCopyInto(out *Response) {
 		*out = new(Response_DynamicJSON)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RateLimitDescriptors != nil {
+		in, out := &in.RateLimitDescriptors, &out.RateLimitDescriptors
+		*out = new(Response_RateLimitDescriptors)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response.
@@ -1029,6 +1864,21 @@ func (in *Response) DeepCopy() *Response {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Response_Cookie) DeepCopyInto(out *Response_Cookie) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response_Cookie.
+func (in *Response_Cookie) DeepCopy() *Response_Cookie {
+	if in == nil {
+		return nil
+	}
+	out := new(Response_Cookie)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Response_DynamicJSON) DeepCopyInto(out *Response_DynamicJSON) {
 	*out = *in
@@ -1051,6 +1901,28 @@ func (in *Response_DynamicJSON) DeepCopy() *Response_DynamicJSON {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Response_RateLimitDescriptors) DeepCopyInto(out *Response_RateLimitDescriptors) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]JsonProperty, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Response_RateLimitDescriptors.
+func (in *Response_RateLimitDescriptors) DeepCopy() *Response_RateLimitDescriptors {
+	if in == nil {
+		return nil
+	}
+	out := new(Response_RateLimitDescriptors)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Response_Wristband) DeepCopyInto(out *Response_Wristband) {
 	*out = *in
@@ -1089,6 +1961,26 @@ func (in *Response_Wristband) DeepCopy() *Response_Wristband {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Revocation) DeepCopyInto(out *Revocation) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Revocation.
+func (in *Revocation) DeepCopy() *Revocation {
+	if in == nil {
+		return nil
+	}
+	out := new(Revocation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
 	*out = *in
@@ -1104,6 +1996,96 @@ func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedSpec) DeepCopyInto(out *SharedSpec) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make(map[string]JSONPatternExpressions, len(*in))
+		for key, val := range *in {
+			var outVal []JSONPatternExpression
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(JSONPatternExpressions, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Identity != nil {
+		in, out := &in.Identity, &out.Identity
+		*out = make([]*Identity, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Identity)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = make([]*Metadata, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Metadata)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Authorization != nil {
+		in, out := &in.Authorization, &out.Authorization
+		*out = make([]*Authorization, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Authorization)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = make([]*Response, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Response)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Callbacks != nil {
+		in, out := &in.Callbacks, &out.Callbacks
+		*out = make([]*Callback, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(Callback)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.DenyWith != nil {
+		in, out := &in.DenyWith, &out.DenyWith
+		*out = new(DenyWith)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedSpec.
+func (in *SharedSpec) DeepCopy() *SharedSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SigningKeyRef) DeepCopyInto(out *SigningKeyRef) {
 	*out = *in
@@ -1143,6 +2125,11 @@ func (in *Summary) DeepCopyInto(out *Summary) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.HostsNotReady != nil {
+		in, out := &in.HostsNotReady, &out.HostsNotReady
+		*out = make([]HostStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Summary.
@@ -1155,6 +2142,21 @@ func (in *Summary) DeepCopy() *Summary {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetRef) DeepCopyInto(out *TargetRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRef.
+func (in *TargetRef) DeepCopy() *TargetRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ValueFrom) DeepCopyInto(out *ValueFrom) {
 	*out = *in
@@ -18,14 +18,20 @@ package controllers
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"net/http"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	api "github.com/kuadrant/authorino/api/v1beta1"
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators"
 	authorization_evaluators "github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	custom_evaluators "github.com/kuadrant/authorino/pkg/evaluators/custom"
+	external_evaluators "github.com/kuadrant/authorino/pkg/evaluators/external"
 	identity_evaluators "github.com/kuadrant/authorino/pkg/evaluators/identity"
 	metadata_evaluators "github.com/kuadrant/authorino/pkg/evaluators/metadata"
 	response_evaluators "github.com/kuadrant/authorino/pkg/evaluators/response"
@@ -33,25 +39,40 @@ import (
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/oauth2"
+	"github.com/kuadrant/authorino/pkg/replay"
+	"github.com/kuadrant/authorino/pkg/revocation"
 	"github.com/kuadrant/authorino/pkg/utils"
 
 	"github.com/go-logr/logr"
+	multierror "github.com/hashicorp/go-multierror"
 	"gopkg.in/square/go-jose.v2"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
 	failedToCleanConfig = "failed to clean up all asynchronous workers"
 
 	AuthConfigsReadyzSubpath = "authconfigs"
+
+	// authConfigHostsIndexField is the name of the index of the cache of authconfigs by host
+	authConfigHostsIndexField = "spec.hosts"
+
+	// secretsAccessReviewTTL is how long the review of the access to secrets in all namespaces is reused
+	secretsAccessReviewTTL = 5 * time.Minute
 )
 
 // AuthConfigReconciler reconciles an AuthConfig object
@@ -63,11 +84,29 @@ type AuthConfigReconciler struct {
 	StatusReport  *StatusReportMap
 	LabelSelector labels.Selector
 	Namespace     string
+	// Recorder, if set, records Kubernetes events about the reconciled resources
+	Recorder record.EventRecorder
+	// AllowCrossNamespaceAPIKeys allows api key identities to read the api key secrets from a namespace other than the
+	// one of the authconfig (`spec.identity.apiKey.namespace`)
+	AllowCrossNamespaceAPIKeys bool
 
 	indexBootstrap sync.Mutex
+	// secretsAccess caches the review of the access of Authorino to secrets in all namespaces
+	secretsAccess struct {
+		sync.Mutex
+		allowed    bool
+		reviewedAt time.Time
+	}
+	// hostsReleased enqueues the authconfigs waiting for hosts released by other authconfigs
+	hostsReleased chan event.GenericEvent
 }
 
 // +kubebuilder:rbac:groups=authorino.kuadrant.io,resources=authconfigs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=authorino.kuadrant.io,resources=clusterauthconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=selfsubjectaccessreviews,verbs=create
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways;httproutes,verbs=get;list;watch
 
 func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	if err := r.bootstrapIndex(ctx); err != nil {
@@ -96,8 +135,10 @@ func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 
 		// delete related authconfigs from the index.
+		releasedHosts := r.Index.FindKeys(resourceId)
 		r.Index.Delete(resourceId)
 		r.StatusReport.Clear(resourceId)
+		r.requeueAuthConfigsWaitingForHosts(ctx, resourceId, releasedHosts)
 		reportReconciled = false
 	} else {
 		// resource found and it is to be watched by this controller
@@ -108,28 +149,116 @@ func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			logger.Error(err, failedToCleanConfig)
 		}
 
+		// the configs inherited from the base authconfigs of the namespace and from the cluster authconfigs are merged into
+		// the spec before the translation
+		if err := r.inheritBaseAuthConfigs(log.IntoContext(ctx, logger), &authConfig); err != nil {
+			r.reportInvalidResource(&authConfig, resourceId, err.Error(), err)
+			return ctrl.Result{}, err
+		}
+		if err := r.inheritClusterAuthConfigs(log.IntoContext(ctx, logger), &authConfig); err != nil {
+			r.reportInvalidResource(&authConfig, resourceId, err.Error(), err)
+			return ctrl.Result{}, err
+		}
+
+		// the hostnames of the gateway api resource referred in the targetRef are protected in addition to the hosts of the spec
+		hostnames, err := targetRefHostnames(ctx, r.Client, &authConfig)
+		if err != nil {
+			r.reportInvalidResource(&authConfig, resourceId, err.Error(), err)
+			return ctrl.Result{}, err
+		}
+		authConfig.Spec.Hosts = append(authConfig.Spec.Hosts, utils.SubtractSlice(hostnames, authConfig.Spec.Hosts)...)
+
 		translatedAuthConfig, err := r.translateAuthConfig(log.IntoContext(ctx, logger), &authConfig)
 		if err != nil {
-			r.StatusReport.Set(resourceId, api.StatusReasonInvalidResource, err.Error(), []string{})
+			r.reportInvalidResource(&authConfig, resourceId, err.Error(), err)
 			return ctrl.Result{}, err
 		}
 
-		// delete unused hosts from the index
-		for _, host := range utils.SubtractSlice(r.Index.FindKeys(resourceId), authConfig.Spec.Hosts) {
-			r.Index.DeleteKey(resourceId, host)
+		// hosts with overrides get a config of their own; the other hosts share the same config.
+		// hosts whose overrides fail to be translated are reported as failed, while the other hosts are still served.
+		translatedAuthConfigs := map[string]*evaluators.AuthConfig{}
+		failedHosts := []api.HostStatus{}
+		var translationErrs []string
+		for _, host := range authConfig.Spec.Hosts {
+			override, found := authConfig.Spec.HostOverrides[host]
+			if !found {
+				translatedAuthConfigs[host] = translatedAuthConfig
+				continue
+			}
+			hostAuthConfig, err := r.translateAuthConfig(log.IntoContext(ctx, logger.WithValues("host", host)), authConfigWithOverride(&authConfig, override))
+			if err != nil {
+				message := fmt.Sprintf("host %s: %v", host, err)
+				failedHosts = append(failedHosts, api.HostStatus{Host: host, Reason: r.invalidResourceReason(&authConfig, message, err), Message: err.Error()})
+				translationErrs = append(translationErrs, message)
+				continue
+			}
+			hostAuthConfig.Labels["host"] = host
+			translatedAuthConfigs[host] = hostAuthConfig
 		}
 
-		linkedHosts, looseHosts, err = r.addToIndex(log.IntoContext(ctx, logger), req.Namespace, resourceId, translatedAuthConfig, authConfig.Spec.Hosts)
+		// the shared config is left out if all the hosts have overrides
+		if !sharedConfigUsed(translatedAuthConfig, translatedAuthConfigs) {
+			_ = translatedAuthConfig.Clean(ctx)
+		}
+
+		// delete unused hosts from the index, as well as the hosts that failed to be translated
+		releasedHosts := utils.SubtractSlice(r.Index.FindKeys(resourceId), authConfig.Spec.Hosts)
+		for _, host := range r.Index.FindKeys(resourceId) {
+			if _, translated := translatedAuthConfigs[host]; !translated {
+				r.Index.DeleteKey(resourceId, host)
+			}
+		}
+		r.requeueAuthConfigsWaitingForHosts(ctx, resourceId, releasedHosts)
+
+		linkedHosts, looseHosts = []string{}, []string{}
+		for _, host := range authConfig.Spec.Hosts {
+			if _, translated := translatedAuthConfigs[host]; !translated {
+				continue
+			}
+			linked, loose, indexErr := r.addToIndex(log.IntoContext(ctx, logger), req.Namespace, resourceId, translatedAuthConfigs[host], []string{host})
+			linkedHosts = append(linkedHosts, linked...)
+			looseHosts = append(looseHosts, loose...)
+			for _, looseHost := range loose {
+				failedHosts = append(failedHosts, api.HostStatus{Host: looseHost, Reason: api.StatusReasonHostTaken, Message: "host already taken by another resource"})
+				r.recordEvent(&authConfig, v1.EventTypeWarning, api.StatusReasonHostTaken, fmt.Sprintf("host %s already taken by another resource", looseHost))
+			}
+			if err = indexErr; err != nil {
+				failedHosts = append(failedHosts, api.HostStatus{Host: host, Reason: api.StatusReasonCachingError, Message: err.Error()})
+				break
+			}
+		}
 
 		if len(looseHosts) > 0 {
-			r.StatusReport.Set(resourceId, api.StatusReasonHostsNotLinked, "one or more hosts are not linked to the resource", linkedHosts)
+			r.StatusReport.Set(resourceId, api.StatusReasonHostsNotLinked, "one or more hosts are not linked to the resource", linkedHosts, failedHosts...)
+			reportReconciled = false
+		}
+
+		if len(translationErrs) > 0 {
+			r.StatusReport.Set(resourceId, failedHosts[0].Reason, strings.Join(translationErrs, "; "), linkedHosts, failedHosts...)
 			reportReconciled = false
 		}
 
 		if err != nil {
-			r.StatusReport.Set(resourceId, api.StatusReasonCachingError, err.Error(), linkedHosts)
+			r.StatusReport.Set(resourceId, api.StatusReasonCachingError, err.Error(), linkedHosts, failedHosts...)
 			return ctrl.Result{}, err
 		}
+
+		if len(translationErrs) > 0 {
+			return ctrl.Result{}, goerrors.New(strings.Join(translationErrs, "; "))
+		}
+
+		// the authconfig is served anyway, with the discovery retried at request time, but it is only reported as ready once
+		// the issuers are reachable. requeuing relies on the rate limiter of the controller to back off exponentially.
+		var endpoints []string
+		for _, c := range distinctAuthConfigs(nil, translatedAuthConfigs) {
+			endpoints = append(endpoints, utils.SubtractSlice(undiscoveredOIDCEndpoints(c), endpoints)...)
+		}
+		if len(endpoints) > 0 && reportReconciled {
+			message := fmt.Sprintf("failed to discover openid connect configuration: %s", strings.Join(endpoints, ", "))
+			r.StatusReport.Set(resourceId, api.StatusReasonDiscoveryFailed, message, linkedHosts)
+			r.recordEvent(&authConfig, v1.EventTypeWarning, api.StatusReasonDiscoveryFailed, message)
+			return ctrl.Result{Requeue: true}, nil
+		}
 	}
 
 	if len(linkedHosts) > 0 {
@@ -144,16 +273,332 @@ func (r *AuthConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 }
 
 func (r *AuthConfigReconciler) cleanConfigs(resourceId string, ctx context.Context) error {
-	if hosts := r.Index.FindKeys(resourceId); len(hosts) > 0 {
-		// no need to clean for all the hosts as the config should be the same
-		if authConfig := r.Index.Get(hosts[0]); authConfig != nil {
-			return authConfig.Clean(ctx)
+	var errs error
+	sharedConfigCleaned := false
+	for _, host := range r.Index.FindKeys(resourceId) {
+		authConfig := r.Index.Get(host)
+		if authConfig == nil {
+			continue
+		}
+		// no need to clean the shared config for all the hosts as it should be the same; only hosts with overrides have configs of their own
+		if _, overridden := authConfig.Labels["host"]; !overridden {
+			if sharedConfigCleaned {
+				continue
+			}
+			sharedConfigCleaned = true
+		}
+		if err := authConfig.Clean(ctx); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// authConfigWithOverride returns a copy of the AuthConfig with the overrides for a host applied
+func authConfigWithOverride(authConfig *api.AuthConfig, override api.HostOverride) *api.AuthConfig {
+	overridden := authConfig.DeepCopy()
+	spec := &overridden.Spec
+	override = *override.DeepCopy()
+
+	spec.Identity = overrideByName(spec.Identity, override.Identity, func(c *api.Identity) string { return c.Name })
+	spec.Metadata = overrideByName(spec.Metadata, override.Metadata, func(c *api.Metadata) string { return c.Name })
+	spec.Authorization = overrideByName(spec.Authorization, override.Authorization, func(c *api.Authorization) string { return c.Name })
+	spec.Response = overrideByName(spec.Response, override.Response, func(c *api.Response) string { return c.Name })
+	if override.DenyWith != nil {
+		spec.DenyWith = override.DenyWith
+	}
+	spec.HostOverrides = nil
+
+	return overridden
+}
+
+// overrideByName replaces the configs of a list with the overrides of same name, keeping the order, and appends the
+// overrides whose names are not in the list
+func overrideByName[T any](configs, overrides []*T, name func(*T) string) []*T {
+	for _, override := range overrides {
+		replaced := false
+		for i, config := range configs {
+			if name(config) == name(override) {
+				configs[i] = override
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			configs = append(configs, override)
+		}
+	}
+	return configs
+}
+
+// inheritClusterAuthConfigs merges into the spec of an authconfig the configs of the cluster authconfigs that select it,
+// in the alphabetical order of the names of the cluster authconfigs. Only cluster-wide instances inherit cluster authconfigs.
+func (r *AuthConfigReconciler) inheritClusterAuthConfigs(ctx context.Context, authConfig *api.AuthConfig) error {
+	if !r.ClusterWide() {
+		return nil
+	}
+
+	clusterAuthConfigList := api.ClusterAuthConfigList{}
+	if err := r.List(ctx, &clusterAuthConfigList); err != nil {
+		return err
+	}
+
+	clusterAuthConfigs := clusterAuthConfigList.Items
+	sort.Slice(clusterAuthConfigs, func(i, j int) bool { return clusterAuthConfigs[i].Name < clusterAuthConfigs[j].Name })
+
+	for i := range clusterAuthConfigs {
+		clusterAuthConfig := &clusterAuthConfigs[i]
+		selected, err := inheritedBy(clusterAuthConfig, authConfig)
+		if err != nil {
+			return fmt.Errorf("clusterauthconfig %s: %v", clusterAuthConfig.Name, err)
+		}
+		if !selected {
+			continue
+		}
+		log.FromContext(ctx).V(1).Info("inheriting configs", "clusterauthconfig", clusterAuthConfig.Name)
+		mergeDefaults(&authConfig.Spec, &clusterAuthConfig.Spec.SharedSpec)
+	}
+
+	return nil
+}
+
+// inheritedBy tells whether the selector of a cluster authconfig matches the labels of an authconfig
+func inheritedBy(clusterAuthConfig *api.ClusterAuthConfig, authConfig *api.AuthConfig) (bool, error) {
+	if clusterAuthConfig.Spec.Selector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(clusterAuthConfig.Spec.Selector)
+	if err != nil {
+		return false, fmt.Errorf("invalid selector: %v", err)
+	}
+	return selector.Matches(labels.Set(authConfig.Labels)), nil
+}
+
+// inheritBaseAuthConfigs merges into the spec of an authconfig the defaults and the overrides of the base authconfigs of
+// the namespace, in the alphabetical order of the names of the base authconfigs, the first prevailing over the next ones.
+// Base authconfigs do not inherit from other base authconfigs.
+func (r *AuthConfigReconciler) inheritBaseAuthConfigs(ctx context.Context, authConfig *api.AuthConfig) error {
+	if isBaseAuthConfig(authConfig) {
+		return nil
+	}
+
+	baseAuthConfigs, err := r.baseAuthConfigs(ctx, authConfig.Namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, baseAuthConfig := range baseAuthConfigs {
+		if defaults := baseAuthConfig.Spec.Defaults; defaults != nil {
+			log.FromContext(ctx).V(1).Info("inheriting defaults", "base", baseAuthConfig.Name)
+			mergeDefaults(&authConfig.Spec, defaults)
 		}
 	}
+	for i := len(baseAuthConfigs) - 1; i >= 0; i-- {
+		if overrides := baseAuthConfigs[i].Spec.Overrides; overrides != nil {
+			log.FromContext(ctx).V(1).Info("inheriting overrides", "base", baseAuthConfigs[i].Name)
+			mergeOverrides(&authConfig.Spec, overrides)
+		}
+	}
+
 	return nil
 }
 
+// baseAuthConfigs lists the base authconfigs of a namespace watched by the reconciler, sorted by name
+func (r *AuthConfigReconciler) baseAuthConfigs(ctx context.Context, namespace string) ([]api.AuthConfig, error) {
+	authConfigList := api.AuthConfigList{}
+	listOptions := []client.ListOption{client.InNamespace(namespace)}
+	if r.LabelSelector != nil {
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: r.LabelSelector})
+	}
+	if err := r.List(ctx, &authConfigList, listOptions...); err != nil {
+		return nil, err
+	}
+
+	var baseAuthConfigs []api.AuthConfig
+	for _, authConfig := range authConfigList.Items {
+		if isBaseAuthConfig(&authConfig) {
+			baseAuthConfigs = append(baseAuthConfigs, authConfig)
+		}
+	}
+	sort.Slice(baseAuthConfigs, func(i, j int) bool { return baseAuthConfigs[i].Name < baseAuthConfigs[j].Name })
+	return baseAuthConfigs, nil
+}
+
+func isBaseAuthConfig(authConfig *api.AuthConfig) bool {
+	return authConfig.Annotations[api.BaseAuthConfigAnnotation] == "true"
+}
+
+// mergeDefaults merges shared configs into the spec of an authconfig as defaults.
+// The configs of the authconfig prevail over the defaults of same name, as well as the named patterns and each kind of
+// custom denial response; the defaults of new names are listed before the configs of the authconfig.
+func mergeDefaults(spec *api.AuthConfigSpec, defaults *api.SharedSpec) {
+	defaults = defaults.DeepCopy()
+
+	spec.Identity = overrideByName(defaults.Identity, spec.Identity, func(c *api.Identity) string { return c.Name })
+	spec.Metadata = overrideByName(defaults.Metadata, spec.Metadata, func(c *api.Metadata) string { return c.Name })
+	spec.Authorization = overrideByName(defaults.Authorization, spec.Authorization, func(c *api.Authorization) string { return c.Name })
+	spec.Response = overrideByName(defaults.Response, spec.Response, func(c *api.Response) string { return c.Name })
+	spec.Callbacks = overrideByName(defaults.Callbacks, spec.Callbacks, func(c *api.Callback) string { return c.Name })
+
+	for name, expressions := range defaults.Patterns {
+		if _, found := spec.Patterns[name]; found {
+			continue
+		}
+		if spec.Patterns == nil {
+			spec.Patterns = map[string]api.JSONPatternExpressions{}
+		}
+		spec.Patterns[name] = expressions
+	}
+
+	spec.DenyWith = mergeDenyWith(spec.DenyWith, defaults.DenyWith)
+}
+
+// mergeOverrides merges shared configs into the spec of an authconfig as overrides.
+// The overrides prevail over the configs of the authconfig of same name, including the ones of the host overrides, as well
+// as over the named patterns and each kind of custom denial response; the overrides of new names are added to the end of
+// the lists.
+func mergeOverrides(spec *api.AuthConfigSpec, overrides *api.SharedSpec) {
+	overrides = overrides.DeepCopy()
+
+	spec.Identity = overrideByName(spec.Identity, overrides.Identity, func(c *api.Identity) string { return c.Name })
+	spec.Metadata = overrideByName(spec.Metadata, overrides.Metadata, func(c *api.Metadata) string { return c.Name })
+	spec.Authorization = overrideByName(spec.Authorization, overrides.Authorization, func(c *api.Authorization) string { return c.Name })
+	spec.Response = overrideByName(spec.Response, overrides.Response, func(c *api.Response) string { return c.Name })
+	spec.Callbacks = overrideByName(spec.Callbacks, overrides.Callbacks, func(c *api.Callback) string { return c.Name })
+
+	for name, expressions := range overrides.Patterns {
+		if spec.Patterns == nil {
+			spec.Patterns = map[string]api.JSONPatternExpressions{}
+		}
+		spec.Patterns[name] = expressions
+	}
+
+	for host, hostOverride := range spec.HostOverrides {
+		hostOverride.Identity = replaceByName(hostOverride.Identity, overrides.Identity, func(c *api.Identity) string { return c.Name })
+		hostOverride.Metadata = replaceByName(hostOverride.Metadata, overrides.Metadata, func(c *api.Metadata) string { return c.Name })
+		hostOverride.Authorization = replaceByName(hostOverride.Authorization, overrides.Authorization, func(c *api.Authorization) string { return c.Name })
+		hostOverride.Response = replaceByName(hostOverride.Response, overrides.Response, func(c *api.Response) string { return c.Name })
+		if hostOverride.DenyWith != nil {
+			hostOverride.DenyWith = mergeDenyWith(overrides.DenyWith.DeepCopy(), hostOverride.DenyWith)
+		}
+		spec.HostOverrides[host] = hostOverride
+	}
+
+	spec.DenyWith = mergeDenyWith(overrides.DenyWith, spec.DenyWith)
+}
+
+// replaceByName replaces the configs of a list with the replacements of same name, keeping the order, and leaves out the
+// replacements whose names are not in the list
+func replaceByName[T any](configs, replacements []*T, name func(*T) string) []*T {
+	for i, config := range configs {
+		for _, replacement := range replacements {
+			if name(config) == name(replacement) {
+				configs[i] = replacement
+				break
+			}
+		}
+	}
+	return configs
+}
+
+// mergeDenyWith fills the kinds of custom denial responses (unauthenticated, unauthorized, unmatched) not set in a denyWith
+// with the ones of another
+func mergeDenyWith(denyWith, other *api.DenyWith) *api.DenyWith {
+	if other == nil {
+		return denyWith
+	}
+	if denyWith == nil {
+		return other
+	}
+	if denyWith.Unauthenticated == nil {
+		denyWith.Unauthenticated = other.Unauthenticated
+	}
+	if denyWith.Unauthorized == nil {
+		denyWith.Unauthorized = other.Unauthorized
+	}
+	if denyWith.Unmatched == nil {
+		denyWith.Unmatched = other.Unmatched
+	}
+	return denyWith
+}
+
+// distinctAuthConfigs returns the configs of the hosts, each one only once, along with an optional additional config
+func distinctAuthConfigs(additional *evaluators.AuthConfig, byHost map[string]*evaluators.AuthConfig) []*evaluators.AuthConfig {
+	configs := []*evaluators.AuthConfig{}
+	seen := map[*evaluators.AuthConfig]bool{}
+	if additional != nil {
+		configs = append(configs, additional)
+		seen[additional] = true
+	}
+	for _, c := range byHost {
+		if !seen[c] {
+			configs = append(configs, c)
+			seen[c] = true
+		}
+	}
+	return configs
+}
+
+func sharedConfigUsed(shared *evaluators.AuthConfig, byHost map[string]*evaluators.AuthConfig) bool {
+	for _, c := range byHost {
+		if c == shared {
+			return true
+		}
+	}
+	return false
+}
+
+// reportInvalidResource reports an authconfig that failed to be translated, in the status of the resource and in a
+// Kubernetes event
+func (r *AuthConfigReconciler) reportInvalidResource(authConfig *api.AuthConfig, resourceId, message string, err error) {
+	r.StatusReport.Set(resourceId, r.invalidResourceReason(authConfig, message, err), message, []string{})
+}
+
+// invalidResourceReason returns the reason of the status of an authconfig that failed to be translated, after
+// recording the failure in a Kubernetes event
+func (r *AuthConfigReconciler) invalidResourceReason(authConfig *api.AuthConfig, message string, err error) string {
+	reason := api.StatusReasonInvalidResource
+
+	var policyErr *authorization_evaluators.PolicyCompilationError
+	if goerrors.As(err, &policyErr) {
+		reason = api.StatusReasonPolicyCompilationFailed
+	} else if isSecretNotFound(err) {
+		reason = api.StatusReasonSecretNotFound
+	}
+
+	r.recordEvent(authConfig, v1.EventTypeWarning, reason, message)
+
+	return reason
+}
+
+// recordEvent records a Kubernetes event about an authconfig, if the reconciler has an event recorder
+func (r *AuthConfigReconciler) recordEvent(authConfig *api.AuthConfig, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(authConfig, eventType, reason, message)
+	}
+}
+
+// isSecretNotFound tells whether an error is due to a Kubernetes Secret that does not exist
+func isSecretNotFound(err error) bool {
+	var statusErr *errors.StatusError
+	if !goerrors.As(err, &statusErr) || !errors.IsNotFound(statusErr) {
+		return false
+	}
+	details := statusErr.Status().Details
+	return details != nil && details.Kind == "secrets"
+}
+
 func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConfig *api.AuthConfig) (*evaluators.AuthConfig, error) {
+	if dups := findDuplicateNames(authConfig.Spec); len(dups) > 0 {
+		return nil, fmt.Errorf("duplicate names in %s: %s", dups[0].path, strings.Join(dups[0].names, ", "))
+	}
+	if refs := undefinedPatternRefs(authConfig); len(refs) > 0 {
+		return nil, fmt.Errorf("named pattern not found: %s", strings.Join(refs, ", "))
+	}
+	if err := checkPatternRegexes(authConfig); err != nil {
+		return nil, err
+	}
+
 	var ctxWithLogger context.Context
 
 	identityConfigs := make([]evaluators.IdentityConfig, 0)
@@ -189,6 +634,42 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			Metrics:            identity.Metrics,
 		}
 
+		if claimMappings := identity.ClaimMappings; claimMappings != nil {
+			translatedIdentity.ClaimMappings = &evaluators.IdentityClaimMappings{
+				Username: claimMappings.Username,
+				Groups:   claimMappings.Groups,
+				Email:    claimMappings.Email,
+				Tenant:   claimMappings.Tenant,
+			}
+		}
+
+		if rev := identity.Revocation; rev != nil {
+			var selector labels.Selector
+			if rev.Selector != nil {
+				var err error
+				if selector, err = metav1.LabelSelectorAsSelector(rev.Selector); err != nil {
+					return nil, err
+				}
+			}
+			translatedIdentity.Revocation = revocation.NewList(selector, authConfig.Namespace, rev.Endpoint, r.Client, ctxWithLogger)
+		}
+
+		if replayProtection := identity.ReplayProtection; replayProtection != nil {
+			selector := replayProtection.Selector
+			if selector == "" {
+				selector = "jti"
+			}
+			translatedIdentity.ReplayProtection = replay.NewProtection(selector, replayProtection.TTL, fmt.Sprintf("%s/%s/%s", authConfig.Namespace, authConfig.Name, identity.Name))
+		}
+
+		if challenge := identity.Challenge; challenge != nil {
+			translatedIdentity.Challenge = &evaluators.IdentityChallenge{
+				Scheme:   challenge.Scheme,
+				Realm:    challenge.Realm,
+				Disabled: challenge.Disabled,
+			}
+		}
+
 		if identity.Cache != nil {
 			ttl := identity.Cache.TTL
 			if ttl == 0 {
@@ -226,18 +707,88 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 		// oidc
 		case api.IdentityOidc:
 			translatedIdentity.OIDC = identity_evaluators.NewOIDC(identity.Oidc.Endpoint, authCred, identity.Oidc.TTL, ctxWithLogger)
+			translatedIdentity.OIDC.Audiences = identity.Oidc.Audiences
+			translatedIdentity.OIDC.AllowedIssuers = identity.Oidc.AllowedIssuers
+			if identity.Oidc.DPoP != nil {
+				translatedIdentity.OIDC.DPoP = identity_evaluators.NewDPoP(identity.Oidc.DPoP.MaxAge)
+			}
+			if introspection := identity.Oidc.Introspection; introspection != nil {
+				secret := &v1.Secret{}
+				if err := r.Client.Get(ctx, types.NamespacedName{
+					Namespace: authConfig.Namespace,
+					Name:      introspection.Credentials.Name},
+					secret); err != nil {
+					return nil, err
+				}
+				translatedIdentity.OIDC.Introspection = identity_evaluators.NewOAuth2Identity(
+					introspection.TokenIntrospectionUrl,
+					introspection.TokenTypeHint,
+					string(secret.Data["clientID"]),
+					string(secret.Data["clientSecret"]),
+					authCred,
+				)
+			}
+
+		// jwt
+		case api.IdentityJWT:
+			jwks, err := r.fetchJWKS(ctx, authConfig.Namespace, identity.JWT)
+			if err != nil {
+				return nil, err
+			}
+			if translatedIdentity.JWT, err = identity_evaluators.NewJWTIdentity(jwks, authCred); err != nil {
+				return nil, err
+			}
+			translatedIdentity.JWT.Audiences = identity.JWT.Audiences
+			translatedIdentity.JWT.AllowedIssuers = identity.JWT.AllowedIssuers
+
+		// spiffe
+		case api.IdentitySPIFFE:
+			var bundle []byte
+			var err error
+			if ref := identity.SPIFFE.TrustBundleRef; ref != nil {
+				if bundle, err = r.fetchReferencedKey(ctx, authConfig.Namespace, ref); err != nil {
+					return nil, err
+				}
+			}
+			if translatedIdentity.SPIFFE, err = identity_evaluators.NewSPIFFEIdentity(identity.SPIFFE.TrustDomain, bundle, identity.SPIFFE.TrustBundleEndpoint, identity.SPIFFE.TTL, identity.SPIFFE.Audiences, authCred, ctxWithLogger); err != nil {
+				return nil, err
+			}
+
+		// saml
+		case api.IdentitySAML:
+			var metadata []byte
+			var err error
+			if ref := identity.SAML.IdPMetadataRef; ref != nil {
+				if metadata, err = r.fetchReferencedKey(ctx, authConfig.Namespace, ref); err != nil {
+					return nil, err
+				}
+			}
+			if translatedIdentity.SAML, err = identity_evaluators.NewSAMLIdentity(metadata, identity.SAML.IdPMetadataUrl, identity.SAML.TTL, identity.SAML.Audiences, identity.SAML.MaxClockSkew, authCred, ctxWithLogger); err != nil {
+				return nil, err
+			}
 
 		// apiKey
 		case api.IdentityApiKey:
 			namespace := authConfig.Namespace
+			if ns := identity.APIKey.Namespace; ns != "" && ns != namespace {
+				if !r.AllowCrossNamespaceAPIKeys {
+					return nil, fmt.Errorf("api key secrets in other namespaces are not allowed: %s", ns)
+				}
+				namespace = ns
+			}
 			if identity.APIKey.AllNamespaces && r.ClusterWide() {
-				namespace = ""
+				if r.canListSecretsInAllNamespaces(ctx) {
+					namespace = ""
+				} else {
+					namespace = authConfig.Namespace
+					log.FromContext(ctx).Info("not allowed to list secrets in all namespaces, looking for api key secrets only in the namespace of the authconfig", "identity", identity.Name)
+				}
 			}
 			selector, err := metav1.LabelSelectorAsSelector(identity.APIKey.Selector)
 			if err != nil {
 				return nil, err
 			}
-			translatedIdentity.APIKey = identity_evaluators.NewApiKeyIdentity(identity.Name, selector, namespace, authCred, r.Client, ctxWithLogger)
+			translatedIdentity.APIKey = identity_evaluators.NewApiKeyIdentity(identity.Name, selector, namespace, identity.APIKey.KeyHashing, authCred, r.Client, ctxWithLogger)
 
 		// MTLS
 		case api.IdentityMTLS:
@@ -251,6 +802,30 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			}
 			translatedIdentity.MTLS = identity_evaluators.NewMTLSIdentity(identity.Name, selector, namespace, r.Client, ctxWithLogger)
 
+		// hmac
+		case api.IdentityHMAC:
+			namespace := authConfig.Namespace
+			if identity.HMAC.AllNamespaces && r.ClusterWide() {
+				namespace = ""
+			}
+			selector, err := metav1.LabelSelectorAsSelector(identity.HMAC.Selector)
+			if err != nil {
+				return nil, err
+			}
+			translatedIdentity.HMAC = identity_evaluators.NewHMACIdentity(identity.Name, selector, namespace, identity.HMAC.Algorithm, identity.HMAC.SignatureHeader, identity.HMAC.SignedComponents, identity.HMAC.MaxClockSkew, r.Client, ctxWithLogger)
+
+		// aws sigv4
+		case api.IdentityAWSSigV4:
+			namespace := authConfig.Namespace
+			if identity.AWSSigV4.AllNamespaces && r.ClusterWide() {
+				namespace = ""
+			}
+			selector, err := metav1.LabelSelectorAsSelector(identity.AWSSigV4.Selector)
+			if err != nil {
+				return nil, err
+			}
+			translatedIdentity.AWSSigV4 = identity_evaluators.NewAWSSigV4Identity(identity.Name, selector, namespace, identity.AWSSigV4.Region, identity.AWSSigV4.Service, identity.AWSSigV4.MaxClockSkew, identity.AWSSigV4.AllowUnsignedPayload, r.Client, ctxWithLogger)
+
 		// kubernetes auth
 		case api.IdentityKubernetesAuth:
 			if k8sAuthConfig, err := identity_evaluators.NewKubernetesAuthIdentity(authCred, identity.KubernetesAuth.Audiences); err != nil {
@@ -265,6 +840,21 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 		case api.IdentityAnonymous:
 			translatedIdentity.Noop = &identity_evaluators.Noop{AuthCredentials: authCred}
 
+		case api.IdentityCustom:
+			ev, err := custom_evaluators.New(custom_evaluators.Identity, identity.Custom.Name, identity.Custom.Settings.Raw, authCred, ctxWithLogger)
+			if err != nil {
+				return nil, err
+			}
+			translatedIdentity.Custom = ev
+
+		case api.IdentityExternal:
+			ev, err := r.buildExternalEvaluator(ctx, authConfig, identity.Name, identity.External)
+			if err != nil {
+				return nil, err
+			}
+			ev.AuthCredentials = authCred
+			translatedIdentity.External = ev
+
 		case api.TypeUnknown:
 			return nil, fmt.Errorf("unknown identity type %v", identity)
 		}
@@ -281,6 +871,7 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			Priority:   metadata.Priority,
 			Conditions: buildJSONPatternExpressions(authConfig, metadata.Conditions),
 			Metrics:    metadata.Metrics,
+			Required:   metadata.Required,
 		}
 
 		if metadata.Cache != nil {
@@ -312,17 +903,25 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			); err != nil {
 				return nil, err
 			} else {
+				if filter := metadata.UMA.Filter; filter != nil {
+					uma.Filter = metadata_evaluators.UMAResourceFilter{
+						URI:   getJsonFromStaticDynamic(filter.URI),
+						Type:  getJsonFromStaticDynamic(filter.Type),
+						Owner: getJsonFromStaticDynamic(filter.Owner),
+					}
+				}
+				uma.PageSize = metadata.UMA.PageSize
 				translatedMetadata.UMA = uma
 			}
 
 		// user_info
 		case api.MetadataUserinfo:
-			translatedMetadata.UserInfo = &metadata_evaluators.UserInfo{}
-
-			if idConfig, err := findIdentityConfigByName(identityConfigs, metadata.UserInfo.IdentitySource); err != nil {
+			if idConfig, err := evaluators.FindIdentityConfigByName(identityConfigs, metadata.UserInfo.IdentitySource); err != nil {
 				return nil, err
+			} else if idConfig.OIDC == nil {
+				return nil, fmt.Errorf("identity config %v is not of type oidc", idConfig.Name)
 			} else {
-				translatedMetadata.UserInfo.OIDC = idConfig.OIDC
+				translatedMetadata.UserInfo = metadata_evaluators.NewUserInfo(idConfig.OIDC, metadata.UserInfo.TTL)
 			}
 
 		// generic http
@@ -333,6 +932,34 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			}
 			translatedMetadata.GenericHTTP = ev
 
+		case api.MetadataCustom:
+			ev, err := custom_evaluators.New(custom_evaluators.Metadata, metadata.Custom.Name, metadata.Custom.Settings.Raw, nil, log.IntoContext(ctx, log.FromContext(ctx).WithName("metadata")))
+			if err != nil {
+				return nil, err
+			}
+			translatedMetadata.Custom = ev
+
+		case api.MetadataExternal:
+			ev, err := r.buildExternalEvaluator(ctx, authConfig, metadata.Name, metadata.External)
+			if err != nil {
+				return nil, err
+			}
+			translatedMetadata.External = ev
+
+		case api.MetadataSQL:
+			ev, err := r.buildSQLMetadata(ctx, authConfig.Namespace, metadata.SQL)
+			if err != nil {
+				return nil, err
+			}
+			translatedMetadata.SQL = ev
+
+		case api.MetadataGRPC:
+			ev, err := r.buildGRPCMetadata(ctx, authConfig.Namespace, metadata.GRPC)
+			if err != nil {
+				return nil, err
+			}
+			translatedMetadata.GRPC = ev
+
 		case api.TypeUnknown:
 			return nil, fmt.Errorf("unknown metadata type %v", metadata)
 		}
@@ -345,10 +972,11 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 
 	for index, authorization := range authConfig.Spec.Authorization {
 		translatedAuthorization := &evaluators.AuthorizationConfig{
-			Name:       authorization.Name,
-			Priority:   authorization.Priority,
-			Conditions: buildJSONPatternExpressions(authConfig, authorization.Conditions),
-			Metrics:    authorization.Metrics,
+			Name:          authorization.Name,
+			Priority:      authorization.Priority,
+			Conditions:    buildJSONPatternExpressions(authConfig, authorization.Conditions),
+			Metrics:       authorization.Metrics,
+			FailurePolicy: string(authorization.FailurePolicy),
 		}
 
 		if authorization.Cache != nil {
@@ -368,17 +996,10 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			policyName := authConfig.GetNamespace() + "/" + authConfig.GetName() + "/" + authorization.Name
 			opa := authorization.OPA
 			externalRegistry := opa.ExternalRegistry
-			secret := &v1.Secret{}
-			var sharedSecret string
 
-			if externalRegistry.SharedSecret != nil {
-				if err := r.Client.Get(ctx, types.NamespacedName{
-					Namespace: authConfig.Namespace,
-					Name:      externalRegistry.SharedSecret.Name},
-					secret); err != nil {
-					return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
-				}
-				sharedSecret = string(secret.Data[externalRegistry.SharedSecret.Key])
+			sharedSecret, err := r.fetchSecretKey(ctx, authConfig.Namespace, externalRegistry.SharedSecret)
+			if err != nil {
+				return nil, err
 			}
 
 			externalSource := &authorization_evaluators.OPAExternalSource{
@@ -388,8 +1009,12 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 				TTL:             externalRegistry.TTL,
 			}
 
-			var err error
-			translatedAuthorization.OPA, err = authorization_evaluators.NewOPAAuthorization(policyName, opa.InlineRego, externalSource, opa.AllValues, index, ctxWithLogger)
+			libraries, err := r.fetchRegoLibraries(ctx, authConfig.Namespace, opa.Libraries)
+			if err != nil {
+				return nil, err
+			}
+
+			translatedAuthorization.OPA, err = authorization_evaluators.NewOPAAuthorizationWithLibraries(policyName, opa.InlineRego, libraries, externalSource, opa.AllValues, index, ctxWithLogger)
 			if err != nil {
 				return nil, err
 			}
@@ -400,6 +1025,14 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 				Rules: buildJSONPatternExpressions(authConfig, authorization.JSON.Rules),
 			}
 
+		// cel
+		case api.AuthorizationCEL:
+			var err error
+			translatedAuthorization.CEL, err = authorization_evaluators.NewCELAuthorization(authorization.CEL.Expression)
+			if err != nil {
+				return nil, err
+			}
+
 		case api.AuthorizationKubernetesAuthz:
 			user := authorization.KubernetesAuthz.User
 			authorinoUser := json.JSONValue{Static: user.Value, Pattern: user.ValueFrom.AuthJSON}
@@ -426,13 +1059,9 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 		case api.AuthorizationAuthzed:
 			authzed := authorization.Authzed
 
-			secret := &v1.Secret{}
-			var sharedSecret string
-			if secretRef := authzed.SharedSecret; secretRef != nil {
-				if err := r.Client.Get(ctx, types.NamespacedName{Namespace: authConfig.Namespace, Name: secretRef.Name}, secret); err != nil {
-					return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
-				}
-				sharedSecret = string(secret.Data[secretRef.Key])
+			sharedSecret, err := r.fetchSecretKey(ctx, authConfig.Namespace, authzed.SharedSecret)
+			if err != nil {
+				return nil, err
 			}
 
 			translatedAuthzed := &authorization_evaluators.Authzed{
@@ -446,6 +1075,89 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 
 			translatedAuthorization.Authzed = translatedAuthzed
 
+		case api.AuthorizationUMA:
+			uma := authorization.UMA
+
+			secret := &v1.Secret{}
+			if err := r.Client.Get(ctx, types.NamespacedName{
+				Namespace: authConfig.Namespace,
+				Name:      uma.Credentials.Name},
+				secret); err != nil {
+				return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
+			}
+
+			accessToken := auth.NewAuthCredential("", "")
+			if creds := uma.AccessToken; creds != nil {
+				accessToken = auth.NewAuthCredential(creds.KeySelector, string(creds.In))
+			}
+
+			scopes := make([]json.JSONValue, 0, len(uma.Scopes))
+			for i := range uma.Scopes {
+				scopes = append(scopes, *getJsonFromStaticDynamic(&uma.Scopes[i]))
+			}
+
+			var err error
+			translatedAuthorization.UMA, err = authorization_evaluators.NewUMAAuthorization(
+				uma.Endpoint,
+				string(secret.Data["clientID"]),
+				string(secret.Data["clientSecret"]),
+				accessToken,
+				getJsonFromStaticDynamic(uma.ResourceID),
+				scopes,
+			)
+			if err != nil {
+				return nil, err
+			}
+
+		case api.AuthorizationGenericHTTP:
+			authzHttp := authorization.GenericHTTP
+
+			body := authzHttp.Body
+			if body == nil {
+				body = &api.StaticOrDynamicValue{ValueFrom: api.ValueFrom{AuthJSON: "@this"}}
+			}
+			method := api.GenericHTTP_Method("POST")
+
+			ev, err := r.buildGenericHttpEvaluator(ctx, &api.Metadata_GenericHTTP{
+				Endpoint:     authzHttp.Endpoint,
+				Method:       &method,
+				Body:         body,
+				ContentType:  "application/json",
+				Headers:      authzHttp.Headers,
+				SharedSecret: authzHttp.SharedSecret,
+				OAuth2:       authzHttp.OAuth2,
+				Credentials:  authzHttp.Credentials,
+			}, authConfig.Namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			failurePolicy := authorization_evaluators.FailurePolicyDeny
+			if authzHttp.FailurePolicy != "" {
+				failurePolicy = string(authzHttp.FailurePolicy)
+			}
+
+			translatedAuthorization.GenericHTTP = &authorization_evaluators.GenericHttp{
+				GenericHttp:   ev,
+				Rules:         buildJSONPatternExpressions(authConfig, authzHttp.Rules),
+				Timeout:       time.Duration(authzHttp.Timeout) * time.Millisecond,
+				FailurePolicy: failurePolicy,
+			}
+
+		case api.AuthorizationCustom:
+			ev, err := custom_evaluators.New(custom_evaluators.Authorization, authorization.Custom.Name, authorization.Custom.Settings.Raw, nil, ctxWithLogger)
+			if err != nil {
+				return nil, err
+			}
+			translatedAuthorization.Custom = ev
+
+		case api.AuthorizationExternal:
+			ev, err := r.buildExternalEvaluator(ctx, authConfig, authorization.Name, authorization.External)
+			if err != nil {
+				return nil, err
+			}
+			translatedAuthorization.External = ev
+
 		case api.TypeUnknown:
 			return nil, fmt.Errorf("unknown authorization type %v", authorization)
 		}
@@ -465,6 +1177,17 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 			response.Metrics,
 		)
 
+		if cookie := response.Cookie; cookie != nil {
+			translatedResponse.Cookie = &response_evaluators.Cookie{
+				Domain:   cookie.Domain,
+				Path:     cookie.Path,
+				MaxAge:   cookie.TTL,
+				Secure:   cookie.Secure,
+				HttpOnly: cookie.HttpOnly,
+				SameSite: cookie.SameSite,
+			}
+		}
+
 		if response.Cache != nil {
 			ttl := response.Cache.TTL
 			if ttl == 0 {
@@ -541,6 +1264,26 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 
 			translatedResponse.DynamicJSON = response_evaluators.NewDynamicJSONResponse(jsonProperties)
 
+		// rate limit descriptors
+		case api.ResponseRateLimitDescriptors:
+			if translatedResponse.Wrapper != evaluators.ENVOY_DYNAMIC_METADATA_WRAPPER {
+				return nil, fmt.Errorf("rate limit descriptors must be wrapped as %s", evaluators.ENVOY_DYNAMIC_METADATA_WRAPPER)
+			}
+
+			entries := make([]json.JSONProperty, 0)
+
+			for _, entry := range response.RateLimitDescriptors.Entries {
+				entries = append(entries, json.JSONProperty{
+					Name: entry.Name,
+					Value: json.JSONValue{
+						Static:  entry.Value,
+						Pattern: entry.ValueFrom.AuthJSON,
+					},
+				})
+			}
+
+			translatedResponse.RateLimitDescriptors = response_evaluators.NewRateLimitDescriptors(entries)
+
 		case api.TypeUnknown:
 			return nil, fmt.Errorf("unknown response type %v", response)
 		}
@@ -586,10 +1329,34 @@ func (r *AuthConfigReconciler) translateAuthConfig(ctx context.Context, authConf
 
 	// denyWith
 	if denyWith := authConfig.Spec.DenyWith; denyWith != nil {
-		translatedAuthConfig.Unauthenticated = buildAuthorinoDenyWithValues(denyWith.Unauthenticated)
-		translatedAuthConfig.Unauthorized = buildAuthorinoDenyWithValues(denyWith.Unauthorized)
+		var err error
+		if translatedAuthConfig.Unauthenticated, err = buildAuthorinoDenyWithValues(denyWith.Unauthenticated); err != nil {
+			return nil, fmt.Errorf("invalid denyWith.unauthenticated: %w", err)
+		}
+		if translatedAuthConfig.Unauthorized, err = buildAuthorinoDenyWithValues(denyWith.Unauthorized); err != nil {
+			return nil, fmt.Errorf("invalid denyWith.unauthorized: %w", err)
+		}
+		if translatedAuthConfig.Unmatched, err = buildAuthorinoDenyWithValues(denyWith.Unmatched); err != nil {
+			return nil, fmt.Errorf("invalid denyWith.unmatched: %w", err)
+		}
+	}
+
+	// requestBody
+	if requestBody := authConfig.Spec.RequestBody; requestBody != nil {
+		maxSize := requestBody.MaxSize
+		if maxSize == 0 {
+			maxSize = api.RequestBodyDefaultMaxSize
+		}
+		translatedAuthConfig.RequestBody = &evaluators.RequestBody{MaxSize: maxSize}
+	}
+
+	// headersToRemove
+	for _, header := range authConfig.Spec.HeadersToRemove {
+		translatedAuthConfig.HeadersToRemove = append(translatedAuthConfig.HeadersToRemove, strings.ToLower(header))
 	}
 
+	translatedAuthConfig.DryRun = authConfig.Spec.EnforcementMode == api.EnforcementModeDryRun
+
 	return translatedAuthConfig, nil
 }
 
@@ -617,6 +1384,39 @@ func (r *AuthConfigReconciler) addToIndex(ctx context.Context, resourceNamespace
 	return
 }
 
+// requeueAuthConfigsWaitingForHosts enqueues for reconciliation the other authconfigs that declare any of the hosts
+// released by an authconfig, so they can link the hosts they could not link before due to the collision
+func (r *AuthConfigReconciler) requeueAuthConfigsWaitingForHosts(ctx context.Context, resourceId string, hosts []string) {
+	if r.hostsReleased == nil || len(hosts) == 0 {
+		return
+	}
+
+	enqueued := map[string]bool{resourceId: true}
+	for _, host := range hosts {
+		authConfigList := api.AuthConfigList{}
+		if err := r.List(ctx, &authConfigList, client.MatchingFields{authConfigHostsIndexField: host}); err != nil {
+			r.Logger.Error(err, "failed to list authconfigs waiting for released host", "host", host)
+			continue
+		}
+
+		for i := range authConfigList.Items {
+			authConfig := &authConfigList.Items[i]
+			id := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}.String()
+			if enqueued[id] || !utils.SliceContains(authConfig.Spec.Hosts, host) || !Watched(&authConfig.ObjectMeta, r.LabelSelector) {
+				continue
+			}
+			enqueued[id] = true
+
+			r.Logger.V(1).Info("host released", "host", host, "authconfig", id)
+			select {
+			case r.hostsReleased <- event.GenericEvent{Object: authConfig}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
 func (r *AuthConfigReconciler) bootstrapIndex(ctx context.Context) error {
 	r.indexBootstrap.Lock()
 	defer r.indexBootstrap.Unlock()
@@ -678,10 +1478,280 @@ func (r *AuthConfigReconciler) ClusterWide() bool {
 	return r.Namespace == ""
 }
 
+// canListSecretsInAllNamespaces tells whether the service account of Authorino is allowed to list secrets cluster-wide.
+// If the access review cannot be performed, it is assumed to be allowed, and possible permission errors are left to surface when listing.
+// The result of the review is reused for secretsAccessReviewTTL.
+func (r *AuthConfigReconciler) canListSecretsInAllNamespaces(ctx context.Context) bool {
+	r.secretsAccess.Lock()
+	defer r.secretsAccess.Unlock()
+
+	if !r.secretsAccess.reviewedAt.IsZero() && time.Since(r.secretsAccess.reviewedAt) < secretsAccessReviewTTL {
+		return r.secretsAccess.allowed
+	}
+
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "list", Resource: "secrets"},
+		},
+	}
+	if err := r.Client.Create(ctx, review); err != nil {
+		log.FromContext(ctx).V(1).Info("failed to review access to secrets in all namespaces", "reason", err)
+		return true
+	}
+	r.secretsAccess.allowed = review.Status.Allowed
+	r.secretsAccess.reviewedAt = time.Now()
+	return r.secretsAccess.allowed
+}
+
+// fetchJWKS returns the JSON Web Key Set of a jwt identity config, either inline or read from the referenced Secret or ConfigMap
+func (r *AuthConfigReconciler) fetchJWKS(ctx context.Context, namespace string, jwtConfig *api.Identity_JWT) ([]byte, error) {
+	if jwtConfig.JWKSRef == nil {
+		return []byte(jwtConfig.JWKS), nil
+	}
+	return r.fetchReferencedKey(ctx, namespace, jwtConfig.JWKSRef)
+}
+
+func (r *AuthConfigReconciler) buildExternalEvaluator(ctx context.Context, authConfig *api.AuthConfig, name string, external *api.ExternalEvaluator) (*external_evaluators.GRPC, error) {
+	sharedSecret, err := r.fetchSecretKey(ctx, authConfig.Namespace, external.SharedSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &external_evaluators.GRPC{
+		Endpoint:     external.Endpoint,
+		Insecure:     external.Insecure,
+		SharedSecret: sharedSecret,
+		Timeout:      time.Duration(external.Timeout) * time.Millisecond,
+		Name:         name,
+		AuthConfig:   authConfig.Namespace + "/" + authConfig.Name,
+		Settings:     external.Settings.Raw,
+	}, nil
+}
+
+func (r *AuthConfigReconciler) buildSQLMetadata(ctx context.Context, namespace string, sql *api.Metadata_SQL) (*metadata_evaluators.SQL, error) {
+	if !metadata_evaluators.SQLDriverSupported(string(sql.Driver)) {
+		return nil, fmt.Errorf("unsupported sql driver: %q", sql.Driver)
+	}
+	if sql.DSN == nil {
+		return nil, fmt.Errorf("missing dsnRef")
+	}
+	dsn, err := r.fetchSecretKey(ctx, namespace, sql.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]json.JSONValue, len(sql.Parameters))
+	for i := range sql.Parameters {
+		params[i] = *getJsonFromStaticDynamic(&sql.Parameters[i])
+	}
+
+	return &metadata_evaluators.SQL{
+		Driver:       string(sql.Driver),
+		DSN:          dsn,
+		Query:        sql.Query,
+		Parameters:   params,
+		MaxRows:      sql.MaxRows,
+		MaxOpenConns: sql.MaxOpenConns,
+		Timeout:      time.Duration(sql.Timeout) * time.Millisecond,
+	}, nil
+}
+
+func (r *AuthConfigReconciler) buildGRPCMetadata(ctx context.Context, namespace string, grpc *api.Metadata_GRPC) (*metadata_evaluators.GRPC, error) {
+	sharedSecret, err := r.fetchSecretKey(ctx, namespace, grpc.SharedSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var body *json.JSONValue
+	if b := grpc.Body; b != nil {
+		body = &json.JSONValue{Static: b.Value, Pattern: b.ValueFrom.AuthJSON}
+	}
+
+	params := make([]json.JSONProperty, 0, len(grpc.Parameters))
+	for _, param := range grpc.Parameters {
+		params = append(params, json.JSONProperty{
+			Name: param.Name,
+			Value: json.JSONValue{
+				Static:  param.Value,
+				Pattern: param.ValueFrom.AuthJSON,
+			},
+		})
+	}
+
+	headers := make([]json.JSONProperty, 0, len(grpc.Headers))
+	for _, header := range grpc.Headers {
+		headers = append(headers, json.JSONProperty{
+			Name: header.Name,
+			Value: json.JSONValue{
+				Static:  header.Value,
+				Pattern: header.ValueFrom.AuthJSON,
+			},
+		})
+	}
+
+	return &metadata_evaluators.GRPC{
+		Endpoint:     grpc.Endpoint,
+		Method:       grpc.Method,
+		Insecure:     grpc.Insecure,
+		SharedSecret: sharedSecret,
+		Body:         body,
+		Parameters:   params,
+		Headers:      headers,
+		Timeout:      time.Duration(grpc.Timeout) * time.Millisecond,
+	}, nil
+}
+
+// fetchRegoLibraries reads the Rego modules stored in the referenced ConfigMaps, by "<configmap>/<key>"
+func (r *AuthConfigReconciler) fetchRegoLibraries(ctx context.Context, namespace string, refs []v1.LocalObjectReference) (map[string]string, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	libraries := make(map[string]string)
+	for _, ref := range refs {
+		configMap := &v1.ConfigMap{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, configMap); err != nil {
+			return nil, err
+		}
+		for key, module := range configMap.Data {
+			libraries[ref.Name+"/"+key] = module
+		}
+	}
+	return libraries, nil
+}
+
+// fetchSecretKey reads the value of a key of a Secret in the namespace, e.g. a shared secret or a client secret used by
+// an evaluator to authenticate with an external service. It returns an empty string if the reference is nil, and an
+// error if the Secret or the key does not exist, so the evaluator is never set with empty credentials by mistake.
+func (r *AuthConfigReconciler) fetchSecretKey(ctx context.Context, namespace string, ref *api.SecretKeyReference) (string, error) {
+	if ref == nil {
+		return "", nil
+	}
+	secret := &v1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return "", err
+	}
+	value, found := secret.Data[ref.Key]
+	if !found {
+		return "", fmt.Errorf("missing key %s in secret %s/%s", ref.Key, namespace, ref.Name)
+	}
+	return string(value), nil
+}
+
+// fetchReferencedKey reads the value of a key of a Secret or ConfigMap
+func (r *AuthConfigReconciler) fetchReferencedKey(ctx context.Context, namespace string, ref *api.JWKSReference) ([]byte, error) {
+	objectKey := types.NamespacedName{Namespace: namespace, Name: ref.Name}
+
+	if ref.Kind == "ConfigMap" {
+		configMap := &v1.ConfigMap{}
+		if err := r.Client.Get(ctx, objectKey, configMap); err != nil {
+			return nil, err
+		}
+		if value, found := configMap.Data[ref.Key]; found {
+			return []byte(value), nil
+		}
+		return configMap.BinaryData[ref.Key], nil
+	}
+
+	secret := &v1.Secret{}
+	if err := r.Client.Get(ctx, objectKey, secret); err != nil {
+		return nil, err
+	}
+	return secret.Data[ref.Key], nil
+}
+
 func (r *AuthConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	// index of authconfigs by host, to look up the ones waiting for a host when it is released by another authconfig
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &api.AuthConfig{}, authConfigHostsIndexField, func(obj client.Object) []string {
+		return obj.(*api.AuthConfig).Spec.Hosts
+	}); err != nil {
+		return err
+	}
+
+	r.hostsReleased = make(chan event.GenericEvent, 100)
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&api.AuthConfig{}, builder.WithPredicates(LabelSelectorPredicate(r.LabelSelector))).
-		Complete(r)
+		Watches(&source.Channel{Source: r.hostsReleased}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &api.AuthConfig{}}, handler.EnqueueRequestsFromMapFunc(r.authConfigsInheritingFromBase), builder.WithPredicates(LabelSelectorPredicate(r.LabelSelector)))
+
+	// cluster authconfigs are cluster-scoped, thus only watched by cluster-wide instances
+	if r.ClusterWide() {
+		controllerBuilder = controllerBuilder.Watches(&source.Kind{Type: &api.ClusterAuthConfig{}}, handler.EnqueueRequestsFromMapFunc(r.authConfigsInheriting))
+	}
+
+	controllerBuilder, err := watchTargetRefs(mgr, controllerBuilder, r.authConfigsTargeting)
+	if err != nil {
+		return err
+	}
+
+	return controllerBuilder.Complete(r)
+}
+
+// authConfigsTargeting maps a gateway api resource to the requests to reconcile the authconfigs whose hostnames depend on it
+func (r *AuthConfigReconciler) authConfigsTargeting(obj client.Object) []reconcile.Request {
+	requests, err := findAuthConfigsTargeting(context.Background(), r.Client, r.LabelSelector, obj)
+	if err != nil {
+		r.Logger.Error(err, "failed to list authconfigs targeting resource", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "resource", obj.GetNamespace()+"/"+obj.GetName())
+	}
+	return requests
+}
+
+// authConfigsInheritingFromBase maps a base authconfig to the requests to reconcile the other authconfigs of the namespace
+func (r *AuthConfigReconciler) authConfigsInheritingFromBase(obj client.Object) []reconcile.Request {
+	baseAuthConfig, ok := obj.(*api.AuthConfig)
+	if !ok || !isBaseAuthConfig(baseAuthConfig) {
+		return nil
+	}
+
+	authConfigList := api.AuthConfigList{}
+	listOptions := []client.ListOption{client.InNamespace(baseAuthConfig.Namespace)}
+	if r.LabelSelector != nil {
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: r.LabelSelector})
+	}
+	if err := r.List(context.Background(), &authConfigList, listOptions...); err != nil {
+		r.Logger.Error(err, "failed to list authconfigs inheriting from base", "base", baseAuthConfig.Namespace+"/"+baseAuthConfig.Name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range authConfigList.Items {
+		authConfig := &authConfigList.Items[i]
+		if isBaseAuthConfig(authConfig) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+	}
+	return requests
+}
+
+// authConfigsInheriting maps a cluster authconfig to the requests to reconcile the authconfigs that inherit its configs
+func (r *AuthConfigReconciler) authConfigsInheriting(obj client.Object) []reconcile.Request {
+	clusterAuthConfig, ok := obj.(*api.ClusterAuthConfig)
+	if !ok {
+		return nil
+	}
+
+	authConfigList := api.AuthConfigList{}
+	listOptions := []client.ListOption{}
+	if r.LabelSelector != nil {
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: r.LabelSelector})
+	}
+	if err := r.List(context.Background(), &authConfigList, listOptions...); err != nil {
+		r.Logger.Error(err, "failed to list authconfigs inheriting configs", "clusterauthconfig", clusterAuthConfig.Name)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range authConfigList.Items {
+		authConfig := &authConfigList.Items[i]
+		// an invalid selector is reported in the status of all the authconfigs
+		if selected, err := inheritedBy(clusterAuthConfig, authConfig); !selected && err == nil {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+	}
+	return requests
 }
 
 func (r *AuthConfigReconciler) Ready(includes, _ []string, _ bool) error {
@@ -702,26 +1772,22 @@ func (r *AuthConfigReconciler) Ready(includes, _ []string, _ bool) error {
 
 func (r *AuthConfigReconciler) buildGenericHttpEvaluator(ctx context.Context, http *api.Metadata_GenericHTTP, namespace string) (*metadata_evaluators.GenericHttp, error) {
 	var sharedSecret string
-	if sharedSecretRef := http.SharedSecret; sharedSecretRef != nil {
-		secret := &v1.Secret{}
-		if sharedSecretRef != nil {
-			if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: sharedSecretRef.Name}, secret); err != nil {
-				return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
-			}
-			sharedSecret = string(secret.Data[sharedSecretRef.Key])
-		}
-	}
-
 	var oauth2ClientCredentialsConfig *oauth2.ClientCredentials
 	oauth2TokenForceFetch := false
+
+	// the shared secret is ignored if used together with oauth2
 	if oauth2Config := http.OAuth2; oauth2Config != nil {
-		secret := &v1.Secret{}
-		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: oauth2Config.ClientSecret.Name}, secret); err != nil {
-			return nil, err // TODO: Review this error, perhaps we don't need to return an error, just reenqueue.
+		clientSecret, err := r.fetchSecretKey(ctx, namespace, &oauth2Config.ClientSecret)
+		if err != nil {
+			return nil, err
 		}
-		clientSecret := string(secret.Data[oauth2Config.ClientSecret.Key])
-		oauth2ClientCredentialsConfig = oauth2.NewClientCredentialsConfig(oauth2Config.TokenUrl, oauth2Config.ClientId, clientSecret, oauth2Config.Scopes, oauth2Config.ExtraParams)
+		oauth2ClientCredentialsConfig = oauth2.SharedTokenManager.ClientCredentials(oauth2Config.TokenUrl, oauth2Config.ClientId, clientSecret, oauth2Config.Scopes, oauth2Config.ExtraParams)
 		oauth2TokenForceFetch = oauth2Config.Cache != nil && !*oauth2Config.Cache
+	} else {
+		var err error
+		if sharedSecret, err = r.fetchSecretKey(ctx, namespace, http.SharedSecret); err != nil {
+			return nil, err
+		}
 	}
 
 	var body *json.JSONValue
@@ -775,13 +1841,15 @@ func (r *AuthConfigReconciler) buildGenericHttpEvaluator(ctx context.Context, ht
 	return ev, nil
 }
 
-func findIdentityConfigByName(identityConfigs []evaluators.IdentityConfig, name string) (*evaluators.IdentityConfig, error) {
-	for _, id := range identityConfigs {
-		if id.Name == name {
-			return &id, nil
+// undiscoveredOIDCEndpoints returns the endpoints of the oidc identity configs whose openid connect configuration could not be discovered
+func undiscoveredOIDCEndpoints(authConfig *evaluators.AuthConfig) []string {
+	var endpoints []string
+	for _, config := range authConfig.IdentityConfigs {
+		if idConfig, ok := config.(*evaluators.IdentityConfig); ok && idConfig.OIDC != nil && !identity_evaluators.DeferOIDCDiscovery && !idConfig.OIDC.Discovered() {
+			endpoints = append(endpoints, idConfig.OIDC.Endpoint)
 		}
 	}
-	return nil, fmt.Errorf("missing identity config %v", name)
+	return endpoints
 }
 
 func buildJSONPatternExpressions(authConfig *api.AuthConfig, patterns []api.JSONPattern) []json.JSONPatternMatchingRule {
@@ -792,25 +1860,90 @@ func buildJSONPatternExpressions(authConfig *api.AuthConfig, patterns []api.JSON
 
 		if expressionsByRef, found := authConfig.Spec.Patterns[pattern.JSONPatternName]; found {
 			expressionsToAdd = append(expressionsToAdd, expressionsByRef...)
+		} else if len(pattern.Any) > 0 {
+			expressions = append(expressions, json.JSONPatternMatchingRule{Any: buildJSONPatternMatchingRules(pattern.Any)})
+			continue
 		} else {
 			expressionsToAdd = append(expressionsToAdd, pattern.JSONPatternExpression)
 		}
 
-		for _, expression := range expressionsToAdd {
-			expressions = append(expressions, json.JSONPatternMatchingRule{
-				Selector: expression.Selector,
-				Operator: string(expression.Operator),
-				Value:    expression.Value,
-			})
-		}
+		expressions = append(expressions, buildJSONPatternMatchingRules(expressionsToAdd)...)
 	}
 
 	return expressions
 }
 
-func buildAuthorinoDenyWithValues(denyWithSpec *api.DenyWithSpec) *evaluators.DenyWithValues {
+func buildJSONPatternMatchingRules(expressions []api.JSONPatternExpression) []json.JSONPatternMatchingRule {
+	rules := make([]json.JSONPatternMatchingRule, 0, len(expressions))
+	for _, expression := range expressions {
+		rule := json.JSONPatternMatchingRule{
+			Selector: expression.Selector,
+			Operator: string(expression.Operator),
+			Value:    expression.Value,
+		}
+		_ = rule.Compile() // invalid regular expressions are rejected beforehand
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// undefinedPatternRefs returns the names of the patterns referred in the AuthConfig that are not defined in `spec.patterns`
+func undefinedPatternRefs(authConfig *api.AuthConfig) []string {
+	refs := []string{}
+	for _, list := range jsonPatternsOf(authConfig) {
+		for _, pattern := range list {
+			name := pattern.JSONPatternName
+			if _, found := authConfig.Spec.Patterns[name]; name != "" && !found && !utils.SliceContains(refs, name) {
+				refs = append(refs, name)
+			}
+		}
+	}
+	return refs
+}
+
+// checkPatternRegexes checks the regular expressions of all the JSON patterns of the AuthConfig with the `matches` operator
+func checkPatternRegexes(authConfig *api.AuthConfig) error {
+	for _, list := range jsonPatternsOf(authConfig) {
+		for _, rule := range buildJSONPatternExpressions(authConfig, list) {
+			if err := rule.Compile(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonPatternsOf returns all the lists of JSON patterns of the AuthConfig, i.e. conditions and JSON pattern-matching rules
+func jsonPatternsOf(authConfig *api.AuthConfig) [][]api.JSONPattern {
+	spec := authConfig.Spec
+	patterns := [][]api.JSONPattern{spec.Conditions}
+	for _, identity := range spec.Identity {
+		patterns = append(patterns, identity.Conditions)
+	}
+	for _, metadata := range spec.Metadata {
+		patterns = append(patterns, metadata.Conditions)
+	}
+	for _, authorization := range spec.Authorization {
+		patterns = append(patterns, authorization.Conditions)
+		if authorization.JSON != nil {
+			patterns = append(patterns, authorization.JSON.Rules)
+		}
+		if authorization.GenericHTTP != nil {
+			patterns = append(patterns, authorization.GenericHTTP.Rules)
+		}
+	}
+	for _, response := range spec.Response {
+		patterns = append(patterns, response.Conditions)
+	}
+	for _, callback := range spec.Callbacks {
+		patterns = append(patterns, callback.Conditions)
+	}
+	return patterns
+}
+
+func buildAuthorinoDenyWithValues(denyWithSpec *api.DenyWithSpec) (*evaluators.DenyWithValues, error) {
 	if denyWithSpec == nil {
-		return nil
+		return nil, nil
 	}
 
 	headers := make([]json.JSONProperty, 0, len(denyWithSpec.Headers))
@@ -818,12 +1951,36 @@ func buildAuthorinoDenyWithValues(denyWithSpec *api.DenyWithSpec) *evaluators.De
 		headers = append(headers, json.JSONProperty{Name: header.Name, Value: json.JSONValue{Static: header.Value, Pattern: header.ValueFrom.AuthJSON}})
 	}
 
-	return &evaluators.DenyWithValues{
-		Code:    int32(denyWithSpec.Code),
-		Message: getJsonFromStaticDynamic(denyWithSpec.Message),
-		Headers: headers,
-		Body:    getJsonFromStaticDynamic(denyWithSpec.Body),
+	var bodyTemplate *evaluators.DenyWithTemplate
+	if denyWithSpec.BodyTemplate != "" {
+		var err error
+		if bodyTemplate, err = evaluators.NewDenyWithTemplate(denyWithSpec.BodyTemplate, denyWithSpec.ContentType); err != nil {
+			return nil, err
+		}
 	}
+
+	var redirect *evaluators.DenyWithRedirect
+	if denyWithSpec.Redirect != nil {
+		location, err := evaluators.NewDenyWithTemplate(denyWithSpec.Redirect.Location, "")
+		if err != nil {
+			return nil, err
+		}
+		code := int32(denyWithSpec.Redirect.Code)
+		if code == 0 {
+			code = http.StatusFound
+		}
+		redirect = &evaluators.DenyWithRedirect{Code: code, Location: location}
+	}
+
+	return &evaluators.DenyWithValues{
+		Code:         int32(denyWithSpec.Code),
+		Message:      getJsonFromStaticDynamic(denyWithSpec.Message),
+		Headers:      headers,
+		Body:         getJsonFromStaticDynamic(denyWithSpec.Body),
+		BodyTemplate: bodyTemplate,
+		ContentType:  denyWithSpec.ContentType,
+		Redirect:     redirect,
+	}, nil
 }
 
 func getJsonFromStaticDynamic(value *api.StaticOrDynamicValue) *json.JSONValue {
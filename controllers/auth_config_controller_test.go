@@ -5,9 +5,15 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators"
+	authorization_evaluators "github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	custom_evaluators "github.com/kuadrant/authorino/pkg/evaluators/custom"
+	identity_evaluators "github.com/kuadrant/authorino/pkg/evaluators/identity"
+	response_evaluators "github.com/kuadrant/authorino/pkg/evaluators/response"
 	"github.com/kuadrant/authorino/pkg/httptest"
 	"github.com/kuadrant/authorino/pkg/index"
 	mock_index "github.com/kuadrant/authorino/pkg/index/mocks"
@@ -15,14 +21,18 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"gotest.tools/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -160,11 +170,299 @@ func TestMissingRequiredSecret(t *testing.T) {
 	authConfig := newTestAuthConfig(map[string]string{})
 	client := newTestK8sClient(&authConfig)
 	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+	recorder := record.NewFakeRecorder(1)
+	reconciler.Recorder = recorder
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
 
-	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}})
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
 
 	assert.Check(t, errors.IsNotFound(err))    // Error should be "secret" not found.
 	assert.DeepEqual(t, result, ctrl.Result{}) // Result should be empty
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonSecretNotFound)
+	assert.Equal(t, <-recorder.Events, "Warning SecretNotFound "+err.Error())
+}
+
+func TestReconcileAuthConfigWithUndiscoveredOIDC(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Identity[0].Oidc.Endpoint = "http://127.0.0.1:9001/auth/realms/unknown"
+	authConfig.Spec.Metadata = nil
+	client := newTestK8sClient(&authConfig)
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+	recorder := record.NewFakeRecorder(1)
+	reconciler.Recorder = recorder
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, ctrl.Result{Requeue: true})
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonDiscoveryFailed)
+	assert.Equal(t, <-recorder.Events, "Warning DiscoveryFailed "+status.Message)
+	assert.Check(t, authConfigIndex.Get("echo-api") != nil) // served anyway
+
+	identity_evaluators.DeferOIDCDiscovery = true
+	defer func() { identity_evaluators.DeferOIDCDiscovery = false }()
+
+	result, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, ctrl.Result{})
+	status, _ = reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonReconciled)
+}
+
+func TestReconcileAuthConfigWithInvalidPolicy(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Identity = nil
+	authConfig.Spec.Metadata = nil
+	authConfig.Spec.Authorization = []*api.Authorization{{Name: "rego", OPA: &api.Authorization_OPA{InlineRego: "allow {\n  is_admin(input.auth.identity)\n}"}}}
+	client := newTestK8sClient(&authConfig)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+	recorder := record.NewFakeRecorder(1)
+	reconciler.Recorder = recorder
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+
+	expectedMsg := "failed to compile policy authorino/auth-config-1/rego: line 2, column 3: rego_type_error: undefined function is_admin"
+	assert.Error(t, err, expectedMsg)
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonPolicyCompilationFailed)
+	assert.Equal(t, status.Message, expectedMsg)
+	assert.Equal(t, <-recorder.Events, "Warning PolicyCompilationFailed "+expectedMsg)
+}
+
+type accessReviewClientMock struct {
+	client.WithWatch
+	allowed bool
+	reviews int
+}
+
+func (c *accessReviewClientMock) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if review, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+		c.reviews++
+		review.Status.Allowed = c.allowed
+		return nil
+	}
+	return c.WithWatch.Create(ctx, obj, opts...)
+}
+
+func TestTranslateAuthConfigWithApiKeyNamespaces(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Identity: []*api.Identity{
+				{Name: "all-namespaces", APIKey: &api.Identity_APIKey{Selector: &metav1.LabelSelector{}, AllNamespaces: true}},
+				{Name: "other-namespace", APIKey: &api.Identity_APIKey{Selector: &metav1.LabelSelector{}, Namespace: "other"}},
+			},
+		},
+	}
+	apiKeyNamespaces := func(config *evaluators.AuthConfig) []string {
+		var namespaces []string
+		for _, identity := range config.IdentityConfigs {
+			namespaces = append(namespaces, identity.(*evaluators.IdentityConfig).APIKey.Namespace)
+		}
+		return namespaces
+	}
+
+	client := &accessReviewClientMock{WithWatch: newTestK8sClient(), allowed: true}
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+	reconciler.AllowCrossNamespaceAPIKeys = true
+	config, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, apiKeyNamespaces(config), []string{"", "other"})
+
+	// the access review is reused
+	_, err = reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, client.reviews, 1)
+
+	// not allowed to list secrets cluster-wide
+	reconciler = newTestAuthConfigReconciler(&accessReviewClientMock{WithWatch: newTestK8sClient(), allowed: false}, index.NewIndex())
+	reconciler.AllowCrossNamespaceAPIKeys = true
+	config, err = reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, apiKeyNamespaces(config), []string{"authorino", "other"})
+
+	// namespaced instance
+	reconciler.Namespace = "authorino"
+	config, err = reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, apiKeyNamespaces(config), []string{"authorino", "other"})
+
+	// cross-namespace api keys not allowed
+	reconciler.AllowCrossNamespaceAPIKeys = false
+	_, err = reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.Error(t, err, "api key secrets in other namespaces are not allowed: other")
+
+	authConfig.Spec.Identity[1].APIKey.Namespace = "authorino"
+	config, err = reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, apiKeyNamespaces(config), []string{"authorino", "authorino"})
+}
+
+func TestTranslateAuthConfigWithJWKSRefs(t *testing.T) {
+	const jwks = `{"keys":[{"kty":"RSA","kid":"k1","alg":"RS256","use":"sig","n":"sKIc4qQ7nvgVgg6OgWAZr4ov9v8rJqzSZc6uZyEzGBwY-tCNwHJTbAO5mS2ccxoUtDgRNmBw0hTsLdc5Lb3Ljvz7jBIEcRpDh_hn2Rb8VLBRDiE_R5Z6sQQp8qv4ml6NVW0Bvm27yFgdo7TPVjnP8BP1-Q9B7W6V9dAsVpEd1G1khVdpmqW6e3ztSvFmhsm4tZsmxLyDSMfxKqoNUuTUSrEJ-eRpZqG0B6m96oYg-QBmqHBqQtQVsbtx3BX44Dd4v6M0EQHGuvqUgw2GqvsSI5IDlzG-lnWwkdq7mP-ug-JRKNC-p6nv2cBmEXt8ArU8J57phJIvfJe4fVuT9g5IVQ","e":"AQAB"}]}`
+
+	secret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "jwks", Namespace: "authorino"}, Data: map[string][]byte{"jwks.json": []byte(jwks)}}
+	configMap := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "jwks", Namespace: "authorino"}, Data: map[string]string{"jwks.json": jwks}}
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(secret, configMap), index.NewIndex())
+
+	for _, jwtConfig := range []*api.Identity_JWT{
+		{JWKS: jwks},
+		{JWKSRef: &api.JWKSReference{Name: "jwks", Key: "jwks.json"}},
+		{JWKSRef: &api.JWKSReference{Kind: "ConfigMap", Name: "jwks", Key: "jwks.json"}},
+	} {
+		authConfig := &api.AuthConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+			Spec:       api.AuthConfigSpec{Hosts: []string{"echo-api"}, Identity: []*api.Identity{{Name: "jwt", JWT: jwtConfig}}},
+		}
+		config, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+		assert.NilError(t, err)
+		assert.Check(t, config.IdentityConfigs[0].(*evaluators.IdentityConfig).JWT != nil)
+	}
+
+	// missing key
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec:       api.AuthConfigSpec{Hosts: []string{"echo-api"}, Identity: []*api.Identity{{Name: "jwt", JWT: &api.Identity_JWT{JWKSRef: &api.JWKSReference{Name: "jwks", Key: "other"}}}}},
+	}
+	_, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.Error(t, err, "invalid json web key set")
+}
+
+func TestTranslateAuthConfigWithRegoLibraries(t *testing.T) {
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rego-libs", Namespace: "authorino"},
+		Data:       map[string]string{"helpers.rego": "package lib.helpers\nis_admin(user) { user.groups[_] == \"admin\" }"},
+	}
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(configMap), index.NewIndex())
+
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Authorization: []*api.Authorization{{Name: "rego", OPA: &api.Authorization_OPA{
+				InlineRego: "import data.lib.helpers\nallow { helpers.is_admin(input.auth.identity) }",
+				Libraries:  []v1.LocalObjectReference{{Name: "rego-libs"}},
+			}}},
+		},
+	}
+	config, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, config.AuthorizationConfigs[0].(*evaluators.AuthorizationConfig).OPA.Libraries, map[string]string{"rego-libs/helpers.rego": configMap.Data["helpers.rego"]})
+
+	// missing configmap
+	authConfig.Spec.Authorization[0].OPA.Libraries = []v1.LocalObjectReference{{Name: "other"}}
+	_, err = reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.Check(t, err != nil)
+}
+
+func TestTranslateAuthConfigWithRateLimitDescriptors(t *testing.T) {
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(), index.NewIndex())
+
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Response: []*api.Response{{
+				Name:       "rate-limit",
+				Wrapper:    "envoyDynamicMetadata",
+				WrapperKey: "ext_auth_data",
+				RateLimitDescriptors: &api.Response_RateLimitDescriptors{Entries: []api.JsonProperty{
+					{Name: "user_id", ValueFrom: api.ValueFrom{AuthJSON: "auth.identity.sub"}},
+				}},
+			}},
+		},
+	}
+	config, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	responseConfig := config.ResponseConfigs[0].(*evaluators.ResponseConfig)
+	assert.Equal(t, responseConfig.WrapperKey, "ext_auth_data")
+	assert.Equal(t, len(responseConfig.RateLimitDescriptors.Entries), 1)
+	assert.Equal(t, responseConfig.RateLimitDescriptors.Entries[0].Name, "user_id")
+	assert.Equal(t, responseConfig.RateLimitDescriptors.Entries[0].Value.Pattern, "auth.identity.sub")
+
+	authConfig.Spec.Response[0].Wrapper = "httpHeader"
+	_, err = reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.Error(t, err, "rate limit descriptors must be wrapped as envoyDynamicMetadata")
+}
+
+func TestTranslateAuthConfigWithCookies(t *testing.T) {
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(), index.NewIndex())
+
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Response: []*api.Response{{
+				Name:    "session",
+				Wrapper: "httpCookie",
+				Cookie:  &api.Response_Cookie{Domain: "my-app.io", Path: "/", TTL: 300, Secure: true, HttpOnly: true, SameSite: "Strict"},
+				JSON:    &api.Response_DynamicJSON{},
+			}},
+		},
+	}
+	config, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	responseConfig := config.ResponseConfigs[0].(*evaluators.ResponseConfig)
+	assert.Equal(t, responseConfig.Wrapper, "httpCookie")
+	assert.Equal(t, responseConfig.WrapperKey, "session")
+	assert.DeepEqual(t, *responseConfig.Cookie, response_evaluators.Cookie{Domain: "my-app.io", Path: "/", MaxAge: 300, Secure: true, HttpOnly: true, SameSite: "Strict"})
+}
+
+func TestTranslateAuthConfigWithHeadersToRemove(t *testing.T) {
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(), index.NewIndex())
+
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts:           []string{"echo-api"},
+			HeadersToRemove: []string{"Authorization", "X-API-Key"},
+		},
+	}
+	config, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, config.HeadersToRemove, []string{"authorization", "x-api-key"})
+}
+
+func TestTranslateAuthConfigWithEvaluatorMetrics(t *testing.T) {
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(), index.NewIndex())
+
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Identity: []*api.Identity{
+				{Name: "anonymous", Anonymous: &api.Identity_Anonymous{}, Metrics: true},
+				{Name: "other", Anonymous: &api.Identity_Anonymous{}},
+			},
+			Metadata: []*api.Metadata{
+				{Name: "geo", GenericHTTP: &api.Metadata_GenericHTTP{Endpoint: "http://geo.io"}, Metrics: true},
+			},
+			Authorization: []*api.Authorization{
+				{Name: "rules", JSON: &api.Authorization_JSONPatternMatching{}, Metrics: true},
+			},
+			Response: []*api.Response{
+				{Name: "data", JSON: &api.Response_DynamicJSON{}, Metrics: true},
+			},
+			Callbacks: []*api.Callback{
+				{Name: "audit", HTTP: &api.Metadata_GenericHTTP{Endpoint: "http://audit.io"}, Metrics: true},
+			},
+		},
+	}
+	config, err := reconciler.translateAuthConfig(context.TODO(), authConfig)
+	assert.NilError(t, err)
+	assert.Check(t, config.IdentityConfigs[0].(*evaluators.IdentityConfig).MetricsEnabled())
+	assert.Check(t, !config.IdentityConfigs[1].(*evaluators.IdentityConfig).MetricsEnabled())
+	assert.Check(t, config.MetadataConfigs[0].(*evaluators.MetadataConfig).MetricsEnabled())
+	assert.Check(t, config.AuthorizationConfigs[0].(*evaluators.AuthorizationConfig).MetricsEnabled())
+	assert.Check(t, config.ResponseConfigs[0].(*evaluators.ResponseConfig).MetricsEnabled())
+	assert.Check(t, config.CallbackConfigs[0].(*evaluators.CallbackConfig).MetricsEnabled())
 }
 
 func TestAuthConfigNotFound(t *testing.T) {
@@ -211,6 +509,496 @@ func TestRemoveHostFromAuthConfig(t *testing.T) {
 	assert.Check(t, config == nil)
 }
 
+func TestHostOverrides(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Hosts = append(authConfig.Spec.Hosts, "other.io")
+	authConfig.Spec.HostOverrides = map[string]api.HostOverride{
+		"other.io": {
+			Authorization: []*api.Authorization{
+				{
+					Name: "some-extra-rules",
+					JSON: &api.Authorization_JSONPatternMatching{
+						Rules: []api.JSONPattern{{JSONPatternExpression: api.JSONPatternExpression{Selector: "context.identity.role", Operator: "eq", Value: "member"}}},
+					},
+				},
+				{
+					Name: "even-more-rules",
+					JSON: &api.Authorization_JSONPatternMatching{
+						Rules: []api.JSONPattern{{JSONPatternExpression: api.JSONPatternExpression{Selector: "context.identity.email_verified", Operator: "eq", Value: "true"}}},
+					},
+				},
+			},
+			DenyWith: &api.DenyWith{Unauthorized: &api.DenyWithSpec{Code: 302}},
+		},
+	}
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	for i := 0; i < 2; i++ { // reconciling again cleans up the configs of all the hosts
+		_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+		assert.NilError(t, err)
+	}
+
+	config := authConfigIndex.Get("echo-api")
+	assert.Equal(t, len(config.AuthorizationConfigs), 2)
+	assert.Check(t, config.DenyWith.Unauthorized == nil)
+	assert.Equal(t, config.Labels["host"], "")
+
+	config = authConfigIndex.Get("other.io")
+	assert.Equal(t, len(config.AuthorizationConfigs), 3)
+	assert.Equal(t, config.AuthorizationConfigs[1].(*evaluators.AuthorizationConfig).Name, "some-extra-rules")
+	assert.Equal(t, config.AuthorizationConfigs[1].(*evaluators.AuthorizationConfig).JSON.Rules[0].Value, "member")
+	assert.Equal(t, config.AuthorizationConfigs[2].(*evaluators.AuthorizationConfig).Name, "even-more-rules")
+	assert.Equal(t, config.DenyWith.Unauthorized.Code, int32(302))
+	assert.Equal(t, config.Labels["host"], "other.io")
+}
+
+func TestHostOverridesPartialFailure(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	_ = authConfigIndex.Set("other-namespace/other-auth-config", "taken.io", evaluators.AuthConfig{}, false)
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Hosts = append(authConfig.Spec.Hosts, "other.io", "taken.io")
+	authConfig.Spec.HostOverrides = map[string]api.HostOverride{
+		"other.io": {
+			Authorization: []*api.Authorization{{Name: "rego", OPA: &api.Authorization_OPA{InlineRego: "allow {\n  is_admin(input.auth.identity)\n}"}}},
+		},
+	}
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+	recorder := record.NewFakeRecorder(2)
+	reconciler.Recorder = recorder
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+
+	expectedMsg := "failed to compile policy authorino/auth-config-1/rego: line 2, column 3: rego_type_error: undefined function is_admin"
+	assert.Error(t, err, "host other.io: "+expectedMsg)
+
+	// the host that translated successfully is still served
+	assert.Check(t, authConfigIndex.Get("echo-api") != nil)
+	assert.Check(t, authConfigIndex.Get("other.io") == nil)
+	id, _ := authConfigIndex.FindId("taken.io")
+	assert.Equal(t, id, "other-namespace/other-auth-config")
+
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonPolicyCompilationFailed)
+	assert.DeepEqual(t, status.LinkedHosts, []string{"echo-api"})
+	assert.DeepEqual(t, status.FailedHosts, []api.HostStatus{
+		{Host: "other.io", Reason: api.StatusReasonPolicyCompilationFailed, Message: expectedMsg},
+		{Host: "taken.io", Reason: api.StatusReasonHostTaken, Message: "host already taken by another resource"},
+	})
+	assert.Equal(t, <-recorder.Events, "Warning PolicyCompilationFailed host other.io: "+expectedMsg)
+	assert.Equal(t, <-recorder.Events, "Warning HostTaken host taken.io already taken by another resource")
+}
+
+func TestReconcileAuthConfigWithTargetRef(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.TargetRef = &api.TargetRef{Kind: "HTTPRoute", Name: "talker-api"}
+	route := newTestGatewayAPIObject("HTTPRoute", "authorino", "talker-api", map[string]interface{}{
+		"hostnames": []interface{}{"talker-api.io", "echo-api"},
+	})
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret, route)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.DeepEqual(t, status.LinkedHosts, []string{"echo-api", "talker-api.io"})
+	assert.Check(t, authConfigIndex.Get("talker-api.io") != nil)
+
+	// changes to the hostnames of the route are picked up when the authconfig is reconciled again
+	_ = unstructured.SetNestedStringSlice(route.Object, []string{"talker-api.com"}, "spec", "hostnames")
+	assert.NilError(t, client.Update(context.Background(), route))
+	assert.DeepEqual(t, reconciler.authConfigsTargeting(route), []reconcile.Request{{NamespacedName: authConfigName}})
+
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	status, _ = reconciler.StatusReport.Get(authConfigName.String())
+	assert.DeepEqual(t, status.LinkedHosts, []string{"echo-api", "talker-api.com"})
+	assert.Check(t, authConfigIndex.Get("talker-api.io") == nil)
+	assert.Check(t, authConfigIndex.Get("talker-api.com") != nil)
+
+	// the route is gone
+	assert.NilError(t, client.Delete(context.Background(), route))
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.Check(t, errors.IsNotFound(err))
+	status, _ = reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonInvalidResource)
+}
+
+func TestInheritClusterAuthConfigs(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{"team": "a"})
+	authConfig.Spec.DenyWith = &api.DenyWith{Unauthenticated: &api.DenyWithSpec{Code: 302}}
+	baseline := &api.ClusterAuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "baseline"},
+		Spec: api.ClusterAuthConfigSpec{
+			SharedSpec: api.SharedSpec{
+				Patterns: map[string]api.JSONPatternExpressions{
+					"not-blocked": {{Selector: "context.identity.blocked", Operator: "neq", Value: "true"}},
+				},
+				Identity: []*api.Identity{{Name: "corporate-sso", Oidc: &api.Identity_OidcConfig{Endpoint: "http://127.0.0.1:9001/auth/realms/demo"}}},
+				Authorization: []*api.Authorization{
+					{Name: "deny-blocked", JSON: &api.Authorization_JSONPatternMatching{Rules: []api.JSONPattern{{JSONPatternRef: api.JSONPatternRef{JSONPatternName: "not-blocked"}}}}},
+					{Name: "some-extra-rules", JSON: &api.Authorization_JSONPatternMatching{Rules: []api.JSONPattern{{JSONPatternExpression: api.JSONPatternExpression{Selector: "context.identity.role", Operator: "eq", Value: "member"}}}}},
+				},
+				DenyWith: &api.DenyWith{Unauthenticated: &api.DenyWithSpec{Code: 401}, Unauthorized: &api.DenyWithSpec{Code: 404}},
+			},
+		},
+	}
+	otherTeam := &api.ClusterAuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-team"},
+		Spec: api.ClusterAuthConfigSpec{
+			Selector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+			SharedSpec: api.SharedSpec{Authorization: []*api.Authorization{{Name: "other-team-only", JSON: &api.Authorization_JSONPatternMatching{}}}},
+		},
+	}
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret, baseline, otherTeam)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+
+	config := authConfigIndex.Get("echo-api")
+	assert.Equal(t, len(config.IdentityConfigs), 2)
+	assert.Equal(t, config.IdentityConfigs[0].(*evaluators.IdentityConfig).Name, "corporate-sso")
+	assert.Equal(t, config.IdentityConfigs[1].(*evaluators.IdentityConfig).Name, "keycloak")
+	assert.Equal(t, len(config.AuthorizationConfigs), 3)
+	assert.Equal(t, config.AuthorizationConfigs[0].(*evaluators.AuthorizationConfig).Name, "deny-blocked")
+	assert.Equal(t, config.AuthorizationConfigs[1].(*evaluators.AuthorizationConfig).Name, "some-extra-rules")
+	assert.Equal(t, config.AuthorizationConfigs[1].(*evaluators.AuthorizationConfig).JSON.Rules[0].Value, "admin") // the config of the authconfig prevails
+	assert.Equal(t, config.AuthorizationConfigs[2].(*evaluators.AuthorizationConfig).Name, "main-policy")
+	assert.Equal(t, config.DenyWith.Unauthenticated.Code, int32(302))
+	assert.Equal(t, config.DenyWith.Unauthorized.Code, int32(404))
+
+	// changes to the cluster authconfigs requeue the authconfigs that inherit them
+	assert.DeepEqual(t, reconciler.authConfigsInheriting(baseline), []reconcile.Request{{NamespacedName: authConfigName}})
+	assert.Equal(t, len(reconciler.authConfigsInheriting(otherTeam)), 0)
+
+	// namespaced instances do not inherit cluster authconfigs
+	reconciler.Namespace = "authorino"
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+	config = authConfigIndex.Get("echo-api")
+	assert.Equal(t, len(config.IdentityConfigs), 1)
+	assert.Equal(t, len(config.AuthorizationConfigs), 2)
+}
+
+func TestInheritClusterAuthConfigWithInvalidSelector(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	clusterAuthConfig := &api.ClusterAuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+		Spec: api.ClusterAuthConfigSpec{
+			Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Like"}}},
+		},
+	}
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret, clusterAuthConfig)
+	authConfigIndex := index.NewIndex()
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+
+	assert.ErrorContains(t, err, "clusterauthconfig broken: invalid selector")
+	status, _ := reconciler.StatusReport.Get(authConfigName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonInvalidResource)
+	assert.Check(t, authConfigIndex.Get("echo-api") == nil)
+	assert.DeepEqual(t, reconciler.authConfigsInheriting(clusterAuthConfig), []reconcile.Request{{NamespacedName: authConfigName}})
+}
+
+func TestInheritBaseAuthConfigs(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Hosts = append(authConfig.Spec.Hosts, "other.io")
+	authConfig.Spec.HostOverrides = map[string]api.HostOverride{
+		"other.io": {
+			Authorization: []*api.Authorization{
+				{Name: "some-extra-rules", JSON: &api.Authorization_JSONPatternMatching{Rules: []api.JSONPattern{{JSONPatternExpression: api.JSONPatternExpression{Selector: "context.identity.role", Operator: "eq", Value: "owner"}}}}},
+			},
+			DenyWith: &api.DenyWith{Unauthorized: &api.DenyWithSpec{Code: 302}},
+		},
+	}
+	base := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "base",
+			Namespace:   "authorino",
+			Annotations: map[string]string{api.BaseAuthConfigAnnotation: "true"},
+		},
+		Spec: api.AuthConfigSpec{
+			Defaults: &api.SharedSpec{
+				Identity: []*api.Identity{{Name: "corporate-sso", Oidc: &api.Identity_OidcConfig{Endpoint: "http://127.0.0.1:9001/auth/realms/demo"}}},
+				DenyWith: &api.DenyWith{Unauthenticated: &api.DenyWithSpec{Code: 302}, Unauthorized: &api.DenyWithSpec{Code: 404}},
+			},
+			Overrides: &api.SharedSpec{
+				Authorization: []*api.Authorization{
+					{Name: "some-extra-rules", JSON: &api.Authorization_JSONPatternMatching{Rules: []api.JSONPattern{{JSONPatternExpression: api.JSONPatternExpression{Selector: "context.identity.role", Operator: "eq", Value: "member"}}}}},
+				},
+				DenyWith: &api.DenyWith{Unauthenticated: &api.DenyWithSpec{Code: 401}},
+			},
+		},
+	}
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret, base)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.NilError(t, err)
+
+	config := authConfigIndex.Get("echo-api")
+	assert.Equal(t, len(config.IdentityConfigs), 2)
+	assert.Equal(t, config.IdentityConfigs[0].(*evaluators.IdentityConfig).Name, "corporate-sso")
+	assert.Equal(t, config.IdentityConfigs[1].(*evaluators.IdentityConfig).Name, "keycloak")
+	assert.Equal(t, len(config.AuthorizationConfigs), 2)
+	assert.Equal(t, config.AuthorizationConfigs[1].(*evaluators.AuthorizationConfig).Name, "some-extra-rules")
+	assert.Equal(t, config.AuthorizationConfigs[1].(*evaluators.AuthorizationConfig).JSON.Rules[0].Value, "member") // the overrides prevail
+	assert.Equal(t, config.DenyWith.Unauthenticated.Code, int32(401))
+	assert.Equal(t, config.DenyWith.Unauthorized.Code, int32(404))
+
+	config = authConfigIndex.Get("other.io")
+	assert.Equal(t, len(config.AuthorizationConfigs), 2)
+	assert.Equal(t, config.AuthorizationConfigs[1].(*evaluators.AuthorizationConfig).JSON.Rules[0].Value, "member") // the overrides prevail over the host overrides too
+	assert.Equal(t, config.DenyWith.Unauthenticated.Code, int32(401))
+	assert.Equal(t, config.DenyWith.Unauthorized.Code, int32(302))
+
+	// base authconfigs without hosts reconcile just fine
+	baseName := types.NamespacedName{Name: base.Name, Namespace: base.Namespace}
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: baseName})
+	assert.NilError(t, err)
+	status, _ := reconciler.StatusReport.Get(baseName.String())
+	assert.Equal(t, status.Reason, api.StatusReasonReconciled)
+
+	// changes to the base authconfig requeue the other authconfigs of the namespace
+	assert.DeepEqual(t, reconciler.authConfigsInheritingFromBase(base), []reconcile.Request{{NamespacedName: authConfigName}})
+	assert.Equal(t, len(reconciler.authConfigsInheritingFromBase(&authConfig)), 0)
+}
+
+func TestTranslateAuthConfigWithUndefinedPatternRefs(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Patterns = map[string]api.JSONPatternExpressions{
+		"admin": {{Selector: "context.identity.role", Operator: "eq", Value: "admin"}},
+	}
+	authConfig.Spec.Conditions = []api.JSONPattern{{JSONPatternRef: api.JSONPatternRef{JSONPatternName: "admin"}}}
+	authConfig.Spec.Authorization[1].JSON.Rules = []api.JSONPattern{
+		{JSONPatternRef: api.JSONPatternRef{JSONPatternName: "admin"}},
+		{JSONPatternRef: api.JSONPatternRef{JSONPatternName: "member"}},
+	}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	_, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.Error(t, err, "named pattern not found: member")
+}
+
+func TestTranslateAuthConfigWithDuplicateNames(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Authorization[1].Name = "main-policy"
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	_, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.Error(t, err, "duplicate names in spec.authorization: main-policy")
+}
+
+func TestTranslateAuthConfigWithCustomEvaluators(t *testing.T) {
+	custom_evaluators.Register(custom_evaluators.Authorization, "test-translate-custom", func(_ []byte, _ context.Context) (auth.AuthConfigEvaluator, error) {
+		return &authorization_evaluators.JSONPatternMatching{}, nil
+	})
+
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Authorization = append(authConfig.Spec.Authorization, &api.Authorization{
+		Name:   "custom",
+		Custom: &api.CustomEvaluator{Name: "test-translate-custom", Settings: runtime.RawExtension{Raw: []byte(`{"roles":["admin"]}`)}},
+	})
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	translated, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	customConfig := translated.AuthorizationConfigs[2].(*evaluators.AuthorizationConfig)
+	assert.Equal(t, customConfig.GetType(), "AUTHORIZATION_CUSTOM")
+	assert.Equal(t, customConfig.Custom.Plugin, "test-translate-custom")
+
+	authConfig.Spec.Metadata = append(authConfig.Spec.Metadata, &api.Metadata{
+		Name:   "custom",
+		Custom: &api.CustomEvaluator{Name: "not-registered"},
+	})
+	_, err = reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.Error(t, err, "unknown custom metadata evaluator not-registered")
+}
+
+func TestTranslateAuthConfigWithExternalEvaluators(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Authorization = append(authConfig.Spec.Authorization, &api.Authorization{
+		Name: "plugin",
+		External: &api.ExternalEvaluator{
+			Endpoint:     "plugin.authorino.svc:50051",
+			SharedSecret: &api.SecretKeyReference{Name: "plugin-credentials", Key: "token"},
+			Timeout:      500,
+			Settings:     runtime.RawExtension{Raw: []byte(`{"region":"eu"}`)},
+		},
+	})
+	secret := newTestOAuthClientSecret()
+	pluginSecret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "plugin-credentials", Namespace: authConfig.Namespace},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	client := newTestK8sClient(&authConfig, &secret, &pluginSecret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	translated, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	pluginConfig := translated.AuthorizationConfigs[2].(*evaluators.AuthorizationConfig)
+	assert.Equal(t, pluginConfig.GetType(), "AUTHORIZATION_EXTERNAL")
+	assert.Equal(t, pluginConfig.External.SharedSecret, "s3cr3t")
+	assert.Equal(t, pluginConfig.External.Timeout, 500*time.Millisecond)
+	assert.Equal(t, pluginConfig.External.AuthConfig, authConfig.Namespace+"/"+authConfig.Name)
+	assert.Equal(t, string(pluginConfig.External.Settings), `{"region":"eu"}`)
+}
+
+func TestTranslateAuthConfigWithSQLMetadata(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Metadata = []*api.Metadata{{
+		Name: "entitlements",
+		SQL: &api.Metadata_SQL{
+			Driver:     "postgres",
+			DSN:        &api.SecretKeyReference{Name: "entitlements-db", Key: "dsn"},
+			Query:      "SELECT plan FROM entitlements WHERE user_id = $1",
+			Parameters: []api.StaticOrDynamicValue{{ValueFrom: api.ValueFrom{AuthJSON: "auth.identity.sub"}}},
+			Timeout:    200,
+		},
+	}}
+	secret := newTestOAuthClientSecret()
+	dbSecret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "entitlements-db", Namespace: authConfig.Namespace},
+		Data:       map[string][]byte{"dsn": []byte("postgres://authorino:secret@db:5432/plans")},
+	}
+	client := newTestK8sClient(&authConfig, &secret, &dbSecret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	translated, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	metadataConfig := translated.MetadataConfigs[0].(*evaluators.MetadataConfig)
+	assert.Equal(t, metadataConfig.GetType(), "METADATA_SQL")
+	assert.Equal(t, metadataConfig.SQL.Driver, "postgres")
+	assert.Equal(t, metadataConfig.SQL.DSN, "postgres://authorino:secret@db:5432/plans")
+	assert.Equal(t, metadataConfig.SQL.Parameters[0].Pattern, "auth.identity.sub")
+	assert.Equal(t, metadataConfig.SQL.Timeout, 200*time.Millisecond)
+
+	dbSecret.Data = map[string][]byte{}
+	client = newTestK8sClient(&authConfig, &secret, &dbSecret)
+	reconciler = newTestAuthConfigReconciler(client, index.NewIndex())
+	_, err = reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.ErrorContains(t, err, "missing key dsn in secret")
+
+	authConfig.Spec.Metadata[0].SQL.Driver = "sqlite3"
+	_, err = reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.Error(t, err, `unsupported sql driver: "sqlite3"`)
+}
+
+func TestTranslateAuthConfigWithGRPCMetadata(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Metadata = []*api.Metadata{{
+		Name: "entitlements",
+		GRPC: &api.Metadata_GRPC{
+			Endpoint:     "entitlements.acme.svc:50051",
+			Method:       "acme.entitlements.v1.Entitlements/GetPlan",
+			Parameters:   []api.JsonProperty{{Name: "user_id", ValueFrom: api.ValueFrom{AuthJSON: "auth.identity.sub"}}},
+			Headers:      []api.JsonProperty{{Name: "x-tenant", Value: runtime.RawExtension{Raw: []byte(`"acme"`)}}},
+			SharedSecret: &api.SecretKeyReference{Name: "entitlements-credentials", Key: "token"},
+			Timeout:      300,
+		},
+	}}
+	secret := newTestOAuthClientSecret()
+	grpcSecret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "entitlements-credentials", Namespace: authConfig.Namespace},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	client := newTestK8sClient(&authConfig, &secret, &grpcSecret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	translated, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	metadataConfig := translated.MetadataConfigs[0].(*evaluators.MetadataConfig)
+	assert.Equal(t, metadataConfig.GetType(), "METADATA_GRPC")
+	assert.Equal(t, metadataConfig.GRPC.Method, "acme.entitlements.v1.Entitlements/GetPlan")
+	assert.Equal(t, metadataConfig.GRPC.SharedSecret, "s3cr3t")
+	assert.Equal(t, metadataConfig.GRPC.Parameters[0].Value.Pattern, "auth.identity.sub")
+	assert.Equal(t, metadataConfig.GRPC.Headers[0].Name, "x-tenant")
+	assert.Equal(t, metadataConfig.GRPC.Timeout, 300*time.Millisecond)
+}
+
+func TestTranslateAuthConfigWithHTTPCredentials(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Metadata = []*api.Metadata{{
+		Name: "shared-secret",
+		GenericHTTP: &api.Metadata_GenericHTTP{
+			Endpoint:     "http://metadata.acme.svc/users/{auth.identity.sub}",
+			SharedSecret: &api.SecretKeyReference{Name: "http-credentials", Key: "token"},
+			Credentials:  api.Credentials{In: "custom_header", KeySelector: "X-Api-Key"},
+		},
+	}}
+	authConfig.Spec.Authorization = []*api.Authorization{{
+		Name: "oauth2",
+		GenericHTTP: &api.Authorization_GenericHTTP{
+			Endpoint: "http://authz.acme.svc/check",
+			OAuth2: &api.OAuth2ClientAuthentication{
+				TokenUrl:     "http://sso.acme.svc/token",
+				ClientId:     "authorino",
+				ClientSecret: api.SecretKeyReference{Name: "http-credentials", Key: "client-secret"},
+			},
+		},
+	}}
+	secret := newTestOAuthClientSecret()
+	httpSecret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "http-credentials", Namespace: authConfig.Namespace},
+		Data:       map[string][]byte{"token": []byte("s3cr3t"), "client-secret": []byte("cl13nt-s3cr3t")},
+	}
+	client := newTestK8sClient(&authConfig, &secret, &httpSecret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	translated, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.NilError(t, err)
+	metadataConfig := translated.MetadataConfigs[0].(*evaluators.MetadataConfig)
+	assert.Equal(t, metadataConfig.GenericHTTP.SharedSecret, "s3cr3t")
+	assert.Check(t, metadataConfig.GenericHTTP.OAuth2 == nil)
+	assert.Check(t, metadataConfig.GenericHTTP.AuthCredentials != nil)
+	authorizationConfig := translated.AuthorizationConfigs[0].(*evaluators.AuthorizationConfig)
+	assert.Check(t, authorizationConfig.GenericHTTP.GenericHttp.OAuth2 != nil)
+	assert.Check(t, authorizationConfig.GenericHTTP.GenericHttp.AuthCredentials != nil)
+
+	// missing keys are not translated into empty credentials
+	httpSecret.Data = map[string][]byte{"token": []byte("s3cr3t")}
+	client = newTestK8sClient(&authConfig, &secret, &httpSecret)
+	reconciler = newTestAuthConfigReconciler(client, index.NewIndex())
+	_, err = reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.ErrorContains(t, err, "missing key client-secret in secret authorino/http-credentials")
+}
+
+func TestTranslateAuthConfigWithInvalidRegex(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Conditions = []api.JSONPattern{{JSONPatternExpression: api.JSONPatternExpression{Selector: "context.request.http.path", Operator: "matches", Value: "^/pets/("}}}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, index.NewIndex())
+
+	_, err := reconciler.translateAuthConfig(context.TODO(), &authConfig)
+	assert.ErrorContains(t, err, "error parsing regexp")
+}
+
 func TestTranslateAuthConfig(t *testing.T) {
 	// TODO
 }
@@ -240,6 +1028,39 @@ func TestPreventHostCollision(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestRequeueAuthConfigsWaitingForReleasedHosts(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfig.Spec.Hosts = append(authConfig.Spec.Hosts, "other.io")
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	waitingAuthConfig := newTestAuthConfig(map[string]string{})
+	waitingAuthConfig.Name = "waiting-auth-config"
+	waitingAuthConfig.Spec.Hosts = []string{"other.io"}
+	unrelatedAuthConfig := newTestAuthConfig(map[string]string{})
+	unrelatedAuthConfig.Name = "unrelated-auth-config"
+	unrelatedAuthConfig.Spec.Hosts = []string{"unrelated.io"}
+	secret := newTestOAuthClientSecret()
+	client := newTestK8sClient(&authConfig, &waitingAuthConfig, &unrelatedAuthConfig, &secret)
+	reconciler := newTestAuthConfigReconciler(client, authConfigIndex)
+	reconciler.hostsReleased = make(chan event.GenericEvent, 10)
+
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.Equal(t, len(reconciler.hostsReleased), 0)
+
+	authConfig.Spec.Hosts = []string{"echo-api"} // releases other.io
+	_ = client.Update(context.Background(), &authConfig)
+
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.Equal(t, len(reconciler.hostsReleased), 1)
+	e := <-reconciler.hostsReleased
+	assert.Equal(t, e.Object.GetName(), "waiting-auth-config")
+
+	_ = client.Delete(context.Background(), &authConfig) // releases echo-api, not declared by any other authconfig
+
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.Equal(t, len(reconciler.hostsReleased), 0)
+}
+
 func TestMissingWatchedAuthConfigLabels(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	response_evaluators "github.com/kuadrant/authorino/pkg/evaluators/response"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const defaultCredentialsKeySelector = "Bearer"
+
+// DefaultAuthConfig fills the unset fields of an AuthConfig with the same defaults the controller assumes when translating
+// the resource, so the defaults become explicit in the stored spec. It sets the location of the credentials (HTTP
+// Authorization header, "Bearer" prefix), the time span of the Festival Wristband tokens and the status codes of the
+// custom denial responses.
+func DefaultAuthConfig(authConfig *api.AuthConfig) {
+	spec := &authConfig.Spec
+
+	defaultEvaluators(spec.Identity, spec.Metadata, spec.Authorization, spec.Response)
+	defaultDenyWith(spec.DenyWith)
+
+	for host, override := range spec.HostOverrides {
+		defaultEvaluators(override.Identity, override.Metadata, override.Authorization, override.Response)
+		defaultDenyWith(override.DenyWith)
+		spec.HostOverrides[host] = override
+	}
+
+	for _, callback := range spec.Callbacks {
+		if callback != nil && callback.HTTP != nil && callback.HTTP.SharedSecret != nil {
+			defaultCredentials(&callback.HTTP.Credentials)
+		}
+	}
+}
+
+func defaultEvaluators(identities []*api.Identity, metadata []*api.Metadata, authorizations []*api.Authorization, responses []*api.Response) {
+	for _, identity := range identities {
+		if identity != nil {
+			defaultCredentials(&identity.Credentials)
+		}
+	}
+
+	for _, m := range metadata {
+		if m != nil && m.GenericHTTP != nil && m.GenericHTTP.SharedSecret != nil {
+			defaultCredentials(&m.GenericHTTP.Credentials)
+		}
+	}
+
+	for _, authorization := range authorizations {
+		if authorization == nil {
+			continue
+		}
+		if opa := authorization.OPA; opa != nil && opa.ExternalRegistry.SharedSecret != nil {
+			defaultCredentials(&opa.ExternalRegistry.Credentials)
+		}
+		if httpAuthz := authorization.GenericHTTP; httpAuthz != nil && httpAuthz.SharedSecret != nil {
+			defaultCredentials(&httpAuthz.Credentials)
+		}
+		if uma := authorization.UMA; uma != nil && uma.AccessToken != nil {
+			defaultCredentials(uma.AccessToken)
+		}
+	}
+
+	for _, response := range responses {
+		if response != nil && response.Wristband != nil && response.Wristband.TokenDuration == nil {
+			duration := response_evaluators.DEFAULT_WRISTBAND_DURATION
+			response.Wristband.TokenDuration = &duration
+		}
+	}
+}
+
+func defaultCredentials(credentials *api.Credentials) {
+	if credentials.In == "" {
+		credentials.In = "authorization_header"
+	}
+	if credentials.In == "authorization_header" && credentials.KeySelector == "" {
+		credentials.KeySelector = defaultCredentialsKeySelector
+	}
+}
+
+func defaultDenyWith(denyWith *api.DenyWith) {
+	if denyWith == nil {
+		return
+	}
+	defaultDenyWithCode(denyWith.Unauthenticated, http.StatusUnauthorized)
+	defaultDenyWithCode(denyWith.Unauthorized, http.StatusForbidden)
+	defaultDenyWithCode(denyWith.Unmatched, http.StatusForbidden)
+}
+
+func defaultDenyWithCode(denyWithSpec *api.DenyWithSpec, code int) {
+	if denyWithSpec != nil && denyWithSpec.Code == 0 && denyWithSpec.Redirect == nil {
+		denyWithSpec.Code = api.DenyWith_Code(code)
+	}
+}
+
+// +kubebuilder:webhook:path=/mutate-authorino-kuadrant-io-v1beta1-authconfig,mutating=true,failurePolicy=ignore,sideEffects=None,groups=authorino.kuadrant.io,resources=authconfigs,verbs=create;update,versions=v1beta1,name=mauthconfig.authorino.kuadrant.io,admissionReviewVersions=v1
+
+// AuthConfigDefaulter is a mutating admission webhook that fills the defaults of the AuthConfigs created or updated
+type AuthConfigDefaulter struct{}
+
+func (d *AuthConfigDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	authConfig, ok := obj.(*api.AuthConfig)
+	if !ok {
+		return fmt.Errorf("expected an AuthConfig but got a %T", obj)
+	}
+	DefaultAuthConfig(authConfig)
+	return nil
+}
+
+func (d *AuthConfigDefaulter) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&api.AuthConfig{}).
+		WithDefaulter(d).
+		Complete()
+}
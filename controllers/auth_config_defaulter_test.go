@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+	k8score "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDefaultAuthConfig(t *testing.T) {
+	tokenDuration := int64(60)
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api", "other.io"},
+			Identity: []*api.Identity{
+				{Name: "keycloak", Oidc: &api.Identity_OidcConfig{Endpoint: "http://keycloak:8080/auth/realms/kuadrant"}},
+				{Name: "api-key", APIKey: &api.Identity_APIKey{}, Credentials: api.Credentials{In: "authorization_header", KeySelector: "APIKEY"}},
+				{Name: "query", APIKey: &api.Identity_APIKey{}, Credentials: api.Credentials{In: "query", KeySelector: "api_key"}},
+			},
+			Metadata: []*api.Metadata{
+				{Name: "no-secret", GenericHTTP: &api.Metadata_GenericHTTP{Endpoint: "http://metadata"}},
+				{Name: "secret", GenericHTTP: &api.Metadata_GenericHTTP{Endpoint: "http://metadata", SharedSecret: &api.SecretKeyReference{Name: "secret", Key: "key"}}},
+			},
+			Authorization: []*api.Authorization{
+				{Name: "uma", UMA: &api.Authorization_UMA{Endpoint: "http://uma", Credentials: &k8score.LocalObjectReference{Name: "uma"}, AccessToken: &api.Credentials{}}},
+			},
+			Response: []*api.Response{
+				{Name: "wristband", Wristband: &api.Response_Wristband{Issuer: "http://authorino"}},
+				{Name: "wristband-with-duration", Wristband: &api.Response_Wristband{Issuer: "http://authorino", TokenDuration: &tokenDuration}},
+			},
+			DenyWith: &api.DenyWith{
+				Unauthenticated: &api.DenyWithSpec{Message: &api.StaticOrDynamicValue{Value: "login"}},
+				Unauthorized:    &api.DenyWithSpec{Code: 404},
+				Unmatched:       &api.DenyWithSpec{Redirect: &api.DenyWithRedirect{Location: "http://login"}},
+			},
+			HostOverrides: map[string]api.HostOverride{
+				"other.io": {
+					Identity: []*api.Identity{{Name: "keycloak", Oidc: &api.Identity_OidcConfig{Endpoint: "http://keycloak:8080/auth/realms/partners"}}},
+					DenyWith: &api.DenyWith{Unauthorized: &api.DenyWithSpec{}},
+				},
+			},
+		},
+	}
+
+	err := (&AuthConfigDefaulter{}).Default(context.TODO(), authConfig)
+	assert.NilError(t, err)
+
+	spec := authConfig.Spec
+	assert.DeepEqual(t, spec.Identity[0].Credentials, api.Credentials{In: "authorization_header", KeySelector: "Bearer"})
+	assert.DeepEqual(t, spec.Identity[1].Credentials, api.Credentials{In: "authorization_header", KeySelector: "APIKEY"})
+	assert.DeepEqual(t, spec.Identity[2].Credentials, api.Credentials{In: "query", KeySelector: "api_key"})
+	assert.DeepEqual(t, spec.Metadata[0].GenericHTTP.Credentials, api.Credentials{}) // no credentials sent
+	assert.DeepEqual(t, spec.Metadata[1].GenericHTTP.Credentials, api.Credentials{In: "authorization_header", KeySelector: "Bearer"})
+	assert.DeepEqual(t, *spec.Authorization[0].UMA.AccessToken, api.Credentials{In: "authorization_header", KeySelector: "Bearer"})
+	assert.Equal(t, *spec.Response[0].Wristband.TokenDuration, int64(300))
+	assert.Equal(t, *spec.Response[1].Wristband.TokenDuration, int64(60))
+	assert.Equal(t, spec.DenyWith.Unauthenticated.Code, api.DenyWith_Code(401))
+	assert.Equal(t, spec.DenyWith.Unauthorized.Code, api.DenyWith_Code(404))
+	assert.Equal(t, spec.DenyWith.Unmatched.Code, api.DenyWith_Code(0)) // redirect
+	assert.DeepEqual(t, spec.HostOverrides["other.io"].Identity[0].Credentials, api.Credentials{In: "authorization_header", KeySelector: "Bearer"})
+	assert.Equal(t, spec.HostOverrides["other.io"].DenyWith.Unauthorized.Code, api.DenyWith_Code(403))
+
+	// the defaults do not make the resource invalid
+	assert.Equal(t, len(ValidateAuthConfig(authConfig)), 0)
+}
+
+func TestDefaultAuthConfigWrongType(t *testing.T) {
+	err := (&AuthConfigDefaulter{}).Default(context.TODO(), &k8score.Secret{})
+	assert.Error(t, err, "expected an AuthConfig but got a *v1.Secret")
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -18,6 +19,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // AuthConfigStatusUpdater updates the status of a newly reconciled auth config
@@ -59,13 +61,21 @@ func (u *AuthConfigStatusUpdater) updateAuthConfigStatus(ctx context.Context, re
 
 	var reason, message string
 	linkedHosts := []string{}
+	failedHosts := []api.HostStatus{}
 	report, reportAvailable := u.StatusReport.Get(resourceId)
 	if reportAvailable {
 		reason = report.Reason
 		message = report.Message
 		linkedHosts = report.LinkedHosts
+		failedHosts = report.FailedHosts
 	}
-	looseHosts := utils.SubtractSlice(authConfig.Spec.Hosts, linkedHosts)
+	hosts := authConfig.Spec.Hosts
+	if hostnames, err := targetRefHostnames(ctx, u.Client, authConfig); err != nil {
+		logger.V(1).Info("failed to resolve the hostnames of the targetRef", "error", err.Error())
+	} else {
+		hosts = append(utils.SubtractSlice(hosts, hostnames), hostnames...)
+	}
+	looseHosts := utils.SubtractSlice(hosts, linkedHosts)
 
 	// available
 	changed := updateStatusAvailable(authConfig, len(linkedHosts) > 0)
@@ -75,7 +85,7 @@ func (u *AuthConfigStatusUpdater) updateAuthConfigStatus(ctx context.Context, re
 	changed = updateStatusReady(authConfig, ready, reason, message) || changed
 
 	// summary
-	changed = updateStatusSummary(authConfig, linkedHosts) || changed
+	changed = updateStatusSummary(authConfig, hosts, linkedHosts, hostsNotReady(looseHosts, failedHosts, reason, message)) || changed
 
 	if !authConfig.Status.Ready() {
 		err = fmt.Errorf("resource not ready")
@@ -100,9 +110,25 @@ func (u *AuthConfigStatusUpdater) updateAuthConfigStatus(ctx context.Context, re
 }
 
 func (u *AuthConfigStatusUpdater) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&api.AuthConfig{}, builder.WithPredicates(LabelSelectorPredicate(u.LabelSelector))).
-		Complete(u)
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		For(&api.AuthConfig{}, builder.WithPredicates(LabelSelectorPredicate(u.LabelSelector)))
+
+	// the number of hosts of the authconfigs with a targetRef changes with the hostnames of the target resources
+	controllerBuilder, err := watchTargetRefs(mgr, controllerBuilder, u.authConfigsTargeting)
+	if err != nil {
+		return err
+	}
+
+	return controllerBuilder.Complete(u)
+}
+
+// authConfigsTargeting maps a gateway api resource to the requests to update the status of the authconfigs whose hostnames depend on it
+func (u *AuthConfigStatusUpdater) authConfigsTargeting(obj client.Object) []reconcile.Request {
+	requests, err := findAuthConfigsTargeting(context.Background(), u.Client, u.LabelSelector, obj)
+	if err != nil {
+		u.Logger.Error(err, "failed to list authconfigs targeting resource", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "resource", obj.GetNamespace()+"/"+obj.GetName())
+	}
+	return requests
 }
 
 func updateStatusConditions(currentConditions []api.Condition, newCondition api.Condition) ([]api.Condition, bool) {
@@ -174,7 +200,7 @@ func updateStatusReady(authConfig *api.AuthConfig, ready bool, reason, message s
 	return
 }
 
-func updateStatusSummary(authConfig *api.AuthConfig, newLinkedHosts []string) (changed bool) {
+func updateStatusSummary(authConfig *api.AuthConfig, hosts, newLinkedHosts []string, newHostsNotReady []api.HostStatus) (changed bool) {
 	current := authConfig.Status.Summary
 
 	if len(newLinkedHosts) == 0 {
@@ -184,7 +210,8 @@ func updateStatusSummary(authConfig *api.AuthConfig, newLinkedHosts []string) (c
 	new := api.Summary{
 		Ready:                    authConfig.Status.Ready(),
 		HostsReady:               newLinkedHosts,
-		NumHostsReady:            fmt.Sprintf("%d/%d", len(newLinkedHosts), len(authConfig.Spec.Hosts)),
+		HostsNotReady:            newHostsNotReady,
+		NumHostsReady:            fmt.Sprintf("%d/%d", len(newLinkedHosts), len(hosts)),
 		NumIdentitySources:       int64(len(authConfig.Spec.Identity)),
 		NumMetadataSources:       int64(len(authConfig.Spec.Metadata)),
 		NumAuthorizationPolicies: int64(len(authConfig.Spec.Authorization)),
@@ -199,6 +226,7 @@ func updateStatusSummary(authConfig *api.AuthConfig, newLinkedHosts []string) (c
 	changed = new.Ready != current.Ready ||
 		new.NumHostsReady != current.NumHostsReady ||
 		strings.Join(currentLinkedHosts, ",") != strings.Join(newLinkedHosts, ",") ||
+		!reflect.DeepEqual(current.HostsNotReady, new.HostsNotReady) ||
 		new.NumIdentitySources != current.NumIdentitySources ||
 		new.NumMetadataSources != current.NumMetadataSources ||
 		new.NumAuthorizationPolicies != current.NumAuthorizationPolicies ||
@@ -212,6 +240,32 @@ func updateStatusSummary(authConfig *api.AuthConfig, newLinkedHosts []string) (c
 	return
 }
 
+// hostsNotReady returns the status of each host not linked to the resource in the index. Hosts without a reason of their
+// own get the reason of the resource.
+func hostsNotReady(looseHosts []string, failedHosts []api.HostStatus, reason, message string) []api.HostStatus {
+	if len(looseHosts) == 0 {
+		return nil
+	}
+
+	if reason == "" || reason == api.StatusReasonReconciled {
+		reason = api.StatusReasonUnknown
+	}
+
+	statuses := make([]api.HostStatus, 0, len(looseHosts))
+	for _, host := range looseHosts {
+		status := api.HostStatus{Host: host, Reason: reason, Message: message}
+		for _, failedHost := range failedHosts {
+			if failedHost.Host == host {
+				status = failedHost
+				break
+			}
+		}
+		status.Message = utils.CapitalizeString(status.Message)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
 func issuingWristbands(authConfig *api.AuthConfig) bool {
 	for _, responseConfig := range authConfig.Spec.Response {
 		if responseConfig.GetType() == api.ResponseWristband {
@@ -161,6 +161,54 @@ func TestAuthConfigStatusUpdater_HostNotLinked(t *testing.T) {
 	assert.Equal(t, status.Summary.HostsReady[0], "my-api.com")
 }
 
+func TestAuthConfigStatusUpdater_HostsNotReady(t *testing.T) {
+	authConfig := mockStatusUpdateAuthConfigWithHosts([]string{"my-api.com", "my-api.local", "my-api.io"})
+	resourceName := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}
+	client := newTestK8sClient(&authConfig)
+	reconciler := mockStatusUpdaterReconciler(client)
+	reconciler.StatusReport.Set(resourceName.String(), api.StatusReasonCachingError, "failed to cache", []string{"my-api.com"},
+		api.HostStatus{Host: "my-api.local", Reason: api.StatusReasonHostTaken, Message: "host already taken by another resource"},
+	)
+
+	_, err := reconciler.Reconcile(context.Background(), controllerruntime.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+
+	authConfigCheck := api.AuthConfig{}
+	_ = client.Get(context.TODO(), resourceName, &authConfigCheck)
+	summary := authConfigCheck.Status.Summary
+	assert.Check(t, !summary.Ready)
+	assert.DeepEqual(t, summary.HostsReady, []string{"my-api.com"})
+	assert.Equal(t, summary.NumHostsReady, "1/3")
+	assert.DeepEqual(t, summary.HostsNotReady, []api.HostStatus{
+		{Host: "my-api.local", Reason: api.StatusReasonHostTaken, Message: "Host already taken by another resource"},
+		{Host: "my-api.io", Reason: api.StatusReasonCachingError, Message: "Failed to cache"},
+	})
+}
+
+func TestAuthConfigStatusUpdater_TargetRefHosts(t *testing.T) {
+	authConfig := mockStatusUpdateAuthConfigWithHosts([]string{"my-api.com"})
+	authConfig.Spec.TargetRef = &api.TargetRef{Kind: "HTTPRoute", Name: "my-api"}
+	route := newTestGatewayAPIObject("HTTPRoute", "authorino", "my-api", map[string]interface{}{
+		"hostnames": []interface{}{"my-api.com", "my-api.io"},
+	})
+	resourceName := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}
+	client := newTestK8sClient(&authConfig, route)
+	reconciler := mockStatusUpdaterReconciler(client)
+	reconciler.StatusReport.Set(resourceName.String(), api.StatusReasonReconciled, "", []string{"my-api.com"})
+
+	_, err := reconciler.Reconcile(context.Background(), controllerruntime.Request{NamespacedName: resourceName})
+	assert.NilError(t, err)
+
+	authConfigCheck := api.AuthConfig{}
+	_ = client.Get(context.TODO(), resourceName, &authConfigCheck)
+	summary := authConfigCheck.Status.Summary
+	assert.Check(t, !summary.Ready)
+	assert.Equal(t, summary.NumHostsReady, "1/2")
+	assert.Equal(t, len(summary.HostsNotReady), 1)
+	assert.Equal(t, summary.HostsNotReady[0].Host, "my-api.io")
+	assert.DeepEqual(t, reconciler.authConfigsTargeting(route), []reconcile.Request{{NamespacedName: resourceName}})
+}
+
 func mockStatusUpdateAuthConfig() api.AuthConfig {
 	return mockStatusUpdateAuthConfigWithLabelsAndHosts(map[string]string{"authorino.kuadrant.io/managed-by": "authorino"}, []string{"echo-api"})
 }
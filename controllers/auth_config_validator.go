@@ -0,0 +1,495 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	authorization_evaluators "github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	custom_evaluators "github.com/kuadrant/authorino/pkg/evaluators/custom"
+	identity_evaluators "github.com/kuadrant/authorino/pkg/evaluators/identity"
+	metadata_evaluators "github.com/kuadrant/authorino/pkg/evaluators/metadata"
+	response_evaluators "github.com/kuadrant/authorino/pkg/evaluators/response"
+	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	endpointPlaceholders = regexp.MustCompile(`{[^}]*}`)
+	jsonPatternOperators = []api.JSONPatternOperator{"eq", "neq", "incl", "excl", "matches", "withinSchedule"}
+)
+
+// ValidateAuthConfig checks an AuthConfig for errors that would prevent the controller from translating it, without reaching out
+// to the Kubernetes API server nor to any external service. It compiles the inline Rego policies, parses the label selectors,
+// the endpoints and the JSON patterns, and checks the references to named patterns and identity sources.
+func ValidateAuthConfig(authConfig *api.AuthConfig) []error {
+	v := &authConfigValidator{authConfig: authConfig}
+	v.validate()
+	return v.errors
+}
+
+type authConfigValidator struct {
+	authConfig *api.AuthConfig
+	errors     []error
+}
+
+func (v *authConfigValidator) addError(path string, err error) {
+	v.errors = append(v.errors, fmt.Errorf("%s: %v", path, err))
+}
+
+func (v *authConfigValidator) validate() {
+	spec := v.authConfig.Spec
+
+	if isBaseAuthConfig(v.authConfig) {
+		v.validateDenyWith("spec.defaults.denyWith", sharedDenyWith(spec.Defaults))
+		v.validateDenyWith("spec.overrides.denyWith", sharedDenyWith(spec.Overrides))
+	} else {
+		if len(spec.Hosts) == 0 && spec.TargetRef == nil {
+			v.addError("spec.hosts", fmt.Errorf("at least one host or a targetRef is required"))
+		}
+		if spec.Defaults != nil {
+			v.addError("spec.defaults", fmt.Errorf("requires the %s annotation", api.BaseAuthConfigAnnotation))
+		}
+		if spec.Overrides != nil {
+			v.addError("spec.overrides", fmt.Errorf("requires the %s annotation", api.BaseAuthConfigAnnotation))
+		}
+	}
+
+	if targetRef := spec.TargetRef; targetRef != nil {
+		if targetRef.Kind != api.TargetRefKindHTTPRoute && targetRef.Kind != api.TargetRefKindGateway {
+			v.addError("spec.targetRef.kind", fmt.Errorf("unsupported kind %s", targetRef.Kind))
+		}
+		if targetRef.Group != "" && targetRef.Group != gatewayAPIGroup {
+			v.addError("spec.targetRef.group", fmt.Errorf("unsupported group %s", targetRef.Group))
+		}
+	}
+
+	for host, override := range spec.HostOverrides {
+		// hosts resolved from the targetRef are only known at reconcile time
+		if spec.TargetRef == nil && !utils.SliceContains(spec.Hosts, host) {
+			v.addError("spec.hostOverrides."+host, fmt.Errorf("host not listed in spec.hosts"))
+		}
+		v.validateDenyWith("spec.hostOverrides."+host+".denyWith", override.DenyWith)
+	}
+	v.validateDenyWith("spec.denyWith", spec.DenyWith)
+
+	for i, header := range spec.HeadersToRemove {
+		path := fmt.Sprintf("spec.headersToRemove[%d]", i)
+		switch {
+		case header == "":
+			v.addError(path, fmt.Errorf("header name cannot be empty"))
+		case strings.HasPrefix(header, ":") || strings.EqualFold(header, "host"):
+			v.addError(path, fmt.Errorf("header %s cannot be removed", header))
+		}
+	}
+
+	for _, d := range findDuplicateNames(spec) {
+		v.addError(d.path, fmt.Errorf("duplicate names: %s", strings.Join(d.names, ", ")))
+	}
+
+	for name, expressions := range spec.Patterns {
+		for i, expression := range expressions {
+			v.validateJSONPatternExpression(fmt.Sprintf("spec.patterns.%s[%d]", name, i), expression)
+		}
+	}
+	v.validateJSONPatterns("spec.when", spec.Conditions)
+
+	identityTypes := map[string]string{}
+
+	for i, identity := range spec.Identity {
+		path := fmt.Sprintf("spec.identity[%d]", i)
+		identityTypes[identity.Name] = identity.GetType()
+		v.validateJSONPatterns(path+".when", identity.Conditions)
+
+		if rev := identity.Revocation; rev != nil {
+			if rev.Selector == nil && rev.Endpoint == "" {
+				v.addError(path+".revocation", fmt.Errorf("at least one of selector or endpoint must be set"))
+			}
+			if rev.Selector != nil {
+				v.validateLabelSelector(path+".revocation.selector", rev.Selector)
+			}
+			if rev.Endpoint != "" {
+				v.validateEndpoint(path+".revocation.endpoint", rev.Endpoint)
+			}
+		}
+
+		switch identity.GetType() {
+		case api.IdentityOAuth2:
+			v.validateEndpoint(path+".oauth2.tokenIntrospectionUrl", identity.OAuth2.TokenIntrospectionUrl)
+		case api.IdentityOidc:
+			v.validateEndpoint(path+".oidc.endpoint", identity.Oidc.Endpoint)
+			if introspection := identity.Oidc.Introspection; introspection != nil && introspection.TokenIntrospectionUrl != "" {
+				v.validateEndpoint(path+".oidc.introspection.tokenIntrospectionUrl", introspection.TokenIntrospectionUrl)
+			}
+		case api.IdentityApiKey:
+			v.validateLabelSelector(path+".apiKey.selector", identity.APIKey.Selector)
+			switch identity.APIKey.KeyHashing {
+			case "", identity_evaluators.APIKeyHashingSHA256, identity_evaluators.APIKeyHashingBcrypt:
+			default:
+				v.addError(path+".apiKey.keyHashing", fmt.Errorf("unsupported hashing algorithm: %q", identity.APIKey.KeyHashing))
+			}
+		case api.IdentityMTLS:
+			v.validateLabelSelector(path+".mtls.selector", identity.MTLS.Selector)
+		case api.IdentityJWT:
+			if (identity.JWT.JWKS == "") == (identity.JWT.JWKSRef == nil) {
+				v.addError(path+".jwt", fmt.Errorf("exactly one of jwks or jwksRef must be set"))
+			}
+		case api.IdentitySPIFFE:
+			if (identity.SPIFFE.TrustBundleEndpoint == "") == (identity.SPIFFE.TrustBundleRef == nil) {
+				v.addError(path+".spiffe", fmt.Errorf("exactly one of trustBundleRef or trustBundleEndpoint must be set"))
+			}
+		case api.IdentitySAML:
+			if (identity.SAML.IdPMetadataUrl == "") == (identity.SAML.IdPMetadataRef == nil) {
+				v.addError(path+".saml", fmt.Errorf("exactly one of idpMetadataRef or idpMetadataUrl must be set"))
+			}
+			if len(identity.SAML.Audiences) == 0 {
+				v.addError(path+".saml.audiences", fmt.Errorf("at least one audience must be set"))
+			}
+		case api.IdentityAWSSigV4:
+			v.validateLabelSelector(path+".awsSigV4.selector", identity.AWSSigV4.Selector)
+		case api.IdentityHMAC:
+			v.validateLabelSelector(path+".hmac.selector", identity.HMAC.Selector)
+			switch identity.HMAC.Algorithm {
+			case "", identity_evaluators.HMACAlgorithmSHA256, identity_evaluators.HMACAlgorithmSHA512:
+			default:
+				v.addError(path+".hmac.algorithm", fmt.Errorf("unsupported hmac algorithm: %q", identity.HMAC.Algorithm))
+			}
+			for j, component := range identity.HMAC.SignedComponents {
+				if !identity_evaluators.ValidHMACSignedComponent(component) {
+					v.addError(fmt.Sprintf("%s.hmac.signedComponents[%d]", path, j), fmt.Errorf("unsupported signed component: %q", component))
+				}
+			}
+			if !identity_evaluators.HMACDateSigned(identity.HMAC.SignedComponents) {
+				v.addError(path+".hmac.signedComponents", fmt.Errorf("the date must be a signed component"))
+			}
+		case api.IdentityCustom:
+			v.validateCustomEvaluator(path+".custom", custom_evaluators.Identity, identity.Custom)
+		case api.IdentityExternal:
+			v.validateExternalEvaluator(path+".external", identity.External)
+		case api.TypeUnknown:
+			v.addError(path, fmt.Errorf("unknown identity type"))
+		}
+	}
+
+	for i, metadata := range spec.Metadata {
+		path := fmt.Sprintf("spec.metadata[%d]", i)
+		v.validateJSONPatterns(path+".when", metadata.Conditions)
+
+		switch metadata.GetType() {
+		case api.MetadataUma:
+			v.validateEndpoint(path+".uma.endpoint", metadata.UMA.Endpoint)
+		case api.MetadataUserinfo:
+			if identityType, found := identityTypes[metadata.UserInfo.IdentitySource]; !found {
+				v.addError(path+".userInfo.identitySource", fmt.Errorf("identity source not found: %s", metadata.UserInfo.IdentitySource))
+			} else if identityType != api.IdentityOidc {
+				v.addError(path+".userInfo.identitySource", fmt.Errorf("identity source is not of type oidc: %s", metadata.UserInfo.IdentitySource))
+			}
+		case api.MetadataGenericHTTP:
+			v.validateEndpoint(path+".http.endpoint", metadata.GenericHTTP.Endpoint)
+		case api.MetadataCustom:
+			v.validateCustomEvaluator(path+".custom", custom_evaluators.Metadata, metadata.Custom)
+		case api.MetadataExternal:
+			v.validateExternalEvaluator(path+".external", metadata.External)
+		case api.MetadataSQL:
+			v.validateSQLMetadata(path+".sql", metadata.SQL)
+		case api.MetadataGRPC:
+			v.validateGRPCMetadata(path+".grpc", metadata.GRPC)
+		case api.TypeUnknown:
+			v.addError(path, fmt.Errorf("unknown metadata type"))
+		}
+	}
+
+	for i, authorization := range spec.Authorization {
+		path := fmt.Sprintf("spec.authorization[%d]", i)
+		v.validateJSONPatterns(path+".when", authorization.Conditions)
+
+		switch authorization.GetType() {
+		case api.AuthorizationOPA:
+			opa := authorization.OPA
+			policyName := v.authConfig.GetNamespace() + "/" + v.authConfig.GetName() + "/" + authorization.Name
+			if opa.InlineRego != "" && len(opa.Libraries) > 0 {
+				// the libraries are only available in reconciliation-time
+				if err := authorization_evaluators.ParseOPAPolicy(policyName, opa.InlineRego); err != nil {
+					v.addError(path+".opa.inlineRego", err)
+				}
+			} else if opa.InlineRego != "" {
+				if _, err := authorization_evaluators.NewOPAAuthorization(policyName, opa.InlineRego, &authorization_evaluators.OPAExternalSource{}, opa.AllValues, i, context.TODO()); err != nil {
+					v.addError(path+".opa.inlineRego", err)
+				}
+			}
+			if opa.ExternalRegistry.Endpoint != "" {
+				v.validateEndpoint(path+".opa.externalRegistry.endpoint", opa.ExternalRegistry.Endpoint)
+			}
+		case api.AuthorizationJSONPatternMatching:
+			v.validateJSONPatterns(path+".json.rules", authorization.JSON.Rules)
+		case api.AuthorizationCEL:
+			if _, err := authorization_evaluators.NewCELAuthorization(authorization.CEL.Expression); err != nil {
+				v.addError(path+".cel.expression", err)
+			}
+		case api.AuthorizationAuthzed:
+			if authorization.Authzed.Endpoint == "" {
+				v.addError(path+".authzed.endpoint", fmt.Errorf("endpoint is required"))
+			}
+		case api.AuthorizationUMA:
+			v.validateEndpoint(path+".uma.endpoint", authorization.UMA.Endpoint)
+		case api.AuthorizationGenericHTTP:
+			v.validateEndpoint(path+".http.endpoint", authorization.GenericHTTP.Endpoint)
+			v.validateJSONPatterns(path+".http.rules", authorization.GenericHTTP.Rules)
+		case api.AuthorizationCustom:
+			v.validateCustomEvaluator(path+".custom", custom_evaluators.Authorization, authorization.Custom)
+		case api.AuthorizationExternal:
+			v.validateExternalEvaluator(path+".external", authorization.External)
+		case api.TypeUnknown:
+			v.addError(path, fmt.Errorf("unknown authorization type"))
+		}
+	}
+
+	for i, response := range spec.Response {
+		path := fmt.Sprintf("spec.response[%d]", i)
+		v.validateJSONPatterns(path+".when", response.Conditions)
+
+		if response.Wrapper == evaluators.HTTP_COOKIE_WRAPPER {
+			cookieName := response.WrapperKey
+			if cookieName == "" {
+				cookieName = response.Name
+			}
+			if !response_evaluators.IsCookieName(cookieName) {
+				v.addError(path+".wrapperKey", fmt.Errorf("invalid cookie name: %q", cookieName))
+			}
+		}
+
+		switch response.GetType() {
+		case api.ResponseWristband:
+			v.validateEndpoint(path+".wristband.issuer", response.Wristband.Issuer)
+			for j, claim := range response.Wristband.CustomClaims {
+				if response_evaluators.IsReservedClaim(claim.Name) {
+					v.addError(fmt.Sprintf("%s.wristband.customClaims[%d]", path, j), fmt.Errorf("claim %s is reserved", claim.Name))
+				}
+			}
+			if duration := response.Wristband.TokenDuration; duration != nil && *duration <= 0 {
+				v.addError(path+".wristband.tokenDuration", fmt.Errorf("must be greater than 0"))
+			}
+		case api.ResponseRateLimitDescriptors:
+			if response.Wrapper != evaluators.ENVOY_DYNAMIC_METADATA_WRAPPER {
+				v.addError(path+".wrapper", fmt.Errorf("rate limit descriptors must be wrapped as %s", evaluators.ENVOY_DYNAMIC_METADATA_WRAPPER))
+			}
+		case api.TypeUnknown:
+			v.addError(path, fmt.Errorf("unknown response type"))
+		}
+	}
+
+	for i, callback := range spec.Callbacks {
+		path := fmt.Sprintf("spec.callbacks[%d]", i)
+		v.validateJSONPatterns(path+".when", callback.Conditions)
+
+		switch callback.GetType() {
+		case api.CallbackHTTP:
+			v.validateEndpoint(path+".http.endpoint", callback.HTTP.Endpoint)
+		case api.TypeUnknown:
+			v.addError(path, fmt.Errorf("unknown callback type"))
+		}
+	}
+}
+
+func (v *authConfigValidator) validateJSONPatterns(path string, patterns []api.JSONPattern) {
+	for i, pattern := range patterns {
+		patternPath := fmt.Sprintf("%s[%d]", path, i)
+		if name := pattern.JSONPatternName; name != "" {
+			if _, found := v.authConfig.Spec.Patterns[name]; !found {
+				v.addError(patternPath+".patternRef", fmt.Errorf("named pattern not found: %s", name))
+			}
+			continue
+		}
+		if len(pattern.Any) > 0 {
+			for j, expression := range pattern.Any {
+				v.validateJSONPatternExpression(fmt.Sprintf("%s.any[%d]", patternPath, j), expression)
+			}
+			continue
+		}
+		v.validateJSONPatternExpression(patternPath, pattern.JSONPatternExpression)
+	}
+}
+
+func (v *authConfigValidator) validateJSONPatternExpression(path string, expression api.JSONPatternExpression) {
+	// the selector of a schedule is optional; it defaults to the current time
+	if expression.Selector == "" && expression.Operator != "withinSchedule" {
+		v.addError(path+".selector", fmt.Errorf("selector is required"))
+	}
+
+	validOperator := false
+	for _, operator := range jsonPatternOperators {
+		if expression.Operator == operator {
+			validOperator = true
+			break
+		}
+	}
+	if !validOperator {
+		v.addError(path+".operator", fmt.Errorf("unsupported operator: %q", expression.Operator))
+	}
+
+	switch expression.Operator {
+	case "matches":
+		if _, err := regexp.Compile(expression.Value); err != nil {
+			v.addError(path+".value", err)
+		}
+	case "withinSchedule":
+		if _, err := json.ParseSchedule(expression.Value); err != nil {
+			v.addError(path+".value", err)
+		}
+	}
+}
+
+func (v *authConfigValidator) validateEndpoint(path, endpoint string) {
+	// placeholders are only resolved in request-time
+	u, err := url.Parse(endpointPlaceholders.ReplaceAllString(endpoint, "placeholder"))
+	if err != nil {
+		v.addError(path, err)
+		return
+	}
+	if u.Scheme == "" || u.Host == "" {
+		v.addError(path, fmt.Errorf("invalid endpoint: %q", endpoint))
+	}
+}
+
+func (v *authConfigValidator) validateLabelSelector(path string, selector *metav1.LabelSelector) {
+	if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+		v.addError(path, err)
+	}
+}
+
+func (v *authConfigValidator) validateDenyWith(path string, denyWith *api.DenyWith) {
+	if denyWith == nil {
+		return
+	}
+	names := []string{"unauthenticated", "unauthorized", "unmatched"}
+	for i, spec := range []*api.DenyWithSpec{denyWith.Unauthenticated, denyWith.Unauthorized, denyWith.Unmatched} {
+		if spec == nil {
+			continue
+		}
+		if spec.BodyTemplate != "" {
+			if _, err := evaluators.NewDenyWithTemplate(spec.BodyTemplate, spec.ContentType); err != nil {
+				v.addError(path+"."+names[i]+".bodyTemplate", err)
+			}
+		}
+		if spec.Redirect != nil {
+			if _, err := evaluators.NewDenyWithTemplate(spec.Redirect.Location, ""); err != nil {
+				v.addError(path+"."+names[i]+".redirect.location", err)
+			}
+		}
+	}
+}
+
+func (v *authConfigValidator) validateExternalEvaluator(path string, evaluator *api.ExternalEvaluator) {
+	if evaluator.Endpoint == "" {
+		v.addError(path+".endpoint", fmt.Errorf("endpoint is required"))
+	} else if _, _, err := net.SplitHostPort(evaluator.Endpoint); err != nil {
+		v.addError(path+".endpoint", fmt.Errorf("invalid endpoint: %q", evaluator.Endpoint))
+	}
+}
+
+func (v *authConfigValidator) validateGRPCMetadata(path string, grpc *api.Metadata_GRPC) {
+	if grpc.Endpoint == "" {
+		v.addError(path+".endpoint", fmt.Errorf("endpoint is required"))
+	} else if _, _, err := net.SplitHostPort(grpc.Endpoint); err != nil {
+		v.addError(path+".endpoint", fmt.Errorf("invalid endpoint: %q", grpc.Endpoint))
+	}
+	if parts := strings.Split(grpc.Method, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		v.addError(path+".method", fmt.Errorf("invalid method: %q (expected <package>.<service>/<method>)", grpc.Method))
+	}
+}
+
+func (v *authConfigValidator) validateSQLMetadata(path string, sql *api.Metadata_SQL) {
+	if !metadata_evaluators.SQLDriverSupported(string(sql.Driver)) {
+		v.addError(path+".driver", fmt.Errorf("unsupported sql driver: %q", sql.Driver))
+	}
+	if sql.DSN == nil {
+		v.addError(path+".dsnRef", fmt.Errorf("dsnRef is required"))
+	}
+	// the query is run in a read-only transaction anyway; this only catches obvious mistakes early
+	if fields := strings.Fields(sql.Query); len(fields) == 0 {
+		v.addError(path+".query", fmt.Errorf("query is required"))
+	} else if keyword := strings.ToUpper(fields[0]); keyword != "SELECT" && keyword != "WITH" {
+		v.addError(path+".query", fmt.Errorf("only SELECT queries are supported"))
+	}
+}
+
+func (v *authConfigValidator) validateCustomEvaluator(path string, kind custom_evaluators.Kind, evaluator *api.CustomEvaluator) {
+	if !custom_evaluators.Registered(kind, evaluator.Name) {
+		v.addError(path+".name", fmt.Errorf("custom %s evaluator not registered in this build: %q", kind, evaluator.Name))
+	}
+}
+
+// duplicateNames lists the names repeated in a list of evaluators of an AuthConfig
+type duplicateNames struct {
+	path  string
+	names []string
+}
+
+func sharedDenyWith(shared *api.SharedSpec) *api.DenyWith {
+	if shared == nil {
+		return nil
+	}
+	return shared.DenyWith
+}
+
+// findDuplicateNames returns the names repeated within each list of evaluators of an AuthConfig, including the lists of the host overrides.
+// Evaluators are referred by name (e.g. the identity source of the userInfo metadata, the host overrides), thus duplicates would shadow each other.
+func findDuplicateNames(spec api.AuthConfigSpec) []duplicateNames {
+	var found []duplicateNames
+	check := func(path string, names []string) {
+		if dups := duplicates(names); len(dups) > 0 {
+			found = append(found, duplicateNames{path: path, names: dups})
+		}
+	}
+
+	checkEvaluators := func(path string, identity []*api.Identity, metadata []*api.Metadata, authorization []*api.Authorization, response []*api.Response) {
+		check(path+".identity", namesOf(identity, func(e *api.Identity) string { return e.Name }))
+		check(path+".metadata", namesOf(metadata, func(e *api.Metadata) string { return e.Name }))
+		check(path+".authorization", namesOf(authorization, func(e *api.Authorization) string { return e.Name }))
+		check(path+".response", namesOf(response, func(e *api.Response) string { return e.Name }))
+	}
+
+	checkEvaluators("spec", spec.Identity, spec.Metadata, spec.Authorization, spec.Response)
+	check("spec.callbacks", namesOf(spec.Callbacks, func(e *api.Callback) string { return e.Name }))
+
+	hosts := make([]string, 0, len(spec.HostOverrides))
+	for host := range spec.HostOverrides {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		override := spec.HostOverrides[host]
+		checkEvaluators("spec.hostOverrides."+host, override.Identity, override.Metadata, override.Authorization, override.Response)
+	}
+
+	return found
+}
+
+func namesOf[T any](items []*T, name func(*T) string) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, name(item))
+	}
+	return names
+}
+
+// duplicates returns the values that occur more than once in a list, each one only once
+func duplicates(values []string) []string {
+	seen := map[string]int{}
+	var dups []string
+	for _, value := range values {
+		seen[value]++
+		if seen[value] == 2 {
+			dups = append(dups, value)
+		}
+	}
+	return dups
+}
@@ -0,0 +1,432 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/auth"
+	custom_evaluators "github.com/kuadrant/authorino/pkg/evaluators/custom"
+	identity_evaluators "github.com/kuadrant/authorino/pkg/evaluators/identity"
+
+	"gotest.tools/assert"
+	k8score "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateAuthConfig(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Identity: []*api.Identity{
+				{Name: "keycloak", Oidc: &api.Identity_OidcConfig{Endpoint: "http://keycloak:8080/auth/realms/kuadrant"}},
+			},
+			Metadata: []*api.Metadata{
+				{Name: "userinfo", UserInfo: &api.Metadata_UserInfo{IdentitySource: "keycloak"}},
+				{Name: "ext", GenericHTTP: &api.Metadata_GenericHTTP{Endpoint: "http://{context.request.http.host}/metadata?p={context.request.http.path}"}},
+			},
+			Authorization: []*api.Authorization{
+				{Name: "rego", OPA: &api.Authorization_OPA{InlineRego: "allow { true }"}},
+				{Name: "json", JSON: &api.Authorization_JSONPatternMatching{Rules: []api.JSONPattern{
+					{JSONPatternRef: api.JSONPatternRef{JSONPatternName: "admins"}},
+					{JSONPatternExpression: api.JSONPatternExpression{Selector: "auth.identity.email", Operator: "matches", Value: "@acme\\.com$"}},
+					{JSONPatternExpression: api.JSONPatternExpression{Operator: "withinSchedule", Value: "TZ=Europe/Berlin * 9-17 * * MON-FRI"}},
+				}}},
+			},
+			Patterns: map[string]api.JSONPatternExpressions{
+				"admins": {{Selector: "auth.identity.group", Operator: "incl", Value: "admin"}},
+			},
+		},
+	}
+
+	assert.Equal(t, len(ValidateAuthConfig(authConfig)), 0)
+}
+
+func TestValidateInvalidAuthConfig(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Identity: []*api.Identity{
+				{Name: "keycloak", Oidc: &api.Identity_OidcConfig{Endpoint: "keycloak"}},
+				{Name: "api-key", APIKey: &api.Identity_APIKey{Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "bogus"}}}}},
+			},
+			Metadata: []*api.Metadata{
+				{Name: "userinfo", UserInfo: &api.Metadata_UserInfo{IdentitySource: "unknown"}},
+				{Name: "userinfo-api-key", UserInfo: &api.Metadata_UserInfo{IdentitySource: "api-key"}},
+			},
+			Authorization: []*api.Authorization{
+				{Name: "rego", OPA: &api.Authorization_OPA{InlineRego: "allow { "}},
+				{Name: "json", JSON: &api.Authorization_JSONPatternMatching{Rules: []api.JSONPattern{
+					{JSONPatternRef: api.JSONPatternRef{JSONPatternName: "missing"}},
+					{JSONPatternExpression: api.JSONPatternExpression{Selector: "auth.identity.email", Operator: "matches", Value: "(["}},
+					{JSONPatternExpression: api.JSONPatternExpression{Selector: "auth.identity.email", Operator: "gt", Value: "1"}},
+					{JSONPatternExpression: api.JSONPatternExpression{Operator: "withinSchedule", Value: "* 9-17 * *"}},
+				}}},
+			},
+		},
+	}
+
+	var errs []string
+	for _, err := range ValidateAuthConfig(authConfig) {
+		errs = append(errs, err.Error())
+	}
+
+	assert.Equal(t, len(errs), 10)
+	assert.Equal(t, errs[0], "spec.hosts: at least one host or a targetRef is required")
+	assert.Equal(t, errs[1], `spec.identity[0].oidc.endpoint: invalid endpoint: "keycloak"`)
+	assert.Check(t, strings.HasPrefix(errs[2], "spec.identity[1].apiKey.selector: "))
+	assert.Equal(t, errs[3], "spec.metadata[0].userInfo.identitySource: identity source not found: unknown")
+	assert.Equal(t, errs[4], "spec.metadata[1].userInfo.identitySource: identity source is not of type oidc: api-key")
+	assert.Check(t, strings.Contains(errs[5], "rego_parse_error"))
+	assert.Equal(t, errs[6], "spec.authorization[1].json.rules[0].patternRef: named pattern not found: missing")
+	assert.Check(t, strings.HasPrefix(errs[7], "spec.authorization[1].json.rules[1].value: error parsing regexp"))
+	assert.Equal(t, errs[8], `spec.authorization[1].json.rules[2].operator: unsupported operator: "gt"`)
+	assert.Check(t, strings.HasPrefix(errs[9], "spec.authorization[1].json.rules[3].value: invalid schedule"))
+}
+
+func TestValidateHMACIdentity(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "webhooks"}}
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Identity: []*api.Identity{
+				{Name: "defaults", HMAC: &api.Identity_HMAC{Selector: selector}},
+				{Name: "signed-date", HMAC: &api.Identity_HMAC{Selector: selector, SignedComponents: []string{"method", "path", "date", "digest"}}},
+				{Name: "unsigned-date", HMAC: &api.Identity_HMAC{Selector: selector, SignedComponents: []string{"body"}}},
+				{Name: "unsupported", HMAC: &api.Identity_HMAC{Selector: selector, SignedComponents: []string{"date", "query"}}},
+			},
+		},
+	}
+
+	var errs []string
+	for _, err := range ValidateAuthConfig(authConfig) {
+		errs = append(errs, err.Error())
+	}
+
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, errs[0], "spec.identity[2].hmac.signedComponents: the date must be a signed component")
+	assert.Equal(t, errs[1], `spec.identity[3].hmac.signedComponents[1]: unsupported signed component: "query"`)
+}
+
+func TestValidateSAMLIdentity(t *testing.T) {
+	metadataRef := &api.JWKSReference{Kind: "ConfigMap", Name: "idp", Key: "metadata.xml"}
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Identity: []*api.Identity{
+				{Name: "metadata-ref", SAML: &api.Identity_SAML{IdPMetadataRef: metadataRef, Audiences: []string{"echo-api"}}},
+				{Name: "metadata-url", SAML: &api.Identity_SAML{IdPMetadataUrl: "https://idp/metadata", Audiences: []string{"echo-api"}}},
+				{Name: "both", SAML: &api.Identity_SAML{IdPMetadataRef: metadataRef, IdPMetadataUrl: "https://idp/metadata", Audiences: []string{"echo-api"}}},
+				{Name: "no-audiences", SAML: &api.Identity_SAML{IdPMetadataUrl: "https://idp/metadata"}},
+			},
+		},
+	}
+
+	var errs []string
+	for _, err := range ValidateAuthConfig(authConfig) {
+		errs = append(errs, err.Error())
+	}
+
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, errs[0], "spec.identity[2].saml: exactly one of idpMetadataRef or idpMetadataUrl must be set")
+	assert.Equal(t, errs[1], "spec.identity[3].saml.audiences: at least one audience must be set")
+}
+
+func TestValidateSQLMetadata(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Metadata: []*api.Metadata{
+				{Name: "ok", SQL: &api.Metadata_SQL{Driver: "postgres", DSN: &api.SecretKeyReference{Name: "db", Key: "dsn"}, Query: "select plan from entitlements where user_id = $1"}},
+				{Name: "no-dsn", SQL: &api.Metadata_SQL{Driver: "postgres", Query: "SELECT 1"}},
+				{Name: "no-query", SQL: &api.Metadata_SQL{Driver: "mysql", DSN: &api.SecretKeyReference{Name: "db", Key: "dsn"}}},
+				{Name: "write", SQL: &api.Metadata_SQL{Driver: "mysql", DSN: &api.SecretKeyReference{Name: "db", Key: "dsn"}, Query: "DELETE FROM entitlements"}},
+				{Name: "unknown-driver", SQL: &api.Metadata_SQL{Driver: "sqlite3", DSN: &api.SecretKeyReference{Name: "db", Key: "dsn"}, Query: "SELECT 1"}},
+			},
+		},
+	}
+
+	var errs []string
+	for _, err := range ValidateAuthConfig(authConfig) {
+		errs = append(errs, err.Error())
+	}
+
+	assert.Equal(t, len(errs), 4)
+	assert.Equal(t, errs[0], "spec.metadata[1].sql.dsnRef: dsnRef is required")
+	assert.Equal(t, errs[1], "spec.metadata[2].sql.query: query is required")
+	assert.Equal(t, errs[2], "spec.metadata[3].sql.query: only SELECT queries are supported")
+	assert.Equal(t, errs[3], `spec.metadata[4].sql.driver: unsupported sql driver: "sqlite3"`)
+}
+
+func TestValidateCELAuthorization(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Authorization: []*api.Authorization{
+				{Name: "ok", CEL: &api.Authorization_CEL{Expression: `"admin" in auth.identity.groups`}},
+				{Name: "syntax", CEL: &api.Authorization_CEL{Expression: `auth.identity.groups ==`}},
+				{Name: "not-bool", CEL: &api.Authorization_CEL{Expression: `1 + 1`}},
+			},
+		},
+	}
+
+	var errs []string
+	for _, err := range ValidateAuthConfig(authConfig) {
+		errs = append(errs, err.Error())
+	}
+
+	assert.Equal(t, len(errs), 2)
+	assert.Check(t, strings.HasPrefix(errs[0], "spec.authorization[1].cel.expression: "))
+	assert.Equal(t, errs[1], "spec.authorization[2].cel.expression: cel expression must evaluate to a bool, got int")
+}
+
+func TestValidateGRPCMetadata(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Metadata: []*api.Metadata{
+				{Name: "ok", GRPC: &api.Metadata_GRPC{Endpoint: "entitlements:50051", Method: "acme.entitlements.v1.Entitlements/GetPlan"}},
+				{Name: "invalid", GRPC: &api.Metadata_GRPC{Endpoint: "entitlements", Method: "GetPlan"}},
+			},
+		},
+	}
+
+	var errs []string
+	for _, err := range ValidateAuthConfig(authConfig) {
+		errs = append(errs, err.Error())
+	}
+
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, errs[0], `spec.metadata[1].grpc.endpoint: invalid endpoint: "entitlements"`)
+	assert.Equal(t, errs[1], `spec.metadata[1].grpc.method: invalid method: "GetPlan" (expected <package>.<service>/<method>)`)
+}
+
+func TestValidateHostOverrides(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			HostOverrides: map[string]api.HostOverride{
+				"echo-api": {DenyWith: &api.DenyWith{}},
+				"other.io": {DenyWith: &api.DenyWith{}},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 1)
+	assert.Equal(t, errs[0].Error(), "spec.hostOverrides.other.io: host not listed in spec.hosts")
+}
+
+func TestValidateHeadersToRemove(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts:           []string{"echo-api"},
+			HeadersToRemove: []string{"Authorization", "x-api-key"},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 0)
+
+	authConfig.Spec.HeadersToRemove = []string{"", ":authority", "Host", "x-api-key"}
+
+	errs = ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 3)
+	assert.Equal(t, errs[0].Error(), "spec.headersToRemove[0]: header name cannot be empty")
+	assert.Equal(t, errs[1].Error(), "spec.headersToRemove[1]: header :authority cannot be removed")
+	assert.Equal(t, errs[2].Error(), "spec.headersToRemove[2]: header Host cannot be removed")
+}
+
+func TestValidateTargetRef(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			TargetRef: &api.TargetRef{Kind: "HTTPRoute", Name: "talker-api"},
+			HostOverrides: map[string]api.HostOverride{
+				"talker-api.io": {DenyWith: &api.DenyWith{}},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 0)
+
+	authConfig.Spec.TargetRef = &api.TargetRef{Group: "networking.k8s.io", Kind: "Ingress", Name: "talker-api"}
+
+	errs = ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, errs[0].Error(), "spec.targetRef.kind: unsupported kind Ingress")
+	assert.Equal(t, errs[1].Error(), "spec.targetRef.group: unsupported group networking.k8s.io")
+}
+
+func TestValidateBaseAuthConfig(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "base", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Defaults: &api.SharedSpec{
+				DenyWith: &api.DenyWith{Unauthorized: &api.DenyWithSpec{BodyTemplate: "{{.Missing"}},
+			},
+			Overrides: &api.SharedSpec{},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 3)
+	assert.Equal(t, errs[0].Error(), "spec.hosts: at least one host or a targetRef is required")
+	assert.Equal(t, errs[1].Error(), "spec.defaults: requires the authorino.kuadrant.io/base annotation")
+	assert.Equal(t, errs[2].Error(), "spec.overrides: requires the authorino.kuadrant.io/base annotation")
+
+	authConfig.Annotations = map[string]string{api.BaseAuthConfigAnnotation: "true"}
+
+	errs = ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "spec.defaults.denyWith.unauthorized.bodyTemplate: ")
+}
+
+func TestValidateDuplicateNames(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Identity: []*api.Identity{
+				{Name: "anonymous", Anonymous: &api.Identity_Anonymous{}},
+				{Name: "anonymous", Anonymous: &api.Identity_Anonymous{}},
+				{Name: "plain", Plain: &api.Identity_Plain{AuthJSON: "context.request.http.headers.x-user"}},
+				{Name: "anonymous", Anonymous: &api.Identity_Anonymous{}},
+			},
+			HostOverrides: map[string]api.HostOverride{
+				"echo-api": {
+					Response: []*api.Response{
+						{Name: "x-user", Wrapper: "httpHeader", JSON: &api.Response_DynamicJSON{}},
+						{Name: "x-user", Wrapper: "httpHeader", JSON: &api.Response_DynamicJSON{}},
+					},
+				},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, errs[0].Error(), "spec.identity: duplicate names: anonymous")
+	assert.Equal(t, errs[1].Error(), "spec.hostOverrides.echo-api.response: duplicate names: x-user")
+}
+
+func TestValidateCustomEvaluators(t *testing.T) {
+	custom_evaluators.Register(custom_evaluators.Identity, "test-validate-custom", func(_ []byte, _ context.Context) (auth.AuthConfigEvaluator, error) {
+		return &identity_evaluators.Noop{}, nil
+	})
+
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Identity: []*api.Identity{
+				{Name: "custom", Custom: &api.CustomEvaluator{Name: "test-validate-custom"}},
+			},
+			Authorization: []*api.Authorization{
+				{Name: "custom", Custom: &api.CustomEvaluator{Name: "test-validate-custom"}},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 1)
+	assert.Equal(t, errs[0].Error(), `spec.authorization[0].custom.name: custom authorization evaluator not registered in this build: "test-validate-custom"`)
+}
+
+func TestValidateExternalEvaluators(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Metadata: []*api.Metadata{
+				{Name: "plugin-1", External: &api.ExternalEvaluator{Endpoint: "plugin.authorino.svc:50051"}},
+				{Name: "plugin-2", External: &api.ExternalEvaluator{Endpoint: "plugin.authorino.svc"}},
+			},
+			Authorization: []*api.Authorization{
+				{Name: "plugin-3", External: &api.ExternalEvaluator{}},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 2)
+	assert.Equal(t, errs[0].Error(), `spec.metadata[1].external.endpoint: invalid endpoint: "plugin.authorino.svc"`)
+	assert.Equal(t, errs[1].Error(), "spec.authorization[0].external.endpoint: endpoint is required")
+}
+
+func TestValidateDenyWithTemplates(t *testing.T) {
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			DenyWith: &api.DenyWith{
+				Unauthenticated: &api.DenyWithSpec{BodyTemplate: `<p>Hello, {{.auth.identity.username}}</p>`, ContentType: "text/html"},
+				Unauthorized:    &api.DenyWithSpec{BodyTemplate: `{"user":{{json .auth.identity.username}`},
+				Unmatched:       &api.DenyWithSpec{Redirect: &api.DenyWithRedirect{Location: `https://sso.acme.com/login?redirect_uri={{requestURL .`}},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 2)
+	assert.ErrorContains(t, errs[0], "spec.denyWith.unauthorized.bodyTemplate: template: body:1:")
+	assert.ErrorContains(t, errs[1], "spec.denyWith.unmatched.redirect.location: template: body:1:")
+}
+
+func TestValidateRegoWithLibraries(t *testing.T) {
+	libraries := []k8score.LocalObjectReference{{Name: "rego-libs"}}
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Authorization: []*api.Authorization{
+				{Name: "rego-1", OPA: &api.Authorization_OPA{InlineRego: "import data.lib.helpers\nallow { helpers.is_admin(input.auth.identity) }", Libraries: libraries}},
+				{Name: "rego-2", OPA: &api.Authorization_OPA{InlineRego: "allow { ", Libraries: libraries}},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], "spec.authorization[1].opa.inlineRego: ")
+}
+
+func TestValidateResponses(t *testing.T) {
+	tokenDuration := int64(0)
+	authConfig := &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth-config-1", Namespace: "authorino"},
+		Spec: api.AuthConfigSpec{
+			Hosts: []string{"echo-api"},
+			Response: []*api.Response{
+				{Name: "wristband", Wristband: &api.Response_Wristband{
+					Issuer: "http://authorino:8083/authorino/auth-config-1/wristband",
+					CustomClaims: []api.JsonProperty{
+						{Name: "tenant", ValueFrom: api.ValueFrom{AuthJSON: "auth.metadata.uma.tenant"}},
+						{Name: "exp", ValueFrom: api.ValueFrom{AuthJSON: "auth.identity.exp"}},
+					},
+					TokenDuration: &tokenDuration,
+				}},
+				{Name: "rate-limit", Wrapper: "httpHeader", RateLimitDescriptors: &api.Response_RateLimitDescriptors{}},
+				{Name: "session", Wrapper: "httpCookie", JSON: &api.Response_DynamicJSON{}},
+				{Name: "session cookie", Wrapper: "httpCookie", JSON: &api.Response_DynamicJSON{}},
+			},
+		},
+	}
+
+	errs := ValidateAuthConfig(authConfig)
+	assert.Equal(t, len(errs), 4)
+	assert.Error(t, errs[0], "spec.response[0].wristband.customClaims[1]: claim exp is reserved")
+	assert.Error(t, errs[1], "spec.response[0].wristband.tokenDuration: must be greater than 0")
+	assert.Error(t, errs[2], "spec.response[1].wrapper: rate limit descriptors must be wrapped as envoyDynamicMetadata")
+	assert.Error(t, errs[3], `spec.response[3].wrapperKey: invalid cookie name: "session cookie"`)
+}
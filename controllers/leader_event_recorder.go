@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// NewLeaderEventRecorder wraps an event recorder so events are only recorded after the replica is elected leader,
+// i.e. after the elected channel is closed. Events of the replicas that are not the leader are dropped, the same way
+// only the leader updates the status of the resources.
+func NewLeaderEventRecorder(recorder record.EventRecorder, elected <-chan struct{}) record.EventRecorder {
+	return &leaderEventRecorder{recorder: recorder, elected: elected}
+}
+
+type leaderEventRecorder struct {
+	recorder record.EventRecorder
+	elected  <-chan struct{}
+}
+
+func (r *leaderEventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.isLeader() {
+		r.recorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r *leaderEventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.isLeader() {
+		r.recorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *leaderEventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.isLeader() {
+		r.recorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *leaderEventRecorder) isLeader() bool {
+	select {
+	case <-r.elected:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestLeaderEventRecorder(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	fakeRecorder := record.NewFakeRecorder(3)
+	elected := make(chan struct{})
+	recorder := NewLeaderEventRecorder(fakeRecorder, elected)
+
+	recorder.Event(&authConfig, "Warning", "NotLeader", "dropped")
+	recorder.Eventf(&authConfig, "Warning", "NotLeader", "%s", "dropped")
+	recorder.AnnotatedEventf(&authConfig, nil, "Warning", "NotLeader", "%s", "dropped")
+	assert.Equal(t, len(fakeRecorder.Events), 0)
+
+	close(elected)
+
+	recorder.Event(&authConfig, "Warning", "Leader", "recorded")
+	recorder.Eventf(&authConfig, "Warning", "Leader", "%s", "recorded")
+	recorder.AnnotatedEventf(&authConfig, nil, "Warning", "Leader", "%s", "recorded")
+	assert.Equal(t, len(fakeRecorder.Events), 3)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, <-fakeRecorder.Events, "Warning Leader recorded")
+	}
+}
+
+func TestReconcileEventsOnlyRecordedByTheLeader(t *testing.T) {
+	authConfig := newTestAuthConfig(map[string]string{})
+	authConfigName := types.NamespacedName{Name: authConfig.Name, Namespace: authConfig.Namespace}
+	fakeRecorder := record.NewFakeRecorder(1)
+	elected := make(chan struct{})
+
+	reconciler := newTestAuthConfigReconciler(newTestK8sClient(&authConfig), index.NewIndex())
+	reconciler.Recorder = NewLeaderEventRecorder(fakeRecorder, elected)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.ErrorContains(t, err, "not found")
+	assert.Equal(t, len(fakeRecorder.Events), 0)
+
+	close(elected)
+
+	_, err = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: authConfigName})
+	assert.ErrorContains(t, err, "not found")
+	assert.Equal(t, <-fakeRecorder.Events, "Warning SecretNotFound "+err.Error())
+}
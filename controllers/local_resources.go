@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultLocalResourceNamespace = "default"
+
+// LocalResource is a Kubernetes resource read from a local file
+type LocalResource struct {
+	Path   string
+	Object client.Object
+}
+
+// ReadLocalResources reads the AuthConfigs, ClusterAuthConfigs and Secrets from YAML or JSON files, possibly with multiple documents each.
+// Namespaced resources that do not specify a namespace are set to the "default" namespace.
+// Directories are read (non-recursively) for files with extension .yaml, .yml or .json. Resources of other kinds are ignored.
+func ReadLocalResources(paths ...string) ([]LocalResource, error) {
+	var resources []LocalResource
+
+	for _, path := range paths {
+		files, err := listLocalResourceFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			objects, err := readLocalResourceFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			for _, obj := range objects {
+				resources = append(resources, LocalResource{Path: file, Object: obj})
+			}
+		}
+	}
+
+	return resources, nil
+}
+
+func listLocalResourceFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+func readLocalResourceFile(path string) ([]client.Object, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var objects []client.Object
+	decoder := k8syaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		raw := runtime.RawExtension{}
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(raw.Raw) == 0 || string(raw.Raw) == "null" {
+			continue
+		}
+
+		typeMeta := metav1.TypeMeta{}
+		if err := json.Unmarshal(raw.Raw, &typeMeta); err != nil {
+			return nil, err
+		}
+
+		var obj client.Object
+		switch typeMeta.Kind {
+		case "AuthConfig":
+			obj = &api.AuthConfig{}
+		case "ClusterAuthConfig":
+			obj = &api.ClusterAuthConfig{}
+		case "Secret":
+			obj = &v1.Secret{}
+		default:
+			continue
+		}
+		if err := json.Unmarshal(raw.Raw, obj); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", typeMeta.Kind, err)
+		}
+		if _, clusterScoped := obj.(*api.ClusterAuthConfig); obj.GetNamespace() == "" && !clusterScoped {
+			obj.SetNamespace(defaultLocalResourceNamespace)
+		}
+		if secret, ok := obj.(*v1.Secret); ok {
+			// as the Kubernetes API server would do, `stringData` entries take precedence over the ones in `data`
+			for key, value := range secret.StringData {
+				if secret.Data == nil {
+					secret.Data = map[string][]byte{}
+				}
+				secret.Data[key] = []byte(value)
+			}
+			secret.StringData = nil
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const (
+	localResourcesReloadDelay   = 500 * time.Millisecond
+	localResourcesMinRetryDelay = time.Second
+	localResourcesMaxRetryDelay = 5 * time.Minute
+)
+
+// LocalResourcesReconciler reconciles AuthConfigs, ClusterAuthConfigs and Secrets read from local files into the index, without a Kubernetes API server.
+// The resources are served to the AuthConfig reconciler by an in-memory client, rebuilt on every reload.
+type LocalResourcesReconciler struct {
+	Paths         []string
+	Logger        logr.Logger
+	Scheme        *runtime.Scheme
+	Index         index.Index
+	StatusReport  *StatusReportMap
+	LabelSelector labels.Selector
+	// AllowCrossNamespaceAPIKeys allows api key identities to read the api key secrets from a namespace other than the
+	// one of the authconfig
+	AllowCrossNamespaceAPIKeys bool
+
+	mu          sync.Mutex
+	authConfigs map[types.NamespacedName]bool
+	retry       bool
+}
+
+// Reconcile reads all the local resources and reconciles every AuthConfig, including the ones no longer found in the files
+func (r *LocalResourcesReconciler) Reconcile(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	resources, err := ReadLocalResources(r.Paths...)
+	if err != nil {
+		return err
+	}
+
+	objects := make([]client.Object, 0, len(resources))
+	authConfigs := make(map[types.NamespacedName]bool)
+	for _, resource := range resources {
+		objects = append(objects, resource.Object)
+		if _, ok := resource.Object.(*api.AuthConfig); ok {
+			authConfigs[client.ObjectKeyFromObject(resource.Object)] = true
+		}
+	}
+
+	reconciler := &AuthConfigReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(r.Scheme).WithObjects(objects...).Build(),
+		Logger:        r.Logger.WithName("authconfig"),
+		Scheme:        r.Scheme,
+		Index:         r.Index,
+		StatusReport:  r.StatusReport,
+		LabelSelector: r.LabelSelector,
+
+		AllowCrossNamespaceAPIKeys: r.AllowCrossNamespaceAPIKeys,
+	}
+
+	for name := range r.authConfigs {
+		if !authConfigs[name] {
+			authConfigs[name] = false // deleted
+		}
+	}
+
+	r.retry = false
+	for name, found := range authConfigs {
+		if result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+			r.Logger.Error(err, "failed to reconcile local resource", "authconfig", name.String())
+		} else if result.Requeue {
+			r.retry = true
+		}
+		if !found {
+			delete(authConfigs, name)
+		}
+	}
+
+	r.authConfigs = authConfigs
+	return nil
+}
+
+// Watch reconciles the local resources whenever the files change, until the context is done
+func (r *LocalResourcesReconciler) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, path := range r.Paths {
+		if err := watcher.Add(path); err != nil {
+			return err
+		}
+	}
+
+	// editors and config map volumes often write files in multiple steps, so the events are debounced.
+	// authconfigs that could not be fully reconciled (e.g. issuer unreachable) are retried with exponential backoff.
+	var reload <-chan time.Time
+	retryDelay := localResourcesMinRetryDelay
+	if r.needsRetry() {
+		reload = time.After(retryDelay)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			r.Logger.V(1).Info("local resources changed", "file", event.Name, "op", event.Op.String())
+			reload = time.After(localResourcesReloadDelay)
+			retryDelay = localResourcesMinRetryDelay
+		case err := <-watcher.Errors:
+			r.Logger.Error(err, "failed to watch local resources")
+		case <-reload:
+			reload = nil
+			if err := r.Reconcile(ctx); err != nil {
+				r.Logger.Error(err, "failed to reload local resources")
+			}
+			if r.needsRetry() {
+				reload = time.After(retryDelay)
+				if retryDelay *= 2; retryDelay > localResourcesMaxRetryDelay {
+					retryDelay = localResourcesMaxRetryDelay
+				}
+			} else {
+				retryDelay = localResourcesMinRetryDelay
+			}
+		}
+	}
+}
+
+func (r *LocalResourcesReconciler) needsRetry() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retry
+}
+
+// Ready tells whether all local AuthConfigs have been reconciled
+func (r *LocalResourcesReconciler) Ready(includes, excludes []string, verbose bool) error {
+	return (&AuthConfigReconciler{StatusReport: r.StatusReport}).Ready(includes, excludes, verbose)
+}
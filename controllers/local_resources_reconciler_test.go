@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestLocalResourcesReconciler(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "talker-api.yaml")
+
+	writeAuthConfig := func(hosts string) {
+		content := `apiVersion: authorino.kuadrant.io/v1beta1
+kind: AuthConfig
+metadata:
+  name: talker-api-protection
+spec:
+  hosts: ` + hosts + `
+  identity:
+  - name: friends
+    apiKey:
+      selector:
+        matchLabels:
+          group: friends
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: api-key-1
+  labels:
+    group: friends
+stringData:
+  api_key: ndyBzreUzF4zqDQsqSPMHkRhriEOtcRx
+`
+		assert.NilError(t, os.WriteFile(file, []byte(content), 0600))
+	}
+
+	scheme := runtime.NewScheme()
+	_ = api.AddToScheme(scheme)
+	_ = v1.AddToScheme(scheme)
+
+	reconciler := &LocalResourcesReconciler{
+		Paths:        []string{dir},
+		Logger:       log.WithName("test"),
+		Scheme:       scheme,
+		Index:        index.NewIndex(),
+		StatusReport: NewStatusReportMap(),
+	}
+
+	writeAuthConfig("[talker-api, talker-api.io]")
+	assert.NilError(t, reconciler.Reconcile(context.TODO()))
+	assert.Check(t, reconciler.Index.Get("talker-api") != nil)
+	assert.Check(t, reconciler.Index.Get("talker-api.io") != nil)
+	assert.NilError(t, reconciler.Ready([]string{AuthConfigsReadyzSubpath}, nil, false))
+
+	writeAuthConfig("[talker-api]")
+	assert.NilError(t, reconciler.Reconcile(context.TODO()))
+	assert.Check(t, reconciler.Index.Get("talker-api") != nil)
+	assert.Check(t, reconciler.Index.Get("talker-api.io") == nil)
+
+	assert.NilError(t, os.Remove(file))
+	assert.NilError(t, reconciler.Reconcile(context.TODO()))
+	assert.Check(t, reconciler.Index.Get("talker-api") == nil)
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestReadLocalResources(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlContent := `apiVersion: authorino.kuadrant.io/v1beta1
+kind: AuthConfig
+metadata:
+  name: talker-api-protection
+  namespace: authorino
+spec:
+  hosts:
+  - talker-api
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: api-key-1
+  namespace: authorino
+stringData:
+  api_key: ndyBzreUzF4zqDQsqSPMHkRhriEOtcRx
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: authorino.kuadrant.io/v1beta1
+kind: ClusterAuthConfig
+metadata:
+  name: baseline
+spec:
+  identity:
+  - name: corporate-sso
+    oidc:
+      endpoint: https://sso.acme.com
+`
+	jsonContent := `{"apiVersion":"authorino.kuadrant.io/v1beta1","kind":"AuthConfig","metadata":{"name":"other","namespace":"authorino"},"spec":{"hosts":["other"]}}`
+
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "resources.yaml"), []byte(yamlContent), 0600))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "other.json"), []byte(jsonContent), 0600))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# ignored"), 0600))
+
+	resources, err := ReadLocalResources(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(resources), 4)
+
+	authConfig, ok := resources[0].Object.(*api.AuthConfig)
+	assert.Check(t, ok)
+	assert.Equal(t, authConfig.Name, "other")
+	assert.Equal(t, resources[0].Path, filepath.Join(dir, "other.json"))
+
+	authConfig, ok = resources[1].Object.(*api.AuthConfig)
+	assert.Check(t, ok)
+	assert.Equal(t, authConfig.Name, "talker-api-protection")
+	assert.DeepEqual(t, authConfig.Spec.Hosts, []string{"talker-api"})
+
+	secret, ok := resources[2].Object.(*v1.Secret)
+	assert.Check(t, ok)
+	assert.Equal(t, string(secret.Data["api_key"]), "ndyBzreUzF4zqDQsqSPMHkRhriEOtcRx")
+
+	clusterAuthConfig, ok := resources[3].Object.(*api.ClusterAuthConfig)
+	assert.Check(t, ok)
+	assert.Equal(t, clusterAuthConfig.Name, "baseline")
+	assert.Equal(t, clusterAuthConfig.Namespace, "") // cluster-scoped
+	assert.Equal(t, clusterAuthConfig.Spec.Identity[0].Name, "corporate-sso")
+}
+
+func TestReadLocalResourcesMissingFile(t *testing.T) {
+	_, err := ReadLocalResources(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Check(t, os.IsNotExist(err))
+}
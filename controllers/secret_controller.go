@@ -40,6 +40,8 @@ type SecretReconciler struct {
 func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := r.Logger.WithValues("secret", req.NamespacedName)
 
+	authConfigs := r.Index.List()
+
 	secret := v1.Secret{}
 	if err := r.Client.Get(ctx, req.NamespacedName, &secret); err != nil && !errors.IsNotFound(err) {
 		// could not get the resource but not because of a 404 Not found, some error must have happened
@@ -48,14 +50,20 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		// could not find the resource (404 Not found, resource must have been deleted)
 		// or the resource is no longer to be watched (labels no longer match)
 		// => delete the K8s Secret-based identity from all AuthConfigs
-		r.eachAuthConfigsWithK8sSecretBasedIdentity(func(authConfig *evaluators.AuthConfig) {
+		r.eachAuthConfigsWithK8sSecretBasedIdentity(authConfigs, func(authConfig *evaluators.AuthConfig) {
 			r.revokeK8sSecretBasedIdentity(ctx, authConfig, req.NamespacedName)
 		})
 	} else {
 		// resource found => if the K8s Secret labels match, update all AuthConfigs
-		r.eachAuthConfigsWithK8sSecretBasedIdentity(func(authConfig *evaluators.AuthConfig) {
+		r.eachAuthConfigsWithK8sSecretBasedIdentity(authConfigs, func(authConfig *evaluators.AuthConfig) {
 			r.refreshK8sSecretBasedIdentity(ctx, authConfig, secret)
 		})
+		// rotate the signing keys of the wristbands stored in the secret
+		for _, authConfig := range authConfigs {
+			if authConfig.Labels["namespace"] == secret.Namespace {
+				r.refreshK8sSecretBasedSigningKeys(ctx, authConfig, secret)
+			}
+		}
 	}
 
 	logger.Info("resource reconciled")
@@ -72,24 +80,24 @@ func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-func (r *SecretReconciler) eachAuthConfigsWithK8sSecretBasedIdentity(f func(*evaluators.AuthConfig)) {
-	for authConfig := range r.getAuthConfigsWithK8sSecretBasedIdentity() {
+func (r *SecretReconciler) eachAuthConfigsWithK8sSecretBasedIdentity(authConfigs []*evaluators.AuthConfig, f func(*evaluators.AuthConfig)) {
+	for authConfig := range r.getAuthConfigsWithK8sSecretBasedIdentity(authConfigs) {
 		f(authConfig)
 	}
 }
 
-func (r *SecretReconciler) getAuthConfigsWithK8sSecretBasedIdentity() authConfigSet {
-	authConfigs := make(authConfigSet)
+func (r *SecretReconciler) getAuthConfigsWithK8sSecretBasedIdentity(authConfigs []*evaluators.AuthConfig) authConfigSet {
+	set := make(authConfigSet)
 	var s struct{}
-	for _, authConfig := range r.Index.List() {
+	for _, authConfig := range authConfigs {
 		for _, identityEvaluator := range authConfig.IdentityConfigs {
 			if _, ok := identityEvaluator.(auth.K8sSecretBasedIdentityConfigEvaluator); ok {
-				authConfigs[authConfig] = s
+				set[authConfig] = s
 				break
 			}
 		}
 	}
-	return authConfigs
+	return set
 }
 
 func (r *SecretReconciler) revokeK8sSecretBasedIdentity(ctx context.Context, authConfig *evaluators.AuthConfig, deleted types.NamespacedName) {
@@ -123,6 +131,23 @@ func (r *SecretReconciler) refreshK8sSecretBasedIdentity(ctx context.Context, au
 	}
 }
 
+func (r *SecretReconciler) refreshK8sSecretBasedSigningKeys(ctx context.Context, authConfig *evaluators.AuthConfig, secret v1.Secret) {
+	logger := log.FromContext(ctx).WithValues("authconfig", authConfigName(authConfig))
+	for _, responseEvaluator := range authConfig.ResponseConfigs {
+		ev, ok := responseEvaluator.(auth.ResponseConfigEvaluator)
+		if !ok {
+			continue
+		}
+		if issuer, ok := ev.GetWristbandIssuer().(auth.K8sSecretBasedSigningKeysIssuer); ok {
+			if refreshed, err := issuer.RefreshK8sSecretBasedSigningKey(ctx, secret); err != nil {
+				logger.Error(err, "failed to rotate the signing key", "config", ev.GetName())
+			} else if refreshed {
+				logger.V(1).Info("signing key rotated", "config", ev.GetName())
+			}
+		}
+	}
+}
+
 func authConfigName(authConfig *evaluators.AuthConfig) string {
 	return types.NamespacedName{Namespace: authConfig.Labels["namespace"], Name: authConfig.Labels["name"]}.String()
 }
@@ -2,6 +2,9 @@ package controllers
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 
 	"gotest.tools/assert"
@@ -11,10 +14,12 @@ import (
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators"
 	identity_evaluators "github.com/kuadrant/authorino/pkg/evaluators/identity"
+	response_evaluators "github.com/kuadrant/authorino/pkg/evaluators/response"
 	mock_index "github.com/kuadrant/authorino/pkg/index/mocks"
 	"github.com/kuadrant/authorino/pkg/log"
 
 	"github.com/golang/mock/gomock"
+	jose "gopkg.in/square/go-jose.v2"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -94,7 +99,7 @@ func newSecretReconcilerTest(mockCtrl *gomock.Controller, secretLabels map[strin
 	indexedAuthConfig := &evaluators.AuthConfig{
 		Labels: map[string]string{"namespace": "authorino", "name": "api-protection"},
 		IdentityConfigs: []auth.AuthConfigEvaluator{&fakeAPIKeyIdentityConfig{
-			evaluator: identity_evaluators.NewApiKeyIdentity("api-key", apiKeyLabelSelectors, "", auth.NewAuthCredential("", ""), fakeK8sClient, context.TODO()),
+			evaluator: identity_evaluators.NewApiKeyIdentity("api-key", apiKeyLabelSelectors, "", "", auth.NewAuthCredential("", ""), fakeK8sClient, context.TODO()),
 		}},
 	}
 	indexMock := mock_index.NewMockIndex(mockCtrl)
@@ -197,3 +202,58 @@ func TestMatchingSecretLabels(t *testing.T) {
 	assert.Check(t, apiKeyIdentityConfig.refreshed)
 	assert.NilError(t, err)
 }
+
+func newTestEd25519SigningKey() []byte {
+	_, key, _ := ed25519.GenerateKey(nil)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestRotateWristbandSigningKey(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	secret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "wristband-signing-key",
+			Namespace: "authorino",
+			Labels:    map[string]string{"authorino.kuadrant.io/managed-by": "authorino"},
+		},
+		Data: map[string][]byte{"key.pem": newTestEd25519SigningKey()},
+	}
+	scheme := runtime.NewScheme()
+	_ = v1.AddToScheme(scheme)
+
+	signingKey, _ := response_evaluators.NewSigningKey("wristband-signing-key", "EdDSA", newTestEd25519SigningKey())
+	wristband, _ := response_evaluators.NewWristbandConfig("http://authorino", nil, nil, []jose.JSONWebKey{*signingKey})
+	jwks, _ := wristband.JWKS()
+
+	newIndexedAuthConfig := func(namespace string) *evaluators.AuthConfig {
+		return &evaluators.AuthConfig{
+			Labels:          map[string]string{"namespace": namespace, "name": "api-protection"},
+			ResponseConfigs: []auth.AuthConfigEvaluator{&evaluators.ResponseConfig{Name: "wristband", Wristband: wristband}},
+		}
+	}
+
+	indexMock := mock_index.NewMockIndex(mockCtrl)
+	secretReconciler := &SecretReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(&secret).Build(),
+		Logger:        log.WithName("test").WithName("secretreconciler"),
+		Index:         indexMock,
+		LabelSelector: ToLabelSelector("authorino.kuadrant.io/managed-by=authorino"),
+	}
+	request := controllerruntime.Request{NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}}
+
+	// authconfig in another namespace
+	indexMock.EXPECT().List().Return([]*evaluators.AuthConfig{newIndexedAuthConfig("other")})
+	_, err := secretReconciler.Reconcile(context.Background(), request)
+	assert.NilError(t, err)
+	rotatedJWKS, _ := wristband.JWKS()
+	assert.Equal(t, rotatedJWKS, jwks)
+
+	indexMock.EXPECT().List().Return([]*evaluators.AuthConfig{newIndexedAuthConfig("authorino")})
+	_, err = secretReconciler.Reconcile(context.Background(), request)
+	assert.NilError(t, err)
+	rotatedJWKS, _ = wristband.JWKS()
+	assert.Check(t, rotatedJWKS != jwks)
+}
@@ -4,6 +4,7 @@ import (
 	"sync"
 	"time"
 
+	api "github.com/kuadrant/authorino/api/v1beta1"
 	"github.com/kuadrant/authorino/pkg/utils"
 )
 
@@ -26,7 +27,7 @@ func (m *StatusReportMap) Get(id string) (status StatusReport, found bool) {
 	return
 }
 
-func (m *StatusReportMap) Set(id, reason, message string, hosts []string) {
+func (m *StatusReportMap) Set(id, reason, message string, hosts []string, failedHosts ...api.HostStatus) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -34,6 +35,7 @@ func (m *StatusReportMap) Set(id, reason, message string, hosts []string) {
 		Reason:        reason,
 		Message:       message,
 		LinkedHosts:   hosts,
+		FailedHosts:   failedHosts,
 		LastUpdatedAt: time.Now(),
 	}
 }
@@ -56,5 +58,6 @@ type StatusReport struct {
 	Reason        string
 	Message       string
 	LinkedHosts   []string
+	FailedHosts   []api.HostStatus
 	LastUpdatedAt time.Time
 }
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/index"
+	"github.com/kuadrant/authorino/pkg/utils"
+)
+
+// AuthConfigReport is the read-only view of an AuthConfig reconciled by Authorino, as enforced by the data plane
+type AuthConfigReport struct {
+	// Id of the AuthConfig resource (namespace/name)
+	Id string `json:"id"`
+	// Hosts of the AuthConfig currently in the index, i.e. the ones the authorization requests are matched against
+	Hosts []string `json:"hosts"`
+	// FailedHosts are the hosts of the AuthConfig that could not be indexed
+	FailedHosts []api.HostStatus `json:"failedHosts,omitempty"`
+	// Ready tells whether the last reconciliation of the AuthConfig succeeded
+	Ready bool `json:"ready"`
+	// Reason and Message of the status of the last reconciliation of the AuthConfig
+	Reason  string `json:"reason"`
+	Message string `json:"message,omitempty"`
+	// LastReconciledAt is the time of the last reconciliation of the AuthConfig
+	LastReconciledAt time.Time `json:"lastReconciledAt"`
+}
+
+// StatusReportHandler returns an http handler that lists the AuthConfigs reconciled by Authorino, with the hosts
+// currently in the index, the readiness and the time of the last reconciliation of each AuthConfig.
+// The list can be filtered by AuthConfig id and by host, with the `id` and the `host` query string parameters.
+// The host filter matches the hosts exactly as listed in the AuthConfig, i.e. wildcards are not resolved.
+func StatusReportHandler(authConfigIndex index.Index, statusReport *StatusReportMap) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := req.URL.Query()
+		filterId, filterHost := query.Get("id"), query.Get("host")
+
+		reports := []AuthConfigReport{}
+		for id, status := range statusReport.ReadAll() {
+			if filterId != "" && id != filterId {
+				continue
+			}
+			hosts := append([]string{}, authConfigIndex.FindKeys(id)...)
+			if filterHost != "" && !utils.SliceContains(hosts, filterHost) {
+				continue
+			}
+			reports = append(reports, AuthConfigReport{
+				Id:               id,
+				Hosts:            hosts,
+				FailedHosts:      status.FailedHosts,
+				Ready:            status.Reason == api.StatusReasonReconciled,
+				Reason:           status.Reason,
+				Message:          status.Message,
+				LastReconciledAt: status.LastUpdatedAt,
+			})
+		}
+		sort.Slice(reports, func(i, j int) bool { return reports[i].Id < reports[j].Id })
+
+		resp.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(resp).Encode(map[string]interface{}{"authConfigs": reports})
+	})
+}
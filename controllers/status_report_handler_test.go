@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"gotest.tools/assert"
+)
+
+func TestStatusReportHandler(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	_ = authConfigIndex.Set("ns/dogs", "dogs.pets.com", evaluators.AuthConfig{}, false)
+	_ = authConfigIndex.Set("ns/dogs", "dogs.animals.com", evaluators.AuthConfig{}, false)
+	_ = authConfigIndex.Set("ns/cats", "cats.pets.com", evaluators.AuthConfig{}, false)
+
+	statusReport := NewStatusReportMap()
+	statusReport.Set("ns/dogs", api.StatusReasonReconciled, "", []string{"dogs.pets.com", "dogs.animals.com"})
+	statusReport.Set("ns/cats", api.StatusReasonHostsNotLinked, "one or more hosts are not linked to the resource", []string{"cats.pets.com"}, api.HostStatus{Host: "pets.com", Reason: api.StatusReasonHostTaken})
+	statusReport.Set("ns/birds", api.StatusReasonInvalidResource, "invalid resource", []string{})
+
+	handler := StatusReportHandler(authConfigIndex, statusReport)
+
+	request := func(method, path string) (*httptest.ResponseRecorder, []AuthConfigReport) {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(method, path, nil))
+		var body struct {
+			AuthConfigs []AuthConfigReport `json:"authConfigs"`
+		}
+		_ = json.Unmarshal(resp.Body.Bytes(), &body)
+		return resp, body.AuthConfigs
+	}
+
+	resp, reports := request(http.MethodGet, "/debug/authconfigs")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, resp.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, len(reports), 3)
+
+	birds := reports[0]
+	assert.Equal(t, birds.Id, "ns/birds")
+	assert.DeepEqual(t, birds.Hosts, []string{})
+	assert.Check(t, !birds.Ready)
+	assert.Equal(t, birds.Reason, api.StatusReasonInvalidResource)
+
+	cats := reports[1]
+	assert.Equal(t, cats.Id, "ns/cats")
+	assert.DeepEqual(t, cats.Hosts, []string{"cats.pets.com"})
+	assert.DeepEqual(t, cats.FailedHosts, []api.HostStatus{{Host: "pets.com", Reason: api.StatusReasonHostTaken}})
+	assert.Check(t, !cats.Ready)
+
+	dogs := reports[2]
+	assert.Equal(t, dogs.Id, "ns/dogs")
+	assert.DeepEqual(t, dogs.Hosts, []string{"dogs.pets.com", "dogs.animals.com"})
+	assert.Check(t, dogs.Ready)
+	assert.Equal(t, dogs.Reason, api.StatusReasonReconciled)
+	assert.Check(t, !dogs.LastReconciledAt.IsZero())
+
+	_, reports = request(http.MethodGet, "/debug/authconfigs?host=dogs.animals.com")
+	assert.Equal(t, len(reports), 1)
+	assert.Equal(t, reports[0].Id, "ns/dogs")
+
+	_, reports = request(http.MethodGet, "/debug/authconfigs?id=ns/cats")
+	assert.Equal(t, len(reports), 1)
+	assert.Equal(t, reports[0].Id, "ns/cats")
+
+	_, reports = request(http.MethodGet, "/debug/authconfigs?id=ns/cats&host=dogs.pets.com")
+	assert.Equal(t, len(reports), 0)
+
+	resp, _ = request(http.MethodDelete, "/debug/authconfigs")
+	assert.Equal(t, resp.Code, http.StatusMethodNotAllowed)
+}
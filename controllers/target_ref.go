@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Gateway API resources are read as unstructured objects, so Authorino does not depend on a particular release of the
+// Gateway API module nor requires the CRDs to be installed in the cluster
+const (
+	gatewayAPIGroup   = "gateway.networking.k8s.io"
+	gatewayAPIVersion = "v1beta1"
+)
+
+// targetRefHostnames resolves the hostnames of the Gateway API resource referred in the targetRef of an authconfig
+func targetRefHostnames(ctx context.Context, c client.Reader, authConfig *api.AuthConfig) ([]string, error) {
+	targetRef := authConfig.Spec.TargetRef
+	if targetRef == nil {
+		return nil, nil
+	}
+
+	if targetRef.Group != "" && targetRef.Group != gatewayAPIGroup {
+		return nil, fmt.Errorf("targetRef: unsupported group %s", targetRef.Group)
+	}
+
+	var hostnames []string
+	var err error
+	switch targetRef.Kind {
+	case api.TargetRefKindHTTPRoute:
+		hostnames, err = httpRouteHostnames(ctx, c, authConfig.Namespace, targetRef.Name)
+	case api.TargetRefKindGateway:
+		hostnames, err = gatewayHostnames(ctx, c, authConfig.Namespace, targetRef.Name, "")
+	default:
+		err = fmt.Errorf("unsupported kind %s", targetRef.Kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("targetRef: %w", err)
+	}
+	return hostnames, nil
+}
+
+// httpRouteHostnames returns the hostnames listed in the spec of an HTTPRoute or, if none, the ones of the listeners of
+// its parent Gateways
+func httpRouteHostnames(ctx context.Context, c client.Reader, namespace, name string) ([]string, error) {
+	route, err := getGatewayAPIObject(ctx, c, api.TargetRefKindHTTPRoute, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	hostnames, _, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if err != nil || len(hostnames) > 0 {
+		return hostnames, err
+	}
+
+	parentRefs, _, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parentRefs {
+		parentRef, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if nestedStringOrDefault(parentRef, "group", gatewayAPIGroup) != gatewayAPIGroup || nestedStringOrDefault(parentRef, "kind", api.TargetRefKindGateway) != api.TargetRefKindGateway {
+			continue
+		}
+		parentNamespace := nestedStringOrDefault(parentRef, "namespace", namespace)
+		parentName := nestedStringOrDefault(parentRef, "name", "")
+		sectionName := nestedStringOrDefault(parentRef, "sectionName", "")
+		listenerHostnames, err := gatewayHostnames(ctx, c, parentNamespace, parentName, sectionName)
+		if err != nil {
+			return nil, err
+		}
+		hostnames = append(hostnames, utils.SubtractSlice(listenerHostnames, hostnames)...)
+	}
+	return hostnames, nil
+}
+
+// gatewayHostnames returns the hostnames of the listeners of a Gateway, optionally restricted to the listener of a given name
+func gatewayHostnames(ctx context.Context, c client.Reader, namespace, name, sectionName string) ([]string, error) {
+	gateway, err := getGatewayAPIObject(ctx, c, api.TargetRefKindGateway, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners, _, err := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	if err != nil {
+		return nil, err
+	}
+	var hostnames []string
+	for _, l := range listeners {
+		listener, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if listenerName, _, _ := unstructured.NestedString(listener, "name"); sectionName != "" && listenerName != sectionName {
+			continue
+		}
+		// listeners without a hostname match any host, which cannot be protected by an authconfig
+		if hostname, _, _ := unstructured.NestedString(listener, "hostname"); hostname != "" && !utils.SliceContains(hostnames, hostname) {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames, nil
+}
+
+func nestedStringOrDefault(obj map[string]interface{}, field, defaultValue string) string {
+	if value, found, _ := unstructured.NestedString(obj, field); found {
+		return value
+	}
+	return defaultValue
+}
+
+func getGatewayAPIObject(ctx context.Context, c client.Reader, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Group: gatewayAPIGroup, Version: gatewayAPIVersion, Kind: kind})
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// findAuthConfigsTargeting maps a Gateway API resource to the requests to reconcile the authconfigs whose hostnames depend on it,
+// i.e. the authconfigs that refer to the resource in the targetRef and, for a Gateway, the ones that refer to an HTTPRoute,
+// whose hostnames can be the ones of its parent Gateways
+func findAuthConfigsTargeting(ctx context.Context, c client.Reader, labelSelector labels.Selector, obj client.Object) ([]reconcile.Request, error) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+
+	authConfigList := api.AuthConfigList{}
+	listOptions := []client.ListOption{}
+	if kind == api.TargetRefKindHTTPRoute {
+		listOptions = append(listOptions, client.InNamespace(obj.GetNamespace()))
+	}
+	if labelSelector != nil {
+		listOptions = append(listOptions, client.MatchingLabelsSelector{Selector: labelSelector})
+	}
+	if err := c.List(ctx, &authConfigList, listOptions...); err != nil {
+		return nil, err
+	}
+
+	var requests []reconcile.Request
+	for _, authConfig := range authConfigList.Items {
+		targetRef := authConfig.Spec.TargetRef
+		if targetRef == nil {
+			continue
+		}
+		targeted := targetRef.Kind == kind && targetRef.Name == obj.GetName() && authConfig.Namespace == obj.GetNamespace()
+		if targeted || (kind == api.TargetRefKindGateway && targetRef.Kind == api.TargetRefKindHTTPRoute) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}})
+		}
+	}
+	return requests, nil
+}
+
+// watchTargetRefs adds to a controller the watches of the Gateway API resources that can be referred in the targetRef of the
+// authconfigs. Kinds whose CRDs are not installed in the cluster are not watched.
+func watchTargetRefs(mgr ctrl.Manager, controllerBuilder *builder.Builder, mapFunc handler.MapFunc) (*builder.Builder, error) {
+	for _, kind := range []string{api.TargetRefKindHTTPRoute, api.TargetRefKindGateway} {
+		gvk := schema.GroupVersionKind{Group: gatewayAPIGroup, Version: gatewayAPIVersion, Kind: kind}
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); meta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		// hostnames are part of the spec, thus changes only to the status are ignored
+		controllerBuilder = controllerBuilder.Watches(&source.Kind{Type: obj}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+	return controllerBuilder, nil
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/kuadrant/authorino/api/v1beta1"
+
+	"gotest.tools/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func newTestGatewayAPIObject(kind, namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Group: gatewayAPIGroup, Version: gatewayAPIVersion, Kind: kind})
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func newTestAuthConfigWithTargetRef(namespace, name, kind, targetName string) *api.AuthConfig {
+	return &api.AuthConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       api.AuthConfigSpec{TargetRef: &api.TargetRef{Kind: kind, Name: targetName}},
+	}
+}
+
+func TestTargetRefHostnames(t *testing.T) {
+	client := newTestK8sClient(
+		newTestGatewayAPIObject("HTTPRoute", "authorino", "talker-api", map[string]interface{}{
+			"hostnames": []interface{}{"talker-api.io", "talker-api.com"},
+		}),
+		newTestGatewayAPIObject("HTTPRoute", "authorino", "echo-api", map[string]interface{}{
+			"parentRefs": []interface{}{
+				map[string]interface{}{"name": "internal"},
+				map[string]interface{}{"name": "external", "namespace": "gateways", "sectionName": "https"},
+				map[string]interface{}{"group": "", "kind": "Service", "name": "echo-api"},
+			},
+		}),
+		newTestGatewayAPIObject("Gateway", "authorino", "internal", map[string]interface{}{
+			"listeners": []interface{}{
+				map[string]interface{}{"name": "http", "hostname": "*.acme.local"},
+				map[string]interface{}{"name": "any"},
+			},
+		}),
+		newTestGatewayAPIObject("Gateway", "gateways", "external", map[string]interface{}{
+			"listeners": []interface{}{
+				map[string]interface{}{"name": "http", "hostname": "www.acme.com"},
+				map[string]interface{}{"name": "https", "hostname": "api.acme.com"},
+			},
+		}),
+	)
+
+	hostnames, err := targetRefHostnames(context.TODO(), client, newTestAuthConfigWithTargetRef("authorino", "auth-config-1", "HTTPRoute", "talker-api"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, hostnames, []string{"talker-api.io", "talker-api.com"})
+
+	// routes without hostnames of their own get the ones of the listeners of their parent gateways
+	hostnames, err = targetRefHostnames(context.TODO(), client, newTestAuthConfigWithTargetRef("authorino", "auth-config-1", "HTTPRoute", "echo-api"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, hostnames, []string{"*.acme.local", "api.acme.com"})
+
+	hostnames, err = targetRefHostnames(context.TODO(), client, newTestAuthConfigWithTargetRef("authorino", "auth-config-1", "Gateway", "internal"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, hostnames, []string{"*.acme.local"})
+
+	// the target resource must be in the namespace of the authconfig
+	_, err = targetRefHostnames(context.TODO(), client, newTestAuthConfigWithTargetRef("authorino", "auth-config-1", "Gateway", "external"))
+	assert.Check(t, errors.IsNotFound(err))
+	assert.ErrorContains(t, err, "targetRef: ")
+
+	authConfig := newTestAuthConfig(nil)
+	hostnames, err = targetRefHostnames(context.TODO(), client, &authConfig)
+	assert.NilError(t, err)
+	assert.Equal(t, len(hostnames), 0)
+}
+
+func TestFindAuthConfigsTargeting(t *testing.T) {
+	route := newTestGatewayAPIObject("HTTPRoute", "authorino", "talker-api", map[string]interface{}{})
+	gateway := newTestGatewayAPIObject("Gateway", "authorino", "internal", map[string]interface{}{})
+	authConfig := newTestAuthConfig(nil)
+	client := newTestK8sClient(
+		newTestAuthConfigWithTargetRef("authorino", "route", "HTTPRoute", "talker-api"),
+		newTestAuthConfigWithTargetRef("authorino", "other-route", "HTTPRoute", "echo-api"),
+		newTestAuthConfigWithTargetRef("authorino", "gateway", "Gateway", "internal"),
+		newTestAuthConfigWithTargetRef("other", "route", "HTTPRoute", "talker-api"),
+		&authConfig,
+	)
+
+	requests, err := findAuthConfigsTargeting(context.TODO(), client, nil, route)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, requests, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "authorino", Name: "route"}},
+	})
+
+	// the hostnames of any route can be the ones of the gateway
+	requests, err = findAuthConfigsTargeting(context.TODO(), client, nil, gateway)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, requests, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "authorino", Name: "gateway"}},
+		{NamespacedName: types.NamespacedName{Namespace: "authorino", Name: "other-route"}},
+		{NamespacedName: types.NamespacedName{Namespace: "authorino", Name: "route"}},
+		{NamespacedName: types.NamespacedName{Namespace: "other", Name: "route"}},
+	})
+}
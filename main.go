@@ -17,28 +17,39 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
+	goruntime "runtime"
 	"time"
 
 	"github.com/go-logr/logr"
 	api "github.com/kuadrant/authorino/api/v1beta1"
 	"github.com/kuadrant/authorino/controllers"
+	"github.com/kuadrant/authorino/pkg/circuitbreaker"
+	"github.com/kuadrant/authorino/pkg/diagnostics"
 	"github.com/kuadrant/authorino/pkg/evaluators"
+	identity_evaluators "github.com/kuadrant/authorino/pkg/evaluators/identity"
+	metadata_evaluators "github.com/kuadrant/authorino/pkg/evaluators/metadata"
 	"github.com/kuadrant/authorino/pkg/health"
+	"github.com/kuadrant/authorino/pkg/httpclient"
 	"github.com/kuadrant/authorino/pkg/index"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/metrics"
+	"github.com/kuadrant/authorino/pkg/replay"
+	"github.com/kuadrant/authorino/pkg/revocation"
 	"github.com/kuadrant/authorino/pkg/service"
 	"github.com/kuadrant/authorino/pkg/trace"
 	"github.com/kuadrant/authorino/pkg/utils"
 
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
@@ -48,10 +59,12 @@ import (
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	otel_grpc "go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -64,6 +77,9 @@ import (
 const (
 	gRPCMaxConcurrentStreams = 10000
 	leaderElectionIDSuffix   = "authorino.kuadrant.io"
+
+	// evaluators mostly wait on I/O (e.g. outbound HTTP requests), so the default worker pool runs many of them per CPU
+	evaluatorWorkersPerCPU = 64
 )
 
 var (
@@ -72,10 +88,13 @@ var (
 
 	// option flags
 	watchNamespace                 string
+	localConfigPath                string
 	watchedAuthConfigLabelSelector string
 	watchedSecretLabelSelector     string
+	allowCrossNamespaceAPIKeys     bool
 	logLevel                       string
 	logMode                        string
+	logRedact                      bool
 	timeout                        int
 	extAuthGRPCPort                int
 	extAuthHTTPPort                int
@@ -85,11 +104,36 @@ var (
 	oidcTLSCertPath                string
 	oidcTLSCertKeyPath             string
 	evaluatorCacheSize             int
+	evaluatorCacheMaxEntries       int
+	userInfoCacheMaxEntries        int
+	jwksCacheMaxEntries            int
+	revocationCacheMaxEntries      int
+	replayCacheMaxEntries          int
+	evaluatorWorkerPoolSize        int
+	maxInFlightCheckRequests       int
+	checkRequestQueueTimeout       int
+	checkRequestLimitStatus        int
+	maxRequestHeaders              int
+	maxRequestHeaderSize           int
+	maxRequestBodySize             int
+	dropOversizedRequestAttributes bool
+	httpClientTimeout              int
+	httpClientMaxIdleConnsPerHost  int
+	httpClientCACertPath           string
+	circuitBreakerThreshold        int
+	circuitBreakerOpenDuration     int
+	circuitBreakerHalfOpenProbes   int
+	deferOIDCDiscovery             bool
+	replayStoreURL                 string
 	deepMetricsEnabled             bool
 	metricsAddr                    string
 	healthProbeAddr                string
 	enableLeaderElection           bool
+	enableDefaultingWebhook        bool
+	webhookCertDir                 string
 	maxHttpRequestBodySize         int64
+	diagnosticsAddr                string
+	diagnosticsTokenPath           string
 	tracingServiceEndpoint         string
 	tracingServiceTags             []string
 
@@ -116,11 +160,14 @@ func main() {
 		Run:   run,
 	}
 
-	cmdServer.PersistentFlags().StringVar(&watchNamespace, "watch-namespace", utils.EnvVar("WATCH_NAMESPACE", ""), "Kubernetes namespace to watch")
+	cmdServer.PersistentFlags().StringVar(&watchNamespace, "watch-namespace", utils.EnvVar("WATCH_NAMESPACE", ""), "Kubernetes namespace to watch - comma-separated list for multiple namespaces, empty for cluster-wide")
+	cmdServer.PersistentFlags().StringVar(&localConfigPath, "local-config-path", utils.EnvVar("LOCAL_CONFIG_PATH", ""), "Comma-separated list of local files or directories to load AuthConfigs and Secrets from, instead of watching a Kubernetes cluster (standalone mode)")
 	cmdServer.PersistentFlags().StringVar(&watchedAuthConfigLabelSelector, "auth-config-label-selector", utils.EnvVar("AUTH_CONFIG_LABEL_SELECTOR", ""), "Kubernetes label selector to filter AuthConfig resources to watch")
 	cmdServer.PersistentFlags().StringVar(&watchedSecretLabelSelector, "secret-label-selector", utils.EnvVar("SECRET_LABEL_SELECTOR", "authorino.kuadrant.io/managed-by=authorino"), "Kubernetes label selector to filter Secret resources to watch")
+	cmdServer.PersistentFlags().BoolVar(&allowCrossNamespaceAPIKeys, "allow-cross-namespace-api-keys", utils.EnvVar("ALLOW_CROSS_NAMESPACE_API_KEYS", false), "Allow the AuthConfigs to read API key Secrets from a namespace other than their own (spec.identity.apiKey.namespace)")
 	cmdServer.PersistentFlags().StringVar(&logLevel, "log-level", utils.EnvVar("LOG_LEVEL", "info"), "Log level")
 	cmdServer.PersistentFlags().StringVar(&logMode, "log-mode", utils.EnvVar("LOG_MODE", "production"), "Log mode")
+	cmdServer.PersistentFlags().BoolVar(&logRedact, "log-redact", utils.EnvVar("LOG_REDACT", true), "Redact bearer tokens, API keys and the contents of Kubernetes Secrets in the log messages")
 	cmdServer.PersistentFlags().IntVar(&timeout, "timeout", utils.EnvVar("TIMEOUT", 0), "Server timeout - in milliseconds")
 	cmdServer.PersistentFlags().IntVar(&extAuthGRPCPort, "ext-auth-grpc-port", utils.EnvVar("EXT_AUTH_GRPC_PORT", 50051), "Port number of authorization server - gRPC interface")
 	cmdServer.PersistentFlags().IntVar(&extAuthHTTPPort, "ext-auth-http-port", utils.EnvVar("EXT_AUTH_HTTP_PORT", 5001), "Port number of authorization server - raw HTTP interface")
@@ -130,11 +177,36 @@ func main() {
 	cmdServer.PersistentFlags().StringVar(&oidcTLSCertPath, "oidc-tls-cert", utils.EnvVar("OIDC_TLS_CERT", ""), "Path to the public TLS server certificate file in the file system - Festival Wristband OIDC Discovery server")
 	cmdServer.PersistentFlags().StringVar(&oidcTLSCertKeyPath, "oidc-tls-cert-key", utils.EnvVar("OIDC_TLS_CERT_KEY", ""), "Path to the private TLS server certificate key file in the file system - Festival Wristband OIDC Discovery server")
 	cmdServer.PersistentFlags().IntVar(&evaluatorCacheSize, "evaluator-cache-size", utils.EnvVar("EVALUATOR_CACHE_SIZE", 1), "Cache size of each Authorino evaluator if enabled in the AuthConfig - in megabytes")
+	cmdServer.PersistentFlags().IntVar(&evaluatorCacheMaxEntries, "evaluator-cache-max-entries", utils.EnvVar("EVALUATOR_CACHE_MAX_ENTRIES", 10000), "Maximum number of entries of the cache of each Authorino evaluator if enabled in the AuthConfig - least recently used entries are evicted first, 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&userInfoCacheMaxEntries, "userinfo-cache-max-entries", utils.EnvVar("USERINFO_CACHE_MAX_ENTRIES", 10000), "Maximum number of OpenID Connect UserInfo responses cached by each UserInfo metadata evaluator if enabled in the AuthConfig - 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&jwksCacheMaxEntries, "jwks-cache-max-entries", utils.EnvVar("JWKS_CACHE_MAX_ENTRIES", 100), "Maximum number of JSON Web Key Sets of OpenID Connect issuers cached - 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&revocationCacheMaxEntries, "revocation-cache-max-entries", utils.EnvVar("REVOCATION_CACHE_MAX_ENTRIES", revocation.SessionsMaxEntries), "Maximum number of sessions logged out at the identity providers remembered - 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&replayCacheMaxEntries, "replay-cache-max-entries", utils.EnvVar("REPLAY_CACHE_MAX_ENTRIES", replay.DefaultMemoryStoreMaxEntries), "Maximum number of one-time values of the replay protection kept by the in-memory store - 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&evaluatorWorkerPoolSize, "evaluator-worker-pool-size", utils.EnvVar("EVALUATOR_WORKER_POOL_SIZE", evaluatorWorkersPerCPU*goruntime.GOMAXPROCS(0)), "Maximum number of evaluators running concurrently across all requests - 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&maxInFlightCheckRequests, "max-inflight-check-requests", utils.EnvVar("MAX_INFLIGHT_CHECK_REQUESTS", 0), "Maximum number of authorization requests processed concurrently across the gRPC and the raw HTTP interfaces - 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&checkRequestQueueTimeout, "check-request-queue-timeout", utils.EnvVar("CHECK_REQUEST_QUEUE_TIMEOUT", 0), "Time an authorization request beyond the maximum number of requests in flight waits to be processed before it is rejected - in milliseconds, 0 to reject right away")
+	cmdServer.PersistentFlags().IntVar(&checkRequestLimitStatus, "check-request-limit-status", utils.EnvVar("CHECK_REQUEST_LIMIT_STATUS", 503), "HTTP status code of the denial of the authorization requests rejected for exceeding the maximum number of requests in flight")
+	cmdServer.PersistentFlags().IntVar(&maxRequestHeaders, "max-request-headers", utils.EnvVar("MAX_REQUEST_HEADERS", 0), "Maximum number of headers of the authorization requests - 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&maxRequestHeaderSize, "max-request-header-size", utils.EnvVar("MAX_REQUEST_HEADER_SIZE", 0), "Maximum size of each header (name and value) of the authorization requests - in bytes, 0 for unbounded")
+	cmdServer.PersistentFlags().IntVar(&maxRequestBodySize, "max-request-body-size", utils.EnvVar("MAX_REQUEST_BODY_SIZE", 0), "Maximum size of the body of the authorization requests forwarded by the proxy - in bytes, 0 for unbounded")
+	cmdServer.PersistentFlags().BoolVar(&dropOversizedRequestAttributes, "drop-oversized-request-attributes", utils.EnvVar("DROP_OVERSIZED_REQUEST_ATTRIBUTES", false), "Drop the headers and the body of the authorization requests that exceed the size limits, instead of denying the requests")
+	cmdServer.PersistentFlags().IntVar(&httpClientTimeout, "http-client-timeout", utils.EnvVar("HTTP_CLIENT_TIMEOUT", 0), "Timeout of the outbound HTTP requests sent by the evaluators to external endpoints - in milliseconds, 0 for no timeout")
+	cmdServer.PersistentFlags().IntVar(&httpClientMaxIdleConnsPerHost, "http-client-max-idle-conns-per-host", utils.EnvVar("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", 10), "Maximum number of idle (keep-alive) connections kept in the pool per external host")
+	cmdServer.PersistentFlags().StringVar(&httpClientCACertPath, "http-client-ca-cert", utils.EnvVar("HTTP_CLIENT_CA_CERT", ""), "Path to a PEM bundle of certificate authorities trusted in addition to the system ones when calling external endpoints")
+	cmdServer.PersistentFlags().IntVar(&circuitBreakerThreshold, "circuit-breaker-failure-threshold", utils.EnvVar("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 0), "Number of consecutive failures calling an external endpoint (OIDC, UMA, UserInfo, HTTP services) that opens its circuit breaker - 0 to disable")
+	cmdServer.PersistentFlags().IntVar(&circuitBreakerOpenDuration, "circuit-breaker-open-duration", utils.EnvVar("CIRCUIT_BREAKER_OPEN_DURATION", 30), "Time an open circuit breaker rejects calls to the external endpoint before letting probe requests through - in seconds")
+	cmdServer.PersistentFlags().IntVar(&circuitBreakerHalfOpenProbes, "circuit-breaker-half-open-probes", utils.EnvVar("CIRCUIT_BREAKER_HALF_OPEN_PROBES", 1), "Number of successful probe requests to a half-open external endpoint required to close its circuit breaker")
+	cmdServer.PersistentFlags().BoolVar(&deferOIDCDiscovery, "defer-oidc-discovery", utils.EnvVar("DEFER_OIDC_DISCOVERY", false), "Defer the discovery of the OpenID Connect configuration of the issuers to the first request, instead of when the AuthConfig is reconciled")
+	cmdServer.PersistentFlags().StringVar(&replayStoreURL, "replay-store-url", utils.EnvVar("REPLAY_STORE_URL", ""), "URL of a Redis server (redis://<user>:<password>@<host>:<port>/<db>) to store the one-time values of the replay protection, shared among replicas - empty for in-memory")
 	cmdServer.PersistentFlags().BoolVar(&deepMetricsEnabled, "deep-metrics-enabled", utils.EnvVar("DEEP_METRICS_ENABLED", false), "Enable deep metrics at the level of each evaluator when requested in the AuthConfig, exported by the metrics server")
 	cmdServer.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", ":8080", "The network address the metrics endpoint binds to")
 	cmdServer.PersistentFlags().StringVar(&healthProbeAddr, "health-probe-addr", ":8081", "The network address the health probe endpoint binds to")
-	cmdServer.PersistentFlags().BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election for status updater - ensures only one instance of Authorino tries to update the status of reconciled resources")
+	cmdServer.PersistentFlags().BoolVar(&enableLeaderElection, "enable-leader-election", utils.EnvVar("ENABLE_LEADER_ELECTION", false), "Enable leader election for status updater - ensures only one replica of the Authorino instance tries to update the status of reconciled resources and to record events about them, while all replicas serve traffic")
+	cmdServer.PersistentFlags().BoolVar(&enableDefaultingWebhook, "enable-defaulting-webhook", utils.EnvVar("ENABLE_DEFAULTING_WEBHOOK", false), "Enable the mutating admission webhook that fills the defaults of the AuthConfigs, served on port 9443")
+	cmdServer.PersistentFlags().StringVar(&webhookCertDir, "webhook-cert-dir", utils.EnvVar("WEBHOOK_CERT_DIR", ""), "Path to the directory in the file system containing the TLS server certificate (tls.crt) and key (tls.key) of the admission webhook - empty for /tmp/k8s-webhook-server/serving-certs")
 	cmdServer.PersistentFlags().Int64Var(&maxHttpRequestBodySize, "max-http-request-body-size", utils.EnvVar("MAX_HTTP_REQUEST_BODY_SIZE", int64(8192)), "Maximum size of the body of requests accepted in the raw HTTP interface of the authorization server - in bytes")
+	cmdServer.PersistentFlags().StringVar(&diagnosticsAddr, "diagnostics-addr", utils.EnvVar("DIAGNOSTICS_ADDR", ""), "The network address the diagnostics endpoint (pprof, goroutine dump, runtime stats, cached AuthConfigs) binds to - empty to disable")
+	cmdServer.PersistentFlags().StringVar(&diagnosticsTokenPath, "diagnostics-token-path", utils.EnvVar("DIAGNOSTICS_TOKEN_PATH", ""), "Path to a file in the file system containing the bearer token required to access the diagnostics endpoint")
 	cmdServer.PersistentFlags().StringVar(&tracingServiceEndpoint, "tracing-service-endpoint", "", "Endpoint URL of the OpenTelemetry tracing collector service")
 	cmdServer.PersistentFlags().StringArrayVar(&tracingServiceTags, "tracing-service-tag", []string{}, "Fixed key=value tag to add to the OpenTelemetry traces")
 
@@ -144,7 +216,14 @@ func main() {
 		Run:   printVersion,
 	}
 
-	cmdRoot.AddCommand(cmdServer, cmdVersion)
+	cmdValidate := &cobra.Command{
+		Use:   "validate [file or directory]...",
+		Short: "Validates AuthConfig resources read from local YAML or JSON files",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   validate,
+	}
+
+	cmdRoot.AddCommand(cmdServer, cmdValidate, cmdVersion)
 
 	if err := cmdRoot.Execute(); err != nil {
 		fmt.Println("error: ", err)
@@ -153,7 +232,7 @@ func main() {
 }
 
 func run(cmd *cobra.Command, _ []string) {
-	logOpts := log.Options{Level: log.ToLogLevel(logLevel), Mode: log.ToLogMode(logMode)}
+	logOpts := log.Options{Level: log.ToLogLevel(logLevel), Mode: log.ToLogMode(logMode), Redact: logRedact}
 	logger = log.NewLogger(logOpts).WithName("authorino")
 	log.SetLogger(logger, logOpts)
 
@@ -167,18 +246,60 @@ func run(cmd *cobra.Command, _ []string) {
 	logger.V(1).Info("setting up with options", flags...)
 
 	evaluators.EvaluatorCacheSize = evaluatorCacheSize
+	evaluators.EvaluatorCacheMaxEntries = evaluatorCacheMaxEntries
+	metadata_evaluators.UserInfoCacheMaxEntries = userInfoCacheMaxEntries
+	identity_evaluators.JWKSCache.Resize(jwksCacheMaxEntries)
+	revocation.SharedSessions.Resize(revocationCacheMaxEntries)
+	service.EvaluatorWorkerPool = service.NewWorkerPool(evaluatorWorkerPoolSize)
+	service.CheckRequestLimiter = service.NewConcurrencyLimiter(maxInFlightCheckRequests, time.Duration(checkRequestQueueTimeout)*time.Millisecond)
+	if checkRequestLimitStatus < 400 || checkRequestLimitStatus > 599 {
+		logger.Error(fmt.Errorf("invalid status code %d", checkRequestLimitStatus), "the status of the requests rejected for exceeding the maximum number of requests in flight must be 4xx or 5xx")
+		os.Exit(1)
+	}
+	service.CheckRequestLimitStatus = envoy_type.StatusCode(checkRequestLimitStatus)
+	service.CheckRequestLimits = service.RequestLimits{
+		MaxHeaders:    maxRequestHeaders,
+		MaxHeaderSize: maxRequestHeaderSize,
+		MaxBodySize:   maxRequestBodySize,
+		DropOversized: dropOversizedRequestAttributes,
+	}
 	metrics.DeepMetricsEnabled = deepMetricsEnabled
+	if err := httpclient.Configure(httpclient.Options{
+		Timeout:             time.Duration(httpClientTimeout) * time.Millisecond,
+		MaxIdleConnsPerHost: httpClientMaxIdleConnsPerHost,
+		CACertPath:          httpClientCACertPath,
+	}); err != nil {
+		logger.Error(err, "unable to configure the http client")
+		os.Exit(1)
+	}
+	circuitbreaker.FailureThreshold = circuitBreakerThreshold
+	circuitbreaker.OpenDuration = time.Duration(circuitBreakerOpenDuration) * time.Second
+	circuitbreaker.HalfOpenProbes = circuitBreakerHalfOpenProbes
+	identity_evaluators.DeferOIDCDiscovery = deferOIDCDiscovery
+	if replayStoreURL != "" {
+		store, err := replay.NewRedisStore(replayStoreURL)
+		if err != nil {
+			logger.Error(err, "unable to configure the replay protection store")
+			os.Exit(1)
+		}
+		replay.SharedStore = store
+	} else {
+		replay.SharedStore = replay.NewMemoryStore(replayCacheMaxEntries)
+	}
 
 	managerOptions := ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		HealthProbeBindAddress: healthProbeAddr,
 		Port:                   9443,
+		CertDir:                webhookCertDir,
 		LeaderElection:         false,
 	}
 
-	if watchNamespace != "" {
-		managerOptions.Namespace = watchNamespace
+	if namespaces := utils.SplitList(watchNamespace); len(namespaces) > 1 {
+		managerOptions.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	} else if len(namespaces) == 1 {
+		managerOptions.Namespace = namespaces[0]
 	}
 
 	if tracingServiceEndpoint != "" {
@@ -193,16 +314,35 @@ func run(cmd *cobra.Command, _ []string) {
 
 	otel.SetTextMapPropagator(otel_propagation.NewCompositeTextMapPropagator(otel_propagation.TraceContext{}, otel_propagation.Baggage{}))
 
+	if paths := utils.SplitList(localConfigPath); len(paths) > 0 {
+		runStandalone(paths)
+		return
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), managerOptions)
 	if err != nil {
 		logger.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	// status update manager – status updates and events are gated behind the leader election
+	leaderElectionId := sha256.Sum256([]byte(watchedAuthConfigLabelSelector))
+	managerOptions.LeaderElection = enableLeaderElection
+	managerOptions.LeaderElectionID = fmt.Sprintf("%v.%v", hex.EncodeToString(leaderElectionId[:4]), leaderElectionIDSuffix)
+	managerOptions.MetricsBindAddress = "0"
+	managerOptions.HealthProbeBindAddress = "0"
+	statusUpdateManager, err := ctrl.NewManager(ctrl.GetConfigOrDie(), managerOptions)
+	if err != nil {
+		logger.Error(err, "unable to start status update manager")
+		os.Exit(1)
+	}
+
 	index := index.NewIndex()
 	statusReport := controllers.NewStatusReportMap()
 	controllerLogger := log.WithName("controller-runtime").WithName("manager").WithName("controller")
 
+	startDiagnosticsServer(index, statusReport)
+
 	// sets up the auth config reconciler
 	authConfigReconciler := &controllers.AuthConfigReconciler{
 		Client:        mgr.GetClient(),
@@ -212,6 +352,9 @@ func run(cmd *cobra.Command, _ []string) {
 		Scheme:        mgr.GetScheme(),
 		LabelSelector: controllers.ToLabelSelector(watchedAuthConfigLabelSelector),
 		Namespace:     watchNamespace,
+		Recorder:      controllers.NewLeaderEventRecorder(mgr.GetEventRecorderFor("authorino"), statusUpdateManager.Elected()),
+
+		AllowCrossNamespaceAPIKeys: allowCrossNamespaceAPIKeys,
 	}
 	if err = authConfigReconciler.SetupWithManager(mgr); err != nil {
 		logger.Error(err, "unable to create controller", "controller", "authconfig")
@@ -231,6 +374,14 @@ func run(cmd *cobra.Command, _ []string) {
 		os.Exit(1)
 	}
 
+	// sets up the defaulting webhook
+	if enableDefaultingWebhook {
+		if err = (&controllers.AuthConfigDefaulter{}).SetupWithManager(mgr); err != nil {
+			logger.Error(err, "unable to create webhook", "webhook", "authconfig")
+			os.Exit(1)
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	startExtAuthServerGRPC(index)
@@ -264,18 +415,6 @@ func run(cmd *cobra.Command, _ []string) {
 		}
 	}()
 
-	// status update manager
-	leaderElectionId := sha256.Sum256([]byte(watchedAuthConfigLabelSelector))
-	managerOptions.LeaderElection = enableLeaderElection
-	managerOptions.LeaderElectionID = fmt.Sprintf("%v.%v", hex.EncodeToString(leaderElectionId[:4]), leaderElectionIDSuffix)
-	managerOptions.MetricsBindAddress = "0"
-	managerOptions.HealthProbeBindAddress = "0"
-	statusUpdateManager, err := ctrl.NewManager(ctrl.GetConfigOrDie(), managerOptions)
-	if err != nil {
-		logger.Error(err, "unable to start status update manager")
-		os.Exit(1)
-	}
-
 	// sets up auth config status update controller
 	if err = (&controllers.AuthConfigStatusUpdater{
 		Client:        statusUpdateManager.GetClient(),
@@ -294,6 +433,90 @@ func run(cmd *cobra.Command, _ []string) {
 	}
 }
 
+// runStandalone runs the authorization server without a Kubernetes API server, with the index built from local files
+func runStandalone(paths []string) {
+	index := index.NewIndex()
+	statusReport := controllers.NewStatusReportMap()
+
+	localResourcesReconciler := &controllers.LocalResourcesReconciler{
+		Paths:         paths,
+		Logger:        log.WithName("standalone"),
+		Scheme:        scheme,
+		Index:         index,
+		StatusReport:  statusReport,
+		LabelSelector: controllers.ToLabelSelector(watchedAuthConfigLabelSelector),
+
+		AllowCrossNamespaceAPIKeys: allowCrossNamespaceAPIKeys,
+	}
+
+	startDiagnosticsServer(index, statusReport)
+
+	signalHandler := ctrl.SetupSignalHandler()
+
+	if err := localResourcesReconciler.Reconcile(signalHandler); err != nil {
+		logger.Error(err, "unable to load local resources", "paths", paths)
+		os.Exit(1)
+	}
+
+	startExtAuthServerGRPC(index)
+	startExtAuthServerHTTP(index)
+	startOIDCServer(index)
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.Handler())
+	metricsMux.Handle("/server-metrics", promhttp.Handler())
+	startStandaloneHTTPServer("metrics", metricsAddr, metricsMux)
+
+	readinessCheck := health.NewHandler(controllers.AuthConfigsReadyzSubpath, health.Observe(localResourcesReconciler))
+	healthMux := http.NewServeMux()
+	healthMux.Handle("/healthz/", http.StripPrefix("/healthz", &healthz.Handler{Checks: map[string]healthz.Checker{"ping": healthz.Ping}}))
+	healthMux.Handle("/readyz/", http.StripPrefix("/readyz", &healthz.Handler{Checks: map[string]healthz.Checker{controllers.AuthConfigsReadyzSubpath: readinessCheck.HandleReadyzCheck}}))
+	healthMux.Handle("/healthz", http.RedirectHandler("/healthz/", http.StatusMovedPermanently))
+	healthMux.Handle("/readyz", http.RedirectHandler("/readyz/", http.StatusMovedPermanently))
+	startStandaloneHTTPServer("health probe", healthProbeAddr, healthMux)
+
+	logger.Info("watching local resources", "paths", paths)
+
+	if err := localResourcesReconciler.Watch(signalHandler); err != nil {
+		logger.Error(err, "problem watching local resources")
+		os.Exit(1)
+	}
+}
+
+func startStandaloneHTTPServer(name, addr string, handler http.Handler) {
+	if addr == "" || addr == "0" {
+		return
+	}
+
+	go func() {
+		logger.Info(fmt.Sprintf("starting %s server", name), "addr", addr)
+		if err := http.ListenAndServe(addr, handler); err != nil {
+			logger.Error(err, fmt.Sprintf("failed to start %s server", name))
+			os.Exit(1)
+		}
+	}()
+}
+
+func startDiagnosticsServer(authConfigIndex index.Index, statusReport *controllers.StatusReportMap) {
+	if diagnosticsAddr == "" {
+		return
+	}
+
+	token, err := ioutil.ReadFile(diagnosticsTokenPath)
+	if err == nil && len(bytes.TrimSpace(token)) == 0 {
+		err = fmt.Errorf("empty token")
+	}
+	if err != nil {
+		logger.Error(err, "failed to read the token of the diagnostics endpoint", "path", diagnosticsTokenPath)
+		os.Exit(1)
+	}
+
+	handler := diagnostics.NewHandler(string(bytes.TrimSpace(token)), func() map[string]interface{} {
+		return map[string]interface{}{"inFlightCheckRequests": service.InFlightChecks()}
+	}, diagnostics.WithHandler("log", log.Handler()), diagnostics.WithHandler("authconfigs", controllers.StatusReportHandler(authConfigIndex, statusReport)))
+	startStandaloneHTTPServer("diagnostics", diagnosticsAddr, handler)
+}
+
 func startExtAuthServerGRPC(authConfigIndex index.Index) {
 	lis, err := listen(extAuthGRPCPort)
 
@@ -368,7 +591,8 @@ func startHTTPService(name string, port int, basePath, tlsCertPath, tlsCertKeyPa
 		return
 	}
 
-	http.Handle(basePath, otel_http.NewHandler(handler, name))
+	mux := http.NewServeMux()
+	mux.Handle(basePath, otel_http.NewHandler(handler, name))
 
 	tlsEnabled := tlsCertPath != "" && tlsCertKeyPath != ""
 
@@ -379,6 +603,7 @@ func startHTTPService(name string, port int, basePath, tlsCertPath, tlsCertKeyPa
 
 		if tlsEnabled {
 			server := &http.Server{
+				Handler: mux,
 				TLSConfig: &tls.Config{
 					MinVersion: tls.VersionTLS12,
 					ClientAuth: tls.RequestClientCert,
@@ -386,7 +611,7 @@ func startHTTPService(name string, port int, basePath, tlsCertPath, tlsCertKeyPa
 			}
 			err = server.ServeTLS(lis, tlsCertPath, tlsCertKeyPath)
 		} else {
-			err = http.Serve(lis, nil)
+			err = http.Serve(lis, mux)
 		}
 
 		if err != nil {
@@ -421,6 +646,34 @@ func timeoutMs() time.Duration {
 	return time.Duration(timeout) * time.Millisecond
 }
 
+func validate(_ *cobra.Command, paths []string) {
+	resources, err := controllers.ReadLocalResources(paths...)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+
+	valid := true
+	for _, resource := range resources {
+		authConfig, ok := resource.Object.(*api.AuthConfig)
+		if !ok {
+			continue
+		}
+		resourceId := types.NamespacedName{Namespace: authConfig.Namespace, Name: authConfig.Name}
+		errs := controllers.ValidateAuthConfig(authConfig)
+		for _, err := range errs {
+			fmt.Printf("%s: %s: %v\n", resource.Path, resourceId, err)
+		}
+		if len(errs) > 0 {
+			valid = false
+		}
+	}
+
+	if !valid {
+		os.Exit(1)
+	}
+}
+
 func printVersion(_ *cobra.Command, _ []string) {
 	fmt.Println("Authorino", version)
 }
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	gojson "encoding/json"
+	"sort"
+	"strings"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/tidwall/gjson"
+)
+
+// ResolvedIdentity is the identity object resolved in the identity phase of the Auth Pipeline, along with the identity source that resolved it
+type ResolvedIdentity struct {
+	// Source is the identity config that resolved the identity object
+	Source IdentityConfigEvaluator
+	// Object is the resolved identity object, e.g. the claims of a JWT, a Kubernetes Secret, an introspected token
+	Object interface{}
+}
+
+// Resolved tells whether the identity has been resolved by an identity source
+func (i ResolvedIdentity) Resolved() bool {
+	return i.Source != nil
+}
+
+// Claims returns the identity object as a map of claims, or nil if the identity object is not a JSON object
+func (i ResolvedIdentity) Claims() map[string]interface{} {
+	if claims, ok := i.Object.(map[string]interface{}); ok {
+		return claims
+	}
+	var claims map[string]interface{}
+	if j, err := gojson.Marshal(i.Object); err == nil {
+		_ = gojson.Unmarshal(j, &claims)
+	}
+	return claims
+}
+
+// Claim returns the value of a claim of the identity object selected by a JSON path (e.g. `sub`, `realm_access.roles`), and whether the claim exists
+func (i ResolvedIdentity) Claim(path string) (interface{}, bool) {
+	if value := i.claim(path); value.Exists() {
+		return value.Value(), true
+	}
+	return nil, false
+}
+
+// StringClaim returns the value of a claim of the identity object selected by a JSON path as a string, or empty if the claim does not exist
+func (i ResolvedIdentity) StringClaim(path string) string {
+	return i.claim(path).String()
+}
+
+func (i ResolvedIdentity) claim(path string) gjson.Result {
+	j, err := gojson.Marshal(i.Object)
+	if err != nil {
+		return gjson.Result{}
+	}
+	return gjson.GetBytes(j, path)
+}
+
+// MetadataSet holds the objects fetched in the metadata phase of the Auth Pipeline, by name of the metadata config
+type MetadataSet map[string]interface{}
+
+// Get returns the object fetched by the metadata config with the given name, and whether it was found
+func (m MetadataSet) Get(name string) (interface{}, bool) {
+	obj, found := m[name]
+	return obj, found
+}
+
+// Names returns the names of the metadata configs whose objects were fetched, sorted
+func (m MetadataSet) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RequestAttributes are the attributes of the request being authorized, as sent by the proxy
+type RequestAttributes struct {
+	*envoy_auth.AttributeContext
+}
+
+// HTTP returns the attributes of the HTTP request
+func (r RequestAttributes) HTTP() *envoy_auth.AttributeContext_HttpRequest {
+	return r.GetRequest().GetHttp()
+}
+
+// Header returns the value of the request header with the given name (case-insensitive), and whether the header is set
+func (r RequestAttributes) Header(name string) (string, bool) {
+	value, found := r.HTTP().GetHeaders()[strings.ToLower(name)]
+	return value, found
+}
+
+// ClientCertificate returns the URL-encoded PEM client certificate of the downstream connection, or empty if not available
+func (r RequestAttributes) ClientCertificate() string {
+	return r.GetSource().GetCertificate()
+}
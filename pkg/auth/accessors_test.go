@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"testing"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"gotest.tools/assert"
+)
+
+type testIdentityConfig struct{}
+
+func (c *testIdentityConfig) GetAuthCredentials() AuthCredentials { return nil }
+func (c *testIdentityConfig) GetOIDC() interface{}                { return nil }
+func (c *testIdentityConfig) ResolveExtendedProperties(AuthPipeline) (interface{}, error) {
+	return nil, nil
+}
+
+type testIdentityObject struct {
+	Sub   string   `json:"sub"`
+	Roles []string `json:"roles"`
+}
+
+func TestResolvedIdentity(t *testing.T) {
+	identity := ResolvedIdentity{}
+	assert.Check(t, !identity.Resolved())
+	assert.Check(t, identity.Claims() == nil)
+	assert.Equal(t, identity.StringClaim("sub"), "")
+
+	identity = ResolvedIdentity{Source: &testIdentityConfig{}, Object: &testIdentityObject{Sub: "john", Roles: []string{"admin", "user"}}}
+	assert.Check(t, identity.Resolved())
+	assert.DeepEqual(t, identity.Claims(), map[string]interface{}{"sub": "john", "roles": []interface{}{"admin", "user"}})
+	assert.Equal(t, identity.StringClaim("sub"), "john")
+	assert.Equal(t, identity.StringClaim("roles.1"), "user")
+
+	roles, found := identity.Claim("roles")
+	assert.Check(t, found)
+	assert.DeepEqual(t, roles, []interface{}{"admin", "user"})
+
+	_, found = identity.Claim("email")
+	assert.Check(t, !found)
+}
+
+func TestMetadataSet(t *testing.T) {
+	metadata := MetadataSet{"user-info": map[string]interface{}{"email": "john@example.com"}, "geo": "EU"}
+	obj, found := metadata.Get("geo")
+	assert.Check(t, found)
+	assert.Equal(t, obj, "EU")
+	_, found = metadata.Get("missing")
+	assert.Check(t, !found)
+	assert.DeepEqual(t, metadata.Names(), []string{"geo", "user-info"})
+}
+
+func TestRequestAttributes(t *testing.T) {
+	attributes := RequestAttributes{AttributeContext: &envoy_auth.AttributeContext{
+		Source: &envoy_auth.AttributeContext_Peer{Certificate: "-----BEGIN%20CERTIFICATE-----"},
+		Request: &envoy_auth.AttributeContext_Request{
+			Http: &envoy_auth.AttributeContext_HttpRequest{Method: "GET", Headers: map[string]string{"x-tenant": "acme"}},
+		},
+	}}
+	assert.Equal(t, attributes.HTTP().GetMethod(), "GET")
+	value, found := attributes.Header("X-Tenant")
+	assert.Check(t, found)
+	assert.Equal(t, value, "acme")
+	_, found = attributes.Header("x-other")
+	assert.Check(t, !found)
+	assert.Equal(t, attributes.ClientCertificate(), "-----BEGIN%20CERTIFICATE-----")
+
+	// nil-safe
+	attributes = RequestAttributes{}
+	_, found = attributes.Header("x-tenant")
+	assert.Check(t, !found)
+	assert.Equal(t, attributes.ClientCertificate(), "")
+}
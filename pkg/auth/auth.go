@@ -18,7 +18,20 @@ type AuthPipeline interface {
 	GetRequest() *envoy_auth.CheckRequest
 	GetHttp() *envoy_auth.AttributeContext_HttpRequest
 	GetAPI() interface{}
+	// GetIdentity returns the identity object resolved in the identity phase, along with the identity source that resolved it
+	GetIdentity() ResolvedIdentity
+	// GetMetadata returns the objects fetched in the metadata phase, by name of the metadata config
+	GetMetadata() MetadataSet
+	// GetRequestAttributes returns the attributes of the request being authorized
+	GetRequestAttributes() RequestAttributes
+	// GetResolvedIdentity returns the identity config and the identity object resolved in the identity phase.
+	// Deprecated: use GetIdentity instead.
 	GetResolvedIdentity() (interface{}, interface{})
+	// GetMetadataByName returns the object fetched by the metadata config with the given name, and whether it was found.
+	// Deprecated: use GetMetadata instead.
+	GetMetadataByName(name string) (interface{}, bool)
+	// GetAuthorizationJSON returns the Authorization JSON (see AuthorizationJSON), i.e. the "working memory" of the
+	// pipeline, encoded in JSON. Selectors of the pkg/json package are resolved against this document.
 	GetAuthorizationJSON() string
 }
 
@@ -44,6 +57,12 @@ type Prioritizable interface {
 	GetPriority() int
 }
 
+// CostlyEvaluator is implemented by the evaluators that can tell how expensive their evaluation is, so the cheap ones
+// can be attempted first (e.g. local verification of credentials before requests to external services)
+type CostlyEvaluator interface {
+	GetCost() int
+}
+
 type ConditionalEvaluator interface {
 	GetConditions() []json.JSONPatternMatchingRule
 }
@@ -67,6 +86,12 @@ type WristbandIssuer interface {
 	JWKS() (string, error)
 }
 
+// K8sSecretBasedSigningKeysIssuer is an issuer of tokens signed with keys stored in Kubernetes secrets, that can be
+// rotated by updating the secrets
+type K8sSecretBasedSigningKeysIssuer interface {
+	RefreshK8sSecretBasedSigningKey(context.Context, v1.Secret) (bool, error)
+}
+
 type ResponseConfigEvaluator interface {
 	NamedEvaluator
 	GetWristbandIssuer() WristbandIssuer
@@ -84,6 +109,10 @@ type AuthResult struct {
 	Message string `json:"message,omitempty"`
 	// Headers are other HTTP headers to inject in the response
 	Headers []map[string]string `json:"headers,omitempty"`
+	// ResponseHeaders are HTTP headers to add to the response sent to the client, when the request is authorized
+	ResponseHeaders []map[string]string `json:"responseHeaders,omitempty"`
+	// HeadersToRemove are HTTP headers to remove from the request sent upstream, when the request is authorized
+	HeadersToRemove []string `json:"headersToRemove,omitempty"`
 	// Metadata are Envoy dynamic metadata content
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 	// Body in the response of the request
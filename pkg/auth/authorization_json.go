@@ -0,0 +1,49 @@
+package auth
+
+import (
+	gojson "encoding/json"
+)
+
+// AuthorizationJSON is the canonical "working memory" of the Auth Pipeline, i.e. the document the selectors of the
+// pkg/json package (JSON paths, pattern-matching rules, templates) and the policy evaluators (e.g. the input of OPA)
+// are resolved against
+type AuthorizationJSON struct {
+	// Context holds the attributes of the request, as supplied by the proxy and possibly extended by Authorino
+	// (e.g. parsed body, query parameters, cookies)
+	Context interface{} `json:"context"`
+	// Auth holds the objects resolved in each phase of the Auth Pipeline
+	Auth AuthData `json:"auth"`
+}
+
+// AuthData holds the objects resolved in phases (i) to (v) of the Auth Pipeline.
+// The fields are kept in alphabetical order, so the encoded document does not change with the typed representation.
+type AuthData struct {
+	// Authorization are the results of the authorization policies, by name of the authorization config
+	Authorization map[string]interface{} `json:"authorization"`
+	// Callbacks are the responses of the callbacks, by name of the callback config
+	Callbacks map[string]interface{} `json:"callbacks,omitempty"`
+	// Identity is the identity object resolved in the identity phase, or nil if no identity has been resolved
+	Identity interface{} `json:"identity"`
+	// Metadata are the objects fetched in the metadata phase, by name of the metadata config
+	Metadata map[string]interface{} `json:"metadata"`
+	// Response are the objects built in the response phase, by name of the response config
+	Response map[string]interface{} `json:"response"`
+}
+
+// NewAuthorizationJSON returns an Authorization JSON for the given request context, with empty objects for each phase
+func NewAuthorizationJSON(context interface{}) *AuthorizationJSON {
+	return &AuthorizationJSON{
+		Context: context,
+		Auth: AuthData{
+			Authorization: make(map[string]interface{}),
+			Metadata:      make(map[string]interface{}),
+			Response:      make(map[string]interface{}),
+		},
+	}
+}
+
+// String returns the Authorization JSON encoded in JSON, i.e. the format in which the selectors are resolved
+func (a *AuthorizationJSON) String() string {
+	j, _ := gojson.Marshal(a)
+	return string(j)
+}
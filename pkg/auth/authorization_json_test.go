@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"gotest.tools/assert"
+)
+
+func TestNewAuthorizationJSON(t *testing.T) {
+	authJSON := NewAuthorizationJSON(map[string]interface{}{"request": map[string]interface{}{"http": map[string]interface{}{"path": "/hello"}}})
+	assert.Equal(t, authJSON.String(), `{"context":{"request":{"http":{"path":"/hello"}}},"auth":{"authorization":{},"identity":null,"metadata":{},"response":{}}}`)
+}
+
+func TestAuthorizationJSONSelectors(t *testing.T) {
+	authJSON := NewAuthorizationJSON(map[string]interface{}{"request": map[string]interface{}{"http": map[string]interface{}{"path": "/hello"}}})
+	authJSON.Auth.Identity = map[string]interface{}{"sub": "john", "roles": []string{"admin"}}
+	authJSON.Auth.Metadata["user-info"] = map[string]interface{}{"tier": "gold"}
+	authJSON.Auth.Authorization["policy"] = true
+	authJSON.Auth.Response["wristband"] = "token"
+	authJSON.Auth.Callbacks = map[string]interface{}{"audit": "ok"}
+
+	doc := authJSON.String()
+	assert.Equal(t, (&json.JSONValue{Pattern: "context.request.http.path"}).ResolveFor(doc), "/hello")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.identity.sub"}).ResolveFor(doc), "john")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.identity.roles.0"}).ResolveFor(doc), "admin")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.metadata.user-info.tier"}).ResolveFor(doc), "gold")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.authorization.policy"}).ResolveFor(doc), true)
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.response.wristband"}).ResolveFor(doc), "token")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.callbacks.audit"}).ResolveFor(doc), "ok")
+
+	rule := &json.JSONPatternMatchingRule{Selector: "auth.identity.roles", Operator: "incl", Value: "admin"}
+	matched, err := rule.EvaluateFor(doc)
+	assert.NilError(t, err)
+	assert.Check(t, matched)
+}
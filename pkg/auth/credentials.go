@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -83,21 +84,21 @@ func (c *AuthCredential) GetCredentialsIn() string {
 }
 
 func (c *AuthCredential) BuildRequestWithCredentials(ctx context.Context, endpoint string, method string, credentialValue string, body io.Reader) (*http.Request, error) {
-	url := endpoint
+	reqURL := endpoint
 
 	// build url with creds (if credentialValue is not empty)
 	if c.In == inQuery && credentialValue != "" {
 		var separator string
-		if strings.Contains(url, "?") {
+		if strings.Contains(reqURL, "?") {
 			separator = "&"
 		} else {
 			separator = "?"
 		}
-		url = url + separator + c.KeySelector + "=" + credentialValue
+		reqURL = reqURL + separator + c.KeySelector + "=" + url.QueryEscape(credentialValue)
 	}
 
 	// build request
-	if req, err := http.NewRequestWithContext(ctx, method, url, body); err != nil {
+	if req, err := http.NewRequestWithContext(ctx, method, reqURL, body); err != nil {
 		return nil, err
 	} else {
 		// don't add creds if credentialValue is empty
@@ -149,22 +150,43 @@ func getFromCookieHeader(headers map[string]string, keyName string) (string, err
 		return "", notFoundErr
 	}
 
-	for _, part := range strings.Split(header, ";") {
-		keyAndValue := strings.TrimSpace(part)
-		if strings.HasPrefix(keyAndValue, keyName+"=") {
-			return strings.TrimPrefix(keyAndValue, keyName+"="), nil
-		}
+	if value, ok := ParseCookies(header)[keyName]; ok {
+		return value, nil
 	}
 
 	return "", notFoundErr
 }
 
+// ParseCookies parses the value of a Cookie header into a map of cookie names to values.
+// Values wrapped in double quotes are unquoted. If a name repeats, the first occurrence wins.
+func ParseCookies(header string) map[string]string {
+	cookies := make(map[string]string)
+	for _, part := range strings.Split(header, ";") {
+		name, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found || name == "" {
+			continue
+		}
+		if _, exists := cookies[name]; exists {
+			continue
+		}
+		if len(value) > 1 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		}
+		cookies[name] = value
+	}
+	return cookies
+}
+
 func getCredFromQuery(path string, keyName string) (string, error) {
 	const credValue = "credValue"
-	regex := regexp.MustCompile("([?&]" + keyName + "=)(?P<" + credValue + ">[^&]*)")
+	regex := regexp.MustCompile("([?&]" + regexp.QuoteMeta(keyName) + "=)(?P<" + credValue + ">[^&#]*)")
 	matches := regex.FindStringSubmatch(path)
 	if len(matches) == 0 {
 		return "", notFoundErr
 	}
-	return matches[regex.SubexpIndex(credValue)], nil
+	value := matches[regex.SubexpIndex(credValue)]
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		return unescaped, nil
+	}
+	return value, nil
 }
@@ -144,6 +144,32 @@ func TestGetCredentialsFromCookieHeaderWithEqualSign(t *testing.T) {
 	assert.Check(t, cred == "SHVtYW5JbnN0cnVtZW50YWxpdHk=")
 }
 
+func TestGetCredentialsFromCookieHeaderQuoted(t *testing.T) {
+	var httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
+		Headers: map[string]string{"cookie": `session=abc; API-KEY="HumanInstrumentality"`},
+	}
+
+	authCredentials := AuthCredential{
+		KeySelector: "API-KEY",
+		In:          "cookie",
+	}
+	cred, err := authCredentials.GetCredentialsFromReq(&httpReq)
+
+	assert.NilError(t, err)
+	assert.Equal(t, cred, "HumanInstrumentality")
+}
+
+func TestParseCookies(t *testing.T) {
+	cookies := ParseCookies(`session=abc; theme="dark";; invalid; empty=; session=xyz; token=a=b=`)
+	assert.DeepEqual(t, cookies, map[string]string{
+		"session": "abc",
+		"theme":   "dark",
+		"empty":   "",
+		"token":   "a=b=",
+	})
+	assert.Equal(t, len(ParseCookies("")), 0)
+}
+
 func TestGetCredentialsFromCookieHeaderNoCookieHeaderFail(t *testing.T) {
 	var httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
 		Headers: map[string]string{"cookie": "Expires=Tue, 01-Jan-2016 21:47:38 GMT"},
@@ -200,6 +226,30 @@ func TestGetCredentialsFromQuerySuccess(t *testing.T) {
 	assert.Check(t, cred == "DasUberApiKey")
 }
 
+func TestGetCredentialsFromQueryEscaped(t *testing.T) {
+	var httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
+		Path: "/seele.de/hip?x.api.key=Das%2BUber%2FApiKey#eva",
+	}
+
+	authCredentials := AuthCredential{
+		KeySelector: "x.api.key",
+		In:          "query",
+	}
+	cred, err := authCredentials.GetCredentialsFromReq(&httpReq)
+
+	assert.NilError(t, err)
+	assert.Equal(t, cred, "Das+Uber/ApiKey")
+
+	// regex metacharacters in the key selector are matched literally
+	httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
+		Path: "/seele.de/hip?xzapizkey=DasUberApiKey",
+	}
+
+	_, err = authCredentials.GetCredentialsFromReq(&httpReq)
+
+	assert.Error(t, err, "credential not found")
+}
+
 func TestGetCredentialsFromQueryFail(t *testing.T) {
 	var httpReq = envoyServiceAuthV3.AttributeContext_HttpRequest{
 		Path: "/seele.de/hip?third_impact=true&some=scheisse",
@@ -223,6 +273,15 @@ func TestBuildRequestWithCredentials(t *testing.T) {
 	assert.Equal(t, req.Header.Get("Authorization"), creds.KeySelector+" 123")
 }
 
+func TestBuildRequestWithCredentialsInQuery(t *testing.T) {
+	creds := NewAuthCredential("api_key", "query")
+	req, err := creds.BuildRequestWithCredentials(context.TODO(), "http://example.com/path?a=1", "GET", "Das+Uber", nil)
+
+	assert.NilError(t, err)
+	assert.Equal(t, req.URL.Query().Get("api_key"), "Das+Uber")
+	assert.Equal(t, req.URL.Query().Get("a"), "1")
+}
+
 func TestBuildRequestWithCredentialsEmpty(t *testing.T) {
 	creds := NewAuthCredential("", "")
 	req, err := creds.BuildRequestWithCredentials(context.TODO(), "http://example.com", "GET", "", nil)
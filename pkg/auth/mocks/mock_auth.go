@@ -96,6 +96,49 @@ func (mr *MockAuthPipelineMockRecorder) GetHttp() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHttp", reflect.TypeOf((*MockAuthPipeline)(nil).GetHttp))
 }
 
+// GetIdentity mocks base method.
+func (m *MockAuthPipeline) GetIdentity() auth.ResolvedIdentity {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentity")
+	ret0, _ := ret[0].(auth.ResolvedIdentity)
+	return ret0
+}
+
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockAuthPipelineMockRecorder) GetIdentity() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockAuthPipeline)(nil).GetIdentity))
+}
+
+// GetMetadata mocks base method.
+func (m *MockAuthPipeline) GetMetadata() auth.MetadataSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata")
+	ret0, _ := ret[0].(auth.MetadataSet)
+	return ret0
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockAuthPipelineMockRecorder) GetMetadata() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockAuthPipeline)(nil).GetMetadata))
+}
+
+// GetMetadataByName mocks base method.
+func (m *MockAuthPipeline) GetMetadataByName(arg0 string) (interface{}, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadataByName", arg0)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// GetMetadataByName indicates an expected call of GetMetadataByName.
+func (mr *MockAuthPipelineMockRecorder) GetMetadataByName(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadataByName", reflect.TypeOf((*MockAuthPipeline)(nil).GetMetadataByName), arg0)
+}
+
 // GetRequest mocks base method.
 func (m *MockAuthPipeline) GetRequest() *authv3.CheckRequest {
 	m.ctrl.T.Helper()
@@ -110,6 +153,20 @@ func (mr *MockAuthPipelineMockRecorder) GetRequest() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequest", reflect.TypeOf((*MockAuthPipeline)(nil).GetRequest))
 }
 
+// GetRequestAttributes mocks base method.
+func (m *MockAuthPipeline) GetRequestAttributes() auth.RequestAttributes {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRequestAttributes")
+	ret0, _ := ret[0].(auth.RequestAttributes)
+	return ret0
+}
+
+// GetRequestAttributes indicates an expected call of GetRequestAttributes.
+func (mr *MockAuthPipelineMockRecorder) GetRequestAttributes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestAttributes", reflect.TypeOf((*MockAuthPipeline)(nil).GetRequestAttributes))
+}
+
 // GetResolvedIdentity mocks base method.
 func (m *MockAuthPipeline) GetResolvedIdentity() (interface{}, interface{}) {
 	m.ctrl.T.Helper()
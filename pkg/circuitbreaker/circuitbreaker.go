@@ -0,0 +1,187 @@
+package circuitbreaker
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/metrics"
+)
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+var (
+	// FailureThreshold is the number of consecutive failures that opens the circuit of an endpoint. 0 disables the circuit breakers.
+	FailureThreshold = 0
+	// OpenDuration is how long the circuit of an endpoint stays open before letting probe requests through.
+	OpenDuration = 30 * time.Second
+	// HalfOpenProbes is the number of probe requests let through while half-open, all of which must succeed to close the circuit again.
+	HalfOpenProbes = 1
+
+	ErrOpen = errors.New("circuit breaker is open")
+
+	breakers sync.Map
+
+	stateMetric    = metrics.NewGaugeMetric("circuit_breaker_state", "State of the circuit breaker of an external endpoint (0 = closed, 1 = half-open, 2 = open).", "endpoint")
+	rejectedMetric = metrics.NewCounterMetric("circuit_breaker_rejected_total", "Total number of calls to an external endpoint rejected by the circuit breaker.", "endpoint")
+)
+
+func init() {
+	metrics.Register(
+		stateMetric,
+		rejectedMetric,
+	)
+}
+
+type State int
+
+func (s State) String() string {
+	switch s {
+	case StateHalfOpen:
+		return "half-open"
+	case StateOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// For returns the circuit breaker of an endpoint, creating it if it does not exist yet
+func For(endpoint string) *Breaker {
+	breaker, _ := breakers.LoadOrStore(endpoint, &Breaker{endpoint: endpoint})
+	return breaker.(*Breaker)
+}
+
+// Breaker tracks the failures of calls to an external endpoint
+type Breaker struct {
+	endpoint string
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+}
+
+// Allow tells whether a call to the endpoint can go through.
+// Every allowed call must be followed by a call to Done with the outcome.
+func (b *Breaker) Allow() error {
+	if FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < OpenDuration {
+			metrics.ReportMetric(rejectedMetric, b.endpoint)
+			return ErrOpen
+		}
+		b.setState(StateHalfOpen)
+		fallthrough
+	case StateHalfOpen:
+		if b.probes >= HalfOpenProbes {
+			metrics.ReportMetric(rejectedMetric, b.endpoint)
+			return ErrOpen
+		}
+		b.probes++
+	}
+
+	return nil
+}
+
+// Done records the outcome of a call allowed by the circuit breaker.
+// Calls that did not complete (e.g. cancelled along with the auth pipeline) should not be reported as failures.
+func (b *Breaker) Done(success, completed bool) {
+	if FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		if !completed {
+			return
+		}
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= FailureThreshold {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		if !completed {
+			b.probes--
+			return
+		}
+		if !success {
+			b.setState(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= HalfOpenProbes {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// State returns the current state of the circuit breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *Breaker) setState(state State) {
+	b.state = state
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+	if state == StateOpen {
+		b.openedAt = time.Now()
+	}
+	stateMetric.WithLabelValues(b.endpoint).Set(float64(state))
+	log.WithName("circuitbreaker").V(1).Info("circuit breaker state changed", "endpoint", b.endpoint, "state", state.String())
+}
+
+// Transport is an http.RoundTripper that protects each endpoint (scheme and host) with a circuit breaker.
+// Transport errors and 5xx responses count as failures.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := For(req.URL.Scheme + "://" + req.URL.Host)
+	if err := breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		cancelled := errors.Is(err, gocontext.Canceled) || req.Context().Err() == gocontext.Canceled
+		breaker.Done(false, !cancelled)
+		return nil, err
+	}
+
+	breaker.Done(resp.StatusCode < http.StatusInternalServerError, true)
+	return resp, nil
+}
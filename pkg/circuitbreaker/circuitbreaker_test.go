@@ -0,0 +1,85 @@
+package circuitbreaker
+
+import (
+	"net/http"
+	gohttptest "net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func withSettings(threshold int, openDuration time.Duration, probes int) func() {
+	defaultThreshold, defaultOpenDuration, defaultProbes := FailureThreshold, OpenDuration, HalfOpenProbes
+	FailureThreshold, OpenDuration, HalfOpenProbes = threshold, openDuration, probes
+	return func() {
+		FailureThreshold, OpenDuration, HalfOpenProbes = defaultThreshold, defaultOpenDuration, defaultProbes
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	defer withSettings(2, 50*time.Millisecond, 1)()
+
+	breaker := &Breaker{endpoint: "test-breaker"}
+
+	assert.NilError(t, breaker.Allow())
+	breaker.Done(false, true)
+	assert.NilError(t, breaker.Allow())
+	breaker.Done(true, true) // resets the count of consecutive failures
+	assert.NilError(t, breaker.Allow())
+	breaker.Done(false, true)
+	assert.Equal(t, breaker.State(), StateClosed)
+	assert.NilError(t, breaker.Allow())
+	breaker.Done(false, true)
+	assert.Equal(t, breaker.State(), StateOpen)
+	assert.Equal(t, breaker.Allow(), ErrOpen)
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.NilError(t, breaker.Allow()) // probe
+	assert.Equal(t, breaker.State(), StateHalfOpen)
+	assert.Equal(t, breaker.Allow(), ErrOpen)
+	breaker.Done(false, false) // cancelled probes do not count
+	assert.NilError(t, breaker.Allow())
+	breaker.Done(false, true)
+	assert.Equal(t, breaker.State(), StateOpen)
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.NilError(t, breaker.Allow())
+	breaker.Done(true, true)
+	assert.Equal(t, breaker.State(), StateClosed)
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	defer withSettings(0, time.Minute, 1)()
+
+	breaker := &Breaker{endpoint: "test-breaker-disabled"}
+	for i := 0; i < 10; i++ {
+		assert.NilError(t, breaker.Allow())
+		breaker.Done(false, true)
+	}
+	assert.Equal(t, breaker.State(), StateClosed)
+}
+
+func TestTransport(t *testing.T) {
+	defer withSettings(1, time.Minute, 1)()
+
+	calls := 0
+	server := gohttptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{}}
+
+	resp, err := client.Get(server.URL)
+	assert.NilError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusServiceUnavailable)
+	assert.Equal(t, For(server.URL).State(), StateOpen)
+
+	_, err = client.Get(server.URL + "/other")
+	assert.ErrorContains(t, err, ErrOpen.Error())
+	assert.Equal(t, calls, 1)
+}
@@ -0,0 +1,77 @@
+package diagnostics
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+)
+
+const BasePath = "/debug/"
+
+// Stats reports runtime information about the authorization server
+type Stats func() map[string]interface{}
+
+// HandlerOption adds features to the diagnostics handler
+type HandlerOption func(*http.ServeMux)
+
+// WithHandler exposes an additional handler at the given path, relative to the base path of the diagnostics handler
+func WithHandler(path string, handler http.Handler) HandlerOption {
+	return func(mux *http.ServeMux) {
+		mux.Handle(BasePath+path, handler)
+	}
+}
+
+// NewHandler returns an http handler that exposes the pprof profiles, a dump of the goroutines and runtime stats of the authorization server.
+// Requests must be authenticated with the given token in the Authorization header, with the Bearer prefix.
+func NewHandler(token string, stats Stats, options ...HandlerOption) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(BasePath+"pprof/", pprof.Index)
+	mux.HandleFunc(BasePath+"pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc(BasePath+"pprof/profile", pprof.Profile)
+	mux.HandleFunc(BasePath+"pprof/symbol", pprof.Symbol)
+	mux.HandleFunc(BasePath+"pprof/trace", pprof.Trace)
+	mux.HandleFunc(BasePath+"goroutines", func(resp http.ResponseWriter, _ *http.Request) {
+		resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		buf := make([]byte, 1<<20)
+		for {
+			n := runtime.Stack(buf, true)
+			if n < len(buf) {
+				_, _ = resp.Write(buf[:n])
+				return
+			}
+			buf = make([]byte, 2*len(buf))
+		}
+	})
+	mux.HandleFunc(BasePath+"stats", func(resp http.ResponseWriter, _ *http.Request) {
+		s := map[string]interface{}{}
+		if stats != nil {
+			s = stats()
+		}
+		s["goroutines"] = runtime.NumGoroutine()
+		resp.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(resp).Encode(s)
+	})
+	for _, o := range options {
+		o(mux)
+	}
+	return &handler{token: []byte(token), next: mux}
+}
+
+type handler struct {
+	token []byte
+	next  http.Handler
+}
+
+func (h *handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	authorization := req.Header.Get("Authorization")
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization || len(h.token) == 0 || subtle.ConstantTimeCompare([]byte(token), h.token) != 1 {
+		resp.Header().Set("WWW-Authenticate", "Bearer")
+		resp.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	h.next.ServeHTTP(resp, req)
+}
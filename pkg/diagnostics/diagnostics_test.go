@@ -0,0 +1,61 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler("secret", func() map[string]interface{} {
+		return map[string]interface{}{"inFlightCheckRequests": 3}
+	}, WithHandler("hello", http.HandlerFunc(func(resp http.ResponseWriter, _ *http.Request) {
+		_, _ = resp.Write([]byte("hi"))
+	})))
+
+	request := func(path, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	assert.Equal(t, request("/debug/stats", "").Code, http.StatusUnauthorized)
+	assert.Equal(t, request("/debug/stats", "Bearer wrong").Code, http.StatusUnauthorized)
+	assert.Equal(t, request("/debug/stats", "secret").Code, http.StatusUnauthorized)
+
+	resp := request("/debug/stats", "Bearer secret")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	var stats map[string]interface{}
+	assert.NilError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, stats["inFlightCheckRequests"], float64(3))
+	assert.Check(t, stats["goroutines"].(float64) > 0)
+
+	resp = request("/debug/goroutines", "Bearer secret")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Check(t, strings.Contains(resp.Body.String(), "goroutine "))
+
+	resp = request("/debug/hello", "Bearer secret")
+	assert.Equal(t, resp.Body.String(), "hi")
+	assert.Equal(t, request("/debug/hello", "").Code, http.StatusUnauthorized)
+
+	resp = request("/debug/pprof/", "Bearer secret")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Check(t, strings.Contains(resp.Body.String(), "heap"))
+}
+
+func TestHandlerWithoutToken(t *testing.T) {
+	handler := NewHandler("", nil)
+	req := httptest.NewRequest(http.MethodGet, "/debug/stats", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusUnauthorized)
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	"github.com/kuadrant/authorino/pkg/evaluators/custom"
+	"github.com/kuadrant/authorino/pkg/evaluators/external"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
 )
@@ -15,6 +17,11 @@ const (
 	authorizationJSON       = "AUTHORIZATION_JSON"
 	authorizationKubernetes = "AUTHORIZATION_KUBERNETES"
 	authorizationAuthzed    = "AUTHORIZATION_AUTHZED"
+	authorizationUMA        = "AUTHORIZATION_UMA"
+	authorizationHTTP       = "AUTHORIZATION_HTTP"
+	authorizationCustom     = "AUTHORIZATION_CUSTOM"
+	authorizationExternal   = "AUTHORIZATION_EXTERNAL"
+	authorizationCEL        = "AUTHORIZATION_CEL"
 )
 
 type AuthorizationConfig struct {
@@ -24,10 +31,19 @@ type AuthorizationConfig struct {
 	Metrics    bool                           `yaml:"metrics"`
 	Cache      EvaluatorCache
 
+	// FailurePolicy tells whether to deny (default) or to allow the request when the policy cannot be evaluated due to a
+	// failure of an external dependency
+	FailurePolicy string `yaml:"failurePolicy,omitempty"`
+
 	OPA             *authorization.OPA                 `yaml:"opa,omitempty"`
 	JSON            *authorization.JSONPatternMatching `yaml:"json,omitempty"`
 	KubernetesAuthz *authorization.KubernetesAuthz     `yaml:"kubernetes,omitempty"`
 	Authzed         *authorization.Authzed             `yaml:"authzed,omitempty"`
+	UMA             *authorization.UMA                 `yaml:"uma,omitempty"`
+	GenericHTTP     *authorization.GenericHttp         `yaml:"http,omitempty"`
+	Custom          *custom.Evaluator                  `yaml:"custom,omitempty"`
+	External        *external.GRPC                     `yaml:"external,omitempty"`
+	CEL             *authorization.CEL                 `yaml:"cel,omitempty"`
 }
 
 func (config *AuthorizationConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator {
@@ -40,6 +56,16 @@ func (config *AuthorizationConfig) GetAuthConfigEvaluator() auth.AuthConfigEvalu
 		return config.KubernetesAuthz
 	case authorizationAuthzed:
 		return config.Authzed
+	case authorizationUMA:
+		return config.UMA
+	case authorizationHTTP:
+		return config.GenericHTTP
+	case authorizationCustom:
+		return config.Custom
+	case authorizationExternal:
+		return config.External
+	case authorizationCEL:
+		return config.CEL
 	default:
 		return nil
 	}
@@ -65,12 +91,19 @@ func (config *AuthorizationConfig) Call(pipeline auth.AuthPipeline, ctx context.
 			}
 		}
 
-		obj, err := evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+		var obj interface{}
+		var err error
+		if cacheKey != nil {
+			obj, err = callAndCache(cache, cacheKey, func() (interface{}, error) {
+				return evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+			}, logger)
+		} else {
+			obj, err = evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+		}
 
-		if err == nil && cacheKey != nil {
-			if err := cache.Set(cacheKey, obj); err != nil {
-				logger.V(1).Info("unable to store data in the cache", "err", err)
-			}
+		if config.FailurePolicy == authorization.FailurePolicyAllow && authorization.IsDependencyFailure(err) {
+			logger.V(1).Info("failed to evaluate the authorization policy; allowing the request per failure policy", "config", config.Name, "err", err)
+			return nil, nil
 		}
 
 		return obj, err
@@ -95,6 +128,16 @@ func (config *AuthorizationConfig) GetType() string {
 		return authorizationKubernetes
 	case config.Authzed != nil:
 		return authorizationAuthzed
+	case config.UMA != nil:
+		return authorizationUMA
+	case config.GenericHTTP != nil:
+		return authorizationHTTP
+	case config.Custom != nil:
+		return authorizationCustom
+	case config.External != nil:
+		return authorizationExternal
+	case config.CEL != nil:
+		return authorizationCEL
 	default:
 		return ""
 	}
@@ -133,6 +176,12 @@ func (config *AuthorizationConfig) getCleaner() auth.AuthConfigCleaner {
 	switch {
 	case config.OPA != nil:
 		return config.OPA
+	case config.Authzed != nil:
+		return config.Authzed
+	case config.Custom != nil:
+		return config.Custom
+	case config.External != nil:
+		return config.External
 	default:
 		return nil
 	}
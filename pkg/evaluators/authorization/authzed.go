@@ -3,6 +3,7 @@ package authorization
 import (
 	gocontext "context"
 	"fmt"
+	"sync"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/json"
@@ -10,7 +11,6 @@ import (
 	insecuregrpc "google.golang.org/grpc/credentials/insecure"
 
 	authzedpb "github.com/authzed/authzed-go/proto/authzed/api/v1"
-	authzed "github.com/authzed/authzed-go/v1"
 	"github.com/authzed/grpcutil"
 )
 
@@ -24,6 +24,11 @@ type Authzed struct {
 	Resource     json.JSONValue
 	ResourceKind json.JSONValue
 	Permission   json.JSONValue
+
+	// the grpc connection is shared by all requests, established on the first one
+	conn   *grpc.ClientConn
+	client authzedpb.PermissionsServiceClient
+	mu     sync.Mutex
 }
 
 type permissionResponse struct {
@@ -32,16 +37,7 @@ type permissionResponse struct {
 }
 
 func (a *Authzed) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
-	var dialOpts []grpc.DialOption
-
-	if a.Insecure {
-		dialOpts = append(dialOpts, grpcutil.WithInsecureBearerToken(a.SharedSecret), grpc.WithTransportCredentials(insecuregrpc.NewCredentials()))
-	} else {
-		systemCertsOption, _ := grpcutil.WithSystemCerts(grpcutil.VerifyCA)
-		dialOpts = append(dialOpts, grpcutil.WithBearerToken(a.SharedSecret), systemCertsOption)
-	}
-
-	client, err := authzed.NewClient(a.Endpoint, dialOpts...)
+	client, err := a.getClient()
 	if err != nil {
 		return nil, err
 	}
@@ -74,6 +70,46 @@ func (a *Authzed) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (inter
 	return obj, nil
 }
 
+// Clean closes the grpc connection to the Authzed service
+func (a *Authzed) Clean(_ gocontext.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.conn == nil {
+		return nil
+	}
+	err := a.conn.Close()
+	a.conn, a.client = nil, nil
+	return err
+}
+
+func (a *Authzed) getClient() (authzedpb.PermissionsServiceClient, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	var dialOpts []grpc.DialOption
+
+	if a.Insecure {
+		dialOpts = append(dialOpts, grpcutil.WithInsecureBearerToken(a.SharedSecret), grpc.WithTransportCredentials(insecuregrpc.NewCredentials()))
+	} else {
+		systemCertsOption, _ := grpcutil.WithSystemCerts(grpcutil.VerifyCA)
+		dialOpts = append(dialOpts, grpcutil.WithBearerToken(a.SharedSecret), systemCertsOption)
+	}
+
+	conn, err := grpc.Dial(a.Endpoint, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	a.conn = conn
+	a.client = authzedpb.NewPermissionsServiceClient(conn)
+	return a.client, nil
+}
+
 func authzedObjectFor(name, kind json.JSONValue, authJSON string) *authzedpb.ObjectReference {
 	return &authzedpb.ObjectReference{
 		ObjectId:   fmt.Sprintf("%s", name.ResolveFor(authJSON)),
@@ -132,3 +132,46 @@ func testAuthzedAuthDataMock() string {
 
 	return string(authJSON)
 }
+
+func TestAuthzedReusesConnection(t *testing.T) {
+	testAuthzedServer := httptest.NewGrpcServerMock(testAuthzedServerEndpoint, func(server *grpc.Server) {
+		authzedpb.RegisterPermissionsServiceServer(server, &testAuthzedPermissionService{
+			checkPermissionHandler: func() *authzedpb.CheckPermissionResponse {
+				return &authzedpb.CheckPermissionResponse{
+					Permissionship: authzedpb.CheckPermissionResponse_Permissionship(authzedpb.CheckPermissionResponse_PERMISSIONSHIP_HAS_PERMISSION),
+				}
+			},
+		})
+	})
+	defer testAuthzedServer.Close()
+
+	ctx := context.TODO()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(testAuthzedAuthDataMock()).Times(2)
+
+	authzed := &Authzed{
+		Endpoint:     testAuthzedServerEndpoint,
+		Insecure:     true,
+		SharedSecret: "secret",
+		Subject:      json.JSONValue{Static: "1"},
+		SubjectKind:  json.JSONValue{Static: "user"},
+		Resource:     json.JSONValue{Static: "123"},
+		ResourceKind: json.JSONValue{Static: "post"},
+		Permission:   json.JSONValue{Static: "read"},
+	}
+
+	_, err := authzed.Call(pipelineMock, ctx)
+	assert.NilError(t, err)
+	conn := authzed.conn
+	assert.Check(t, conn != nil)
+
+	_, err = authzed.Call(pipelineMock, ctx)
+	assert.NilError(t, err)
+	assert.Check(t, authzed.conn == conn)
+
+	assert.NilError(t, authzed.Clean(ctx))
+	assert.Check(t, authzed.conn == nil)
+}
@@ -0,0 +1,76 @@
+package authorization
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+
+	"github.com/google/cel-go/cel"
+)
+
+const (
+	celContextVariable = "context"
+	celAuthVariable    = "auth"
+)
+
+// NewCELAuthorization compiles a CEL expression into an authorization policy evaluated against the Authorization JSON.
+// The expression must evaluate to a boolean; compilation and type errors are returned at reconcile time.
+func NewCELAuthorization(expression string) (*CEL, error) {
+	env, err := cel.NewEnv(
+		cel.Variable(celContextVariable, cel.DynType),
+		cel.Variable(celAuthVariable, cel.DynType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if outputType := ast.OutputType(); !outputType.IsAssignableType(cel.BoolType) {
+		return nil, fmt.Errorf("cel expression must evaluate to a bool, got %v", outputType)
+	}
+
+	program, err := env.Program(ast, cel.EvalOptions(cel.OptOptimize), cel.InterruptCheckFrequency(100))
+	if err != nil {
+		return nil, err
+	}
+
+	return &CEL{Expression: expression, program: program}, nil
+}
+
+type CEL struct {
+	Expression string
+
+	program cel.Program
+}
+
+func (c *CEL) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	var authJSON map[string]interface{}
+	if err := gojson.Unmarshal([]byte(pipeline.GetAuthorizationJSON()), &authJSON); err != nil {
+		return false, err
+	}
+
+	if ctx == nil {
+		ctx = context.TODO()
+	}
+
+	result, _, err := c.program.ContextEval(ctx, map[string]interface{}{
+		celContextVariable: authJSON[celContextVariable],
+		celAuthVariable:    authJSON[celAuthVariable],
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if allowed, ok := result.Value().(bool); !ok {
+		return false, fmt.Errorf("cel expression evaluated to a non-bool value: %v", result.Value())
+	} else if !allowed {
+		return false, fmt.Errorf(unauthorizedErrorMsg)
+	}
+
+	return true, nil
+}
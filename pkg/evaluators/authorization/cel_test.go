@@ -0,0 +1,87 @@
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	. "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+const celTestAuthJSON = `{"context":{"request":{"http":{"method":"GET","path":"/pets","headers":{"x-origin":"some-origin"}}}},"auth":{"identity":{"username":"john","groups":["users","admins"]}}}`
+
+func TestCELAuthorization(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(celTestAuthJSON).AnyTimes()
+
+	testCases := []struct {
+		expression string
+		authorized bool
+	}{
+		{`context.request.http.method == "GET"`, true},
+		{`context.request.http.method == "POST"`, false},
+		{`"admins" in auth.identity.groups && context.request.http.path.startsWith("/pets")`, true},
+		{`auth.identity.username == "jane" || context.request.http.headers["x-origin"] == "other-origin"`, false},
+		{`has(auth.identity.username) && !has(auth.identity.email)`, true},
+	}
+
+	for _, tc := range testCases {
+		policy, err := NewCELAuthorization(tc.expression)
+		assert.NilError(t, err, tc.expression)
+
+		authorized, err := policy.Call(pipelineMock, context.TODO())
+		if tc.authorized {
+			assert.NilError(t, err, tc.expression)
+			assert.Equal(t, authorized, true)
+		} else {
+			assert.Error(t, err, unauthorizedErrorMsg, tc.expression)
+			assert.Equal(t, authorized, false)
+		}
+	}
+}
+
+func TestCELAuthorizationCompilationErrors(t *testing.T) {
+	_, err := NewCELAuthorization(`context.request.http.method ==`)
+	assert.ErrorContains(t, err, "Syntax error")
+
+	_, err = NewCELAuthorization(`request.http.method == "GET"`)
+	assert.ErrorContains(t, err, "undeclared reference to 'request'")
+
+	_, err = NewCELAuthorization(`"not a bool"`)
+	assert.ErrorContains(t, err, "must evaluate to a bool")
+}
+
+func TestCELAuthorizationNonBoolResult(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(celTestAuthJSON)
+
+	policy, err := NewCELAuthorization(`auth.identity.username`)
+	assert.NilError(t, err)
+
+	authorized, err := policy.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "non-bool value")
+	assert.Equal(t, authorized, false)
+}
+
+func TestCELAuthorizationMissingAttribute(t *testing.T) {
+	ctrl := NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(celTestAuthJSON)
+
+	policy, err := NewCELAuthorization(`auth.metadata.plan == "premium"`)
+	assert.NilError(t, err)
+
+	authorized, err := policy.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "no such key")
+	assert.Equal(t, authorized, false)
+}
@@ -0,0 +1,55 @@
+package authorization
+
+import (
+	gocontext "context"
+	"errors"
+	"net"
+	"net/url"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	FailurePolicyDeny  = "deny"
+	FailurePolicyAllow = "allow"
+)
+
+// DependencyError is an error caused by a failure to reach or to get a valid response from an external service the
+// authorization policy depends on, as opposed to the policy denying the request
+type DependencyError struct {
+	Err error
+}
+
+func (e *DependencyError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DependencyError) Unwrap() error {
+	return e.Err
+}
+
+// IsDependencyFailure tells whether an error returned by an authorization evaluator was caused by a failure of an
+// external dependency (i.e. network errors, timeouts and unavailability of the service), rather than by a denial
+func IsDependencyFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var dependencyErr *DependencyError
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &dependencyErr) || errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, gocontext.DeadlineExceeded) {
+		return true
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded:
+			return true
+		}
+	}
+
+	return k8s_errors.IsServiceUnavailable(err) || k8s_errors.IsTimeout(err) || k8s_errors.IsServerTimeout(err)
+}
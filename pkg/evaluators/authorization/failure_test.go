@@ -0,0 +1,27 @@
+package authorization
+
+import (
+	gocontext "context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsDependencyFailure(t *testing.T) {
+	assert.Check(t, !IsDependencyFailure(nil))
+	assert.Check(t, !IsDependencyFailure(fmt.Errorf(unauthorizedErrorMsg)))
+	assert.Check(t, !IsDependencyFailure(status.Error(codes.PermissionDenied, "denied")))
+	assert.Check(t, !IsDependencyFailure(k8s_errors.NewForbidden(schema.GroupResource{Resource: "subjectaccessreviews"}, "", fmt.Errorf("forbidden"))))
+
+	assert.Check(t, IsDependencyFailure(&DependencyError{Err: fmt.Errorf("500 Internal Server Error")}))
+	assert.Check(t, IsDependencyFailure(&url.Error{Op: "Post", URL: "http://127.0.0.1", Err: fmt.Errorf("connection refused")}))
+	assert.Check(t, IsDependencyFailure(fmt.Errorf("failed: %w", gocontext.DeadlineExceeded)))
+	assert.Check(t, IsDependencyFailure(status.Error(codes.Unavailable, "unavailable")))
+	assert.Check(t, IsDependencyFailure(k8s_errors.NewServiceUnavailable("unavailable")))
+}
@@ -0,0 +1,85 @@
+package authorization
+
+import (
+	gocontext "context"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
+	"github.com/kuadrant/authorino/pkg/httpclient"
+	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/log"
+)
+
+// GenericHttp delegates the authorization decision to an external HTTP service.
+// Access is granted if the service responds with a 2xx status code and the rules, if any, match the body of the response.
+// Failures to reach the service and 5xx responses are handled according to the failure policy.
+type GenericHttp struct {
+	*metadata.GenericHttp
+
+	Rules         []json.JSONPatternMatchingRule
+	Timeout       time.Duration
+	FailurePolicy string
+}
+
+func (h *GenericHttp) Call(pipeline auth.AuthPipeline, parentCtx gocontext.Context) (interface{}, error) {
+	ctx := parentCtx
+	if h.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(parentCtx, h.Timeout)
+		defer cancel()
+	}
+
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := h.BuildRequest(ctx, pipeline.GetAuthorizationJSON())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return h.failure(parentCtx, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return h.failure(parentCtx, err)
+	}
+
+	switch {
+	case resp.StatusCode >= 500:
+		return h.failure(parentCtx, fmt.Errorf("%s: %s", resp.Status, body))
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf(unauthorizedErrorMsg)
+	}
+
+	for _, rule := range h.Rules {
+		if authorized, err := rule.EvaluateFor(string(body)); err != nil {
+			return nil, err
+		} else if !authorized {
+			return nil, fmt.Errorf(unauthorizedErrorMsg)
+		}
+	}
+
+	var obj interface{}
+	if err := gojson.Unmarshal(body, &obj); err != nil {
+		return string(body), nil
+	}
+	return obj, nil
+}
+
+func (h *GenericHttp) failure(ctx gocontext.Context, err error) (interface{}, error) {
+	if h.FailurePolicy == FailurePolicyAllow {
+		log.FromContext(ctx).V(1).Info("failed to call the authorization service; allowing the request per failure policy", "err", err)
+		return nil, nil
+	}
+	return nil, &DependencyError{Err: err}
+}
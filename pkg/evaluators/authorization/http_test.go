@@ -0,0 +1,81 @@
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
+	"github.com/kuadrant/authorino/pkg/httptest"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+const testHttpAuthzServerHost string = "127.0.0.1:9013"
+
+func newHttpAuthz(path string, rules []json.JSONPatternMatchingRule, failurePolicy string) *GenericHttp {
+	return &GenericHttp{
+		GenericHttp: &metadata.GenericHttp{
+			Endpoint:    "http://" + testHttpAuthzServerHost + path,
+			Method:      "POST",
+			Body:        &json.JSONValue{Pattern: "@this"},
+			ContentType: "application/json",
+		},
+		Rules:         rules,
+		FailurePolicy: failurePolicy,
+	}
+}
+
+func TestGenericHttpAuthorization(t *testing.T) {
+	httpServer := httptest.NewHttpServerMock(testHttpAuthzServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/allow":  httptest.NewHttpServerMockResponseFuncJSON(`{"allowed":true}`),
+		"/deny":   httptest.NewHttpServerMockResponseFuncJSON(`{"allowed":false}`),
+		"/403":    httptest.NewHttpServerMockResponseFunc(403, nil, ""),
+		"/500":    httptest.NewHttpServerMockResponseFunc(500, nil, "oops"),
+		"/silent": httptest.NewHttpServerMockResponseFunc(204, nil, ""),
+	})
+	defer httpServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john"}}}`).AnyTimes()
+
+	allowed := []json.JSONPatternMatchingRule{{Selector: "allowed", Operator: "eq", Value: "true"}}
+
+	obj, err := newHttpAuthz("/allow", allowed, FailurePolicyDeny).Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(map[string]interface{})["allowed"], true)
+
+	_, err = newHttpAuthz("/silent", nil, FailurePolicyDeny).Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	_, err = newHttpAuthz("/deny", allowed, FailurePolicyDeny).Call(pipelineMock, context.TODO())
+	assert.Error(t, err, unauthorizedErrorMsg)
+
+	_, err = newHttpAuthz("/403", nil, FailurePolicyAllow).Call(pipelineMock, context.TODO())
+	assert.Error(t, err, unauthorizedErrorMsg)
+
+	_, err = newHttpAuthz("/500", nil, FailurePolicyDeny).Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "500 Internal Server Error")
+
+	_, err = newHttpAuthz("/500", nil, FailurePolicyAllow).Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+}
+
+func TestGenericHttpAuthorizationUnreachable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`).AnyTimes()
+
+	_, err := newHttpAuthz("/allow", nil, FailurePolicyDeny).Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "connection refused")
+
+	_, err = newHttpAuthz("/allow", nil, FailurePolicyAllow).Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+}
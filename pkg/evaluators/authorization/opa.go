@@ -4,19 +4,25 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/httpclient"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/lru"
 	"github.com/kuadrant/authorino/pkg/workers"
 
 	opaParser "github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/resolver"
+	opaTypes "github.com/open-policy-agent/opa/types"
 
 	"go.opentelemetry.io/otel"
 	otel_propagation "go.opentelemetry.io/otel/propagation"
@@ -26,6 +32,7 @@ const (
 	policyTemplate = `package %s
 default allow = false
 %s`
+	policyTemplateLines    = 2 // lines added by the template before the policy
 	policyUIDHashSeparator = "|"
 	allowQuery             = "allow"
 
@@ -36,9 +43,37 @@ default allow = false
 	msg_opaPolicyRefreshFromRegistrySkipped  = "external policy unchanged"
 	msg_opaPolicyRefreshFromRegistrySuccess  = "policy updated from external registry"
 	msg_opaPolicyRefreshFromRegistryDisabled = "auto-refresh of external policy disabled"
+
+	precompiledPoliciesCacheSize = 1000
 )
 
+// precompiledPolicies caches the prepared queries of the policies, so reconciling an AuthConfig whose policies did not
+// change does not compile them again
+var precompiledPolicies = &precompiledPolicyCache{cache: lru.New("opa_policies", lru.Options{MaxEntries: precompiledPoliciesCacheSize})}
+
+type precompiledPolicyCache struct {
+	cache *lru.Cache
+}
+
+func (c *precompiledPolicyCache) get(key string) *rego.PreparedEvalQuery {
+	if policy, found := c.cache.Get(key); found {
+		return policy.(*rego.PreparedEvalQuery)
+	}
+	return nil
+}
+
+func (c *precompiledPolicyCache) set(key string, policy *rego.PreparedEvalQuery) {
+	c.cache.Add(key, policy)
+}
+
 func NewOPAAuthorization(policyName string, rego string, externalSource *OPAExternalSource, allValues bool, nonce int, ctx context.Context) (*OPA, error) {
+	return NewOPAAuthorizationWithLibraries(policyName, rego, nil, externalSource, allValues, nonce, ctx)
+}
+
+// NewOPAAuthorizationWithLibraries builds an OPA authorization policy compiled together with a set of shared Rego
+// modules (libraries), by name of the module. Unlike the policy, the libraries must declare their packages, to be
+// imported by the policy (e.g. `import data.lib.helpers`).
+func NewOPAAuthorizationWithLibraries(policyName string, rego string, libraries map[string]string, externalSource *OPAExternalSource, allValues bool, nonce int, ctx context.Context) (*OPA, error) {
 	logger := log.FromContext(ctx).WithName("opa")
 
 	pullFromRegistry := rego == "" && externalSource != nil && externalSource.Endpoint != ""
@@ -55,6 +90,7 @@ func NewOPAAuthorization(policyName string, rego string, externalSource *OPAExte
 	o := &OPA{
 		ExternalSource: externalSource,
 		AllValues:      allValues,
+		Libraries:      libraries,
 		policyName:     policyName,
 		policyUID:      generatePolicyUID(policyName, rego, nonce),
 		opaContext:     context.TODO(),
@@ -74,6 +110,7 @@ type OPA struct {
 	Rego           string `yaml:"rego"`
 	ExternalSource *OPAExternalSource
 	AllValues      bool
+	Libraries      map[string]string
 
 	opaContext context.Context
 	policy     *rego.PreparedEvalQuery
@@ -91,8 +128,16 @@ func (opa *OPA) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface
 	if err := json.Unmarshal([]byte(pipeline.GetAuthorizationJSON()), &authJSON); err != nil {
 		return false, err
 	} else {
-		options := rego.EvalInput(authJSON)
-		results, err := opa.policy.Eval(opa.opaContext, options)
+		options := []rego.EvalOption{
+			rego.EvalInput(authJSON),
+			rego.EvalResolver(metadataDataRef, &metadataResolver{pipeline: pipeline}),
+		}
+		// evaluates within the context of the request, so built-ins such as http.send are cancelled along with the auth pipeline
+		if ctx == nil {
+			ctx = opa.opaContext
+		}
+		evalCtx := context.WithValue(ctx, authPipelineContextKey{}, pipeline) // read by the authorino built-ins
+		results, err := opa.policy.Eval(evalCtx, options...)
 
 		if err != nil {
 			return nil, err
@@ -128,17 +173,25 @@ func (opa *OPA) updateRego(rego string, ctx context.Context, force bool) (bool,
 
 	opa.Rego = newRego
 
-	if policy, err := precompilePolicy(opa.opaContext, opa.policyUID, opa.Rego, opa.AllValues); err != nil {
+	cacheKey := strings.Join([]string{opa.policyUID, hash(opa.Rego), fmt.Sprint(opa.AllValues), hashLibraries(opa.Libraries)}, policyUIDHashSeparator)
+	if policy := precompiledPolicies.get(cacheKey); policy != nil {
+		opa.policy = policy
+		return true, nil
+	}
+
+	if policy, err := precompilePolicy(opa.opaContext, opa.policyUID, opa.Rego, opa.Libraries, opa.AllValues); err != nil {
+		err = newPolicyCompilationError(opa.policyName, opa.policyUID+".rego", err)
 		opa.Rego = currentRego
 		log.FromContext(ctx).Error(err, msg_OpaPolicyPrecompileError, "policy", opa.policyName)
 		return false, err
 	} else {
+		precompiledPolicies.set(cacheKey, policy)
 		opa.policy = policy
 		return true, nil
 	}
 }
 
-func precompilePolicy(ctx context.Context, policyUID, policyRego string, allValues bool) (*rego.PreparedEvalQuery, error) {
+func precompilePolicy(ctx context.Context, policyUID, policyRego string, libraries map[string]string, allValues bool) (*rego.PreparedEvalQuery, error) {
 	policyName := fmt.Sprintf(`authorino.authz["%s"]`, policyUID)
 	policyContent := fmt.Sprintf(policyTemplate, policyName, policyRego)
 	policyFileName := policyUID + ".rego"
@@ -163,10 +216,16 @@ func precompilePolicy(ctx context.Context, policyUID, policyRego string, allValu
 		}
 	}
 
-	r := rego.New(
+	options := []func(*rego.Rego){
 		rego.Query(strings.Join(queries, ";")),
 		rego.ParsedModule(module),
-	)
+	}
+	for _, name := range sortedKeys(libraries) {
+		options = append(options, rego.Module(name, libraries[name]))
+	}
+	options = append(options, builtins...)
+
+	r := rego.New(options...)
 
 	if regoPolicy, err := r.PrepareForEval(ctx); err != nil {
 		return nil, err
@@ -175,6 +234,169 @@ func precompilePolicy(ctx context.Context, policyUID, policyRego string, allValu
 	}
 }
 
+// PolicyCompilationError is the error of a policy that failed to be parsed or compiled, with the locations of the
+// errors relative to the Rego document of the policy, or to the library where they occurred
+type PolicyCompilationError struct {
+	Policy string
+	Errors []PolicyCompilationErrorDetail
+}
+
+type PolicyCompilationErrorDetail struct {
+	// Library is the name of the library where the error occurred; empty for errors in the policy itself
+	Library string
+	Line    int
+	Column  int
+	Code    string
+	Message string
+}
+
+func (e *PolicyCompilationError) Error() string {
+	details := make([]string, len(e.Errors))
+	for i, detail := range e.Errors {
+		location := fmt.Sprintf("line %d, column %d", detail.Line, detail.Column)
+		if detail.Library != "" {
+			location = detail.Library + ": " + location
+		}
+		details[i] = fmt.Sprintf("%s: %s: %s", location, detail.Code, detail.Message)
+	}
+	return fmt.Sprintf("failed to compile policy %s: %s", e.Policy, strings.Join(details, "; "))
+}
+
+// newPolicyCompilationError wraps the errors of the rego parser and compiler; other errors are returned untouched
+func newPolicyCompilationError(policyName, policyFileName string, err error) error {
+	var regoErrs opaParser.Errors
+	if errs, ok := err.(rego.Errors); ok {
+		// errors of the parser of the libraries come wrapped in a list of errors
+		for _, e := range errs {
+			var astErrs opaParser.Errors
+			if !errors.As(e, &astErrs) {
+				return err
+			}
+			regoErrs = append(regoErrs, astErrs...)
+		}
+	} else if !errors.As(err, &regoErrs) {
+		return err
+	}
+
+	compilationErr := &PolicyCompilationError{Policy: policyName}
+	for _, regoErr := range regoErrs {
+		detail := PolicyCompilationErrorDetail{Code: regoErr.Code, Message: regoErr.Message}
+		if location := regoErr.Location; location != nil {
+			detail.Line, detail.Column = location.Row, location.Col
+			if location.File == policyFileName {
+				if detail.Line -= policyTemplateLines; detail.Line < 1 {
+					detail.Line = 1
+				}
+			} else {
+				detail.Library = location.File
+			}
+		}
+		compilationErr.Errors = append(compilationErr.Errors, detail)
+	}
+	return compilationErr
+}
+
+// ParseOPAPolicy checks the syntax of a policy, without compiling it (e.g. policies that depend on libraries)
+func ParseOPAPolicy(policyName, policyRego string) error {
+	policyFileName := policyName + ".rego"
+	policyContent := fmt.Sprintf(policyTemplate, `authorino.authz["policy"]`, cleanUpRegoDocument(policyRego))
+	if _, err := opaParser.ParseModule(policyFileName, policyContent); err != nil {
+		return newPolicyCompilationError(policyName, policyFileName, err)
+	}
+	return nil
+}
+
+type authPipelineContextKey struct{}
+
+// builtins are the authorino-specific built-in functions available to the policies, that read data directly from the
+// auth pipeline:
+//   - authorino.identity(): the resolved identity object
+//   - authorino.metadata(name): the metadata object fetched by the metadata config with the given name
+//   - authorino.request_header(name): the value of the request header with the given name (case-insensitive)
+var builtins = []func(*rego.Rego){
+	rego.FunctionDyn(
+		&rego.Function{Name: "authorino.identity", Decl: opaTypes.NewFunction(nil, opaTypes.A)},
+		func(bctx rego.BuiltinContext, _ []*opaParser.Term) (*opaParser.Term, error) {
+			pipeline := authPipelineFromContext(bctx.Context)
+			if pipeline == nil {
+				return nil, nil
+			}
+			return builtinResult(pipeline.GetIdentity().Object)
+		},
+	),
+	rego.Function1(
+		&rego.Function{Name: "authorino.metadata", Decl: opaTypes.NewFunction(opaTypes.Args(opaTypes.S), opaTypes.A)},
+		func(bctx rego.BuiltinContext, name *opaParser.Term) (*opaParser.Term, error) {
+			pipeline := authPipelineFromContext(bctx.Context)
+			if pipeline == nil {
+				return nil, nil
+			}
+			metadataName, ok := name.Value.(opaParser.String)
+			if !ok {
+				return nil, fmt.Errorf("invalid metadata name")
+			}
+			if metadata, found := pipeline.GetMetadata().Get(string(metadataName)); found {
+				return builtinResult(metadata)
+			}
+			return nil, nil
+		},
+	),
+	rego.Function1(
+		&rego.Function{Name: "authorino.request_header", Decl: opaTypes.NewFunction(opaTypes.Args(opaTypes.S), opaTypes.S)},
+		func(bctx rego.BuiltinContext, name *opaParser.Term) (*opaParser.Term, error) {
+			pipeline := authPipelineFromContext(bctx.Context)
+			if pipeline == nil {
+				return nil, nil
+			}
+			headerName, ok := name.Value.(opaParser.String)
+			if !ok {
+				return nil, fmt.Errorf("invalid header name")
+			}
+			if value, found := pipeline.GetRequestAttributes().Header(string(headerName)); found {
+				return opaParser.StringTerm(value), nil
+			}
+			return nil, nil
+		},
+	),
+}
+
+// metadataDataRef is the path of the data document that holds the objects fetched in the metadata phase of the auth
+// pipeline, by name of the metadata config (e.g. data.metadata["user-info"].email)
+var metadataDataRef = opaParser.MustParseRef("data.metadata")
+
+// metadataResolver resolves the metadata data document, only if referred by the policy
+type metadataResolver struct {
+	pipeline auth.AuthPipeline
+}
+
+func (r *metadataResolver) Eval(_ context.Context, _ resolver.Input) (resolver.Result, error) {
+	value, err := opaParser.InterfaceToValue(map[string]interface{}(r.pipeline.GetMetadata()))
+	if err != nil {
+		return resolver.Result{}, err
+	}
+	return resolver.Result{Value: value}, nil
+}
+
+func authPipelineFromContext(ctx context.Context) auth.AuthPipeline {
+	if ctx == nil {
+		return nil
+	}
+	pipeline, _ := ctx.Value(authPipelineContextKey{}).(auth.AuthPipeline)
+	return pipeline
+}
+
+// builtinResult converts a value to a rego term; nil values are undefined
+func builtinResult(value interface{}) (*opaParser.Term, error) {
+	if value == nil {
+		return nil, nil
+	}
+	v, err := opaParser.InterfaceToValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return opaParser.NewTerm(v), nil
+}
+
 func cleanUpRegoDocument(rego string) string {
 	r, _ := regexp.Compile("(\\s)*package.*[;\\n]+")
 	return r.ReplaceAllString(rego, "")
@@ -184,6 +406,24 @@ func generatePolicyUID(policyName string, policyContent string, nonce int) strin
 	return hash(fmt.Sprint(nonce) + policyUIDHashSeparator + policyName + policyUIDHashSeparator + policyContent)
 }
 
+// hashLibraries hashes the names and contents of the libraries, in order of name
+func hashLibraries(libraries map[string]string) string {
+	var b strings.Builder
+	for _, name := range sortedKeys(libraries) {
+		b.WriteString(name + policyUIDHashSeparator + libraries[name] + policyUIDHashSeparator)
+	}
+	return hash(b.String())
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func hash(s string) string {
 	data := []byte(s)
 	return fmt.Sprintf("%x", sha256.Sum256(data))
@@ -213,7 +453,7 @@ func (ext *OPAExternalSource) downloadRegoDataFromUrl() (string, error) {
 
 	otel.GetTextMapPropagator().Inject(req.Context(), otel_propagation.HeaderCarrier(req.Header))
 
-	if resp, err := http.DefaultClient.Do(req); err != nil {
+	if resp, err := httpclient.Client().Do(req); err != nil {
 		return "", fmt.Errorf("failed to fetch Rego config: %v", err)
 	} else {
 		defer resp.Body.Close()
@@ -3,7 +3,10 @@ package authorization
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	gohttptest "net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -34,6 +37,178 @@ func TestOPAInlineRego(t *testing.T) {
 	assertOPAAuthorization(t, opa)
 }
 
+func TestOPAInlineRegoPrecompiledOnce(t *testing.T) {
+	opa1, err := NewOPAAuthorization("test-opa-precompiled", opaInlineRegoDataMock, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+
+	opa2, err := NewOPAAuthorization("test-opa-precompiled", opaInlineRegoDataMock, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+	assert.Check(t, opa1.policy == opa2.policy)
+	assertOPAAuthorization(t, opa2)
+
+	opa3, err := NewOPAAuthorization("test-opa-precompiled", opaInlineRegoDataMock, &OPAExternalSource{}, true, 0, context.TODO())
+	assert.NilError(t, err)
+	assert.Check(t, opa1.policy != opa3.policy)
+
+	opa4, err := NewOPAAuthorization("test-opa-precompiled", "allow { true }", &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+	assert.Check(t, opa1.policy != opa4.policy)
+}
+
+func TestOPAInvalidInlineRego(t *testing.T) {
+	_, err := NewOPAAuthorization("test-opa-invalid", "allow { ", &OPAExternalSource{}, false, 0, context.TODO())
+	assert.ErrorContains(t, err, "rego_parse_error")
+}
+
+func TestOPAPolicyCompilationError(t *testing.T) {
+	_, err := NewOPAAuthorization("test-opa-invalid", "allow {\n  is_admin(input.auth.identity)\n}", &OPAExternalSource{}, false, 0, context.TODO())
+	var compilationErr *PolicyCompilationError
+	assert.Check(t, errors.As(err, &compilationErr))
+	assert.Equal(t, compilationErr.Policy, "test-opa-invalid")
+	assert.Equal(t, len(compilationErr.Errors), 1)
+	assert.Equal(t, compilationErr.Errors[0].Library, "")
+	assert.Equal(t, compilationErr.Errors[0].Line, 2)
+	assert.Equal(t, compilationErr.Errors[0].Column, 3)
+	assert.Equal(t, compilationErr.Errors[0].Code, "rego_type_error")
+	assert.Error(t, err, "failed to compile policy test-opa-invalid: line 2, column 3: rego_type_error: undefined function is_admin")
+
+	libraries := map[string]string{"libs/helpers.rego": "package lib.helpers\nis_admin(user) { user.groups[_] == }"}
+	_, err = NewOPAAuthorizationWithLibraries("test-opa-invalid", "allow { true }", libraries, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.Check(t, errors.As(err, &compilationErr))
+	assert.Equal(t, compilationErr.Errors[0].Library, "libs/helpers.rego")
+	assert.Equal(t, compilationErr.Errors[0].Line, 2)
+}
+
+func newTestRequestAttributes(headers map[string]string) auth.RequestAttributes {
+	return auth.RequestAttributes{AttributeContext: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Headers: headers}},
+	}}
+}
+
+func TestOPABuiltins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rego := `allow {
+		authorino.identity().username == "john"
+		authorino.metadata("user-info").tier == "gold"
+		authorino.request_header("X-Tenant") == "acme"
+		not authorino.metadata("missing")
+	}`
+	opa, err := NewOPAAuthorization("test-opa-builtins", rego, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{"identity":{"username":"john"},"metadata":{"user-info":{"tier":"gold"}}}}`).AnyTimes()
+	pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Object: map[string]interface{}{"username": "john"}}).AnyTimes()
+	pipelineMock.EXPECT().GetMetadata().Return(auth.MetadataSet{"user-info": map[string]interface{}{"tier": "gold"}}).AnyTimes()
+	pipelineMock.EXPECT().GetRequestAttributes().Return(newTestRequestAttributes(map[string]string{"x-tenant": "acme"})).AnyTimes()
+
+	_, err = opa.Call(pipelineMock, nil)
+	assert.NilError(t, err)
+
+	pipelineMock = mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{"identity":{"username":"john"},"metadata":{"user-info":{"tier":"gold"}}}}`).AnyTimes()
+	pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Object: map[string]interface{}{"username": "john"}}).AnyTimes()
+	pipelineMock.EXPECT().GetMetadata().Return(auth.MetadataSet{"user-info": map[string]interface{}{"tier": "gold"}}).AnyTimes()
+	pipelineMock.EXPECT().GetRequestAttributes().Return(newTestRequestAttributes(map[string]string{"x-tenant": "other"})).AnyTimes()
+
+	_, err = opa.Call(pipelineMock, nil)
+	assert.Error(t, err, unauthorizedErrorMsg)
+}
+
+func TestOPAMetadataDataDocument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rego := `allow {
+		data.metadata["user-info"].tier == "gold"
+		count([r | walk(data.metadata.resources, [_, r]); r.owner == "john"]) == 2
+		not data.metadata.missing
+	}`
+	opa, err := NewOPAAuthorization("test-opa-metadata-data", rego, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+
+	metadata := auth.MetadataSet{
+		"user-info": map[string]interface{}{"tier": "gold"},
+		"resources": []interface{}{map[string]interface{}{"owner": "john"}, map[string]interface{}{"owner": "jane"}, map[string]interface{}{"owner": "john"}},
+	}
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{}}`).AnyTimes()
+	pipelineMock.EXPECT().GetMetadata().Return(metadata).AnyTimes()
+
+	_, err = opa.Call(pipelineMock, nil)
+	assert.NilError(t, err)
+
+	metadata["user-info"] = map[string]interface{}{"tier": "silver"}
+	_, err = opa.Call(pipelineMock, nil)
+	assert.Error(t, err, unauthorizedErrorMsg)
+}
+
+func TestOPALibraries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	libraries := map[string]string{
+		"libs/helpers.rego": `package lib.helpers
+		is_admin(user) { user.groups[_] == "admin" }`,
+	}
+	rego := `import data.lib.helpers
+	allow { helpers.is_admin(input.auth.identity) }`
+
+	_, err := NewOPAAuthorization("test-opa-libraries", rego, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.ErrorContains(t, err, "undefined function data.lib.helpers.is_admin")
+
+	opa, err := NewOPAAuthorizationWithLibraries("test-opa-libraries", rego, libraries, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{"identity":{"groups":["admin"]}}}`)
+	_, err = opa.Call(pipelineMock, nil)
+	assert.NilError(t, err)
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{"identity":{"groups":["dev"]}}}`).Times(2)
+	_, err = opa.Call(pipelineMock, nil)
+	assert.Error(t, err, unauthorizedErrorMsg)
+
+	// changes to the libraries are compiled again
+	libraries["libs/helpers.rego"] = `package lib.helpers
+	is_admin(user) { user.groups[_] == "dev" }`
+	opa, err = NewOPAAuthorizationWithLibraries("test-opa-libraries", rego, libraries, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+	_, err = opa.Call(pipelineMock, nil)
+	assert.NilError(t, err)
+}
+
+func TestOPACallCancelledWithTheRequest(t *testing.T) {
+	slowServer := gohttptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slowServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rego := fmt.Sprintf(`allow { http.send({"method": "get", "url": "%s"}).status_code == 200 }`, slowServer.URL)
+	opa, err := NewOPAAuthorization("test-opa-cancelled", rego, &OPAExternalSource{}, false, 0, context.TODO())
+	assert.NilError(t, err)
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{}}`)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = opa.Call(pipelineMock, ctx)
+	assert.Check(t, err != nil)
+	assert.Check(t, time.Since(start) < 2*time.Second)
+}
+
 func TestOPAExternalUrl(t *testing.T) {
 	extHttpMetadataServer := httptest.NewHttpServerMock(opaExtHttpServerMockAddr, map[string]httptest.HttpServerMockResponseFunc{
 		"/rego": func() httptest.HttpServerMockResponse {
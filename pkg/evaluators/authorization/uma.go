@@ -0,0 +1,203 @@
+package authorization
+
+import (
+	"bytes"
+	gocontext "context"
+	gojson "encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
+	"github.com/kuadrant/authorino/pkg/httpclient"
+	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"go.opentelemetry.io/otel"
+	otel_propagation "go.opentelemetry.io/otel/propagation"
+)
+
+const umaTicketGrantType = "urn:ietf:params:oauth:grant-type:uma-ticket"
+
+// NewUMAAuthorization discovers the UMA server and returns an authorization evaluator that enforces its policies by performing the UMA 2.0 grant flow.
+func NewUMAAuthorization(endpoint string, clientID string, clientSecret string, authCred auth.AuthCredentials, resourceID *json.JSONValue, scopes []json.JSONValue) (*UMA, error) {
+	uma, err := metadata.NewUMAMetadata(endpoint, clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if uma.GetProvider().GetPermissionURL() == "" {
+		return nil, fmt.Errorf("uma provider does not support permission tickets")
+	}
+	return &UMA{
+		AuthCredentials: authCred,
+		ResourceID:      resourceID,
+		Scopes:          scopes,
+		uma:             uma,
+	}, nil
+}
+
+// UMA enforces the policies of a User-Managed Access (UMA) server (e.g. Keycloak Authorization Services).
+// It requests a permission ticket for the resource with the protection API token (PAT) of the resource server,
+// then exchanges the ticket for a requesting party token (RPT) on behalf of the user, using the access token supplied in the request.
+type UMA struct {
+	auth.AuthCredentials
+
+	// ResourceID of the protected resource. If omitted, resources are queried by the path of the request.
+	ResourceID *json.JSONValue
+	Scopes     []json.JSONValue
+
+	uma *metadata.UMA
+}
+
+type umaPermission struct {
+	ResourceID     string   `json:"resource_id"`
+	ResourceScopes []string `json:"resource_scopes,omitempty"`
+}
+
+type umaTicket struct {
+	Ticket string `json:"ticket"`
+}
+
+func (u *UMA) Call(pipeline auth.AuthPipeline, parentCtx gocontext.Context) (interface{}, error) {
+	ctx := log.IntoContext(parentCtx, log.FromContext(parentCtx).WithName("uma"))
+
+	accessToken, err := u.GetCredentialsFromReq(pipeline.GetHttp())
+	if err != nil {
+		return nil, err
+	}
+
+	var pat metadata.PAT
+	if err := u.uma.RequestPAT(ctx, &pat); err != nil {
+		return nil, err
+	}
+
+	permissions, err := u.buildPermissions(pipeline, pat, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) == 0 {
+		log.FromContext(ctx).V(1).Info("no uma resource found")
+		return nil, fmt.Errorf(unauthorizedErrorMsg)
+	}
+
+	ticket, err := u.requestTicket(permissions, pat, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.requestRPT(ticket, accessToken, ctx)
+}
+
+func (u *UMA) buildPermissions(pipeline auth.AuthPipeline, pat metadata.PAT, ctx gocontext.Context) ([]umaPermission, error) {
+	authJSON := pipeline.GetAuthorizationJSON()
+
+	scopes := make([]string, 0, len(u.Scopes))
+	for _, scope := range u.Scopes {
+		if resolved := scope.ResolveFor(authJSON); resolved != nil {
+			scopes = append(scopes, fmt.Sprintf("%v", resolved))
+		}
+	}
+
+	var resourceIDs []string
+	if u.ResourceID != nil {
+		if resolved := u.ResourceID.ResolveFor(authJSON); resolved != nil {
+			if resourceID := fmt.Sprintf("%v", resolved); resourceID != "" {
+				resourceIDs = []string{resourceID}
+			}
+		}
+	} else {
+		var err error
+		if resourceIDs, err = u.uma.GetProvider().QueryResources(metadata.UMAResourceQuery{URI: pipeline.GetHttp().GetPath()}, pat, ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	permissions := make([]umaPermission, 0, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		permissions = append(permissions, umaPermission{ResourceID: resourceID, ResourceScopes: scopes})
+	}
+	return permissions, nil
+}
+
+func (u *UMA) requestTicket(permissions []umaPermission, pat metadata.PAT, ctx gocontext.Context) (string, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return "", err
+	}
+
+	payload, _ := gojson.Marshal(permissions)
+	permissionURL := u.uma.GetProvider().GetPermissionURL()
+	req, err := http.NewRequestWithContext(ctx, "POST", permissionURL, bytes.NewBuffer(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+pat.String())
+
+	log.FromContext(ctx).V(1).Info("requesting permission ticket", "url", permissionURL, "permissions", string(payload))
+
+	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// the permission endpoint responds with 201 Created
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("failed to request uma permission ticket: %s: %s", resp.Status, body)
+	}
+
+	var ticket umaTicket
+	if err := gojson.Unmarshal(body, &ticket); err != nil || ticket.Ticket == "" {
+		return "", fmt.Errorf("failed to decode uma permission ticket: %s", body)
+	}
+	return ticket.Ticket, nil
+}
+
+func (u *UMA) requestRPT(ticket string, accessToken string, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	tokenURL := u.uma.GetProvider().GetTokenURL()
+	data := url.Values{"grant_type": {umaTicketGrantType}, "ticket": {ticket}}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	log.FromContext(ctx).V(1).Info("requesting rpt", "url", tokenURL)
+
+	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return nil, fmt.Errorf(unauthorizedErrorMsg)
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to request uma rpt: %s: %s", resp.Status, body)
+	}
+
+	var rpt map[string]interface{}
+	if err := gojson.NewDecoder(resp.Body).Decode(&rpt); err != nil {
+		return nil, fmt.Errorf("failed to decode uma rpt: %v", err)
+	}
+	return rpt, nil
+}
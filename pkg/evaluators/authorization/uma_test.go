@@ -0,0 +1,103 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/httptest"
+	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/oauth2"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+const testUMAServerHost string = "127.0.0.1:9012"
+
+var (
+	testUMAIssuer          = fmt.Sprintf("http://%v/uma", testUMAServerHost)
+	testUMAWellKnownConfig = fmt.Sprintf(`{
+		"issuer": "%v",
+		"token_endpoint": "%v/token",
+		"resource_registration_endpoint": "%v/resource_set",
+		"permission_endpoint": "%v/permission"
+	}`, testUMAIssuer, testUMAIssuer, testUMAIssuer, testUMAIssuer)
+)
+
+// newUMAServerMock responds to the token endpoint with the PAT first and then with the given status for the RPT
+func newUMAServerMock(rptStatus int) func() {
+	// the pat is cached by the shared token manager otherwise
+	oauth2.SharedTokenManager = oauth2.NewTokenManager()
+
+	tokenRequests := 0
+	json := func(status int, body string) httptest.HttpServerMockResponseFunc {
+		return httptest.NewHttpServerMockResponseFunc(status, map[string]string{"Content-Type": "application/json"}, body)
+	}
+	server := httptest.NewHttpServerMock(testUMAServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/uma/.well-known/uma2-configuration": json(200, testUMAWellKnownConfig),
+		"/uma/token": func() httptest.HttpServerMockResponse {
+			tokenRequests++
+			if tokenRequests == 1 {
+				return json(200, `{"access_token":"pat"}`)()
+			}
+			if rptStatus != 200 {
+				return json(rptStatus, `{"error":"access_denied"}`)()
+			}
+			return json(200, `{"access_token":"rpt","token_type":"Bearer"}`)()
+		},
+		"/uma/resource_set?uri=/greetings": json(200, `["a1b2c3"]`),
+		"/uma/permission":                  json(201, `{"ticket":"some-ticket"}`),
+	})
+	return server.Close
+}
+
+func TestUMAAuthorizationGranted(t *testing.T) {
+	closeServer := newUMAServerMock(200)
+	defer closeServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	request := &envoy_auth.AttributeContext_HttpRequest{
+		Path:    "/greetings",
+		Headers: map[string]string{"authorization": "Bearer user-token"},
+	}
+	pipelineMock.EXPECT().GetHttp().Return(request).AnyTimes()
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	uma, err := NewUMAAuthorization(testUMAIssuer, "client-id", "client-secret", auth.NewAuthCredential("Bearer", "authorization_header"), nil, []json.JSONValue{{Static: "read"}})
+	assert.NilError(t, err)
+
+	obj, err := uma.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	rpt, _ := obj.(map[string]interface{})
+	assert.Equal(t, rpt["access_token"], "rpt")
+}
+
+func TestUMAAuthorizationDenied(t *testing.T) {
+	closeServer := newUMAServerMock(403)
+	defer closeServer()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	request := &envoy_auth.AttributeContext_HttpRequest{
+		Path:    "/greetings",
+		Headers: map[string]string{"authorization": "Bearer user-token"},
+	}
+	pipelineMock.EXPECT().GetHttp().Return(request).AnyTimes()
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	uma, err := NewUMAAuthorization(testUMAIssuer, "client-id", "client-secret", auth.NewAuthCredential("Bearer", "authorization_header"), &json.JSONValue{Static: "a1b2c3"}, nil)
+	assert.NilError(t, err)
+
+	obj, err := uma.Call(pipelineMock, context.TODO())
+	assert.Check(t, obj == nil)
+	assert.Error(t, err, unauthorizedErrorMsg)
+}
@@ -0,0 +1,44 @@
+package evaluators
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+func TestAuthorizationFailurePolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`).AnyTimes()
+
+	unreachable := &authorization.GenericHttp{
+		GenericHttp:   &metadata.GenericHttp{Endpoint: "http://127.0.0.1:9999/unreachable", Method: "POST", ContentType: "application/json"},
+		FailurePolicy: authorization.FailurePolicyDeny,
+	}
+
+	authorizationConfig := &AuthorizationConfig{Name: "unreachable", GenericHTTP: unreachable}
+	_, err := authorizationConfig.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "connection refused")
+
+	authorizationConfig.FailurePolicy = authorization.FailurePolicyAllow
+	_, err = authorizationConfig.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	// denials are not affected by the failure policy
+	authorizationConfig = &AuthorizationConfig{
+		Name:          "deny",
+		JSON:          &authorization.JSONPatternMatching{Rules: []json.JSONPatternMatchingRule{{Selector: "auth.identity.sub", Operator: "eq", Value: "john"}}},
+		FailurePolicy: authorization.FailurePolicyAllow,
+	}
+	_, err = authorizationConfig.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "Unauthorized")
+}
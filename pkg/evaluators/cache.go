@@ -2,31 +2,39 @@ package evaluators
 
 import (
 	gojson "encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/kuadrant/authorino/pkg/json"
-
-	"github.com/coocood/freecache"
-	gocache "github.com/eko/gocache/cache"
-	cache_store "github.com/eko/gocache/store"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/lru"
+	"github.com/kuadrant/authorino/pkg/singleflight"
 )
 
-var EvaluatorCacheSize int // in megabytes
+var (
+	EvaluatorCacheSize       int // in megabytes
+	EvaluatorCacheMaxEntries int
+)
 
 type EvaluatorCache interface {
 	Get(key interface{}) (interface{}, error)
 	Set(key, value interface{}) error
 	ResolveKeyFor(authJSON string) interface{}
+	// Do calls fn, unless a call for the same key is already in-flight, in which case it waits for that call to finish
+	// and returns its results instead. This prevents concurrent requests that miss the cache from calling the
+	// evaluator repeatedly for the same key.
+	Do(key interface{}, fn func() (interface{}, error)) (interface{}, error)
 	Shutdown() error
 }
 
 func NewEvaluatorCache(keyTemplate json.JSONValue, ttl int) EvaluatorCache {
-	duration := time.Duration(ttl) * time.Second
-	cacheClient := freecache.NewCache(EvaluatorCacheSize * 1024 * 1024)
-	cacheStore := cache_store.NewFreecache(cacheClient, &cache_store.Options{Expiration: duration})
 	c := &evaluatorCache{
 		keyTemplate: keyTemplate,
-		store:       gocache.New(cacheStore),
+		store: lru.New("evaluator", lru.Options{
+			MaxEntries: EvaluatorCacheMaxEntries,
+			MaxBytes:   EvaluatorCacheSize * 1024 * 1024,
+			TTL:        time.Duration(ttl) * time.Second,
+		}),
 	}
 	return c
 }
@@ -34,11 +42,12 @@ func NewEvaluatorCache(keyTemplate json.JSONValue, ttl int) EvaluatorCache {
 // evaluatorCache caches JSON values (objects, arrays, strings, etc)
 type evaluatorCache struct {
 	keyTemplate json.JSONValue
-	store       *gocache.Cache
+	store       *lru.Cache
+	inflight    singleflight.Group
 }
 
 func (c *evaluatorCache) Get(key interface{}) (interface{}, error) {
-	if valueAsBytes, ttl, _ := c.store.GetWithTTL(key); valueAsBytes != nil && ttl > 0 {
+	if valueAsBytes, found := c.store.Get(fmt.Sprint(key)); found {
 		var value interface{}
 		if err := gojson.Unmarshal(valueAsBytes.([]byte), &value); err != nil {
 			return nil, err
@@ -54,7 +63,8 @@ func (c *evaluatorCache) Set(key, value interface{}) error {
 	if valueAsBytes, err := gojson.Marshal(value); err != nil {
 		return err
 	} else {
-		return c.store.Set(key, valueAsBytes, nil)
+		c.store.Add(fmt.Sprint(key), valueAsBytes)
+		return nil
 	}
 }
 
@@ -62,6 +72,26 @@ func (c *evaluatorCache) ResolveKeyFor(authJSON string) interface{} {
 	return c.keyTemplate.ResolveFor(authJSON)
 }
 
+func (c *evaluatorCache) Do(key interface{}, fn func() (interface{}, error)) (interface{}, error) {
+	value, err, _ := c.inflight.Do(fmt.Sprint(key), fn)
+	return value, err
+}
+
 func (c *evaluatorCache) Shutdown() error {
-	return c.store.Clear()
+	c.store.Purge()
+	return nil
+}
+
+// callAndCache calls an evaluator on a cache miss and stores the result in the cache.
+// Concurrent calls for the same cache key share the result of a single call to the evaluator.
+func callAndCache(cache EvaluatorCache, cacheKey interface{}, call func() (interface{}, error), logger log.Logger) (interface{}, error) {
+	return cache.Do(cacheKey, func() (interface{}, error) {
+		obj, err := call()
+		if err == nil {
+			if err := cache.Set(cacheKey, obj); err != nil {
+				logger.V(1).Info("unable to store data in the cache", "err", err)
+			}
+		}
+		return obj, err
+	})
 }
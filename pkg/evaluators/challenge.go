@@ -0,0 +1,94 @@
+package evaluators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+)
+
+const (
+	challengeSchemeAPIKey = "ApiKey"
+	challengeSchemeHMAC   = "HMAC"
+	challengeSchemeBearer = "Bearer"
+)
+
+// challengeLocations maps the locations of the credentials to the values of the "in" parameter of the challenges
+var challengeLocations = map[string]string{
+	"custom_header": "header",
+	"cookie":        "cookie",
+	"query":         "query",
+}
+
+// IdentityChallenge customizes the challenge of an identity config, returned in the WWW-Authenticate header when the
+// request cannot be authenticated
+type IdentityChallenge struct {
+	Scheme   string `yaml:"scheme,omitempty"`
+	Realm    string `yaml:"realm,omitempty"`
+	Disabled bool   `yaml:"disabled,omitempty"`
+}
+
+// GetChallenge returns the value of the WWW-Authenticate header that challenges the client to authenticate with the
+// identity config, or an empty string for identity configs that are not authenticated with credentials of the request
+// (e.g. mTLS, anonymous access).
+//
+// The scheme of the challenge defaults to the prefix of the credentials passed in the Authorization header (e.g.
+// "Bearer"), or to "ApiKey" with the location of the credentials otherwise (e.g. `ApiKey realm="x", in="header", name="X-API-Key"`).
+// Bearer challenges tell when the credentials found in the request are invalid (RFC 6750).
+func (config *IdentityConfig) GetChallenge(httpRequest *envoy_auth.AttributeContext_HttpRequest) string {
+	challenge := config.Challenge
+	if challenge == nil {
+		challenge = &IdentityChallenge{}
+	}
+	if challenge.Disabled {
+		return ""
+	}
+
+	var creds auth.AuthCredentials
+	switch config.GetType() {
+	case identityNoop, identityPlain, identityMTLS:
+		if challenge.Scheme == "" {
+			return ""
+		}
+	default:
+		creds = config.GetAuthCredentials()
+	}
+
+	realm := challenge.Realm
+	if realm == "" {
+		realm = config.Name
+	}
+	params := []string{challengeParam("realm", realm)}
+
+	scheme := challenge.Scheme
+	if creds != nil {
+		in, selector := creds.GetCredentialsIn(), creds.GetCredentialsKeySelector()
+		if in == "authorization_header" {
+			if scheme == "" {
+				scheme = selector
+			}
+		} else {
+			if scheme == "" {
+				scheme = challengeSchemeAPIKey
+				if config.GetType() == identityHMAC {
+					scheme = challengeSchemeHMAC
+				}
+			}
+			params = append(params, challengeParam("in", challengeLocations[in]), challengeParam("name", selector))
+		}
+
+		if strings.EqualFold(scheme, challengeSchemeBearer) && httpRequest != nil {
+			if _, err := creds.GetCredentialsFromReq(httpRequest); err == nil {
+				params = append(params, challengeParam("error", "invalid_token"))
+			}
+		}
+	}
+
+	return scheme + " " + strings.Join(params, ", ")
+}
+
+func challengeParam(name, value string) string {
+	return fmt.Sprintf("%s=%q", name, value)
+}
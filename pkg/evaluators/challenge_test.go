@@ -0,0 +1,57 @@
+package evaluators
+
+import (
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators/identity"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"gotest.tools/assert"
+)
+
+func TestGetChallenge(t *testing.T) {
+	withToken := &envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"authorization": "Bearer xyz"}}
+	withoutToken := &envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{}}
+
+	sso := &IdentityConfig{Name: "sso", JWT: &identity.JWT{AuthCredentials: auth.NewAuthCredential("", "")}}
+	assert.Equal(t, sso.GetChallenge(withToken), `Bearer realm="sso", error="invalid_token"`)
+	assert.Equal(t, sso.GetChallenge(withoutToken), `Bearer realm="sso"`)
+
+	apiKey := &IdentityConfig{Name: "api-key-users", APIKey: &identity.APIKey{AuthCredentials: auth.NewAuthCredential("X-API-KEY", "custom_header")}}
+	assert.Equal(t, apiKey.GetChallenge(withToken), `ApiKey realm="api-key-users", in="header", name="X-API-KEY"`)
+
+	apiKeyInQuery := &IdentityConfig{Name: "api-key-users", APIKey: &identity.APIKey{AuthCredentials: auth.NewAuthCredential("api_key", "query")}}
+	assert.Equal(t, apiKeyInQuery.GetChallenge(withToken), `ApiKey realm="api-key-users", in="query", name="api_key"`)
+
+	hmac := &IdentityConfig{Name: "hmac", HMAC: &identity.HMAC{AuthCredentials: auth.NewAuthCredential("X-Signature", "custom_header")}}
+	assert.Equal(t, hmac.GetChallenge(withToken), `HMAC realm="hmac", in="header", name="X-Signature"`)
+
+	basic := &IdentityConfig{Name: "users", APIKey: &identity.APIKey{AuthCredentials: auth.NewAuthCredential("Basic", "authorization_header")}, Challenge: &IdentityChallenge{Realm: "Acme"}}
+	assert.Equal(t, basic.GetChallenge(withToken), `Basic realm="Acme"`)
+
+	disabled := &IdentityConfig{Name: "sso", JWT: &identity.JWT{AuthCredentials: auth.NewAuthCredential("", "")}, Challenge: &IdentityChallenge{Disabled: true}}
+	assert.Equal(t, disabled.GetChallenge(withToken), "")
+
+	anonymous := &IdentityConfig{Name: "anonymous", Noop: &identity.Noop{}}
+	assert.Equal(t, anonymous.GetChallenge(withToken), "")
+
+	mtls := &IdentityConfig{Name: "mtls", MTLS: &identity.MTLS{}, Challenge: &IdentityChallenge{Scheme: "Mutual"}}
+	assert.Equal(t, mtls.GetChallenge(withToken), `Mutual realm="mtls"`)
+}
+
+func TestGetChallengeHeaders(t *testing.T) {
+	config := AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{
+			&IdentityConfig{Name: "sso", JWT: &identity.JWT{AuthCredentials: auth.NewAuthCredential("", "")}},
+			&IdentityConfig{Name: "mtls", MTLS: &identity.MTLS{}},
+			&IdentityConfig{Name: "api-key-users", APIKey: &identity.APIKey{AuthCredentials: auth.NewAuthCredential("APIKEY", "authorization_header")}},
+		},
+	}
+
+	headers := config.GetChallengeHeaders(&envoy_auth.AttributeContext_HttpRequest{})
+	assert.DeepEqual(t, headers, []map[string]string{
+		{"WWW-Authenticate": `Bearer realm="sso"`},
+		{"WWW-Authenticate": `APIKEY realm="api-key-users"`},
+	})
+}
@@ -2,12 +2,12 @@ package evaluators
 
 import (
 	"context"
-	"fmt"
 	"sync"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/json"
 
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	multierror "github.com/hashicorp/go-multierror"
 )
 
@@ -23,15 +23,27 @@ type AuthConfig struct {
 	CallbackConfigs      []auth.AuthConfigEvaluator `yaml:"callbacks,omitempty"`
 
 	DenyWith
+
+	// RequestBody enables parsing the body of the request into the authorization JSON
+	RequestBody *RequestBody `yaml:"requestBody,omitempty"`
+
+	// HeadersToRemove are the names of the HTTP headers to remove from the request sent upstream, when the request is authorized
+	HeadersToRemove []string `yaml:"headersToRemove,omitempty"`
+
+	// DryRun makes the auth pipeline allow all requests, while still logging and reporting the metrics of the decisions
+	DryRun bool `yaml:"dryRun,omitempty"`
 }
 
-func (config *AuthConfig) GetChallengeHeaders() []map[string]string {
+// GetChallengeHeaders returns the WWW-Authenticate headers that challenge the client to authenticate with any of the
+// identity configs
+func (config *AuthConfig) GetChallengeHeaders(httpRequest *envoy_auth.AttributeContext_HttpRequest) []map[string]string {
 	challengeHeaders := make([]map[string]string, 0)
 
 	for _, authConfig := range config.IdentityConfigs {
 		if idConfig, ok := authConfig.(*IdentityConfig); ok {
-			challenge := fmt.Sprintf("%v realm=\"%v\"", idConfig.GetAuthCredentials().GetCredentialsKeySelector(), idConfig.Name)
-			challengeHeaders = append(challengeHeaders, map[string]string{"WWW-Authenticate": challenge})
+			if challenge := idConfig.GetChallenge(httpRequest); challenge != "" {
+				challengeHeaders = append(challengeHeaders, map[string]string{"WWW-Authenticate": challenge})
+			}
 		}
 	}
 
@@ -69,11 +81,19 @@ func (config *AuthConfig) Clean(ctx context.Context) error {
 type DenyWith struct {
 	Unauthenticated *DenyWithValues
 	Unauthorized    *DenyWithValues
+	Unmatched       *DenyWithValues
+}
+
+type RequestBody struct {
+	MaxSize int
 }
 
 type DenyWithValues struct {
-	Code    int32
-	Message *json.JSONValue
-	Headers []json.JSONProperty
-	Body    *json.JSONValue
+	Code         int32
+	Message      *json.JSONValue
+	Headers      []json.JSONProperty
+	Body         *json.JSONValue
+	BodyTemplate *DenyWithTemplate
+	ContentType  string
+	Redirect     *DenyWithRedirect
 }
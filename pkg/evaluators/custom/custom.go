@@ -0,0 +1,134 @@
+// Package custom is the extension point for evaluators compiled into downstream builds of Authorino.
+//
+// A downstream build registers its evaluators, usually from the init function of a package imported by the main
+// package, under a kind and a name:
+//
+//	func init() {
+//		custom.Register(custom.Authorization, "my-policy", func(settings []byte, ctx context.Context) (auth.AuthConfigEvaluator, error) {
+//			...
+//		})
+//	}
+//
+// The evaluators are then referred in the AuthConfigs by name, within the `custom` block of the corresponding phase,
+// along with free-form settings passed as-is (JSON-encoded) to the factory.
+package custom
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/log"
+)
+
+// Kind is the phase of the auth pipeline a custom evaluator is registered for
+type Kind string
+
+const (
+	Identity      Kind = "identity"
+	Metadata      Kind = "metadata"
+	Authorization Kind = "authorization"
+)
+
+// Factory builds a custom evaluator out of the settings (JSON) of an AuthConfig
+type Factory func(settings []byte, ctx context.Context) (auth.AuthConfigEvaluator, error)
+
+var (
+	registry   = make(map[Kind]map[string]Factory)
+	registryMu sync.RWMutex
+)
+
+// Register makes a custom evaluator available under a name, for the given kind.
+// It panics if the factory is nil or if the name is already registered for the kind.
+func Register(kind Kind, name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("custom: nil factory for %s evaluator %s", kind, name))
+	}
+	if _, dup := registry[kind][name]; dup {
+		panic(fmt.Sprintf("custom: %s evaluator %s registered twice", kind, name))
+	}
+	if registry[kind] == nil {
+		registry[kind] = make(map[string]Factory)
+	}
+	registry[kind][name] = factory
+}
+
+// Registered tells whether a custom evaluator is registered under the name, for the given kind
+func Registered(kind Kind, name string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	_, ok := registry[kind][name]
+	return ok
+}
+
+// Names returns the sorted names of the custom evaluators registered for the given kind
+func Names(kind Kind) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry[kind]))
+	for name := range registry[kind] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the custom evaluator registered under the name, for the given kind.
+// The credentials are only relevant for custom identity evaluators that do not read credentials themselves.
+func New(kind Kind, name string, settings []byte, creds auth.AuthCredentials, ctx context.Context) (*Evaluator, error) {
+	registryMu.RLock()
+	factory, ok := registry[kind][name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown custom %s evaluator %s", kind, name)
+	}
+
+	evaluator, err := factory(settings, log.IntoContext(ctx, log.FromContext(ctx).WithName("custom").WithValues("plugin", name)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build custom %s evaluator %s: %w", kind, name, err)
+	}
+	if evaluator == nil {
+		return nil, fmt.Errorf("failed to build custom %s evaluator %s: nil evaluator", kind, name)
+	}
+
+	if c, ok := evaluator.(auth.AuthCredentials); ok {
+		creds = c
+	}
+
+	return &Evaluator{
+		AuthCredentials: creds,
+		Plugin:          name,
+		evaluator:       evaluator,
+	}, nil
+}
+
+// Evaluator wraps a custom evaluator built by a registered factory
+type Evaluator struct {
+	auth.AuthCredentials
+
+	// Plugin is the name under which the custom evaluator is registered
+	Plugin string
+
+	evaluator auth.AuthConfigEvaluator
+}
+
+// impl:AuthConfigEvaluator
+
+func (e *Evaluator) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	return e.evaluator.Call(pipeline, log.IntoContext(ctx, log.FromContext(ctx).WithValues("plugin", e.Plugin)))
+}
+
+// impl:AuthConfigCleaner
+
+func (e *Evaluator) Clean(ctx context.Context) error {
+	if cleaner, ok := e.evaluator.(auth.AuthConfigCleaner); ok {
+		return cleaner.Clean(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package custom
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+
+	"gotest.tools/assert"
+)
+
+type greeter struct {
+	Greeting string `json:"greeting"`
+	cleaned  bool
+}
+
+func (g *greeter) Call(_ auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	return g.Greeting, nil
+}
+
+func (g *greeter) Clean(_ context.Context) error {
+	g.cleaned = true
+	return nil
+}
+
+func newGreeter(settings []byte, _ context.Context) (auth.AuthConfigEvaluator, error) {
+	g := &greeter{}
+	if err := gojson.Unmarshal(settings, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register(Metadata, "test-register-b", newGreeter)
+	Register(Metadata, "test-register-a", newGreeter)
+
+	assert.Check(t, Registered(Metadata, "test-register-a"))
+	assert.Check(t, !Registered(Identity, "test-register-a"))
+	assert.Check(t, !Registered(Metadata, "test-register-c"))
+	assert.DeepEqual(t, Names(Metadata), []string{"test-register-a", "test-register-b"})
+}
+
+func TestRegisterTwice(t *testing.T) {
+	Register(Authorization, "test-register-twice", newGreeter)
+
+	defer func() {
+		assert.Equal(t, recover(), "custom: authorization evaluator test-register-twice registered twice")
+	}()
+	Register(Authorization, "test-register-twice", newGreeter)
+}
+
+func TestNew(t *testing.T) {
+	Register(Metadata, "test-new", newGreeter)
+
+	evaluator, err := New(Metadata, "test-new", []byte(`{"greeting":"hello"}`), nil, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, evaluator.Plugin, "test-new")
+
+	obj, err := evaluator.Call(nil, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj, "hello")
+
+	assert.NilError(t, evaluator.Clean(context.TODO()))
+	assert.Check(t, evaluator.evaluator.(*greeter).cleaned)
+}
+
+func TestNewUnknown(t *testing.T) {
+	_, err := New(Identity, "test-new-unknown", nil, nil, context.TODO())
+	assert.Error(t, err, "unknown custom identity evaluator test-new-unknown")
+}
+
+func TestNewWithInvalidSettings(t *testing.T) {
+	Register(Metadata, "test-new-invalid", func(_ []byte, _ context.Context) (auth.AuthConfigEvaluator, error) {
+		return nil, fmt.Errorf("missing greeting")
+	})
+
+	_, err := New(Metadata, "test-new-invalid", []byte(`{}`), nil, context.TODO())
+	assert.Error(t, err, "failed to build custom metadata evaluator test-new-invalid: missing greeting")
+}
+
+func TestNewWithCredentials(t *testing.T) {
+	Register(Identity, "test-new-creds", newGreeter)
+
+	creds := auth.NewAuthCredential("X-API-KEY", "custom_header")
+	evaluator, err := New(Identity, "test-new-creds", []byte(`{}`), creds, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, evaluator.GetCredentialsKeySelector(), "X-API-KEY")
+}
@@ -0,0 +1,98 @@
+package evaluators
+
+import (
+	"bytes"
+	gojson "encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DenyWithTemplate is a Go template of the body of a denial, rendered with the Authorization JSON as data
+// (e.g. "{{.auth.identity.username}}", "{{index .context.request.http.headers \"x-request-id\"}}", "{{requestURL . | urlquery}}").
+// Templates of HTML bodies escape the values rendered into the body.
+type DenyWithTemplate struct {
+	tmpl interface {
+		Execute(wr io.Writer, data interface{}) error
+	}
+}
+
+var denyWithTemplateFuncs = map[string]interface{}{
+	"json": func(v interface{}) (string, error) {
+		var b strings.Builder
+		encoder := gojson.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(b.String(), "\n"), nil
+	},
+	"requestURL": requestURL,
+}
+
+// requestURL returns the URL of the original request, out of the Authorization JSON (e.g. "{{requestURL .}}")
+func requestURL(data map[string]interface{}) string {
+	httpRequest := lookup(data, "context", "request", "http")
+	scheme, _ := httpRequest["scheme"].(string)
+	if scheme == "" {
+		scheme, _ = lookup(httpRequest, "headers")["x-forwarded-proto"].(string)
+	}
+	if scheme == "" {
+		scheme = "https"
+	}
+	host, _ := httpRequest["host"].(string)
+	path, _ := httpRequest["path"].(string)
+	return scheme + "://" + host + path
+}
+
+func lookup(data map[string]interface{}, keys ...string) map[string]interface{} {
+	for _, key := range keys {
+		data, _ = data[key].(map[string]interface{})
+	}
+	return data
+}
+
+// NewDenyWithTemplate parses the template of the body of a denial.
+// The body is rendered as HTML when the content type is "text/html".
+func NewDenyWithTemplate(text, contentType string) (*DenyWithTemplate, error) {
+	if IsHTMLContentType(contentType) {
+		tmpl, err := htmltemplate.New("body").Funcs(denyWithTemplateFuncs).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		return &DenyWithTemplate{tmpl: tmpl}, nil
+	}
+
+	tmpl, err := texttemplate.New("body").Funcs(denyWithTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &DenyWithTemplate{tmpl: tmpl}, nil
+}
+
+// Render renders the template with the Authorization JSON
+func (t *DenyWithTemplate) Render(authJSON string) (string, error) {
+	var data map[string]interface{}
+	if err := gojson.Unmarshal([]byte(authJSON), &data); err != nil {
+		return "", err
+	}
+	var body bytes.Buffer
+	if err := t.tmpl.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("failed to render the template of the denial: %w", err)
+	}
+	return body.String(), nil
+}
+
+// DenyWithRedirect redirects the client of a denied request to another location (e.g. the login page of an identity provider)
+type DenyWithRedirect struct {
+	Code     int32
+	Location *DenyWithTemplate
+}
+
+// IsHTMLContentType tells whether a value of the Content-Type header is of an HTML document
+func IsHTMLContentType(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	return strings.EqualFold(mediaType, "text/html")
+}
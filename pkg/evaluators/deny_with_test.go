@@ -0,0 +1,52 @@
+package evaluators
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+const denyWithTestAuthJSON = `{"context":{"request":{"http":{"host":"my-api","headers":{"x-request-id":"1234"}}}},"auth":{"identity":{"username":"<john>","roles":["reader"]}}}`
+
+func TestDenyWithTemplate(t *testing.T) {
+	tmpl, err := NewDenyWithTemplate(`{"user":{{json .auth.identity.username}},"roles":{{json .auth.identity.roles}},"request":"{{index .context.request.http.headers "x-request-id"}}"}`, "application/json")
+	assert.NilError(t, err)
+
+	body, err := tmpl.Render(denyWithTestAuthJSON)
+	assert.NilError(t, err)
+	assert.Equal(t, body, `{"user":"<john>","roles":["reader"],"request":"1234"}`)
+}
+
+func TestDenyWithHTMLTemplate(t *testing.T) {
+	tmpl, err := NewDenyWithTemplate(`<p>Sorry, {{.auth.identity.username}}. Contact support@{{.context.request.http.host}}.</p>`, "text/html; charset=utf-8")
+	assert.NilError(t, err)
+
+	body, err := tmpl.Render(denyWithTestAuthJSON)
+	assert.NilError(t, err)
+	assert.Equal(t, body, `<p>Sorry, &lt;john&gt;. Contact support@my-api.</p>`)
+}
+
+func TestDenyWithTemplateRequestURL(t *testing.T) {
+	tmpl, err := NewDenyWithTemplate(`https://sso.acme.com/login?redirect_uri={{requestURL . | urlquery}}`, "")
+	assert.NilError(t, err)
+
+	location, err := tmpl.Render(`{"context":{"request":{"http":{"host":"my-api","path":"/pets?kind=dog"}}}}`)
+	assert.NilError(t, err)
+	assert.Equal(t, location, "https://sso.acme.com/login?redirect_uri=https%3A%2F%2Fmy-api%2Fpets%3Fkind%3Ddog")
+
+	location, err = tmpl.Render(`{"context":{"request":{"http":{"host":"my-api","path":"/","headers":{"x-forwarded-proto":"http"}}}}}`)
+	assert.NilError(t, err)
+	assert.Equal(t, location, "https://sso.acme.com/login?redirect_uri=http%3A%2F%2Fmy-api%2F")
+}
+
+func TestDenyWithTemplateInvalid(t *testing.T) {
+	_, err := NewDenyWithTemplate(`{{.auth.identity.username`, "")
+	assert.ErrorContains(t, err, "unclosed action")
+}
+
+func TestIsHTMLContentType(t *testing.T) {
+	assert.Check(t, IsHTMLContentType("text/html"))
+	assert.Check(t, IsHTMLContentType("Text/HTML; charset=utf-8"))
+	assert.Check(t, !IsHTMLContentType("application/json"))
+	assert.Check(t, !IsHTMLContentType(""))
+}
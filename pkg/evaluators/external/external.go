@@ -0,0 +1,132 @@
+package external
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"github.com/authzed/grpcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	insecuregrpc "google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GRPC is an evaluator that delegates to an external service (plugin) implementing the authorino.plugin.v1.Evaluator
+// gRPC service. It can be used in the identity, metadata and authorization phases.
+type GRPC struct {
+	// AuthCredentials are only relevant for the identity phase
+	auth.AuthCredentials
+
+	Endpoint     string
+	Insecure     bool
+	SharedSecret string
+	Timeout      time.Duration
+
+	// Name of the evaluator config and namespace/name of the AuthConfig, sent to the plugin in the gRPC metadata
+	Name       string
+	AuthConfig string
+
+	// Settings are the free-form settings of the evaluator config, JSON-encoded
+	Settings []byte
+
+	// the grpc connection is shared by all requests, established on the first one
+	conn   *grpc.ClientConn
+	client EvaluatorClient
+	mu     sync.Mutex
+}
+
+func (g *GRPC) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	client, err := g.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var authJSON map[string]interface{}
+	if err := gojson.Unmarshal([]byte(pipeline.GetAuthorizationJSON()), &authJSON); err != nil {
+		return nil, err
+	}
+	in, err := structpb.NewStruct(authJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	md := metadata.Pairs(EvaluatorMetadataKey, g.Name, AuthConfigMetadataKey, g.AuthConfig)
+	if len(g.Settings) > 0 {
+		md.Set(SettingsMetadataKey, string(g.Settings))
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	out, err := client.Evaluate(ctx, in)
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			switch s.Code() {
+			case codes.PermissionDenied, codes.Unauthenticated:
+				log.FromContext(ctx).V(1).Info("denied by the plugin", "endpoint", g.Endpoint, "code", s.Code())
+				return nil, fmt.Errorf("%s", s.Message())
+			}
+		}
+		return nil, err
+	}
+
+	return out.AsInterface(), nil
+}
+
+// Clean closes the grpc connection to the plugin
+func (g *GRPC) Clean(_ context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn, g.client = nil, nil
+	return err
+}
+
+func (g *GRPC) getClient() (EvaluatorClient, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.client != nil {
+		return g.client, nil
+	}
+
+	var dialOpts []grpc.DialOption
+
+	if g.Insecure {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecuregrpc.NewCredentials()))
+		if g.SharedSecret != "" {
+			dialOpts = append(dialOpts, grpcutil.WithInsecureBearerToken(g.SharedSecret))
+		}
+	} else {
+		systemCertsOption, _ := grpcutil.WithSystemCerts(grpcutil.VerifyCA)
+		dialOpts = append(dialOpts, systemCertsOption)
+		if g.SharedSecret != "" {
+			dialOpts = append(dialOpts, grpcutil.WithBearerToken(g.SharedSecret))
+		}
+	}
+
+	conn, err := grpc.Dial(g.Endpoint, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	g.conn = conn
+	g.client = NewEvaluatorClient(conn)
+	return g.client, nil
+}
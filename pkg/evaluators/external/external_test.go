@@ -0,0 +1,119 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
+	"github.com/kuadrant/authorino/pkg/httptest"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gotest.tools/assert"
+)
+
+const testPluginServerEndpoint string = "127.0.0.1:9015"
+
+type testPlugin struct {
+	evaluate func(context.Context, *structpb.Struct) (*structpb.Value, error)
+}
+
+func (p *testPlugin) Evaluate(ctx context.Context, in *structpb.Struct) (*structpb.Value, error) {
+	return p.evaluate(ctx, in)
+}
+
+func newTestPluginServer(evaluate func(context.Context, *structpb.Struct) (*structpb.Value, error)) interface{ Close() } {
+	return httptest.NewGrpcServerMock(testPluginServerEndpoint, func(server *grpc.Server) {
+		RegisterEvaluatorServer(server, &testPlugin{evaluate: evaluate})
+	})
+}
+
+func TestGRPCCall(t *testing.T) {
+	var md metadata.MD
+	server := newTestPluginServer(func(ctx context.Context, in *structpb.Struct) (*structpb.Value, error) {
+		md, _ = metadata.FromIncomingContext(ctx)
+		path := in.Fields["context"].GetStructValue().Fields["request"].GetStructValue().Fields["http"].GetStructValue().Fields["path"].GetStringValue()
+		return structpb.NewValue(map[string]interface{}{"path": path, "allowed": true})
+	})
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"path":"/pets"}}},"auth":{}}`)
+
+	evaluator := &GRPC{
+		Endpoint:     testPluginServerEndpoint,
+		Insecure:     true,
+		SharedSecret: "secret",
+		Name:         "my-plugin",
+		AuthConfig:   "authorino/my-api",
+		Settings:     []byte(`{"region":"eu"}`),
+	}
+	defer evaluator.Clean(context.TODO())
+
+	obj, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, obj, map[string]interface{}{"path": "/pets", "allowed": true})
+	assert.DeepEqual(t, md.Get(EvaluatorMetadataKey), []string{"my-plugin"})
+	assert.DeepEqual(t, md.Get(AuthConfigMetadataKey), []string{"authorino/my-api"})
+	assert.DeepEqual(t, md.Get(SettingsMetadataKey), []string{`{"region":"eu"}`})
+	assert.DeepEqual(t, md.Get("authorization"), []string{"Bearer secret"})
+}
+
+func TestGRPCCallDenied(t *testing.T) {
+	server := newTestPluginServer(func(_ context.Context, _ *structpb.Struct) (*structpb.Value, error) {
+		return nil, status.Error(codes.PermissionDenied, "outside business hours")
+	})
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{}}`)
+
+	evaluator := &GRPC{Endpoint: testPluginServerEndpoint, Insecure: true}
+	defer evaluator.Clean(context.TODO())
+
+	obj, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.Check(t, obj == nil)
+	assert.Error(t, err, "outside business hours")
+	assert.Check(t, !authorization.IsDependencyFailure(err))
+}
+
+func TestGRPCCallFailure(t *testing.T) {
+	server := newTestPluginServer(func(_ context.Context, _ *structpb.Struct) (*structpb.Value, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{}}`)
+
+	evaluator := &GRPC{Endpoint: testPluginServerEndpoint, Insecure: true}
+	defer evaluator.Clean(context.TODO())
+
+	_, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.Equal(t, status.Code(err), codes.Unknown)
+}
+
+func TestGRPCCallUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{}}`)
+
+	evaluator := &GRPC{Endpoint: "127.0.0.1:9999", Insecure: true}
+	defer evaluator.Clean(context.TODO())
+
+	_, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.Check(t, authorization.IsDependencyFailure(err))
+}
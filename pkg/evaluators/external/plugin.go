@@ -0,0 +1,85 @@
+package external
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Client and server of the authorino.plugin.v1.Evaluator gRPC service (see plugin.proto).
+// The service only exchanges well-known protobuf types, thus the stubs are written by hand, in the shape of the ones
+// generated by protoc-gen-go-grpc, so plugins written in Go do not need to generate code.
+
+const (
+	ServiceName    = "authorino.plugin.v1.Evaluator"
+	EvaluateMethod = "/" + ServiceName + "/Evaluate"
+
+	// gRPC metadata sent along with every request to the plugins
+	EvaluatorMetadataKey  = "x-authorino-evaluator"
+	AuthConfigMetadataKey = "x-authorino-authconfig"
+	SettingsMetadataKey   = "x-authorino-settings"
+)
+
+// EvaluatorClient is the client API for the authorino.plugin.v1.Evaluator service
+type EvaluatorClient interface {
+	Evaluate(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Value, error)
+}
+
+type evaluatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEvaluatorClient(cc grpc.ClientConnInterface) EvaluatorClient {
+	return &evaluatorClient{cc}
+}
+
+func (c *evaluatorClient) Evaluate(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Value, error) {
+	out := new(structpb.Value)
+	if err := c.cc.Invoke(ctx, EvaluateMethod, in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EvaluatorServer is the server API for the authorino.plugin.v1.Evaluator service, to be implemented by the plugins
+type EvaluatorServer interface {
+	Evaluate(context.Context, *structpb.Struct) (*structpb.Value, error)
+}
+
+// RegisterEvaluatorServer registers the implementation of a plugin in a gRPC server
+func RegisterEvaluatorServer(s grpc.ServiceRegistrar, srv EvaluatorServer) {
+	s.RegisterService(&Evaluator_ServiceDesc, srv)
+}
+
+func evaluateHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvaluatorServer).Evaluate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EvaluateMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvaluatorServer).Evaluate(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Evaluator_ServiceDesc is the grpc.ServiceDesc of the authorino.plugin.v1.Evaluator service
+var Evaluator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*EvaluatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Evaluate",
+			Handler:    evaluateHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin.proto",
+}
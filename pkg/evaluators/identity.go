@@ -6,10 +6,15 @@ import (
 	"fmt"
 
 	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators/custom"
+	"github.com/kuadrant/authorino/pkg/evaluators/external"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/replay"
+	"github.com/kuadrant/authorino/pkg/revocation"
 
+	"github.com/tidwall/gjson"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
@@ -20,12 +25,32 @@ const (
 	identityOIDC       = "IDENTITY_OIDC"
 	identityMTLS       = "IDENTITY_MTLS"
 	identityHMAC       = "IDENTITY_HMAC"
+	identityAWSSigV4   = "IDENTITY_AWS_SIGV4"
+	identityJWT        = "IDENTITY_JWT"
+	identitySPIFFE     = "IDENTITY_SPIFFE"
+	identitySAML       = "IDENTITY_SAML"
 	identityAPIKey     = "IDENTITY_APIKEY"
 	identityKubernetes = "IDENTITY_KUBERNETES"
 	identityPlain      = "IDENTITY_PLAIN"
 	identityNoop       = "IDENTITY_NOOP"
+	identityCustom     = "IDENTITY_CUSTOM"
+	identityExternal   = "IDENTITY_EXTERNAL"
+
+	// costs of the identity configs
+	IdentityCostLocal  = 0 // verified locally, out of cached data
+	IdentityCostRemote = 1 // verified by an external service (e.g. token introspection, Kubernetes TokenReview)
 )
 
+// FindIdentityConfigByName returns the identity config with the given name, of any type
+func FindIdentityConfigByName(identityConfigs []IdentityConfig, name string) (*IdentityConfig, error) {
+	for i := range identityConfigs {
+		if identityConfigs[i].Name == name {
+			return &identityConfigs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("missing identity config %v", name)
+}
+
 type IdentityConfig struct {
 	Name       string                         `yaml:"name"`
 	Priority   int                            `yaml:"priority"`
@@ -37,12 +62,52 @@ type IdentityConfig struct {
 	OIDC           *identity.OIDC           `yaml:"oidc,omitempty"`
 	MTLS           *identity.MTLS           `yaml:"mtls,omitempty"`
 	HMAC           *identity.HMAC           `yaml:"hmac,omitempty"`
+	AWSSigV4       *identity.AWSSigV4       `yaml:"awsSigV4,omitempty"`
+	JWT            *identity.JWT            `yaml:"jwt,omitempty"`
+	SPIFFE         *identity.SPIFFE         `yaml:"spiffe,omitempty"`
+	SAML           *identity.SAML           `yaml:"saml,omitempty"`
 	APIKey         *identity.APIKey         `yaml:"apiKey,omitempty"`
 	KubernetesAuth *identity.KubernetesAuth `yaml:"kubernetes,omitempty"`
 	Plain          *identity.Plain          `yaml:"plain,omitempty"`
 	Noop           *identity.Noop           `yaml:"noop,omitempty"`
+	Custom         *custom.Evaluator        `yaml:"custom,omitempty"`
+	External       *external.GRPC           `yaml:"external,omitempty"`
+
+	ExtendedProperties []json.JSONProperty    `yaml:"extendedProperties"`
+	ClaimMappings      *IdentityClaimMappings `yaml:"claimMappings,omitempty"`
+	Revocation         *revocation.List       `yaml:"revocation,omitempty"`
+	ReplayProtection   *replay.Protection     `yaml:"replayProtection,omitempty"`
+	Challenge          *IdentityChallenge     `yaml:"challenge,omitempty"`
+}
+
+// IdentityClaimMappings selects claims of the resolved identity object, by JSON paths relative to the identity object,
+// to be projected into the normalized "username", "groups", "email" and "tenant" properties
+type IdentityClaimMappings struct {
+	Username string `yaml:"username,omitempty"`
+	Groups   string `yaml:"groups,omitempty"`
+	Email    string `yaml:"email,omitempty"`
+	Tenant   string `yaml:"tenant,omitempty"`
+}
 
-	ExtendedProperties []json.JSONProperty `yaml:"extendedProperties"`
+// apply sets the mapped claims found in the identity object (as JSON) to the extended identity object
+func (m *IdentityClaimMappings) apply(identityObjAsJSON string, extendedIdentityObject map[string]interface{}) {
+	for name, path := range map[string]string{"username": m.Username, "email": m.Email, "tenant": m.Tenant} {
+		if path == "" {
+			continue
+		}
+		if claim := gjson.Get(identityObjAsJSON, path); claim.Exists() {
+			extendedIdentityObject[name] = claim.Value()
+		}
+	}
+
+	if m.Groups == "" {
+		return
+	}
+	if claim := gjson.Get(identityObjAsJSON, m.Groups); claim.IsArray() {
+		extendedIdentityObject["groups"] = claim.Value()
+	} else if claim.Exists() {
+		extendedIdentityObject["groups"] = []interface{}{claim.Value()}
+	}
 }
 
 func (config *IdentityConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator {
@@ -55,6 +120,14 @@ func (config *IdentityConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator
 		return config.MTLS
 	case identityHMAC:
 		return config.HMAC
+	case identityAWSSigV4:
+		return config.AWSSigV4
+	case identityJWT:
+		return config.JWT
+	case identitySPIFFE:
+		return config.SPIFFE
+	case identitySAML:
+		return config.SAML
 	case identityAPIKey:
 		return config.APIKey
 	case identityKubernetes:
@@ -63,6 +136,10 @@ func (config *IdentityConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator
 		return config.Plain
 	case identityNoop:
 		return config.Noop
+	case identityCustom:
+		return config.Custom
+	case identityExternal:
+		return config.External
 	default:
 		return nil
 	}
@@ -88,20 +165,41 @@ func (config *IdentityConfig) Call(pipeline auth.AuthPipeline, ctx context.Conte
 			if cachedObj, err := cache.Get(cacheKey); err != nil {
 				logger.V(1).Error(err, "failed to retrieve data from the cache")
 			} else if cachedObj != nil {
-				return cachedObj, nil
+				return config.checkIdentityObject(cachedObj, log.IntoContext(ctx, logger))
 			}
 		}
 
-		obj, err := evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+		var obj interface{}
+		var err error
+		if cacheKey != nil {
+			obj, err = callAndCache(cache, cacheKey, func() (interface{}, error) {
+				return evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+			}, logger)
+		} else {
+			obj, err = evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+		}
 
-		if err == nil && cacheKey != nil {
-			if err := cache.Set(cacheKey, obj); err != nil {
-				logger.V(1).Info("unable to store data in the cache", "err", err)
-			}
+		if err != nil {
+			return obj, err
 		}
 
-		return obj, err
+		return config.checkIdentityObject(obj, log.IntoContext(ctx, logger))
+	}
+}
+
+// checkIdentityObject returns the identity object unless it has been revoked or its one-time value has been used before
+func (config *IdentityConfig) checkIdentityObject(obj interface{}, ctx context.Context) (interface{}, error) {
+	if config.Revocation != nil {
+		if err := config.Revocation.Check(ctx, obj); err != nil {
+			return nil, err
+		}
+	}
+	if config.ReplayProtection != nil {
+		if err := config.ReplayProtection.Check(ctx, obj); err != nil {
+			return nil, err
+		}
 	}
+	return obj, nil
 }
 
 // impl:NamedEvaluator
@@ -122,6 +220,14 @@ func (config *IdentityConfig) GetType() string {
 		return identityMTLS
 	case config.HMAC != nil:
 		return identityHMAC
+	case config.AWSSigV4 != nil:
+		return identityAWSSigV4
+	case config.JWT != nil:
+		return identityJWT
+	case config.SPIFFE != nil:
+		return identitySPIFFE
+	case config.SAML != nil:
+		return identitySAML
 	case config.APIKey != nil:
 		return identityAPIKey
 	case config.KubernetesAuth != nil:
@@ -130,6 +236,10 @@ func (config *IdentityConfig) GetType() string {
 		return identityPlain
 	case config.Noop != nil:
 		return identityNoop
+	case config.Custom != nil:
+		return identityCustom
+	case config.External != nil:
+		return identityExternal
 	default:
 		return ""
 	}
@@ -141,6 +251,17 @@ func (config *IdentityConfig) GetPriority() int {
 	return config.Priority
 }
 
+// impl:CostlyEvaluator
+
+func (config *IdentityConfig) GetCost() int {
+	switch config.GetType() {
+	case identityOAuth2, identityKubernetes, identityExternal, identityCustom:
+		return IdentityCostRemote
+	default:
+		return IdentityCostLocal
+	}
+}
+
 // impl:ConditionalEvaluator
 
 func (config *IdentityConfig) GetConditions() []json.JSONPatternMatchingRule {
@@ -160,8 +281,18 @@ func (config *IdentityConfig) Clean(ctx context.Context) error {
 
 func (config *IdentityConfig) getCleaner() auth.AuthConfigCleaner {
 	switch {
+	case config.APIKey != nil:
+		return config.APIKey
 	case config.OIDC != nil:
 		return config.OIDC
+	case config.SPIFFE != nil:
+		return config.SPIFFE
+	case config.SAML != nil:
+		return config.SAML
+	case config.Custom != nil:
+		return config.Custom
+	case config.External != nil:
+		return config.External
 	default:
 		return nil
 	}
@@ -175,15 +306,15 @@ func (config *IdentityConfig) GetOIDC() interface{} {
 
 func (config *IdentityConfig) GetAuthCredentials() auth.AuthCredentials {
 	evaluator := config.GetAuthConfigEvaluator()
-	creds := evaluator.(auth.AuthCredentials)
+	creds, _ := evaluator.(auth.AuthCredentials)
 	return creds
 }
 
 func (config *IdentityConfig) ResolveExtendedProperties(pipeline auth.AuthPipeline) (interface{}, error) {
-	_, resolvedIdentityObj := pipeline.GetResolvedIdentity()
+	resolvedIdentityObj := pipeline.GetIdentity().Object
 
 	// return the original object if there is no extension property to resolve (to save the unnecessary json marshaling/unmarshaling overhead)
-	if len(config.ExtendedProperties) == 0 {
+	if len(config.ExtendedProperties) == 0 && config.ClaimMappings == nil {
 		return resolvedIdentityObj, nil
 	}
 
@@ -193,6 +324,13 @@ func (config *IdentityConfig) ResolveExtendedProperties(pipeline auth.AuthPipeli
 	if err != nil {
 		return nil, err
 	}
+	if extendedIdentityObject == nil { // e.g. identity sources that resolve to null
+		extendedIdentityObject = make(map[string]interface{})
+	}
+
+	if config.ClaimMappings != nil {
+		config.ClaimMappings.apply(string(identityObjAsJSON), extendedIdentityObject)
+	}
 
 	authJSON := pipeline.GetAuthorizationJSON()
 
@@ -206,48 +344,62 @@ func (config *IdentityConfig) ResolveExtendedProperties(pipeline auth.AuthPipeli
 // impl:K8sSecretBasedIdentityConfigEvaluator
 
 func (config *IdentityConfig) AddK8sSecretBasedIdentity(ctx context.Context, new v1.Secret) {
-	var ev auth.K8sSecretBasedIdentityConfigEvaluator
-
-	switch config.GetType() {
-	case identityMTLS:
-		ev = config.MTLS
-	case identityAPIKey:
-		ev = config.APIKey
-	default:
-		return
+	if ev := config.getK8sSecretBasedEvaluator(); ev != nil {
+		// with a revocation list, the secret may not match the label selector of the identity evaluator
+		if selector := ev.GetK8sSecretLabelSelectors(); config.Revocation == nil || selector == nil || selector.Matches(labels.Set(new.Labels)) {
+			ev.AddK8sSecretBasedIdentity(ctx, new)
+		} else {
+			ev.RevokeK8sSecretBasedIdentity(ctx, types.NamespacedName{Namespace: new.Namespace, Name: new.Name})
+		}
 	}
 
-	ev.AddK8sSecretBasedIdentity(ctx, new)
+	if config.Revocation != nil {
+		if selector := config.Revocation.GetK8sSecretLabelSelectors(); selector != nil && selector.Matches(labels.Set(new.Labels)) {
+			config.Revocation.AddK8sSecretBasedIdentity(ctx, new)
+		} else {
+			config.Revocation.RevokeK8sSecretBasedIdentity(ctx, types.NamespacedName{Namespace: new.Namespace, Name: new.Name})
+		}
+	}
 }
 
 func (config *IdentityConfig) RevokeK8sSecretBasedIdentity(ctx context.Context, deleted types.NamespacedName) {
-	var ev auth.K8sSecretBasedIdentityConfigEvaluator
-
-	switch config.GetType() {
-	case identityMTLS:
-		ev = config.MTLS
-	case identityAPIKey:
-		ev = config.APIKey
-	default:
-		return
+	if ev := config.getK8sSecretBasedEvaluator(); ev != nil {
+		ev.RevokeK8sSecretBasedIdentity(ctx, deleted)
 	}
 
-	ev.RevokeK8sSecretBasedIdentity(ctx, deleted)
+	if config.Revocation != nil {
+		config.Revocation.RevokeK8sSecretBasedIdentity(ctx, deleted)
+	}
 }
 
+// GetK8sSecretLabelSelectors returns the label selector of the Secrets of the identity evaluator.
+// Identity configs with a revocation list return nil, as each Secret is matched against the selectors of the identity
+// evaluator and of the revocation list separately.
 func (config *IdentityConfig) GetK8sSecretLabelSelectors() labels.Selector {
-	var ev auth.K8sSecretBasedIdentityConfigEvaluator
+	if config.Revocation != nil {
+		return nil
+	}
+
+	if ev := config.getK8sSecretBasedEvaluator(); ev != nil {
+		return ev.GetK8sSecretLabelSelectors()
+	}
 
+	return nil
+}
+
+func (config *IdentityConfig) getK8sSecretBasedEvaluator() auth.K8sSecretBasedIdentityConfigEvaluator {
 	switch config.GetType() {
 	case identityMTLS:
-		ev = config.MTLS
+		return config.MTLS
 	case identityAPIKey:
-		ev = config.APIKey
+		return config.APIKey
+	case identityHMAC:
+		return config.HMAC
+	case identityAWSSigV4:
+		return config.AWSSigV4
 	default:
 		return nil
 	}
-
-	return ev.GetK8sSecretLabelSelectors()
 }
 
 // impl:metrics.Object
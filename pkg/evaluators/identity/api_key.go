@@ -2,12 +2,18 @@ package identity
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/metrics"
 
+	"golang.org/x/crypto/bcrypt"
 	k8s "k8s.io/api/core/v1"
 	k8s_labels "k8s.io/apimachinery/pkg/labels"
 	k8s_types "k8s.io/apimachinery/pkg/types"
@@ -15,30 +21,73 @@ import (
 )
 
 const (
+	APIKeyHashingSHA256 = "sha256"
+	APIKeyHashingBcrypt = "bcrypt"
+
 	apiKeySelector              = "api_key"
+	apiKeyAnnotationPrefix      = "authorino.kuadrant.io/"
+	apiKeyExpiresAtAnnotation   = apiKeyAnnotationPrefix + "expires-at"
+	apiKeyIdSeparator           = "."
+	apiKeyIdHashSeparator       = ":"
 	invalidApiKeyMsg            = "the API Key provided is invalid"
+	expiredApiKeyMsg            = "the API Key provided has expired"
 	credentialsFetchingErrorMsg = "Something went wrong fetching the authorized credentials"
 )
 
+var (
+	apiKeyIndexEntriesMetric    = metrics.NewGaugeMetric("api_key_index_entries", "Number of API keys indexed in memory from the Kubernetes Secrets, across all API key identity configs.")
+	apiKeyIndexUpdatesMetric    = metrics.NewCounterMetric("api_key_index_updates_total", "Total number of updates of the index of API keys triggered by changes to the Kubernetes Secrets, partitioned by operation.", "operation")
+	apiKeyIndexLastUpdateMetric = metrics.NewGaugeMetric("api_key_index_last_update_timestamp_seconds", "Time of the last update of the index of API keys (Unix time, in seconds).")
+)
+
+func init() {
+	metrics.Register(
+		apiKeyIndexEntriesMetric,
+		apiKeyIndexUpdatesMetric,
+		apiKeyIndexLastUpdateMetric,
+	)
+}
+
 type APIKey struct {
 	auth.AuthCredentials
 
 	Name           string              `yaml:"name"`
 	LabelSelectors k8s_labels.Selector `yaml:"labelSelectors"`
 	Namespace      string              `yaml:"namespace"`
+	// KeyHashing is the hashing algorithm of the API keys stored in the secrets (sha256 or bcrypt). Empty for plain text API keys.
+	KeyHashing string `yaml:"keyHashing,omitempty"`
 
-	secrets   map[string]k8s.Secret
-	mutex     sync.RWMutex
-	k8sClient k8s_client.Reader
+	// secrets indexes the API keys (or their hashes), so verifying the key of a request is a map read.
+	// bcrypt hashes are salted, so they are indexed by the id of the key instead, so verifying the key of a request takes at most one bcrypt comparison.
+	// keysBySecret indexes the same keys by Secret (namespace/name), so changes to a Secret do not scan all the keys.
+	secrets      map[string]apiKeyEntry
+	keysBySecret map[string][]string
+	mutex        sync.RWMutex
+	k8sClient    k8s_client.Reader
 }
 
-func NewApiKeyIdentity(name string, labelSelectors k8s_labels.Selector, namespace string, authCred auth.AuthCredentials, k8sClient k8s_client.Reader, ctx context.Context) *APIKey {
+// apiKeyEntry is an API key read from an entry of a Secret.
+// A Secret can hold multiple API keys (e.g. current and previous ones, during a rotation), in entries `api_key` and `api_key_*`.
+type apiKeyEntry struct {
+	secret    k8s.Secret
+	expiresAt time.Time
+	// hash is the bcrypt hash of the API key, for keys indexed by id
+	hash []byte
+}
+
+func (e apiKeyEntry) expired() bool {
+	return !e.expiresAt.IsZero() && time.Now().After(e.expiresAt)
+}
+
+func NewApiKeyIdentity(name string, labelSelectors k8s_labels.Selector, namespace string, keyHashing string, authCred auth.AuthCredentials, k8sClient k8s_client.Reader, ctx context.Context) *APIKey {
 	apiKey := &APIKey{
 		AuthCredentials: authCred,
 		Name:            name,
 		LabelSelectors:  labelSelectors,
 		Namespace:       namespace,
-		secrets:         make(map[string]k8s.Secret),
+		KeyHashing:      keyHashing,
+		secrets:         make(map[string]apiKeyEntry),
+		keysBySecret:    make(map[string][]string),
 		k8sClient:       k8sClient,
 	}
 	if err := apiKey.loadSecrets(context.TODO()); err != nil {
@@ -64,6 +113,7 @@ func (a *APIKey) loadSecrets(ctx context.Context) error {
 	for _, secret := range secretList.Items {
 		a.appendK8sSecretBasedIdentity(secret)
 	}
+	apiKeyIndexLastUpdateMetric.WithLabelValues().SetToCurrentTime()
 
 	return nil
 }
@@ -76,16 +126,41 @@ func (a *APIKey) Call(pipeline auth.AuthPipeline, _ context.Context) (interface{
 		a.mutex.RLock()
 		defer a.mutex.RUnlock()
 
-		for key, secret := range a.secrets {
-			if key == reqKey {
-				return secret, nil
+		if entry, found := a.findAPIKey(reqKey); found {
+			if entry.expired() {
+				return nil, fmt.Errorf(expiredApiKeyMsg)
 			}
+			return entry.secret, nil
 		}
 	}
 	err := fmt.Errorf(invalidApiKeyMsg)
 	return nil, err
 }
 
+// findAPIKey looks up the API key supplied in the request among the cached ones, hashing it first if the keys are stored hashed
+// Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
+func (a *APIKey) findAPIKey(reqKey string) (apiKeyEntry, bool) {
+	switch a.KeyHashing {
+	case APIKeyHashingSHA256:
+		hash := sha256.Sum256([]byte(reqKey))
+		entry, found := a.secrets[hex.EncodeToString(hash[:])]
+		return entry, found
+	case APIKeyHashingBcrypt:
+		// bcrypt hashes are salted, so the key is looked up by its id and compared only against the hash stored for that id
+		keyId, _, found := strings.Cut(reqKey, apiKeyIdSeparator)
+		if !found {
+			break
+		}
+		if entry, found := a.secrets[keyId]; found && bcrypt.CompareHashAndPassword(entry.hash, []byte(reqKey)) == nil {
+			return entry, true
+		}
+	default:
+		entry, found := a.secrets[reqKey]
+		return entry, found
+	}
+	return apiKeyEntry{}, false
+}
+
 // impl:K8sSecretBasedIdentityConfigEvaluator
 
 func (a *APIKey) GetK8sSecretLabelSelectors() k8s_labels.Selector {
@@ -103,21 +178,15 @@ func (a *APIKey) AddK8sSecretBasedIdentity(ctx context.Context, new k8s.Secret)
 	logger := log.FromContext(ctx).WithName("apikey")
 
 	// updating existing
-	newAPIKeyValue := string(new.Data[apiKeySelector])
-	for oldAPIKeyValue, current := range a.secrets {
-		if current.GetNamespace() == new.GetNamespace() && current.GetName() == new.GetName() {
-			if oldAPIKeyValue != newAPIKeyValue {
-				a.appendK8sSecretBasedIdentity(new)
-				delete(a.secrets, oldAPIKeyValue)
-				logger.V(1).Info("api key updated")
-			} else {
-				logger.V(1).Info("api key unchanged")
-			}
-			return
-		}
+	if a.deleteK8sSecretBasedIdentity(new.GetNamespace(), new.GetName()) {
+		a.appendK8sSecretBasedIdentity(new)
+		reportAPIKeyIndexUpdate("update")
+		logger.V(1).Info("api key updated")
+		return
 	}
 
 	if a.appendK8sSecretBasedIdentity(new) {
+		reportAPIKeyIndexUpdate("add")
 		logger.V(1).Info("api key added")
 	}
 }
@@ -130,26 +199,92 @@ func (a *APIKey) RevokeK8sSecretBasedIdentity(ctx context.Context, deleted k8s_t
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
-	for key, secret := range a.secrets {
-		if secret.GetNamespace() == deleted.Namespace && secret.GetName() == deleted.Name {
-			delete(a.secrets, key)
-			log.FromContext(ctx).WithName("apikey").V(1).Info("api key deleted")
-			return
-		}
+	if a.deleteK8sSecretBasedIdentity(deleted.Namespace, deleted.Name) {
+		reportAPIKeyIndexUpdate("delete")
+		log.FromContext(ctx).WithName("apikey").V(1).Info("api key deleted")
 	}
 }
 
+// Clean removes the API keys of the identity config from the index
+func (a *APIKey) Clean(_ context.Context) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	apiKeyIndexEntriesMetric.WithLabelValues().Sub(float64(len(a.secrets)))
+	a.secrets = make(map[string]apiKeyEntry)
+	a.keysBySecret = make(map[string][]string)
+	return nil
+}
+
 func (a *APIKey) withinScope(namespace string) bool {
 	return a.Namespace == "" || a.Namespace == namespace
 }
 
-// Appends the K8s Secret to the cache of API keys
+// Appends the API keys of the K8s Secret to the cache of API keys
+// Each key can expire at the time (RFC 3339) set in the annotation `authorino.kuadrant.io/<entry>-expires-at` of the Secret,
+// or otherwise in the annotation `authorino.kuadrant.io/expires-at`. Keys with an invalid expiration time are taken as expired.
 // Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
 func (a *APIKey) appendK8sSecretBasedIdentity(secret k8s.Secret) bool {
-	value, isAPIKeySecret := secret.Data[apiKeySelector]
-	if isAPIKeySecret && len(value) > 0 {
-		a.secrets[string(value)] = secret
-		return true
+	secretKey := k8s_types.NamespacedName{Namespace: secret.GetNamespace(), Name: secret.GetName()}.String()
+	appended := false
+	for entry, value := range secret.Data {
+		if entry != apiKeySelector && !strings.HasPrefix(entry, apiKeySelector+"_") || len(value) == 0 {
+			continue
+		}
+		expiresAt, ok := secret.Annotations[apiKeyAnnotationPrefix+entry+"-expires-at"]
+		if !ok {
+			expiresAt = secret.Annotations[apiKeyExpiresAtAnnotation]
+		}
+		key, hash := string(value), []byte(nil)
+		if a.KeyHashing == APIKeyHashingBcrypt {
+			// bcrypt-hashed API keys are stored as `<key-id>:<hash>`
+			keyId, keyHash, found := strings.Cut(key, apiKeyIdHashSeparator)
+			if !found || keyId == "" || strings.Contains(keyId, apiKeyIdSeparator) {
+				continue
+			}
+			key, hash = keyId, []byte(keyHash)
+		}
+		if _, exists := a.secrets[key]; !exists {
+			apiKeyIndexEntriesMetric.WithLabelValues().Inc()
+		}
+		a.secrets[key] = apiKeyEntry{secret: secret, expiresAt: parseAPIKeyExpiration(expiresAt), hash: hash}
+		a.keysBySecret[secretKey] = append(a.keysBySecret[secretKey], key)
+		appended = true
+	}
+	return appended
+}
+
+// Deletes all the API keys of a K8s Secret from the cache of API keys
+// Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
+func (a *APIKey) deleteK8sSecretBasedIdentity(namespace, name string) bool {
+	secretKey := k8s_types.NamespacedName{Namespace: namespace, Name: name}.String()
+	keys, found := a.keysBySecret[secretKey]
+	if !found {
+		return false
+	}
+	for _, key := range keys {
+		// the same key may have been added by another secret since
+		if entry, exists := a.secrets[key]; exists && entry.secret.GetNamespace() == namespace && entry.secret.GetName() == name {
+			delete(a.secrets, key)
+			apiKeyIndexEntriesMetric.WithLabelValues().Dec()
+		}
+	}
+	delete(a.keysBySecret, secretKey)
+	return true
+}
+
+func reportAPIKeyIndexUpdate(operation string) {
+	apiKeyIndexUpdatesMetric.WithLabelValues(operation).Inc()
+	apiKeyIndexLastUpdateMetric.WithLabelValues().SetToCurrentTime()
+}
+
+func parseAPIKeyExpiration(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Unix(0, 0)
 	}
-	return false
+	return expiresAt
 }
@@ -2,16 +2,25 @@ package identity
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+
 	k8s "k8s.io/api/core/v1"
 	k8s_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
 
 	gomock "github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"golang.org/x/crypto/bcrypt"
 	"gotest.tools/assert"
 )
 
@@ -32,7 +41,7 @@ func TestNewApiKeyIdentityAllNamespaces(t *testing.T) {
 	defer ctrl.Finish()
 
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("jedi", selector, "", mock_auth.NewMockAuthCredentials(ctrl), testAPIKeyK8sClient, context.TODO())
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", mock_auth.NewMockAuthCredentials(ctrl), testAPIKeyK8sClient, context.TODO())
 
 	assert.Equal(t, apiKey.Name, "jedi")
 	assert.Equal(t, apiKey.LabelSelectors.String(), "planet=coruscant")
@@ -51,7 +60,7 @@ func TestNewApiKeyIdentitySingleNamespace(t *testing.T) {
 	defer ctrl.Finish()
 
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("jedi", selector, "ns1", mock_auth.NewMockAuthCredentials(ctrl), testAPIKeyK8sClient, context.TODO())
+	apiKey := NewApiKeyIdentity("jedi", selector, "ns1", "", mock_auth.NewMockAuthCredentials(ctrl), testAPIKeyK8sClient, context.TODO())
 
 	assert.Equal(t, apiKey.Name, "jedi")
 	assert.Equal(t, apiKey.LabelSelectors.String(), "planet=coruscant")
@@ -74,7 +83,7 @@ func TestCallSuccess(t *testing.T) {
 	authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("ObiWanKenobiLightSaber", nil)
 
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("jedi", selector, "", authCredMock, testAPIKeyK8sClient, context.TODO())
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", authCredMock, testAPIKeyK8sClient, context.TODO())
 	auth, err := apiKey.Call(pipelineMock, context.TODO())
 
 	assert.NilError(t, err)
@@ -90,7 +99,7 @@ func TestCallNoApiKeyFail(t *testing.T) {
 	authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("", fmt.Errorf("something went wrong getting the API Key"))
 
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("jedi", selector, "", authCredMock, testAPIKeyK8sClient, context.TODO())
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", authCredMock, testAPIKeyK8sClient, context.TODO())
 
 	_, err := apiKey.Call(pipelineMock, context.TODO())
 
@@ -106,15 +115,91 @@ func TestCallInvalidApiKeyFail(t *testing.T) {
 	authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("ASithLightSaber", nil)
 
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("jedi", selector, "", authCredMock, testAPIKeyK8sClient, context.TODO())
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", authCredMock, testAPIKeyK8sClient, context.TODO())
 	_, err := apiKey.Call(pipelineMock, context.TODO())
 
 	assert.Error(t, err, "the API Key provided is invalid")
 }
 
+func TestCallWithCustomCredentialsLocation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("planet=coruscant")
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": "ObiWanKenobiLightSaber"}})
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", auth.NewAuthCredential("X-API-KEY", "custom_header"), testAPIKeyK8sClient, context.TODO())
+	obj, err := apiKey.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(k8s.Secret).Name, "obi-wan")
+
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Path: "/hello?api_key=MasterYodaLightSaber"})
+	apiKey = NewApiKeyIdentity("jedi", selector, "", "", auth.NewAuthCredential("api_key", "query"), testAPIKeyK8sClient, context.TODO())
+	obj, err = apiKey.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(k8s.Secret).Name, "yoda")
+
+	// the key is not accepted from the authorization header when another location is configured
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"authorization": "APIKEY MasterYodaLightSaber"}})
+	_, err = apiKey.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "credential not found")
+}
+
+func TestCallWithHashedApiKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sha256Hash := sha256.Sum256([]byte("ObiWanKenobiLightSaber"))
+	bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("yoda.MasterYodaLightSaber"), bcrypt.MinCost)
+	unidentifiedBcryptHash, _ := bcrypt.GenerateFromPassword([]byte("LukeSkywalkerLightSaber"), bcrypt.MinCost)
+	k8sClient := mockK8sClient(
+		&k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: "obi-wan", Namespace: "ns1", Labels: map[string]string{"hashing": "sha256"}}, Data: map[string][]byte{"api_key": []byte(hex.EncodeToString(sha256Hash[:]))}},
+		&k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: "yoda", Namespace: "ns1", Labels: map[string]string{"hashing": "bcrypt"}}, Data: map[string][]byte{"api_key": append([]byte("yoda:"), bcryptHash...)}},
+		&k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: "luke", Namespace: "ns1", Labels: map[string]string{"hashing": "bcrypt"}}, Data: map[string][]byte{"api_key": unidentifiedBcryptHash}},
+	)
+	creds := auth.NewAuthCredential("X-API-KEY", "custom_header")
+
+	selector, _ := k8s_labels.Parse("hashing=sha256")
+	apiKey := NewApiKeyIdentity("jedi", selector, "", APIKeyHashingSHA256, creds, k8sClient, context.TODO())
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": "ObiWanKenobiLightSaber"}})
+	obj, err := apiKey.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(k8s.Secret).Name, "obi-wan")
+
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": hex.EncodeToString(sha256Hash[:])}})
+	_, err = apiKey.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "the API Key provided is invalid") // the hash itself is not a valid api key
+
+	selector, _ = k8s_labels.Parse("hashing=bcrypt")
+	apiKey = NewApiKeyIdentity("jedi", selector, "", APIKeyHashingBcrypt, creds, k8sClient, context.TODO())
+	assert.Equal(t, len(apiKey.secrets), 1) // bcrypt hashes are indexed by key id; the hash without a key id is ignored
+	_, exists := apiKey.secrets["yoda"]
+	assert.Check(t, exists)
+
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": "yoda.MasterYodaLightSaber"}})
+	obj, err = apiKey.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(k8s.Secret).Name, "yoda")
+
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": "yoda.ASithLightSaber"}})
+	_, err = apiKey.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "the API Key provided is invalid")
+
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": "MasterYodaLightSaber"}})
+	_, err = apiKey.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "the API Key provided is invalid") // no key id
+
+	pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": "LukeSkywalkerLightSaber"}})
+	_, err = apiKey.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "the API Key provided is invalid")
+}
+
 func TestLoadSecretsSuccess(t *testing.T) {
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("X-API-KEY", selector, "", nil, testAPIKeyK8sClient, nil)
+	apiKey := NewApiKeyIdentity("X-API-KEY", selector, "", "", nil, testAPIKeyK8sClient, nil)
 
 	err := apiKey.loadSecrets(context.TODO())
 	assert.NilError(t, err)
@@ -122,16 +207,16 @@ func TestLoadSecretsSuccess(t *testing.T) {
 
 	secret1, exists := apiKey.secrets["ObiWanKenobiLightSaber"]
 	assert.Check(t, exists)
-	assert.Equal(t, testAPIKeyK8sSecret1.String(), secret1.String())
+	assert.Equal(t, testAPIKeyK8sSecret1.String(), secret1.secret.String())
 
 	secret2, exists := apiKey.secrets["MasterYodaLightSaber"]
 	assert.Check(t, exists)
-	assert.Equal(t, testAPIKeyK8sSecret2.String(), secret2.String())
+	assert.Equal(t, testAPIKeyK8sSecret2.String(), secret2.secret.String())
 }
 
 func TestLoadSecretsFail(t *testing.T) {
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("X-API-KEY", selector, "", nil, &flawedAPIkeyK8sClient{}, context.TODO())
+	apiKey := NewApiKeyIdentity("X-API-KEY", selector, "", "", nil, &flawedAPIkeyK8sClient{}, context.TODO())
 
 	err := apiKey.loadSecrets(context.TODO())
 	assert.Error(t, err, "something terribly wrong happened")
@@ -145,7 +230,7 @@ func BenchmarkAPIKeyAuthn(b *testing.B) {
 	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
 	authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("ObiWanKenobiLightSaber", nil).MinTimes(1)
 	selector, _ := k8s_labels.Parse("planet=coruscant")
-	apiKey := NewApiKeyIdentity("jedi", selector, "", authCredMock, testAPIKeyK8sClient, context.TODO())
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", authCredMock, testAPIKeyK8sClient, context.TODO())
 
 	var err error
 	b.ResetTimer()
@@ -155,3 +240,99 @@ func BenchmarkAPIKeyAuthn(b *testing.B) {
 	b.StopTimer()
 	assert.NilError(b, err)
 }
+
+func TestCallWithMultipleApiKeysPerSecret(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secret := &k8s.Secret{
+		ObjectMeta: k8s_meta.ObjectMeta{
+			Name:      "luke",
+			Namespace: "ns1",
+			Labels:    map[string]string{"planet": "dagobah"},
+			Annotations: map[string]string{
+				"authorino.kuadrant.io/api_key_previous-expires-at": time.Now().Add(-time.Minute).Format(time.RFC3339),
+				"authorino.kuadrant.io/api_key_next-expires-at":     "not-a-timestamp",
+			},
+		},
+		Data: map[string][]byte{
+			"api_key":          []byte("LukeSkywalkerGreenLightSaber"),
+			"api_key_previous": []byte("LukeSkywalkerBlueLightSaber"),
+			"api_key_next":     []byte("LukeSkywalkerYellowLightSaber"),
+			"other":            []byte("LukeSkywalkerBlaster"),
+		},
+	}
+	selector, _ := k8s_labels.Parse("planet=dagobah")
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", auth.NewAuthCredential("X-API-KEY", "custom_header"), mockK8sClient(secret), context.TODO())
+	assert.Equal(t, len(apiKey.secrets), 3)
+
+	call := func(key string) (interface{}, error) {
+		pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+		pipelineMock.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-api-key": key}})
+		return apiKey.Call(pipelineMock, context.TODO())
+	}
+
+	obj, err := call("LukeSkywalkerGreenLightSaber")
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(k8s.Secret).Name, "luke")
+
+	_, err = call("LukeSkywalkerBlueLightSaber")
+	assert.Error(t, err, "the API Key provided has expired")
+
+	_, err = call("LukeSkywalkerYellowLightSaber") // invalid expiration time
+	assert.Error(t, err, "the API Key provided has expired")
+
+	_, err = call("LukeSkywalkerBlaster")
+	assert.Error(t, err, "the API Key provided is invalid")
+
+	// the whole secret expires
+	secret.Annotations = map[string]string{"authorino.kuadrant.io/expires-at": time.Now().Add(-time.Minute).Format(time.RFC3339)}
+	apiKey.AddK8sSecretBasedIdentity(context.TODO(), *secret)
+	assert.Equal(t, len(apiKey.secrets), 3)
+	_, err = call("LukeSkywalkerGreenLightSaber")
+	assert.Error(t, err, "the API Key provided has expired")
+
+	// rotation: the previous key is removed from the secret
+	delete(secret.Data, "api_key_previous")
+	apiKey.AddK8sSecretBasedIdentity(context.TODO(), *secret)
+	assert.Equal(t, len(apiKey.secrets), 2)
+
+	apiKey.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "luke"})
+	assert.Equal(t, len(apiKey.secrets), 0)
+}
+
+func TestAPIKeyIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	entries := func() float64 { return testutil.ToFloat64(apiKeyIndexEntriesMetric.WithLabelValues()) }
+	initialEntries := entries()
+
+	selector, _ := k8s_labels.Parse("planet=coruscant")
+	apiKey := NewApiKeyIdentity("jedi", selector, "", "", mock_auth.NewMockAuthCredentials(ctrl), testAPIKeyK8sClient, context.TODO())
+	assert.Equal(t, len(apiKey.keysBySecret), 2)
+	assert.DeepEqual(t, apiKey.keysBySecret["ns1/obi-wan"], []string{"ObiWanKenobiLightSaber"})
+	assert.Equal(t, entries(), initialEntries+2)
+
+	// another secret with the same key as an existing one
+	clone := testAPIKeyK8sSecret1.DeepCopy()
+	clone.Name = "obi-wan-clone"
+	apiKey.AddK8sSecretBasedIdentity(context.TODO(), *clone)
+	assert.Equal(t, len(apiKey.secrets), 2)
+	assert.Equal(t, entries(), initialEntries+2)
+
+	// revoking the original secret does not delete the key now held by the clone
+	apiKey.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "obi-wan"})
+	assert.Equal(t, apiKey.secrets["ObiWanKenobiLightSaber"].secret.Name, "obi-wan-clone")
+	_, found := apiKey.keysBySecret["ns1/obi-wan"]
+	assert.Check(t, !found)
+
+	apiKey.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "obi-wan-clone"})
+	assert.Equal(t, len(apiKey.secrets), 1)
+	assert.Equal(t, entries(), initialEntries+1)
+	assert.Check(t, testutil.ToFloat64(apiKeyIndexLastUpdateMetric.WithLabelValues()) > 0)
+
+	assert.NilError(t, apiKey.Clean(context.TODO()))
+	assert.Equal(t, len(apiKey.secrets), 0)
+	assert.Equal(t, entries(), initialEntries)
+}
@@ -0,0 +1,379 @@
+package identity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	k8s "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+	k8s_client "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	DefaultAWSSigV4MaxClockSkew = 300 // seconds
+
+	awsSigV4Algorithm           = "AWS4-HMAC-SHA256"
+	awsSigV4DateFormat          = "20060102T150405Z"
+	awsSigV4ScopeTerminator     = "aws4_request"
+	awsSigV4UnsignedPayload     = "UNSIGNED-PAYLOAD"
+	awsAccessKeyIdSelector      = "aws_access_key_id"
+	awsSecretAccessKeySelector  = "aws_secret_access_key"
+	invalidAWSSigV4HeaderMsg    = "invalid aws sigv4 authorization header"
+	invalidAWSSigV4SignatureMsg = "the request signature is invalid"
+)
+
+// AWSSigV4 verifies requests signed with AWS Signature Version 4 against the access key pairs stored in Kubernetes Secrets
+// (`aws_access_key_id` and `aws_secret_access_key` entries).
+// Only signatures sent in the Authorization header are supported.
+// The host and x-amz-date headers must be signed, and the payload hash (x-amz-content-sha256 header) must match the body of
+// the request, unless AllowUnsignedPayload is enabled and the payload hash is "UNSIGNED-PAYLOAD".
+type AWSSigV4 struct {
+	auth.AuthCredentials
+
+	Name           string
+	LabelSelectors k8s_labels.Selector
+	Namespace      string
+	Region         string
+	Service        string
+	MaxClockSkew   time.Duration
+
+	AllowUnsignedPayload bool
+
+	// access key pairs indexed by access key id
+	secrets   map[string]k8s.Secret
+	mutex     sync.RWMutex
+	k8sClient k8s_client.Reader
+}
+
+func NewAWSSigV4Identity(name string, labelSelectors k8s_labels.Selector, namespace string, region string, service string, maxClockSkew int, allowUnsignedPayload bool, k8sClient k8s_client.Reader, ctx context.Context) *AWSSigV4 {
+	if maxClockSkew <= 0 {
+		maxClockSkew = DefaultAWSSigV4MaxClockSkew
+	}
+	a := &AWSSigV4{
+		AuthCredentials:      auth.NewAuthCredential(awsSigV4Algorithm, "authorization_header"),
+		Name:                 name,
+		LabelSelectors:       labelSelectors,
+		Namespace:            namespace,
+		Region:               region,
+		Service:              service,
+		MaxClockSkew:         time.Duration(maxClockSkew) * time.Second,
+		AllowUnsignedPayload: allowUnsignedPayload,
+		secrets:              make(map[string]k8s.Secret),
+		k8sClient:            k8sClient,
+	}
+	if err := a.loadSecrets(context.TODO()); err != nil {
+		log.FromContext(ctx).WithName("awssigv4").Error(err, credentialsFetchingErrorMsg)
+	}
+	return a
+}
+
+// loadSecrets will load the matching k8s secrets from the cluster to the cache of access key pairs
+func (a *AWSSigV4) loadSecrets(ctx context.Context) error {
+	opts := []k8s_client.ListOption{k8s_client.MatchingLabelsSelector{Selector: a.LabelSelectors}}
+	if namespace := a.Namespace; namespace != "" {
+		opts = append(opts, k8s_client.InNamespace(namespace))
+	}
+	var secretList = &k8s.SecretList{}
+	if err := a.k8sClient.List(ctx, secretList, opts...); err != nil {
+		return err
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, secret := range secretList.Items {
+		a.appendK8sSecretBasedIdentity(secret)
+	}
+
+	return nil
+}
+
+type awsSigV4Authorization struct {
+	accessKeyId   string
+	date          string
+	region        string
+	service       string
+	signedHeaders []string
+	signature     []byte
+}
+
+func (s awsSigV4Authorization) scope() string {
+	return strings.Join([]string{s.date, s.region, s.service, awsSigV4ScopeTerminator}, "/")
+}
+
+// Call verifies the signature of the request, returning the Secret of the access key that signed the request, without its data
+func (a *AWSSigV4) Call(pipeline auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	httpRequest := pipeline.GetHttp()
+
+	credentials, err := a.GetCredentialsFromReq(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	authorization, err := parseAWSSigV4Authorization(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if (a.Region != "" && authorization.region != a.Region) || (a.Service != "" && authorization.service != a.Service) {
+		return nil, fmt.Errorf("the request is not signed for the expected region or service")
+	}
+
+	headers := httpRequest.GetHeaders()
+	signingTime, err := awsSigV4SigningTime(headers)
+	if err != nil {
+		return nil, err
+	}
+	if skew := time.Since(signingTime); skew > a.MaxClockSkew || skew < -a.MaxClockSkew {
+		return nil, fmt.Errorf("the date of the request is out of the allowed clock skew")
+	}
+	amzDate := signingTime.UTC().Format(awsSigV4DateFormat)
+	if amzDate[:8] != authorization.date {
+		return nil, fmt.Errorf(invalidAWSSigV4HeaderMsg)
+	}
+
+	payloadHash, err := a.payloadHash(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mutex.RLock()
+	secret, found := a.secrets[authorization.accessKeyId]
+	a.mutex.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("the access key id is unknown")
+	}
+
+	stringToSign := strings.Join([]string{awsSigV4Algorithm, amzDate, authorization.scope(), hexSHA256([]byte(awsSigV4CanonicalRequest(httpRequest, authorization, payloadHash)))}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+string(secret.Data[awsSecretAccessKeySelector])), authorization.date)
+	for _, k := range []string{authorization.region, authorization.service, awsSigV4ScopeTerminator} {
+		signingKey = hmacSHA256(signingKey, k)
+	}
+
+	if !hmac.Equal(hmacSHA256(signingKey, stringToSign), authorization.signature) {
+		return nil, fmt.Errorf(invalidAWSSigV4SignatureMsg)
+	}
+
+	return sanitizedSecret(secret), nil
+}
+
+// payloadHash returns the hex-encoded SHA-256 hash of the body of the request to build the canonical request with.
+// If present, the x-amz-content-sha256 header must match the hash of the body, or be "UNSIGNED-PAYLOAD" when unsigned payloads are allowed.
+func (a *AWSSigV4) payloadHash(httpRequest *envoy_auth.AttributeContext_HttpRequest) (string, error) {
+	body := []byte(httpRequest.GetBody())
+	if len(body) == 0 {
+		body = httpRequest.GetRawBody()
+	}
+	payloadHash := hexSHA256(body)
+
+	contentSHA256, found := httpRequest.GetHeaders()["x-amz-content-sha256"]
+	switch {
+	case !found || contentSHA256 == payloadHash:
+		return payloadHash, nil
+	case contentSHA256 == awsSigV4UnsignedPayload && a.AllowUnsignedPayload:
+		return awsSigV4UnsignedPayload, nil
+	default:
+		return "", fmt.Errorf("the payload hash does not match the body of the request")
+	}
+}
+
+// parseAWSSigV4Authorization parses the value of the Authorization header (without the algorithm prefix),
+// e.g. "Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=host;x-amz-date, Signature=5d672d79..."
+func parseAWSSigV4Authorization(value string) (*awsSigV4Authorization, error) {
+	params := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		keyAndValue := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(keyAndValue) != 2 {
+			return nil, fmt.Errorf(invalidAWSSigV4HeaderMsg)
+		}
+		params[keyAndValue[0]] = keyAndValue[1]
+	}
+
+	credential := strings.Split(params["Credential"], "/")
+	if len(credential) != 5 || credential[4] != awsSigV4ScopeTerminator {
+		return nil, fmt.Errorf(invalidAWSSigV4HeaderMsg)
+	}
+
+	signedHeaders := strings.Split(params["SignedHeaders"], ";")
+	signature, err := hex.DecodeString(params["Signature"])
+	if err != nil || len(signature) != sha256.Size || len(signedHeaders) == 0 || signedHeaders[0] == "" {
+		return nil, fmt.Errorf(invalidAWSSigV4HeaderMsg)
+	}
+	if !utils.SliceContains(signedHeaders, "host") || !utils.SliceContains(signedHeaders, "x-amz-date") {
+		return nil, fmt.Errorf("the host and x-amz-date headers must be signed")
+	}
+
+	return &awsSigV4Authorization{
+		accessKeyId:   credential[0],
+		date:          credential[1],
+		region:        credential[2],
+		service:       credential[3],
+		signedHeaders: signedHeaders,
+		signature:     signature,
+	}, nil
+}
+
+func awsSigV4SigningTime(headers map[string]string) (time.Time, error) {
+	if t, err := time.Parse(awsSigV4DateFormat, headers["x-amz-date"]); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date header")
+}
+
+// awsSigV4CanonicalRequest builds the canonical request as specified in https://docs.aws.amazon.com/general/latest/gr/create-signed-request.html
+func awsSigV4CanonicalRequest(httpRequest *envoy_auth.AttributeContext_HttpRequest, authorization *awsSigV4Authorization, payloadHash string) string {
+	path, rawQuery := httpRequest.GetPath(), ""
+	if i := strings.Index(path, "?"); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+	if path == "" {
+		path = "/"
+	}
+	// except for s3, the path segments are uri-encoded twice by the aws sdks
+	if authorization.service != "s3" {
+		path = awsURIEncode(path, false)
+	}
+
+	query, _ := url.ParseQuery(rawQuery)
+	queryKeys := make([]string, 0, len(query))
+	for key := range query {
+		queryKeys = append(queryKeys, key)
+	}
+	sort.Strings(queryKeys)
+	queryParams := make([]string, 0, len(query))
+	for _, key := range queryKeys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			queryParams = append(queryParams, awsURIEncode(key, true)+"="+awsURIEncode(value, true))
+		}
+	}
+
+	headers := httpRequest.GetHeaders()
+	canonicalHeaders := make([]string, 0, len(authorization.signedHeaders))
+	for _, name := range authorization.signedHeaders {
+		value, found := headers[name]
+		if !found && name == "host" {
+			value = httpRequest.GetHost()
+		}
+		canonicalHeaders = append(canonicalHeaders, name+":"+strings.Join(strings.Fields(value), " ")+"\n")
+	}
+
+	return strings.Join([]string{
+		httpRequest.GetMethod(),
+		path,
+		strings.Join(queryParams, "&"),
+		strings.Join(canonicalHeaders, ""),
+		strings.Join(authorization.signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+}
+
+// awsURIEncode encodes every byte except the unreserved characters (A-Z, a-z, 0-9, '-', '.', '_', '~') and, unless encodeSlash, '/'
+func awsURIEncode(s string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' || (c == '/' && !encodeSlash) {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// impl:K8sSecretBasedIdentityConfigEvaluator
+
+func (a *AWSSigV4) GetK8sSecretLabelSelectors() k8s_labels.Selector {
+	return a.LabelSelectors
+}
+
+func (a *AWSSigV4) AddK8sSecretBasedIdentity(ctx context.Context, new k8s.Secret) {
+	if !a.withinScope(new.GetNamespace()) {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	logger := log.FromContext(ctx).WithName("awssigv4")
+
+	existed := a.deleteK8sSecretBasedIdentity(k8s_types.NamespacedName{Namespace: new.Namespace, Name: new.Name})
+	added := a.appendK8sSecretBasedIdentity(new)
+
+	switch {
+	case existed && added:
+		logger.V(1).Info("aws access key updated")
+	case added:
+		logger.V(1).Info("aws access key added")
+	case existed:
+		logger.V(1).Info("aws access key deleted")
+	}
+}
+
+func (a *AWSSigV4) RevokeK8sSecretBasedIdentity(ctx context.Context, deleted k8s_types.NamespacedName) {
+	if !a.withinScope(deleted.Namespace) {
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.deleteK8sSecretBasedIdentity(deleted) {
+		log.FromContext(ctx).WithName("awssigv4").V(1).Info("aws access key deleted")
+	}
+}
+
+func (a *AWSSigV4) withinScope(namespace string) bool {
+	return a.Namespace == "" || a.Namespace == namespace
+}
+
+// Appends the K8s Secret to the cache of access key pairs
+// Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
+func (a *AWSSigV4) appendK8sSecretBasedIdentity(secret k8s.Secret) bool {
+	accessKeyId := string(secret.Data[awsAccessKeyIdSelector])
+	if accessKeyId == "" || len(secret.Data[awsSecretAccessKeySelector]) == 0 {
+		return false
+	}
+	a.secrets[accessKeyId] = secret
+	return true
+}
+
+// Deletes the access key pair of a K8s Secret from the cache
+// Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
+func (a *AWSSigV4) deleteK8sSecretBasedIdentity(secretName k8s_types.NamespacedName) bool {
+	for accessKeyId, secret := range a.secrets {
+		if secret.Namespace == secretName.Namespace && secret.Name == secretName.Name {
+			delete(a.secrets, accessKeyId)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package identity
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	gomock "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+	k8s "k8s.io/api/core/v1"
+	k8s_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+)
+
+// test vectors from the aws signature version 4 test suite
+const (
+	awsTestAccessKeyId     = "AKIDEXAMPLE"
+	awsTestSecretAccessKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	awsTestAmzDate         = "20150830T123600Z"
+	awsTestMaxClockSkew    = 100 * 365 * 24 * 3600 // the test vectors are signed in the past
+)
+
+func newAWSTestSecret(name, namespace, accessKeyId, secretAccessKey string) *k8s.Secret {
+	return &k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": "aws"}}, Data: map[string][]byte{"aws_access_key_id": []byte(accessKeyId), "aws_secret_access_key": []byte(secretAccessKey)}}
+}
+
+func newAWSTestRequest(path, signature string) *envoy_auth.AttributeContext_HttpRequest {
+	return &envoy_auth.AttributeContext_HttpRequest{
+		Method: "GET",
+		Host:   "example.amazonaws.com",
+		Path:   path,
+		Headers: map[string]string{
+			"x-amz-date":    awsTestAmzDate,
+			"authorization": "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + signature,
+		},
+	}
+}
+
+func TestAWSSigV4Call(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("app=aws")
+	client := mockK8sClient(newAWSTestSecret("example", "ns1", awsTestAccessKeyId, awsTestSecretAccessKey))
+	sigv4 := NewAWSSigV4Identity("aws", selector, "ns1", "", "", awsTestMaxClockSkew, false, client, context.TODO())
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	// get-vanilla
+	pipeline.EXPECT().GetHttp().Return(newAWSTestRequest("/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"))
+	obj, err := sigv4.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(k8s.Secret).Name, "example")
+	assert.Equal(t, len(obj.(k8s.Secret).Data), 0) // the access key pair is not part of the identity object
+
+	// get-vanilla-query-order-key-case
+	pipeline.EXPECT().GetHttp().Return(newAWSTestRequest("/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+
+	// tampered request
+	pipeline.EXPECT().GetHttp().Return(newAWSTestRequest("/other", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the request signature is invalid")
+
+	// malformed authorization header
+	pipeline.EXPECT().GetHttp().Return(newAWSTestRequest("/", "invalid"))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.Error(t, err, "invalid aws sigv4 authorization header")
+}
+
+func TestAWSSigV4CallWithRestrictions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("app=aws")
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	// region mismatch
+	sigv4 := NewAWSSigV4Identity("aws", selector, "ns1", "eu-west-1", "", awsTestMaxClockSkew, false, mockK8sClient(newAWSTestSecret("example", "ns1", awsTestAccessKeyId, awsTestSecretAccessKey)), context.TODO())
+	pipeline.EXPECT().GetHttp().Return(newAWSTestRequest("/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"))
+	_, err := sigv4.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the request is not signed for the expected region or service")
+
+	// signing time out of the default clock skew
+	sigv4 = NewAWSSigV4Identity("aws", selector, "ns1", "us-east-1", "service", 0, false, mockK8sClient(newAWSTestSecret("example", "ns1", awsTestAccessKeyId, awsTestSecretAccessKey)), context.TODO())
+	pipeline.EXPECT().GetHttp().Return(newAWSTestRequest("/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the date of the request is out of the allowed clock skew")
+
+	// unknown access key
+	sigv4 = NewAWSSigV4Identity("aws", selector, "ns1", "", "", awsTestMaxClockSkew, false, mockK8sClient(newAWSTestSecret("example", "ns1", "AKIDOTHER", awsTestSecretAccessKey)), context.TODO())
+	pipeline.EXPECT().GetHttp().Return(newAWSTestRequest("/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the access key id is unknown")
+}
+
+func TestAWSSigV4CallWithPayload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("app=aws")
+	client := mockK8sClient(newAWSTestSecret("example", "ns1", awsTestAccessKeyId, awsTestSecretAccessKey))
+	sigv4 := NewAWSSigV4Identity("aws", selector, "ns1", "", "", awsTestMaxClockSkew, false, client, context.TODO())
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	body := `{"name":"john"}`
+	bodyHash := hexSHA256([]byte(body))
+
+	// payload hash omitted
+	pipeline.EXPECT().GetHttp().Return(newSignedAWSTestPostRequest(body, "", bodyHash))
+	_, err := sigv4.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+
+	// payload hash matching the body
+	pipeline.EXPECT().GetHttp().Return(newSignedAWSTestPostRequest(body, bodyHash, bodyHash))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+
+	// payload hash signed for another body
+	otherHash := hexSHA256([]byte(`{"name":"jane"}`))
+	pipeline.EXPECT().GetHttp().Return(newSignedAWSTestPostRequest(body, otherHash, otherHash))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the payload hash does not match the body of the request")
+
+	// unsigned payload not allowed
+	pipeline.EXPECT().GetHttp().Return(newSignedAWSTestPostRequest(body, "UNSIGNED-PAYLOAD", "UNSIGNED-PAYLOAD"))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the payload hash does not match the body of the request")
+
+	// unsigned payload allowed
+	sigv4 = NewAWSSigV4Identity("aws", selector, "ns1", "", "", awsTestMaxClockSkew, true, client, context.TODO())
+	pipeline.EXPECT().GetHttp().Return(newSignedAWSTestPostRequest(body, "UNSIGNED-PAYLOAD", "UNSIGNED-PAYLOAD"))
+	_, err = sigv4.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+}
+
+func TestAWSSigV4CallWithMissingSignedHeaders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("app=aws")
+	sigv4 := NewAWSSigV4Identity("aws", selector, "ns1", "", "", awsTestMaxClockSkew, false, mockK8sClient(newAWSTestSecret("example", "ns1", awsTestAccessKeyId, awsTestSecretAccessKey)), context.TODO())
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	for _, signedHeaders := range []string{"host", "x-amz-date", "content-type;x-amz-date"} {
+		request := newAWSTestRequest("/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31")
+		request.Headers["authorization"] = strings.Replace(request.Headers["authorization"], "SignedHeaders=host;x-amz-date", "SignedHeaders="+signedHeaders, 1)
+		pipeline.EXPECT().GetHttp().Return(request)
+		_, err := sigv4.Call(pipeline, context.TODO())
+		assert.Error(t, err, "the host and x-amz-date headers must be signed", signedHeaders)
+	}
+}
+
+// newSignedAWSTestPostRequest builds a request with the given body and x-amz-content-sha256 header (omitted if empty),
+// signed with the test access key pair for the given payload hash
+func newSignedAWSTestPostRequest(body, contentSHA256, signedPayloadHash string) *envoy_auth.AttributeContext_HttpRequest {
+	request := &envoy_auth.AttributeContext_HttpRequest{
+		Method:  "POST",
+		Host:    "example.amazonaws.com",
+		Path:    "/",
+		Body:    body,
+		Headers: map[string]string{"x-amz-date": awsTestAmzDate},
+	}
+	if contentSHA256 != "" {
+		request.Headers["x-amz-content-sha256"] = contentSHA256
+	}
+
+	authorization := &awsSigV4Authorization{date: awsTestAmzDate[:8], region: "us-east-1", service: "service", signedHeaders: []string{"host", "x-amz-date"}}
+	stringToSign := strings.Join([]string{awsSigV4Algorithm, awsTestAmzDate, authorization.scope(), hexSHA256([]byte(awsSigV4CanonicalRequest(request, authorization, signedPayloadHash)))}, "\n")
+	signingKey := hmacSHA256([]byte("AWS4"+awsTestSecretAccessKey), authorization.date)
+	for _, k := range []string{authorization.region, authorization.service, awsSigV4ScopeTerminator} {
+		signingKey = hmacSHA256(signingKey, k)
+	}
+	request.Headers["authorization"] = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	return request
+}
+
+func TestAWSSigV4AddAndRevokeK8sSecretBasedIdentity(t *testing.T) {
+	selector, _ := k8s_labels.Parse("app=aws")
+	sigv4 := NewAWSSigV4Identity("aws", selector, "", "", "", 0, false, mockK8sClient(), context.TODO())
+	assert.Equal(t, len(sigv4.secrets), 0)
+
+	sigv4.AddK8sSecretBasedIdentity(context.TODO(), *newAWSTestSecret("example", "ns1", awsTestAccessKeyId, awsTestSecretAccessKey))
+	assert.Equal(t, len(sigv4.secrets), 1)
+
+	// rotated access key id
+	sigv4.AddK8sSecretBasedIdentity(context.TODO(), *newAWSTestSecret("example", "ns1", "AKIDROTATED", awsTestSecretAccessKey))
+	assert.Equal(t, len(sigv4.secrets), 1)
+	_, exists := sigv4.secrets["AKIDROTATED"]
+	assert.Check(t, exists)
+
+	sigv4.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "example"})
+	assert.Equal(t, len(sigv4.secrets), 0)
+}
@@ -0,0 +1,166 @@
+package identity
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/replay"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const (
+	DPoPHeader        = "dpop"
+	DPoPProofType     = "dpop+jwt"
+	DefaultDPoPMaxAge = 60
+	dpopMaxFutureSkew = 5 * time.Second
+
+	msg_dpopProofMissingError   = "missing dpop proof"
+	msg_dpopProofInvalidError   = "invalid dpop proof"
+	msg_dpopProofExpiredError   = "the dpop proof is expired or issued in the future"
+	msg_dpopProofMismatchError  = "the dpop proof does not match the request"
+	msg_dpopProofReplayedError  = "the dpop proof has already been used"
+	msg_dpopReplayCheckError    = "failed to check the replay of the dpop proof"
+	msg_dpopTokenNotBoundError  = "the access token is not bound to the dpop proof key"
+	msg_dpopAccessTokenMismatch = "the dpop proof is not bound to the access token"
+)
+
+// dpopSigningAlgs are the asymmetric JWS algorithms accepted for signing DPoP proofs
+var dpopSigningAlgs = []string{
+	string(jose.RS256), string(jose.RS384), string(jose.RS512),
+	string(jose.ES256), string(jose.ES384), string(jose.ES512),
+	string(jose.PS256), string(jose.PS384), string(jose.PS512),
+	string(jose.EdDSA),
+}
+
+// DPoP verifies Demonstrating Proof-of-Possession proofs (RFC 9449) of sender-constrained access tokens
+type DPoP struct {
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+func NewDPoP(maxAge int) *DPoP {
+	if maxAge <= 0 {
+		maxAge = DefaultDPoPMaxAge
+	}
+	return &DPoP{
+		MaxAge: time.Duration(maxAge) * time.Second,
+	}
+}
+
+type dpopProofClaims struct {
+	JTI string `json:"jti"`
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	IAT int64  `json:"iat"`
+	ATH string `json:"ath"`
+}
+
+// Verify checks the DPoP proof supplied in the request against the request itself, the access token and the `cnf.jkt`
+// claim of the access token
+func (d *DPoP) Verify(ctx context.Context, request *envoy_auth.AttributeContext_HttpRequest, accessToken string, tokenClaims interface{}) error {
+	proof := request.GetHeaders()[DPoPHeader]
+	if proof == "" {
+		return fmt.Errorf(msg_dpopProofMissingError)
+	}
+
+	jws, err := jose.ParseSigned(proof)
+	if err != nil || len(jws.Signatures) != 1 {
+		return fmt.Errorf(msg_dpopProofInvalidError)
+	}
+	header := jws.Signatures[0].Protected
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != DPoPProofType || !containsAny(dpopSigningAlgs, header.Algorithm) {
+		return fmt.Errorf(msg_dpopProofInvalidError)
+	}
+	jwk := header.JSONWebKey
+	if jwk == nil || !jwk.IsPublic() {
+		return fmt.Errorf(msg_dpopProofInvalidError)
+	}
+	payload, err := jws.Verify(jwk)
+	if err != nil {
+		return fmt.Errorf(msg_dpopProofInvalidError)
+	}
+
+	var claims dpopProofClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.JTI == "" {
+		return fmt.Errorf(msg_dpopProofInvalidError)
+	}
+
+	// time validity
+	now := time.Now()
+	issuedAt := time.Unix(claims.IAT, 0)
+	if issuedAt.Before(now.Add(-d.MaxAge)) || issuedAt.After(now.Add(dpopMaxFutureSkew)) {
+		return fmt.Errorf(msg_dpopProofExpiredError)
+	}
+
+	// htm and htu
+	if !strings.EqualFold(claims.HTM, request.GetMethod()) || !dpopMatchHTU(claims.HTU, request) {
+		return fmt.Errorf(msg_dpopProofMismatchError)
+	}
+
+	// ath
+	ath := sha256.Sum256([]byte(accessToken))
+	if claims.ATH != base64.RawURLEncoding.EncodeToString(ath[:]) {
+		return fmt.Errorf(msg_dpopAccessTokenMismatch)
+	}
+
+	// cnf.jkt
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf(msg_dpopProofInvalidError)
+	}
+	jkt := base64.RawURLEncoding.EncodeToString(thumbprint)
+	if tokenJKT(tokenClaims) != jkt {
+		return fmt.Errorf(msg_dpopTokenNotBoundError)
+	}
+
+	// replay – the ids of the proofs are remembered in the shared store of one-time values until the proofs expire
+	unused, err := replay.SharedStore.Use(ctx, "dpop/"+jkt+"/"+claims.JTI, issuedAt.Add(d.MaxAge+dpopMaxFutureSkew).Sub(now))
+	if err != nil {
+		return fmt.Errorf(msg_dpopReplayCheckError)
+	}
+	if !unused {
+		return fmt.Errorf(msg_dpopProofReplayedError)
+	}
+
+	return nil
+}
+
+// dpopMatchHTU compares the `htu` claim of a proof with the URI of the request, ignoring query and fragment
+func dpopMatchHTU(htu string, request *envoy_auth.AttributeContext_HttpRequest) bool {
+	u, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	scheme := request.GetScheme()
+	if scheme == "" {
+		scheme = "https"
+	}
+	path := strings.SplitN(strings.SplitN(request.GetPath(), "?", 2)[0], "#", 2)[0]
+	if path == "" {
+		path = "/"
+	}
+	htuPath := u.EscapedPath()
+	if htuPath == "" {
+		htuPath = "/"
+	}
+	return strings.EqualFold(u.Scheme, scheme) && strings.EqualFold(u.Host, request.GetHost()) && htuPath == path
+}
+
+// tokenJKT returns the `cnf.jkt` claim of an access token
+func tokenJKT(claims interface{}) string {
+	if c, ok := claims.(map[string]interface{}); ok {
+		if cnf, ok := c["cnf"].(map[string]interface{}); ok {
+			jkt, _ := cnf["jkt"].(string)
+			return jkt
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package identity
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	gojson "encoding/json"
+	"testing"
+	"time"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"gopkg.in/square/go-jose.v2"
+	"gotest.tools/assert"
+)
+
+const dpopTestAccessToken = "access-token"
+
+func signDPoPTestProof(t *testing.T, key *ecdsa.PrivateKey, typ string, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, (&jose.SignerOptions{EmbedJWK: true}).WithType(jose.ContentType(typ)))
+	assert.NilError(t, err)
+	payload, _ := gojson.Marshal(claims)
+	jws, err := signer.Sign(payload)
+	assert.NilError(t, err)
+	proof, err := jws.CompactSerialize()
+	assert.NilError(t, err)
+	return proof
+}
+
+func newDPoPTestClaims(jti string) map[string]interface{} {
+	ath := sha256.Sum256([]byte(dpopTestAccessToken))
+	return map[string]interface{}{
+		"jti": jti,
+		"htm": "GET",
+		"htu": "https://api.example.com/resource",
+		"iat": time.Now().Unix(),
+		"ath": base64.RawURLEncoding.EncodeToString(ath[:]),
+	}
+}
+
+func newDPoPTestRequest(proof string) *envoy_auth.AttributeContext_HttpRequest {
+	return &envoy_auth.AttributeContext_HttpRequest{
+		Method:  "GET",
+		Scheme:  "https",
+		Host:    "api.example.com",
+		Path:    "/resource?param=value",
+		Headers: map[string]string{"dpop": proof},
+	}
+}
+
+func TestDPoPVerify(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	thumbprint, _ := (&jose.JSONWebKey{Key: key.Public()}).Thumbprint(crypto.SHA256)
+	tokenClaims := map[string]interface{}{"sub": "john", "cnf": map[string]interface{}{"jkt": base64.RawURLEncoding.EncodeToString(thumbprint)}}
+
+	dpop := NewDPoP(0)
+	assert.Equal(t, dpop.MaxAge, 60*time.Second)
+
+	proof := signDPoPTestProof(t, key, DPoPProofType, newDPoPTestClaims("1"))
+	assert.NilError(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, tokenClaims))
+
+	// replayed
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, tokenClaims), "the dpop proof has already been used")
+
+	// missing
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(""), dpopTestAccessToken, tokenClaims), "missing dpop proof")
+
+	// wrong type
+	proof = signDPoPTestProof(t, key, "JWT", newDPoPTestClaims("2"))
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, tokenClaims), "invalid dpop proof")
+
+	// other method
+	claims := newDPoPTestClaims("3")
+	claims["htm"] = "POST"
+	proof = signDPoPTestProof(t, key, DPoPProofType, claims)
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, tokenClaims), "the dpop proof does not match the request")
+
+	// other uri
+	claims = newDPoPTestClaims("4")
+	claims["htu"] = "https://api.example.com/other"
+	proof = signDPoPTestProof(t, key, DPoPProofType, claims)
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, tokenClaims), "the dpop proof does not match the request")
+
+	// expired
+	claims = newDPoPTestClaims("5")
+	claims["iat"] = time.Now().Add(-time.Hour).Unix()
+	proof = signDPoPTestProof(t, key, DPoPProofType, claims)
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, tokenClaims), "the dpop proof is expired or issued in the future")
+
+	// other access token
+	proof = signDPoPTestProof(t, key, DPoPProofType, newDPoPTestClaims("6"))
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), "other-token", tokenClaims), "the dpop proof is not bound to the access token")
+
+	// token bound to another key
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	proof = signDPoPTestProof(t, otherKey, DPoPProofType, newDPoPTestClaims("7"))
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, tokenClaims), "the access token is not bound to the dpop proof key")
+
+	// token not bound
+	proof = signDPoPTestProof(t, key, DPoPProofType, newDPoPTestClaims("8"))
+	assert.Error(t, dpop.Verify(context.TODO(), newDPoPTestRequest(proof), dpopTestAccessToken, map[string]interface{}{"sub": "john"}), "the access token is not bound to the dpop proof key")
+}
@@ -2,16 +2,323 @@ package identity
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	k8s "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+	k8s_client "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	HMACAlgorithmSHA256 = "sha256"
+	HMACAlgorithmSHA512 = "sha512"
+
+	DefaultHMACSignatureHeader = "X-Signature"
+	DefaultHMACMaxClockSkew    = 300 // seconds
+
+	hmacSecretSelector      = "hmac_secret"
+	hmacHeaderComponent     = "header:"
+	hmacDateComponent       = "date"
+	invalidHMACSignatureMsg = "the request signature is invalid"
+
+	lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// HMACSignedComponents are the supported components of the request that can be signed, in the default order
+var HMACSignedComponents = []string{"method", "authority", "path", hmacDateComponent, "body", "digest"}
+
+// HMAC verifies the signature of the request against the shared secrets stored in Kubernetes Secrets (`hmac_secret` entry).
+// The signed message is built out of the signed components of the request, in order, each one prefixed with its length
+// in bytes and a colon, and followed by a new line (e.g. "4:POST\n"). The date of the request must always be signed, so
+// signatures cannot be replayed beyond the allowed clock skew.
 type HMAC struct {
 	auth.AuthCredentials
 
-	Secret string `yaml:"secret"`
+	Name             string
+	LabelSelectors   k8s_labels.Selector
+	Namespace        string
+	Algorithm        string
+	SignedComponents []string
+	MaxClockSkew     time.Duration
+
+	secrets   map[string]k8s.Secret
+	mutex     sync.RWMutex
+	k8sClient k8s_client.Reader
+}
+
+func NewHMACIdentity(name string, labelSelectors k8s_labels.Selector, namespace string, algorithm string, signatureHeader string, signedComponents []string, maxClockSkew int, k8sClient k8s_client.Reader, ctx context.Context) *HMAC {
+	if algorithm == "" {
+		algorithm = HMACAlgorithmSHA256
+	}
+	if signatureHeader == "" {
+		signatureHeader = DefaultHMACSignatureHeader
+	}
+	if len(signedComponents) == 0 {
+		signedComponents = append([]string{}, HMACSignedComponents...)
+	}
+	if maxClockSkew <= 0 {
+		maxClockSkew = DefaultHMACMaxClockSkew
+	}
+	h := &HMAC{
+		AuthCredentials:  auth.NewAuthCredential(signatureHeader, "custom_header"),
+		Name:             name,
+		LabelSelectors:   labelSelectors,
+		Namespace:        namespace,
+		Algorithm:        algorithm,
+		SignedComponents: signedComponents,
+		MaxClockSkew:     time.Duration(maxClockSkew) * time.Second,
+		secrets:          make(map[string]k8s.Secret),
+		k8sClient:        k8sClient,
+	}
+	if err := h.loadSecrets(context.TODO()); err != nil {
+		log.FromContext(ctx).WithName("hmac").Error(err, credentialsFetchingErrorMsg)
+	}
+	return h
+}
+
+// HMACDateSigned tells whether the date of the request is among the signed components, as required to verify the signatures
+func HMACDateSigned(signedComponents []string) bool {
+	if len(signedComponents) == 0 {
+		return true // defaults to HMACSignedComponents
+	}
+	for _, component := range signedComponents {
+		if component == hmacDateComponent {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidHMACSignedComponent tells whether a component of the request is supported to be signed
+func ValidHMACSignedComponent(component string) bool {
+	if strings.HasPrefix(component, hmacHeaderComponent) {
+		return len(component) > len(hmacHeaderComponent)
+	}
+	for _, c := range HMACSignedComponents {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSecrets will load the matching k8s secrets from the cluster to the cache of shared secrets
+func (h *HMAC) loadSecrets(ctx context.Context) error {
+	opts := []k8s_client.ListOption{k8s_client.MatchingLabelsSelector{Selector: h.LabelSelectors}}
+	if namespace := h.Namespace; namespace != "" {
+		opts = append(opts, k8s_client.InNamespace(namespace))
+	}
+	var secretList = &k8s.SecretList{}
+	if err := h.k8sClient.List(ctx, secretList, opts...); err != nil {
+		return err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for _, secret := range secretList.Items {
+		h.appendK8sSecretBasedIdentity(secret)
+	}
+
+	return nil
+}
+
+// Call verifies the signature of the request against each of the shared secrets, returning the Secret whose shared secret matches, without its data
+func (h *HMAC) Call(pipeline auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	httpRequest := pipeline.GetHttp()
+
+	encodedSignature, err := h.GetCredentialsFromReq(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	newHash := h.hashFunc()
+	signature := decodeHMACSignature(encodedSignature, h.Algorithm, newHash().Size())
+	if signature == nil {
+		return nil, fmt.Errorf(invalidHMACSignatureMsg)
+	}
+
+	message, err := h.signedMessage(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	for _, secret := range h.secrets {
+		mac := hmac.New(newHash, secret.Data[hmacSecretSelector])
+		mac.Write(message)
+		if hmac.Equal(mac.Sum(nil), signature) {
+			return sanitizedSecret(secret), nil
+		}
+	}
+
+	return nil, fmt.Errorf(invalidHMACSignatureMsg)
+}
+
+func (h *HMAC) hashFunc() func() hash.Hash {
+	if h.Algorithm == HMACAlgorithmSHA512 {
+		return sha512.New
+	}
+	return sha256.New
+}
+
+// signedMessage builds the message signed by the client out of the signed components of the request
+func (h *HMAC) signedMessage(httpRequest *envoy_auth.AttributeContext_HttpRequest) ([]byte, error) {
+	if !HMACDateSigned(h.SignedComponents) {
+		return nil, fmt.Errorf("the date of the request must be a signed component")
+	}
+
+	body := httpRequest.GetBody()
+	if body == "" {
+		body = string(httpRequest.GetRawBody())
+	}
+
+	components := make([]string, 0, len(h.SignedComponents))
+	for _, component := range h.SignedComponents {
+		switch {
+		case component == "method":
+			components = append(components, httpRequest.GetMethod())
+		case component == "authority":
+			components = append(components, httpRequest.GetHost())
+		case component == "path":
+			components = append(components, httpRequest.GetPath())
+		case component == hmacDateComponent:
+			date := httpRequest.GetHeaders()["date"]
+			t, err := http.ParseTime(date)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date header")
+			}
+			if skew := time.Since(t); skew > h.MaxClockSkew || skew < -h.MaxClockSkew {
+				return nil, fmt.Errorf("the date of the request is out of the allowed clock skew")
+			}
+			components = append(components, date)
+		case component == "body":
+			components = append(components, body)
+		case component == "digest":
+			digest := sha256.Sum256([]byte(body))
+			components = append(components, "SHA-256="+base64.StdEncoding.EncodeToString(digest[:]))
+		case strings.HasPrefix(component, hmacHeaderComponent):
+			components = append(components, httpRequest.GetHeaders()[strings.ToLower(strings.TrimPrefix(component, hmacHeaderComponent))])
+		default:
+			return nil, fmt.Errorf("unsupported signed component: %s", component)
+		}
+	}
+
+	return encodeHMACMessage(components), nil
+}
+
+// encodeHMACMessage frames each component with its length in bytes, so the boundaries between the components are unambiguous
+func encodeHMACMessage(components []string) []byte {
+	var message strings.Builder
+	for _, component := range components {
+		message.WriteString(strconv.Itoa(len(component)))
+		message.WriteByte(':')
+		message.WriteString(component)
+		message.WriteByte('\n')
+	}
+	return []byte(message.String())
+}
+
+// decodeHMACSignature decodes a hex- or base64-encoded signature, optionally prefixed with the name of the algorithm (e.g. "sha256=...")
+func decodeHMACSignature(encoded, algorithm string, size int) []byte {
+	if prefix := algorithm + "="; len(encoded) > len(prefix) && strings.EqualFold(encoded[:len(prefix)], prefix) {
+		encoded = encoded[len(prefix):]
+	}
+	if signature, err := hex.DecodeString(encoded); err == nil && len(signature) == size {
+		return signature
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if signature, err := encoding.DecodeString(encoded); err == nil && len(signature) == size {
+			return signature
+		}
+	}
+	return nil
+}
+
+// impl:K8sSecretBasedIdentityConfigEvaluator
+
+func (h *HMAC) GetK8sSecretLabelSelectors() k8s_labels.Selector {
+	return h.LabelSelectors
+}
+
+func (h *HMAC) AddK8sSecretBasedIdentity(ctx context.Context, new k8s.Secret) {
+	if !h.withinScope(new.GetNamespace()) {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	logger := log.FromContext(ctx).WithName("hmac")
+	secretName := k8s_types.NamespacedName{Namespace: new.Namespace, Name: new.Name}.String()
+	_, exists := h.secrets[secretName]
+
+	if !h.appendK8sSecretBasedIdentity(new) {
+		if exists {
+			delete(h.secrets, secretName)
+			logger.V(1).Info("hmac secret deleted")
+		}
+		return
+	}
+
+	if exists {
+		logger.V(1).Info("hmac secret updated")
+	} else {
+		logger.V(1).Info("hmac secret added")
+	}
+}
+
+func (h *HMAC) RevokeK8sSecretBasedIdentity(ctx context.Context, deleted k8s_types.NamespacedName) {
+	if !h.withinScope(deleted.Namespace) {
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, found := h.secrets[deleted.String()]; found {
+		delete(h.secrets, deleted.String())
+		log.FromContext(ctx).WithName("hmac").V(1).Info("hmac secret deleted")
+	}
+}
+
+func (h *HMAC) withinScope(namespace string) bool {
+	return h.Namespace == "" || h.Namespace == namespace
+}
+
+// Appends the K8s Secret to the cache of shared secrets
+// Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
+func (h *HMAC) appendK8sSecretBasedIdentity(secret k8s.Secret) bool {
+	if value, isHMACSecret := secret.Data[hmacSecretSelector]; isHMACSecret && len(value) > 0 {
+		h.secrets[k8s_types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}.String()] = secret
+		return true
+	}
+	return false
 }
 
-func (self *HMAC) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
-	return "Authenticated with HMAC", nil // TODO: implement
+// sanitizedSecret returns a copy of the Secret to be used as identity object, i.e. its metadata without the data.
+// The annotation with the last applied configuration is removed as well, since it may hold the data of the Secret.
+func sanitizedSecret(secret k8s.Secret) k8s.Secret {
+	sanitized := k8s.Secret{TypeMeta: secret.TypeMeta, ObjectMeta: *secret.ObjectMeta.DeepCopy(), Type: secret.Type}
+	sanitized.ManagedFields = nil
+	delete(sanitized.Annotations, lastAppliedConfigAnnotation)
+	return sanitized
 }
@@ -0,0 +1,208 @@
+package identity
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	gomock "github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+	k8s "k8s.io/api/core/v1"
+	k8s_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+)
+
+func newHMACTestSecret(name, namespace, secret string) *k8s.Secret {
+	return &k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": "webhooks"}}, Data: map[string][]byte{"hmac_secret": []byte(secret)}}
+}
+
+func TestNewHMACIdentity(t *testing.T) {
+	selector, _ := k8s_labels.Parse("app=webhooks")
+	client := mockK8sClient(newHMACTestSecret("github", "ns1", "s3cr3t"), newHMACTestSecret("stripe", "ns2", "an0th3r"), &k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: "other", Namespace: "ns1", Labels: map[string]string{"app": "webhooks"}}})
+
+	h := NewHMACIdentity("hmac", selector, "", "", "", nil, 0, client, context.TODO())
+	assert.Equal(t, h.Algorithm, HMACAlgorithmSHA256)
+	assert.Equal(t, h.GetCredentialsKeySelector(), DefaultHMACSignatureHeader)
+	assert.DeepEqual(t, h.SignedComponents, []string{"method", "authority", "path", "date", "body", "digest"})
+	assert.Equal(t, h.MaxClockSkew, 300*time.Second)
+	assert.Equal(t, len(h.secrets), 2)
+
+	h = NewHMACIdentity("hmac", selector, "ns1", "", "", nil, 0, client, context.TODO())
+	assert.Equal(t, len(h.secrets), 1)
+	_, exists := h.secrets["ns1/github"]
+	assert.Check(t, exists)
+}
+
+func TestHMACCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("app=webhooks")
+	client := mockK8sClient(newHMACTestSecret("github", "ns1", "s3cr3t"))
+	h := NewHMACIdentity("hmac", selector, "ns1", "", "X-Hub-Signature-256", []string{"method", "path", "date", "digest", "header:X-Delivery"}, 60, client, context.TODO())
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	body := `{"action":"opened"}`
+	digest := sha256.Sum256([]byte(body))
+	message := encodeHMACMessage([]string{"POST", "/hooks", date, "SHA-256=" + base64.StdEncoding.EncodeToString(digest[:]), "123"})
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write(message)
+	signature := mac.Sum(nil)
+
+	request := func(signature, date string) *envoy_auth.AttributeContext_HttpRequest {
+		return &envoy_auth.AttributeContext_HttpRequest{
+			Method:  "POST",
+			Path:    "/hooks",
+			Body:    body,
+			Headers: map[string]string{"x-hub-signature-256": signature, "date": date, "x-delivery": "123"},
+		}
+	}
+
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	// hex with algorithm prefix
+	pipeline.EXPECT().GetHttp().Return(request("sha256="+hex.EncodeToString(signature), date))
+	obj, err := h.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(k8s.Secret).Name, "github")
+	assert.Equal(t, len(obj.(k8s.Secret).Data), 0) // the shared secret is not part of the identity object
+	assert.Equal(t, len(obj.(k8s.Secret).StringData), 0)
+
+	// base64
+	pipeline.EXPECT().GetHttp().Return(request(base64.StdEncoding.EncodeToString(signature), date))
+	_, err = h.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+
+	// wrong signature
+	pipeline.EXPECT().GetHttp().Return(request(hex.EncodeToString(make([]byte, 32)), date))
+	_, err = h.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the request signature is invalid")
+
+	// date out of the allowed clock skew
+	pipeline.EXPECT().GetHttp().Return(request(hex.EncodeToString(signature), time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)))
+	_, err = h.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the date of the request is out of the allowed clock skew")
+
+	// missing date
+	pipeline.EXPECT().GetHttp().Return(request(hex.EncodeToString(signature), ""))
+	_, err = h.Call(pipeline, context.TODO())
+	assert.Error(t, err, "invalid date header")
+
+	// missing signature
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{}})
+	_, err = h.Call(pipeline, context.TODO())
+	assert.ErrorContains(t, err, "credential not found")
+}
+
+func TestHMACCallWithDefaultSignedComponents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("app=webhooks")
+	client := mockK8sClient(newHMACTestSecret("github", "ns1", "s3cr3t"))
+	h := NewHMACIdentity("hmac", selector, "ns1", HMACAlgorithmSHA512, "", nil, 0, client, context.TODO())
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	emptyDigest := sha256.Sum256(nil)
+	sign := func(method, path string) string {
+		mac := hmac.New(sha512.New, []byte("s3cr3t"))
+		mac.Write(encodeHMACMessage([]string{method, "webhooks.io", path, date, "", "SHA-256=" + base64.StdEncoding.EncodeToString(emptyDigest[:])}))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	request := func(method, path, signature string) *envoy_auth.AttributeContext_HttpRequest {
+		return &envoy_auth.AttributeContext_HttpRequest{Method: method, Host: "webhooks.io", Path: path, Headers: map[string]string{"x-signature": signature, "date": date}}
+	}
+
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	pipeline.EXPECT().GetHttp().Return(request("GET", "/hooks", sign("GET", "/hooks")))
+	_, err := h.Call(pipeline, context.TODO())
+	assert.NilError(t, err)
+
+	// the signature of an empty-body request cannot be replayed against another method or path
+	pipeline.EXPECT().GetHttp().Return(request("DELETE", "/hooks/1", sign("GET", "/hooks")))
+	_, err = h.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the request signature is invalid")
+}
+
+func TestHMACCallWithUnsignedDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	selector, _ := k8s_labels.Parse("app=webhooks")
+	client := mockK8sClient(newHMACTestSecret("github", "ns1", "s3cr3t"))
+	h := NewHMACIdentity("hmac", selector, "ns1", "", "", []string{"body"}, 0, client, context.TODO())
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write(encodeHMACMessage([]string{"hello"}))
+
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Body: "hello", Headers: map[string]string{"x-signature": hex.EncodeToString(mac.Sum(nil))}})
+	_, err := h.Call(pipeline, context.TODO())
+	assert.Error(t, err, "the date of the request must be a signed component")
+}
+
+func TestEncodeHMACMessage(t *testing.T) {
+	assert.Equal(t, string(encodeHMACMessage([]string{"POST", "", "a\nb"})), "4:POST\n0:\n3:a\nb\n")
+	// components with the same concatenation produce different messages
+	assert.Check(t, string(encodeHMACMessage([]string{"a\nb", "c"})) != string(encodeHMACMessage([]string{"a", "b\nc"})))
+}
+
+func TestHMACAddAndRevokeK8sSecretBasedIdentity(t *testing.T) {
+	selector, _ := k8s_labels.Parse("app=webhooks")
+	h := NewHMACIdentity("hmac", selector, "ns1", "", "", nil, 0, mockK8sClient(), context.TODO())
+	assert.Equal(t, len(h.secrets), 0)
+
+	h.AddK8sSecretBasedIdentity(context.TODO(), *newHMACTestSecret("github", "ns1", "s3cr3t"))
+	h.AddK8sSecretBasedIdentity(context.TODO(), *newHMACTestSecret("stripe", "ns2", "an0th3r")) // out of scope
+	assert.Equal(t, len(h.secrets), 1)
+
+	h.AddK8sSecretBasedIdentity(context.TODO(), *newHMACTestSecret("github", "ns1", "")) // no longer a valid hmac secret
+	assert.Equal(t, len(h.secrets), 0)
+
+	h.AddK8sSecretBasedIdentity(context.TODO(), *newHMACTestSecret("github", "ns1", "s3cr3t"))
+	h.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "github"})
+	assert.Equal(t, len(h.secrets), 0)
+}
+
+func TestValidHMACSignedComponent(t *testing.T) {
+	assert.Check(t, ValidHMACSignedComponent("digest"))
+	assert.Check(t, ValidHMACSignedComponent("header:x-delivery"))
+	assert.Check(t, !ValidHMACSignedComponent("header:"))
+	assert.Check(t, !ValidHMACSignedComponent("query"))
+}
+
+func TestSanitizedSecret(t *testing.T) {
+	secret := k8s.Secret{
+		ObjectMeta: k8s_meta.ObjectMeta{
+			Name:        "github",
+			Namespace:   "ns1",
+			Labels:      map[string]string{"app": "webhooks"},
+			Annotations: map[string]string{"owner": "john", lastAppliedConfigAnnotation: `{"stringData":{"hmac_secret":"s3cr3t"}}`},
+		},
+		Data:       map[string][]byte{"hmac_secret": []byte("s3cr3t")},
+		StringData: map[string]string{"hmac_secret": "s3cr3t"},
+	}
+
+	sanitized := sanitizedSecret(secret)
+	assert.Equal(t, sanitized.Name, "github")
+	assert.Equal(t, sanitized.Namespace, "ns1")
+	assert.Equal(t, sanitized.Labels["app"], "webhooks")
+	assert.Equal(t, sanitized.Annotations["owner"], "john")
+	_, found := sanitized.Annotations[lastAppliedConfigAnnotation]
+	assert.Check(t, !found)
+	assert.Check(t, sanitized.Data == nil)
+	assert.Check(t, sanitized.StringData == nil)
+	_, found = secret.Annotations[lastAppliedConfigAnnotation] // the original secret is unchanged
+	assert.Check(t, found)
+}
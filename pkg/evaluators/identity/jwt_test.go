@@ -0,0 +1,73 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	gojson "encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang/mock/gomock"
+	"gopkg.in/square/go-jose.v2"
+	"gotest.tools/assert"
+)
+
+func TestNewJWTIdentityInvalidJWKS(t *testing.T) {
+	_, err := NewJWTIdentity([]byte(`not-a-jwks`), nil)
+	assert.Error(t, err, "invalid json web key set")
+
+	_, err = NewJWTIdentity([]byte(`{"keys":[]}`), nil)
+	assert.Error(t, err, "invalid json web key set")
+}
+
+func TestJWTCall(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks, _ := gojson.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key1.Public(), KeyID: "k1", Algorithm: "RS256", Use: "sig"}}})
+
+	evaluator, err := NewJWTIdentity(jwks, auth.NewAuthCredential("", "authorization_header"))
+	assert.NilError(t, err)
+	evaluator.Audiences = []string{"talker-api"}
+	evaluator.AllowedIssuers = []string{"https://issuer.internal"}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	call := func(token string) (interface{}, error) {
+		pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"authorization": "Bearer " + token}})
+		return evaluator.Call(pipeline, context.TODO())
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	obj, err := call(signTestTokenWithClaims(t, key1, "k1", fmt.Sprintf(`{"iss":"https://issuer.internal","aud":"talker-api","sub":"john","exp":%d}`, exp)))
+	assert.NilError(t, err)
+	assert.Equal(t, obj.(map[string]interface{})["sub"], "john")
+
+	// no kid
+	_, err = call(signTestTokenWithClaims(t, key1, "", fmt.Sprintf(`{"iss":"https://issuer.internal","aud":"talker-api","exp":%d}`, exp)))
+	assert.NilError(t, err)
+
+	// unknown kid
+	_, err = call(signTestTokenWithClaims(t, key2, "k2", fmt.Sprintf(`{"iss":"https://issuer.internal","aud":"talker-api","exp":%d}`, exp)))
+	assert.ErrorContains(t, err, "no matching key found in the json web key set")
+
+	// wrong key
+	_, err = call(signTestTokenWithClaims(t, key2, "k1", fmt.Sprintf(`{"iss":"https://issuer.internal","aud":"talker-api","exp":%d}`, exp)))
+	assert.ErrorContains(t, err, "failed to verify the signature of the token")
+
+	// expired
+	_, err = call(signTestTokenWithClaims(t, key1, "k1", fmt.Sprintf(`{"iss":"https://issuer.internal","aud":"talker-api","exp":%d}`, time.Now().Add(-time.Hour).Unix())))
+	assert.ErrorContains(t, err, "token is expired")
+
+	// issuer not allowed
+	_, err = call(signTestTokenWithClaims(t, key1, "k1", fmt.Sprintf(`{"iss":"https://other","aud":"talker-api","exp":%d}`, exp)))
+	assert.Error(t, err, "token issuer not allowed")
+}
@@ -78,7 +78,7 @@ func (m *MTLS) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{
 	if err != nil {
 		return nil, fmt.Errorf("invalid client certificate")
 	}
-	cert := decodeCertificate([]byte(pemEncodedCert))
+	cert := DecodeCertificate([]byte(pemEncodedCert))
 	if cert == nil {
 		return nil, fmt.Errorf("invalid client certificate")
 	}
@@ -166,10 +166,11 @@ func certificateFromSecret(secret k8s.Secret) (cert *x509.Certificate) {
 	} else {
 		return nil
 	}
-	return decodeCertificate(encodedCert)
+	return DecodeCertificate(encodedCert)
 }
 
-func decodeCertificate(encodedCert []byte) (cert *x509.Certificate) {
+// DecodeCertificate returns the last valid X.509 certificate of a PEM-encoded chain, or nil if there is none
+func DecodeCertificate(encodedCert []byte) (cert *x509.Certificate) {
 	for len(encodedCert) > 0 {
 		var block *pem.Block
 		block, encodedCert = pem.Decode(encodedCert)
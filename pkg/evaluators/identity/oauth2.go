@@ -3,6 +3,7 @@ package identity
 import (
 	"bytes"
 	gocontext "context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,7 +11,9 @@ import (
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/httpclient"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/singleflight"
 
 	"go.opentelemetry.io/otel"
 	otel_propagation "go.opentelemetry.io/otel/propagation"
@@ -23,6 +26,8 @@ type OAuth2 struct {
 	TokenTypeHint         string `yaml:"tokenTypeHint,omitempty"`
 	ClientID              string `yaml:"clientId"`
 	ClientSecret          string `yaml:"clientSecret"`
+
+	inflight singleflight.Group
 }
 
 func NewOAuth2Identity(tokenIntrospectionUrl string, tokenTypeHint string, clientID string, clientSecret string, creds auth.AuthCredentials) *OAuth2 {
@@ -34,11 +39,11 @@ func NewOAuth2Identity(tokenIntrospectionUrl string, tokenTypeHint string, clien
 	}
 
 	return &OAuth2{
-		creds,
-		tokenIntrospectionUrl,
-		tokenHint,
-		clientID,
-		clientSecret,
+		AuthCredentials:       creds,
+		TokenIntrospectionUrl: tokenIntrospectionUrl,
+		TokenTypeHint:         tokenHint,
+		ClientID:              clientID,
+		ClientSecret:          clientSecret,
 	}
 }
 
@@ -53,8 +58,24 @@ func (oauth *OAuth2) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (in
 		return nil, err
 	}
 
-	// introspect token
-	tokenIntrospectionURL, _ := url.Parse(oauth.TokenIntrospectionUrl)
+	return oauth.introspect(ctx, oauth.TokenIntrospectionUrl, accessToken)
+}
+
+// introspect requests the token introspection endpoint (RFC 7662) to verify the access token, returning the claims of
+// active tokens. Concurrent requests to introspect the same access token share a single call to the endpoint.
+func (oauth *OAuth2) introspect(ctx gocontext.Context, endpoint, accessToken string) (interface{}, error) {
+	tokenHash := sha256.Sum256([]byte(accessToken))
+	claims, err, _ := oauth.inflight.Do(endpoint+"#"+string(tokenHash[:]), func() (interface{}, error) {
+		return oauth.sendIntrospectionRequest(ctx, endpoint, accessToken)
+	})
+	return claims, err
+}
+
+func (oauth *OAuth2) sendIntrospectionRequest(ctx gocontext.Context, endpoint, accessToken string) (interface{}, error) {
+	tokenIntrospectionURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
 	tokenIntrospectionURL.User = url.UserPassword(oauth.ClientID, oauth.ClientSecret)
 
 	formData := url.Values{
@@ -73,7 +94,7 @@ func (oauth *OAuth2) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (in
 
 	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -3,7 +3,10 @@ package identity
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/httptest"
@@ -51,6 +54,43 @@ func TestOAuth2Call(t *testing.T) {
 	}
 }
 
+func TestOAuth2CallConcurrentIntrospection(t *testing.T) {
+	var hits int32
+	authServer := httptest.NewHttpServerMock(oauthServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/introspect": func() httptest.HttpServerMockResponse {
+			atomic.AddInt32(&hits, 1)
+			time.Sleep(500 * time.Millisecond)
+			return httptest.HttpServerMockResponse{Status: 200, Body: `{ "active": true }`}
+		},
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
+	authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("oauth-opaque-token", nil).AnyTimes()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetHttp().Return(nil).AnyTimes()
+
+	oauthEvaluator := NewOAuth2Identity(fmt.Sprintf("http://%v/introspect", oauthServerHost), "access_token", "client-id", "client-secret", authCredMock)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obj, err := oauthEvaluator.Call(pipelineMock, context.Background())
+			assert.NilError(t, err)
+			assert.Assert(t, obj.(map[string]interface{})["active"])
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, atomic.LoadInt32(&hits), int32(1))
+}
+
 func TestDefaultTokenTypeHint(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
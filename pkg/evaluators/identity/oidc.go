@@ -4,13 +4,22 @@ import (
 	gocontext "context"
 	"fmt"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/httpclient"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/lru"
+	"github.com/kuadrant/authorino/pkg/replay"
+	"github.com/kuadrant/authorino/pkg/revocation"
 	"github.com/kuadrant/authorino/pkg/workers"
 
 	goidc "github.com/coreos/go-oidc"
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang/groupcache/singleflight"
 )
 
 const (
@@ -18,13 +27,60 @@ const (
 	msg_oidcProviderConfigRefreshSuccess  = "openid connect configuration updated"
 	msg_oidcProviderConfigRefreshError    = "failed to discovery openid connect configuration"
 	msg_oidcProviderConfigRefreshDisabled = "auto-refresh of openid connect configuration disabled"
+	msg_oidcTokenAudienceNotAllowed       = "token audience not allowed"
+	msg_oidcTokenIssuerNotAllowed         = "token issuer not allowed"
+	msg_oidcSessionLoggedOut              = "the session has been logged out"
+	msg_oidcInvalidLogoutToken            = "invalid logout token"
+	msg_oidcLogoutTokenReplayed           = "the logout token has already been used"
+	msg_oidcLogoutReplayCheckError        = "failed to check the replay of the logout token"
+	msg_oidcIntrospectionEndpointMissing  = "missing token introspection endpoint"
+	msg_oidcProviderEndpointMissing       = "missing endpoint in the openid connect configuration"
+
+	backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+	oidcDiscoveryTimeout = 30 * time.Second
 )
 
+// DeferOIDCDiscovery skips the discovery of the OpenID Connect configuration when the evaluator is created, deferring it to the
+// first request to verify a token. Either way, the discovery is retried at request time for as long as it has not succeeded.
+var DeferOIDCDiscovery = false
+
+// JWKSCache caches the JWKS of the issuers, by `jwks_uri`, shared by all the OpenID Connect identity evaluators.
+// Key sets evicted from the cache are fetched again on the next verification of a token.
+var JWKSCache = lru.New("jwks", lru.Options{MaxEntries: 100})
+
+// supportedSigningAlgs are the JWS algorithms supported by the token verifier, in order of preference
+var supportedSigningAlgs = []string{
+	goidc.RS256, goidc.RS384, goidc.RS512,
+	goidc.ES256, goidc.ES384, goidc.ES512,
+	goidc.PS256, goidc.PS384, goidc.PS512,
+}
+
 type OIDC struct {
 	auth.AuthCredentials
-	Endpoint  string `yaml:"endpoint"`
-	provider  *goidc.Provider
-	refresher workers.Worker
+	Endpoint string `yaml:"endpoint"`
+	// Audiences, if not empty, requires the `aud` claim of the token to include at least one of the values
+	Audiences []string `yaml:"audiences,omitempty"`
+	// AllowedIssuers, if not empty, requires the `iss` claim of the token to be one of the values
+	AllowedIssuers []string `yaml:"allowedIssuers,omitempty"`
+	// DPoP, if not nil, requires the access tokens to be sender-constrained with DPoP proofs
+	DPoP *DPoP `yaml:"dpop,omitempty"`
+	// Introspection, if not nil, verifies opaque (non-JWS) access tokens with the token introspection endpoint of the issuer
+	Introspection *OAuth2 `yaml:"introspection,omitempty"`
+	provider      *goidc.Provider
+	refresher     workers.Worker
+
+	// jwksURI locates the JWKS of the issuer in the JWKSCache. The cached key set survives refreshes of the OpenID
+	// Connect configuration as long as the `jwks_uri` does not change. Signatures of tokens issued with unknown key ids
+	// trigger a single re-fetch of the JWKS, shared by all concurrent verifications.
+	jwksURI     string
+	signingAlgs []string
+
+	// introspectionEndpoint is the `introspection_endpoint` announced in the OpenID Connect configuration
+	introspectionEndpoint string
+
+	discovery singleflight.Group
+	mu        sync.RWMutex
 }
 
 func NewOIDC(endpoint string, creds auth.AuthCredentials, ttl int, ctx gocontext.Context) *OIDC {
@@ -33,39 +89,131 @@ func NewOIDC(endpoint string, creds auth.AuthCredentials, ttl int, ctx gocontext
 		Endpoint:        endpoint,
 	}
 	ctxWithLogger := log.IntoContext(ctx, log.FromContext(ctx).WithName("oidc"))
-	_ = oidc.getProvider(ctxWithLogger, false)
+	if !DeferOIDCDiscovery {
+		_ = oidc.getProvider(ctxWithLogger, false)
+	}
 	oidc.configureProviderRefresh(ttl, ctxWithLogger)
 	return oidc
 }
 
 func (oidc *OIDC) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
 	// retrieve access token
-	accessToken, err := oidc.GetCredentialsFromReq(pipeline.GetRequest().GetAttributes().GetRequest().GetHttp())
+	httpReq := pipeline.GetRequest().GetAttributes().GetRequest().GetHttp()
+	accessToken, err := oidc.GetCredentialsFromReq(httpReq)
 	if err != nil {
 		return nil, err
 	}
 
+	// opaque tokens are introspected instead, if enabled
+	if oidc.Introspection != nil && !isJWS(accessToken) {
+		return oidc.introspectToken(ctx, httpReq, accessToken)
+	}
+
 	// verify jwt and extract claims
 	var claims interface{}
-	if _, err := oidc.decodeAndVerifyToken(accessToken, log.IntoContext(ctx, log.FromContext(ctx).WithName("oidc")), &claims); err != nil {
+	idToken, err := oidc.decodeAndVerifyToken(accessToken, log.IntoContext(ctx, log.FromContext(ctx).WithName("oidc")), &claims)
+	if err != nil {
 		return nil, err
-	} else {
-		return claims, nil
 	}
+
+	// reject tokens of sessions logged out at the issuer
+	sessionID, _ := claimValue(claims, "sid").(string)
+	if revocation.SharedSessions.LoggedOut(idToken.Issuer, idToken.Subject, sessionID, idToken.IssuedAt) {
+		return nil, fmt.Errorf(msg_oidcSessionLoggedOut)
+	}
+
+	// verify the proof of possession of sender-constrained tokens
+	if oidc.DPoP != nil {
+		if err := oidc.DPoP.Verify(ctx, httpReq, accessToken, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
 }
 
-func (oidc *OIDC) getProvider(ctx gocontext.Context, force bool) *goidc.Provider {
-	if oidc.provider == nil || force {
-		endpoint := oidc.Endpoint
-		if provider, err := goidc.NewProvider(gocontext.TODO(), endpoint); err != nil {
-			log.FromContext(ctx).Error(err, msg_oidcProviderConfigRefreshError, "endpoint", endpoint)
-		} else {
-			log.FromContext(ctx).V(1).Info(msg_oidcProviderConfigRefreshSuccess, "endpoint", endpoint)
-			oidc.provider = provider
+func (oidc *OIDC) introspectToken(ctx gocontext.Context, httpReq *envoy_auth.AttributeContext_HttpRequest, accessToken string) (interface{}, error) {
+	endpoint := oidc.Introspection.TokenIntrospectionUrl
+	if endpoint == "" {
+		if provider := oidc.getProvider(log.IntoContext(ctx, log.FromContext(ctx).WithName("oidc")), false); provider == nil {
+			return nil, fmt.Errorf(msg_oidcProviderConfigMissingError)
 		}
+		oidc.mu.RLock()
+		endpoint = oidc.introspectionEndpoint
+		oidc.mu.RUnlock()
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf(msg_oidcIntrospectionEndpointMissing)
+	}
+
+	claims, err := oidc.Introspection.introspect(ctx, endpoint, accessToken)
+	if err != nil {
+		return nil, err
 	}
 
-	return oidc.provider
+	if oidc.DPoP != nil {
+		if err := oidc.DPoP.Verify(ctx, httpReq, accessToken, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// Discovered tells whether the OpenID Connect configuration of the issuer has been discovered
+func (oidc *OIDC) Discovered() bool {
+	oidc.mu.RLock()
+	defer oidc.mu.RUnlock()
+	return oidc.provider != nil
+}
+
+func (oidc *OIDC) getProvider(ctx gocontext.Context, force bool) *goidc.Provider {
+	oidc.mu.RLock()
+	provider := oidc.provider
+	oidc.mu.RUnlock()
+
+	if provider == nil || force {
+		// concurrent discoveries (e.g. while the issuer is unreachable) are collapsed into a single request
+		_, _ = oidc.discovery.Do(oidc.Endpoint, func() (interface{}, error) {
+			// the discovery is shared by all the concurrent callers, so it must not be canceled with the context of the one that triggered it
+			discoveryCtx, cancel := gocontext.WithTimeout(gocontext.Background(), oidcDiscoveryTimeout)
+			defer cancel()
+			oidc.discover(log.IntoContext(discoveryCtx, log.FromContext(ctx)))
+			return nil, nil
+		})
+
+		oidc.mu.RLock()
+		provider = oidc.provider
+		oidc.mu.RUnlock()
+	}
+
+	return provider
+}
+
+func (oidc *OIDC) discover(ctx gocontext.Context) {
+	endpoint := oidc.Endpoint
+	provider, err := goidc.NewProvider(goidc.ClientContext(ctx, httpclient.Client()), endpoint)
+	if err != nil {
+		log.FromContext(ctx).Error(err, msg_oidcProviderConfigRefreshError, "endpoint", endpoint)
+		return
+	}
+
+	var providerClaims struct {
+		JWKSURI               string   `json:"jwks_uri"`
+		SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+		IntrospectionEndpoint string   `json:"introspection_endpoint"`
+	}
+	_ = provider.Claims(&providerClaims)
+
+	oidc.mu.Lock()
+	defer oidc.mu.Unlock()
+
+	oidc.jwksURI = providerClaims.JWKSURI
+	oidc.signingAlgs = filterSigningAlgs(providerClaims.SigningAlgs)
+	oidc.introspectionEndpoint = providerClaims.IntrospectionEndpoint
+	oidc.provider = provider
+
+	log.FromContext(ctx).V(1).Info(msg_oidcProviderConfigRefreshSuccess, "endpoint", endpoint)
 }
 
 func (oidc *OIDC) decodeAndVerifyToken(accessToken string, ctx gocontext.Context, claims *interface{}) (*goidc.IDToken, error) {
@@ -88,29 +236,100 @@ func (oidc *OIDC) decodeAndVerifyToken(accessToken string, ctx gocontext.Context
 }
 
 func (oidc *OIDC) verifyToken(accessToken string, ctx gocontext.Context) (*goidc.IDToken, error) {
-	provider := oidc.getProvider(ctx, false)
-
-	if provider == nil {
+	if provider := oidc.getProvider(ctx, false); provider == nil {
 		return nil, fmt.Errorf(msg_oidcProviderConfigMissingError)
 	}
 
-	tokenVerifierConfig := &goidc.Config{SkipClientIDCheck: true, SkipIssuerCheck: true}
-	if idToken, err := provider.Verifier(tokenVerifierConfig).Verify(ctx, accessToken); err != nil {
+	oidc.mu.RLock()
+	jwksURI, signingAlgs := oidc.jwksURI, oidc.signingAlgs
+	oidc.mu.RUnlock()
+
+	keySet := keySetFor(jwksURI)
+	tokenVerifierConfig := &goidc.Config{SkipClientIDCheck: true, SkipIssuerCheck: true, SupportedSigningAlgs: signingAlgs}
+	idToken, err := goidc.NewVerifier(oidc.Endpoint, keySet, tokenVerifierConfig).Verify(ctx, accessToken)
+	if err != nil {
 		return nil, err
-	} else {
-		return idToken, nil
 	}
+
+	if err := verifyIssuerAndAudiences(idToken, oidc.AllowedIssuers, oidc.Audiences); err != nil {
+		return nil, err
+	}
+
+	return idToken, nil
+}
+
+// keySetFor returns the key set of a JWKS from the cache, creating it if it is not cached.
+// The key set outlives the request that created it.
+func keySetFor(jwksURI string) goidc.KeySet {
+	if keySet, found := JWKSCache.Get(jwksURI); found {
+		return keySet.(goidc.KeySet)
+	}
+	keySet := goidc.NewRemoteKeySet(goidc.ClientContext(gocontext.TODO(), httpclient.Client()), jwksURI)
+	JWKSCache.Add(jwksURI, keySet)
+	return keySet
+}
+
+// BackChannelLogout verifies a logout token sent by the issuer (OpenID Connect Back-Channel Logout 1.0) and records
+// the logout of the session, or of all the sessions of the subject if the token has no session id.
+// The logout token must be issued for one of the audiences of the identity source, and cannot be used more than once.
+func (oidc *OIDC) BackChannelLogout(ctx gocontext.Context, logoutToken string) error {
+	idToken, err := oidc.verifyToken(logoutToken, ctx)
+	if err != nil {
+		return err
+	}
+
+	var claims struct {
+		SessionID string                 `json:"sid"`
+		JTI       string                 `json:"jti"`
+		Events    map[string]interface{} `json:"events"`
+		Nonce     *string                `json:"nonce"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return err
+	}
+
+	if _, isLogout := claims.Events[backChannelLogoutEvent]; !isLogout || claims.Nonce != nil || claims.JTI == "" || (idToken.Subject == "" && claims.SessionID == "") {
+		return fmt.Errorf(msg_oidcInvalidLogoutToken)
+	}
+	// the issuer of the token must be the one of the identity source
+	if idToken.Issuer != oidc.Endpoint {
+		return fmt.Errorf(msg_oidcTokenIssuerNotAllowed)
+	}
+	// the audience of the token must be the client, i.e. one of the audiences of the identity source (checked when verifying the token)
+	if len(oidc.Audiences) == 0 {
+		return fmt.Errorf(msg_oidcTokenAudienceNotAllowed)
+	}
+
+	// replay – the ids of the logout tokens are remembered in the shared store of one-time values until the tokens expire
+	unused, err := replay.SharedStore.Use(ctx, "logout/"+idToken.Issuer+"/"+claims.JTI, time.Until(idToken.Expiry))
+	if err != nil {
+		return fmt.Errorf(msg_oidcLogoutReplayCheckError)
+	}
+	if !unused {
+		return fmt.Errorf(msg_oidcLogoutTokenReplayed)
+	}
+
+	revocation.SharedSessions.Logout(idToken.Issuer, idToken.Subject, claims.SessionID)
+	log.FromContext(ctx).V(1).Info("session logged out", "issuer", idToken.Issuer, "sub", idToken.Subject, "sid", claims.SessionID)
+	return nil
 }
 
 func (oidc *OIDC) GetURL(name string, ctx gocontext.Context) (*url.URL, error) {
-	var providerClaims map[string]interface{}
-	_ = oidc.getProvider(ctx, false).Claims(&providerClaims)
+	provider := oidc.getProvider(ctx, false)
+	if provider == nil {
+		return nil, fmt.Errorf(msg_oidcProviderConfigMissingError)
+	}
 
-	if endpoint, err := url.Parse(providerClaims[name].(string)); err != nil {
+	var providerClaims map[string]interface{}
+	if err := provider.Claims(&providerClaims); err != nil {
 		return nil, err
-	} else {
-		return endpoint, nil
 	}
+
+	endpoint, ok := providerClaims[name].(string)
+	if !ok || endpoint == "" {
+		return nil, fmt.Errorf("%s: %s", msg_oidcProviderEndpointMissing, name)
+	}
+	return url.Parse(endpoint)
 }
 
 func (oidc *OIDC) configureProviderRefresh(ttl int, ctx gocontext.Context) {
@@ -132,3 +351,54 @@ func (oidc *OIDC) Clean(ctx gocontext.Context) error {
 	}
 	return oidc.refresher.Stop()
 }
+
+// verifyIssuerAndAudiences checks the `iss` and `aud` claims of a verified token against the allowed values, if any
+func verifyIssuerAndAudiences(idToken *goidc.IDToken, allowedIssuers, audiences []string) error {
+	if len(allowedIssuers) > 0 && !containsAny(allowedIssuers, idToken.Issuer) {
+		return fmt.Errorf(msg_oidcTokenIssuerNotAllowed)
+	}
+	if len(audiences) > 0 && !containsAny(audiences, idToken.Audience...) {
+		return fmt.Errorf(msg_oidcTokenAudienceNotAllowed)
+	}
+	return nil
+}
+
+// isJWS tells whether a token is in the JWS compact serialization (i.e. a signed JWT), as opposed to opaque tokens
+func isJWS(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// claimValue returns the value of a top-level claim of a decoded token
+func claimValue(claims interface{}, name string) interface{} {
+	if c, ok := claims.(map[string]interface{}); ok {
+		return c[name]
+	}
+	return nil
+}
+
+// containsAny tells whether any of the values is in the list
+func containsAny(list []string, values ...string) bool {
+	for _, value := range values {
+		for _, item := range list {
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterSigningAlgs returns the signing algorithms announced by the issuer that are supported by the token verifier.
+// Returns nil if none is supported, in which case the verifier defaults to RS256.
+func filterSigningAlgs(algs []string) []string {
+	var filtered []string
+	for _, alg := range algs {
+		for _, supported := range supportedSigningAlgs {
+			if alg == supported {
+				filtered = append(filtered, alg)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -2,15 +2,23 @@ package identity
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	gojson "encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
+	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/httptest"
+	"github.com/kuadrant/authorino/pkg/replay"
+	"github.com/kuadrant/authorino/pkg/revocation"
 	mock_workers "github.com/kuadrant/authorino/pkg/workers/mocks"
 
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	"github.com/golang/mock/gomock"
+	"gopkg.in/square/go-jose.v2"
 	"gotest.tools/assert"
 )
 
@@ -73,6 +81,96 @@ func TestOidcVerifyTokenServerInternalError(t *testing.T) {
 	assert.Error(t, err, "missing openid connect configuration")
 }
 
+func TestOidcDeferredDiscovery(t *testing.T) {
+	count := 0
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/.well-known/openid-configuration": func() httptest.HttpServerMockResponse {
+			count += 1
+			return oidcServerMockResponse(count)
+		},
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
+
+	DeferOIDCDiscovery = true
+	defer func() { DeferOIDCDiscovery = false }()
+
+	evaluator := NewOIDC(fmt.Sprintf("http://%v", oidcServerHost), authCredMock, 0, context.TODO())
+	defer evaluator.Clean(context.Background())
+
+	assert.Equal(t, 0, count)
+	assert.Check(t, !evaluator.Discovered())
+
+	_, _ = evaluator.verifyToken("token", context.TODO())
+
+	assert.Equal(t, 1, count)
+	assert.Check(t, evaluator.Discovered())
+}
+
+func TestOidcDiscoveryNotCanceledWithTheRequest(t *testing.T) {
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/.well-known/openid-configuration": func() httptest.HttpServerMockResponse { return oidcServerMockResponse(1) },
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
+
+	DeferOIDCDiscovery = true
+	defer func() { DeferOIDCDiscovery = false }()
+
+	evaluator := NewOIDC(fmt.Sprintf("http://%v", oidcServerHost), authCredMock, 0, context.TODO())
+	defer evaluator.Clean(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Check(t, evaluator.getProvider(ctx, false) != nil)
+	assert.Check(t, evaluator.Discovered())
+}
+
+func TestOidcGetURL(t *testing.T) {
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/.well-known/openid-configuration": func() httptest.HttpServerMockResponse { return oidcServerMockResponse(1) },
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
+
+	evaluator := NewOIDC(fmt.Sprintf("http://%v", oidcServerHost), authCredMock, 0, context.TODO())
+	defer evaluator.Clean(context.Background())
+
+	endpoint, err := evaluator.GetURL("authorization_endpoint", context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, endpoint.String(), fmt.Sprintf("http://%v/auth?count=1", oidcServerHost))
+
+	endpoint, err = evaluator.GetURL("userinfo_endpoint", context.TODO())
+	assert.Check(t, endpoint == nil)
+	assert.Error(t, err, "missing endpoint in the openid connect configuration: userinfo_endpoint")
+}
+
+func TestOidcGetURLServerUnknownHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
+
+	evaluator := NewOIDC("http://unreachable-server", authCredMock, 0, context.TODO())
+	endpoint, err := evaluator.GetURL("userinfo_endpoint", context.TODO())
+
+	assert.Check(t, endpoint == nil)
+	assert.Error(t, err, "missing openid connect configuration")
+}
+
 func TestOidcProviderRefreshDisabled(t *testing.T) {
 	count := 0
 	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
@@ -142,3 +240,245 @@ func TestOidcProviderRefreshClean(t *testing.T) {
 	err := evaluator.Clean(context.Background())
 	assert.NilError(t, err)
 }
+
+func TestOidcVerifyTokenWithKeyRotation(t *testing.T) {
+	key1, _ := rsa.GenerateKey(rand.Reader, 2048)
+	key2, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key1.Public(), KeyID: "k1", Algorithm: "RS256", Use: "sig"}}}
+	jwksCount := 0
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/.well-known/openid-configuration": func() httptest.HttpServerMockResponse {
+			return httptest.HttpServerMockResponse{
+				Status:  200,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    fmt.Sprintf(`{ "issuer": "http://%v", "jwks_uri": "http://%v/jwks" }`, oidcServerHost, oidcServerHost),
+			}
+		},
+		"/jwks": func() httptest.HttpServerMockResponse {
+			jwksCount += 1
+			body, _ := gojson.Marshal(jwks)
+			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: string(body)}
+		},
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	evaluator := NewOIDC(fmt.Sprintf("http://%v", oidcServerHost), mock_auth.NewMockAuthCredentials(ctrl), 0, context.TODO())
+
+	_, err := evaluator.verifyToken(signTestToken(t, key1, "k1"), context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, jwksCount, 1)
+
+	// the jwks is kept across refreshes of the openid connect configuration
+	_ = evaluator.getProvider(context.TODO(), true)
+	_, err = evaluator.verifyToken(signTestToken(t, key1, "k1"), context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, jwksCount, 1)
+
+	// unknown kid triggers a re-fetch of the jwks
+	jwks.Keys = append(jwks.Keys, jose.JSONWebKey{Key: key2.Public(), KeyID: "k2", Algorithm: "RS256", Use: "sig"})
+	_, err = evaluator.verifyToken(signTestToken(t, key2, "k2"), context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, jwksCount, 2)
+}
+
+func TestOidcVerifyTokenWithAudiencesAndAllowedIssuers(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "k1", Algorithm: "RS256", Use: "sig"}}}
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/.well-known/openid-configuration": func() httptest.HttpServerMockResponse {
+			return httptest.HttpServerMockResponse{
+				Status:  200,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    fmt.Sprintf(`{ "issuer": "http://%v", "jwks_uri": "http://%v/jwks" }`, oidcServerHost, oidcServerHost),
+			}
+		},
+		"/jwks": func() httptest.HttpServerMockResponse {
+			body, _ := gojson.Marshal(jwks)
+			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: string(body)}
+		},
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	evaluator := NewOIDC(fmt.Sprintf("http://%v", oidcServerHost), mock_auth.NewMockAuthCredentials(ctrl), 0, context.TODO())
+	evaluator.Audiences = []string{"talker-api", "echo-api"}
+	evaluator.AllowedIssuers = []string{fmt.Sprintf("http://%v", oidcServerHost)}
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	_, err := evaluator.verifyToken(signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":["other","echo-api"],"exp":%d}`, oidcServerHost, exp)), context.TODO())
+	assert.NilError(t, err)
+
+	_, err = evaluator.verifyToken(signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"other","exp":%d}`, oidcServerHost, exp)), context.TODO())
+	assert.Error(t, err, "token audience not allowed")
+
+	_, err = evaluator.verifyToken(signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","exp":%d}`, oidcServerHost, exp)), context.TODO())
+	assert.Error(t, err, "token audience not allowed")
+
+	_, err = evaluator.verifyToken(signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://other","aud":"talker-api","exp":%d}`, exp)), context.TODO())
+	assert.Error(t, err, "token issuer not allowed")
+}
+
+func TestOidcBackChannelLogout(t *testing.T) {
+	revocation.SharedSessions = revocation.NewSessions()
+	replay.SharedStore = replay.NewMemoryStore(replay.DefaultMemoryStoreMaxEntries)
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "k1", Algorithm: "RS256", Use: "sig"}}}
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/.well-known/openid-configuration": func() httptest.HttpServerMockResponse {
+			return httptest.HttpServerMockResponse{
+				Status:  200,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    fmt.Sprintf(`{ "issuer": "http://%v", "jwks_uri": "http://%v/jwks" }`, oidcServerHost, oidcServerHost),
+			}
+		},
+		"/jwks": func() httptest.HttpServerMockResponse {
+			body, _ := gojson.Marshal(jwks)
+			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: string(body)}
+		},
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	evaluator := NewOIDC(fmt.Sprintf("http://%v", oidcServerHost), auth.NewAuthCredential("", "authorization_header"), 0, context.TODO())
+	evaluator.Audiences = []string{"my-client"}
+
+	call := func(token string) error {
+		pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"authorization": "Bearer " + token}}}}})
+		_, err := evaluator.Call(pipeline, context.TODO())
+		return err
+	}
+
+	now := time.Now()
+	iat, exp := now.Add(-time.Minute).Unix(), now.Add(time.Hour).Unix()
+	accessToken1 := signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","sub":"john","sid":"s1","iat":%d,"exp":%d}`, oidcServerHost, iat, exp))
+	accessToken2 := signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","sub":"john","sid":"s2","iat":%d,"exp":%d}`, oidcServerHost, iat, exp))
+	assert.NilError(t, call(accessToken1))
+
+	// invalid logout tokens
+	err := evaluator.BackChannelLogout(context.TODO(), signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","jti":"l0","sid":"s1","exp":%d}`, oidcServerHost, exp)))
+	assert.Error(t, err, "invalid logout token")
+	err = evaluator.BackChannelLogout(context.TODO(), signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","jti":"l0","sid":"s1","nonce":"n","events":{"%s":{}},"exp":%d}`, oidcServerHost, backChannelLogoutEvent, exp)))
+	assert.Error(t, err, "invalid logout token")
+	err = evaluator.BackChannelLogout(context.TODO(), signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","sid":"s1","events":{"%s":{}},"exp":%d}`, oidcServerHost, backChannelLogoutEvent, exp)))
+	assert.Error(t, err, "invalid logout token")
+	err = evaluator.BackChannelLogout(context.TODO(), signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"other-client","jti":"l0","sid":"s1","events":{"%s":{}},"exp":%d}`, oidcServerHost, backChannelLogoutEvent, exp)))
+	assert.Error(t, err, "token audience not allowed")
+	assert.NilError(t, call(accessToken1))
+
+	// session logout
+	logoutToken := signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","jti":"l1","sub":"john","sid":"s1","events":{"%s":{}},"exp":%d}`, oidcServerHost, backChannelLogoutEvent, exp))
+	err = evaluator.BackChannelLogout(context.TODO(), logoutToken)
+	assert.NilError(t, err)
+	err = evaluator.BackChannelLogout(context.TODO(), logoutToken)
+	assert.Error(t, err, "the logout token has already been used")
+	assert.Error(t, call(accessToken1), "the session has been logged out")
+	assert.NilError(t, call(accessToken2))
+
+	// subject logout
+	err = evaluator.BackChannelLogout(context.TODO(), signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","jti":"l2","sub":"john","events":{"%s":{}},"exp":%d}`, oidcServerHost, backChannelLogoutEvent, exp)))
+	assert.NilError(t, err)
+	assert.Error(t, call(accessToken2), "the session has been logged out")
+
+	// new login
+	accessToken3 := signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","sub":"john","sid":"s3","iat":%d,"exp":%d}`, oidcServerHost, now.Add(time.Minute).Unix(), exp))
+	assert.NilError(t, call(accessToken3))
+
+	// no audiences to validate the logout tokens against
+	evaluator.Audiences = nil
+	err = evaluator.BackChannelLogout(context.TODO(), signTestTokenWithClaims(t, key, "k1", fmt.Sprintf(`{"iss":"http://%v","aud":"my-client","jti":"l3","sub":"john","events":{"%s":{}},"exp":%d}`, oidcServerHost, backChannelLogoutEvent, exp)))
+	assert.Error(t, err, "token audience not allowed")
+	assert.NilError(t, call(accessToken3))
+}
+
+func TestOidcIntrospectionFallback(t *testing.T) {
+	revocation.SharedSessions = revocation.NewSessions()
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "k1", Algorithm: "RS256", Use: "sig"}}}
+	introspectionCount := 0
+	authServer := httptest.NewHttpServerMock(oidcServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/.well-known/openid-configuration": func() httptest.HttpServerMockResponse {
+			return httptest.HttpServerMockResponse{
+				Status:  200,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    fmt.Sprintf(`{ "issuer": "http://%v", "jwks_uri": "http://%v/jwks", "introspection_endpoint": "http://%v/introspect" }`, oidcServerHost, oidcServerHost, oidcServerHost),
+			}
+		},
+		"/jwks": func() httptest.HttpServerMockResponse {
+			body, _ := gojson.Marshal(jwks)
+			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: string(body)}
+		},
+		"/introspect": func() httptest.HttpServerMockResponse {
+			introspectionCount += 1
+			return httptest.HttpServerMockResponse{Status: 200, Body: `{ "active": true, "sub": "jane" }`}
+		},
+	})
+	defer authServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	creds := auth.NewAuthCredential("", "authorization_header")
+	evaluator := NewOIDC(fmt.Sprintf("http://%v", oidcServerHost), creds, 0, context.TODO())
+
+	call := func(token string) (interface{}, error) {
+		pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"authorization": "Bearer " + token}}}}})
+		return evaluator.Call(pipeline, context.TODO())
+	}
+
+	// introspection disabled
+	_, err := call("opaque-token")
+	assert.Check(t, err != nil)
+	assert.Equal(t, introspectionCount, 0)
+
+	evaluator.Introspection = NewOAuth2Identity("", "", "client-id", "client-secret", creds)
+
+	// opaque token
+	obj, err := call("opaque-token")
+	assert.NilError(t, err)
+	assert.Equal(t, claimValue(obj, "sub"), "jane")
+	assert.Equal(t, introspectionCount, 1)
+
+	// jwt
+	obj, err = call(signTestToken(t, key, "k1"))
+	assert.NilError(t, err)
+	assert.Equal(t, claimValue(obj, "sub"), "john")
+	assert.Equal(t, introspectionCount, 1)
+}
+
+func TestIsJWS(t *testing.T) {
+	assert.Check(t, isJWS("eyJhbGciOiJSUzI1NiJ9.eyJzdWIiOiJqb2huIn0.c2ln"))
+	assert.Check(t, !isJWS("opaque-token"))
+	assert.Check(t, !isJWS("a.b.c.d.e"))
+}
+
+func TestFilterSigningAlgs(t *testing.T) {
+	assert.DeepEqual(t, filterSigningAlgs([]string{"HS256", "RS256", "ES384", "none"}), []string{"RS256", "ES384"})
+	assert.Check(t, filterSigningAlgs([]string{"HS256"}) == nil)
+}
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	return signTestTokenWithClaims(t, key, kid, fmt.Sprintf(`{"iss":"http://%v","sub":"john","exp":%d}`, oidcServerHost, time.Now().Add(time.Hour).Unix()))
+}
+
+func signTestTokenWithClaims(t *testing.T, key *rsa.PrivateKey, kid string, payload string) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: kid}}, nil)
+	assert.NilError(t, err)
+	jws, err := signer.Sign([]byte(payload))
+	assert.NilError(t, err)
+	token, err := jws.CompactSerialize()
+	assert.NilError(t, err)
+	return token
+}
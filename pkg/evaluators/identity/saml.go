@@ -0,0 +1,422 @@
+package identity
+
+import (
+	gocontext "context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/httpclient"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/replay"
+	"github.com/kuadrant/authorino/pkg/utils"
+	"github.com/kuadrant/authorino/pkg/workers"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	samlAssertionNS = "urn:oasis:names:tc:SAML:2.0:assertion"
+	samlProtocolNS  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlSuccess     = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	xmlDSigNS       = "http://www.w3.org/2000/09/xmldsig#"
+
+	msg_samlInvalidMetadataError  = "invalid saml idp metadata"
+	msg_samlMetadataFetchError    = "failed to fetch the saml idp metadata"
+	msg_samlMetadataRefreshed     = "saml idp metadata updated"
+	msg_samlInvalidResponseError  = "invalid saml response"
+	msg_samlUnsuccessfulResponse  = "the saml response is not successful"
+	msg_samlUnsignedAssertion     = "the saml assertion is not signed"
+	msg_samlInvalidSignature      = "invalid signature of the saml assertion"
+	msg_samlIssuerNotAllowed      = "the issuer of the saml assertion is not the idp"
+	msg_samlAudienceNotAllowed    = "the audience of the saml assertion is not allowed"
+	msg_samlAssertionNotYetValid  = "the saml assertion is not yet valid"
+	msg_samlAssertionExpired      = "the saml assertion has expired"
+	msg_samlAssertionMissingField = "the saml assertion does not identify the subject"
+	msg_samlAssertionNoExpiry     = "the saml assertion does not expire"
+	msg_samlAssertionReplayed     = "the saml assertion has already been used"
+	msg_samlReplayCheckError      = "failed to check the replay of the saml assertion"
+)
+
+// SAML verifies SAML 2.0 responses and assertions (base64-encoded, as in the HTTP-POST binding) signed by a trusted
+// identity provider (IdP), whose entity ID and signing certificates are read from its SAML metadata
+type SAML struct {
+	auth.AuthCredentials
+	MetadataUrl  string   `yaml:"metadataUrl,omitempty"`
+	Audiences    []string `yaml:"audiences"`
+	MaxClockSkew int      `yaml:"maxClockSkew"`
+
+	entityID  string
+	certs     []*x509.Certificate
+	refresher workers.Worker
+	mu        sync.RWMutex
+}
+
+// SAMLIdentityObject is the identity object resolved out of a valid SAML assertion
+type SAMLIdentityObject struct {
+	Issuer       string                 `json:"issuer"`
+	Subject      string                 `json:"subject"`
+	NameIDFormat string                 `json:"nameIdFormat,omitempty"`
+	SessionIndex string                 `json:"sessionIndex,omitempty"`
+	Attributes   map[string]interface{} `json:"attributes"`
+}
+
+type samlEntityDescriptor struct {
+	XMLName           xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID          string   `xml:"entityID,attr"`
+	IDPSSODescriptors []struct {
+		KeyDescriptors []struct {
+			Use          string   `xml:"use,attr"`
+			Certificates []string `xml:"KeyInfo>X509Data>X509Certificate"`
+		} `xml:"KeyDescriptor"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+// NewSAMLIdentity creates a SAML identity verifier. If a metadata URL is provided, the metadata of the IdP is fetched
+// from the URL and refreshed every ttl seconds; otherwise, the static metadata is used.
+func NewSAMLIdentity(metadata []byte, metadataUrl string, ttl int, audiences []string, maxClockSkew int, creds auth.AuthCredentials, ctx gocontext.Context) (*SAML, error) {
+	s := &SAML{
+		AuthCredentials: creds,
+		MetadataUrl:     metadataUrl,
+		Audiences:       audiences,
+		MaxClockSkew:    maxClockSkew,
+	}
+
+	if metadataUrl != "" {
+		var err error
+		if metadata, err = fetchSAMLMetadata(ctx, metadataUrl); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.setMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	if metadataUrl != "" {
+		ctxWithLogger := log.IntoContext(ctx, log.FromContext(ctx).WithName("saml"))
+		s.refresher, _ = workers.StartWorker(ctxWithLogger, ttl, func() {
+			s.refreshMetadata(ctxWithLogger)
+		})
+	}
+
+	return s, nil
+}
+
+func (s *SAML) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	encoded, err := s.GetCredentialsFromReq(pipeline.GetHttp())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf(msg_samlInvalidResponseError)
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil || doc.Root() == nil {
+		return nil, fmt.Errorf(msg_samlInvalidResponseError)
+	}
+
+	assertion, err := s.verifiedAssertion(doc.Root())
+	if err != nil {
+		return nil, err
+	}
+	return s.identityObject(ctx, assertion, time.Now())
+}
+
+// Clean ensures the goroutine started to refresh the metadata of the IdP is cleaned up
+func (s *SAML) Clean(_ gocontext.Context) error {
+	if s.refresher == nil {
+		return nil
+	}
+	return s.refresher.Stop()
+}
+
+// verifiedAssertion returns the assertion of a SAML response (or the assertion itself, if supplied alone) as covered
+// by a valid signature of the IdP, either of the response or of the assertion.
+// Only the elements returned by the validation of the signature are read, to prevent signature wrapping attacks.
+func (s *SAML) verifiedAssertion(root *etree.Element) (*etree.Element, error) {
+	switch {
+	case isSAMLElement(root, samlProtocolNS, "Response"):
+		response := root
+		responseSigned := isSAMLElementSigned(response)
+		if responseSigned {
+			var err error
+			if response, err = s.validateSignature(response); err != nil {
+				return nil, err
+			}
+		}
+		if status := samlChild(response, samlProtocolNS, "Status"); status != nil {
+			if code := samlChild(status, samlProtocolNS, "StatusCode"); code == nil || code.SelectAttrValue("Value", "") != samlSuccess {
+				return nil, fmt.Errorf(msg_samlUnsuccessfulResponse)
+			}
+		}
+		assertions := samlChildren(response, samlAssertionNS, "Assertion")
+		if len(assertions) != 1 {
+			return nil, fmt.Errorf(msg_samlInvalidResponseError)
+		}
+		if responseSigned {
+			return assertions[0], nil
+		}
+		return s.validateSignature(assertions[0])
+
+	case isSAMLElement(root, samlAssertionNS, "Assertion"):
+		return s.validateSignature(root)
+
+	default:
+		return nil, fmt.Errorf(msg_samlInvalidResponseError)
+	}
+}
+
+func (s *SAML) validateSignature(el *etree.Element) (*etree.Element, error) {
+	if !isSAMLElementSigned(el) {
+		return nil, fmt.Errorf(msg_samlUnsignedAssertion)
+	}
+
+	s.mu.RLock()
+	certs := s.certs
+	s.mu.RUnlock()
+
+	validated, err := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs}).Validate(el)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", msg_samlInvalidSignature, err)
+	}
+	return validated, nil
+}
+
+// identityObject checks the issuer, the conditions, the subject confirmation and the one-time use of a verified assertion,
+// and maps the assertion into the identity object. Attributes with a single value map to the value; otherwise, to the
+// list of values.
+func (s *SAML) identityObject(ctx gocontext.Context, assertion *etree.Element, now time.Time) (interface{}, error) {
+	s.mu.RLock()
+	entityID := s.entityID
+	s.mu.RUnlock()
+
+	issuer := samlChildText(assertion, samlAssertionNS, "Issuer")
+	if entityID != "" && issuer != entityID {
+		return nil, fmt.Errorf(msg_samlIssuerNotAllowed)
+	}
+
+	skew := time.Duration(s.MaxClockSkew) * time.Second
+	conditions := samlChild(assertion, samlAssertionNS, "Conditions")
+	if conditions == nil {
+		return nil, fmt.Errorf(msg_samlAudienceNotAllowed)
+	}
+	expiry, err := checkSAMLValidity(conditions, now, skew)
+	if err != nil {
+		return nil, err
+	}
+	restrictions := samlChildren(conditions, samlAssertionNS, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, fmt.Errorf(msg_samlAudienceNotAllowed)
+	}
+	for _, restriction := range restrictions {
+		allowed := false
+		for _, audience := range samlChildren(restriction, samlAssertionNS, "Audience") {
+			if utils.SliceContains(s.Audiences, strings.TrimSpace(audience.Text())) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf(msg_samlAudienceNotAllowed)
+		}
+	}
+
+	subject := samlChild(assertion, samlAssertionNS, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf(msg_samlAssertionMissingField)
+	}
+	nameID := samlChild(subject, samlAssertionNS, "NameID")
+	if nameID == nil || strings.TrimSpace(nameID.Text()) == "" {
+		return nil, fmt.Errorf(msg_samlAssertionMissingField)
+	}
+	for _, confirmation := range samlChildren(subject, samlAssertionNS, "SubjectConfirmation") {
+		if data := samlChild(confirmation, samlAssertionNS, "SubjectConfirmationData"); data != nil {
+			notOnOrAfter, err := checkSAMLValidity(data, now, skew)
+			if err != nil {
+				return nil, err
+			}
+			if expiry.IsZero() || (!notOnOrAfter.IsZero() && notOnOrAfter.Before(expiry)) {
+				expiry = notOnOrAfter
+			}
+		}
+	}
+
+	// replay – the ids of the assertions are remembered in the shared store of one-time values until the assertions expire
+	id := assertion.SelectAttrValue("ID", "")
+	if id == "" {
+		return nil, fmt.Errorf(msg_samlInvalidResponseError)
+	}
+	if expiry.IsZero() {
+		return nil, fmt.Errorf(msg_samlAssertionNoExpiry)
+	}
+	unused, err := replay.SharedStore.Use(ctx, "saml/"+issuer+"/"+id, expiry.Add(skew).Sub(now))
+	if err != nil {
+		return nil, fmt.Errorf(msg_samlReplayCheckError)
+	}
+	if !unused {
+		return nil, fmt.Errorf(msg_samlAssertionReplayed)
+	}
+
+	identityObject := SAMLIdentityObject{
+		Issuer:       issuer,
+		Subject:      strings.TrimSpace(nameID.Text()),
+		NameIDFormat: nameID.SelectAttrValue("Format", ""),
+		Attributes:   make(map[string]interface{}),
+	}
+	if authnStatement := samlChild(assertion, samlAssertionNS, "AuthnStatement"); authnStatement != nil {
+		identityObject.SessionIndex = authnStatement.SelectAttrValue("SessionIndex", "")
+	}
+	for _, statement := range samlChildren(assertion, samlAssertionNS, "AttributeStatement") {
+		for _, attribute := range samlChildren(statement, samlAssertionNS, "Attribute") {
+			var values []string
+			for _, value := range samlChildren(attribute, samlAssertionNS, "AttributeValue") {
+				values = append(values, strings.TrimSpace(value.Text()))
+			}
+			if len(values) == 1 {
+				identityObject.Attributes[attribute.SelectAttrValue("Name", "")] = values[0]
+			} else {
+				identityObject.Attributes[attribute.SelectAttrValue("Name", "")] = values
+			}
+		}
+	}
+
+	return identityObject, nil
+}
+
+// setMetadata parses the SAML metadata of the IdP and replaces its entity ID and signing certificates
+func (s *SAML) setMetadata(metadata []byte) error {
+	var descriptor samlEntityDescriptor
+	if err := xml.Unmarshal(metadata, &descriptor); err != nil {
+		return fmt.Errorf(msg_samlInvalidMetadataError)
+	}
+
+	var certs []*x509.Certificate
+	for _, idp := range descriptor.IDPSSODescriptors {
+		for _, key := range idp.KeyDescriptors {
+			if key.Use != "" && key.Use != "signing" {
+				continue
+			}
+			for _, encoded := range key.Certificates {
+				der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(encoded), ""))
+				if err != nil {
+					return fmt.Errorf(msg_samlInvalidMetadataError)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return fmt.Errorf(msg_samlInvalidMetadataError)
+				}
+				certs = append(certs, cert)
+			}
+		}
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf(msg_samlInvalidMetadataError)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entityID = descriptor.EntityID
+	s.certs = certs
+	return nil
+}
+
+func (s *SAML) refreshMetadata(ctx gocontext.Context) {
+	logger := log.FromContext(ctx)
+	metadata, err := fetchSAMLMetadata(ctx, s.MetadataUrl)
+	if err == nil {
+		err = s.setMetadata(metadata)
+	}
+	if err != nil {
+		logger.Error(err, msg_samlMetadataFetchError, "url", s.MetadataUrl)
+		return
+	}
+	logger.V(1).Info(msg_samlMetadataRefreshed, "url", s.MetadataUrl)
+}
+
+func fetchSAMLMetadata(ctx gocontext.Context, metadataUrl string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", metadataUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", msg_samlMetadataFetchError, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// checkSAMLValidity checks the NotBefore and NotOnOrAfter attributes of an element against the current time.
+// Returns the NotOnOrAfter time, or the zero time if the element does not set one.
+func checkSAMLValidity(el *etree.Element, now time.Time, skew time.Duration) (time.Time, error) {
+	if notBefore := el.SelectAttrValue("NotBefore", ""); notBefore != "" {
+		t, err := time.Parse(time.RFC3339Nano, notBefore)
+		if err != nil {
+			return time.Time{}, fmt.Errorf(msg_samlInvalidResponseError)
+		}
+		if now.Add(skew).Before(t) {
+			return time.Time{}, fmt.Errorf(msg_samlAssertionNotYetValid)
+		}
+	}
+	var expiry time.Time
+	if notOnOrAfter := el.SelectAttrValue("NotOnOrAfter", ""); notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339Nano, notOnOrAfter)
+		if err != nil {
+			return time.Time{}, fmt.Errorf(msg_samlInvalidResponseError)
+		}
+		if !now.Add(-skew).Before(t) {
+			return time.Time{}, fmt.Errorf(msg_samlAssertionExpired)
+		}
+		expiry = t
+	}
+	return expiry, nil
+}
+
+func isSAMLElement(el *etree.Element, namespace, tag string) bool {
+	return el.Tag == tag && el.NamespaceURI() == namespace
+}
+
+func isSAMLElementSigned(el *etree.Element) bool {
+	return samlChild(el, xmlDSigNS, "Signature") != nil
+}
+
+func samlChildren(el *etree.Element, namespace, tag string) []*etree.Element {
+	var children []*etree.Element
+	for _, child := range el.ChildElements() {
+		if isSAMLElement(child, namespace, tag) {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+func samlChild(el *etree.Element, namespace, tag string) *etree.Element {
+	if children := samlChildren(el, namespace, tag); len(children) > 0 {
+		return children[0]
+	}
+	return nil
+}
+
+func samlChildText(el *etree.Element, namespace, tag string) string {
+	if child := samlChild(el, namespace, tag); child != nil {
+		return strings.TrimSpace(child.Text())
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package identity
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	"github.com/beevik/etree"
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang/mock/gomock"
+	dsig "github.com/russellhaering/goxmldsig"
+	"gotest.tools/assert"
+)
+
+const samlTestIdP = "https://idp.example.com"
+
+var samlTestAssertionCount int
+
+func newSAMLTestMetadata(t *testing.T, keyStore dsig.X509KeyStore) []byte {
+	_, cert, err := keyStore.GetKeyPair()
+	assert.NilError(t, err)
+	return []byte(fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="encryption"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>not-a-certificate</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+    <md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, samlTestIdP, base64.StdEncoding.EncodeToString(cert)))
+}
+
+// newSAMLTestAssertion returns an assertion with a new id, so it is not rejected as a replay
+func newSAMLTestAssertion(issuer, audience string, notOnOrAfter time.Time) string {
+	samlTestAssertionCount++
+	return fmt.Sprintf(`<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_assertion%[5]d" Version="2.0" IssueInstant="%[4]s">
+  <saml:Issuer>%[1]s</saml:Issuer>
+  <saml:Subject>
+    <saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">john@example.com</saml:NameID>
+    <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer"><saml:SubjectConfirmationData NotOnOrAfter="%[3]s"/></saml:SubjectConfirmation>
+  </saml:Subject>
+  <saml:Conditions NotBefore="%[4]s" NotOnOrAfter="%[3]s">
+    <saml:AudienceRestriction><saml:Audience>%[2]s</saml:Audience></saml:AudienceRestriction>
+  </saml:Conditions>
+  <saml:AuthnStatement AuthnInstant="%[4]s" SessionIndex="_session"/>
+  <saml:AttributeStatement>
+    <saml:Attribute Name="email"><saml:AttributeValue>john@example.com</saml:AttributeValue></saml:Attribute>
+    <saml:Attribute Name="groups"><saml:AttributeValue>admin</saml:AttributeValue><saml:AttributeValue>dev</saml:AttributeValue></saml:Attribute>
+  </saml:AttributeStatement>
+</saml:Assertion>`, issuer, audience, notOnOrAfter.UTC().Format(time.RFC3339), time.Now().Add(-time.Minute).UTC().Format(time.RFC3339), samlTestAssertionCount)
+}
+
+func newSAMLTestResponse(status, assertion string) string {
+	return fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_response" Version="2.0"><samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status>%s</samlp:Response>`, status, assertion)
+}
+
+// signSAMLTestDocument signs the root element of the document, or the element with the given tag if set
+func signSAMLTestDocument(t *testing.T, keyStore dsig.X509KeyStore, xml string, tag string) string {
+	doc := etree.NewDocument()
+	assert.NilError(t, doc.ReadFromString(xml))
+	el := doc.Root()
+	if tag != "" {
+		el = doc.FindElement("//" + tag)
+	}
+	signingContext := dsig.NewDefaultSigningContext(keyStore)
+	signingContext.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("") // as the idps do
+	signed, err := signingContext.SignEnveloped(el)
+	assert.NilError(t, err)
+	if el == doc.Root() {
+		doc.SetRoot(signed)
+	} else {
+		parent := el.Parent()
+		parent.RemoveChild(el)
+		parent.AddChild(signed)
+	}
+	out, err := doc.WriteToString()
+	assert.NilError(t, err)
+	return out
+}
+
+func TestNewSAMLIdentityInvalidMetadata(t *testing.T) {
+	_, err := NewSAMLIdentity([]byte(`not-metadata`), "", 0, []string{"echo-api"}, 60, nil, context.TODO())
+	assert.Error(t, err, "invalid saml idp metadata")
+
+	_, err = NewSAMLIdentity([]byte(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com"/>`), "", 0, []string{"echo-api"}, 60, nil, context.TODO())
+	assert.Error(t, err, "invalid saml idp metadata")
+}
+
+func TestSAMLCall(t *testing.T) {
+	keyStore := dsig.RandomKeyStoreForTest()
+	otherKeyStore := dsig.RandomKeyStoreForTest()
+
+	evaluator, err := NewSAMLIdentity(newSAMLTestMetadata(t, keyStore), "", 0, []string{"echo-api"}, 60, auth.NewAuthCredential("X-SAML-Response", "custom_header"), context.TODO())
+	assert.NilError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	call := func(xml string) (interface{}, error) {
+		pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-saml-response": base64.StdEncoding.EncodeToString([]byte(xml))}})
+		return evaluator.Call(pipeline, context.TODO())
+	}
+
+	validUntil := time.Now().Add(5 * time.Minute)
+	assertion := newSAMLTestAssertion(samlTestIdP, "echo-api", validUntil)
+
+	// signed assertion
+	signedAssertion := signSAMLTestDocument(t, keyStore, assertion, "")
+	obj, err := call(signedAssertion)
+	assert.NilError(t, err)
+	identity := obj.(SAMLIdentityObject)
+	assert.Equal(t, identity.Issuer, samlTestIdP)
+	assert.Equal(t, identity.Subject, "john@example.com")
+	assert.Equal(t, identity.NameIDFormat, "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress")
+	assert.Equal(t, identity.SessionIndex, "_session")
+	assert.Equal(t, identity.Attributes["email"], "john@example.com")
+	assert.DeepEqual(t, identity.Attributes["groups"], []string{"admin", "dev"})
+
+	// replayed
+	_, err = call(signedAssertion)
+	assert.Error(t, err, "the saml assertion has already been used")
+	_, err = call(signSAMLTestDocument(t, keyStore, newSAMLTestResponse(samlSuccess, assertion), ""))
+	assert.Error(t, err, "the saml assertion has already been used")
+
+	// signed response
+	_, err = call(signSAMLTestDocument(t, keyStore, newSAMLTestResponse(samlSuccess, newSAMLTestAssertion(samlTestIdP, "echo-api", validUntil)), ""))
+	assert.NilError(t, err)
+
+	// signed assertion in an unsigned response
+	_, err = call(signSAMLTestDocument(t, keyStore, newSAMLTestResponse(samlSuccess, newSAMLTestAssertion(samlTestIdP, "echo-api", validUntil)), "saml:Assertion"))
+	assert.NilError(t, err)
+
+	// unsuccessful response
+	_, err = call(signSAMLTestDocument(t, keyStore, newSAMLTestResponse("urn:oasis:names:tc:SAML:2.0:status:Requester", assertion), ""))
+	assert.Error(t, err, "the saml response is not successful")
+
+	// unsigned
+	_, err = call(assertion)
+	assert.Error(t, err, "the saml assertion is not signed")
+	_, err = call(newSAMLTestResponse(samlSuccess, assertion))
+	assert.Error(t, err, "the saml assertion is not signed")
+
+	// tampered
+	_, err = call(strings.Replace(signSAMLTestDocument(t, keyStore, assertion, ""), "john@example.com</saml:NameID>", "admin@example.com</saml:NameID>", 1))
+	assert.ErrorContains(t, err, "invalid signature of the saml assertion")
+
+	// signed by another key
+	_, err = call(signSAMLTestDocument(t, otherKeyStore, assertion, ""))
+	assert.ErrorContains(t, err, "invalid signature of the saml assertion")
+
+	// other issuer
+	_, err = call(signSAMLTestDocument(t, keyStore, newSAMLTestAssertion("https://other-idp.example.com", "echo-api", validUntil), ""))
+	assert.Error(t, err, "the issuer of the saml assertion is not the idp")
+
+	// other audience
+	_, err = call(signSAMLTestDocument(t, keyStore, newSAMLTestAssertion(samlTestIdP, "other-api", validUntil), ""))
+	assert.Error(t, err, "the audience of the saml assertion is not allowed")
+
+	// expired
+	_, err = call(signSAMLTestDocument(t, keyStore, newSAMLTestAssertion(samlTestIdP, "echo-api", time.Now().Add(-2*time.Minute)), ""))
+	assert.Error(t, err, "the saml assertion has expired")
+
+	// not base64
+	pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"x-saml-response": "<saml:Assertion/>"}})
+	_, err = evaluator.Call(pipeline, context.TODO())
+	assert.Error(t, err, "invalid saml response")
+}
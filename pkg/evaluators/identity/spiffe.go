@@ -0,0 +1,230 @@
+package identity
+
+import (
+	gocontext "context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/httpclient"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/workers"
+
+	goidc "github.com/coreos/go-oidc"
+	"gopkg.in/square/go-jose.v2"
+)
+
+const (
+	spiffeX509SVIDUse = "x509-svid"
+	spiffeJWTSVIDUse  = "jwt-svid"
+
+	msg_spiffeInvalidTrustBundleError = "invalid spiffe trust bundle"
+	msg_spiffeTrustBundleFetchError   = "failed to fetch the spiffe trust bundle"
+	msg_spiffeTrustBundleRefreshed    = "spiffe trust bundle updated"
+	msg_spiffeInvalidSVIDError        = "invalid spiffe svid"
+	msg_spiffeTrustDomainError        = "the spiffe id does not belong to the trust domain"
+)
+
+// SPIFFE verifies X.509-SVIDs (client certificates forwarded by the proxy) and JWT-SVIDs against the trust bundle of
+// a SPIFFE trust domain, either supplied statically or fetched from a SPIFFE bundle endpoint (e.g. of a SPIRE server)
+type SPIFFE struct {
+	auth.AuthCredentials
+	TrustDomain    string   `yaml:"trustDomain"`
+	BundleEndpoint string   `yaml:"bundleEndpoint,omitempty"`
+	Audiences      []string `yaml:"audiences,omitempty"`
+
+	x509Roots *x509.CertPool
+	jwtKeys   *staticKeySet
+	refresher workers.Worker
+	mu        sync.RWMutex
+}
+
+// SPIFFEIdentityObject is the identity object resolved out of a valid SVID
+type SPIFFEIdentityObject struct {
+	SPIFFEID    string      `json:"spiffeId"`
+	TrustDomain string      `json:"trustDomain"`
+	Path        string      `json:"path"`
+	SVIDType    string      `json:"svidType"`
+	Claims      interface{} `json:"claims,omitempty"`
+}
+
+// NewSPIFFEIdentity creates a SPIFFE identity verifier. If a bundle endpoint is provided, the trust bundle is fetched
+// from the endpoint and refreshed every ttl seconds; otherwise, the static bundle is used.
+func NewSPIFFEIdentity(trustDomain string, bundle []byte, bundleEndpoint string, ttl int, audiences []string, creds auth.AuthCredentials, ctx gocontext.Context) (*SPIFFE, error) {
+	s := &SPIFFE{
+		AuthCredentials: creds,
+		TrustDomain:     trustDomain,
+		BundleEndpoint:  bundleEndpoint,
+		Audiences:       audiences,
+	}
+
+	if bundleEndpoint != "" {
+		var err error
+		if bundle, err = fetchSPIFFEBundle(ctx, bundleEndpoint); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.setBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	if bundleEndpoint != "" {
+		ctxWithLogger := log.IntoContext(ctx, log.FromContext(ctx).WithName("spiffe"))
+		s.refresher, _ = workers.StartWorker(ctxWithLogger, ttl, func() {
+			s.refreshBundle(ctxWithLogger)
+		})
+	}
+
+	return s, nil
+}
+
+func (s *SPIFFE) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if err := context.CheckContext(ctx); err != nil {
+		return nil, err
+	}
+
+	// x509-svid
+	if urlEncodedCert := pipeline.GetRequest().GetAttributes().GetSource().GetCertificate(); urlEncodedCert != "" {
+		return s.verifyX509SVID(urlEncodedCert)
+	}
+
+	// jwt-svid
+	token, err := s.GetCredentialsFromReq(pipeline.GetHttp())
+	if err != nil {
+		return nil, err
+	}
+	return s.verifyJWTSVID(ctx, token)
+}
+
+// Clean ensures the goroutine started to refresh the trust bundle is cleaned up
+func (s *SPIFFE) Clean(_ gocontext.Context) error {
+	if s.refresher == nil {
+		return nil
+	}
+	return s.refresher.Stop()
+}
+
+func (s *SPIFFE) verifyX509SVID(urlEncodedCert string) (interface{}, error) {
+	pemEncodedCert, err := url.QueryUnescape(urlEncodedCert)
+	if err != nil {
+		return nil, fmt.Errorf(msg_spiffeInvalidSVIDError)
+	}
+	cert := DecodeCertificate([]byte(pemEncodedCert))
+	if cert == nil || len(cert.URIs) != 1 {
+		return nil, fmt.Errorf(msg_spiffeInvalidSVIDError)
+	}
+
+	s.mu.RLock()
+	roots := s.x509Roots
+	s.mu.RUnlock()
+
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+		return nil, err
+	}
+
+	return s.identityObject(cert.URIs[0].String(), "x509", nil)
+}
+
+func (s *SPIFFE) verifyJWTSVID(ctx gocontext.Context, token string) (interface{}, error) {
+	s.mu.RLock()
+	keySet := s.jwtKeys
+	s.mu.RUnlock()
+
+	tokenVerifierConfig := &goidc.Config{SkipClientIDCheck: true, SkipIssuerCheck: true, SupportedSigningAlgs: supportedSigningAlgs}
+	idToken, err := goidc.NewVerifier("", keySet, tokenVerifierConfig).Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyIssuerAndAudiences(idToken, nil, s.Audiences); err != nil {
+		return nil, err
+	}
+
+	var claims interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return s.identityObject(idToken.Subject, "jwt", claims)
+}
+
+// identityObject builds the identity object out of a SPIFFE ID, checking that it belongs to the trust domain
+func (s *SPIFFE) identityObject(spiffeID, svidType string, claims interface{}) (interface{}, error) {
+	id, err := url.Parse(spiffeID)
+	if err != nil || id.Scheme != "spiffe" || id.Host == "" {
+		return nil, fmt.Errorf(msg_spiffeInvalidSVIDError)
+	}
+	if !strings.EqualFold(id.Host, s.TrustDomain) {
+		return nil, fmt.Errorf(msg_spiffeTrustDomainError)
+	}
+	return SPIFFEIdentityObject{
+		SPIFFEID:    spiffeID,
+		TrustDomain: id.Host,
+		Path:        id.Path,
+		SVIDType:    svidType,
+		Claims:      claims,
+	}, nil
+}
+
+// setBundle parses a SPIFFE trust bundle and replaces the trusted x509 roots and jwt signing keys
+func (s *SPIFFE) setBundle(bundle []byte) error {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(bundle, &jwks); err != nil || len(jwks.Keys) == 0 {
+		return fmt.Errorf(msg_spiffeInvalidTrustBundleError)
+	}
+
+	roots := x509.NewCertPool()
+	jwtKeys := &staticKeySet{}
+	for _, key := range jwks.Keys {
+		switch key.Use {
+		case spiffeX509SVIDUse:
+			for _, cert := range key.Certificates {
+				roots.AddCert(cert)
+			}
+		case spiffeJWTSVIDUse:
+			jwtKeys.keys.Keys = append(jwtKeys.keys.Keys, key)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.x509Roots = roots
+	s.jwtKeys = jwtKeys
+	return nil
+}
+
+func (s *SPIFFE) refreshBundle(ctx gocontext.Context) {
+	logger := log.FromContext(ctx)
+	bundle, err := fetchSPIFFEBundle(ctx, s.BundleEndpoint)
+	if err == nil {
+		err = s.setBundle(bundle)
+	}
+	if err != nil {
+		logger.Error(err, msg_spiffeTrustBundleFetchError, "endpoint", s.BundleEndpoint)
+		return
+	}
+	logger.V(1).Info(msg_spiffeTrustBundleRefreshed, "endpoint", s.BundleEndpoint)
+}
+
+func fetchSPIFFEBundle(ctx gocontext.Context, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", msg_spiffeTrustBundleFetchError, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,124 @@
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	gojson "encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	"github.com/golang/mock/gomock"
+	"gopkg.in/square/go-jose.v2"
+	"gotest.tools/assert"
+)
+
+func newSPIFFETestCert(t *testing.T, spiffeID string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{Organization: []string{"SPIRE"}},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	} else {
+		id, _ := url.Parse(spiffeID)
+		template.URIs = []*url.URL{id}
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	assert.NilError(t, err)
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func urlEncodedPEM(cert *x509.Certificate) string {
+	return url.QueryEscape(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+}
+
+func TestNewSPIFFEIdentityInvalidBundle(t *testing.T) {
+	_, err := NewSPIFFEIdentity("example.org", []byte(`not-a-bundle`), "", 0, nil, nil, context.TODO())
+	assert.Error(t, err, "invalid spiffe trust bundle")
+}
+
+func TestSPIFFECall(t *testing.T) {
+	ca, caKey := newSPIFFETestCert(t, "", nil, nil)
+	jwtKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	bundle, _ := gojson.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: ca.PublicKey, Certificates: []*x509.Certificate{ca}, Use: "x509-svid"},
+		{Key: jwtKey.Public(), KeyID: "k1", Algorithm: "RS256", Use: "jwt-svid"},
+	}})
+
+	evaluator, err := NewSPIFFEIdentity("example.org", bundle, "", 0, []string{"talker-api"}, auth.NewAuthCredential("", "authorization_header"), context.TODO())
+	assert.NilError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipeline := mock_auth.NewMockAuthPipeline(ctrl)
+
+	callWithCert := func(cert *x509.Certificate) (interface{}, error) {
+		pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{Source: &envoy_auth.AttributeContext_Peer{Certificate: urlEncodedPEM(cert)}}})
+		return evaluator.Call(pipeline, context.TODO())
+	}
+
+	callWithToken := func(token string) (interface{}, error) {
+		pipeline.EXPECT().GetRequest().Return(&envoy_auth.CheckRequest{})
+		pipeline.EXPECT().GetHttp().Return(&envoy_auth.AttributeContext_HttpRequest{Headers: map[string]string{"authorization": "Bearer " + token}})
+		return evaluator.Call(pipeline, context.TODO())
+	}
+
+	// x509-svid
+	cert, _ := newSPIFFETestCert(t, "spiffe://example.org/ns/default/sa/talker", ca, caKey)
+	obj, err := callWithCert(cert)
+	assert.NilError(t, err)
+	id := obj.(SPIFFEIdentityObject)
+	assert.Equal(t, id.SPIFFEID, "spiffe://example.org/ns/default/sa/talker")
+	assert.Equal(t, id.Path, "/ns/default/sa/talker")
+	assert.Equal(t, id.SVIDType, "x509")
+
+	// x509-svid of another trust domain
+	cert, _ = newSPIFFETestCert(t, "spiffe://other.org/workload", ca, caKey)
+	_, err = callWithCert(cert)
+	assert.Error(t, err, "the spiffe id does not belong to the trust domain")
+
+	// x509-svid signed by an untrusted ca
+	otherCA, otherCAKey := newSPIFFETestCert(t, "", nil, nil)
+	cert, _ = newSPIFFETestCert(t, "spiffe://example.org/workload", otherCA, otherCAKey)
+	_, err = callWithCert(cert)
+	assert.ErrorContains(t, err, "certificate signed by unknown authority")
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	// jwt-svid
+	obj, err = callWithToken(signTestTokenWithClaims(t, jwtKey, "k1", fmt.Sprintf(`{"sub":"spiffe://example.org/workload","aud":"talker-api","exp":%d}`, exp)))
+	assert.NilError(t, err)
+	id = obj.(SPIFFEIdentityObject)
+	assert.Equal(t, id.SPIFFEID, "spiffe://example.org/workload")
+	assert.Equal(t, id.SVIDType, "jwt")
+
+	// jwt-svid with another audience
+	_, err = callWithToken(signTestTokenWithClaims(t, jwtKey, "k1", fmt.Sprintf(`{"sub":"spiffe://example.org/workload","aud":"other","exp":%d}`, exp)))
+	assert.Error(t, err, "token audience not allowed")
+
+	// jwt-svid with an invalid spiffe id
+	_, err = callWithToken(signTestTokenWithClaims(t, jwtKey, "k1", fmt.Sprintf(`{"sub":"john","aud":"talker-api","exp":%d}`, exp)))
+	assert.Error(t, err, "invalid spiffe svid")
+}
@@ -1,15 +1,22 @@
 package evaluators
 
 import (
+	"context"
 	gojson "encoding/json"
 	"testing"
 
+	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/replay"
+	"github.com/kuadrant/authorino/pkg/revocation"
 
 	"github.com/golang/mock/gomock"
 	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func TestIdentityConfig_ResolveExtendedProperties(t *testing.T) {
@@ -30,7 +37,7 @@ func TestIdentityConfig_ResolveExtendedProperties(t *testing.T) {
 	}
 
 	_ = gojson.Unmarshal([]byte(`{"sub":"foo","exp":1629884250}`), &identityObject)
-	pipelineMock.EXPECT().GetResolvedIdentity().Return(nil, identityObject)
+	pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Object: identityObject})
 
 	extendedIdentityObject, err = identityConfig.ResolveExtendedProperties(pipelineMock)
 	assert.NilError(t, err)
@@ -46,7 +53,7 @@ func TestIdentityConfig_ResolveExtendedProperties(t *testing.T) {
 		},
 	}
 
-	pipelineMock.EXPECT().GetResolvedIdentity().Return(nil, identityObject)
+	pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Object: identityObject})
 	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{},"auth":{"identity":{"sub":"foo","exp":1629884250}}}`)
 
 	extendedIdentityObject, err = identityConfig.ResolveExtendedProperties(pipelineMock)
@@ -54,3 +61,151 @@ func TestIdentityConfig_ResolveExtendedProperties(t *testing.T) {
 	extendedIdentityObjectJSON, _ := gojson.Marshal(extendedIdentityObject)
 	assert.Equal(t, string(extendedIdentityObjectJSON), `{"exp":1629884250,"prop1":"value1","prop2":"foo","sub":"foo"}`)
 }
+
+func TestIdentityConfig_ResolveExtendedPropertiesOfNullIdentity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Object: nil})
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"headers":{"x-tenant":"acme"}}}},"auth":{"identity":null}}`)
+
+	identityConfig := IdentityConfig{
+		Name: "test",
+		Noop: &identity.Noop{},
+		ExtendedProperties: []json.JSONProperty{
+			{Name: "anonymous", Value: json.JSONValue{Static: true}},
+			{Name: "tenant", Value: json.JSONValue{Pattern: "context.request.http.headers.x-tenant"}},
+		},
+	}
+
+	extendedIdentityObject, err := identityConfig.ResolveExtendedProperties(pipelineMock)
+	assert.NilError(t, err)
+	extendedIdentityObjectJSON, _ := gojson.Marshal(extendedIdentityObject)
+	assert.Equal(t, string(extendedIdentityObjectJSON), `{"anonymous":true,"tenant":"acme"}`)
+}
+
+func TestIdentityConfig_ResolveClaimMappings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+
+	var identityObject interface{}
+	_ = gojson.Unmarshal([]byte(`{"sub":"1234","preferred_username":"john","realm_access":{"roles":["admin","dev"]},"org":"acme"}`), &identityObject)
+
+	identityConfig := IdentityConfig{
+		Name: "test",
+		OIDC: &identity.OIDC{},
+		ClaimMappings: &IdentityClaimMappings{
+			Username: "preferred_username",
+			Groups:   "realm_access.roles",
+			Email:    "email",
+			Tenant:   "org",
+		},
+		ExtendedProperties: []json.JSONProperty{
+			{Name: "org", Value: json.JSONValue{Static: "overridden"}},
+		},
+	}
+
+	pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Object: identityObject})
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	extendedIdentityObject, err := identityConfig.ResolveExtendedProperties(pipelineMock)
+	assert.NilError(t, err)
+	extendedIdentityObjectJSON, _ := gojson.Marshal(extendedIdentityObject)
+	assert.Equal(t, string(extendedIdentityObjectJSON), `{"groups":["admin","dev"],"org":"overridden","preferred_username":"john","realm_access":{"roles":["admin","dev"]},"sub":"1234","tenant":"acme","username":"john"}`)
+
+	// single group
+	identityConfig.ClaimMappings = &IdentityClaimMappings{Groups: "org"}
+	identityConfig.ExtendedProperties = nil
+	pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Object: identityObject})
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	extendedIdentityObject, err = identityConfig.ResolveExtendedProperties(pipelineMock)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, extendedIdentityObject.(map[string]interface{})["groups"], []interface{}{"acme"})
+}
+
+func TestIdentityConfig_Revocation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+
+	selector, _ := labels.Parse("revoked=true")
+	identityConfig := IdentityConfig{
+		Name:       "test",
+		Noop:       &identity.Noop{},
+		Revocation: revocation.NewList(selector, "ns1", "", nil, context.TODO()),
+	}
+	assert.Check(t, identityConfig.GetK8sSecretLabelSelectors() == nil)
+
+	_, err := identityConfig.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	secret := v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "deny-list", Namespace: "ns1", Labels: map[string]string{"revoked": "true"}}, Data: map[string][]byte{"anonymous": []byte("true")}}
+	identityConfig.AddK8sSecretBasedIdentity(context.TODO(), secret)
+	_, err = identityConfig.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "the identity has been revoked")
+
+	// no longer matching the selector
+	secret.Labels = map[string]string{}
+	identityConfig.AddK8sSecretBasedIdentity(context.TODO(), secret)
+	_, err = identityConfig.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+}
+
+func TestIdentityConfig_ReplayProtection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+
+	identityConfig := IdentityConfig{
+		Name:             "test",
+		Plain:            &identity.Plain{Pattern: "context.request.http.headers"},
+		ReplayProtection: replay.NewProtection("x-request-id", 60, "ns1/test/replay"),
+	}
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"headers":{"x-request-id":"a1b2c3"}}}}}`).Times(2)
+	_, err := identityConfig.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	_, err = identityConfig.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "the credential has already been used")
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"headers":{"x-request-id":"d4e5f6"}}}}}`)
+	_, err = identityConfig.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"headers":{}}}}}`)
+	_, err = identityConfig.Call(pipelineMock, context.TODO())
+	assert.Error(t, err, "the credential has no one-time value")
+}
+
+func TestFindIdentityConfigByName(t *testing.T) {
+	identityConfigs := []IdentityConfig{
+		{Name: "oidc", OIDC: &identity.OIDC{Endpoint: "http://keycloak"}},
+		{Name: "plain", Plain: &identity.Plain{Pattern: "context.request.http.headers.x-user"}},
+	}
+
+	idConfig, err := FindIdentityConfigByName(identityConfigs, "oidc")
+	assert.NilError(t, err)
+	assert.Equal(t, idConfig.GetType(), identityOIDC)
+
+	idConfig, err = FindIdentityConfigByName(identityConfigs, "plain")
+	assert.NilError(t, err)
+	assert.Equal(t, idConfig.GetType(), identityPlain)
+	assert.Check(t, idConfig == &identityConfigs[1])
+
+	_, err = FindIdentityConfigByName(identityConfigs, "missing")
+	assert.Error(t, err, "missing identity config missing")
+}
+
+func TestIdentityConfig_GetCost(t *testing.T) {
+	assert.Equal(t, (&IdentityConfig{APIKey: &identity.APIKey{}}).GetCost(), IdentityCostLocal)
+	assert.Equal(t, (&IdentityConfig{JWT: &identity.JWT{}}).GetCost(), IdentityCostLocal)
+	assert.Equal(t, (&IdentityConfig{OIDC: &identity.OIDC{}}).GetCost(), IdentityCostLocal)
+	assert.Equal(t, (&IdentityConfig{OAuth2: &identity.OAuth2{}}).GetCost(), IdentityCostRemote)
+	assert.Equal(t, (&IdentityConfig{KubernetesAuth: &identity.KubernetesAuth{}}).GetCost(), IdentityCostRemote)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators/custom"
+	"github.com/kuadrant/authorino/pkg/evaluators/external"
 	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
@@ -14,6 +16,10 @@ const (
 	metadataUserInfo    = "METADATA_USERINFO"
 	metadataUMA         = "METADATA_UMA"
 	metadataGenericHTTP = "METADATA_GENERIC_HTTP"
+	metadataCustom      = "METADATA_CUSTOM"
+	metadataExternal    = "METADATA_EXTERNAL"
+	metadataSQL         = "METADATA_SQL"
+	metadataGRPC        = "METADATA_GRPC"
 )
 
 type MetadataConfig struct {
@@ -22,10 +28,16 @@ type MetadataConfig struct {
 	Conditions []json.JSONPatternMatchingRule `yaml:"conditions"`
 	Metrics    bool                           `yaml:"metrics"`
 	Cache      EvaluatorCache
+	// Required metadata configs deny the request when the metadata cannot be fetched
+	Required bool `yaml:"required,omitempty"`
 
 	UserInfo    *metadata.UserInfo    `yaml:"userinfo,omitempty"`
 	UMA         *metadata.UMA         `yaml:"uma,omitempty"`
 	GenericHTTP *metadata.GenericHttp `yaml:"http,omitempty"`
+	Custom      *custom.Evaluator     `yaml:"custom,omitempty"`
+	External    *external.GRPC        `yaml:"external,omitempty"`
+	SQL         *metadata.SQL         `yaml:"sql,omitempty"`
+	GRPC        *metadata.GRPC        `yaml:"grpc,omitempty"`
 }
 
 func (config *MetadataConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator {
@@ -36,6 +48,14 @@ func (config *MetadataConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator
 		return config.UMA
 	case metadataGenericHTTP:
 		return config.GenericHTTP
+	case metadataCustom:
+		return config.Custom
+	case metadataExternal:
+		return config.External
+	case metadataSQL:
+		return config.SQL
+	case metadataGRPC:
+		return config.GRPC
 	default:
 		return nil
 	}
@@ -61,15 +81,13 @@ func (config *MetadataConfig) Call(pipeline auth.AuthPipeline, ctx context.Conte
 			}
 		}
 
-		obj, err := evaluator.Call(pipeline, log.IntoContext(ctx, logger))
-
-		if err == nil && cacheKey != nil {
-			if err := cache.Set(cacheKey, obj); err != nil {
-				logger.V(1).Info("unable to store data in the cache", "err", err)
-			}
+		if cacheKey == nil {
+			return evaluator.Call(pipeline, log.IntoContext(ctx, logger))
 		}
 
-		return obj, err
+		return callAndCache(cache, cacheKey, func() (interface{}, error) {
+			return evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+		}, logger)
 	}
 }
 
@@ -89,6 +107,14 @@ func (config *MetadataConfig) GetType() string {
 		return metadataUMA
 	case config.GenericHTTP != nil:
 		return metadataGenericHTTP
+	case config.Custom != nil:
+		return metadataCustom
+	case config.External != nil:
+		return metadataExternal
+	case config.SQL != nil:
+		return metadataSQL
+	case config.GRPC != nil:
+		return metadataGRPC
 	default:
 		return ""
 	}
@@ -108,7 +134,27 @@ func (config *MetadataConfig) GetConditions() []json.JSONPatternMatchingRule {
 
 // impl:AuthConfigCleaner
 
-func (config *MetadataConfig) Clean(_ context.Context) error {
+func (config *MetadataConfig) Clean(ctx context.Context) error {
+	if config.Custom != nil {
+		if err := config.Custom.Clean(ctx); err != nil {
+			return err
+		}
+	}
+	if config.External != nil {
+		if err := config.External.Clean(ctx); err != nil {
+			return err
+		}
+	}
+	if config.SQL != nil {
+		if err := config.SQL.Clean(ctx); err != nil {
+			return err
+		}
+	}
+	if config.GRPC != nil {
+		if err := config.GRPC.Clean(ctx); err != nil {
+			return err
+		}
+	}
 	if config.Cache != nil {
 		return config.Cache.Shutdown()
 	}
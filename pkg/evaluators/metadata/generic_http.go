@@ -12,6 +12,7 @@ import (
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/httpclient"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/oauth2"
@@ -38,15 +39,12 @@ func (h *GenericHttp) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (i
 		return nil, err
 	}
 
-	authJSON := pipeline.GetAuthorizationJSON()
-	endpoint := json.ReplaceJSONPlaceholders(h.Endpoint, authJSON)
-
-	req, err := h.buildRequest(ctx, endpoint, authJSON)
+	req, err := h.BuildRequest(ctx, pipeline.GetAuthorizationJSON())
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +85,10 @@ func (h *GenericHttp) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (i
 	return string(str), nil
 }
 
-func (h *GenericHttp) buildRequest(ctx gocontext.Context, endpoint, authJSON string) (*http.Request, error) {
+// BuildRequest builds the request to the HTTP service, with endpoint placeholders, body, headers and credentials resolved for the authorization JSON
+func (h *GenericHttp) BuildRequest(ctx gocontext.Context, authJSON string) (*http.Request, error) {
+	endpoint := json.ReplaceJSONPlaceholders(h.Endpoint, authJSON)
+
 	var requestBody io.Reader
 	var contentType string
 
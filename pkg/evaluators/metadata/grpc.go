@@ -0,0 +1,298 @@
+package metadata
+
+import (
+	gocontext "context"
+	gojson "encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"github.com/authzed/grpcutil"
+	"google.golang.org/grpc"
+	insecuregrpc "google.golang.org/grpc/credentials/insecure"
+	grpc_metadata "google.golang.org/grpc/metadata"
+	reflection "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPC is a metadata evaluator that calls a unary method of an arbitrary gRPC service.
+// The service does not have to implement any particular contract: the descriptors of the method are fetched from the
+// gRPC server reflection service, and the request and response messages are transcoded from and to JSON.
+type GRPC struct {
+	// Endpoint of the gRPC service (host:port)
+	Endpoint string
+	// Method is the full name of the method, in the format <package>.<service>/<method>
+	Method string
+	// Insecure connection (i.e. without TLS)
+	Insecure bool
+	// SharedSecret is sent to the service as a bearer token
+	SharedSecret string
+	// Body is a JSON template of the request message; it supersedes Parameters
+	Body *json.JSONValue
+	// Parameters are the fields of the request message
+	Parameters []json.JSONProperty
+	// Headers are sent in the gRPC metadata of the request
+	Headers []json.JSONProperty
+	// Timeout of the call
+	Timeout time.Duration
+
+	// the grpc connection and the descriptor of the method are shared by all requests, resolved on the first one
+	conn   *grpc.ClientConn
+	method protoreflect.MethodDescriptor
+	mu     sync.Mutex
+}
+
+func (g *GRPC) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	if g.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+
+	conn, method, err := g.getMethod(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authJSON := pipeline.GetAuthorizationJSON()
+
+	body, err := g.buildRequestBody(authJSON)
+	if err != nil {
+		return nil, err
+	}
+	request := dynamicpb.NewMessage(method.Input())
+	if err := protojson.Unmarshal(body, request); err != nil {
+		return nil, fmt.Errorf("failed to build the request message: %v", err)
+	}
+
+	for _, header := range g.Headers {
+		value, err := json.StringifyJSON(header.Value.ResolveFor(authJSON))
+		if err != nil {
+			return nil, err
+		}
+		ctx = grpc_metadata.AppendToOutgoingContext(ctx, strings.ToLower(header.Name), value)
+	}
+
+	log.FromContext(ctx).WithName("grpc").V(1).Info("calling the grpc service", "endpoint", g.Endpoint, "method", g.Method, "request", string(body))
+
+	response := dynamicpb.NewMessage(method.Output())
+	if err := conn.Invoke(ctx, "/"+g.Method, request, response); err != nil {
+		return nil, err
+	}
+
+	responseJSON, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var obj interface{}
+	if err := gojson.Unmarshal(responseJSON, &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Clean closes the grpc connection to the service
+func (g *GRPC) Clean(_ gocontext.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn == nil {
+		return nil
+	}
+	err := g.conn.Close()
+	g.conn, g.method = nil, nil
+	return err
+}
+
+func (g *GRPC) buildRequestBody(authJSON string) ([]byte, error) {
+	if g.Body != nil {
+		body := g.Body.ResolveFor(authJSON)
+		if s, ok := body.(string); ok {
+			return []byte(s), nil
+		}
+		return gojson.Marshal(body)
+	}
+
+	fields := make(map[string]interface{}, len(g.Parameters))
+	for _, param := range g.Parameters {
+		fields[param.Name] = param.Value.ResolveFor(authJSON)
+	}
+	return gojson.Marshal(fields)
+}
+
+func (g *GRPC) getMethod(ctx gocontext.Context) (*grpc.ClientConn, protoreflect.MethodDescriptor, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.conn != nil && g.method != nil {
+		return g.conn, g.method, nil
+	}
+
+	if g.conn == nil {
+		conn, err := g.dial()
+		if err != nil {
+			return nil, nil, err
+		}
+		g.conn = conn
+	}
+
+	method, err := resolveGRPCMethod(ctx, g.conn, g.Method)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.method = method
+	return g.conn, method, nil
+}
+
+func (g *GRPC) dial() (*grpc.ClientConn, error) {
+	var dialOpts []grpc.DialOption
+
+	if g.Insecure {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecuregrpc.NewCredentials()))
+		if g.SharedSecret != "" {
+			dialOpts = append(dialOpts, grpcutil.WithInsecureBearerToken(g.SharedSecret))
+		}
+	} else {
+		systemCertsOption, _ := grpcutil.WithSystemCerts(grpcutil.VerifyCA)
+		dialOpts = append(dialOpts, systemCertsOption)
+		if g.SharedSecret != "" {
+			dialOpts = append(dialOpts, grpcutil.WithBearerToken(g.SharedSecret))
+		}
+	}
+
+	return grpc.Dial(g.Endpoint, dialOpts...)
+}
+
+// resolveGRPCMethod fetches the descriptor of a method (<package>.<service>/<method>) from the server reflection service
+func resolveGRPCMethod(ctx gocontext.Context, conn *grpc.ClientConn, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	parts := strings.Split(fullMethod, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid grpc method: %s", fullMethod)
+	}
+	serviceName, methodName := protoreflect.FullName(parts[0]), protoreflect.Name(parts[1])
+
+	stream, err := reflection.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the server reflection service: %v", err)
+	}
+	defer func() { _ = stream.CloseSend() }()
+
+	resolver := &reflectionResolver{stream: stream, protos: map[string]*descriptorpb.FileDescriptorProto{}, files: &protoregistry.Files{}}
+	file, err := resolver.fetch(&reflection.ServerReflectionRequest{
+		MessageRequest: &reflection.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: string(serviceName)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := resolver.build(file); err != nil {
+		return nil, err
+	}
+
+	descriptor, err := resolver.files.FindDescriptorByName(serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("grpc service not found: %s", serviceName)
+	}
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("not a grpc service: %s", serviceName)
+	}
+	method := service.Methods().ByName(methodName)
+	if method == nil {
+		return nil, fmt.Errorf("grpc method not found: %s", fullMethod)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("grpc method is not unary: %s", fullMethod)
+	}
+	return method, nil
+}
+
+// reflectionResolver builds the descriptors of the proto files fetched from the server reflection service,
+// fetching the dependencies that are not known yet
+type reflectionResolver struct {
+	stream reflection.ServerReflection_ServerReflectionInfoClient
+	protos map[string]*descriptorpb.FileDescriptorProto
+	files  *protoregistry.Files
+}
+
+// fetch requests proto files to the server reflection service and returns the name of the first file of the response
+func (r *reflectionResolver) fetch(req *reflection.ServerReflectionRequest) (string, error) {
+	if err := r.stream.Send(req); err != nil {
+		return "", err
+	}
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return "", err
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return "", fmt.Errorf("server reflection error: %s", errResp.GetErrorMessage())
+	}
+
+	var first string
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return "", err
+		}
+		if first == "" {
+			first = fd.GetName()
+		}
+		r.protos[fd.GetName()] = fd
+	}
+	if first == "" {
+		return "", fmt.Errorf("server reflection returned no file descriptor")
+	}
+	return first, nil
+}
+
+// build registers a proto file and its dependencies
+func (r *reflectionResolver) build(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+
+	fdp, found := r.protos[path]
+	if !found {
+		if fd, err := protoregistry.GlobalFiles.FindFileByPath(path); err == nil {
+			return fd, nil
+		}
+		if _, err := r.fetch(&reflection.ServerReflectionRequest{
+			MessageRequest: &reflection.ServerReflectionRequest_FileByFilename{FileByFilename: path},
+		}); err != nil {
+			return nil, err
+		}
+		if fdp, found = r.protos[path]; !found {
+			return nil, fmt.Errorf("proto file not found: %s", path)
+		}
+	}
+
+	deps := &protoregistry.Files{}
+	for _, dep := range fdp.GetDependency() {
+		depFile, err := r.build(dep)
+		if err != nil {
+			return nil, err
+		}
+		if err := deps.RegisterFile(depFile); err != nil {
+			return nil, err
+		}
+	}
+
+	fd, err := protodesc.NewFile(fdp, deps)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.files.RegisterFile(fd); err != nil {
+		return nil, err
+	}
+	return fd, nil
+}
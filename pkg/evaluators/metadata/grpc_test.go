@@ -0,0 +1,130 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/httptest"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpc_metadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"gotest.tools/assert"
+)
+
+const testGrpcMetadataServerHost string = "127.0.0.1:9016"
+
+// testHealthServer wraps the standard health server to record the gRPC metadata of the requests
+type testHealthServer struct {
+	*health.Server
+	md grpc_metadata.MD
+}
+
+func (s *testHealthServer) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.md, _ = grpc_metadata.FromIncomingContext(ctx)
+	return s.Server.Check(ctx, in)
+}
+
+func newTestGrpcMetadataServer(registerReflection bool) (interface{ Close() }, *testHealthServer) {
+	healthServer := &testHealthServer{Server: health.NewServer()}
+	healthServer.SetServingStatus("john", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("jane", healthpb.HealthCheckResponse_NOT_SERVING)
+	server := httptest.NewGrpcServerMock(testGrpcMetadataServerHost, func(server *grpc.Server) {
+		healthpb.RegisterHealthServer(server, healthServer)
+		if registerReflection {
+			reflection.Register(server)
+		}
+	})
+	return server, healthServer
+}
+
+func TestGRPCMetadataCall(t *testing.T) {
+	server, healthServer := newTestGrpcMetadataServer(true)
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john"}}}`)
+
+	evaluator := &GRPC{
+		Endpoint:     testGrpcMetadataServerHost,
+		Method:       "grpc.health.v1.Health/Check",
+		Insecure:     true,
+		SharedSecret: "secret",
+		Parameters:   []json.JSONProperty{{Name: "service", Value: json.JSONValue{Pattern: "auth.identity.sub"}}},
+		Headers:      []json.JSONProperty{{Name: "X-Tenant", Value: json.JSONValue{Static: "acme"}}},
+	}
+	defer evaluator.Clean(context.TODO())
+
+	obj, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, obj, map[string]interface{}{"status": "SERVING"})
+	assert.DeepEqual(t, healthServer.md.Get("x-tenant"), []string{"acme"})
+	assert.DeepEqual(t, healthServer.md.Get("authorization"), []string{"Bearer secret"})
+}
+
+func TestGRPCMetadataCallWithBody(t *testing.T) {
+	server, _ := newTestGrpcMetadataServer(true)
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"jane"}}}`)
+
+	evaluator := &GRPC{
+		Endpoint: testGrpcMetadataServerHost,
+		Method:   "grpc.health.v1.Health/Check",
+		Insecure: true,
+		Body:     &json.JSONValue{Pattern: `\{"service":"{auth.identity.sub}"\}`},
+	}
+	defer evaluator.Clean(context.TODO())
+
+	obj, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, obj, map[string]interface{}{"status": "NOT_SERVING"})
+}
+
+func TestGRPCMetadataCallWithUnknownMethod(t *testing.T) {
+	server, _ := newTestGrpcMetadataServer(true)
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+
+	evaluator := &GRPC{Endpoint: testGrpcMetadataServerHost, Method: "grpc.health.v1.Health/Unknown", Insecure: true}
+	defer evaluator.Clean(context.TODO())
+	_, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "grpc method not found: grpc.health.v1.Health/Unknown")
+
+	evaluator = &GRPC{Endpoint: testGrpcMetadataServerHost, Method: "grpc.health.v1.Health/Watch", Insecure: true}
+	defer evaluator.Clean(context.TODO())
+	_, err = evaluator.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "grpc method is not unary")
+
+	evaluator = &GRPC{Endpoint: testGrpcMetadataServerHost, Method: "Check", Insecure: true}
+	defer evaluator.Clean(context.TODO())
+	_, err = evaluator.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "invalid grpc method")
+}
+
+func TestGRPCMetadataCallWithoutReflection(t *testing.T) {
+	server, _ := newTestGrpcMetadataServer(false)
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+
+	evaluator := &GRPC{Endpoint: testGrpcMetadataServerHost, Method: "grpc.health.v1.Health/Check", Insecure: true}
+	defer evaluator.Clean(context.TODO())
+	_, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "Unimplemented")
+}
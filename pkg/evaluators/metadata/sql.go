@@ -0,0 +1,169 @@
+package metadata
+
+import (
+	gocontext "context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/json"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/utils"
+
+	// database/sql drivers supported by the sql metadata evaluator
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+const (
+	SQLDefaultMaxRows      = 100
+	SQLDefaultMaxOpenConns = 10
+)
+
+// SQLDrivers are the names of the database/sql drivers supported by the SQL metadata evaluator
+var SQLDrivers = []string{"postgres", "mysql"}
+
+// SQLDriverSupported tells whether a database/sql driver is supported by the SQL metadata evaluator and registered in this build
+func SQLDriverSupported(driver string) bool {
+	return utils.SliceContains(SQLDrivers, driver) && utils.SliceContains(sql.Drivers(), driver)
+}
+
+// SQL is a metadata evaluator that runs a parameterized read-only query against a relational database.
+// The rows returned by the query are exposed as a list of objects, keyed by the names of the columns.
+type SQL struct {
+	// Driver is the name of the registered database/sql driver (e.g. postgres, mysql)
+	Driver string
+	// DSN is the data source name, i.e. the connection string of the database
+	DSN string
+	// Query is the SQL statement, with placeholders for the parameters in the syntax of the driver (e.g. $1, ?)
+	Query string
+	// Parameters are the values of the placeholders of the query, in order, resolved from the authorization JSON
+	Parameters []json.JSONValue
+	// MaxRows is the maximum number of rows read from the result of the query
+	MaxRows int
+	// MaxOpenConns is the maximum number of open connections of the pool
+	MaxOpenConns int
+	// Timeout of the query
+	Timeout time.Duration
+
+	// the connection pool is shared by all requests, created on the first one
+	db *sql.DB
+	mu sync.Mutex
+}
+
+func (s *SQL) Call(pipeline auth.AuthPipeline, ctx gocontext.Context) (interface{}, error) {
+	db, err := s.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	authJSON := pipeline.GetAuthorizationJSON()
+	args := make([]interface{}, len(s.Parameters))
+	for i, param := range s.Parameters {
+		args[i] = param.ResolveFor(authJSON)
+	}
+
+	if s.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = gocontext.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the values of the parameters may carry personal data and are therefore not logged
+	log.FromContext(ctx).WithName("sql").V(1).Info("querying the database", "driver", s.Driver, "params", len(args))
+
+	rows, err := tx.QueryContext(ctx, s.Query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRows(rows, s.maxRows())
+}
+
+// Clean closes the connection pool to the database
+func (s *SQL) Clean(_ gocontext.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+func (s *SQL) getDB() (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	db, err := sql.Open(s.Driver, s.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	maxOpenConns := s.MaxOpenConns
+	if maxOpenConns <= 0 {
+		maxOpenConns = SQLDefaultMaxOpenConns
+	}
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxOpenConns)
+
+	s.db = db
+	return db, nil
+}
+
+func (s *SQL) maxRows() int {
+	if s.MaxRows > 0 {
+		return s.MaxRows
+	}
+	return SQLDefaultMaxRows
+}
+
+// scanRows reads up to max rows of a result set into a list of objects keyed by the names of the columns.
+// Raw bytes are converted to strings.
+func scanRows(rows *sql.Rows, max int) ([]map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for len(result) < max && rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the result of the query: %v", err)
+	}
+
+	return result, nil
+}
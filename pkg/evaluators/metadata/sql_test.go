@@ -0,0 +1,182 @@
+package metadata
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/json"
+
+	"github.com/golang/mock/gomock"
+	"gotest.tools/assert"
+)
+
+// sqlDriverMock is a database/sql driver that answers every query with the rows of the table named by the DSN
+type sqlDriverMock struct {
+	tables  map[string][][]driver.Value
+	queries []sqlQueryMock
+}
+
+type sqlQueryMock struct {
+	query    string
+	args     []driver.Value
+	readOnly bool
+}
+
+type sqlConnMock struct {
+	driver   *sqlDriverMock
+	table    string
+	readOnly bool
+}
+
+type sqlTxMock struct{ conn *sqlConnMock }
+
+type sqlRowsMock struct {
+	rows [][]driver.Value
+	pos  int
+}
+
+func (d *sqlDriverMock) Open(dsn string) (driver.Conn, error) {
+	if _, found := d.tables[dsn]; !found {
+		return nil, fmt.Errorf("unknown database: %s", dsn)
+	}
+	return &sqlConnMock{driver: d, table: dsn}, nil
+}
+
+func (c *sqlConnMock) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (c *sqlConnMock) Close() error { return nil }
+
+func (c *sqlConnMock) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (c *sqlConnMock) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.readOnly = opts.ReadOnly
+	return &sqlTxMock{conn: c}, nil
+}
+
+func (c *sqlConnMock) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.driver.queries = append(c.driver.queries, sqlQueryMock{query: query, args: values, readOnly: c.readOnly})
+	return &sqlRowsMock{rows: c.driver.tables[c.table]}, nil
+}
+
+func (tx *sqlTxMock) Commit() error   { return nil }
+func (tx *sqlTxMock) Rollback() error { return nil }
+
+func (r *sqlRowsMock) Columns() []string { return []string{"plan", "quota"} }
+func (r *sqlRowsMock) Close() error      { return nil }
+
+func (r *sqlRowsMock) Next(dest []driver.Value) error {
+	if r.pos >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+var testSQLDriver = &sqlDriverMock{
+	tables: map[string][][]driver.Value{
+		"entitlements": {{[]byte("premium"), int64(1000)}, {[]byte("basic"), int64(10)}},
+		"empty":        {},
+	},
+}
+
+func init() {
+	sql.Register("authorino-test", testSQLDriver)
+}
+
+func TestSQLCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john"}}}`).AnyTimes()
+
+	evaluator := &SQL{
+		Driver:     "authorino-test",
+		DSN:        "entitlements",
+		Query:      "SELECT plan, quota FROM entitlements WHERE user_id = $1 AND active = $2",
+		Parameters: []json.JSONValue{{Pattern: "auth.identity.sub"}, {Static: "true"}},
+	}
+	defer evaluator.Clean(context.TODO())
+
+	obj, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	rows := obj.([]map[string]interface{})
+	assert.Equal(t, len(rows), 2)
+	assert.Equal(t, rows[0]["plan"], "premium")
+	assert.Equal(t, rows[0]["quota"], int64(1000))
+	assert.Equal(t, rows[1]["plan"], "basic")
+
+	query := testSQLDriver.queries[len(testSQLDriver.queries)-1]
+	assert.Equal(t, query.query, "SELECT plan, quota FROM entitlements WHERE user_id = $1 AND active = $2")
+	assert.DeepEqual(t, query.args, []driver.Value{"john", "true"})
+	assert.Check(t, query.readOnly)
+
+	evaluator.MaxRows = 1
+	obj, err = evaluator.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, len(obj.([]map[string]interface{})), 1)
+}
+
+func TestSQLCallWithNoRows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	evaluator := &SQL{Driver: "authorino-test", DSN: "empty", Query: "SELECT plan, quota FROM entitlements"}
+	defer evaluator.Clean(context.TODO())
+
+	obj, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Equal(t, len(obj.([]map[string]interface{})), 0)
+}
+
+func TestSQLCallWithUnknownDriver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+
+	evaluator := &SQL{Driver: "unknown", DSN: "entitlements", Query: "SELECT 1"}
+	_, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.ErrorContains(t, err, "unknown driver")
+}
+
+func TestSQLDriverSupported(t *testing.T) {
+	assert.Check(t, SQLDriverSupported("postgres"))
+	assert.Check(t, SQLDriverSupported("mysql"))
+	assert.Check(t, !SQLDriverSupported("authorino-test")) // registered, but not supported
+	assert.Check(t, !SQLDriverSupported("sqlite3"))
+}
+
+func TestSQLClean(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`)
+
+	evaluator := &SQL{Driver: "authorino-test", DSN: "entitlements", Query: "SELECT plan, quota FROM entitlements"}
+	assert.NilError(t, evaluator.Clean(context.TODO())) // not connected yet
+
+	_, err := evaluator.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.Check(t, evaluator.db != nil)
+	assert.NilError(t, evaluator.Clean(context.TODO()))
+	assert.Check(t, evaluator.db == nil)
+}
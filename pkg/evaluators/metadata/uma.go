@@ -1,18 +1,20 @@
 package metadata
 
 import (
-	"bytes"
 	gocontext "context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/httpclient"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/oauth2"
 
 	"go.opentelemetry.io/otel"
 	otel_propagation "go.opentelemetry.io/otel/propagation"
@@ -22,12 +24,14 @@ type providerJSON struct {
 	Issuer                  string `json:"issuer"`
 	TokenURL                string `json:"token_endpoint"`
 	ResourceRegistrationURL string `json:"resource_registration_endpoint"`
+	PermissionURL           string `json:"permission_endpoint"`
 }
 
 type Provider struct {
 	issuer                  string
 	tokenURL                string
 	resourceRegistrationURL string
+	permissionURL           string
 
 	// Raw claims returned by the server.
 	rawClaims []byte
@@ -37,27 +41,57 @@ func (provider *Provider) GetTokenURL() string {
 	return provider.tokenURL
 }
 
+func (provider *Provider) GetPermissionURL() string {
+	return provider.permissionURL
+}
+
 func (provider *Provider) GetResourcesByURI(uri string, pat PAT, ctx gocontext.Context) ([]interface{}, error) {
+	return provider.GetResources(UMAResourceQuery{URI: uri}, pat, ctx)
+}
+
+// GetResources fetches the data of the resources matching the query.
+// If the query sets a page size, pages of resource ids are fetched until all matching resources are retrieved.
+func (provider *Provider) GetResources(query UMAResourceQuery, pat PAT, ctx gocontext.Context) ([]interface{}, error) {
 	if err := context.CheckContext(ctx); err != nil {
 		return nil, err
 	}
 
-	resourceIDs, err := provider.queryResourcesByURI(uri, pat, ctx)
+	resourceIDs, err := provider.QueryResources(query, pat, ctx)
 	if err != nil {
 		return nil, err
 	}
 	return provider.getResourcesByIDs(resourceIDs, pat, ctx)
 }
 
-func (provider *Provider) queryResourcesByURI(uri string, pat PAT, ctx gocontext.Context) ([]string, error) {
+// QueryResources fetches the ids of the resources matching the query.
+func (provider *Provider) QueryResources(query UMAResourceQuery, pat PAT, ctx gocontext.Context) ([]string, error) {
+	if query.PageSize <= 0 {
+		return provider.queryResourcesPage(query.rawQuery(nil), pat, ctx)
+	}
+
+	var resourceIDs []string
+	for first := 0; ; first += query.PageSize {
+		page := url.Values{"first": {strconv.Itoa(first)}, "max": {strconv.Itoa(query.PageSize)}}
+		ids, err := provider.queryResourcesPage(query.rawQuery(page), pat, ctx)
+		if err != nil {
+			return nil, err
+		}
+		resourceIDs = append(resourceIDs, ids...)
+		if len(ids) < query.PageSize {
+			return resourceIDs, nil
+		}
+	}
+}
+
+func (provider *Provider) queryResourcesPage(rawQuery string, pat PAT, ctx gocontext.Context) ([]string, error) {
 	if err := context.CheckContext(ctx); err != nil {
 		return nil, err
 	}
 
 	queryResourcesURL, _ := url.Parse(provider.resourceRegistrationURL)
-	queryResourcesURL.RawQuery = "uri=" + uri
+	queryResourcesURL.RawQuery = rawQuery
 
-	log.FromContext(ctx).V(1).Info("querying resources by uri", "url", queryResourcesURL.String())
+	log.FromContext(ctx).V(1).Info("querying resources", "url", queryResourcesURL.String())
 
 	var resourceIDs []string
 	if err := pat.Get(queryResourcesURL.String(), ctx, &resourceIDs); err != nil {
@@ -114,6 +148,33 @@ func (provider *Provider) getResourceByID(resourceID string, pat PAT, ctx gocont
 	return data, nil
 }
 
+// UMAResourceQuery represents a query to the resource registration endpoint of the UMA server
+type UMAResourceQuery struct {
+	URI      string
+	Type     string
+	Owner    string
+	PageSize int
+}
+
+func (query UMAResourceQuery) rawQuery(page url.Values) string {
+	params := url.Values{}
+	for k, v := range page {
+		params[k] = v
+	}
+	if query.Type != "" {
+		params.Set("type", query.Type)
+	}
+	if query.Owner != "" {
+		params.Set("owner", query.Owner)
+	}
+	// the uri is passed unescaped for compatibility with previous versions
+	rawQuery := "uri=" + query.URI
+	if len(params) > 0 {
+		rawQuery += "&" + params.Encode()
+	}
+	return rawQuery
+}
+
 type PAT struct {
 	AccessToken string `json:"access_token"`
 }
@@ -137,7 +198,7 @@ func (pat *PAT) Get(rawurl string, ctx gocontext.Context, v interface{}) error {
 
 	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
 	// get the response
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
 		return err
 	}
@@ -159,11 +220,22 @@ func NewUMAMetadata(endpoint string, clientID string, clientSecret string) (*UMA
 	}
 }
 
+// UMAResourceFilter filters the resources queried from the UMA server, with values resolved from the authorization JSON.
+// If the URI is not set, resources are queried by the path of the request.
+type UMAResourceFilter struct {
+	URI   *json.JSONValue
+	Type  *json.JSONValue
+	Owner *json.JSONValue
+}
+
 type UMA struct {
 	Endpoint     string `yaml:"endpoint,omitempty"`
 	ClientID     string `yaml:"client_id"`
 	ClientSecret string `yaml:"client_secret"`
 
+	Filter   UMAResourceFilter `yaml:"filter,omitempty"`
+	PageSize int               `yaml:"pageSize,omitempty"`
+
 	provider *Provider
 }
 
@@ -172,7 +244,7 @@ func (uma *UMA) wellKnownConfigEndpoint() string {
 }
 
 func (uma *UMA) discover() error {
-	if resp, err := http.Get(uma.wellKnownConfigEndpoint()); err != nil {
+	if resp, err := httpclient.Client().Get(uma.wellKnownConfigEndpoint()); err != nil {
 		return fmt.Errorf("failed to fetch uma config: %v", err)
 	} else {
 		defer resp.Body.Close()
@@ -192,6 +264,7 @@ func (uma *UMA) discover() error {
 			issuer:                  p.Issuer,
 			tokenURL:                p.TokenURL,
 			resourceRegistrationURL: p.ResourceRegistrationURL,
+			permissionURL:           p.PermissionURL,
 			rawClaims:               rawClaims,
 		}
 
@@ -204,13 +277,12 @@ func (uma *UMA) Call(pipeline auth.AuthPipeline, parentCtx gocontext.Context) (i
 
 	// get the protection API token (PAT)
 	var pat PAT
-	if err := uma.requestPAT(ctx, &pat); err != nil {
+	if err := uma.RequestPAT(ctx, &pat); err != nil {
 		return nil, err
 	}
 
 	// get resource data
-	uri := pipeline.GetHttp().GetPath()
-	resourceData, err := uma.provider.GetResourcesByURI(uri, pat, ctx)
+	resourceData, err := uma.provider.GetResources(uma.buildResourceQuery(pipeline), pat, ctx)
 
 	if err != nil {
 		return nil, err
@@ -219,43 +291,55 @@ func (uma *UMA) Call(pipeline auth.AuthPipeline, parentCtx gocontext.Context) (i
 	return resourceData, nil
 }
 
-func (uma *UMA) clientAuthenticatedURL(rawurl string) (*url.URL, error) {
-	parsedURL, err := url.Parse(rawurl)
-	if err != nil {
-		return nil, err
+func (uma *UMA) buildResourceQuery(pipeline auth.AuthPipeline) UMAResourceQuery {
+	query := UMAResourceQuery{PageSize: uma.PageSize}
+
+	filter := uma.Filter
+	if filter.URI == nil && filter.Type == nil && filter.Owner == nil {
+		query.URI = pipeline.GetHttp().GetPath()
+		return query
 	}
-	parsedURL.User = url.UserPassword(uma.ClientID, uma.ClientSecret)
-	return parsedURL, nil
-}
 
-func (uma *UMA) requestPAT(ctx gocontext.Context, pat *PAT) error {
-	if err := context.CheckContext(ctx); err != nil {
-		return err
+	authJSON := pipeline.GetAuthorizationJSON()
+	resolve := func(value *json.JSONValue) string {
+		if value == nil {
+			return ""
+		}
+		if resolved := value.ResolveFor(authJSON); resolved != nil {
+			return fmt.Sprintf("%v", resolved)
+		}
+		return ""
 	}
 
-	// build the request
-	tokenURL, _ := uma.clientAuthenticatedURL(uma.provider.GetTokenURL())
-	data := url.Values{"grant_type": {"client_credentials"}}
-	encodedData := bytes.NewBufferString(data.Encode())
-	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL.String(), encodedData)
-	if err != nil {
-		return err
+	if filter.URI != nil {
+		query.URI = resolve(filter.URI)
+	} else {
+		query.URI = pipeline.GetHttp().GetPath()
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	query.Type = resolve(filter.Type)
+	query.Owner = resolve(filter.Owner)
 
-	log.FromContext(ctx).V(1).Info("requesting pat", "url", tokenURL.String(), "data", encodedData, "headers", req.Header)
+	return query
+}
 
-	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
-	// get the response
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
+func (uma *UMA) GetProvider() *Provider {
+	return uma.provider
+}
+
+// RequestPAT requests a protection API token (PAT) to the UMA server, authenticating with the client credentials.
+// Tokens are shared with other evaluators configured with the same client credentials, and cached until they expire.
+func (uma *UMA) RequestPAT(ctx gocontext.Context, pat *PAT) error {
+	if err := context.CheckContext(ctx); err != nil {
 		return err
 	}
 
-	// parse the pat
-	if err := json.UnmashalJSONResponse(resp, pat, nil); err != nil {
-		return fmt.Errorf("failed to decode uma pat: %v", err)
+	log.FromContext(ctx).V(1).Info("requesting pat", "url", uma.provider.GetTokenURL())
+
+	token, err := oauth2.SharedTokenManager.ClientCredentials(uma.provider.GetTokenURL(), uma.ClientID, uma.ClientSecret, nil, nil).ClientCredentialsToken(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to obtain uma pat: %v", err)
 	}
 
+	pat.AccessToken = token.AccessToken
 	return nil
 }
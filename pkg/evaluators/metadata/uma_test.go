@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"testing"
 
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/httptest"
+	authorinojson "github.com/kuadrant/authorino/pkg/json"
 
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	"github.com/golang/mock/gomock"
@@ -58,14 +60,14 @@ func TestUMAMetadataFailToDecodeConfig(t *testing.T) {
 func TestUMACall(t *testing.T) {
 	jsonResponse := func(body string) httptest.HttpServerMockResponseFunc {
 		return func() httptest.HttpServerMockResponse {
-			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Context-Type": "application/json"}, Body: body}
+			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: body}
 		}
 	}
 
 	resourceData := `{"_id":"44f93c94-a8d0-4b33-8188-8173e86844d2","name":"some-resource","uris":["/someresource"]}`
 	httpServer := httptest.NewHttpServerMock(umaServerHost, map[string]httptest.HttpServerMockResponseFunc{
 		"/uma/.well-known/uma2-configuration":                    jsonResponse(umaWellKnownConfig),
-		"/uma/pat":                                               jsonResponse(`{"some-pat-claim": "some-value"}`),
+		"/uma/pat":                                               jsonResponse(`{"access_token": "some-pat", "some-pat-claim": "some-value"}`),
 		"/uma/resource_set?uri=/someresource":                    jsonResponse(`["44f93c94-a8d0-4b33-8188-8173e86844d2"]`),
 		"/uma/resource_set/44f93c94-a8d0-4b33-8188-8173e86844d2": jsonResponse(resourceData),
 	})
@@ -86,3 +88,46 @@ func TestUMACall(t *testing.T) {
 	assert.Equal(t, "["+resourceData+"]", string(data))
 	assert.NilError(t, err)
 }
+
+func TestUMACallWithFilterAndPagination(t *testing.T) {
+	jsonResponse := func(body string) httptest.HttpServerMockResponseFunc {
+		return func() httptest.HttpServerMockResponse {
+			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: body}
+		}
+	}
+
+	httpServer := httptest.NewHttpServerMock(umaServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/uma/.well-known/uma2-configuration": jsonResponse(umaWellKnownConfig),
+		"/uma/pat":                            jsonResponse(`{"access_token": "some-pat", "some-pat-claim": "some-value"}`),
+		"/uma/resource_set?uri=/greetings&first=0&max=2&owner=john&type=doc": jsonResponse(`["r1","r2"]`),
+		"/uma/resource_set?uri=/greetings&first=2&max=2&owner=john&type=doc": jsonResponse(`["r3"]`),
+		"/uma/resource_set/r1": jsonResponse(`{"_id":"r1"}`),
+		"/uma/resource_set/r2": jsonResponse(`{"_id":"r2"}`),
+		"/uma/resource_set/r3": jsonResponse(`{"_id":"r3"}`),
+	})
+	defer httpServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"context":{"request":{"http":{"path":"/greetings"}}},"auth":{"identity":{"username":"john"}}}`)
+
+	uma, _ := NewUMAMetadata(umaIssuer, "client-id", "client-secret")
+	uma.Filter = UMAResourceFilter{
+		URI:   &authorinojson.JSONValue{Pattern: "context.request.http.path"},
+		Type:  &authorinojson.JSONValue{Static: "doc"},
+		Owner: &authorinojson.JSONValue{Pattern: "auth.identity.username"},
+	}
+	uma.PageSize = 2
+
+	obj, err := uma.Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+
+	ids := []string{}
+	for _, resource := range obj.([]interface{}) {
+		ids = append(ids, resource.(map[string]interface{})["_id"].(string))
+	}
+	sort.Strings(ids)
+	assert.DeepEqual(t, ids, []string{"r1", "r2", "r3"})
+}
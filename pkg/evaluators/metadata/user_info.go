@@ -2,21 +2,46 @@ package metadata
 
 import (
 	gocontext "context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
+	"github.com/kuadrant/authorino/pkg/httpclient"
 	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/lru"
+	"github.com/kuadrant/authorino/pkg/singleflight"
 
 	"go.opentelemetry.io/otel"
 	otel_propagation "go.opentelemetry.io/otel/propagation"
 )
 
+// UserInfoCacheMaxEntries is the maximum number of UserInfo responses cached by each UserInfo metadata evaluator
+var UserInfoCacheMaxEntries = 10000
+
 type UserInfo struct {
 	OIDC *identity.OIDC `yaml:"oidc,omitempty"`
+
+	ttl      int
+	cache    *lru.Cache
+	inflight singleflight.Group
+}
+
+// NewUserInfo creates a UserInfo metadata evaluator.
+// If ttl is greater than 0, the UserInfo responses are cached for ttl seconds, keyed by a hash of the access token.
+func NewUserInfo(oidc *identity.OIDC, ttl int) *UserInfo {
+	userinfo := &UserInfo{
+		OIDC: oidc,
+		ttl:  ttl,
+	}
+	if ttl > 0 {
+		userinfo.cache = lru.New("userinfo", lru.Options{MaxEntries: UserInfoCacheMaxEntries, TTL: time.Duration(ttl) * time.Second})
+	}
+	return userinfo
 }
 
 func (userinfo *UserInfo) Call(pipeline auth.AuthPipeline, parentCtx gocontext.Context) (interface{}, error) {
@@ -24,9 +49,11 @@ func (userinfo *UserInfo) Call(pipeline auth.AuthPipeline, parentCtx gocontext.C
 	oidc := userinfo.OIDC
 
 	// check if corresponding oidc identity was resolved
-	resolvedIdentity, _ := pipeline.GetResolvedIdentity()
-	identityEvaluator, _ := resolvedIdentity.(auth.IdentityConfigEvaluator)
-	if resolvedOIDC, _ := identityEvaluator.GetOIDC().(*identity.OIDC); resolvedOIDC == nil || resolvedOIDC.Endpoint != oidc.Endpoint {
+	resolvedIdentity := pipeline.GetIdentity()
+	if !resolvedIdentity.Resolved() {
+		return nil, fmt.Errorf("Missing identity for OIDC issuer %v. Skipping related UserInfo metadata.", oidc.Endpoint)
+	}
+	if resolvedOIDC, _ := resolvedIdentity.Source.GetOIDC().(*identity.OIDC); resolvedOIDC == nil || resolvedOIDC.Endpoint != oidc.Endpoint {
 		return nil, fmt.Errorf("Missing identity for OIDC issuer %v. Skipping related UserInfo metadata.", oidc.Endpoint)
 	}
 
@@ -36,12 +63,52 @@ func (userinfo *UserInfo) Call(pipeline auth.AuthPipeline, parentCtx gocontext.C
 		return nil, err
 	}
 
-	// fetch user info
-	if userInfoURL, err := oidc.GetURL("userinfo_endpoint", ctx); err != nil {
-		return nil, err
-	} else {
-		return fetchUserInfo(userInfoURL.String(), accessToken, ctx)
+	cacheKey := userInfoCacheKey(accessToken)
+	if cachedClaims := userinfo.getCachedUserInfo(cacheKey); cachedClaims != nil {
+		log.FromContext(ctx).V(1).Info("user info from cache")
+		return cachedClaims, nil
+	}
+
+	// fetch user info (concurrent requests with the same access token share a single call to the userinfo endpoint)
+	claims, err, _ := userinfo.inflight.Do(cacheKey, func() (interface{}, error) {
+		userInfoURL, err := oidc.GetURL("userinfo_endpoint", ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		claims, err := fetchUserInfo(userInfoURL.String(), accessToken, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		userinfo.cacheUserInfo(cacheKey, claims)
+		return claims, nil
+	})
+
+	return claims, err
+}
+
+func (userinfo *UserInfo) getCachedUserInfo(key string) interface{} {
+	if userinfo.cache == nil {
+		return nil
+	}
+	if claims, found := userinfo.cache.Get(key); found {
+		return claims
 	}
+	return nil
+}
+
+func (userinfo *UserInfo) cacheUserInfo(key string, claims interface{}) {
+	if userinfo.cache == nil {
+		return
+	}
+	userinfo.cache.Add(key, claims)
+}
+
+// userInfoCacheKey hashes the access token so the cache does not hold the credential itself
+func userInfoCacheKey(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return string(hash[:])
 }
 
 func fetchUserInfo(userInfoEndpoint string, accessToken string, ctx gocontext.Context) (interface{}, error) {
@@ -59,7 +126,7 @@ func fetchUserInfo(userInfoEndpoint string, accessToken string, ctx gocontext.Co
 
 	otel.GetTextMapPropagator().Inject(ctx, otel_propagation.HeaderCarrier(req.Header))
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Client().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 	"github.com/kuadrant/authorino/pkg/httptest"
@@ -19,6 +20,8 @@ const (
 	userInfoClaims string = `{ "sub": "831707be-ef07-4d63-b427-4216309e9897" }`
 )
 
+var userInfoRequests int
+
 var wellKnownOIDCConfig string = fmt.Sprintf(`{
 		"issuer": "http://%s",
 		"userinfo_endpoint": "http://%s/userinfo"
@@ -42,7 +45,7 @@ func newUserInfoTestData(ctrl *gomock.Controller) userInfoTestData {
 		ctx,
 		cancel,
 		newOIDC,
-		UserInfo{newOIDC},
+		UserInfo{OIDC: newOIDC},
 		authCredMock,
 		mock_auth.NewMockAuthPipeline(ctrl),
 		mock_auth.NewMockIdentityConfigEvaluator(ctrl),
@@ -54,6 +57,7 @@ func TestMain(m *testing.M) {
 			return httptest.HttpServerMockResponse{Status: 200, Body: wellKnownOIDCConfig}
 		},
 		"/userinfo": func() httptest.HttpServerMockResponse {
+			userInfoRequests += 1
 			return httptest.HttpServerMockResponse{Status: 200, Body: userInfoClaims}
 		},
 	})
@@ -69,7 +73,7 @@ func TestUserInfoCall(t *testing.T) {
 	ta.authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("", nil)
 	ta.idConfEvalMock.EXPECT().GetOIDC().Return(ta.newOIDC)
 	ta.pipelineMock.EXPECT().GetHttp().Return(nil)
-	ta.pipelineMock.EXPECT().GetResolvedIdentity().Return(ta.idConfEvalMock, nil)
+	ta.pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Source: ta.idConfEvalMock})
 
 	obj, err := ta.userInfo.Call(ta.pipelineMock, ta.ctx)
 
@@ -87,7 +91,7 @@ func TestUserInfoCanceledContext(t *testing.T) {
 	ta.authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("", nil)
 	ta.idConfEvalMock.EXPECT().GetOIDC().Return(ta.newOIDC)
 	ta.pipelineMock.EXPECT().GetHttp().Return(nil)
-	ta.pipelineMock.EXPECT().GetResolvedIdentity().Return(ta.idConfEvalMock, nil)
+	ta.pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Source: ta.idConfEvalMock})
 
 	ta.cancel()
 	_, err := ta.userInfo.Call(ta.pipelineMock, ta.ctx)
@@ -102,8 +106,33 @@ func TestUserInfoMissingOIDCConfig(t *testing.T) {
 
 	otherOidcEvaluator := identity.NewOIDC("http://wrongServer", ta.authCredMock, 0, context.TODO())
 	ta.idConfEvalMock.EXPECT().GetOIDC().Return(otherOidcEvaluator)
-	ta.pipelineMock.EXPECT().GetResolvedIdentity().Return(ta.idConfEvalMock, nil)
+	ta.pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Source: ta.idConfEvalMock})
 
 	_, err := ta.userInfo.Call(ta.pipelineMock, ta.ctx)
 	assert.Error(t, err, "Missing identity for OIDC issuer http://127.0.0.1:9002. Skipping related UserInfo metadata.")
 }
+
+func TestUserInfoCallWithCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ta := newUserInfoTestData(ctrl)
+	userInfo := NewUserInfo(ta.newOIDC, 60)
+
+	ta.idConfEvalMock.EXPECT().GetOIDC().Return(ta.newOIDC).Times(3)
+	ta.pipelineMock.EXPECT().GetHttp().Return(nil).Times(3)
+	ta.pipelineMock.EXPECT().GetIdentity().Return(auth.ResolvedIdentity{Source: ta.idConfEvalMock}).Times(3)
+	requestsBefore := userInfoRequests
+
+	ta.authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("token-1", nil).Times(2)
+	for i := 0; i < 2; i++ {
+		obj, err := userInfo.Call(ta.pipelineMock, ta.ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, "831707be-ef07-4d63-b427-4216309e9897", obj.(map[string]interface{})["sub"])
+	}
+	assert.Equal(t, userInfoRequests-requestsBefore, 1)
+
+	ta.authCredMock.EXPECT().GetCredentialsFromReq(gomock.Any()).Return("token-2", nil)
+	_, err := userInfo.Call(ta.pipelineMock, ta.ctx)
+	assert.NilError(t, err)
+	assert.Equal(t, userInfoRequests-requestsBefore, 2)
+}
@@ -3,6 +3,8 @@ package evaluators
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -84,3 +86,46 @@ func TestMetadataCaching(t *testing.T) {
 	assert.Equal(t, metadataObjectJSON["foo"], "bar")
 	assert.NilError(t, err)
 }
+
+func TestMetadataCachingConcurrentCalls(t *testing.T) {
+	var hits int32
+	extHttpMetadataServer := httptest.NewHttpServerMock(testMetadataServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/metadata": func() httptest.HttpServerMockResponse {
+			atomic.AddInt32(&hits, 1)
+			time.Sleep(500 * time.Millisecond)
+			return httptest.HttpServerMockResponse{Status: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"foo":"bar"}`}
+		},
+	})
+	defer extHttpMetadataServer.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{}`).AnyTimes()
+
+	metadataConfig := MetadataConfig{
+		Name: "test",
+		GenericHTTP: &metadata.GenericHttp{
+			Endpoint:        fmt.Sprintf("http://%s/metadata", testMetadataServerHost),
+			Method:          "GET",
+			AuthCredentials: auth.NewAuthCredential("", "authorization_header"),
+		},
+		Cache: NewEvaluatorCache(json.JSONValue{Static: "x"}, 60),
+	}
+	defer metadataConfig.Clean(context.TODO())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metadataObject, err := metadataConfig.Call(pipelineMock, context.TODO())
+			assert.NilError(t, err)
+			assert.Equal(t, metadataObject.(map[string]interface{})["foo"], "bar")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, atomic.LoadInt32(&hits), int32(1))
+}
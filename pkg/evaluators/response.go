@@ -14,7 +14,11 @@ const (
 	responseWristband = "RESPONSE_WRISTBAND"
 	responseJSON      = "RESPONSE_JSON"
 
+	responseRateLimitDescriptors = "RESPONSE_RATE_LIMIT_DESCRIPTORS"
+
 	HTTP_HEADER_WRAPPER            = "httpHeader"
+	HTTP_RESPONSE_HEADER_WRAPPER   = "httpResponseHeader"
+	HTTP_COOKIE_WRAPPER            = "httpCookie"
 	ENVOY_DYNAMIC_METADATA_WRAPPER = "envoyDynamicMetadata"
 
 	DEFAULT_WRAPPER = HTTP_HEADER_WRAPPER
@@ -50,8 +54,12 @@ type ResponseConfig struct {
 	Metrics    bool                           `yaml:"metrics"`
 	Cache      EvaluatorCache
 
-	Wristband   auth.WristbandIssuer  `yaml:"wristband,omitempty"`
-	DynamicJSON *response.DynamicJSON `yaml:"json,omitempty"`
+	// Cookie holds the attributes of the cookie set with the response, when wrapped as httpCookie
+	Cookie *response.Cookie `yaml:"cookie,omitempty"`
+
+	Wristband            auth.WristbandIssuer           `yaml:"wristband,omitempty"`
+	DynamicJSON          *response.DynamicJSON          `yaml:"json,omitempty"`
+	RateLimitDescriptors *response.RateLimitDescriptors `yaml:"rateLimitDescriptors,omitempty"`
 }
 
 func (config *ResponseConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator {
@@ -60,6 +68,8 @@ func (config *ResponseConfig) GetAuthConfigEvaluator() auth.AuthConfigEvaluator
 		return config.Wristband
 	case responseJSON:
 		return config.DynamicJSON
+	case responseRateLimitDescriptors:
+		return config.RateLimitDescriptors
 	default:
 		return nil
 	}
@@ -85,15 +95,13 @@ func (config *ResponseConfig) Call(pipeline auth.AuthPipeline, ctx context.Conte
 			}
 		}
 
-		obj, err := evaluator.Call(pipeline, log.IntoContext(ctx, logger))
-
-		if err == nil && cacheKey != nil {
-			if err := cache.Set(cacheKey, obj); err != nil {
-				logger.V(1).Info("unable to store data in the cache", "err", err)
-			}
+		if cacheKey == nil {
+			return evaluator.Call(pipeline, log.IntoContext(ctx, logger))
 		}
 
-		return obj, err
+		return callAndCache(cache, cacheKey, func() (interface{}, error) {
+			return evaluator.Call(pipeline, log.IntoContext(ctx, logger))
+		}, logger)
 	}
 }
 
@@ -111,6 +119,8 @@ func (config *ResponseConfig) GetType() string {
 		return responseWristband
 	case config.DynamicJSON != nil:
 		return responseJSON
+	case config.RateLimitDescriptors != nil:
+		return responseRateLimitDescriptors
 	default:
 		return ""
 	}
@@ -140,18 +150,37 @@ func (config *ResponseConfig) MetricsEnabled() bool {
 	return config.Metrics
 }
 
-func WrapResponses(responses map[*ResponseConfig]interface{}) (responseHeaders map[string]string, responseMetadata map[string]interface{}) {
+// WrapResponses wraps the dynamic responses according to the wrapper of each response config, into HTTP headers to be
+// added to the request sent upstream, HTTP headers to be added to the response sent to the client, and Envoy dynamic metadata.
+// Each cookie is set in its own set of headers added to the response sent to the client, after the other headers.
+func WrapResponses(responses map[*ResponseConfig]interface{}) (responseHeaders map[string]string, clientResponseHeaders []map[string]string, responseMetadata map[string]interface{}) {
 	responseHeaders = make(map[string]string)
+	headers := make(map[string]string)
+	cookies := make([]map[string]string, 0)
 	responseMetadata = make(map[string]interface{})
 
 	for responseConfig, authObj := range responses {
 		switch responseConfig.Wrapper {
 		case HTTP_HEADER_WRAPPER:
 			responseHeaders[responseConfig.WrapperKey], _ = json.StringifyJSON(authObj)
+		case HTTP_RESPONSE_HEADER_WRAPPER:
+			headers[responseConfig.WrapperKey], _ = json.StringifyJSON(authObj)
+		case HTTP_COOKIE_WRAPPER:
+			cookie := responseConfig.Cookie
+			if cookie == nil {
+				cookie = &response.Cookie{}
+			}
+			value, _ := json.StringifyJSON(authObj)
+			cookies = append(cookies, map[string]string{"set-cookie": cookie.SetCookie(responseConfig.WrapperKey, value)})
 		case ENVOY_DYNAMIC_METADATA_WRAPPER:
 			responseMetadata[responseConfig.WrapperKey] = authObj
 		}
 	}
 
-	return responseHeaders, responseMetadata
+	if len(headers) > 0 {
+		clientResponseHeaders = append(clientResponseHeaders, headers)
+	}
+	clientResponseHeaders = append(clientResponseHeaders, cookies...)
+
+	return responseHeaders, clientResponseHeaders, responseMetadata
 }
@@ -0,0 +1,65 @@
+package response
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Cookie holds the attributes of the cookies set in the responses to the clients
+type Cookie struct {
+	Domain string
+	Path   string
+	// MaxAge is the time to live of the cookie, in seconds; 0 means a session cookie
+	MaxAge   int
+	Secure   bool
+	HttpOnly bool
+	// SameSite is one of "Strict", "Lax" or "None"; empty means no SameSite attribute
+	SameSite string
+}
+
+// SetCookie formats the value of a Set-Cookie header.
+// Values with characters not allowed in cookies (e.g. JSON objects) are URL-encoded.
+func (c *Cookie) SetCookie(name, value string) string {
+	if !isCookieValue(value) {
+		value = url.QueryEscape(value)
+	}
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Domain:   c.Domain,
+		Path:     c.Path,
+		MaxAge:   c.MaxAge,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+	}
+	switch strings.ToLower(c.SameSite) {
+	case "strict":
+		cookie.SameSite = http.SameSiteStrictMode
+	case "lax":
+		cookie.SameSite = http.SameSiteLaxMode
+	case "none":
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	return cookie.String()
+}
+
+// IsCookieName tells whether a name is valid for a cookie (RFC 6265)
+func IsCookieName(name string) bool {
+	if name == "" {
+		return false
+	}
+	return strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || strings.ContainsRune("!#$%&'*+-.^_`|~", r))
+	}) == -1
+}
+
+// isCookieValue tells whether all characters of a value are allowed in cookies (RFC 6265)
+func isCookieValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if b := value[i]; b <= 0x20 || b >= 0x7f || b == '"' || b == ',' || b == ';' || b == '\\' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,26 @@
+package response
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSetCookie(t *testing.T) {
+	cookie := &Cookie{}
+	assert.Equal(t, cookie.SetCookie("session", "eyJhbGciOiJFUzI1NiJ9.e30.c2ln"), "session=eyJhbGciOiJFUzI1NiJ9.e30.c2ln")
+
+	cookie = &Cookie{Domain: "my-app.io", Path: "/", MaxAge: 300, Secure: true, HttpOnly: true, SameSite: "Lax"}
+	assert.Equal(t, cookie.SetCookie("session", "abc"), "session=abc; Path=/; Domain=my-app.io; Max-Age=300; HttpOnly; Secure; SameSite=Lax")
+
+	// values not allowed in cookies are url-encoded
+	assert.Equal(t, (&Cookie{}).SetCookie("user", `{"name":"John Doe"}`), "user=%7B%22name%22%3A%22John+Doe%22%7D")
+}
+
+func TestIsCookieName(t *testing.T) {
+	assert.Check(t, IsCookieName("session"))
+	assert.Check(t, IsCookieName("__Host-wristband"))
+	assert.Check(t, !IsCookieName(""))
+	assert.Check(t, !IsCookieName("my session"))
+	assert.Check(t, !IsCookieName("session;"))
+}
@@ -0,0 +1,42 @@
+package response
+
+import (
+	"context"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/json"
+)
+
+func NewRateLimitDescriptors(entries []json.JSONProperty) *RateLimitDescriptors {
+	return &RateLimitDescriptors{
+		Entries: entries,
+	}
+}
+
+// RateLimitDescriptors builds the entries of the descriptors of a rate limit service (e.g. Limitador), as a flat
+// object of strings, to be read by the 'metadata' actions of the rate limits of Envoy.
+type RateLimitDescriptors struct {
+	Entries []json.JSONProperty
+}
+
+func (d *RateLimitDescriptors) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
+	obj := make(map[string]interface{})
+
+	authJSON := pipeline.GetAuthorizationJSON()
+
+	for _, entry := range d.Entries {
+		value := entry.Value
+		resolved := value.ResolveFor(authJSON)
+		if resolved == nil {
+			// entries without value are omitted, so the rate limit actions can skip them (or not apply the limit)
+			continue
+		}
+		if descriptorValue, err := json.StringifyJSON(resolved); err != nil {
+			return nil, err
+		} else if descriptorValue != "" {
+			obj[entry.Name] = descriptorValue
+		}
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,37 @@
+package response
+
+import (
+	"context"
+	"testing"
+
+	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
+	"github.com/kuadrant/authorino/pkg/json"
+	"gotest.tools/assert"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestRateLimitDescriptorsCall(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	entries := []json.JSONProperty{
+		{Name: "api", Value: json.JSONValue{Static: "pets"}},
+		{Name: "user_id", Value: json.JSONValue{Pattern: "auth.identity.sub"}},
+		{Name: "plan", Value: json.JSONValue{Pattern: "auth.identity.metadata.annotations.plan"}},
+		{Name: "max_pets", Value: json.JSONValue{Pattern: "auth.identity.max_pets"}},
+		{Name: "org", Value: json.JSONValue{Pattern: "auth.identity.org"}},
+	}
+
+	pipelineMock := mock_auth.NewMockAuthPipeline(ctrl)
+	pipelineMock.EXPECT().GetAuthorizationJSON().Return(`{"auth":{"identity":{"sub":"john","max_pets":3,"metadata":{"annotations":{"plan":"gold"}}}}}`)
+
+	descriptors, err := NewRateLimitDescriptors(entries).Call(pipelineMock, context.TODO())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, descriptors, map[string]interface{}{
+		"api":      "pets",
+		"user_id":  "john",
+		"plan":     "gold",
+		"max_pets": "3",
+	})
+}
@@ -2,11 +2,16 @@ package response
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/x509"
 	gojson "encoding/json"
 	"encoding/pem"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
@@ -15,10 +20,24 @@ import (
 
 	"github.com/golang-jwt/jwt"
 	jose "gopkg.in/square/go-jose.v2"
+	v1 "k8s.io/api/core/v1"
 )
 
 const DEFAULT_WRISTBAND_DURATION = int64(300)
 
+// reservedClaims are the claims set by the issuer, that cannot be overridden by custom claims
+var reservedClaims = []string{"iss", "iat", "exp"}
+
+// IsReservedClaim tells whether a claim is set by the wristband issuer and therefore cannot be a custom claim
+func IsReservedClaim(name string) bool {
+	for _, claim := range reservedClaims {
+		if claim == name {
+			return true
+		}
+	}
+	return false
+}
+
 func NewSigningKey(name string, algorithm string, singingKey []byte) (*jose.JSONWebKey, error) {
 	signingKey := &jose.JSONWebKey{
 		KeyID:     name,
@@ -47,13 +66,38 @@ func NewSigningKey(name string, algorithm string, singingKey []byte) (*jose.JSON
 			signingKey.Key = key
 		}
 
+	case "PRIVATE":
+		// PKCS #8, the only format of Ed25519 keys
+		if key, err := x509.ParsePKCS8PrivateKey(keyPEM.Bytes); err != nil {
+			return nil, err
+		} else {
+			signingKey.Key = key
+		}
+
 	default:
 		return nil, fmt.Errorf("invalid signing key algorithm")
 	}
 
+	if !signingKeyMatchesAlgorithm(signingKey.Key, algorithm) {
+		return nil, fmt.Errorf("signing key does not match the algorithm %s", algorithm)
+	}
+
 	return signingKey, nil
 }
 
+func signingKeyMatchesAlgorithm(key interface{}, algorithm string) bool {
+	switch key.(type) {
+	case *ecdsa.PrivateKey:
+		return strings.HasPrefix(algorithm, "ES")
+	case *rsa.PrivateKey:
+		return strings.HasPrefix(algorithm, "RS")
+	case ed25519.PrivateKey:
+		return algorithm == "EdDSA"
+	default:
+		return false
+	}
+}
+
 type Claims map[string]interface{}
 
 func (c *Claims) Valid() error {
@@ -68,6 +112,16 @@ func NewWristbandConfig(issuer string, claims []json.JSONProperty, tokenDuration
 	} else {
 		duration = DEFAULT_WRISTBAND_DURATION
 	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid token duration %d", duration)
+	}
+
+	// custom claims
+	for _, claim := range claims {
+		if IsReservedClaim(claim.Name) {
+			return nil, fmt.Errorf("custom claim %s is reserved", claim.Name)
+		}
+	}
 
 	// signing keys
 	if len(signingKeys) == 0 {
@@ -87,18 +141,22 @@ type Wristband struct {
 	CustomClaims  []json.JSONProperty
 	TokenDuration int64
 	SigningKeys   []jose.JSONWebKey
+
+	// protects the signing keys, that can be rotated while the wristbands are issued
+	mu sync.RWMutex
 }
 
 func (w *Wristband) Call(pipeline auth.AuthPipeline, ctx context.Context) (interface{}, error) {
 	// resolved identity
-	identityConfig, resolvedidentity := pipeline.GetResolvedIdentity()
+	resolvedIdentity := pipeline.GetIdentity()
 
-	identityEvaluator, _ := identityConfig.(auth.IdentityConfigEvaluator)
-	if resolvedOIDC, _ := identityEvaluator.GetOIDC().(*identity.OIDC); resolvedOIDC != nil && resolvedOIDC.Endpoint == w.GetIssuer() {
-		return nil, nil
+	if resolvedIdentity.Resolved() {
+		if resolvedOIDC, _ := resolvedIdentity.Source.GetOIDC().(*identity.OIDC); resolvedOIDC != nil && resolvedOIDC.Endpoint == w.GetIssuer() {
+			return nil, nil
+		}
 	}
 
-	idStr, _ := gojson.Marshal(resolvedidentity)
+	idStr, _ := gojson.Marshal(resolvedIdentity.Object)
 	hash := sha256.New()
 	hash.Write(idStr)
 	sub := fmt.Sprintf("%x", hash.Sum(nil))
@@ -120,12 +178,17 @@ func (w *Wristband) Call(pipeline auth.AuthPipeline, ctx context.Context) (inter
 
 		for _, claim := range w.CustomClaims {
 			value := claim.Value
-			claims[claim.Name] = value.ResolveFor(authJSON)
+			// claims whose values are not found in the authorization json are omitted
+			if resolved := value.ResolveFor(authJSON); resolved != nil {
+				claims[claim.Name] = resolved
+			}
 		}
 	}
 
 	// signing key
+	w.mu.RLock()
 	signingKey := w.SigningKeys[0]
+	w.mu.RUnlock()
 
 	token := jwt.NewWithClaims(jwt.GetSigningMethod(signingKey.Algorithm), &claims)
 	token.Header["kid"] = signingKey.KeyID
@@ -152,7 +215,7 @@ func (w *Wristband) OpenIDConfig() (string, error) {
 	config := &oidcConfig{
 		Issuer:               issuer,
 		JWKSURI:              fmt.Sprintf("%v/.well-known/openid-connect/certs", issuer),
-		SupportedSigningAlgs: []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512"},
+		SupportedSigningAlgs: []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "EdDSA"},
 	}
 
 	if configJSON, err := gojson.Marshal(config); err != nil {
@@ -165,9 +228,11 @@ func (w *Wristband) OpenIDConfig() (string, error) {
 func (w *Wristband) JWKS() (string, error) {
 	publicKeys := make([]jose.JSONWebKey, 0)
 
+	w.mu.RLock()
 	for _, signingKey := range w.SigningKeys {
 		publicKeys = append(publicKeys, signingKey.Public())
 	}
+	w.mu.RUnlock()
 
 	jwks := jose.JSONWebKeySet{
 		Keys: publicKeys,
@@ -179,3 +244,27 @@ func (w *Wristband) JWKS() (string, error) {
 		return string(encodedJWKS), nil
 	}
 }
+
+// RefreshK8sSecretBasedSigningKey replaces the signing key stored in the Kubernetes secret, if the secret is referred
+// by the wristband config, so the key can be rotated without reconciling the AuthConfig.
+// The key keeps the algorithm set in the AuthConfig; a key that cannot be parsed does not replace the current one.
+func (w *Wristband) RefreshK8sSecretBasedSigningKey(_ context.Context, secret v1.Secret) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i, signingKey := range w.SigningKeys {
+		if signingKey.KeyID != secret.Name {
+			continue
+		}
+		newSigningKey, err := NewSigningKey(signingKey.KeyID, signingKey.Algorithm, secret.Data["key.pem"])
+		if err != nil {
+			return false, err
+		}
+		signingKeys := make([]jose.JSONWebKey, len(w.SigningKeys))
+		copy(signingKeys, w.SigningKeys)
+		signingKeys[i] = *newSigningKey
+		w.SigningKeys = signingKeys
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,33 @@
+package evaluators
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/evaluators/response"
+
+	"gotest.tools/assert"
+)
+
+func TestWrapResponses(t *testing.T) {
+	cookie := NewResponseConfig("session", 0, nil, HTTP_COOKIE_WRAPPER, "", false)
+	cookie.Cookie = &response.Cookie{Path: "/", HttpOnly: true}
+
+	responses := map[*ResponseConfig]interface{}{
+		NewResponseConfig("x-user", 0, nil, "", "", false):                                              map[string]interface{}{"name": "john"},
+		NewResponseConfig("tier", 0, nil, HTTP_RESPONSE_HEADER_WRAPPER, "x-tier", false):                "gold",
+		NewResponseConfig("rate-limit", 0, nil, ENVOY_DYNAMIC_METADATA_WRAPPER, "ext_auth_data", false): map[string]interface{}{"username": "john"},
+		cookie: "wristband-token",
+		NewResponseConfig("theme", 0, nil, HTTP_COOKIE_WRAPPER, "", false): "dark",
+	}
+
+	responseHeaders, clientResponseHeaders, responseMetadata := WrapResponses(responses)
+
+	assert.DeepEqual(t, responseHeaders, map[string]string{"x-user": `{"name":"john"}`})
+	assert.Equal(t, len(clientResponseHeaders), 3)
+	assert.DeepEqual(t, clientResponseHeaders[0], map[string]string{"x-tier": "gold"})
+	cookies := []string{clientResponseHeaders[1]["set-cookie"], clientResponseHeaders[2]["set-cookie"]}
+	sort.Strings(cookies)
+	assert.DeepEqual(t, cookies, []string{"session=wristband-token; Path=/; HttpOnly", "theme=dark"})
+	assert.DeepEqual(t, responseMetadata, map[string]interface{}{"ext_auth_data": map[string]interface{}{"username": "john"}})
+}
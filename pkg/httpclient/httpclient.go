@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/circuitbreaker"
+	"github.com/kuadrant/authorino/pkg/metrics"
+)
+
+var (
+	shared = New(Options{})
+
+	requestsInFlightMetric = metrics.NewGaugeMetric("http_client_requests_in_flight", "Number of outbound HTTP requests to external endpoints currently in flight.", "host")
+	connectionsMetric      = metrics.NewCounterMetric("http_client_connections_total", "Total number of connections obtained from the pool of the outbound HTTP client, partitioned by whether the connection was reused.", "host", "reused")
+	requestDurationMetric  = metrics.NewDurationMetric("http_client_request_duration_seconds", "Response latency of the outbound HTTP requests to external endpoints (seconds), partitioned by host.", "host")
+	requestErrorsMetric    = metrics.NewCounterMetric("http_client_request_errors_total", "Total number of outbound HTTP requests to external endpoints that failed, partitioned by host and reason.", "host", "reason")
+)
+
+const (
+	errorReasonTimeout     = "timeout"
+	errorReasonConnection  = "connection"
+	errorReasonServerError = "server_error"
+)
+
+func init() {
+	metrics.Register(
+		requestsInFlightMetric,
+		connectionsMetric,
+		requestDurationMetric,
+		requestErrorsMetric,
+	)
+}
+
+// Options of the shared HTTP client
+type Options struct {
+	// Timeout of each outbound request, including reading the response body. 0 means no timeout.
+	Timeout time.Duration
+	// MaxIdleConns is the maximum number of idle (keep-alive) connections across all hosts. 0 means the default of 100.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of idle (keep-alive) connections to keep per host. 0 means the default of 10.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection remains in the pool. 0 means the default of 90 seconds.
+	IdleConnTimeout time.Duration
+	// CACertPath is the path to a PEM bundle of certificate authorities trusted in addition to the system ones
+	CACertPath string
+}
+
+// Configure replaces the shared HTTP client with a new one built with the given options.
+// It is meant to be called once at startup, before any outbound call is made.
+func Configure(opts Options) error {
+	client, err := newClient(opts)
+	if err != nil {
+		return err
+	}
+	shared = client
+	return nil
+}
+
+// Client returns the shared HTTP client used by the evaluators to call external endpoints.
+// Outbound requests go through a pooled transport that honors the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables,
+// and are protected by the circuit breakers.
+func Client() *http.Client {
+	return shared
+}
+
+// New returns an HTTP client built with the given options, ignoring the options that fail to apply
+func New(opts Options) *http.Client {
+	client, _ := newClient(opts)
+	return client
+}
+
+func newClient(opts Options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.MaxIdleConns = valueOrDefault(opts.MaxIdleConns, 100)
+	transport.MaxIdleConnsPerHost = valueOrDefault(opts.MaxIdleConnsPerHost, 10)
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+
+	var err error
+	if opts.CACertPath != "" {
+		var rootCAs *x509.CertPool
+		if rootCAs, err = loadCACerts(opts.CACertPath); err == nil {
+			transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+		}
+	}
+
+	return &http.Client{
+		Transport: &circuitbreaker.Transport{Base: &instrumentedTransport{base: transport}},
+		Timeout:   opts.Timeout,
+	}, err
+}
+
+func loadCACerts(path string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificates: %v", err)
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no valid CA certificate found in %s", path)
+	}
+	return rootCAs, nil
+}
+
+func valueOrDefault(value, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// instrumentedTransport reports metrics on the usage of the connection pool, and the latency and errors of the requests per host
+type instrumentedTransport struct {
+	base http.RoundTripper
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	inFlight := requestsInFlightMetric.WithLabelValues(host)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.ReportMetric(connectionsMetric, host, strconv.FormatBool(info.Reused))
+		},
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	requestDurationMetric.WithLabelValues(host).Observe(time.Since(start).Seconds())
+
+	if reason := errorReason(req.Context(), resp, err); reason != "" {
+		metrics.ReportMetric(requestErrorsMetric, host, reason)
+	}
+
+	return resp, err
+}
+
+// errorReason tells why a request to an external endpoint failed, or empty if it did not fail
+func errorReason(ctx context.Context, resp *http.Response, err error) string {
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return errorReasonTimeout
+		}
+		return errorReasonConnection
+	}
+	if resp.StatusCode >= 500 {
+		return errorReasonServerError
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	gohttptest "net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestClientReusesConnections(t *testing.T) {
+	server := gohttptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(Options{})
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		assert.NilError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	assert.Equal(t, testutil.ToFloat64(connectionsMetric.WithLabelValues(host, "false")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(connectionsMetric.WithLabelValues(host, "true")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(requestsInFlightMetric.WithLabelValues(host)), float64(0))
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := gohttptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	_, err := New(Options{Timeout: 20 * time.Millisecond}).Get(server.URL)
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+}
+
+func TestClientRequestMetrics(t *testing.T) {
+	server := gohttptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/fail" {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if req.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	client := New(Options{Timeout: 20 * time.Millisecond})
+	series := testutil.CollectAndCount(requestDurationMetric)
+
+	resp, err := client.Get(server.URL)
+	assert.NilError(t, err)
+	resp.Body.Close()
+
+	resp, err = client.Get(server.URL + "/fail")
+	assert.NilError(t, err)
+	resp.Body.Close()
+
+	_, err = client.Get(server.URL + "/slow")
+	assert.Check(t, err != nil)
+
+	assert.Equal(t, testutil.CollectAndCount(requestDurationMetric), series+1) // one series for the host
+	assert.Equal(t, testutil.ToFloat64(requestErrorsMetric.WithLabelValues(host, errorReasonServerError)), float64(1))
+	assert.Equal(t, testutil.ToFloat64(requestErrorsMetric.WithLabelValues(host, errorReasonTimeout)), float64(1))
+	assert.Equal(t, testutil.ToFloat64(requestErrorsMetric.WithLabelValues(host, errorReasonConnection)), float64(0))
+}
+
+func TestConfigureWithInvalidCACert(t *testing.T) {
+	err := Configure(Options{CACertPath: "/does/not/exist.pem"})
+	assert.ErrorContains(t, err, "failed to read CA certificates")
+}
@@ -22,8 +22,8 @@ func NewHttpServerMock(serverHost string, httpServerMocks map[string]HttpServerM
 
 	handler := func(rw http.ResponseWriter, req *http.Request) {
 		for path, responseFunc := range httpServerMocks {
-			response := responseFunc()
 			if path == req.URL.String() {
+				response := responseFunc()
 				for k, v := range response.Headers {
 					rw.Header().Add(k, v)
 				}
@@ -35,6 +35,8 @@ func NewHttpServerMock(serverHost string, httpServerMocks map[string]HttpServerM
 	}
 
 	server := &gohttptest.Server{Listener: listener, Config: &http.Server{Handler: http.HandlerFunc(handler)}}
+	// mocks are often restarted on the same address, so connections must not be kept alive in the pool of the client
+	server.Config.SetKeepAlivesEnabled(false)
 	server.Start()
 
 	return server
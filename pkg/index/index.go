@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/utils"
 )
 
 const (
@@ -73,7 +74,7 @@ func (c *authConfigTree) Set(id, key string, config evaluators.AuthConfig, overr
 		AuthConfig: config,
 	}
 	err := c.root.set(revertKey(key), entry, override)
-	if err == nil {
+	if err == nil && !utils.SliceContains(c.keys[id], key) {
 		c.keys[id] = append(c.keys[id], key)
 	}
 	return err
@@ -83,10 +84,8 @@ func (c *authConfigTree) Delete(id string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if keys, ok := c.keys[id]; ok {
-		for _, key := range keys {
-			c.deleteKey(id, key)
-		}
+	for _, key := range c.keys[id] {
+		c.deleteKey(id, key)
 	}
 }
 
@@ -133,6 +132,10 @@ func (c *authConfigTree) deleteKey(id, key string) {
 	if node, _ := c.root.longestCommonLabel(revertKey(key)); node != nil && node.entry != nil && node.entry.Id == id {
 		node.entry = nil
 	}
+
+	if keys, found := c.keys[id]; found {
+		c.keys[id] = utils.SubtractSlice(keys, []string{key})
+	}
 }
 
 func newTreeNode(label string, parent *treeNode) *treeNode {
@@ -159,14 +162,15 @@ func (n *treeNode) get(key string) *indexEntry {
 	}
 
 	// lookup upwards until the root for a wildcard ('*')
+	// a wildcard stands for at least one label, so it does not match the node of the key itself (e.g. '*.acme.com' does not match 'acme.com')
 	curr := node
-	for {
+	if tail == "" {
+		curr = node.parent
+	}
+	for curr != nil {
 		if child, ok := curr.children["*"]; ok && child.entry != nil {
 			return child.entry
 		}
-		if curr.parent == nil {
-			break
-		}
 		curr = curr.parent
 	}
 
@@ -177,7 +181,7 @@ func (n *treeNode) set(key string, entry *indexEntry, override bool) error {
 	target, tail := n.longestCommonLabel(key)
 
 	if tail == "" {
-		if !override {
+		if target.entry != nil && !override {
 			return fmt.Errorf("authconfig already exists in the index: %s", key)
 		}
 
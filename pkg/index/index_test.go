@@ -132,6 +132,22 @@ func TestAuthConfigTree(t *testing.T) {
 	config = c.Get("talker-api.nip.io")
 	assert.DeepEqual(t, *config, authConfig1) // because `*.io -> auth-1` is still in the tree
 
+	assert.Equal(t, len(c.FindKeys("auth-2")), 0)
+
+	// Delete a single key of an id
+	if err := c.Set("auth-4", "*.acme.com", authConfig4, true); err != nil { // setting the same key again does not duplicate it
+		t.Error(err)
+	}
+	if err := c.Set("auth-4", "acme.com", authConfig4, false); err != nil {
+		t.Error(err)
+	}
+
+	c.DeleteKey("auth-4", "acme.com")
+
+	config = c.Get("acme.com")
+	assert.Check(t, config == nil)
+	assert.DeepEqual(t, c.FindKeys("auth-4"), []string{"*.acme.com"})
+
 	config = c.Get("api.acme.com")
 	assert.DeepEqual(t, *config, authConfig3)
 
@@ -141,6 +157,27 @@ func TestAuthConfigTree(t *testing.T) {
 	assert.DeepEqual(t, *config, authConfig4) // because `*.acme.com -> auth-4` is still in the tree
 }
 
+func TestAuthConfigTreeWildcardDoesNotMatchParentDomain(t *testing.T) {
+	c := newAuthConfigTree()
+
+	wildcardConfig := buildTestAuthConfig()
+	if err := c.Set("auth-1", "*.acme.com", wildcardConfig, false); err != nil {
+		t.Error(err)
+	}
+
+	assert.Check(t, c.Get("acme.com") == nil)
+	assert.DeepEqual(t, *c.Get("www.acme.com"), wildcardConfig)
+	assert.DeepEqual(t, *c.Get("api.eu.acme.com"), wildcardConfig)
+
+	apexConfig := buildTestAuthConfig()
+	if err := c.Set("auth-2", "acme.com", apexConfig, false); err != nil {
+		t.Error(err)
+	}
+
+	assert.DeepEqual(t, *c.Get("acme.com"), apexConfig)
+	assert.DeepEqual(t, *c.Get("www.acme.com"), wildcardConfig)
+}
+
 type bogusIdentity struct{}
 
 func (f *bogusIdentity) Call(_ auth.AuthPipeline, _ context.Context) (interface{}, error) {
@@ -1,7 +1,9 @@
 package json
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +11,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/tidwall/gjson"
@@ -20,6 +23,7 @@ const (
 	operatorIncl  = "incl"
 	operatorExcl  = "excl"
 	operatorRegex = "matches"
+	operatorSched = "withinSchedule"
 
 	unsupportedOperatorErrorMsg = "Unsupported operator for JSON authorization"
 )
@@ -27,6 +31,8 @@ const (
 var (
 	allCurlyBracesRegex          = regexp.MustCompile("{")
 	curlyBracesForModifiersRegex = regexp.MustCompile(`[^@]+@\w+:{`)
+
+	now = time.Now
 )
 
 // JSONProperty represents a name-value pair for a JSON property where the value can be a static value or
@@ -74,9 +80,55 @@ type JSONPatternMatchingRule struct {
 	Selector string
 	Operator string
 	Value    string
+
+	// Any holds alternative rules, at least one of which must match for the rule to match.
+	// If set, the selector, operator and value of the rule itself are ignored.
+	Any []JSONPatternMatchingRule
+
+	regex    *regexp.Regexp
+	schedule *Schedule
+}
+
+// Compile compiles the regular expression of a rule with the `matches` operator and the schedule of a rule with the
+// `withinSchedule` operator (and of its alternative rules), so they are not parsed again on every evaluation
+func (rule *JSONPatternMatchingRule) Compile() error {
+	for i := range rule.Any {
+		if err := rule.Any[i].Compile(); err != nil {
+			return err
+		}
+	}
+	switch rule.Operator {
+	case operatorRegex:
+		re, err := regexp.Compile(rule.Value)
+		if err != nil {
+			return err
+		}
+		rule.regex = re
+	case operatorSched:
+		schedule, err := ParseSchedule(rule.Value)
+		if err != nil {
+			return err
+		}
+		rule.schedule = schedule
+	}
+	return nil
 }
 
 func (rule *JSONPatternMatchingRule) EvaluateFor(jsonData string) (bool, error) {
+	if len(rule.Any) > 0 {
+		var err error
+		for _, alternative := range rule.Any {
+			match, e := alternative.EvaluateFor(jsonData)
+			if match {
+				return true, nil
+			}
+			if e != nil && err == nil {
+				err = e
+			}
+		}
+		return false, err
+	}
+
 	expectedValue := rule.Value
 	obtainedValue := gjson.Get(jsonData, rule.Selector)
 
@@ -104,17 +156,56 @@ func (rule *JSONPatternMatchingRule) EvaluateFor(jsonData string) (bool, error)
 		return true, nil
 
 	case operatorRegex:
+		if rule.regex != nil {
+			return rule.regex.MatchString(obtainedValue.String()), nil
+		}
 		if re, err := regexp.Compile(expectedValue); err != nil {
 			return false, err
 		} else {
 			return re.MatchString(obtainedValue.String()), nil
 		}
 
+	case operatorSched:
+		schedule := rule.schedule
+		if schedule == nil {
+			var err error
+			if schedule, err = ParseSchedule(expectedValue); err != nil {
+				return false, err
+			}
+		}
+		if rule.Selector == "" {
+			return schedule.Includes(now()), nil
+		}
+		if !obtainedValue.Exists() {
+			return false, nil
+		}
+		t, err := parseTime(obtainedValue)
+		if err != nil {
+			return false, err
+		}
+		return schedule.Includes(t), nil
+
 	default:
 		return false, fmt.Errorf(unsupportedOperatorErrorMsg)
 	}
 }
 
+// parseTime reads a point in time from a JSON value, i.e. an RFC 3339 string, a number of seconds since the Unix epoch,
+// or a protobuf timestamp object (`{"seconds":…,"nanos":…}`)
+func parseTime(value gjson.Result) (time.Time, error) {
+	switch value.Type {
+	case gjson.String:
+		return time.Parse(time.RFC3339, value.String())
+	case gjson.Number:
+		return time.Unix(value.Int(), 0), nil
+	case gjson.JSON:
+		if seconds := value.Get("seconds"); seconds.Exists() {
+			return time.Unix(seconds.Int(), value.Get("nanos").Int()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %s", value.Raw)
+}
+
 // UnmashalJSONResponse unmarshalls a generic HTTP response body into a JSON structure
 // Pass optionally a pointer to a byte array to get the raw body of the response object written back
 func UnmashalJSONResponse(resp *http.Response, v interface{}, b *[]byte) error {
@@ -270,6 +361,8 @@ var caseJSONStr = func(json, arg string) string {
 	return json
 }
 
+// base64JSONStr encodes (URL-safe alphabet) or decodes a string value. Decoding accepts values encoded with either the
+// standard or the URL-safe alphabet, padded or not; values that are not valid base64 resolve to nothing.
 var base64JSONStr = func(json, arg string) string {
 	str := gjson.Parse(json).String()
 
@@ -278,8 +371,18 @@ var base64JSONStr = func(json, arg string) string {
 		encoded := base64.URLEncoding.EncodeToString([]byte(str))
 		return fmt.Sprintf("\"%s\"", encoded)
 	case "decode":
-		decoded, _ := base64.URLEncoding.DecodeString(str)
-		return fmt.Sprintf("\"%s\"", decoded)
+		str = strings.TrimRight(str, "=")
+		var decoded []byte
+		var err error
+		if strings.ContainsAny(str, "+/") {
+			decoded, err = base64.RawStdEncoding.DecodeString(str)
+		} else {
+			decoded, err = base64.RawURLEncoding.DecodeString(str)
+		}
+		if err != nil {
+			return ""
+		}
+		return stringJSON(string(decoded))
 	default:
 		return json
 	}
@@ -296,10 +399,59 @@ var stripJSONstr = func(json, arg string) string {
 	return json
 }
 
+// base64DecodeJSONStr is an alias of @base64:decode
+var base64DecodeJSONStr = func(json, arg string) string {
+	return base64JSONStr(json, "decode")
+}
+
+// lowerJSONStr is an alias of @case:lower
+var lowerJSONStr = func(json, arg string) string {
+	return caseJSONStr(json, "lower")
+}
+
+// splitJSONStr splits a string value at occurrences of a separator (default: " ") into an array of strings.
+// The separator can be given as a string (e.g. @split:",") or in an object (e.g. @split:{"sep":","}).
+var splitJSONStr = func(json, arg string) string {
+	sep := " "
+	if arg != "" {
+		switch a := gjson.Parse(arg); {
+		case a.Type == gjson.String:
+			sep = a.String()
+		case a.IsObject():
+			if s := a.Get("sep"); s.Exists() {
+				sep = s.String()
+			}
+		}
+	}
+
+	value := gjson.Parse(json)
+	if value.Type != gjson.String {
+		return json
+	}
+	parts, _ := StringifyJSON(strings.Split(value.String(), sep))
+	return parts
+}
+
+// sha256JSONStr hashes a string value with SHA-256, returning the hex-encoded digest
+var sha256JSONStr = func(json, arg string) string {
+	sum := sha256.Sum256([]byte(gjson.Parse(json).String()))
+	return stringJSON(hex.EncodeToString(sum[:]))
+}
+
+// stringJSON encodes a string as a JSON string
+func stringJSON(str string) string {
+	encoded, _ := json.Marshal(str)
+	return string(encoded)
+}
+
 func init() {
 	gjson.AddModifier("extract", extractJSONStr)
 	gjson.AddModifier("replace", replaceJSONStr)
 	gjson.AddModifier("case", caseJSONStr)
 	gjson.AddModifier("base64", base64JSONStr)
 	gjson.AddModifier("strip", stripJSONstr)
+	gjson.AddModifier("base64decode", base64DecodeJSONStr)
+	gjson.AddModifier("lower", lowerJSONStr)
+	gjson.AddModifier("split", splitJSONStr)
+	gjson.AddModifier("sha256", sha256JSONStr)
 }
@@ -3,6 +3,7 @@ package json
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"gotest.tools/assert"
 
@@ -240,6 +241,46 @@ func TestStripJSONStr(t *testing.T) {
 	assert.Equal(t, gjson.Get(jsonData, "auth.identity.username.@strip").String(), "bob")
 }
 
+func TestBase64DecodeJSONStr(t *testing.T) {
+	const jsonData = `{"auth":{"identity":{"username":{"padded":"am9objpzZWNyZXQ=","raw":"am9objpzZWNyZXQ","std":"aGk/Pz8+","url":"aGk_Pz8-","invalid":"$$$"}}}}`
+
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.username.padded.@base64decode`).String(), "john:secret")
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.username.raw.@base64decode`).String(), "john:secret")
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.username.std.@base64decode`).String(), "hi???>")
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.username.url.@base64decode`).String(), "hi???>")
+	assert.Check(t, !gjson.Get(jsonData, `auth.identity.username.invalid.@base64decode`).Exists())
+
+	// alias of @base64:decode
+	for _, key := range []string{"padded", "raw", "std", "url", "invalid"} {
+		assert.Equal(t, gjson.Get(jsonData, `auth.identity.username.`+key+`.@base64decode`).Raw, gjson.Get(jsonData, `auth.identity.username.`+key+`.@base64:decode`).Raw)
+	}
+}
+
+func TestLowerJSONStr(t *testing.T) {
+	const jsonData = `{"auth":{"identity":{"fullname":"John Doe","roles":["Admin"]}}}`
+
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.fullname.@lower`).String(), "john doe")
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.roles.@lower`).Raw, gjson.Get(jsonData, `auth.identity.roles.@case:lower`).Raw)
+}
+
+func TestSplitJSONStr(t *testing.T) {
+	const jsonData = `{"context":{"request":{"http":{"path":"/pets/123","headers":{"x-groups":"admin,dev"}}}}}`
+
+	assert.Equal(t, gjson.Get(jsonData, `context.request.http.headers.x-groups.@split:","`).Raw, `["admin","dev"]`)
+	assert.Equal(t, gjson.Get(jsonData, `context.request.http.headers.x-groups.@split:{"sep":","}`).Raw, `["admin","dev"]`)
+	assert.Equal(t, gjson.Get(jsonData, `context.request.http.path|@split:"/"|2`).String(), "123")
+	assert.Equal(t, gjson.Get(jsonData, `context.request.http.path|@split:"/"|#`).Int(), int64(3))
+	assert.Equal(t, gjson.Get(jsonData, `context.request.http.headers.x-groups.@split`).Raw, `["admin,dev"]`)
+}
+
+func TestSha256JSONStr(t *testing.T) {
+	const jsonData = `{"auth":{"identity":{"email":"John@Test"}}}`
+
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.email.@sha256`).String(), "affac2366d839fe4c046c6ee4f9addaa8ab36790835ab7a33d42acf511bc2584")
+	// chained
+	assert.Equal(t, gjson.Get(jsonData, `auth.identity.email.@lower.@sha256`).String(), "43bc8eb1a75d7fdc3ef6dbd248b0faf0ecf03c840c512ec207405f768c07d32b")
+}
+
 func TestStringifyJSON(t *testing.T) {
 	var source interface{}
 	var str string
@@ -324,3 +365,91 @@ func TestStringifyJSON(t *testing.T) {
 	assert.Equal(t, str, `{"prop_str":"str","prop_num":123,"prop_bool":false,"prop_null":null,"prop_arr":["a","b","c"],"prop_obj":{"a_prop":"a_value"}}`)
 	assert.NilError(t, err)
 }
+
+func TestJSONPatternMatchingRuleEvaluateForAny(t *testing.T) {
+	jsonData := `{"auth":{"identity":{"sub":"john","roles":["member"],"email":"john@acme.com"}}}`
+
+	rule := JSONPatternMatchingRule{Any: []JSONPatternMatchingRule{
+		{Selector: "auth.identity.roles", Operator: "incl", Value: "admin"},
+		{Selector: "auth.identity.email", Operator: "matches", Value: `@acme\.com$`},
+	}}
+	match, err := rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, match)
+
+	rule = JSONPatternMatchingRule{Any: []JSONPatternMatchingRule{
+		{Selector: "auth.identity.sub", Operator: "eq", Value: "jane"},
+		{Selector: "auth.identity.sub", Operator: "eq", Value: "bob"},
+	}}
+	match, err = rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, !match)
+
+	rule = JSONPatternMatchingRule{Any: []JSONPatternMatchingRule{
+		{Selector: "auth.identity.sub", Operator: "gt", Value: "a"},
+		{Selector: "auth.identity.sub", Operator: "eq", Value: "john"},
+	}}
+	match, err = rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, match)
+}
+
+func TestJSONPatternMatchingRuleCompile(t *testing.T) {
+	jsonData := `{"context":{"request":{"http":{"path":"/pets/123","method":"GET"}}}}`
+
+	rule := JSONPatternMatchingRule{Selector: "context.request.http.path", Operator: "matches", Value: `^/pets/\d+$`}
+	assert.NilError(t, rule.Compile())
+	assert.Check(t, rule.regex != nil)
+	match, err := rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, match)
+
+	rule = JSONPatternMatchingRule{Any: []JSONPatternMatchingRule{
+		{Selector: "context.request.http.method", Operator: "eq", Value: "POST"},
+		{Selector: "context.request.http.path", Operator: "matches", Value: `^/pets/(`},
+	}}
+	assert.ErrorContains(t, rule.Compile(), "error parsing regexp")
+}
+
+func TestJSONPatternMatchingRuleWithinSchedule(t *testing.T) {
+	// Wednesday, 2022-09-14 10:30 UTC (12:30 in Berlin)
+	jsonData := `{"context":{"request":{"time":{"seconds":1663151400,"nanos":0}}},"auth":{"identity":{"iat":1663151400,"updated_at":"2022-09-14T22:30:00Z"}}}`
+
+	rule := JSONPatternMatchingRule{Selector: "context.request.time", Operator: "withinSchedule", Value: "* 9-17 * * MON-FRI"}
+	assert.NilError(t, rule.Compile())
+	assert.Check(t, rule.schedule != nil)
+	match, err := rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, match)
+
+	rule = JSONPatternMatchingRule{Selector: "auth.identity.iat", Operator: "withinSchedule", Value: "TZ=Europe/Berlin * 9-11 * * *"}
+	match, err = rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, !match)
+
+	rule = JSONPatternMatchingRule{Selector: "auth.identity.updated_at", Operator: "withinSchedule", Value: "* 9-17 * * *"}
+	match, err = rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, !match)
+
+	rule = JSONPatternMatchingRule{Selector: "auth.identity.missing", Operator: "withinSchedule", Value: "* * * * *"}
+	match, err = rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, !match)
+
+	rule = JSONPatternMatchingRule{Selector: "auth.identity", Operator: "withinSchedule", Value: "* * * * *"}
+	_, err = rule.EvaluateFor(jsonData)
+	assert.ErrorContains(t, err, "invalid time")
+
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return time.Date(2022, time.September, 17, 10, 30, 0, 0, time.UTC) } // Saturday
+	rule = JSONPatternMatchingRule{Operator: "withinSchedule", Value: "* 9-17 * * MON-FRI"}
+	match, err = rule.EvaluateFor(jsonData)
+	assert.NilError(t, err)
+	assert.Check(t, !match)
+
+	rule = JSONPatternMatchingRule{Operator: "withinSchedule", Value: "* 9-17 * * 1-5"}
+	assert.NilError(t, rule.Compile())
+	rule = JSONPatternMatchingRule{Operator: "withinSchedule", Value: "* 9-17 * *"}
+	assert.ErrorContains(t, rule.Compile(), "expected 5 fields")
+}
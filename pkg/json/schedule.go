@@ -0,0 +1,131 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones of the schedules must be available regardless of the base image
+)
+
+var (
+	monthNames   = map[string]int{"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6, "JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12}
+	weekdayNames = map[string]int{"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6}
+)
+
+// Schedule is a recurring time window, expressed in a cron-like syntax:
+//
+//	[TZ=<time zone> ]<minute> <hour> <day of month> <month> <day of week>
+//
+// Each field accepts `*`, single values, ranges (`a-b`), steps (`*/n`, `a-b/n`) and lists of those (separated by comma).
+// Months and days of the week can also be referred by their 3-letter names (e.g. `JAN`, `MON`). Sunday is either 0 or 7.
+// A point in time is within the schedule if it matches all the fields, in the time zone of the schedule (default: UTC).
+type Schedule struct {
+	location *time.Location
+	minutes  []bool
+	hours    []bool
+	days     []bool
+	months   []bool
+	weekdays []bool
+}
+
+// ParseSchedule parses a cron-like expression of a recurring time window
+func ParseSchedule(expression string) (*Schedule, error) {
+	fields := strings.Fields(expression)
+	schedule := &Schedule{location: time.UTC}
+
+	if len(fields) > 0 && (strings.HasPrefix(fields[0], "TZ=") || strings.HasPrefix(fields[0], "CRON_TZ=")) {
+		name := fields[0][strings.Index(fields[0], "=")+1:]
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone in schedule %q: %v", expression, err)
+		}
+		schedule.location = location
+		fields = fields[1:]
+	}
+
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields (minute, hour, day of month, month, day of week), got %d", expression, len(fields))
+	}
+
+	var err error
+	if schedule.minutes, err = parseScheduleField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid minute in schedule %q: %v", expression, err)
+	}
+	if schedule.hours, err = parseScheduleField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid hour in schedule %q: %v", expression, err)
+	}
+	if schedule.days, err = parseScheduleField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid day of month in schedule %q: %v", expression, err)
+	}
+	if schedule.months, err = parseScheduleField(fields[3], 1, 12, monthNames); err != nil {
+		return nil, fmt.Errorf("invalid month in schedule %q: %v", expression, err)
+	}
+	if schedule.weekdays, err = parseScheduleField(fields[4], 0, 7, weekdayNames); err != nil {
+		return nil, fmt.Errorf("invalid day of week in schedule %q: %v", expression, err)
+	}
+	if schedule.weekdays[7] {
+		schedule.weekdays[0] = true
+	}
+
+	return schedule, nil
+}
+
+// Includes tells whether a point in time is within the schedule
+func (s *Schedule) Includes(t time.Time) bool {
+	t = t.In(s.location)
+	return s.minutes[t.Minute()] && s.hours[t.Hour()] && s.days[t.Day()] && s.months[int(t.Month())] && s.weekdays[int(t.Weekday())]
+}
+
+func parseScheduleField(field string, min, max int, names map[string]int) ([]bool, error) {
+	values := make([]bool, max+1)
+
+	for _, item := range strings.Split(field, ",") {
+		from, to, step := min, max, 1
+
+		if i := strings.Index(item, "/"); i >= 0 {
+			s, err := strconv.Atoi(item[i+1:])
+			if err != nil || s < 1 {
+				return nil, fmt.Errorf("invalid step %q", item[i+1:])
+			}
+			step = s
+			item = item[:i]
+		}
+
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if from, err = parseScheduleValue(bounds[0], min, max, names); err != nil {
+				return nil, err
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = parseScheduleValue(bounds[1], min, max, names); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				to = max
+			}
+			if from > to {
+				return nil, fmt.Errorf("invalid range %q", item)
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+func parseScheduleValue(value string, min, max int, names map[string]int) (int, error) {
+	if v, found := names[strings.ToUpper(value)]; found {
+		return v, nil
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid value %q (expected %d-%d)", value, min, max)
+	}
+	return v, nil
+}
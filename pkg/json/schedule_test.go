@@ -0,0 +1,49 @@
+package json
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestParseSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("0-29 9-17 * * MON-FRI")
+	assert.NilError(t, err)
+	assert.Check(t, schedule.Includes(time.Date(2022, time.September, 14, 9, 0, 0, 0, time.UTC)))
+	assert.Check(t, schedule.Includes(time.Date(2022, time.September, 14, 17, 29, 59, 0, time.UTC)))
+	assert.Check(t, !schedule.Includes(time.Date(2022, time.September, 14, 17, 30, 0, 0, time.UTC)))
+	assert.Check(t, !schedule.Includes(time.Date(2022, time.September, 14, 8, 59, 0, 0, time.UTC)))
+	assert.Check(t, !schedule.Includes(time.Date(2022, time.September, 18, 10, 0, 0, 0, time.UTC))) // Sunday
+
+	schedule, err = ParseSchedule("TZ=America/New_York * 22-23 * * *")
+	assert.NilError(t, err)
+	assert.Check(t, schedule.Includes(time.Date(2022, time.September, 15, 2, 15, 0, 0, time.UTC)))
+	assert.Check(t, !schedule.Includes(time.Date(2022, time.September, 14, 22, 15, 0, 0, time.UTC)))
+
+	schedule, err = ParseSchedule("CRON_TZ=UTC */15 * 1,15 jan,jul 7")
+	assert.NilError(t, err)
+	assert.Check(t, schedule.Includes(time.Date(2023, time.January, 15, 3, 45, 0, 0, time.UTC)))   // Sunday
+	assert.Check(t, !schedule.Includes(time.Date(2023, time.January, 15, 3, 46, 0, 0, time.UTC)))  // not a multiple of 15
+	assert.Check(t, !schedule.Includes(time.Date(2023, time.January, 8, 3, 45, 0, 0, time.UTC)))   // 8th
+	assert.Check(t, !schedule.Includes(time.Date(2022, time.July, 15, 3, 45, 0, 0, time.UTC)))     // Friday
+	assert.Check(t, !schedule.Includes(time.Date(2026, time.February, 15, 3, 45, 0, 0, time.UTC))) // February
+
+	schedule, err = ParseSchedule("5/20 * * * *")
+	assert.NilError(t, err)
+	assert.Check(t, schedule.Includes(time.Date(2022, time.September, 14, 9, 45, 0, 0, time.UTC)))
+	assert.Check(t, !schedule.Includes(time.Date(2022, time.September, 14, 9, 40, 0, 0, time.UTC)))
+
+	_, err = ParseSchedule("TZ=Mars/Olympus_Mons * * * * *")
+	assert.ErrorContains(t, err, "invalid time zone")
+	_, err = ParseSchedule("* 24 * * *")
+	assert.ErrorContains(t, err, "invalid hour")
+	_, err = ParseSchedule("* 17-9 * * *")
+	assert.ErrorContains(t, err, "invalid range")
+	_, err = ParseSchedule("* * 0 * *")
+	assert.ErrorContains(t, err, "invalid day of month")
+	_, err = ParseSchedule("* * * FOO *")
+	assert.ErrorContains(t, err, "invalid month")
+	_, err = ParseSchedule("*/0 * * * *")
+	assert.ErrorContains(t, err, "invalid step")
+}
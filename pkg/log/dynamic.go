@@ -0,0 +1,115 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+)
+
+// dynamicRoot holds the sink that backs all the loggers created with NewLogger, so it can be replaced at runtime
+type dynamicRoot struct {
+	mu         sync.RWMutex
+	sink       logr.LogSink
+	opts       Options
+	generation uint64
+}
+
+func (r *dynamicRoot) set(sink logr.LogSink, opts Options) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// one extra frame for the dynamic sink wrapping the actual one
+	if s, ok := sink.(logr.CallDepthLogSink); ok {
+		sink = s.WithCallDepth(1)
+	}
+	r.sink = sink
+	r.opts = opts
+	atomic.AddUint64(&r.generation, 1)
+}
+
+func (r *dynamicRoot) get() (logr.LogSink, uint64) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sink, atomic.LoadUint64(&r.generation)
+}
+
+func (r *dynamicRoot) options() Options {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.opts
+}
+
+// sinkOp is a transformation applied to the root sink to derive a logger (WithName, WithValues, WithCallDepth)
+type sinkOp func(logr.LogSink) logr.LogSink
+
+type builtSink struct {
+	sink       logr.LogSink
+	generation uint64
+}
+
+// dynamicSink is a logr.LogSink that delegates to the current root sink, re-deriving itself whenever the root sink is replaced
+type dynamicSink struct {
+	root  *dynamicRoot
+	ops   []sinkOp
+	built atomic.Value // *builtSink
+}
+
+func newDynamicSink(root *dynamicRoot, ops []sinkOp) *dynamicSink {
+	return &dynamicSink{root: root, ops: ops}
+}
+
+func (s *dynamicSink) current() logr.LogSink {
+	if b, ok := s.built.Load().(*builtSink); ok && b.generation == atomic.LoadUint64(&s.root.generation) {
+		return b.sink
+	}
+	sink, generation := s.root.get()
+	for _, op := range s.ops {
+		sink = op(sink)
+	}
+	s.built.Store(&builtSink{sink: sink, generation: generation})
+	return sink
+}
+
+func (s *dynamicSink) with(op sinkOp) logr.LogSink {
+	ops := make([]sinkOp, len(s.ops), len(s.ops)+1)
+	copy(ops, s.ops)
+	return newDynamicSink(s.root, append(ops, op))
+}
+
+func (s *dynamicSink) Init(logr.RuntimeInfo) {}
+
+func (s *dynamicSink) Enabled(level int) bool {
+	return s.current().Enabled(level)
+}
+
+func (s *dynamicSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.current().Info(level, msg, s.redact(keysAndValues)...)
+}
+
+func (s *dynamicSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	s.current().Error(err, msg, s.redact(keysAndValues)...)
+}
+
+func (s *dynamicSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return s.with(func(sink logr.LogSink) logr.LogSink { return sink.WithValues(s.redact(keysAndValues)...) })
+}
+
+func (s *dynamicSink) redact(keysAndValues []interface{}) []interface{} {
+	if len(keysAndValues) == 0 || !s.root.options().Redact {
+		return keysAndValues
+	}
+	return redactKeysAndValues(keysAndValues)
+}
+
+func (s *dynamicSink) WithName(name string) logr.LogSink {
+	return s.with(func(sink logr.LogSink) logr.LogSink { return sink.WithName(name) })
+}
+
+func (s *dynamicSink) WithCallDepth(depth int) logr.LogSink {
+	return s.with(func(sink logr.LogSink) logr.LogSink {
+		if s, ok := sink.(logr.CallDepthLogSink); ok {
+			return s.WithCallDepth(depth)
+		}
+		return sink
+	})
+}
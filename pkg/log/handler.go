@@ -0,0 +1,57 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type handlerOptions struct {
+	Level string `json:"level,omitempty"`
+	Mode  string `json:"mode,omitempty"`
+}
+
+// Handler returns an http handler to read (GET) and change (PUT) the level and the mode of the loggers at runtime.
+// The body of PUT requests is a JSON object with the new `level` and/or `mode`, e.g. {"level":"debug"}.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		opts := CurrentOptions()
+
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var changes handlerOptions
+			if err := json.NewDecoder(req.Body).Decode(&changes); err != nil {
+				http.Error(resp, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			if changes.Level != "" {
+				var level zapcore.Level
+				if err := level.UnmarshalText([]byte(changes.Level)); err != nil {
+					http.Error(resp, fmt.Sprintf("invalid log level: %s", changes.Level), http.StatusBadRequest)
+					return
+				}
+				opts.Level = LogLevel(level)
+			}
+			if changes.Mode != "" {
+				switch strings.ToLower(changes.Mode) {
+				case "production", "development":
+					opts.Mode = ToLogMode(changes.Mode)
+				default:
+					http.Error(resp, fmt.Sprintf("invalid log mode: %s", changes.Mode), http.StatusBadRequest)
+					return
+				}
+			}
+			Reconfigure(opts)
+		default:
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(resp).Encode(handlerOptions{Level: opts.Level.String(), Mode: opts.Mode.String()})
+	})
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHandler(t *testing.T) {
+	logger := NewLogger(Options{Level: ToLogLevel("info"), Mode: LogModeProd}).WithName("test")
+	handler := Handler()
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(method, "/debug/log", strings.NewReader(body)))
+		return resp
+	}
+
+	resp := request(http.MethodGet, "")
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(resp.Body.String()), `{"level":"info","mode":"production"}`)
+
+	resp = request(http.MethodPut, `{"level":"debug"}`)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(resp.Body.String()), `{"level":"debug","mode":"production"}`)
+	assert.Check(t, logger.V(1).Enabled())
+
+	resp = request(http.MethodPut, `{"mode":"development"}`)
+	assert.Equal(t, resp.Code, http.StatusOK)
+	assert.Equal(t, strings.TrimSpace(resp.Body.String()), `{"level":"debug","mode":"development"}`)
+
+	assert.Equal(t, request(http.MethodPut, `{"level":"verbose"}`).Code, http.StatusBadRequest)
+	assert.Equal(t, request(http.MethodPut, `{"mode":"json"}`).Code, http.StatusBadRequest)
+	assert.Equal(t, request(http.MethodPut, `not json`).Code, http.StatusBadRequest)
+	assert.Equal(t, request(http.MethodDelete, "").Code, http.StatusMethodNotAllowed)
+	assert.Equal(t, CurrentOptions().Mode, LogModeDev)
+}
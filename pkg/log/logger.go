@@ -20,6 +20,9 @@ var (
 	// until `SetLogger` is called.
 	// This is also useful for mocking the default logger tests.
 	Log Logger = ctrl.Log
+
+	// root backs all the loggers created with NewLogger, so their level and mode can be changed at runtime
+	root = &dynamicRoot{}
 )
 
 type Logger = logr.Logger
@@ -76,6 +79,8 @@ func ToLogMode(mode string) LogMode {
 type Options struct {
 	Level LogLevel
 	Mode  LogMode
+	// Redact replaces bearer tokens, API keys and the contents of Kubernetes Secrets in the log messages with a placeholder
+	Redact bool
 }
 
 // SetLogger sets up a logger.
@@ -85,7 +90,7 @@ func SetLogger(logger Logger, opts Options) {
 	ctrl.SetLogger(Log) // fulfills `logger` as the de facto logger used by controller-runtime
 	klog.SetLogger(Log)
 
-	logger.Info("setting instance base logger", "min level", opts.Level.String(), "mode", opts.Mode.String())
+	logger.Info("setting instance base logger", "min level", opts.Level.String(), "mode", opts.Mode.String(), "redact", opts.Redact)
 }
 
 // WithName uses the singleton logger to create a new logger with the given name.
@@ -123,7 +128,24 @@ func FromContext(ctx context.Context, keysAndValues ...interface{}) Logger {
 // NewLogger returns a new logger with the given options.
 // `logger` param is the actual logger implementation; when omitted, a new
 // logger based on sigs.k8s.io/controller-runtime/pkg/log/zap is created.
+// The options of all loggers created with NewLogger (and loggers derived from them) can be changed at runtime with Reconfigure.
 func NewLogger(opts Options) Logger {
+	root.set(newZapLogger(opts).GetSink(), opts)
+	return logr.New(newDynamicSink(root, nil))
+}
+
+// Reconfigure changes the level and the mode of all loggers created with NewLogger, without having to recreate them.
+func Reconfigure(opts Options) {
+	root.set(newZapLogger(opts).GetSink(), opts)
+	Log.Info("reconfigured instance base logger", "min level", opts.Level.String(), "mode", opts.Mode.String(), "redact", opts.Redact)
+}
+
+// CurrentOptions returns the options of the loggers created with NewLogger.
+func CurrentOptions() Options {
+	return root.options()
+}
+
+func newZapLogger(opts Options) Logger {
 	return zap.New(
 		zap.Level(zapcore.Level(opts.Level)),
 		zap.UseDevMode(opts.Mode == LogModeDev),
@@ -59,3 +59,18 @@ func TestToLogMode(t *testing.T) {
 	}()
 	_ = ToLogMode("invalid")
 }
+
+func TestReconfigure(t *testing.T) {
+	logger := NewLogger(Options{Level: ToLogLevel("info"), Mode: LogModeProd})
+	derived := logger.WithName("test").WithValues("key", "value")
+	assert.Check(t, logger.V(0).Enabled())
+	assert.Check(t, !derived.V(1).Enabled())
+
+	Reconfigure(Options{Level: ToLogLevel("debug"), Mode: LogModeDev})
+	assert.Check(t, logger.V(1).Enabled())
+	assert.Check(t, derived.V(1).Enabled())
+	assert.Equal(t, CurrentOptions().Mode, LogModeDev)
+
+	Reconfigure(Options{Level: ToLogLevel("error"), Mode: LogModeProd})
+	assert.Check(t, !derived.V(0).Enabled())
+}
@@ -0,0 +1,117 @@
+package log
+
+import (
+	gojson "encoding/json"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// names of properties whose values are always redacted (case-insensitive), e.g. HTTP headers carrying credentials
+	sensitiveKeys = map[string]struct{}{
+		"authorization":       {},
+		"proxy-authorization": {},
+		"cookie":              {},
+		"set-cookie":          {},
+		"dpop":                {},
+		"x-api-key":           {},
+		"api-key":             {},
+		"api_key":             {},
+		"apikey":              {},
+		"password":            {},
+		"client_secret":       {},
+		"token":               {},
+		"access_token":        {},
+		"refresh_token":       {},
+		"id_token":            {},
+	}
+
+	// credentials prefixed by an authorization scheme, e.g. "Bearer <token>"
+	schemeCredentialsRegex = regexp.MustCompile(`(?i)\b(Bearer|Basic|APIKEY|DPoP)\s+[^\s",;]+`)
+	// JSON Web Tokens, e.g. access tokens and Festival Wristbands
+	jwtRegex = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// redactKeysAndValues returns a copy of the key-value pairs of a log message with the sensitive data redacted
+func redactKeysAndValues(keysAndValues []interface{}) []interface{} {
+	redactedKeysAndValues := make([]interface{}, len(keysAndValues))
+	for i, v := range keysAndValues {
+		if i%2 == 0 {
+			redactedKeysAndValues[i] = v
+			continue
+		}
+		if key, ok := keysAndValues[i-1].(string); ok && isSensitiveKey(key) {
+			redactedKeysAndValues[i] = redacted
+			continue
+		}
+		redactedKeysAndValues[i] = redact(v)
+	}
+	return redactedKeysAndValues
+}
+
+// redact returns the value with bearer tokens, API keys and the contents of Kubernetes Secrets redacted.
+// Values other than strings and primitive types are converted to their generic JSON representation, i.e. as they would be output to the logs.
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, int, int32, int64, uint, uint32, uint64, float32, float64, error:
+		return v
+	case string:
+		return redactString(v)
+	}
+
+	j, err := gojson.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var generic interface{}
+	if err := gojson.Unmarshal(j, &generic); err != nil {
+		return value
+	}
+	return redactGeneric(generic)
+}
+
+func redactGeneric(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return redactString(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = redactGeneric(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		secret := v["kind"] == "Secret"
+		for key, val := range v {
+			if isSensitiveKey(key) || (secret && (key == "data" || key == "stringData")) {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactGeneric(val)
+		}
+		if secret {
+			// the last applied configuration annotation may include the contents of the secret
+			if metadata, ok := v["metadata"].(map[string]interface{}); ok {
+				if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+					if _, ok := annotations["kubectl.kubernetes.io/last-applied-configuration"]; ok {
+						annotations["kubectl.kubernetes.io/last-applied-configuration"] = redacted
+					}
+				}
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func redactString(value string) string {
+	value = schemeCredentialsRegex.ReplaceAllString(value, "$1 "+redacted)
+	return jwtRegex.ReplaceAllString(value, redacted)
+}
+
+func isSensitiveKey(key string) bool {
+	_, sensitive := sensitiveKeys[strings.ToLower(key)]
+	return sensitive
+}
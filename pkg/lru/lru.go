@@ -0,0 +1,200 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/metrics"
+)
+
+const (
+	evictionReasonCapacity = "capacity"
+	evictionReasonExpired  = "expired"
+)
+
+var (
+	entriesMetric   = metrics.NewGaugeMetric("cache_entries", "Number of entries in the in-memory caches, partitioned by cache.", "cache")
+	evictionsMetric = metrics.NewCounterMetric("cache_evictions_total", "Total number of entries evicted from the in-memory caches, partitioned by cache and reason (capacity or expired).", "cache", "reason")
+	hitsMetric      = metrics.NewCounterMetric("cache_hits_total", "Total number of lookups that found an entry in the in-memory caches, partitioned by cache.", "cache")
+	missesMetric    = metrics.NewCounterMetric("cache_misses_total", "Total number of lookups that did not find an entry in the in-memory caches, partitioned by cache.", "cache")
+)
+
+func init() {
+	metrics.Register(
+		entriesMetric,
+		evictionsMetric,
+		hitsMetric,
+		missesMetric,
+	)
+}
+
+// Options of a cache
+type Options struct {
+	// MaxEntries is the maximum number of entries of the cache. The least recently used entries are evicted to respect
+	// the limit. 0 for no limit.
+	MaxEntries int
+	// MaxBytes is the maximum total size of the values of the cache stored as []byte or string. The least recently used
+	// entries are evicted to respect the limit. 0 for no limit.
+	MaxBytes int
+	// TTL is the default time to live of the entries. 0 for entries that do not expire.
+	TTL time.Duration
+}
+
+// Cache is a thread-safe least recently used (LRU) cache, bounded in number of entries and, optionally, in size.
+// Entries can expire. Expired entries are removed when looked up or when the least recently used ones.
+//
+// All the caches report their number of entries, evictions, hits and misses to the metrics server, partitioned by
+// name of the cache. Caches with the same name add up.
+type Cache struct {
+	name    string
+	options Options
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	bytes int
+}
+
+type entry struct {
+	key       string
+	value     interface{}
+	size      int
+	expiresAt time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// New creates a cache
+func New(name string, options Options) *Cache {
+	return &Cache{
+		name:    name,
+		options: options,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// Get looks up the value of a key
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, found := c.items[key]; found {
+		if e := elem.Value.(*entry); !e.expired(time.Now()) {
+			c.ll.MoveToFront(elem)
+			hitsMetric.WithLabelValues(c.name).Inc()
+			return e.value, true
+		}
+		c.removeElement(elem, evictionReasonExpired)
+	}
+
+	missesMetric.WithLabelValues(c.name).Inc()
+	return nil, false
+}
+
+// Add adds a value to the cache, with the default time to live of the cache
+func (c *Cache) Add(key string, value interface{}) {
+	c.AddWithTTL(key, value, c.options.TTL)
+}
+
+// AddWithTTL adds a value to the cache that expires after a period of time. 0 for a value that does not expire.
+func (c *Cache) AddWithTTL(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &entry{key: key, value: value, size: sizeOf(value)}
+	if ttl > 0 {
+		e.expiresAt = time.Now().Add(ttl)
+	}
+
+	if elem, found := c.items[key]; found {
+		c.bytes += e.size - elem.Value.(*entry).size
+		elem.Value = e
+		c.ll.MoveToFront(elem)
+	} else {
+		c.items[key] = c.ll.PushFront(e)
+		c.bytes += e.size
+		entriesMetric.WithLabelValues(c.name).Inc()
+	}
+
+	c.evict()
+}
+
+// Remove removes a key from the cache
+func (c *Cache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, found := c.items[key]; found {
+		c.removeElement(elem, "")
+	}
+}
+
+// Len returns the number of entries of the cache, including the expired ones not removed yet
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Resize changes the maximum number of entries of the cache, evicting the least recently used entries if needed
+func (c *Cache) Resize(maxEntries int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.options.MaxEntries = maxEntries
+	c.evict()
+}
+
+// Purge removes all the entries of the cache
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entriesMetric.WithLabelValues(c.name).Sub(float64(c.ll.Len()))
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+// evict removes the least recently used entries while the cache exceeds its limits. Expired entries at the back of the
+// list are removed as well.
+func (c *Cache) evict() {
+	now := time.Now()
+	for elem := c.ll.Back(); elem != nil; elem = c.ll.Back() {
+		if elem.Value.(*entry).expired(now) {
+			c.removeElement(elem, evictionReasonExpired)
+			continue
+		}
+		if (c.options.MaxEntries > 0 && c.ll.Len() > c.options.MaxEntries) || (c.options.MaxBytes > 0 && c.bytes > c.options.MaxBytes) {
+			c.removeElement(elem, evictionReasonCapacity)
+			continue
+		}
+		return
+	}
+}
+
+// removeElement removes an entry from the cache. The eviction is reported if a reason is given.
+func (c *Cache) removeElement(elem *list.Element, reason string) {
+	e := elem.Value.(*entry)
+	c.ll.Remove(elem)
+	delete(c.items, e.key)
+	c.bytes -= e.size
+	entriesMetric.WithLabelValues(c.name).Dec()
+	if reason != "" {
+		evictionsMetric.WithLabelValues(c.name, reason).Inc()
+	}
+}
+
+func sizeOf(value interface{}) int {
+	switch v := value.(type) {
+	case []byte:
+		return len(v)
+	case string:
+		return len(v)
+	default:
+		return 0
+	}
+}
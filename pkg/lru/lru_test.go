@@ -0,0 +1,102 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+)
+
+func TestGetAndAdd(t *testing.T) {
+	cache := New("test-get-add", Options{})
+
+	_, found := cache.Get("a")
+	assert.Check(t, !found)
+
+	cache.Add("a", 1)
+	value, found := cache.Get("a")
+	assert.Check(t, found)
+	assert.Equal(t, value, 1)
+
+	cache.Add("a", 2) // replaces the value
+	value, _ = cache.Get("a")
+	assert.Equal(t, value, 2)
+	assert.Equal(t, cache.Len(), 1)
+
+	cache.Remove("a")
+	_, found = cache.Get("a")
+	assert.Check(t, !found)
+
+	assert.Equal(t, testutil.ToFloat64(hitsMetric.WithLabelValues("test-get-add")), float64(2))
+	assert.Equal(t, testutil.ToFloat64(missesMetric.WithLabelValues("test-get-add")), float64(2))
+	assert.Equal(t, testutil.ToFloat64(entriesMetric.WithLabelValues("test-get-add")), float64(0))
+}
+
+func TestMaxEntries(t *testing.T) {
+	cache := New("test-max-entries", Options{MaxEntries: 2})
+
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	_, _ = cache.Get("a") // b becomes the least recently used
+	cache.Add("c", 3)
+
+	assert.Equal(t, cache.Len(), 2)
+	_, found := cache.Get("b")
+	assert.Check(t, !found)
+	_, found = cache.Get("a")
+	assert.Check(t, found)
+	_, found = cache.Get("c")
+	assert.Check(t, found)
+
+	cache.Resize(1)
+	assert.Equal(t, cache.Len(), 1)
+	_, found = cache.Get("c")
+	assert.Check(t, found)
+
+	assert.Equal(t, testutil.ToFloat64(evictionsMetric.WithLabelValues("test-max-entries", "capacity")), float64(2))
+	assert.Equal(t, testutil.ToFloat64(entriesMetric.WithLabelValues("test-max-entries")), float64(1))
+}
+
+func TestMaxBytes(t *testing.T) {
+	cache := New("test-max-bytes", Options{MaxBytes: 10})
+
+	cache.Add("a", []byte("12345"))
+	cache.Add("b", "12345")
+	assert.Equal(t, cache.Len(), 2)
+
+	cache.Add("c", []byte("1"))
+	assert.Equal(t, cache.Len(), 2)
+	_, found := cache.Get("a")
+	assert.Check(t, !found)
+}
+
+func TestTTL(t *testing.T) {
+	cache := New("test-ttl", Options{TTL: 50 * time.Millisecond})
+
+	cache.Add("a", 1)
+	cache.AddWithTTL("b", 2, time.Hour)
+	cache.AddWithTTL("c", 3, 0) // does not expire
+
+	time.Sleep(60 * time.Millisecond)
+
+	_, found := cache.Get("a")
+	assert.Check(t, !found)
+	_, found = cache.Get("b")
+	assert.Check(t, found)
+	_, found = cache.Get("c")
+	assert.Check(t, found)
+
+	assert.Equal(t, testutil.ToFloat64(evictionsMetric.WithLabelValues("test-ttl", "expired")), float64(1))
+}
+
+func TestPurge(t *testing.T) {
+	cache := New("test-purge", Options{})
+
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Purge()
+
+	assert.Equal(t, cache.Len(), 0)
+	assert.Equal(t, testutil.ToFloat64(entriesMetric.WithLabelValues("test-purge")), float64(0))
+}
@@ -28,6 +28,16 @@ func NewCounterMetric(name, help string, labels ...string) *prometheus.CounterVe
 	)
 }
 
+func NewGaugeMetric(name, help string, labels ...string) *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: name,
+			Help: help,
+		},
+		labels,
+	)
+}
+
 func NewDurationMetric(name, help string, labels ...string) *prometheus.HistogramVec {
 	return prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -4,11 +4,15 @@ import (
 	"context"
 	"net/url"
 	"sync"
+	"time"
 
 	gooauth2 "golang.org/x/oauth2"
 	gooauth2clientcredentials "golang.org/x/oauth2/clientcredentials"
 )
 
+// refreshWindow is the fraction of the lifetime of a token before its expiry, within which the token is refreshed in the background
+const refreshWindow = 0.2
+
 func NewClientCredentialsConfig(tokenURL, clientID, clientSecret string, scopes []string, extraParams map[string]string) *ClientCredentials {
 	params := url.Values{}
 	for k, v := range extraParams {
@@ -28,14 +32,21 @@ func NewClientCredentialsConfig(tokenURL, clientID, clientSecret string, scopes
 type ClientCredentials struct {
 	*gooauth2clientcredentials.Config
 
-	mu    sync.RWMutex
-	token *gooauth2.Token
+	mu         sync.RWMutex
+	token      *gooauth2.Token
+	refreshAt  time.Time
+	refreshing bool
 }
 
+// ClientCredentialsToken returns the cached token while it is valid, or fetches a new one otherwise (or if forced).
+// Tokens about to expire are refreshed proactively in the background, while the cached token is still returned.
 func (c *ClientCredentials) ClientCredentialsToken(ctx context.Context, force bool) (*gooauth2.Token, error) {
 	c.mu.RLock()
 	if c.token != nil && c.token.Valid() && !force {
 		defer c.mu.RUnlock()
+		if !c.refreshAt.IsZero() && time.Now().After(c.refreshAt) {
+			go c.refresh()
+		}
 		return c.token, nil
 	}
 	c.mu.RUnlock()
@@ -47,6 +58,35 @@ func (c *ClientCredentials) ClientCredentialsToken(ctx context.Context, force bo
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.token = token
+	c.setToken(token)
 	return c.token, nil
 }
+
+// refresh fetches a new token in the background, unless a refresh is already in progress
+func (c *ClientCredentials) refresh() {
+	c.mu.Lock()
+	if c.refreshing {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing = true
+	c.mu.Unlock()
+
+	token, err := c.Token(context.Background())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing = false
+	if err == nil {
+		c.setToken(token)
+	}
+}
+
+// Caution! This function is not thread-safe. Make sure to acquire a lock before calling it.
+func (c *ClientCredentials) setToken(token *gooauth2.Token) {
+	c.token = token
+	c.refreshAt = time.Time{}
+	if expiry := token.Expiry; !expiry.IsZero() {
+		c.refreshAt = expiry.Add(-time.Duration(float64(time.Until(expiry)) * refreshWindow))
+	}
+}
@@ -0,0 +1,51 @@
+package oauth2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SharedTokenManager is the token manager shared by all the evaluators that need OAuth2 client credentials tokens for outbound calls
+var SharedTokenManager = NewTokenManager()
+
+// TokenManager keeps one ClientCredentials per token endpoint and set of client credentials, so that evaluators configured
+// with the same credentials (usually referred from the same Kubernetes Secret) share the tokens and their refreshes.
+type TokenManager struct {
+	mu      sync.Mutex
+	clients map[string]*ClientCredentials
+}
+
+func NewTokenManager() *TokenManager {
+	return &TokenManager{clients: make(map[string]*ClientCredentials)}
+}
+
+// ClientCredentials returns the ClientCredentials shared for the token endpoint and credentials, creating it if needed
+func (m *TokenManager) ClientCredentials(tokenURL, clientID, clientSecret string, scopes []string, extraParams map[string]string) *ClientCredentials {
+	key := clientCredentialsKey(tokenURL, clientID, clientSecret, scopes, extraParams)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if client, found := m.clients[key]; found {
+		return client
+	}
+	client := NewClientCredentialsConfig(tokenURL, clientID, clientSecret, scopes, extraParams)
+	m.clients[key] = client
+	return client
+}
+
+// clientCredentialsKey identifies a set of client credentials without keeping the client secret in clear text
+func clientCredentialsKey(tokenURL, clientID, clientSecret string, scopes []string, extraParams map[string]string) string {
+	sortedScopes := append([]string{}, scopes...)
+	sort.Strings(sortedScopes)
+	params := url.Values{}
+	for k, v := range extraParams {
+		params.Set(k, v)
+	}
+	hash := sha256.Sum256([]byte(strings.Join([]string{tokenURL, clientID, clientSecret, strings.Join(sortedScopes, " "), params.Encode()}, "\n")))
+	return hex.EncodeToString(hash[:])
+}
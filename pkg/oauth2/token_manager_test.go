@@ -0,0 +1,67 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/httptest"
+	"gotest.tools/assert"
+)
+
+func TestTokenManagerSharesClientCredentials(t *testing.T) {
+	manager := NewTokenManager()
+	tokenUrl := "http://" + testOAuth2ServerHost + "/token"
+
+	c1 := manager.ClientCredentials(tokenUrl, "foo", "secret", []string{"a", "b"}, map[string]string{"audience": "x"})
+	c2 := manager.ClientCredentials(tokenUrl, "foo", "secret", []string{"b", "a"}, map[string]string{"audience": "x"})
+	assert.Check(t, c1 == c2)
+
+	c3 := manager.ClientCredentials(tokenUrl, "foo", "rotated-secret", []string{"a", "b"}, map[string]string{"audience": "x"})
+	assert.Check(t, c1 != c3)
+
+	c4 := manager.ClientCredentials(tokenUrl, "foo", "secret", []string{"a"}, map[string]string{"audience": "x"})
+	assert.Check(t, c1 != c4)
+}
+
+func TestClientCredentialsProactiveRefresh(t *testing.T) {
+	nonce := 0
+	oauth2Server := httptest.NewHttpServerMock(testOAuth2ServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/token": func() httptest.HttpServerMockResponse {
+			nonce = nonce + 1
+			return httptest.HttpServerMockResponse{
+				Status:  http.StatusOK,
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    fmt.Sprintf(`{"access_token":"xyz-%d","token_type":"Bearer","expires_in":300}`, nonce),
+			}
+		},
+	})
+	defer oauth2Server.Close()
+
+	tokenUrl := "http://" + testOAuth2ServerHost + "/token"
+	oauthConfig := NewClientCredentialsConfig(tokenUrl, "foo", "secret", []string{}, map[string]string{})
+
+	token, err := oauthConfig.ClientCredentialsToken(context.TODO(), false)
+	assert.NilError(t, err)
+	assert.Equal(t, token.AccessToken, "xyz-1")
+	assert.Check(t, oauthConfig.refreshAt.After(time.Now().Add(230*time.Second)))
+
+	// within the refresh window, the cached token is returned while a new one is fetched in the background
+	oauthConfig.mu.Lock()
+	oauthConfig.refreshAt = time.Now().Add(-time.Second)
+	oauthConfig.mu.Unlock()
+
+	token, err = oauthConfig.ClientCredentialsToken(context.TODO(), false)
+	assert.NilError(t, err)
+	assert.Equal(t, token.AccessToken, "xyz-1")
+
+	for i := 0; i < 100; i++ {
+		if token, _ = oauthConfig.ClientCredentialsToken(context.TODO(), false); token.AccessToken != "xyz-1" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, token.AccessToken, "xyz-2")
+}
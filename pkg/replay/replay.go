@@ -0,0 +1,135 @@
+package replay
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/lru"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// DefaultTTL is how long one-time values are remembered when no ttl is set and the identity object has no `exp` claim
+	DefaultTTL = 5 * time.Minute
+
+	// DefaultMemoryStoreMaxEntries is the default maximum number of one-time values kept by the in-memory store
+	DefaultMemoryStoreMaxEntries = 100000
+
+	redisKeyPrefix = "authorino:replay:"
+
+	msg_replayed          = "the credential has already been used"
+	msg_missingValue      = "the credential has no one-time value"
+	msg_replayCheckFailed = "failed to check the replay of the credential"
+)
+
+// Store records one-time values (e.g. token ids, proof ids, nonces) and tells whether they have been used before
+type Store interface {
+	// Use records a one-time value as used for a period of time.
+	// Returns false if the value had already been used and the period has not expired yet.
+	Use(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// SharedStore is the store of one-time values used by all the replay protections.
+// It defaults to an in-memory store, local to the instance of Authorino.
+var SharedStore Store = NewMemoryStore(DefaultMemoryStoreMaxEntries)
+
+// NewMemoryStore returns a store of one-time values kept in memory, up to a maximum number of values.
+// The least recently used values are forgotten first.
+func NewMemoryStore(maxEntries int) Store {
+	return &memoryStore{used: lru.New("replay", lru.Options{MaxEntries: maxEntries})}
+}
+
+type memoryStore struct {
+	used *lru.Cache
+	mu   sync.Mutex
+}
+
+func (s *memoryStore) Use(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, used := s.used.Get(key); used {
+		return false, nil
+	}
+
+	s.used.AddWithTTL(key, struct{}{}, ttl)
+	return true, nil
+}
+
+// NewRedisStore returns a store of one-time values kept in Redis, so the values are shared among multiple instances of
+// Authorino. The url follows the format redis://<user>:<password>@<host>:<port>/<db>.
+func NewRedisStore(url string) (Store, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{client: redis.NewClient(options)}, nil
+}
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func (s *redisStore) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, redisKeyPrefix+key, 1, ttl).Result()
+}
+
+// Protection rejects identity objects whose one-time value (e.g. the `jti` claim of a token) has been seen before
+type Protection struct {
+	// Selector of the one-time value in the identity object
+	Selector string
+	// TTL is how long the one-time values are remembered. If zero, values are remembered until the expiry of the
+	// identity object (`exp` claim), or for the DefaultTTL.
+	TTL time.Duration
+	// Scope isolates the one-time values of different identity configs
+	Scope string
+}
+
+func NewProtection(selector string, ttl int, scope string) *Protection {
+	return &Protection{
+		Selector: selector,
+		TTL:      time.Duration(ttl) * time.Second,
+		Scope:    scope,
+	}
+}
+
+// Check returns an error if the one-time value of the identity object is missing or has already been used
+func (p *Protection) Check(ctx context.Context, identityObj interface{}) error {
+	identityJSON, err := gojson.Marshal(identityObj)
+	if err != nil {
+		return err
+	}
+
+	value := gjson.GetBytes(identityJSON, p.Selector).String()
+	if value == "" {
+		return fmt.Errorf(msg_missingValue)
+	}
+
+	ttl := p.TTL
+	if ttl == 0 {
+		ttl = DefaultTTL
+		if exp := gjson.GetBytes(identityJSON, "exp").Int(); exp > 0 {
+			ttl = time.Until(time.Unix(exp, 0))
+		}
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	// one-time values cannot be verified if the store is unavailable, therefore the identity is rejected
+	unused, err := SharedStore.Use(ctx, p.Scope+"/"+value, ttl)
+	if err != nil {
+		log.FromContext(ctx).WithName("replay").V(1).Info(msg_replayCheckFailed, "reason", err)
+		return fmt.Errorf(msg_replayCheckFailed)
+	}
+	if !unused {
+		return fmt.Errorf(msg_replayed)
+	}
+	return nil
+}
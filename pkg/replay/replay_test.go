@@ -0,0 +1,72 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+type failingStore struct{}
+
+func (s *failingStore) Use(_ context.Context, _ string, _ time.Duration) (bool, error) {
+	return false, fmt.Errorf("connection refused")
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(DefaultMemoryStoreMaxEntries)
+
+	unused, err := store.Use(context.TODO(), "a", 10*time.Millisecond)
+	assert.NilError(t, err)
+	assert.Check(t, unused)
+
+	unused, _ = store.Use(context.TODO(), "a", 10*time.Millisecond)
+	assert.Check(t, !unused)
+
+	unused, _ = store.Use(context.TODO(), "b", 10*time.Millisecond)
+	assert.Check(t, unused)
+
+	time.Sleep(20 * time.Millisecond) // expired
+	unused, _ = store.Use(context.TODO(), "a", time.Minute)
+	assert.Check(t, unused)
+}
+
+func TestMemoryStoreMaxEntries(t *testing.T) {
+	store := NewMemoryStore(2)
+
+	_, _ = store.Use(context.TODO(), "a", time.Minute)
+	_, _ = store.Use(context.TODO(), "b", time.Minute)
+	_, _ = store.Use(context.TODO(), "c", time.Minute) // evicts a
+
+	unused, _ := store.Use(context.TODO(), "c", time.Minute)
+	assert.Check(t, !unused)
+	unused, _ = store.Use(context.TODO(), "a", time.Minute)
+	assert.Check(t, unused)
+}
+
+func TestNewRedisStoreInvalidURL(t *testing.T) {
+	_, err := NewRedisStore("http://localhost:6379")
+	assert.ErrorContains(t, err, "invalid URL scheme")
+}
+
+func TestProtectionCheck(t *testing.T) {
+	protection := NewProtection("jti", 0, "ns/authconfig/identity")
+	exp := time.Now().Add(time.Hour).Unix()
+
+	assert.NilError(t, protection.Check(context.TODO(), map[string]interface{}{"jti": "a1", "exp": exp}))
+	assert.Error(t, protection.Check(context.TODO(), map[string]interface{}{"jti": "a1", "exp": exp}), "the credential has already been used")
+	assert.NilError(t, protection.Check(context.TODO(), map[string]interface{}{"jti": "b2"}))
+	assert.Error(t, protection.Check(context.TODO(), map[string]interface{}{"sub": "john"}), "the credential has no one-time value")
+
+	// scoped
+	other := NewProtection("jti", 60, "ns/authconfig/other")
+	assert.NilError(t, other.Check(context.TODO(), map[string]interface{}{"jti": "a1"}))
+
+	// store unavailable
+	defaultStore := SharedStore
+	SharedStore = &failingStore{}
+	defer func() { SharedStore = defaultStore }()
+	assert.Error(t, protection.Check(context.TODO(), map[string]interface{}{"jti": "c3"}), "failed to check the replay of the credential")
+}
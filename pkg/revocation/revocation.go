@@ -0,0 +1,202 @@
+package revocation
+
+import (
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/kuadrant/authorino/pkg/httpclient"
+	"github.com/kuadrant/authorino/pkg/log"
+
+	"github.com/tidwall/gjson"
+	k8s "k8s.io/api/core/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+	k8s_client "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	msg_identityRevoked          = "the identity has been revoked"
+	msg_revocationCheckFailed    = "failed to check the revocation of the identity"
+	msg_revocationListLoadFailed = "failed to load the revocation lists"
+)
+
+// List is a deny-list of identities, consulted after the identity is verified.
+//
+// Revoked values are read from Kubernetes Secrets matching a label selector, whose keys are paths to claims of the
+// identity object (e.g. `jti`, `sub`) and whose values list the revoked values of the claim, one per line. Optionally,
+// an external revocation endpoint is asked about every identity object not revoked by the Secrets.
+type List struct {
+	LabelSelectors k8s_labels.Selector
+	Namespace      string
+	Endpoint       string
+
+	// revoked values by claim, by secret
+	secrets   map[string]map[string]map[string]struct{}
+	mutex     sync.RWMutex
+	k8sClient k8s_client.Reader
+}
+
+func NewList(labelSelectors k8s_labels.Selector, namespace, endpoint string, k8sClient k8s_client.Reader, ctx context.Context) *List {
+	list := &List{
+		LabelSelectors: labelSelectors,
+		Namespace:      namespace,
+		Endpoint:       endpoint,
+		secrets:        make(map[string]map[string]map[string]struct{}),
+		k8sClient:      k8sClient,
+	}
+	if labelSelectors != nil && k8sClient != nil {
+		if err := list.loadSecrets(context.TODO()); err != nil {
+			log.FromContext(ctx).WithName("revocation").Error(err, msg_revocationListLoadFailed)
+		}
+	}
+	return list
+}
+
+func (l *List) loadSecrets(ctx context.Context) error {
+	opts := []k8s_client.ListOption{k8s_client.MatchingLabelsSelector{Selector: l.LabelSelectors}}
+	if namespace := l.Namespace; namespace != "" {
+		opts = append(opts, k8s_client.InNamespace(namespace))
+	}
+	var secretList = &k8s.SecretList{}
+	if err := l.k8sClient.List(ctx, secretList, opts...); err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, secret := range secretList.Items {
+		l.secrets[k8s_types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}.String()] = revokedValuesFromSecret(secret)
+	}
+
+	return nil
+}
+
+// Check returns an error if the identity object is revoked
+func (l *List) Check(ctx context.Context, identityObj interface{}) error {
+	identityJSON, err := gojson.Marshal(identityObj)
+	if err != nil {
+		return err
+	}
+
+	if l.revokedBySecrets(string(identityJSON)) {
+		return fmt.Errorf(msg_identityRevoked)
+	}
+
+	if l.Endpoint == "" {
+		return nil
+	}
+
+	// revocations cannot be verified if the endpoint is unavailable, therefore the identity is rejected
+	revoked, err := l.revokedByEndpoint(ctx, identityJSON)
+	if err != nil {
+		log.FromContext(ctx).WithName("revocation").V(1).Info(msg_revocationCheckFailed, "endpoint", l.Endpoint, "reason", err)
+		return fmt.Errorf(msg_revocationCheckFailed)
+	}
+	if revoked {
+		return fmt.Errorf(msg_identityRevoked)
+	}
+	return nil
+}
+
+func (l *List) revokedBySecrets(identityJSON string) bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	for _, claims := range l.secrets {
+		for claim, values := range claims {
+			revoked := false
+			gjson.Get(identityJSON, claim).ForEach(func(_, value gjson.Result) bool {
+				_, revoked = values[value.String()]
+				return !revoked
+			})
+			if revoked {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// revokedByEndpoint sends the identity object to the revocation endpoint, which is expected to respond with a JSON
+// object whose `revoked` property is true if the identity is revoked
+func (l *List) revokedByEndpoint(ctx context.Context, identityJSON []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", l.Endpoint, bytes.NewReader(identityJSON))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpclient.Client().Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	var result struct {
+		Revoked bool `json:"revoked"`
+	}
+	if err := gojson.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, err
+	}
+	return result.Revoked, nil
+}
+
+// impl:K8sSecretBasedIdentityConfigEvaluator
+
+func (l *List) GetK8sSecretLabelSelectors() k8s_labels.Selector {
+	return l.LabelSelectors
+}
+
+func (l *List) AddK8sSecretBasedIdentity(ctx context.Context, new k8s.Secret) {
+	if !l.withinScope(new.GetNamespace()) {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.secrets[k8s_types.NamespacedName{Namespace: new.Namespace, Name: new.Name}.String()] = revokedValuesFromSecret(new)
+	log.FromContext(ctx).WithName("revocation").V(1).Info("revocation list updated")
+}
+
+func (l *List) RevokeK8sSecretBasedIdentity(ctx context.Context, deleted k8s_types.NamespacedName) {
+	if !l.withinScope(deleted.Namespace) {
+		return
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, found := l.secrets[deleted.String()]; found {
+		delete(l.secrets, deleted.String())
+		log.FromContext(ctx).WithName("revocation").V(1).Info("revocation list deleted")
+	}
+}
+
+func (l *List) withinScope(namespace string) bool {
+	return l.LabelSelectors != nil && (l.Namespace == "" || l.Namespace == namespace)
+}
+
+func revokedValuesFromSecret(secret k8s.Secret) map[string]map[string]struct{} {
+	claims := make(map[string]map[string]struct{}, len(secret.Data))
+	for claim, data := range secret.Data {
+		values := make(map[string]struct{})
+		for _, value := range strings.Split(string(data), "\n") {
+			if value = strings.TrimSpace(value); value != "" {
+				values[value] = struct{}{}
+			}
+		}
+		claims[claim] = values
+	}
+	return claims
+}
@@ -0,0 +1,85 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/httptest"
+
+	"gotest.tools/assert"
+	k8s "k8s.io/api/core/v1"
+	k8s_meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s_labels "k8s.io/apimachinery/pkg/labels"
+	k8s_runtime "k8s.io/apimachinery/pkg/runtime"
+	k8s_types "k8s.io/apimachinery/pkg/types"
+	k8s_client "sigs.k8s.io/controller-runtime/pkg/client"
+	k8s_fake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const revocationServerHost = "127.0.0.1:9014"
+
+func mockK8sClient(initObjs ...k8s_runtime.Object) k8s_client.WithWatch {
+	scheme := k8s_runtime.NewScheme()
+	_ = k8s.AddToScheme(scheme)
+	return k8s_fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(initObjs...).Build()
+}
+
+func newRevocationTestSecret(name, namespace string, data map[string]string) *k8s.Secret {
+	secret := &k8s.Secret{ObjectMeta: k8s_meta.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"authorino.kuadrant.io/revocation-list": "true"}}, Data: map[string][]byte{}}
+	for k, v := range data {
+		secret.Data[k] = []byte(v)
+	}
+	return secret
+}
+
+func TestCheckWithSecrets(t *testing.T) {
+	selector, _ := k8s_labels.Parse("authorino.kuadrant.io/revocation-list=true")
+	client := mockK8sClient(
+		newRevocationTestSecret("tokens", "ns1", map[string]string{"jti": "a1\nb2\n"}),
+		newRevocationTestSecret("users", "ns1", map[string]string{"sub": "mallory", "groups": "contractors"}),
+		newRevocationTestSecret("other", "ns2", map[string]string{"sub": "john"}),
+	)
+	list := NewList(selector, "ns1", "", client, context.TODO())
+
+	assert.NilError(t, list.Check(context.TODO(), map[string]interface{}{"jti": "c3", "sub": "john"}))
+	assert.Error(t, list.Check(context.TODO(), map[string]interface{}{"jti": "b2", "sub": "john"}), "the identity has been revoked")
+	assert.Error(t, list.Check(context.TODO(), map[string]interface{}{"jti": "c3", "sub": "mallory"}), "the identity has been revoked")
+	assert.Error(t, list.Check(context.TODO(), map[string]interface{}{"sub": "jane", "groups": []string{"staff", "contractors"}}), "the identity has been revoked")
+
+	// secret updated
+	list.AddK8sSecretBasedIdentity(context.TODO(), *newRevocationTestSecret("users", "ns1", map[string]string{"sub": "john"}))
+	assert.NilError(t, list.Check(context.TODO(), map[string]interface{}{"sub": "mallory"}))
+	assert.Error(t, list.Check(context.TODO(), map[string]interface{}{"sub": "john"}), "the identity has been revoked")
+
+	// out of scope
+	list.AddK8sSecretBasedIdentity(context.TODO(), *newRevocationTestSecret("more", "ns2", map[string]string{"sub": "jane"}))
+	assert.NilError(t, list.Check(context.TODO(), map[string]interface{}{"sub": "jane"}))
+
+	// secret deleted
+	list.RevokeK8sSecretBasedIdentity(context.TODO(), k8s_types.NamespacedName{Namespace: "ns1", Name: "users"})
+	assert.NilError(t, list.Check(context.TODO(), map[string]interface{}{"sub": "john"}))
+}
+
+func TestCheckWithEndpoint(t *testing.T) {
+	server := httptest.NewHttpServerMock(revocationServerHost, map[string]httptest.HttpServerMockResponseFunc{
+		"/revoked": func() httptest.HttpServerMockResponse {
+			return httptest.HttpServerMockResponse{Status: 200, Body: `{"revoked":true}`}
+		},
+		"/not-revoked": func() httptest.HttpServerMockResponse {
+			return httptest.HttpServerMockResponse{Status: 200, Body: `{"revoked":false}`}
+		},
+		"/error": func() httptest.HttpServerMockResponse { return httptest.HttpServerMockResponse{Status: 500} },
+	})
+	defer server.Close()
+
+	identity := map[string]interface{}{"sub": "john"}
+
+	list := NewList(nil, "", "http://"+revocationServerHost+"/not-revoked", nil, context.TODO())
+	assert.NilError(t, list.Check(context.TODO(), identity))
+
+	list = NewList(nil, "", "http://"+revocationServerHost+"/revoked", nil, context.TODO())
+	assert.Error(t, list.Check(context.TODO(), identity), "the identity has been revoked")
+
+	list = NewList(nil, "", "http://"+revocationServerHost+"/error", nil, context.TODO())
+	assert.Error(t, list.Check(context.TODO(), identity), "failed to check the revocation of the identity")
+}
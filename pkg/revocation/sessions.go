@@ -0,0 +1,69 @@
+package revocation
+
+import (
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/lru"
+)
+
+// SessionRetention is how long logged out sessions are remembered.
+// Tokens issued before a logout and still valid after this period are no longer rejected.
+var SessionRetention = 24 * time.Hour
+
+// SessionsMaxEntries is the maximum number of logged out sessions remembered. The oldest logouts are forgotten first.
+var SessionsMaxEntries = 100000
+
+// SharedSessions is the cache of logged out sessions shared by all the identity evaluators
+var SharedSessions = NewSessions()
+
+// Sessions remembers the sessions logged out at the identity providers (e.g. by OpenID Connect back-channel logout),
+// so that tokens issued for those sessions before the logout are rejected
+type Sessions struct {
+	loggedOut *lru.Cache
+}
+
+func NewSessions() *Sessions {
+	return &Sessions{loggedOut: lru.New("revocation", lru.Options{MaxEntries: SessionsMaxEntries})}
+}
+
+// Resize changes the maximum number of logged out sessions remembered
+func (s *Sessions) Resize(maxEntries int) {
+	s.loggedOut.Resize(maxEntries)
+}
+
+// Logout records the logout of a session of an issuer. If the session id is empty, all sessions of the subject are
+// logged out.
+func (s *Sessions) Logout(issuer, subject, sessionID string) {
+	now := time.Now()
+
+	if sessionID != "" {
+		s.loggedOut.AddWithTTL(sessionKey(issuer, "sid", sessionID), now, SessionRetention)
+	} else if subject != "" {
+		s.loggedOut.AddWithTTL(sessionKey(issuer, "sub", subject), now, SessionRetention)
+	}
+}
+
+// LoggedOut tells whether a token of an issuer, issued at a given time for a subject and session, belongs to a session
+// logged out after the token was issued
+func (s *Sessions) LoggedOut(issuer, subject, sessionID string, issuedAt time.Time) bool {
+	if s.loggedOut.Len() == 0 {
+		return false
+	}
+
+	for _, key := range []string{sessionKey(issuer, "sid", sessionID), sessionKey(issuer, "sub", subject)} {
+		if key == "" {
+			continue
+		}
+		if at, found := s.loggedOut.Get(key); found && !issuedAt.After(at.(time.Time)) {
+			return true
+		}
+	}
+	return false
+}
+
+func sessionKey(issuer, claim, value string) string {
+	if value == "" {
+		return ""
+	}
+	return issuer + "\x00" + claim + "\x00" + value
+}
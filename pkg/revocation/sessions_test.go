@@ -0,0 +1,40 @@
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestSessions(t *testing.T) {
+	sessions := NewSessions()
+	issuedAt := time.Now().Add(-time.Minute)
+
+	assert.Check(t, !sessions.LoggedOut("https://issuer", "john", "s1", issuedAt))
+
+	sessions.Logout("https://issuer", "john", "s1")
+	assert.Check(t, sessions.LoggedOut("https://issuer", "john", "s1", issuedAt))
+	assert.Check(t, !sessions.LoggedOut("https://issuer", "john", "s2", issuedAt))
+	assert.Check(t, !sessions.LoggedOut("https://other", "john", "s1", issuedAt))
+	assert.Check(t, !sessions.LoggedOut("https://issuer", "john", "s1", time.Now().Add(time.Minute))) // issued after the logout
+
+	sessions.Logout("https://issuer", "john", "")
+	assert.Check(t, sessions.LoggedOut("https://issuer", "john", "s2", issuedAt))
+	assert.Check(t, sessions.LoggedOut("https://issuer", "john", "", issuedAt))
+	assert.Check(t, !sessions.LoggedOut("https://issuer", "jane", "", issuedAt))
+}
+
+func TestSessionsMaxEntries(t *testing.T) {
+	sessions := NewSessions()
+	sessions.Resize(2)
+	issuedAt := time.Now().Add(-time.Minute)
+
+	sessions.Logout("https://issuer", "john", "s1")
+	sessions.Logout("https://issuer", "john", "s2")
+	sessions.Logout("https://issuer", "john", "s3") // forgets s1
+
+	assert.Check(t, !sessions.LoggedOut("https://issuer", "john", "s1", issuedAt))
+	assert.Check(t, sessions.LoggedOut("https://issuer", "john", "s2", issuedAt))
+	assert.Check(t, sessions.LoggedOut("https://issuer", "john", "s3", issuedAt))
+}
@@ -4,19 +4,23 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	gocontext "golang.org/x/net/context"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
+	"github.com/kuadrant/authorino/pkg/evaluators"
 	"github.com/kuadrant/authorino/pkg/index"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/metrics"
 	"github.com/kuadrant/authorino/pkg/trace"
+	"github.com/kuadrant/authorino/pkg/utils"
 
 	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
@@ -26,6 +30,7 @@ import (
 	otel_codes "go.opentelemetry.io/otel/codes"
 	rpcstatus "google.golang.org/genproto/googleapis/rpc/status"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	v1 "k8s.io/api/admission/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -33,18 +38,21 @@ import (
 const (
 	HTTPAuthorizationBasePath = "/check"
 
-	X_EXT_AUTH_REASON_HEADER      = "X-Ext-Auth-Reason"
-	ENVOY_TRACE_REQUEST_ID_HEADER = "X-Request-Id"
+	X_EXT_AUTH_REASON_HEADER              = "X-Ext-Auth-Reason"
+	X_ENVOY_AUTH_HEADERS_TO_REMOVE_HEADER = "X-Envoy-Auth-Headers-To-Remove"
+	ENVOY_TRACE_REQUEST_ID_HEADER         = "X-Request-Id"
 
 	RESPONSE_MESSAGE_INVALID_REQUEST   = "Invalid request"
 	RESPONSE_MESSAGE_SERVICE_NOT_FOUND = "Service not found"
+	RESPONSE_MESSAGE_OVERLOADED        = "Too many requests in flight"
 
 	HTTP_MESSAGE_400 = "bad request"
 	HTTP_MESSAGE_404 = "not found"
 	HTTP_MESSAGE_413 = "request body too large"
 	HTTP_MESSAGE_503 = "service unavailable"
 
-	X_LOOKUP_KEY_NAME = "host"
+	X_LOOKUP_KEY_NAME        = "host"
+	X_LOOKUP_AUTHCONFIG_NAME = "authorino.authconfig"
 )
 
 var (
@@ -69,6 +77,14 @@ func init() {
 	)
 }
 
+// number of authorization requests currently being handled by the auth service
+var inFlightChecks int64
+
+// InFlightChecks returns the number of authorization requests currently being handled, across the gRPC and the raw HTTP interfaces
+func InFlightChecks() int64 {
+	return atomic.LoadInt64(&inFlightChecks)
+}
+
 // AuthService is the server API for the authorization service.
 type AuthService struct {
 	Index                  index.Index
@@ -214,9 +230,21 @@ func (a *AuthService) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		} else {
 			// not an admission review request
 			respStatusCode = statusCodeMapping[code]
+			// requests rejected by the auth server itself (request limits, requests in flight) are denied with the status set by the server
+			if deniedStatus := checkResponse.GetDeniedResponse().GetStatus().GetCode(); deniedStatus != 0 && (code == rpc.FAILED_PRECONDITION || code == rpc.UNAVAILABLE) {
+				respStatusCode = deniedStatus
+			}
 			var headers []*envoy_core.HeaderValueOption
 			if code == rpc.OK {
 				headers = checkResponse.GetOkResponse().GetHeaders()
+				// headers to add to the response may repeat (e.g. set-cookie)
+				for _, h := range checkResponse.GetOkResponse().GetResponseHeadersToAdd() {
+					resp.Header().Add(h.Header.GetKey(), h.Header.GetValue())
+				}
+				// envoy's http ext_authz filter removes from the request the headers listed in this header of the authorization response
+				if headersToRemove := checkResponse.GetOkResponse().GetHeadersToRemove(); len(headersToRemove) > 0 {
+					resp.Header().Set(X_ENVOY_AUTH_HEADERS_TO_REMOVE_HEADER, strings.Join(headersToRemove, ","))
+				}
 			} else {
 				headers = checkResponse.GetDeniedResponse().GetHeaders()
 				respBody = []byte(checkResponse.GetDeniedResponse().GetBody())
@@ -247,21 +275,44 @@ func (a *AuthService) Check(parentContext gocontext.Context, req *envoy_auth.Che
 	requestLogger := log.WithName("service").WithName("auth").WithValues("request id", requestId)
 	ctx = log.IntoContext(context.New(context.WithParent(ctx), context.WithTimeout(a.Timeout)), requestLogger)
 
+	// guardrails on the size of the request
+	if result := CheckRequestLimits.Enforce(req); result != nil {
+		a.logAuthResult(*result, ctx)
+		return a.deniedResponse(*result), nil
+	}
+
 	a.logAuthRequest(req, ctx)
 
+	// back-pressure
+	if !CheckRequestLimiter.Acquire(ctx) {
+		metrics.ReportMetric(authServerRejectedMetric)
+		result := auth.AuthResult{Code: rpc.UNAVAILABLE, Status: CheckRequestLimitStatus, Message: RESPONSE_MESSAGE_OVERLOADED}
+		a.logAuthResult(result, ctx)
+		return a.deniedResponse(result), nil
+	}
+	defer CheckRequestLimiter.Release()
+
+	atomic.AddInt64(&inFlightChecks, 1)
+	defer atomic.AddInt64(&inFlightChecks, -1)
+
 	// service config
-	var host string
+	var hosts []string
 	if h, overridden := req.Attributes.ContextExtensions[X_LOOKUP_KEY_NAME]; overridden {
-		host = h
+		hosts = hostLookupKeys(h, h)
 	} else {
-		host = requestData.Host
+		hosts = hostLookupKeys(requestData.Host, normalizeHost(requestData.Host, requestData.Scheme))
 	}
 
-	authConfig := a.Index.Get(host)
-	// If the host is not found, but contains a port, remove the port part and retry.
-	if authConfig == nil && strings.Contains(host, ":") {
-		splitHost := strings.Split(host, ":")
-		authConfig = a.Index.Get(splitHost[0])
+	var authConfig *evaluators.AuthConfig
+	if id, selected := req.Attributes.ContextExtensions[X_LOOKUP_AUTHCONFIG_NAME]; selected {
+		// the AuthConfig explicitly selected by the external authorization client takes precedence over the host lookup
+		authConfig = a.lookupAuthConfigById(id, hosts)
+	} else {
+		for _, host := range hosts {
+			if authConfig = a.Index.Get(host); authConfig != nil {
+				break
+			}
+		}
 	}
 
 	// If we couldn't find the AuthConfig in the config, we return and deny.
@@ -292,6 +343,33 @@ func (a *AuthService) Check(parentContext gocontext.Context, req *envoy_auth.Che
 	}
 }
 
+// lookupAuthConfigById returns the indexed AuthConfig of a resource id (namespace/name), regardless of the host of the request.
+// If the resource is indexed for the host of the request, the config of that host is returned, so host overrides apply.
+// Otherwise, the config shared by the hosts of the resource without overrides is returned, or nil if all its hosts have overrides.
+func (a *AuthService) lookupAuthConfigById(id string, hosts []string) *evaluators.AuthConfig {
+	keys := a.Index.FindKeys(id)
+	for _, key := range hosts {
+		if utils.SliceContains(keys, key) && a.indexedFor(id, key) {
+			return a.Index.Get(key)
+		}
+	}
+	for _, key := range keys {
+		if !a.indexedFor(id, key) {
+			continue
+		}
+		if authConfig := a.Index.Get(key); authConfig != nil && authConfig.Labels["host"] == "" {
+			return authConfig
+		}
+	}
+	return nil
+}
+
+// indexedFor tells whether the key is indexed for the resource id, i.e. not taken by another resource
+func (a *AuthService) indexedFor(id, key string) bool {
+	indexedId, found := a.Index.FindId(key)
+	return found && indexedId == id
+}
+
 func (a *AuthService) successResponse(authResult auth.AuthResult, ctx gocontext.Context) *envoy_auth.CheckResponse {
 	dynamicMetadata, err := buildEnvoyDynamicMetadata(authResult.Metadata)
 	if err != nil {
@@ -307,7 +385,9 @@ func (a *AuthService) successResponse(authResult auth.AuthResult, ctx gocontext.
 		},
 		HttpResponse: &envoy_auth.CheckResponse_OkResponse{
 			OkResponse: &envoy_auth.OkHttpResponse{
-				Headers: buildResponseHeaders(authResult.Headers),
+				Headers:              buildResponseHeaders(authResult.Headers),
+				HeadersToRemove:      authResult.HeadersToRemove,
+				ResponseHeadersToAdd: buildResponseHeadersToAdd(authResult.ResponseHeaders),
 			},
 		},
 		DynamicMetadata: dynamicMetadata,
@@ -400,6 +480,42 @@ func (a *AuthService) logAuthResult(result auth.AuthResult, ctx gocontext.Contex
 	}
 }
 
+// hostLookupKeys returns the keys to look up the AuthConfig of a request by, in order: the host as requested, the normalized
+// host and the normalized host without the port. The host as requested is tried first, so AuthConfigs indexed by exactly the
+// host of the request are not shadowed by the normalization.
+func hostLookupKeys(host, normalizedHost string) []string {
+	keys := []string{host}
+	for _, key := range []string{normalizedHost, stripPort(normalizedHost)} {
+		if !utils.SliceContains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// normalizeHost lowercases the host name, and removes the trailing dot of fully qualified names and the port when it is the default one of the scheme
+func normalizeHost(host, scheme string) string {
+	host = strings.ToLower(host)
+
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return strings.TrimSuffix(host, ".")
+	}
+
+	hostname = strings.TrimSuffix(hostname, ".")
+	if (port == "80" && scheme != "https") || (port == "443" && scheme != "http") {
+		return hostname
+	}
+	return net.JoinHostPort(hostname, port)
+}
+
+func stripPort(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		return hostname
+	}
+	return strings.Split(host, ":")[0]
+}
+
 func buildResponseHeaders(headers []map[string]string) []*envoy_core.HeaderValueOption {
 	responseHeaders := make([]*envoy_core.HeaderValueOption, 0)
 
@@ -417,6 +533,19 @@ func buildResponseHeaders(headers []map[string]string) []*envoy_core.HeaderValue
 	return responseHeaders
 }
 
+// buildResponseHeadersToAdd builds the headers to add to the response sent downstream. Envoy (and therefore Istio's
+// CUSTOM action) overwrites existing headers of the response unless told otherwise, thus cookies are always appended,
+// so multiple set-cookie headers can coexist
+func buildResponseHeadersToAdd(headers []map[string]string) []*envoy_core.HeaderValueOption {
+	responseHeaders := buildResponseHeaders(headers)
+	for _, h := range responseHeaders {
+		if strings.EqualFold(h.Header.Key, "set-cookie") {
+			h.Append = wrapperspb.Bool(true)
+		}
+	}
+	return responseHeaders
+}
+
 func buildResponseHeadersWithReason(authReason string, extraHeaders []map[string]string) []*envoy_core.HeaderValueOption {
 	var headers []map[string]string
 
@@ -3,12 +3,17 @@ package service
 import (
 	gojson "encoding/json"
 	"fmt"
+	"mime"
+	"net/url"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	"github.com/kuadrant/authorino/pkg/context"
 	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 	"github.com/kuadrant/authorino/pkg/json"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/metrics"
@@ -19,6 +24,8 @@ import (
 	gocontext "golang.org/x/net/context"
 )
 
+const noApplicableIdentityMsg = "no identity config applicable to the request"
+
 var (
 	evaluatorMetricLabels = []string{"evaluator_type", "evaluator_name"}
 
@@ -74,16 +81,17 @@ func NewAuthPipeline(parentCtx gocontext.Context, req *envoy_auth.CheckRequest,
 	logger := log.FromContext(parentCtx).WithName("authpipeline")
 
 	return &AuthPipeline{
-		Context:       log.IntoContext(parentCtx, logger),
-		Request:       req,
-		AuthConfig:    &authConfig,
-		Identity:      make(map[*evaluators.IdentityConfig]interface{}),
-		Metadata:      make(map[*evaluators.MetadataConfig]interface{}),
-		Authorization: make(map[*evaluators.AuthorizationConfig]interface{}),
-		Response:      make(map[*evaluators.ResponseConfig]interface{}),
-		Callbacks:     make(map[*evaluators.CallbackConfig]interface{}),
-		Logger:        logger,
-		mu:            sync.RWMutex{},
+		Context:        log.IntoContext(parentCtx, logger),
+		Request:        req,
+		AuthConfig:     &authConfig,
+		Identity:       make(map[*evaluators.IdentityConfig]interface{}),
+		Metadata:       make(map[*evaluators.MetadataConfig]interface{}),
+		Authorization:  make(map[*evaluators.AuthorizationConfig]interface{}),
+		Response:       make(map[*evaluators.ResponseConfig]interface{}),
+		Callbacks:      make(map[*evaluators.CallbackConfig]interface{}),
+		Logger:         logger,
+		requestContext: buildRequestContext(req, authConfig.RequestBody),
+		mu:             sync.RWMutex{},
 	}
 }
 
@@ -103,6 +111,9 @@ type AuthPipeline struct {
 
 	Logger log.Logger
 
+	// context of the request as it goes in the authorization JSON, when different from the attributes of the request
+	requestContext interface{}
+
 	mu sync.RWMutex
 }
 
@@ -118,6 +129,7 @@ func (pipeline *AuthPipeline) evaluateAuthConfig(config auth.AuthConfigEvaluator
 
 	if conditionalEv, ok := config.(auth.ConditionalEvaluator); ok {
 		if err := pipeline.evaluateConditions(conditionalEv.GetConditions()); err != nil {
+			pipeline.Logger.V(1).Info("skipping config", "config", config, "reason", err)
 			metrics.ReportMetricWithObject(authServerEvaluatorIgnoredMetric, monitorable, pipeline.metricLabels()...)
 			return
 		}
@@ -146,36 +158,54 @@ func (pipeline *AuthPipeline) evaluateAuthConfig(config auth.AuthConfigEvaluator
 
 type authConfigEvaluationStrategy func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func())
 
-func (pipeline *AuthPipeline) evaluateAuthConfigs(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse, evaluate authConfigEvaluationStrategy) {
-	ctx, cancel := gocontext.WithCancel(pipeline.Context)
-	waitGroup := new(sync.WaitGroup)
-	waitGroup.Add(len(authConfigs))
+// evaluateAuthConfigs schedules the evaluation of the configs in the evaluator worker pool, without waiting for them to
+// finish. The response channel is closed once all configs are evaluated. Configs that cannot be scheduled because the
+// context is done are evaluated inline, which only reports them as skipped.
+func (pipeline *AuthPipeline) evaluateAuthConfigs(parentCtx gocontext.Context, authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse, evaluate authConfigEvaluationStrategy) {
+	ctx, cancel := gocontext.WithCancel(parentCtx)
+
+	pending := int32(len(authConfigs))
+	if pending == 0 {
+		cancel()
+		close(*respChannel)
+		return
+	}
 
 	for _, authConfig := range authConfigs {
 		objConfig := authConfig
-		go func() {
-			defer waitGroup.Done()
+		task := func() {
+			defer func() {
+				if atomic.AddInt32(&pending, -1) == 0 {
+					cancel()
+					close(*respChannel)
+				}
+			}()
 			evaluate(objConfig, ctx, respChannel, cancel)
-		}()
+		}
+		if err := EvaluatorWorkerPool.Submit(ctx, task); err != nil {
+			task()
+		}
 	}
-
-	waitGroup.Wait()
 }
 
 func (pipeline *AuthPipeline) evaluateOneAuthConfig(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
-	pipeline.evaluateAuthConfigs(authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
+	pipeline.evaluateAuthConfigs(pipeline.Context, authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
 		pipeline.evaluateAuthConfig(conf, ctx, respChannel, cancel, nil) // cancels the context if at least one thread succeeds
 	})
 }
 
 func (pipeline *AuthPipeline) evaluateAllAuthConfigs(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
-	pipeline.evaluateAuthConfigs(authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
+	pipeline.evaluateAuthConfigs(pipeline.Context, authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, cancel func()) {
 		pipeline.evaluateAuthConfig(conf, ctx, respChannel, nil, cancel) // cancels the context if at least one thread fails
 	})
 }
 
 func (pipeline *AuthPipeline) evaluateAnyAuthConfig(authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
-	pipeline.evaluateAuthConfigs(authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, _ func()) {
+	pipeline.evaluateAnyAuthConfigWithContext(pipeline.Context, authConfigs, respChannel)
+}
+
+func (pipeline *AuthPipeline) evaluateAnyAuthConfigWithContext(ctx gocontext.Context, authConfigs []auth.AuthConfigEvaluator, respChannel *chan EvaluationResponse) {
+	pipeline.evaluateAuthConfigs(ctx, authConfigs, respChannel, func(conf auth.AuthConfigEvaluator, ctx gocontext.Context, respChannel *chan EvaluationResponse, _ func()) {
 		pipeline.evaluateAuthConfig(conf, ctx, respChannel, nil, nil)
 	})
 }
@@ -185,13 +215,15 @@ func groupAuthConfigsByPriority(authConfigs []auth.AuthConfigEvaluator) (map[int
 	authConfigsByPriority := make(map[int][]auth.AuthConfigEvaluator)
 
 	for _, conf := range authConfigs {
+		// configs that do not implement auth.Prioritizable belong to the default priority group (0)
+		priority := 0
 		if prioritizableConfig, ok := conf.(auth.Prioritizable); ok {
-			priority := prioritizableConfig.GetPriority()
-			if _, exists := authConfigsByPriority[priority]; !exists {
-				priorities = append(priorities, priority)
-			}
-			authConfigsByPriority[priority] = append(authConfigsByPriority[priority], conf)
+			priority = prioritizableConfig.GetPriority()
 		}
+		if _, exists := authConfigsByPriority[priority]; !exists {
+			priorities = append(priorities, priority)
+		}
+		authConfigsByPriority[priority] = append(authConfigsByPriority[priority], conf)
 	}
 
 	sort.Ints(priorities)
@@ -199,22 +231,62 @@ func groupAuthConfigsByPriority(authConfigs []auth.AuthConfigEvaluator) (map[int
 	return authConfigsByPriority, priorities
 }
 
+// groupAuthConfigsByCost splits a group of configs by cost, in ascending order of cost, preserving the order of
+// declaration within each group. Configs that do not implement auth.CostlyEvaluator belong to the cheapest group (0).
+func groupAuthConfigsByCost(authConfigs []auth.AuthConfigEvaluator) [][]auth.AuthConfigEvaluator {
+	costs := []int{}
+	authConfigsByCost := make(map[int][]auth.AuthConfigEvaluator)
+
+	for _, conf := range authConfigs {
+		cost := 0
+		if costlyConfig, ok := conf.(auth.CostlyEvaluator); ok {
+			cost = costlyConfig.GetCost()
+		}
+		if _, exists := authConfigsByCost[cost]; !exists {
+			costs = append(costs, cost)
+		}
+		authConfigsByCost[cost] = append(authConfigsByCost[cost], conf)
+	}
+
+	sort.Ints(costs)
+
+	groups := make([][]auth.AuthConfigEvaluator, 0, len(costs))
+	for _, cost := range costs {
+		groups = append(groups, authConfigsByCost[cost])
+	}
+	return groups
+}
+
+// evaluateIdentityConfigs evaluates the identity configs by priority group, and then by cost within each priority group,
+// so configs verified locally are attempted before the ones that require requests to external services.
+// Within a group, the configs are evaluated concurrently, but the resolved identity is the one of the first successful
+// config in order of declaration. Once it is known, the evaluation of the other configs of the group is cancelled.
 func (pipeline *AuthPipeline) evaluateIdentityConfigs() EvaluationResponse {
 	logger := pipeline.Logger.WithName("identity").V(1)
 	authConfigsByPriority, priorities := groupAuthConfigsByPriority(pipeline.AuthConfig.IdentityConfigs)
 	count := len(pipeline.AuthConfig.IdentityConfigs)
 	errors := make(map[string]string)
 
+	var groups [][]auth.AuthConfigEvaluator
 	for _, priority := range priorities {
-		configs := authConfigsByPriority[priority]
+		groups = append(groups, groupAuthConfigsByCost(authConfigsByPriority[priority])...)
+	}
+
+	for _, configs := range groups {
 		respChannel := make(chan EvaluationResponse, len(configs))
+		ctx, cancel := gocontext.WithCancel(pipeline.Context)
 
-		go func() {
-			defer close(respChannel)
-			pipeline.evaluateOneAuthConfig(configs, &respChannel)
-		}()
+		pipeline.evaluateAnyAuthConfigWithContext(ctx, configs, &respChannel)
 
-		for resp := range respChannel {
+		// responses by position of the config in the group, so they can be processed in order of declaration
+		positions := make(map[auth.AuthConfigEvaluator]int, len(configs))
+		for i, conf := range configs {
+			positions[conf] = i
+		}
+		responses := make([]*EvaluationResponse, len(configs))
+		next := 0
+
+		processResponse := func(resp EvaluationResponse) (EvaluationResponse, bool) {
 			conf, _ := resp.Evaluator.(*evaluators.IdentityConfig)
 			obj := resp.Object
 
@@ -225,28 +297,68 @@ func (pipeline *AuthPipeline) evaluateIdentityConfigs() EvaluationResponse {
 				pipeline.setIdentityObj(conf, obj)
 
 				if extendedObj, err := conf.ResolveExtendedProperties(pipeline); err != nil {
+					// unsets the object so it cannot be taken as the resolved identity when extending the next successful one
+					pipeline.setIdentityObj(conf, nil)
 					resp.Error = err
 					logger.Error(err, "failed to extend identity object", "config", conf, "object", obj)
 					if count == 1 {
-						return resp
-					} else {
-						errors[conf.Name] = err.Error()
+						return resp, true
 					}
+					errors[conf.Name] = err.Error()
 				} else {
 					pipeline.setIdentityObj(conf, extendedObj)
 
 					logger.Info("identity validated", "config", conf, "object", extendedObj)
-					return resp
+					return resp, true
 				}
 			} else {
 				err := resp.Error
 				logger.Info("cannot validate identity", "config", conf, "reason", err)
 				if count == 1 {
-					return resp
-				} else {
-					errors[conf.Name] = err.Error()
+					return resp, true
 				}
+				errors[conf.Name] = err.Error()
 			}
+			return resp, false
+		}
+
+		// processes the responses available in order of declaration, stopping at the first config still being evaluated
+		// unless all evaluations are finished (configs with no response were skipped)
+		processResponses := func(finished bool) (EvaluationResponse, bool) {
+			for ; next < len(responses); next++ {
+				resp := responses[next]
+				if resp == nil {
+					if finished {
+						continue
+					}
+					break
+				}
+				if result, done := processResponse(*resp); done {
+					return result, true
+				}
+			}
+			return EvaluationResponse{}, false
+		}
+
+		for resp := range respChannel {
+			r := resp
+			responses[positions[resp.Evaluator]] = &r
+			if result, done := processResponses(false); done {
+				cancel()
+				return result
+			}
+		}
+		result, done := processResponses(true)
+		cancel()
+		if done {
+			return result
+		}
+	}
+
+	// all identity configs skipped due to unmatching conditions
+	if len(errors) == 0 {
+		return EvaluationResponse{
+			Error: fmt.Errorf(noApplicableIdentityMsg),
 		}
 	}
 
@@ -256,18 +368,18 @@ func (pipeline *AuthPipeline) evaluateIdentityConfigs() EvaluationResponse {
 	}
 }
 
-func (pipeline *AuthPipeline) evaluateMetadataConfigs() {
+// evaluateMetadataConfigs fetches the metadata by priority group, on a best-effort basis, except for the required
+// metadata configs, whose failure stops the evaluation and is returned
+func (pipeline *AuthPipeline) evaluateMetadataConfigs() EvaluationResponse {
 	logger := pipeline.Logger.WithName("metadata").V(1)
 	authConfigsByPriority, priorities := groupAuthConfigsByPriority(pipeline.AuthConfig.MetadataConfigs)
 
 	for _, priority := range priorities {
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
+		ctx, cancel := gocontext.WithCancel(pipeline.Context)
 
-		go func() {
-			defer close(respChannel)
-			pipeline.evaluateAnyAuthConfig(configs, &respChannel)
-		}()
+		pipeline.evaluateAnyAuthConfigWithContext(ctx, configs, &respChannel)
 
 		for resp := range respChannel {
 			conf, _ := resp.Evaluator.(*evaluators.MetadataConfig)
@@ -276,11 +388,21 @@ func (pipeline *AuthPipeline) evaluateMetadataConfigs() {
 			if resp.Success() {
 				pipeline.setMetadataObj(conf, obj)
 				logger.Info("fetched auth metadata", "config", conf, "object", obj)
+			} else if conf != nil && conf.Required {
+				logger.Info("cannot fetch required metadata", "config", conf, "reason", resp.Error)
+				cancel()
+				return EvaluationResponse{
+					Evaluator: conf,
+					Error:     fmt.Errorf("failed to fetch required metadata %s: %w", conf.Name, resp.Error),
+				}
 			} else {
 				logger.Info("cannot fetch metadata", "config", conf, "reason", resp.Error)
 			}
 		}
+		cancel()
 	}
+
+	return EvaluationResponse{}
 }
 
 func (pipeline *AuthPipeline) evaluateAuthorizationConfigs() EvaluationResponse {
@@ -298,10 +420,7 @@ func (pipeline *AuthPipeline) evaluateAuthorizationConfigs() EvaluationResponse
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
 
-		go func() {
-			defer close(respChannel)
-			pipeline.evaluateAllAuthConfigs(configs, &respChannel)
-		}()
+		pipeline.evaluateAllAuthConfigs(configs, &respChannel)
 
 		for resp := range respChannel {
 			conf, _ := resp.Evaluator.(*evaluators.AuthorizationConfig)
@@ -328,10 +447,7 @@ func (pipeline *AuthPipeline) evaluateResponseConfigs() {
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
 
-		go func() {
-			defer close(respChannel)
-			pipeline.evaluateAllAuthConfigs(configs, &respChannel)
-		}()
+		pipeline.evaluateAllAuthConfigs(configs, &respChannel)
 
 		for resp := range respChannel {
 			conf, _ := resp.Evaluator.(*evaluators.ResponseConfig)
@@ -355,10 +471,7 @@ func (pipeline *AuthPipeline) executeCallbacks() {
 		configs := authConfigsByPriority[priority]
 		respChannel := make(chan EvaluationResponse, len(configs))
 
-		go func() {
-			defer close(respChannel)
-			pipeline.evaluateAnyAuthConfig(configs, &respChannel)
-		}()
+		pipeline.evaluateAnyAuthConfig(configs, &respChannel)
 
 		for resp := range respChannel {
 			conf, _ := resp.Evaluator.(*evaluators.CallbackConfig)
@@ -448,10 +561,32 @@ func (pipeline *AuthPipeline) setCallbackObj(conf *evaluators.CallbackConfig, ob
 
 // Evaluate evaluates all steps of the auth pipeline (identity → metadata → policy enforcement)
 func (pipeline *AuthPipeline) Evaluate() auth.AuthResult {
+	result := pipeline.evaluate()
+
+	if pipeline.AuthConfig.DryRun && !result.Success() {
+		pipeline.Logger.Info("dry run: request would have been denied", "code", result.Code, "status", result.Status, "message", result.Message)
+		return auth.AuthResult{Code: rpc.OK}
+	}
+
+	return result
+}
+
+func (pipeline *AuthPipeline) evaluate() auth.AuthResult {
 	result := auth.AuthResult{Code: rpc.OK}
 
 	if err := pipeline.evaluateConditions(pipeline.AuthConfig.Conditions); err != nil {
-		pipeline.Logger.V(1).Info("skipping", "reason", err)
+		if pipeline.AuthConfig.Unmatched == nil {
+			pipeline.Logger.V(1).Info("skipping", "reason", err)
+			return result
+		}
+
+		// fail fast, without evaluating any phase of the pipeline
+		pipeline.Logger.V(1).Info("denying", "reason", err)
+		result.Code = rpc.PERMISSION_DENIED
+		result.Message = err.Error()
+		result = pipeline.customizeDenyWith(result, pipeline.AuthConfig.Unmatched)
+		metrics.ReportMetric(authServerAuthConfigTotalMetric, pipeline.metricLabels()...)
+		pipeline.reportStatusMetric(result.Code)
 		return result
 	}
 
@@ -467,22 +602,28 @@ func (pipeline *AuthPipeline) Evaluate() auth.AuthResult {
 			if resp := pipeline.evaluateIdentityConfigs(); !resp.Success() {
 				result.Code = rpc.UNAUTHENTICATED
 				result.Message = resp.GetErrorMessage()
-				result.Headers = pipeline.AuthConfig.GetChallengeHeaders()
+				result.Headers = pipeline.AuthConfig.GetChallengeHeaders(pipeline.GetHttp())
 				result = pipeline.customizeDenyWith(result, pipeline.AuthConfig.Unauthenticated)
 			} else {
 				// phase 2: external metadata
-				pipeline.evaluateMetadataConfigs()
+				resp := pipeline.evaluateMetadataConfigs()
 
-				// phase 3: policy enforcement (authorization)
-				if resp := pipeline.evaluateAuthorizationConfigs(); !resp.Success() {
+				// phase 3: policy enforcement (authorization), unless a required metadata failed to be fetched
+				if resp.Success() {
+					resp = pipeline.evaluateAuthorizationConfigs()
+				}
+
+				if !resp.Success() {
 					result.Code = rpc.PERMISSION_DENIED
 					result.Message = resp.GetErrorMessage()
 					result = pipeline.customizeDenyWith(result, pipeline.AuthConfig.Unauthorized)
 				} else {
 					// phase 4: response
 					pipeline.evaluateResponseConfigs()
-					responseHeaders, responseMetadata := evaluators.WrapResponses(pipeline.Response)
+					responseHeaders, clientResponseHeaders, responseMetadata := evaluators.WrapResponses(pipeline.Response)
 					result.Headers = []map[string]string{responseHeaders}
+					result.ResponseHeaders = clientResponseHeaders
+					result.HeadersToRemove = pipeline.AuthConfig.HeadersToRemove
 					result.Metadata = responseMetadata
 				}
 			}
@@ -521,64 +662,245 @@ func (pipeline *AuthPipeline) GetAPI() interface{} {
 	return pipeline.AuthConfig
 }
 
-func (pipeline *AuthPipeline) GetResolvedIdentity() (interface{}, interface{}) {
+func (pipeline *AuthPipeline) GetRequestAttributes() auth.RequestAttributes {
+	return auth.RequestAttributes{AttributeContext: pipeline.Request.Attributes}
+}
+
+func (pipeline *AuthPipeline) GetMetadata() auth.MetadataSet {
+	metadata := auth.MetadataSet{}
+	for config, obj := range pipeline.getMetadataObjs() {
+		metadata[config.Name] = obj
+	}
+	return metadata
+}
+
+func (pipeline *AuthPipeline) GetMetadataByName(name string) (interface{}, bool) {
+	return pipeline.GetMetadata().Get(name)
+}
+
+func (pipeline *AuthPipeline) GetIdentity() auth.ResolvedIdentity {
 	for identityConfig, identityObj := range pipeline.getIdentityObjs() {
 		if identityObj != nil {
-			id := identityConfig
-			obj := identityObj
-			return id, obj
+			return auth.ResolvedIdentity{Source: identityConfig, Object: identityObj}
 		}
 	}
+	return auth.ResolvedIdentity{}
+}
+
+func (pipeline *AuthPipeline) GetResolvedIdentity() (interface{}, interface{}) {
+	if identity := pipeline.GetIdentity(); identity.Resolved() {
+		return identity.Source, identity.Object
+	}
 	return nil, nil
 }
 
-type authorizationJSON struct {
-	Context  *envoy_auth.AttributeContext `json:"context"`
-	AuthData map[string]interface{}       `json:"auth"`
+// buildRequestContext returns the attributes of the request extended with the body parsed into an object, if the parsing of the request body is enabled and the body is parseable,
+// with the parameters of the query string and the cookies, with the service and method of gRPC requests, and with the addresses and certificates of the peers of the connection.
+// Otherwise, it returns nil, meaning the attributes of the request go as is in the authorization JSON.
+func buildRequestContext(req *envoy_auth.CheckRequest, requestBody *evaluators.RequestBody) interface{} {
+	httpAttrs := req.GetAttributes().GetRequest().GetHttp()
+
+	var body interface{}
+	var parsedBody bool
+	if requestBody != nil {
+		body, parsedBody = parseRequestBody(httpAttrs, requestBody.MaxSize)
+	}
+	grpcRequest := parseGRPCRequest(httpAttrs)
+	queryParams := parseQueryParams(httpAttrs)
+	var cookies map[string]string
+	if cookieHeader := httpAttrs.GetHeaders()["cookie"]; cookieHeader != "" {
+		cookies = auth.ParseCookies(cookieHeader)
+	}
+	peers := map[string]map[string]interface{}{
+		"source":      buildPeerAttributes(req.GetAttributes().GetSource()),
+		"destination": buildPeerAttributes(req.GetAttributes().GetDestination()),
+	}
+
+	if !parsedBody && grpcRequest == nil && queryParams == nil && len(cookies) == 0 && len(peers["source"]) == 0 && len(peers["destination"]) == 0 {
+		return nil
+	}
+
+	var requestContext map[string]interface{}
+	if attrs, err := gojson.Marshal(req.GetAttributes()); err != nil {
+		return nil
+	} else if err := gojson.Unmarshal(attrs, &requestContext); err != nil {
+		return nil
+	}
+	request, _ := requestContext["request"].(map[string]interface{})
+	httpRequest, _ := request["http"].(map[string]interface{})
+	if httpRequest == nil {
+		return nil
+	}
+	if parsedBody {
+		httpRequest["body"] = body
+	}
+	if queryParams != nil {
+		httpRequest["queryParams"] = queryParams
+	}
+	if len(cookies) > 0 {
+		httpRequest["cookies"] = cookies
+	}
+	if grpcRequest != nil {
+		request["grpc"] = grpcRequest
+	}
+	for name, attrs := range peers {
+		if peer, _ := requestContext[name].(map[string]interface{}); peer != nil {
+			for key, value := range attrs {
+				peer[key] = value
+			}
+		}
+	}
+	return requestContext
+}
+
+// buildPeerAttributes returns the ip and port of a peer of the connection, and the subject and subject alternative
+// names (DNS names, URIs, email addresses and IP addresses) of the certificate of the peer, if any
+func buildPeerAttributes(peer *envoy_auth.AttributeContext_Peer) map[string]interface{} {
+	attrs := make(map[string]interface{})
+	if socketAddress := peer.GetAddress().GetSocketAddress(); socketAddress != nil {
+		attrs["ip"] = socketAddress.GetAddress()
+		attrs["port"] = socketAddress.GetPortValue()
+	}
+	if urlEncodedCert := peer.GetCertificate(); urlEncodedCert != "" {
+		if pemEncodedCert, err := url.QueryUnescape(urlEncodedCert); err == nil {
+			if cert := identity.DecodeCertificate([]byte(pemEncodedCert)); cert != nil {
+				sans := make([]string, 0)
+				sans = append(sans, cert.DNSNames...)
+				for _, uri := range cert.URIs {
+					sans = append(sans, uri.String())
+				}
+				sans = append(sans, cert.EmailAddresses...)
+				for _, ip := range cert.IPAddresses {
+					sans = append(sans, ip.String())
+				}
+				attrs["certificate_subject"] = cert.Subject.String()
+				attrs["certificate_sans"] = sans
+			}
+		}
+	}
+	return attrs
+}
+
+// parseGRPCRequest returns the service and method of gRPC (and gRPC-Web) requests, out of the path (/<package>.<service>/<method>),
+// and the subtype of the content (e.g. "proto", "json"), or nil if the request is not a gRPC request
+func parseGRPCRequest(httpRequest *envoy_auth.AttributeContext_HttpRequest) map[string]interface{} {
+	mediaType, _, _ := mime.ParseMediaType(httpRequest.GetHeaders()["content-type"])
+	contentType, contentSubtype := mediaType, "proto"
+	if i := strings.Index(mediaType, "+"); i >= 0 {
+		contentType, contentSubtype = mediaType[:i], mediaType[i+1:]
+	}
+	switch contentType {
+	case "application/grpc", "application/grpc-web", "application/grpc-web-text":
+	default:
+		return nil
+	}
+
+	path := strings.SplitN(httpRequest.GetPath(), "?", 2)[0]
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"service":         parts[0],
+		"method":          parts[1],
+		"content_subtype": contentSubtype,
+	}
+}
+
+// parseRequestBody parses JSON and form-urlencoded bodies not exceeding maxSize bytes
+func parseRequestBody(httpRequest *envoy_auth.AttributeContext_HttpRequest, maxSize int) (interface{}, bool) {
+	body := httpRequest.GetBody()
+	if body == "" {
+		body = string(httpRequest.GetRawBody())
+	}
+	if body == "" || len(body) > maxSize {
+		return nil, false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(httpRequest.GetHeaders()["content-type"])
+	switch mediaType {
+	case "application/json":
+		var parsed interface{}
+		if err := gojson.Unmarshal([]byte(body), &parsed); err != nil {
+			return nil, false
+		}
+		return parsed, true
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(body)
+		if err != nil {
+			return nil, false
+		}
+		return flattenValues(values), true
+	default:
+		return nil, false
+	}
+}
+
+// parseQueryParams parses the query string of the request, or returns nil if the request has no query string.
+// Invalid pairs are skipped.
+func parseQueryParams(httpRequest *envoy_auth.AttributeContext_HttpRequest) map[string]interface{} {
+	query := httpRequest.GetQuery()
+	if query == "" {
+		if parts := strings.SplitN(httpRequest.GetPath(), "?", 2); len(parts) == 2 {
+			query = parts[1]
+		}
+	}
+	values, _ := url.ParseQuery(query)
+	if len(values) == 0 {
+		return nil
+	}
+	return flattenValues(values)
+}
+
+// flattenValues maps keys with a single value to the value and keys repeated to the list of values
+func flattenValues(values url.Values) map[string]interface{} {
+	flattened := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if len(value) == 1 {
+			flattened[key] = value[0]
+		} else {
+			flattened[key] = value
+		}
+	}
+	return flattened
 }
 
 func (pipeline *AuthPipeline) GetAuthorizationJSON() string {
-	authData := make(map[string]interface{})
+	var requestContext interface{} = pipeline.GetRequest().Attributes
+	if pipeline.requestContext != nil {
+		requestContext = pipeline.requestContext
+	}
+
+	authJSON := auth.NewAuthorizationJSON(requestContext)
 
 	// identity
-	_, authData["identity"] = pipeline.GetResolvedIdentity()
+	_, authJSON.Auth.Identity = pipeline.GetResolvedIdentity()
 
 	// metadata
-	metadata := make(map[string]interface{})
 	for config, obj := range pipeline.getMetadataObjs() {
-		metadata[config.Name] = obj
+		authJSON.Auth.Metadata[config.Name] = obj
 	}
-	authData["metadata"] = metadata
 
 	// authorization
-	authorization := make(map[string]interface{})
 	for config, obj := range pipeline.getAuthorizationObjs() {
-		authorization[config.Name] = obj
+		authJSON.Auth.Authorization[config.Name] = obj
 	}
-	authData["authorization"] = authorization
 
 	// response
-	response := make(map[string]interface{})
 	for config, obj := range pipeline.getResponseObjs() {
-		response[config.Name] = obj
+		authJSON.Auth.Response[config.Name] = obj
 	}
-	authData["response"] = response
 
 	// callbacks
-	callbacks := make(map[string]interface{})
-	for config, obj := range pipeline.getCallbackObjs() {
-		callbacks[config.Name] = obj
-	}
-	if len(callbacks) > 0 {
-		authData["callbacks"] = callbacks
+	if callbackObjs := pipeline.getCallbackObjs(); len(callbackObjs) > 0 {
+		authJSON.Auth.Callbacks = make(map[string]interface{}, len(callbackObjs))
+		for config, obj := range callbackObjs {
+			authJSON.Auth.Callbacks[config.Name] = obj
+		}
 	}
 
-	authJSON, _ := gojson.Marshal(&authorizationJSON{
-		Context:  pipeline.GetRequest().Attributes,
-		AuthData: authData,
-	})
-
-	return string(authJSON)
+	return authJSON.String()
 }
 
 func (pipeline *AuthPipeline) customizeDenyWith(authResult auth.AuthResult, denyWith *evaluators.DenyWithValues) auth.AuthResult {
@@ -593,7 +915,13 @@ func (pipeline *AuthPipeline) customizeDenyWith(authResult auth.AuthResult, deny
 			authResult.Message, _ = json.StringifyJSON(denyWith.Message.ResolveFor(authJSON))
 		}
 
-		if denyWith.Body != nil {
+		if denyWith.BodyTemplate != nil {
+			if body, err := denyWith.BodyTemplate.Render(authJSON); err != nil {
+				pipeline.Logger.Error(err, "failed to customize the denial")
+			} else {
+				authResult.Body = body
+			}
+		} else if denyWith.Body != nil {
 			authResult.Body, _ = json.StringifyJSON(denyWith.Body.ResolveFor(authJSON))
 		}
 
@@ -605,6 +933,19 @@ func (pipeline *AuthPipeline) customizeDenyWith(authResult auth.AuthResult, deny
 			}
 			authResult.Headers = headers
 		}
+
+		if denyWith.ContentType != "" {
+			authResult.Headers = append(authResult.Headers, map[string]string{"Content-Type": denyWith.ContentType})
+		}
+
+		if redirect := denyWith.Redirect; redirect != nil {
+			if location, err := redirect.Location.Render(authJSON); err != nil {
+				pipeline.Logger.Error(err, "failed to redirect the denied request")
+			} else {
+				authResult.Status = envoy_type.StatusCode(redirect.Code)
+				authResult.Headers = append(authResult.Headers, map[string]string{"Location": location})
+			}
+		}
 	}
 
 	return authResult
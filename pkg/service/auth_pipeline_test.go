@@ -2,22 +2,36 @@ package service
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	gojson "encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kuadrant/authorino/pkg/auth"
 	mock_auth "github.com/kuadrant/authorino/pkg/auth/mocks"
 	"github.com/kuadrant/authorino/pkg/evaluators"
 	"github.com/kuadrant/authorino/pkg/evaluators/authorization"
 	"github.com/kuadrant/authorino/pkg/evaluators/identity"
+	"github.com/kuadrant/authorino/pkg/evaluators/metadata"
 	"github.com/kuadrant/authorino/pkg/httptest"
 	"github.com/kuadrant/authorino/pkg/json"
 
+	envoy_core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
 	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/gogo/googleapis/google/rpc"
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"gotest.tools/assert"
 )
 
@@ -92,10 +106,7 @@ func TestEvaluateOneAuthConfig(t *testing.T) {
 	respChannel := make(chan EvaluationResponse, 2)
 	swap := false
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateOneAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateOneAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -115,10 +126,7 @@ func TestEvaluateOneAuthConfigWithoutSuccess(t *testing.T) {
 	swap := false
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateOneAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateOneAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -141,10 +149,7 @@ func TestEvaluateOneAuthConfigWithoutError(t *testing.T) {
 	swap := false
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateOneAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateOneAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -167,10 +172,7 @@ func TestEvaluateAllAuthConfigs(t *testing.T) {
 	swap := false
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateAllAuthConfigs(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateAllAuthConfigs(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -192,10 +194,7 @@ func TestEvaluateAllAuthConfigsWithError(t *testing.T) {
 	respChannel := make(chan EvaluationResponse, 2)
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateAllAuthConfigs(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateAllAuthConfigs(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if !resp.Success() {
@@ -215,10 +214,7 @@ func TestEvaluateAllAuthConfigsWithoutSuccess(t *testing.T) {
 	swap := false
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateAllAuthConfigs(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateAllAuthConfigs(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -241,10 +237,7 @@ func TestEvaluateAnyAuthConfig(t *testing.T) {
 	swap := false
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateAnyAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateAnyAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -267,10 +260,7 @@ func TestEvaluateAnyAuthConfigsWithoutSuccess(t *testing.T) {
 	swap := false
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateAnyAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateAnyAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -293,10 +283,7 @@ func TestEvaluateAnyAuthConfigsWithoutError(t *testing.T) {
 	swap := false
 	var err error
 
-	go func() {
-		defer close(respChannel)
-		pipeline.evaluateAnyAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
-	}()
+	pipeline.evaluateAnyAuthConfig(pipeline.AuthConfig.IdentityConfigs, &respChannel)
 
 	for resp := range respChannel {
 		if resp.Success() {
@@ -320,6 +307,241 @@ func TestAuthPipelineGetAuthorizationJSON(t *testing.T) {
 	assert.Equal(t, pipeline.GetAuthorizationJSON(), expectedJSON)
 }
 
+func TestAuthPipelineGetAuthorizationJSONWithResolvedObjects(t *testing.T) {
+	idConfig := &evaluators.IdentityConfig{Name: "id"}
+	metadataConfig := &evaluators.MetadataConfig{Name: "meta"}
+	authzConfig := &evaluators.AuthorizationConfig{Name: "authz"}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{}, &requestMock)
+	pipeline.setIdentityObj(idConfig, map[string]interface{}{"sub": "john"})
+	pipeline.setMetadataObj(metadataConfig, map[string]interface{}{"tier": "gold"})
+	pipeline.setAuthorizationObj(authzConfig, true)
+
+	authJSON := pipeline.GetAuthorizationJSON()
+	assert.Equal(t, (&json.JSONValue{Pattern: "context.request.http.path"}).ResolveFor(authJSON), "/operation")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.identity.sub"}).ResolveFor(authJSON), "john")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.metadata.meta.tier"}).ResolveFor(authJSON), "gold")
+	assert.Equal(t, (&json.JSONValue{Pattern: "auth.authorization.authz"}).ResolveFor(authJSON), true)
+}
+
+func TestAuthPipelineGetMetadataByName(t *testing.T) {
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{}, &requestMock)
+	pipeline.setMetadataObj(&evaluators.MetadataConfig{Name: "user-info"}, map[string]interface{}{"tier": "gold"})
+	pipeline.setMetadataObj(&evaluators.MetadataConfig{Name: "resource"}, map[string]interface{}{"owner": "john"})
+
+	obj, found := pipeline.GetMetadataByName("user-info")
+	assert.Check(t, found)
+	assert.DeepEqual(t, obj, map[string]interface{}{"tier": "gold"})
+
+	obj, found = pipeline.GetMetadataByName("resource")
+	assert.Check(t, found)
+	assert.DeepEqual(t, obj, map[string]interface{}{"owner": "john"})
+
+	_, found = pipeline.GetMetadataByName("missing")
+	assert.Check(t, !found)
+}
+
+func TestAuthPipelineTypedAccessors(t *testing.T) {
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{}, &requestMock)
+	assert.Check(t, !pipeline.GetIdentity().Resolved())
+
+	identityConfig := &evaluators.IdentityConfig{Name: "jwt"}
+	pipeline.setIdentityObj(identityConfig, map[string]interface{}{"sub": "john"})
+	pipeline.setMetadataObj(&evaluators.MetadataConfig{Name: "user-info"}, map[string]interface{}{"tier": "gold"})
+
+	identity := pipeline.GetIdentity()
+	assert.Check(t, identity.Resolved())
+	assert.Equal(t, identity.Source, identityConfig)
+	assert.Equal(t, identity.StringClaim("sub"), "john")
+
+	assert.DeepEqual(t, pipeline.GetMetadata().Names(), []string{"user-info"})
+	assert.Equal(t, pipeline.GetRequestAttributes().HTTP(), requestMock.Attributes.Request.Http)
+}
+
+func TestAuthPipelineGetAuthorizationJSONWithParsedRequestBody(t *testing.T) {
+	newRequest := func(contentType, body string) *envoy_auth.CheckRequest {
+		return &envoy_auth.CheckRequest{
+			Attributes: &envoy_auth.AttributeContext{
+				Request: &envoy_auth.AttributeContext_Request{
+					Http: &envoy_auth.AttributeContext_HttpRequest{
+						Path:    "/resources",
+						Headers: map[string]string{"content-type": contentType},
+						Body:    body,
+					},
+				},
+			},
+		}
+	}
+	requestBody := &evaluators.RequestBody{MaxSize: 64}
+	resolve := func(authJSON, pattern string) interface{} {
+		return (&json.JSONValue{Pattern: pattern}).ResolveFor(authJSON)
+	}
+
+	// json
+	authJSON := newTestAuthPipeline(evaluators.AuthConfig{RequestBody: requestBody}, newRequest("application/json; charset=utf-8", `{"resource_id":"123"}`)).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.body.resource_id"), "123")
+	assert.Equal(t, resolve(authJSON, "context.request.http.path"), "/resources")
+
+	// form
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{RequestBody: requestBody}, newRequest("application/x-www-form-urlencoded", "resource_id=123&tag=a&tag=b")).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.body.resource_id"), "123")
+	assert.Equal(t, resolve(authJSON, "context.request.http.body.tag.1"), "b")
+
+	// unsupported content type
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{RequestBody: requestBody}, newRequest("text/plain", "resource_id=123")).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.body"), "resource_id=123")
+
+	// too large
+	largeBody := `{"resource_id":"` + strings.Repeat("x", 64) + `"}`
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{RequestBody: requestBody}, newRequest("application/json", largeBody)).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.body"), largeBody)
+
+	// disabled
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("application/json", `{"resource_id":"123"}`)).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.body"), `{"resource_id":"123"}`)
+}
+
+func TestAuthPipelineGetAuthorizationJSONWithQueryParams(t *testing.T) {
+	newRequest := func(path string) *envoy_auth.CheckRequest {
+		return &envoy_auth.CheckRequest{
+			Attributes: &envoy_auth.AttributeContext{
+				Request: &envoy_auth.AttributeContext_Request{
+					Http: &envoy_auth.AttributeContext_HttpRequest{
+						Method: "GET",
+						Path:   path,
+					},
+				},
+			},
+		}
+	}
+	resolve := func(authJSON, pattern string) interface{} {
+		return (&json.JSONValue{Pattern: pattern}).ResolveFor(authJSON)
+	}
+
+	authJSON := newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("/resources?id=123&tag=a&tag=b&q=hello%20world&empty=")).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.queryParams.id"), "123")
+	assert.Equal(t, resolve(authJSON, "context.request.http.queryParams.tag.#"), float64(2))
+	assert.Equal(t, resolve(authJSON, "context.request.http.queryParams.tag.1"), "b")
+	assert.Equal(t, resolve(authJSON, "context.request.http.queryParams.q"), "hello world")
+	assert.Equal(t, resolve(authJSON, "context.request.http.queryParams.empty"), "")
+	assert.Equal(t, resolve(authJSON, "context.request.http.path"), "/resources?id=123&tag=a&tag=b&q=hello%20world&empty=")
+
+	// invalid pairs are skipped
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("/resources?id=123&bad=%zz")).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.queryParams.id"), "123")
+	assert.Check(t, resolve(authJSON, "context.request.http.queryParams.bad") == nil)
+
+	// no query string
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("/resources?")).GetAuthorizationJSON()
+	assert.Check(t, resolve(authJSON, "context.request.http.queryParams") == nil)
+}
+
+func TestAuthPipelineGetAuthorizationJSONWithCookies(t *testing.T) {
+	newRequest := func(headers map[string]string) *envoy_auth.CheckRequest {
+		return &envoy_auth.CheckRequest{
+			Attributes: &envoy_auth.AttributeContext{
+				Request: &envoy_auth.AttributeContext_Request{
+					Http: &envoy_auth.AttributeContext_HttpRequest{
+						Method:  "GET",
+						Path:    "/resources",
+						Headers: headers,
+					},
+				},
+			},
+		}
+	}
+	resolve := func(authJSON, pattern string) interface{} {
+		return (&json.JSONValue{Pattern: pattern}).ResolveFor(authJSON)
+	}
+
+	authJSON := newTestAuthPipeline(evaluators.AuthConfig{}, newRequest(map[string]string{"cookie": `session=abc123; theme="dark"`})).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.http.cookies.session"), "abc123")
+	assert.Equal(t, resolve(authJSON, "context.request.http.cookies.theme"), "dark")
+	assert.Equal(t, resolve(authJSON, "context.request.http.headers.cookie"), `session=abc123; theme="dark"`)
+
+	// no cookies
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{}, newRequest(map[string]string{"cookie": "invalid"})).GetAuthorizationJSON()
+	assert.Check(t, resolve(authJSON, "context.request.http.cookies") == nil)
+}
+
+func TestAuthPipelineGetAuthorizationJSONWithGRPCRequest(t *testing.T) {
+	newRequest := func(contentType, path string) *envoy_auth.CheckRequest {
+		return &envoy_auth.CheckRequest{
+			Attributes: &envoy_auth.AttributeContext{
+				Request: &envoy_auth.AttributeContext_Request{
+					Http: &envoy_auth.AttributeContext_HttpRequest{
+						Method:  "POST",
+						Path:    path,
+						Headers: map[string]string{"content-type": contentType},
+					},
+				},
+			},
+		}
+	}
+	resolve := func(authJSON, pattern string) interface{} {
+		return (&json.JSONValue{Pattern: pattern}).ResolveFor(authJSON)
+	}
+
+	authJSON := newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("application/grpc", "/helloworld.Greeter/SayHello")).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.grpc.service"), "helloworld.Greeter")
+	assert.Equal(t, resolve(authJSON, "context.request.grpc.method"), "SayHello")
+	assert.Equal(t, resolve(authJSON, "context.request.grpc.content_subtype"), "proto")
+	assert.Equal(t, resolve(authJSON, "context.request.http.path"), "/helloworld.Greeter/SayHello")
+
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("application/grpc-web+json", "/helloworld.Greeter/SayHello")).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.request.grpc.method"), "SayHello")
+	assert.Equal(t, resolve(authJSON, "context.request.grpc.content_subtype"), "json")
+
+	// not a grpc request
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("application/json", "/helloworld.Greeter/SayHello")).GetAuthorizationJSON()
+	assert.Check(t, resolve(authJSON, "context.request.grpc") == nil)
+
+	// invalid path
+	authJSON = newTestAuthPipeline(evaluators.AuthConfig{}, newRequest("application/grpc", "/helloworld.Greeter")).GetAuthorizationJSON()
+	assert.Check(t, resolve(authJSON, "context.request.grpc") == nil)
+}
+
+func TestAuthPipelineGetAuthorizationJSONWithPeers(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	spiffeID, _ := url.Parse("spiffe://cluster.local/ns/default/sa/client")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client", Organization: []string{"ACME"}},
+		DNSNames:     []string{"client.acme.io"},
+		URIs:         []*url.URL{spiffeID},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	socketAddress := func(address string, port uint32) *envoy_core.Address {
+		return &envoy_core.Address{Address: &envoy_core.Address_SocketAddress{SocketAddress: &envoy_core.SocketAddress{Address: address, PortSpecifier: &envoy_core.SocketAddress_PortValue{PortValue: port}}}}
+	}
+	request := &envoy_auth.CheckRequest{
+		Attributes: &envoy_auth.AttributeContext{
+			Source:      &envoy_auth.AttributeContext_Peer{Address: socketAddress("10.0.0.1", 53204), Principal: spiffeID.String(), Certificate: url.QueryEscape(string(cert))},
+			Destination: &envoy_auth.AttributeContext_Peer{Address: socketAddress("10.0.0.2", 8000)},
+			Request:     &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Path: "/"}},
+		},
+	}
+	resolve := func(authJSON, pattern string) interface{} {
+		return (&json.JSONValue{Pattern: pattern}).ResolveFor(authJSON)
+	}
+
+	authJSON := newTestAuthPipeline(evaluators.AuthConfig{}, request).GetAuthorizationJSON()
+	assert.Equal(t, resolve(authJSON, "context.source.ip"), "10.0.0.1")
+	assert.Equal(t, resolve(authJSON, "context.source.port"), float64(53204))
+	assert.Equal(t, resolve(authJSON, "context.source.principal"), "spiffe://cluster.local/ns/default/sa/client")
+	assert.Equal(t, resolve(authJSON, "context.source.certificate_subject"), "CN=client,O=ACME")
+	assert.DeepEqual(t, resolve(authJSON, "context.source.certificate_sans"), []interface{}{"client.acme.io", "spiffe://cluster.local/ns/default/sa/client", "10.0.0.1"})
+	assert.Equal(t, resolve(authJSON, "context.destination.ip"), "10.0.0.2")
+	assert.Equal(t, resolve(authJSON, "context.destination.port"), float64(8000))
+	assert.Check(t, resolve(authJSON, "context.destination.certificate_sans") == nil)
+	assert.Equal(t, resolve(authJSON, "context.request.http.path"), "/")
+}
+
 func TestEvaluateWithCustomDenyOptions(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -329,6 +551,7 @@ func TestEvaluateWithCustomDenyOptions(t *testing.T) {
 	authCredMock := mock_auth.NewMockAuthCredentials(ctrl)
 	authCredMock.EXPECT().GetCredentialsFromReq(request.GetAttributes().GetRequest().Http).Return("xxx", nil)
 	authCredMock.EXPECT().GetCredentialsKeySelector().Return("APIKEY")
+	authCredMock.EXPECT().GetCredentialsIn().Return("authorization_header")
 	authConfigStaticResponse := "testing"
 
 	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
@@ -358,6 +581,74 @@ func TestEvaluateWithCustomDenyOptions(t *testing.T) {
 	assert.Equal(t, string(headers), `[{"X-Static-Header":"some-value"},{"Location":"https://my-app.io/login?redirect_to=https://my-api/operation"}]`)
 }
 
+func TestEvaluateWithTemplatedDenyBody(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	bodyTemplate, _ := evaluators.NewDenyWithTemplate(`{"error":"forbidden","host":{{json .context.request.http.host}}}`, "application/json")
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{&failConfig{}},
+		DenyWith: evaluators.DenyWith{
+			Unauthorized: &evaluators.DenyWithValues{
+				Body:         &json.JSONValue{Static: "ignored"},
+				BodyTemplate: bodyTemplate,
+				ContentType:  "application/json",
+			},
+		},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, authResult.Body, `{"error":"forbidden","host":"my-api"}`)
+	headers, _ := gojson.Marshal(authResult.Headers)
+	assert.Equal(t, string(headers), `[{"Content-Type":"application/json"}]`)
+}
+
+func TestEvaluateWithRedirectDeny(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	location, _ := evaluators.NewDenyWithTemplate(`https://sso.acme.com/login?redirect_uri={{requestURL . | urlquery}}`, "")
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{&evaluators.IdentityConfig{Noop: &identity.Noop{}}},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{&failConfig{}},
+		DenyWith: evaluators.DenyWith{
+			Unauthorized: &evaluators.DenyWithValues{
+				Code:     403,
+				Redirect: &evaluators.DenyWithRedirect{Code: 302, Location: location},
+			},
+		},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, authResult.Status, envoy_type_v3.StatusCode_Found)
+	headers, _ := gojson.Marshal(authResult.Headers)
+	assert.Equal(t, string(headers), `[{"Location":"https://sso.acme.com/login?redirect_uri=https%3A%2F%2Fmy-api%2Foperation"}]`)
+}
+
+func TestEvaluateDryRun(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	idConfig := &evaluators.IdentityConfig{Noop: &identity.Noop{}}
+	authzConfig := &failConfig{}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{idConfig},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{authzConfig},
+		DryRun:               true,
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Check(t, authzConfig.called)
+	assert.Check(t, authResult.Success())
+	assert.Equal(t, authResult.Message, "")
+}
+
 func TestEvaluatePriorities(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -383,6 +674,106 @@ func TestEvaluatePriorities(t *testing.T) {
 	assert.Check(t, !authzConfig2.called)
 }
 
+type unprioritizedConfig struct{}
+
+func (c *unprioritizedConfig) Call(_ auth.AuthPipeline, _ context.Context) (interface{}, error) {
+	return nil, nil
+}
+
+func TestGroupAuthConfigsByPriority(t *testing.T) {
+	conf1 := &successConfig{priority: 2}
+	conf2 := &failConfig{priority: 0}
+	conf3 := &unprioritizedConfig{}
+	conf4 := &successConfig{priority: -1}
+	conf5 := &failConfig{priority: 2}
+
+	authConfigsByPriority, priorities := groupAuthConfigsByPriority([]auth.AuthConfigEvaluator{conf1, conf2, conf3, conf4, conf5})
+
+	assert.DeepEqual(t, priorities, []int{-1, 0, 2})
+	assert.Equal(t, len(authConfigsByPriority[-1]), 1)
+	assert.Equal(t, len(authConfigsByPriority[0]), 2) // conf3 falls into the default priority group
+	assert.Equal(t, authConfigsByPriority[0][1], auth.AuthConfigEvaluator(conf3))
+	assert.Equal(t, len(authConfigsByPriority[2]), 2)
+	assert.Equal(t, authConfigsByPriority[2][0], auth.AuthConfigEvaluator(conf1))
+	assert.Equal(t, authConfigsByPriority[2][1], auth.AuthConfigEvaluator(conf5))
+}
+
+func TestEvaluateRequiredMetadata(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	idConfig := &evaluators.IdentityConfig{Noop: &identity.Noop{}}
+	metadataConfig := &evaluators.MetadataConfig{Name: "unreachable", GenericHTTP: &metadata.GenericHttp{Endpoint: "http://127.0.0.1:9999", Method: "GET"}}
+
+	// optional
+	authzConfig := &successConfig{}
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{idConfig},
+		MetadataConfigs:      []auth.AuthConfigEvaluator{metadataConfig},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{authzConfig},
+	}, &request)
+	authResult := pipeline.Evaluate()
+	assert.Check(t, authResult.Success())
+	assert.Check(t, authzConfig.called)
+
+	// required
+	metadataConfig.Required = true
+	authzConfig = &successConfig{}
+	pipeline = newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{idConfig},
+		MetadataConfigs:      []auth.AuthConfigEvaluator{metadataConfig},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{authzConfig},
+	}, &request)
+	authResult = pipeline.Evaluate()
+	assert.Equal(t, authResult.Code, rpc.PERMISSION_DENIED)
+	assert.Check(t, strings.HasPrefix(authResult.Message, "failed to fetch required metadata unreachable: "))
+	assert.Check(t, !authzConfig.called)
+}
+
+type costlyConfig struct {
+	failConfig
+	cost int
+}
+
+func (c *costlyConfig) GetCost() int {
+	return c.cost
+}
+
+func TestEvaluateIdentityCheapFirst(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	idConfig1 := &costlyConfig{cost: evaluators.IdentityCostRemote} // should never be called; otherwise, it would throw an error as it's not a config.IdentityConfig
+	idConfig2 := &evaluators.IdentityConfig{Name: "local", Noop: &identity.Noop{}}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs: []auth.AuthConfigEvaluator{idConfig1, idConfig2},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+	assert.Check(t, authResult.Success())
+	assert.Check(t, !idConfig1.called)
+	conf, _ := pipeline.GetResolvedIdentity()
+	assert.Equal(t, conf, idConfig2)
+}
+
+func TestGroupAuthConfigsByCost(t *testing.T) {
+	conf1 := &costlyConfig{cost: 1}
+	conf2 := &successConfig{}
+	conf3 := &costlyConfig{cost: 0}
+	conf4 := &costlyConfig{cost: 1}
+
+	groups := groupAuthConfigsByCost([]auth.AuthConfigEvaluator{conf1, conf2, conf3, conf4})
+
+	assert.Equal(t, len(groups), 2)
+	assert.Equal(t, len(groups[0]), 2)
+	assert.Equal(t, groups[0][0], auth.AuthConfigEvaluator(conf2)) // conf2 falls into the cheapest group
+	assert.Equal(t, groups[0][1], auth.AuthConfigEvaluator(conf3))
+	assert.Equal(t, len(groups[1]), 2)
+	assert.Equal(t, groups[1][0], auth.AuthConfigEvaluator(conf1))
+	assert.Equal(t, groups[1][1], auth.AuthConfigEvaluator(conf4))
+}
+
 func TestAuthPipelineWithUnmatchingConditionsInTheAuthConfig(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -408,6 +799,34 @@ func TestAuthPipelineWithUnmatchingConditionsInTheAuthConfig(t *testing.T) {
 	assert.Check(t, !idConfig.called)
 }
 
+func TestAuthPipelineWithUnmatchingConditionsInTheAuthConfigDenied(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	idConfig := &successConfig{}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		Conditions: []json.JSONPatternMatchingRule{
+			{
+				Selector: "context.request.http.path",
+				Operator: "neq",
+				Value:    "/operation",
+			},
+		},
+		IdentityConfigs: []auth.AuthConfigEvaluator{idConfig},
+		DenyWith: evaluators.DenyWith{
+			Unmatched: &evaluators.DenyWithValues{Code: 404, Message: &json.JSONValue{Static: "Not found"}},
+		},
+	}, &request)
+
+	result := pipeline.Evaluate()
+
+	assert.Check(t, !idConfig.called)
+	assert.Equal(t, result.Code, rpc.PERMISSION_DENIED)
+	assert.Equal(t, result.Status, envoy_type_v3.StatusCode_NotFound)
+	assert.Equal(t, result.Message, "Not found")
+}
+
 func TestAuthPipelineWithMatchingConditionsInTheAuthConfig(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -463,6 +882,25 @@ func TestAuthPipelineWithUnmatchingConditionsInTheEvaluator(t *testing.T) {
 	assert.Check(t, !authzConfig.called)
 }
 
+func TestAuthPipelineWithEvaluatorMetrics(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	monitored := &evaluators.IdentityConfig{Name: "monitored", Noop: &identity.Noop{}, Metrics: true}
+	unmonitored := &evaluators.IdentityConfig{Name: "unmonitored", Noop: &identity.Noop{}}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		Labels:          map[string]string{"namespace": "authorino", "name": "evaluator-metrics"},
+		IdentityConfigs: []auth.AuthConfigEvaluator{monitored, unmonitored},
+	}, &request)
+
+	_ = pipeline.Evaluate()
+
+	// only evaluators with metrics enabled are labelled by name, so the cardinality stays low by default
+	assert.Equal(t, testutil.ToFloat64(authServerEvaluatorTotalMetric.WithLabelValues("authorino", "evaluator-metrics", "IDENTITY_NOOP", "monitored")), float64(1))
+	assert.Equal(t, testutil.ToFloat64(authServerEvaluatorTotalMetric.WithLabelValues("authorino", "evaluator-metrics", "IDENTITY_NOOP", "unmonitored")), float64(0))
+}
+
 func TestAuthPipelineWithMatchingConditionsInTheEvaluator(t *testing.T) {
 	request := envoy_auth.CheckRequest{}
 	_ = gojson.Unmarshal([]byte(rawRequest), &request)
@@ -491,6 +929,52 @@ func TestAuthPipelineWithMatchingConditionsInTheEvaluator(t *testing.T) {
 	assert.Check(t, authzConfig.called)
 }
 
+func TestAuthPipelineResolvesIdentitiesInOrderOfDeclaration(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+			IdentityConfigs: []auth.AuthConfigEvaluator{
+				&evaluators.IdentityConfig{Name: "missing", Plain: &identity.Plain{Pattern: "context.request.http.missing"}},
+				&evaluators.IdentityConfig{Name: "host", Plain: &identity.Plain{Pattern: "context.request.http.host"}},
+				&evaluators.IdentityConfig{Name: "path", Plain: &identity.Plain{Pattern: "context.request.http.path"}},
+			},
+		}, &requestMock)
+
+		resp := pipeline.evaluateIdentityConfigs()
+		assert.NilError(t, resp.Error)
+
+		conf, identity := pipeline.GetResolvedIdentity()
+		assert.Equal(t, conf.(*evaluators.IdentityConfig).Name, "host")
+		assert.Equal(t, identity, "my-api")
+	}
+}
+
+func TestAuthPipelineWithAllIdentityConfigsSkipped(t *testing.T) {
+	request := envoy_auth.CheckRequest{}
+	_ = gojson.Unmarshal([]byte(rawRequest), &request)
+
+	unmatchingConditions := []json.JSONPatternMatchingRule{
+		{
+			Selector: "context.request.http.method",
+			Operator: "eq",
+			Value:    "DELETE",
+		},
+	}
+	idConfig1 := &evaluators.IdentityConfig{Name: "id1", Noop: &identity.Noop{AuthCredentials: auth.NewAuthCredential("", "")}, Conditions: unmatchingConditions}
+	idConfig2 := &evaluators.IdentityConfig{Name: "id2", Noop: &identity.Noop{AuthCredentials: auth.NewAuthCredential("", "")}, Conditions: unmatchingConditions}
+	authzConfig := &successConfig{}
+
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{idConfig1, idConfig2},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{authzConfig},
+	}, &request)
+
+	authResult := pipeline.Evaluate()
+
+	assert.Equal(t, authResult.Code, rpc.UNAUTHENTICATED)
+	assert.Equal(t, authResult.Message, "no identity config applicable to the request")
+	assert.Check(t, !authzConfig.called)
+}
+
 func TestAuthPipelineWithCallbacks(t *testing.T) {
 	callbackConfig := &successConfig{}
 
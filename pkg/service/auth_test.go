@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"testing"
 
 	gohttptest "net/http/httptest"
@@ -54,6 +56,24 @@ func TestSuccessResponse(t *testing.T) {
 	headers := []map[string]string{{"X-Custom-Header": "some-value"}}
 	resp = service.successResponse(auth.AuthResult{Headers: headers}, nil).GetOkResponse()
 	assert.Equal(t, getHeader(resp.GetHeaders(), "X-Custom-Header"), "some-value")
+	assert.Equal(t, len(resp.GetResponseHeadersToAdd()), 0)
+
+	responseHeaders := []map[string]string{{"X-Ratelimit-Tier": "gold"}}
+	resp = service.successResponse(auth.AuthResult{Headers: headers, ResponseHeaders: responseHeaders}, nil).GetOkResponse()
+	assert.Equal(t, getHeader(resp.GetHeaders(), "X-Custom-Header"), "some-value")
+	assert.Equal(t, getHeader(resp.GetHeaders(), "X-Ratelimit-Tier"), "")
+	assert.Equal(t, getHeader(resp.GetResponseHeadersToAdd(), "X-Ratelimit-Tier"), "gold")
+	assert.Equal(t, len(resp.GetHeadersToRemove()), 0)
+
+	// cookies are appended to the response, so multiple set-cookie headers coexist
+	responseHeaders = []map[string]string{{"X-Ratelimit-Tier": "gold"}, {"Set-Cookie": "session=123"}, {"Set-Cookie": "theme=dark"}}
+	resp = service.successResponse(auth.AuthResult{ResponseHeaders: responseHeaders, HeadersToRemove: []string{"authorization"}}, nil).GetOkResponse()
+	assert.DeepEqual(t, resp.GetHeadersToRemove(), []string{"authorization"})
+	responseHeadersToAdd := resp.GetResponseHeadersToAdd()
+	assert.Equal(t, len(responseHeadersToAdd), 3)
+	assert.Check(t, responseHeadersToAdd[0].GetAppend() == nil)
+	assert.Check(t, responseHeadersToAdd[1].GetAppend().GetValue())
+	assert.Check(t, responseHeadersToAdd[2].GetAppend().GetValue())
 }
 
 func TestDeniedResponse(t *testing.T) {
@@ -131,6 +151,118 @@ func TestAuthConfigLookup(t *testing.T) {
 	assert.NilError(t, err)
 }
 
+func TestAuthConfigLookupByContextExtension(t *testing.T) {
+	authConfigIndex := index.NewIndex()
+	service := AuthService{Index: authConfigIndex}
+
+	sharedConfig := &evaluators.AuthConfig{Labels: map[string]string{"namespace": "ns", "name": "dogs"}} // denies with 401
+	hostConfig := mockAnonymousAccessAuthConfig()                                                        // allows
+	hostConfig.Labels = map[string]string{"namespace": "ns", "name": "dogs", "host": "dogs.animals.com"}
+	_ = authConfigIndex.Set("ns/dogs", "dogs.pets.com", *sharedConfig, false)
+	_ = authConfigIndex.Set("ns/dogs", "dogs.animals.com", *hostConfig, false)
+	_ = authConfigIndex.Set("ns/cats", "cats.pets.com", *hostConfig, false)
+
+	check := func(host string, contextExtensions map[string]string) int32 {
+		resp, err := service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+			Request:           &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: host}},
+			ContextExtensions: contextExtensions,
+		}})
+		assert.NilError(t, err)
+		if resp.GetDeniedResponse() != nil {
+			return int32(resp.GetDeniedResponse().Status.Code)
+		}
+		return 200
+	}
+
+	// unknown authconfig
+	assert.Equal(t, check("dogs.pets.com", map[string]string{"authorino.authconfig": "ns/unknown"}), int32(404))
+
+	// takes precedence over the host of the request, with the config shared by the hosts without overrides
+	assert.Equal(t, check("cats.pets.com", map[string]string{"authorino.authconfig": "ns/dogs"}), int32(401))
+
+	// prefers the config of the host of the request, if the authconfig is indexed for it
+	assert.Equal(t, check("dogs.animals.com:8000", map[string]string{"authorino.authconfig": "ns/dogs"}), int32(200))
+
+	// takes precedence over the host context extension
+	assert.Equal(t, check("dogs.animals.com", map[string]string{"host": "cats.pets.com", "authorino.authconfig": "ns/dogs"}), int32(401))
+
+	// all hosts with overrides
+	assert.Equal(t, check("dogs.pets.com", map[string]string{"authorino.authconfig": "ns/cats"}), int32(404))
+
+	// host taken by another authconfig
+	_ = authConfigIndex.Set("ns/birds", "dogs.pets.com", evaluators.AuthConfig{}, true)
+	assert.Equal(t, check("dogs.pets.com", map[string]string{"authorino.authconfig": "ns/dogs"}), int32(404))
+}
+
+func TestAuthConfigLookupWithNormalizedHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	i := mock_index.NewMockIndex(ctrl)
+	service := AuthService{Index: i}
+	authConfig := &evaluators.AuthConfig{}
+
+	gomock.InOrder(
+		i.EXPECT().Get("Host.COM.:443").Return(nil),
+		i.EXPECT().Get("host.com").Return(authConfig),
+	)
+	resp, err := service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: "Host.COM.:443", Scheme: "https"}},
+	}})
+	assert.Equal(t, int32(resp.GetDeniedResponse().Status.Code), int32(401))
+	assert.NilError(t, err)
+
+	i.EXPECT().Get("host.com:8443").Return(authConfig)
+	resp, err = service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: "host.com:8443", Scheme: "https"}},
+	}})
+	assert.Equal(t, int32(resp.GetDeniedResponse().Status.Code), int32(401))
+	assert.NilError(t, err)
+
+	gomock.InOrder(
+		i.EXPECT().Get("HOST.com:8443").Return(nil),
+		i.EXPECT().Get("host.com:8443").Return(nil),
+		i.EXPECT().Get("host.com").Return(authConfig),
+	)
+	resp, err = service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: "HOST.com:8443", Scheme: "https"}},
+	}})
+	assert.Equal(t, int32(resp.GetDeniedResponse().Status.Code), int32(401))
+	assert.NilError(t, err)
+
+	// the host as requested takes precedence over the normalized one
+	i.EXPECT().Get("Host.com:443").Return(authConfig)
+	resp, err = service.Check(context.TODO(), &envoy_auth.CheckRequest{Attributes: &envoy_auth.AttributeContext{
+		Request: &envoy_auth.AttributeContext_Request{Http: &envoy_auth.AttributeContext_HttpRequest{Host: "Host.com:443", Scheme: "https"}},
+	}})
+	assert.Equal(t, int32(resp.GetDeniedResponse().Status.Code), int32(401))
+	assert.NilError(t, err)
+}
+
+func TestHostLookupKeys(t *testing.T) {
+	assert.DeepEqual(t, hostLookupKeys("host.com", "host.com"), []string{"host.com"})
+	assert.DeepEqual(t, hostLookupKeys("host.com:8443", "host.com:8443"), []string{"host.com:8443", "host.com"})
+	assert.DeepEqual(t, hostLookupKeys("Host.COM.:443", "host.com"), []string{"Host.COM.:443", "host.com"})
+	assert.DeepEqual(t, hostLookupKeys("HOST.com:8443", "host.com:8443"), []string{"HOST.com:8443", "host.com:8443", "host.com"})
+}
+
+func TestNormalizeHost(t *testing.T) {
+	testCases := []struct {
+		host, scheme, expected string
+	}{
+		{"host.com", "http", "host.com"},
+		{"Host.COM", "http", "host.com"},
+		{"host.com.", "http", "host.com"},
+		{"host.com:80", "http", "host.com"},
+		{"host.com:443", "https", "host.com"},
+		{"host.com:443", "http", "host.com:443"},
+		{"host.com.:8080", "http", "host.com:8080"},
+		{"[::1]:8080", "http", "[::1]:8080"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, normalizeHost(tc.host, tc.scheme), tc.expected)
+	}
+}
+
 func TestBuildDynamicEnvoyMetadata(t *testing.T) {
 	data := map[string]interface{}{
 		"foo": runtime.RawExtension{
@@ -260,6 +392,152 @@ func TestAuthServiceRawHTTPAuthorization_WithHeaders(t *testing.T) {
 	assert.Equal(t, response.Header().Get("X-Auth-Data"), `{"headers":{"authorization":"Bearer secret","content-type":"application/json"}}`)
 }
 
+func TestAuthServiceRawHTTPAuthorization_WithResponseHeaders(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.ResponseConfigs = []auth.AuthConfigEvaluator{
+		&evaluators.ResponseConfig{
+			Name:       "tier",
+			Wrapper:    "httpResponseHeader",
+			WrapperKey: "x-tier",
+			DynamicJSON: &response.DynamicJSON{
+				Properties: []json.JSONProperty{{Name: "name", Value: json.JSONValue{Static: "gold"}}},
+			},
+		},
+		&evaluators.ResponseConfig{
+			Name:       "session",
+			Wrapper:    "httpCookie",
+			WrapperKey: "session",
+			Cookie:     &response.Cookie{HttpOnly: true},
+			DynamicJSON: &response.DynamicJSON{
+				Properties: []json.JSONProperty{{Name: "id", Value: json.JSONValue{Static: "123"}}},
+			},
+		},
+		&evaluators.ResponseConfig{
+			Name:       "theme",
+			Wrapper:    "httpCookie",
+			WrapperKey: "theme",
+			DynamicJSON: &response.DynamicJSON{
+				Properties: []json.JSONProperty{{Name: "color", Value: json.JSONValue{Static: "dark"}}},
+			},
+		},
+	}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("POST", "http://myapp.io/check", bytes.NewReader([]byte(`{}`)))
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 200)
+	assert.Equal(t, response.Header().Get("X-Tier"), `{"name":"gold"}`)
+	cookies := response.Result().Cookies()
+	assert.Equal(t, len(cookies), 2)
+	sort.Slice(cookies, func(i, j int) bool { return cookies[i].Name < cookies[j].Name })
+	assert.Equal(t, cookies[0].Name, "session")
+	assert.Equal(t, cookies[0].Value, "%7B%22id%22%3A%22123%22%7D")
+	assert.Check(t, cookies[0].HttpOnly)
+	assert.Equal(t, cookies[1].Name, "theme")
+}
+
+func TestAuthServiceRawHTTPAuthorization_WithHeadersToRemove(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	authConfig := mockAnonymousAccessAuthConfig()
+	authConfig.HeadersToRemove = []string{"authorization", "x-api-key"}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("POST", "http://myapp.io/check", bytes.NewReader([]byte(`{}`)))
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 200)
+	assert.Equal(t, response.Header().Get(X_ENVOY_AUTH_HEADERS_TO_REMOVE_HEADER), "authorization,x-api-key")
+}
+
+func TestAuthServiceCheckWithConcurrencyLimit(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+
+	defer func(limiter ConcurrencyLimiter, status envoy_type.StatusCode) {
+		CheckRequestLimiter = limiter
+		CheckRequestLimitStatus = status
+	}(CheckRequestLimiter, CheckRequestLimitStatus)
+	CheckRequestLimiter = NewConcurrencyLimiter(1, 0)
+
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(mockAnonymousAccessAuthConfig()).Times(1)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	newRequest := func() *envoy_auth.CheckRequest {
+		return &envoy_auth.CheckRequest{
+			Attributes: &envoy_auth.AttributeContext{
+				Request: &envoy_auth.AttributeContext_Request{
+					Http: &envoy_auth.AttributeContext_HttpRequest{Host: "myapp.io"},
+				},
+			},
+		}
+	}
+
+	// all slots taken
+	assert.Check(t, CheckRequestLimiter.Acquire(context.TODO()))
+
+	resp, err := authService.Check(context.TODO(), newRequest())
+	assert.NilError(t, err)
+	assert.Equal(t, resp.Status.Code, int32(rpc.UNAVAILABLE))
+	assert.Equal(t, resp.GetDeniedResponse().GetStatus().GetCode(), envoy_type.StatusCode_ServiceUnavailable)
+	assert.Equal(t, getHeader(resp.GetDeniedResponse().GetHeaders(), X_EXT_AUTH_REASON_HEADER), RESPONSE_MESSAGE_OVERLOADED)
+
+	CheckRequestLimitStatus = envoy_type.StatusCode_TooManyRequests
+	request, _ := http.NewRequest("POST", "http://myapp.io/check", bytes.NewReader([]byte(`{}`)))
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 429)
+	assert.Equal(t, response.Header().Get(X_EXT_AUTH_REASON_HEADER), RESPONSE_MESSAGE_OVERLOADED)
+
+	// slot released
+	CheckRequestLimiter.Release()
+	resp, err = authService.Check(context.TODO(), newRequest())
+	assert.NilError(t, err)
+	assert.Equal(t, resp.Status.Code, int32(rpc.OK))
+	assert.Equal(t, InFlightChecks(), int64(0))
+}
+
+func TestAuthServiceRawHTTPAuthorization_WithCustomDeniedStatus(t *testing.T) {
+	mockController := gomock.NewController(t)
+	defer mockController.Finish()
+	authCred := auth.NewAuthCredential("", "")
+	identityConfig := &evaluators.IdentityConfig{Name: "anonymous", Noop: &identity.Noop{AuthCredentials: authCred}}
+	authorizationPolicy, _ := authorization.NewOPAAuthorization("a-policy", `allow = false`, nil, false, 0, context.TODO())
+	authorizationConfig := &evaluators.AuthorizationConfig{Name: "always-deny", OPA: authorizationPolicy}
+	authConfig := &evaluators.AuthConfig{
+		IdentityConfigs:      []auth.AuthConfigEvaluator{identityConfig},
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{authorizationConfig},
+		DenyWith:             evaluators.DenyWith{Unauthorized: &evaluators.DenyWithValues{Code: 302}},
+	}
+	indexMock := mock_index.NewMockIndex(mockController)
+	indexMock.EXPECT().Get("myapp.io").Return(authConfig)
+	authService := &AuthService{Index: indexMock, MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("POST", "http://myapp.io/check", bytes.NewReader([]byte(`{}`)))
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 403) // the custom denied status only applies to the grpc interface
+}
+
+func TestAuthServiceRawHTTPAuthorization_OversizedHeader(t *testing.T) {
+	defer func(limits RequestLimits) { CheckRequestLimits = limits }(CheckRequestLimits)
+	CheckRequestLimits = RequestLimits{MaxHeaderSize: 64}
+
+	authService := &AuthService{Index: index.NewIndex(), MaxHttpRequestBodySize: defaultMaxHttpRequestBytes}
+	request, _ := http.NewRequest("POST", "http://myapp.io/check", bytes.NewReader([]byte(`{}`)))
+	request.Header = map[string][]string{"X-Large": {strings.Repeat("x", 64)}}
+	response := gohttptest.NewRecorder()
+	authService.ServeHTTP(response, request)
+	assert.Equal(t, response.Code, 431)
+	assert.Equal(t, response.Header().Get(X_EXT_AUTH_REASON_HEADER), "request header x-large too large")
+}
+
 func TestAuthServiceRawHTTPAuthorization_K8sAdmissionReviewAuthorized(t *testing.T) {
 	mockController := gomock.NewController(t)
 	defer mockController.Finish()
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"sync/atomic"
+	"time"
+
+	gocontext "golang.org/x/net/context"
+
+	"github.com/kuadrant/authorino/pkg/metrics"
+
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CheckRequestLimiter caps the number of authorization requests processed concurrently, across the gRPC and the raw
+// HTTP interfaces. Unbounded by default.
+var CheckRequestLimiter ConcurrencyLimiter = NewConcurrencyLimiter(0, 0)
+
+// CheckRequestLimitStatus is the HTTP status code of the denial of the authorization requests rejected by the CheckRequestLimiter
+var CheckRequestLimitStatus = envoy_type.StatusCode_ServiceUnavailable
+
+// number of authorization requests waiting for a slot of the CheckRequestLimiter
+var queuedChecks int64
+
+var (
+	authServerInFlightMetric = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "auth_server_inflight_check_requests",
+		Help: "Number of authorization requests currently being processed by the auth server.",
+	}, func() float64 { return float64(InFlightChecks()) })
+	authServerMaxInFlightMetric = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "auth_server_max_inflight_check_requests",
+		Help: "Maximum number of authorization requests processed concurrently by the auth server (0 for unbounded).",
+	}, func() float64 { return float64(CheckRequestLimiter.Limit()) })
+	authServerQueuedMetric = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "auth_server_queued_check_requests",
+		Help: "Number of authorization requests waiting to be processed by the auth server.",
+	}, func() float64 { return float64(atomic.LoadInt64(&queuedChecks)) })
+	authServerRejectedMetric = metrics.NewCounterMetric("auth_server_rejected_check_requests", "Number of authorization requests rejected by the auth server for exceeding the maximum number of requests processed concurrently.")
+)
+
+func init() {
+	metrics.Register(
+		authServerInFlightMetric,
+		authServerMaxInFlightMetric,
+		authServerQueuedMetric,
+		authServerRejectedMetric,
+	)
+}
+
+// ConcurrencyLimiter caps the number of tasks running concurrently
+type ConcurrencyLimiter interface {
+	// Acquire reserves a slot to run a task. While all slots are taken, it waits up to the queue timeout of the limiter
+	// for a slot to be released. It returns false if no slot could be reserved in time or the context is done.
+	Acquire(ctx gocontext.Context) bool
+	// Release frees a slot reserved with Acquire.
+	Release()
+	// Limit returns the number of slots of the limiter; 0 means unbounded.
+	Limit() int
+}
+
+// NewConcurrencyLimiter returns a limiter of the given number of slots, whose tasks wait up to queueTimeout for a slot
+// to be released when all are taken. A queue timeout of 0 or less rejects the tasks right away (fail fast).
+// A limit of 0 or less returns an unbounded limiter.
+func NewConcurrencyLimiter(limit int, queueTimeout time.Duration) ConcurrencyLimiter {
+	if limit <= 0 {
+		return &unboundedConcurrencyLimiter{}
+	}
+	return &boundedConcurrencyLimiter{
+		slots:        make(chan struct{}, limit),
+		queueTimeout: queueTimeout,
+	}
+}
+
+type unboundedConcurrencyLimiter struct{}
+
+func (l *unboundedConcurrencyLimiter) Acquire(_ gocontext.Context) bool {
+	return true
+}
+
+func (l *unboundedConcurrencyLimiter) Release() {}
+
+func (l *unboundedConcurrencyLimiter) Limit() int {
+	return 0
+}
+
+type boundedConcurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration
+}
+
+func (l *boundedConcurrencyLimiter) Acquire(ctx gocontext.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	atomic.AddInt64(&queuedChecks, 1)
+	defer atomic.AddInt64(&queuedChecks, -1)
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *boundedConcurrencyLimiter) Release() {
+	<-l.slots
+}
+
+func (l *boundedConcurrencyLimiter) Limit() int {
+	return cap(l.slots)
+}
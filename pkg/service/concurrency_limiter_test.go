@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestUnboundedConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		assert.Check(t, limiter.Acquire(context.TODO()))
+	}
+	assert.Equal(t, limiter.Limit(), 0)
+}
+
+func TestBoundedConcurrencyLimiterFailFast(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2, 0)
+	assert.Equal(t, limiter.Limit(), 2)
+
+	assert.Check(t, limiter.Acquire(context.TODO()))
+	assert.Check(t, limiter.Acquire(context.TODO()))
+	assert.Check(t, !limiter.Acquire(context.TODO()))
+
+	limiter.Release()
+	assert.Check(t, limiter.Acquire(context.TODO()))
+}
+
+func TestBoundedConcurrencyLimiterQueue(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, 50*time.Millisecond)
+	assert.Check(t, limiter.Acquire(context.TODO()))
+
+	// times out waiting for a slot
+	start := time.Now()
+	assert.Check(t, !limiter.Acquire(context.TODO()))
+	assert.Check(t, time.Since(start) >= 50*time.Millisecond)
+
+	// gets the slot released while waiting
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.Release()
+	}()
+	assert.Check(t, limiter.Acquire(context.TODO()))
+}
+
+func TestBoundedConcurrencyLimiterContextDone(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1, time.Minute)
+	assert.Check(t, limiter.Acquire(context.TODO()))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.Check(t, !limiter.Acquire(ctx))
+}
@@ -8,12 +8,18 @@ import (
 	"strings"
 
 	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/evaluators/identity"
 	"github.com/kuadrant/authorino/pkg/index"
 	"github.com/kuadrant/authorino/pkg/log"
 	"github.com/kuadrant/authorino/pkg/metrics"
 )
 
-const OIDCBasePath = "/"
+const (
+	OIDCBasePath = "/"
+
+	backChannelLogoutPath = "/backchannel-logout"
+)
 
 var (
 	oidcServerTotalRequestsMetric  = metrics.NewAuthConfigCounterMetric("oidc_server_requests_total", "Number of get requests received on the OIDC (Festival Wristband) server.", "wristband", "path")
@@ -51,7 +57,20 @@ func (o *OidcService) ServeHTTP(writer http.ResponseWriter, req *http.Request) {
 
 		requestLogger.Info("request received", "realm", realm, "config", wristbandEvaluatorName, "path", pathSuffix)
 
-		if wristband := o.findWristbandIssuer(realm, wristbandEvaluatorName); wristband != nil {
+		// OpenID Connect back-channel logout requests target an oidc identity config instead of a wristband config
+		if pathSuffix == backChannelLogoutPath {
+			if oidc := o.findOIDCIdentity(realm, wristbandEvaluatorName); oidc != nil {
+				statusCode, responseBody = backChannelLogout(oidc, req, requestLogger)
+				writer.Header().Add("Cache-Control", "no-store")
+				if statusCode == http.StatusBadRequest {
+					writer.Header().Add("Content-Type", "application/json")
+				}
+				metrics.ReportMetric(oidcServerTotalRequestsMetric, authconfigNamespace, authconfigName, wristbandEvaluatorName, pathSuffix)
+			} else {
+				statusCode = http.StatusNotFound
+				responseBody = "Not found"
+			}
+		} else if wristband := o.findWristbandIssuer(realm, wristbandEvaluatorName); wristband != nil {
 			var err error
 
 			switch pathSuffix {
@@ -114,6 +133,43 @@ func (o *OidcService) findWristbandIssuer(realm string, wristbandConfigName stri
 	}
 }
 
+// findOIDCIdentity looks for the oidc identity config in the configs of all the hosts of the authconfig, as the config
+// may be declared only in the host overrides
+func (o *OidcService) findOIDCIdentity(realm string, identityConfigName string) *identity.OIDC {
+	for _, host := range o.Index.FindKeys(realm) {
+		if id, found := o.Index.FindId(host); !found || id != realm {
+			continue
+		}
+		authConfig := o.Index.Get(host)
+		if authConfig == nil {
+			continue
+		}
+		for _, config := range authConfig.IdentityConfigs {
+			if identityConfig, ok := config.(*evaluators.IdentityConfig); ok && identityConfig.Name == identityConfigName && identityConfig.OIDC != nil {
+				return identityConfig.OIDC
+			}
+		}
+	}
+	return nil
+}
+
+// backChannelLogout handles an OpenID Connect back-channel logout request, whose logout token is sent in the
+// `logout_token` form parameter
+func backChannelLogout(oidc *identity.OIDC, req *http.Request, logger log.Logger) (int, string) {
+	if req.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, "Method not allowed"
+	}
+	logoutToken := req.PostFormValue("logout_token")
+	if logoutToken == "" {
+		return http.StatusBadRequest, `{"error":"invalid_request","error_description":"missing logout token"}`
+	}
+	if err := oidc.BackChannelLogout(log.IntoContext(req.Context(), logger), logoutToken); err != nil {
+		logger.Info("invalid logout token", "reason", err)
+		return http.StatusBadRequest, `{"error":"invalid_request","error_description":"invalid logout token"}`
+	}
+	return http.StatusOK, ""
+}
+
 func unpackPath(sections []string, vars ...*string) {
 	for i, section := range sections {
 		if i > len(vars)-1 {
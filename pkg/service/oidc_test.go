@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+	"github.com/kuadrant/authorino/pkg/evaluators/identity"
+	"github.com/kuadrant/authorino/pkg/index"
+
+	"gotest.tools/assert"
+)
+
+func TestFindOIDCIdentity(t *testing.T) {
+	sharedOIDC := &identity.OIDC{Endpoint: "http://shared"}
+	overrideOIDC := &identity.OIDC{Endpoint: "http://override"}
+
+	authConfigIndex := index.NewIndex()
+	_ = authConfigIndex.Set("ns/pets", "dogs.pets.com", evaluators.AuthConfig{IdentityConfigs: []auth.AuthConfigEvaluator{
+		&evaluators.IdentityConfig{Name: "keycloak", OIDC: sharedOIDC},
+	}}, false)
+	_ = authConfigIndex.Set("ns/pets", "cats.pets.com", evaluators.AuthConfig{IdentityConfigs: []auth.AuthConfigEvaluator{
+		&evaluators.IdentityConfig{Name: "keycloak", OIDC: sharedOIDC},
+		&evaluators.IdentityConfig{Name: "cats-idp", OIDC: overrideOIDC},
+	}}, false)
+	service := &OidcService{Index: authConfigIndex}
+
+	assert.Equal(t, service.findOIDCIdentity("ns/pets", "keycloak"), sharedOIDC)
+	assert.Equal(t, service.findOIDCIdentity("ns/pets", "cats-idp"), overrideOIDC) // declared only in the config of a host other than the first one
+	assert.Check(t, service.findOIDCIdentity("ns/pets", "unknown") == nil)
+	assert.Check(t, service.findOIDCIdentity("ns/other", "keycloak") == nil)
+
+	// host taken by another authconfig
+	_ = authConfigIndex.Set("ns/birds", "cats.pets.com", evaluators.AuthConfig{}, true)
+	assert.Check(t, service.findOIDCIdentity("ns/pets", "cats-idp") == nil)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/log"
+	"github.com/kuadrant/authorino/pkg/metrics"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/gogo/googleapis/google/rpc"
+)
+
+// CheckRequestLimits are the guardrails on the size of the attributes of the authorization requests consumed by the
+// auth pipeline, across the gRPC and the raw HTTP interfaces. Unbounded by default.
+var CheckRequestLimits = RequestLimits{}
+
+var authServerOversizedMetric = metrics.NewCounterMetric("auth_server_oversized_check_requests", "Number of authorization requests whose attributes exceeded the size limits of the auth server.", "attribute", "action")
+
+func init() {
+	metrics.Register(authServerOversizedMetric)
+}
+
+// RequestLimits caps the size of the attributes of the authorization requests, so oversized requests do not blow up
+// the memory of the auth server while building the Authorization JSON
+type RequestLimits struct {
+	// MaxHeaders is the maximum number of headers of the request; 0 means unbounded
+	MaxHeaders int
+	// MaxHeaderSize is the maximum size of each header of the request (name and value), in bytes; 0 means unbounded
+	MaxHeaderSize int
+	// MaxBodySize is the maximum size of the body of the request, in bytes; 0 means unbounded
+	MaxBodySize int
+	// DropOversized drops the headers and the body that exceed the size limits from the request, instead of denying it.
+	// Requests with too many headers are denied regardless.
+	DropOversized bool
+}
+
+// Enforce checks the attributes of a request against the limits, dropping the oversized ones if so configured.
+// It returns the result of the denial of the request, or nil if the request is within the limits.
+func (l RequestLimits) Enforce(req *envoy_auth.CheckRequest) *auth.AuthResult {
+	httpRequest := req.GetAttributes().GetRequest().GetHttp()
+	if httpRequest == nil {
+		return nil
+	}
+	logger := log.WithName("service").WithName("auth").WithValues("request id", httpRequest.GetId())
+
+	headers := httpRequest.GetHeaders()
+	if l.MaxHeaders > 0 && len(headers) > l.MaxHeaders {
+		metrics.ReportMetric(authServerOversizedMetric, "headers", "denied")
+		return &auth.AuthResult{
+			Code:    rpc.FAILED_PRECONDITION,
+			Status:  envoy_type.StatusCode_RequestHeaderFieldsTooLarge,
+			Message: fmt.Sprintf("too many request headers (%d > %d)", len(headers), l.MaxHeaders),
+		}
+	}
+
+	if l.MaxHeaderSize > 0 {
+		for name, value := range headers {
+			if len(name)+len(value) <= l.MaxHeaderSize {
+				continue
+			}
+			if !l.DropOversized {
+				metrics.ReportMetric(authServerOversizedMetric, "headers", "denied")
+				return &auth.AuthResult{
+					Code:    rpc.FAILED_PRECONDITION,
+					Status:  envoy_type.StatusCode_RequestHeaderFieldsTooLarge,
+					Message: fmt.Sprintf("request header %s too large", name),
+				}
+			}
+			metrics.ReportMetric(authServerOversizedMetric, "headers", "dropped")
+			logger.V(1).Info("dropping oversized request header", "header", name, "size", len(name)+len(value))
+			delete(headers, name)
+		}
+	}
+
+	if bodySize := len(httpRequest.GetBody()) + len(httpRequest.GetRawBody()); l.MaxBodySize > 0 && bodySize > l.MaxBodySize {
+		if !l.DropOversized {
+			metrics.ReportMetric(authServerOversizedMetric, "body", "denied")
+			return &auth.AuthResult{
+				Code:    rpc.FAILED_PRECONDITION,
+				Status:  envoy_type.StatusCode_PayloadTooLarge,
+				Message: fmt.Sprintf("request body too large (%d > %d bytes)", bodySize, l.MaxBodySize),
+			}
+		}
+		metrics.ReportMetric(authServerOversizedMetric, "body", "dropped")
+		logger.V(1).Info("dropping oversized request body", "size", bodySize)
+		httpRequest.Body = ""
+		httpRequest.RawBody = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	envoy_auth "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	envoy_type "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/gogo/googleapis/google/rpc"
+	"gotest.tools/assert"
+)
+
+func newTestCheckRequestWithAttributes(headers map[string]string, body string) *envoy_auth.CheckRequest {
+	return &envoy_auth.CheckRequest{
+		Attributes: &envoy_auth.AttributeContext{
+			Request: &envoy_auth.AttributeContext_Request{
+				Http: &envoy_auth.AttributeContext_HttpRequest{Headers: headers, Body: body},
+			},
+		},
+	}
+}
+
+func TestRequestLimitsUnbounded(t *testing.T) {
+	req := newTestCheckRequestWithAttributes(map[string]string{"x-large": strings.Repeat("x", 1024)}, strings.Repeat("x", 1024))
+	assert.Check(t, RequestLimits{}.Enforce(req) == nil)
+	assert.Check(t, RequestLimits{}.Enforce(&envoy_auth.CheckRequest{}) == nil)
+}
+
+func TestRequestLimitsMaxHeaders(t *testing.T) {
+	limits := RequestLimits{MaxHeaders: 2}
+	assert.Check(t, limits.Enforce(newTestCheckRequestWithAttributes(map[string]string{"a": "1", "b": "2"}, "")) == nil)
+
+	result := limits.Enforce(newTestCheckRequestWithAttributes(map[string]string{"a": "1", "b": "2", "c": "3"}, ""))
+	assert.Equal(t, result.Code, rpc.FAILED_PRECONDITION)
+	assert.Equal(t, result.Status, envoy_type.StatusCode_RequestHeaderFieldsTooLarge)
+	assert.Equal(t, result.Message, "too many request headers (3 > 2)")
+
+	// too many headers are denied even if oversized attributes can be dropped
+	limits.DropOversized = true
+	assert.Check(t, limits.Enforce(newTestCheckRequestWithAttributes(map[string]string{"a": "1", "b": "2", "c": "3"}, "")) != nil)
+}
+
+func TestRequestLimitsMaxHeaderSize(t *testing.T) {
+	limits := RequestLimits{MaxHeaderSize: 24}
+	headers := map[string]string{"authorization": "Bearer x", "x-large": strings.Repeat("x", 24)}
+
+	result := limits.Enforce(newTestCheckRequestWithAttributes(headers, ""))
+	assert.Equal(t, result.Status, envoy_type.StatusCode_RequestHeaderFieldsTooLarge)
+	assert.Equal(t, result.Message, "request header x-large too large")
+	assert.Equal(t, len(headers), 2)
+
+	limits.DropOversized = true
+	assert.Check(t, limits.Enforce(newTestCheckRequestWithAttributes(headers, "")) == nil)
+	assert.DeepEqual(t, headers, map[string]string{"authorization": "Bearer x"})
+}
+
+func TestRequestLimitsMaxBodySize(t *testing.T) {
+	limits := RequestLimits{MaxBodySize: 8}
+	assert.Check(t, limits.Enforce(newTestCheckRequestWithAttributes(nil, "12345678")) == nil)
+
+	req := newTestCheckRequestWithAttributes(nil, "123456789")
+	result := limits.Enforce(req)
+	assert.Equal(t, result.Status, envoy_type.StatusCode_PayloadTooLarge)
+	assert.Equal(t, result.Message, "request body too large (9 > 8 bytes)")
+
+	req.Attributes.Request.Http.RawBody = []byte("123456789")
+	limits.DropOversized = true
+	assert.Check(t, limits.Enforce(req) == nil)
+	assert.Equal(t, req.Attributes.Request.Http.Body, "")
+	assert.Equal(t, len(req.Attributes.Request.Http.RawBody), 0)
+}
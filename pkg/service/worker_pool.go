@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// EvaluatorWorkerPool runs the evaluators of the auth pipelines of all requests.
+// Unbounded unless set otherwise (the Authorino instance bounds it by default, with the --evaluator-worker-pool-size flag).
+var EvaluatorWorkerPool WorkerPool = NewWorkerPool(0)
+
+// WorkerPool runs tasks concurrently
+type WorkerPool interface {
+	// Submit schedules a task to run in the pool. It blocks while all workers of a bounded pool are busy, until a worker
+	// picks the task or the context is done, in which case the task is not scheduled and the error of the context is returned.
+	Submit(ctx context.Context, task func()) error
+	// Stop stops the workers of the pool once all submitted tasks are done.
+	Stop()
+}
+
+// NewWorkerPool returns a pool of the given number of workers.
+// A size of 0 or less returns an unbounded pool, that runs each task in a new goroutine.
+func NewWorkerPool(size int) WorkerPool {
+	if size <= 0 {
+		return &unboundedWorkerPool{}
+	}
+
+	pool := &boundedWorkerPool{tasks: make(chan func())}
+	for i := 0; i < size; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+type unboundedWorkerPool struct{}
+
+func (p *unboundedWorkerPool) Submit(_ context.Context, task func()) error {
+	go task()
+	return nil
+}
+
+func (p *unboundedWorkerPool) Stop() {}
+
+type boundedWorkerPool struct {
+	tasks chan func()
+	once  sync.Once
+}
+
+func (p *boundedWorkerPool) Submit(ctx context.Context, task func()) error {
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *boundedWorkerPool) Stop() {
+	p.once.Do(func() { close(p.tasks) })
+}
+
+func (p *boundedWorkerPool) work() {
+	for task := range p.tasks {
+		task()
+	}
+}
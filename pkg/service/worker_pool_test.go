@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/authorino/pkg/auth"
+	"github.com/kuadrant/authorino/pkg/evaluators"
+
+	"gotest.tools/assert"
+)
+
+func TestBoundedWorkerPool(t *testing.T) {
+	pool := NewWorkerPool(2)
+	defer pool.Stop()
+
+	var running, maxRunning int32
+	var wait sync.WaitGroup
+	wait.Add(10)
+
+	for i := 0; i < 10; i++ {
+		_ = pool.Submit(context.TODO(), func() {
+			defer wait.Done()
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+	}
+
+	wait.Wait()
+	assert.Check(t, maxRunning <= 2)
+}
+
+func TestBoundedWorkerPoolSubmitWithContextDone(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	assert.NilError(t, pool.Submit(context.TODO(), func() { <-release }))
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+
+	ran := false
+	err := pool.Submit(ctx, func() { ran = true }) // the only worker is busy
+	assert.Error(t, err, context.DeadlineExceeded.Error())
+	assert.Check(t, !ran)
+}
+
+func TestEvaluateAllAuthConfigsWithContextDone(t *testing.T) {
+	defaultPool := EvaluatorWorkerPool
+	EvaluatorWorkerPool = NewWorkerPool(1)
+	release := make(chan struct{})
+	defer func() {
+		close(release)
+		EvaluatorWorkerPool.Stop()
+		EvaluatorWorkerPool = defaultPool
+	}()
+	_ = EvaluatorWorkerPool.Submit(context.TODO(), func() { <-release }) // keeps the only worker busy
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	configs := []*successConfig{{}, {}}
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{}, &requestMock)
+	respChannel := make(chan EvaluationResponse, len(configs))
+	pipeline.evaluateAnyAuthConfigWithContext(ctx, []auth.AuthConfigEvaluator{configs[0], configs[1]}, &respChannel)
+
+	responses := 0
+	for range respChannel {
+		responses++
+	}
+	assert.Equal(t, responses, 0)
+	for _, config := range configs {
+		assert.Check(t, !config.called)
+	}
+}
+
+func TestEvaluateAllAuthConfigsWithBoundedWorkerPool(t *testing.T) {
+	defaultPool := EvaluatorWorkerPool
+	EvaluatorWorkerPool = NewWorkerPool(1)
+	defer func() {
+		EvaluatorWorkerPool.Stop()
+		EvaluatorWorkerPool = defaultPool
+	}()
+
+	configs := []*successConfig{{}, {}, {}}
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{
+		AuthorizationConfigs: []auth.AuthConfigEvaluator{configs[0], configs[1], configs[2]},
+	}, &requestMock)
+
+	respChannel := make(chan EvaluationResponse, len(configs))
+	pipeline.evaluateAllAuthConfigs(pipeline.AuthConfig.AuthorizationConfigs, &respChannel)
+
+	responses := 0
+	for resp := range respChannel {
+		assert.Check(t, resp.Success())
+		responses++
+	}
+	assert.Equal(t, responses, 3)
+	for _, config := range configs {
+		assert.Check(t, config.called)
+	}
+}
+
+func benchmarkEvaluateAllAuthConfigs(b *testing.B, poolSize int) {
+	defaultPool := EvaluatorWorkerPool
+	EvaluatorWorkerPool = NewWorkerPool(poolSize)
+	defer func() {
+		EvaluatorWorkerPool.Stop()
+		EvaluatorWorkerPool = defaultPool
+	}()
+
+	configs := make([]auth.AuthConfigEvaluator, 20)
+	for i := range configs {
+		configs[i] = &successConfig{}
+	}
+	pipeline := newTestAuthPipeline(evaluators.AuthConfig{}, &requestMock)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			respChannel := make(chan EvaluationResponse, len(configs))
+			pipeline.evaluateAllAuthConfigs(configs, &respChannel)
+			for range respChannel {
+			}
+		}
+	})
+}
+
+func BenchmarkEvaluateAllAuthConfigsUnbounded(b *testing.B) {
+	benchmarkEvaluateAllAuthConfigs(b, 0)
+}
+
+func BenchmarkEvaluateAllAuthConfigsWorkerPool(b *testing.B) {
+	benchmarkEvaluateAllAuthConfigs(b, 64)
+}
@@ -0,0 +1,53 @@
+package singleflight
+
+import "sync"
+
+// Group suppresses duplicate concurrent calls, so that only one call per key is in-flight at a time.
+// Duplicate callers wait for the in-flight call to finish and share its results.
+// The zero value is ready to use.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	wg     sync.WaitGroup
+	val    interface{}
+	err    error
+	shared bool
+}
+
+// Do executes fn and returns its results, unless a call with the same key is already in-flight, in which case it waits
+// for that call to finish and returns its results instead. The shared return value tells whether the results were
+// returned to more than one caller.
+func (g *Group) Do(key string, fn func() (interface{}, error)) (v interface{}, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, found := g.calls[key]; found {
+		c.shared = true
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.val, c.err = fn()
+
+	g.mu.Lock()
+	shared = c.shared
+	g.mu.Unlock()
+
+	return c.val, c.err, shared
+}
@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestDo(t *testing.T) {
+	var g Group
+	v, err, shared := g.Do("key", func() (interface{}, error) { return "value", nil })
+	assert.NilError(t, err)
+	assert.Equal(t, v, "value")
+	assert.Check(t, !shared)
+
+	_, err, _ = g.Do("key", func() (interface{}, error) { return nil, fmt.Errorf("failed") })
+	assert.ErrorContains(t, err, "failed")
+}
+
+func TestDoDuplicateCalls(t *testing.T) {
+	var g Group
+	var calls int32
+	release := make(chan struct{})
+
+	fn := func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value", nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make(chan interface{}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, _ := g.Do("key", fn)
+			results <- v
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond) // let the duplicate callers block on the in-flight call
+	close(release)
+	wg.Wait()
+	close(results)
+
+	assert.Equal(t, atomic.LoadInt32(&calls), int32(1))
+	for v := range results {
+		assert.Equal(t, v, "value")
+	}
+
+	// the key is released after the call
+	v, _, shared := g.Do("key", func() (interface{}, error) { return "other", nil })
+	assert.Equal(t, v, "other")
+	assert.Check(t, !shared)
+}
+
+func TestDoDifferentKeys(t *testing.T) {
+	var g Group
+	var calls int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, _ = g.Do(fmt.Sprintf("key-%d", i), func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return nil, nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, atomic.LoadInt32(&calls), int32(5))
+}
@@ -5,7 +5,10 @@ Do not add here anything that deserves a package of its own, like complex types,
 
 package utils
 
-import "unicode"
+import (
+	"strings"
+	"unicode"
+)
 
 func CapitalizeString(s string) string {
 	if len(s) == 0 {
@@ -31,6 +34,17 @@ func SubtractSlice(sl1, sl2 []string) []string {
 	return diff
 }
 
+// SplitList splits a comma-separated list of values, trimming spaces and dropping empty values
+func SplitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func SliceContains[T comparable](s []T, val T) bool {
 	for _, v := range s {
 		if v == val {
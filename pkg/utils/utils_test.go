@@ -21,6 +21,13 @@ func TestSubtractSlice(t *testing.T) {
 	assert.Equal(t, strings.Join(SubtractSlice([]string{"a", "b", "c"}, []string{}), ""), "abc")
 }
 
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, strings.Join(SplitList("a"), "|"), "a")
+	assert.Equal(t, strings.Join(SplitList("a,b, c"), "|"), "a|b|c")
+	assert.Equal(t, strings.Join(SplitList(" a,,b ,"), "|"), "a|b")
+	assert.Equal(t, len(SplitList("")), 0)
+}
+
 func TestSliceContains(t *testing.T) {
 	assert.Check(t, SliceContains([]string{"a", "b", "c"}, "a"))
 	assert.Check(t, SliceContains([]string{"a", "b", "c"}, "b"))